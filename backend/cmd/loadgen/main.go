@@ -0,0 +1,334 @@
+// Command loadgen pushes synthetic measurements to a running LiteKPI
+// instance through the batch ingest API, for capacity planning and for
+// validating rollups and caching under load.
+//
+// Usage:
+//
+//	go run ./cmd/loadgen -api-key <data source key> -rate 500 -measurements 20 \
+//		-metadata region=5,plan=3,user_id=10000 -skew 1.2 -duration 5m
+//
+// Each event gets one value per metadata key, drawn from that key's
+// cardinality; -skew above 1 draws values from a Zipf distribution so that a
+// few values are hot, as in real traffic. Use -backfill to spread timestamps
+// over a past window instead of stamping events with the current time.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/ingest"
+)
+
+// tick is how often events are generated; each tick produces rate*tick events.
+const tick = 100 * time.Millisecond
+
+// metadataKey is a metadata key and the number of distinct values it takes.
+type metadataKey struct {
+	name        string
+	cardinality int
+}
+
+type options struct {
+	url          string
+	apiKey       string
+	rate         float64
+	duration     time.Duration
+	measurements int
+	metadata     []metadataKey
+	skew         float64
+	batchSize    int
+	workers      int
+	backfill     time.Duration
+}
+
+// stats collects the outcome of sent batches.
+type stats struct {
+	events  atomic.Int64
+	batches atomic.Int64
+	failed  atomic.Int64 // Failed batches
+	dropped atomic.Int64 // Events not sent because all workers were busy
+
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+func (s *stats) record(events int, latency time.Duration, err error) {
+	s.batches.Add(1)
+	if err != nil {
+		s.failed.Add(1)
+		return
+	}
+	s.events.Add(int64(events))
+	s.mu.Lock()
+	s.latencies = append(s.latencies, latency)
+	s.mu.Unlock()
+}
+
+// percentiles returns the p50 and p99 batch latency.
+func (s *stats) percentiles() (time.Duration, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.latencies) == 0 {
+		return 0, 0
+	}
+	sorted := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) time.Duration {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	return at(0.5), at(0.99)
+}
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+func run() error {
+	opts, err := parseFlags()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if opts.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	log.Printf("Sending %.0f events/s to %s (%d measurements, batches of %d, %d workers)",
+		opts.rate, opts.url, opts.measurements, opts.batchSize, opts.workers)
+
+	gen := newGenerator(opts)
+	st := &stats{}
+	client := &http.Client{Timeout: 30 * time.Second}
+	endpoint := strings.TrimSuffix(opts.url, "/") + "/api/v1/ingest/batch"
+
+	batches := make(chan []ingest.IngestRequest, opts.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				start := time.Now()
+				err := send(client, endpoint, opts.apiKey, batch)
+				st.record(len(batch), time.Since(start), err)
+				if err != nil {
+					log.Printf("batch failed: %v", err)
+				}
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	report := time.NewTicker(5 * time.Second)
+	defer report.Stop()
+
+	owed := 0.0 // Events due but not yet generated, carrying fractions between ticks
+	var pending []ingest.IngestRequest
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-report.C:
+			printStats(st, time.Since(start))
+		case <-ticker.C:
+			owed += opts.rate * tick.Seconds()
+			for ; owed >= 1; owed-- {
+				pending = append(pending, gen.event())
+				if len(pending) == opts.batchSize {
+					enqueue(batches, pending, st)
+					pending = nil
+				}
+			}
+		}
+	}
+	if len(pending) > 0 {
+		enqueue(batches, pending, st)
+	}
+	close(batches)
+	wg.Wait()
+
+	log.Println("Done")
+	printStats(st, time.Since(start))
+	return nil
+}
+
+// enqueue hands a batch to a worker without blocking, so that a slow server
+// shows up as dropped events rather than as a silently lower rate.
+func enqueue(batches chan<- []ingest.IngestRequest, batch []ingest.IngestRequest, st *stats) {
+	select {
+	case batches <- batch:
+	default:
+		st.dropped.Add(int64(len(batch)))
+	}
+}
+
+func send(client *http.Client, endpoint, apiKey string, batch []ingest.IngestRequest) error {
+	body, err := json.Marshal(ingest.BatchIngestRequest{Metrics: batch})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e ingest.ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("status %d: %s %s", resp.StatusCode, e.Error, e.Message)
+	}
+	return nil
+}
+
+func printStats(st *stats, elapsed time.Duration) {
+	p50, p99 := st.percentiles()
+	events := st.events.Load()
+	log.Printf("%d events in %d batches (%.0f events/s), %d failed batches, %d dropped events, latency p50 %s p99 %s",
+		events, st.batches.Load(), float64(events)/elapsed.Seconds(), st.failed.Load(), st.dropped.Load(),
+		p50.Round(time.Millisecond), p99.Round(time.Millisecond))
+}
+
+// generator produces synthetic measurements.
+type generator struct {
+	opts   options
+	rng    *rand.Rand
+	names  *rand.Zipf // Nil for uniform draws
+	values []*rand.Zipf
+}
+
+func newGenerator(opts options) *generator {
+	g := &generator{opts: opts, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	if opts.skew > 1 {
+		g.names = rand.NewZipf(g.rng, opts.skew, 1, uint64(opts.measurements-1))
+		for _, k := range opts.metadata {
+			g.values = append(g.values, rand.NewZipf(g.rng, opts.skew, 1, uint64(k.cardinality-1)))
+		}
+	}
+	return g
+}
+
+// draw returns an index in [0, n), skewed by z if set.
+func (g *generator) draw(z *rand.Zipf, n int) int {
+	if z != nil {
+		return int(z.Uint64())
+	}
+	return g.rng.Intn(n)
+}
+
+func (g *generator) event() ingest.IngestRequest {
+	e := ingest.IngestRequest{
+		Name:  fmt.Sprintf("loadgen_%d", g.draw(g.names, g.opts.measurements)),
+		Value: math.Round(g.rng.ExpFloat64()*1000) / 10,
+	}
+
+	ts := time.Now()
+	if g.opts.backfill > 0 {
+		ts = ts.Add(-time.Duration(g.rng.Int63n(int64(g.opts.backfill))))
+	}
+	e.Timestamp = ts.UTC().Format(time.RFC3339Nano)
+
+	if len(g.opts.metadata) > 0 {
+		e.Metadata = make(map[string]string, len(g.opts.metadata))
+		for i, k := range g.opts.metadata {
+			var z *rand.Zipf
+			if g.values != nil {
+				z = g.values[i]
+			}
+			e.Metadata[k.name] = fmt.Sprintf("%s_%d", k.name, g.draw(z, k.cardinality))
+		}
+	}
+	return e
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	var metadata string
+	flag.StringVar(&opts.url, "url", "http://localhost:8080", "Base URL of the LiteKPI API")
+	flag.StringVar(&opts.apiKey, "api-key", os.Getenv("LITEKPI_API_KEY"), "Data source API key (default $LITEKPI_API_KEY)")
+	flag.Float64Var(&opts.rate, "rate", 100, "Events per second")
+	flag.DurationVar(&opts.duration, "duration", time.Minute, "How long to run (0 runs until interrupted)")
+	flag.IntVar(&opts.measurements, "measurements", 10, "Number of distinct measurement names")
+	flag.StringVar(&metadata, "metadata", "", "Metadata keys with their cardinality, e.g. region=5,user_id=10000")
+	flag.Float64Var(&opts.skew, "skew", 0, "Zipf exponent for measurement names and metadata values; values above 1 make a few values hot, 0 draws uniformly")
+	flag.IntVar(&opts.batchSize, "batch", ingest.MaxBatchSize, "Events per batch request")
+	flag.IntVar(&opts.workers, "workers", 4, "Concurrent requests")
+	flag.DurationVar(&opts.backfill, "backfill", 0, "Spread timestamps uniformly over this past window (0 uses the current time)")
+	flag.Parse()
+
+	switch {
+	case opts.apiKey == "":
+		return opts, errors.New("an API key is required (-api-key or LITEKPI_API_KEY)")
+	case opts.rate <= 0:
+		return opts, errors.New("rate must be positive")
+	case opts.measurements < 1:
+		return opts, errors.New("measurements must be at least 1")
+	case opts.batchSize < 1 || opts.batchSize > ingest.MaxBatchSize:
+		return opts, fmt.Errorf("batch must be between 1 and %d", ingest.MaxBatchSize)
+	case opts.workers < 1:
+		return opts, errors.New("workers must be at least 1")
+	case opts.skew != 0 && opts.skew <= 1:
+		return opts, errors.New("skew must be 0 or greater than 1")
+	}
+
+	keys, err := parseMetadata(metadata)
+	if err != nil {
+		return opts, err
+	}
+	if len(keys) > ingest.MaxMetadataKeys {
+		return opts, fmt.Errorf("at most %d metadata keys are allowed", ingest.MaxMetadataKeys)
+	}
+	opts.metadata = keys
+	return opts, nil
+}
+
+// parseMetadata parses "key=cardinality,..." into metadata keys.
+func parseMetadata(s string) ([]metadataKey, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var keys []metadataKey
+	for _, part := range strings.Split(s, ",") {
+		name, card, ok := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(card)
+		if !ok || name == "" || err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid metadata %q, expected key=cardinality", part)
+		}
+		keys = append(keys, metadataKey{name: name, cardinality: n})
+	}
+	return keys, nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+	"github.com/devbydaniel/litekpi/internal/platform/database"
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+	"github.com/devbydaniel/litekpi/internal/platform/secrets"
+)
+
+const (
+	doctorTimeout     = 10 * time.Second // Per check that reaches another service
+	minJWTSecretBytes = 32
+	defaultJWTSecret  = "your-secret-key-change-in-production"
+)
+
+// requiredExtensions are the Postgres extensions the migrations need.
+var requiredExtensions = []string{"uuid-ossp"}
+
+// severity is how serious a doctor finding is.
+type severity int
+
+const (
+	severityOK severity = iota
+	severityWarning
+	severityError
+)
+
+func (s severity) String() string {
+	switch s {
+	case severityWarning:
+		return "WARN"
+	case severityError:
+		return "FAIL"
+	default:
+		return " OK "
+	}
+}
+
+// finding is the outcome of one doctor check, with what to do about it.
+type finding struct {
+	severity severity
+	area     string
+	message  string
+	fix      string
+}
+
+// runDoctor checks the configuration and the services it points to, and
+// prints what to fix. It fails if any check found an error.
+func runDoctor() error {
+	var findings []finding
+	cfg, err := config.Load()
+	if err != nil {
+		findings = append(findings, finding{severityError, "config", fmt.Sprintf("cannot read the environment: %v", err),
+			"Check the variables named in the error against the configuration table in the README."})
+	} else {
+		ctx := context.Background()
+		findings = append(findings, checkDatabase(ctx, cfg)...)
+		findings = append(findings, checkURLs(cfg)...)
+		findings = append(findings, checkJWTSecret(cfg)...)
+		findings = append(findings, checkSecretsKeys(cfg)...)
+		findings = append(findings, checkGRPC(cfg)...)
+		findings = append(findings, checkEmail(cfg)...)
+		findings = append(findings, checkOAuth(cfg)...)
+	}
+
+	failed := 0
+	for _, f := range findings {
+		fmt.Printf("[%s] %s: %s\n", f.severity, f.area, f.message)
+		if f.fix != "" {
+			fmt.Printf("       %s\n", f.fix)
+		}
+		if f.severity == severityError {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	fmt.Println("No errors found")
+	return nil
+}
+
+// checkDatabase connects to the database and checks its extensions and schema.
+func checkDatabase(ctx context.Context, cfg *config.Config) []finding {
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	db, err := database.New(ctx, cfg.DatabaseURL, cfg.DBStatementTimeout)
+	if err == nil {
+		err = db.Health(ctx)
+	}
+	if err != nil {
+		return []finding{{severityError, "database", fmt.Sprintf("cannot connect: %v", err),
+			"Check DATABASE_URL (host, port, user, password and database name) and that Postgres is running and reachable from here."}}
+	}
+	defer db.Close()
+
+	findings := []finding{{severity: severityOK, area: "database", message: "connected"}}
+
+	for _, ext := range requiredExtensions {
+		var installed, available bool
+		err := db.Pool.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1),
+				EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = $1)`,
+			ext,
+		).Scan(&installed, &available)
+		switch {
+		case err != nil:
+			findings = append(findings, finding{severityWarning, "database", fmt.Sprintf("cannot check extension %s: %v", ext, err), ""})
+		case installed:
+			findings = append(findings, finding{severity: severityOK, area: "database", message: fmt.Sprintf("extension %s is installed", ext)})
+		case available:
+			findings = append(findings, finding{severityWarning, "database", fmt.Sprintf("extension %s is not installed yet", ext),
+				fmt.Sprintf("The migrations create it if the database user may create extensions; otherwise run CREATE EXTENSION IF NOT EXISTS \"%s\" as a superuser.", ext)})
+		default:
+			findings = append(findings, finding{severityError, "database", fmt.Sprintf("extension %s is not available on this server", ext),
+				"Install the Postgres contrib package on the database server, or use the official postgres image."})
+		}
+	}
+
+	status, err := db.SchemaStatus(ctx)
+	switch {
+	case err != nil:
+		findings = append(findings, finding{severityWarning, "database", fmt.Sprintf("cannot read the schema version: %v", err), ""})
+	case status.State == database.SchemaUpToDate:
+		findings = append(findings, finding{severity: severityOK, area: "database", message: fmt.Sprintf("schema is at version %d", status.Version)})
+	case status.State == database.SchemaPending:
+		findings = append(findings, finding{severityWarning, "database", fmt.Sprintf("schema is at version %d, %d migrations are pending", status.Version, len(status.Pending)),
+			"Run the migrations (make migrate) before starting this build."})
+	case status.State == database.SchemaAhead:
+		findings = append(findings, finding{severityWarning, "database", fmt.Sprintf("schema is at version %d, newer than this build (%d)", status.Version, status.ExpectedVersion),
+			"A newer version of LiteKPI migrated this database; run that version instead."})
+	default:
+		findings = append(findings, finding{severityError, "database", fmt.Sprintf("migration %d failed halfway and left the schema dirty", status.Version),
+			"Repair the schema by hand, then mark the version clean with migrate force."})
+	}
+
+	return findings
+}
+
+// checkURLs checks that APP_URL and API_URL are absolute URLs that fit together.
+func checkURLs(cfg *config.Config) []finding {
+	app, appErr := parseBaseURL(cfg.AppURL)
+	if appErr != nil {
+		return []finding{{severityError, "urls", fmt.Sprintf("APP_URL %q is invalid: %v", cfg.AppURL, appErr),
+			"Set APP_URL to the address users open in their browser, such as https://kpi.example.com."}}
+	}
+	api, apiErr := parseBaseURL(cfg.APIURL)
+	if apiErr != nil {
+		return []finding{{severityError, "urls", fmt.Sprintf("API_URL %q is invalid: %v", cfg.APIURL, apiErr),
+			"Set API_URL to the address the backend is reached at, such as https://kpi.example.com or https://api.kpi.example.com."}}
+	}
+
+	var findings []finding
+	if strings.HasSuffix(strings.TrimSuffix(api.Path, "/"), "/api/v1") {
+		findings = append(findings, finding{severityError, "urls", "API_URL ends with /api/v1",
+			"Remove the /api/v1 suffix; OAuth callback URLs add it themselves."})
+	}
+	if app.Scheme != api.Scheme {
+		findings = append(findings, finding{severityWarning, "urls", fmt.Sprintf("APP_URL uses %s but API_URL uses %s", app.Scheme, api.Scheme),
+			"Serve both over https; browsers block calls from an https page to an http API."})
+	}
+	if isLocalHost(app.Hostname()) != isLocalHost(api.Hostname()) {
+		findings = append(findings, finding{severityWarning, "urls", fmt.Sprintf("one of APP_URL (%s) and API_URL (%s) is local and the other is not", app.Host, api.Host),
+			"Both are usually public in production, or both localhost in development."})
+	}
+	if !isLocalHost(app.Hostname()) && app.Scheme != "https" {
+		findings = append(findings, finding{severityWarning, "urls", "APP_URL is public but not https",
+			"Links in emails and OAuth logins send tokens through it; put LiteKPI behind a reverse proxy with TLS."})
+	}
+	if len(findings) == 0 {
+		findings = append(findings, finding{severity: severityOK, area: "urls", message: fmt.Sprintf("app at %s, API at %s", cfg.AppURL, cfg.APIURL)})
+	}
+	return findings
+}
+
+// checkJWTSecret checks that tokens are signed with a strong secret.
+func checkJWTSecret(cfg *config.Config) []finding {
+	switch {
+	case cfg.JWTSecret == defaultJWTSecret:
+		return []finding{{severityError, "jwt", "JWT_SECRET is the built-in default, so anyone can sign valid tokens",
+			"Generate a secret with: openssl rand -base64 48"}}
+	case len(cfg.JWTSecret) < minJWTSecretBytes:
+		return []finding{{severityWarning, "jwt", fmt.Sprintf("JWT_SECRET is %d characters long", len(cfg.JWTSecret)),
+			fmt.Sprintf("Use at least %d random characters, such as from: openssl rand -base64 48", minJWTSecretBytes)}}
+	case len(uniqueBytes(cfg.JWTSecret)) < 8:
+		return []finding{{severityWarning, "jwt", "JWT_SECRET repeats only a few characters",
+			"Use a random secret, such as from: openssl rand -base64 48"}}
+	}
+	return []finding{{severity: severityOK, area: "jwt", message: "JWT_SECRET is set"}}
+}
+
+// checkSecretsKeys checks the keys that encrypt stored third-party credentials.
+func checkSecretsKeys(cfg *config.Config) []finding {
+	keyring, err := secrets.NewKeyring(cfg.SecretsKeys)
+	switch {
+	case err != nil:
+		return []finding{{severityError, "secrets", fmt.Sprintf("SECRETS_KEYS is invalid: %v", err),
+			"Use comma-separated ID:key pairs, such as 2026-10:<key>, with keys from: openssl rand -base64 32"}}
+	case !keyring.Enabled():
+		return []finding{{severityWarning, "secrets", "SECRETS_KEYS is not set, so Slack tokens and export credentials are stored unencrypted",
+			"Set SECRETS_KEYS to an ID and a key from openssl rand -base64 32, such as 2026-10:<key>."}}
+	}
+	return []finding{{severity: severityOK, area: "secrets", message: "SECRETS_KEYS is set"}}
+}
+
+// checkGRPC checks that the gRPC server, if enabled, can serve TLS.
+func checkGRPC(cfg *config.Config) []finding {
+	switch {
+	case cfg.GRPCPort == "":
+		return nil
+	case cfg.GRPCTLSCert == "":
+		return []finding{{severityWarning, "grpc", "the gRPC server serves plaintext, so API keys are sent unencrypted",
+			"Set GRPC_TLS_CERT and GRPC_TLS_KEY, unless a proxy in front of the server terminates TLS."}}
+	}
+	if _, err := tls.LoadX509KeyPair(cfg.GRPCTLSCert, cfg.GRPCTLSKey); err != nil {
+		return []finding{{severityError, "grpc", fmt.Sprintf("cannot load the gRPC TLS certificate: %v", err),
+			"Point GRPC_TLS_CERT and GRPC_TLS_KEY at a matching PEM certificate and key."}}
+	}
+	return []finding{{severity: severityOK, area: "grpc", message: "the gRPC server serves TLS"}}
+}
+
+// checkEmail checks that the mail server accepts the SMTP credentials.
+func checkEmail(cfg *config.Config) []finding {
+	var findings []finding
+	if os.Getenv("SMTP_PASS") != "" && cfg.SMTP.Password == "" {
+		findings = append(findings, finding{severityWarning, "email", "SMTP_PASS is set but LiteKPI reads SMTP_PASSWORD",
+			"Rename the variable to SMTP_PASSWORD."})
+	}
+
+	if cfg.SMTP.Host == "" || cfg.SMTP.From == "" {
+		return append(findings, finding{severityWarning, "email", "email is disabled: SMTP_HOST and SMTP_FROM are both needed",
+			"Without email, verification links, invites, password resets and notifications are not sent."})
+	}
+	if _, err := mail.ParseAddress(cfg.SMTP.From); err != nil {
+		findings = append(findings, finding{severityError, "email", fmt.Sprintf("SMTP_FROM %q is not an email address", cfg.SMTP.From),
+			"Set SMTP_FROM to the address emails are sent from, such as kpi@example.com."})
+	}
+	if (cfg.SMTP.User == "") != (cfg.SMTP.Password == "") {
+		findings = append(findings, finding{severityWarning, "email", "only one of SMTP_USER and SMTP_PASSWORD is set, so emails are sent without authentication",
+			"Set both, or neither for a relay that does not need authentication."})
+	}
+
+	svc := email.NewService(email.Config{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		User:     cfg.SMTP.User,
+		Password: cfg.SMTP.Password,
+		From:     cfg.SMTP.From,
+	})
+	if err := svc.Verify(doctorTimeout); err != nil {
+		return append(findings, finding{severityError, "email", fmt.Sprintf("%s:%d: %v", cfg.SMTP.Host, cfg.SMTP.Port, err),
+			"Check SMTP_HOST, SMTP_PORT (587 for STARTTLS; port 465 is not supported), SMTP_USER and SMTP_PASSWORD with your mail provider."})
+	}
+	return append(findings, finding{severity: severityOK, area: "email", message: fmt.Sprintf("%s:%d accepted the connection and credentials", cfg.SMTP.Host, cfg.SMTP.Port)})
+}
+
+// checkOAuth checks that OAuth providers are configured completely and lists
+// the redirect URLs to register with them.
+func checkOAuth(cfg *config.Config) []finding {
+	providers := []struct {
+		name, id, secret, env string
+	}{
+		{"Google", cfg.OAuth.GoogleClientID, cfg.OAuth.GoogleClientSecret, "OAUTH_GOOGLE"},
+		{"GitHub", cfg.OAuth.GithubClientID, cfg.OAuth.GithubClientSecret, "OAUTH_GITHUB"},
+	}
+
+	var findings []finding
+	for _, p := range providers {
+		area := "oauth " + strings.ToLower(p.name)
+		callback := strings.TrimSuffix(cfg.APIURL, "/") + "/api/v1/auth/" + strings.ToLower(p.name) + "/callback"
+		switch {
+		case p.id == "" && p.secret == "":
+			findings = append(findings, finding{severity: severityOK, area: area, message: "disabled"})
+		case p.id == "" || p.secret == "":
+			findings = append(findings, finding{severityError, area, fmt.Sprintf("only one of %s_CLIENT_ID and %s_CLIENT_SECRET is set", p.env, p.env),
+				"Set both from the OAuth app, or neither to disable the provider."})
+		default:
+			f := finding{severity: severityOK, area: area, message: "enabled with redirect URL " + callback}
+			if u, err := url.Parse(callback); err == nil && u.Scheme != "https" && !isLocalHost(u.Hostname()) {
+				f = finding{severityWarning, area, "redirect URL " + callback + " is not https",
+					fmt.Sprintf("%s only accepts http redirect URLs on localhost; serve API_URL over https.", p.name)}
+			}
+			if f.severity == severityOK {
+				f.fix = fmt.Sprintf("Register exactly this redirect URL with the %s OAuth app.", p.name)
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// parseBaseURL parses an absolute http or https URL.
+func parseBaseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("must start with http:// or https://")
+	}
+	if u.Host == "" {
+		return nil, errors.New("has no host")
+	}
+	return u, nil
+}
+
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func uniqueBytes(s string) map[byte]bool {
+	seen := make(map[byte]bool)
+	for i := 0; i < len(s); i++ {
+		seen[s[i]] = true
+	}
+	return seen
+}
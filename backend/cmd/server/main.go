@@ -14,6 +14,11 @@
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+//
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name X-Admin-Token
+// @description Instance admin token (INSTANCE_ADMIN_TOKEN).
 
 package main
 
@@ -21,18 +26,39 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/devbydaniel/litekpi/internal/datasource"
+	"github.com/devbydaniel/litekpi/internal/ingest"
 	"github.com/devbydaniel/litekpi/internal/platform/config"
 	"github.com/devbydaniel/litekpi/internal/platform/database"
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+	"github.com/devbydaniel/litekpi/internal/platform/lifecycle"
+	platformmw "github.com/devbydaniel/litekpi/internal/platform/middleware"
 	"github.com/devbydaniel/litekpi/internal/platform/router"
+	"github.com/devbydaniel/litekpi/internal/platform/secrets"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 func main() {
+	// "server doctor" checks the configuration instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		if err := runDoctor(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
 		log.Fatalf("Error: %v", err)
 	}
@@ -51,15 +77,39 @@ func run() error {
 
 	// Connect to database
 	log.Println("Connecting to database...")
-	db, err := database.New(ctx, cfg.DatabaseURL)
+	db, err := database.New(ctx, cfg.DatabaseURL, cfg.DBStatementTimeout)
 	if err != nil {
 		return fmt.Errorf("connecting to database: %w", err)
 	}
 	defer db.Close()
 	log.Println("Database connected successfully")
 
+	// Detect a schema that does not match this build's migrations
+	if err := checkSchema(ctx, db, cfg.RequireCurrentSchema); err != nil {
+		return err
+	}
+
+	// Load the keys that encrypt stored third-party credentials
+	keyring, err := secrets.NewKeyring(cfg.SecretsKeys)
+	if err != nil {
+		return fmt.Errorf("loading SECRETS_KEYS: %w", err)
+	}
+	if !keyring.Enabled() {
+		log.Println("Warning: SECRETS_KEYS is not set, third-party credentials are stored unencrypted")
+	}
+
+	// Schedule measurements partition maintenance and downsampling of old
+	// measurements; the router adds the jobs of the modules
+	scheduler := jobs.NewScheduler(db.Pool)
+	scheduler.Register(ingest.NewPartitionMaintainer(ingest.NewRepository(db.Pool), cfg.MeasurementRetentionMonths).Job())
+	scheduler.Register(ingest.NewDownsampler(ingest.NewRepository(db.Pool), cfg.DownsampleAfterDays).Job())
+
 	// Create router
-	r := router.New(db, cfg)
+	drainer := lifecycle.NewDrainer()
+	r := router.New(ctx, db, cfg, keyring, scheduler, drainer)
+
+	// Start background jobs
+	drainer.Go(func() { scheduler.Run(ctx) })
 
 	// Create HTTP server
 	server := &http.Server{
@@ -71,12 +121,45 @@ func run() error {
 	}
 
 	// Start server in goroutine
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, 2)
 	go func() {
 		log.Printf("Server starting on port %s", cfg.ServerPort)
 		serverErrors <- server.ListenAndServe()
 	}()
 
+	// Start gRPC ingestion server
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			return fmt.Errorf("listening on gRPC port: %w", err)
+		}
+		usageService := usage.NewService(usage.NewRepository(db.Pool), cfg)
+		writeAuditService := writeaudit.NewService(writeaudit.NewRepository(db.Pool))
+		dsService := datasource.NewService(datasource.NewRepository(db.Pool), usageService, writeAuditService)
+		drainer.OnFlush(dsService.Flush)
+		var opts []grpc.ServerOption
+		if cfg.GRPCTLSCert != "" {
+			creds, err := credentials.NewServerTLSFromFile(cfg.GRPCTLSCert, cfg.GRPCTLSKey)
+			if err != nil {
+				return fmt.Errorf("loading gRPC TLS certificate: %w", err)
+			}
+			opts = append(opts, grpc.Creds(creds))
+		} else {
+			log.Printf("gRPC server serves plaintext; set GRPC_TLS_CERT and GRPC_TLS_KEY unless a proxy terminates TLS")
+		}
+		grpcServer = ingest.NewGRPCServer(
+			ingest.NewService(ingest.NewRepository(db.Pool), usageService, writeAuditService, cfg),
+			dsService,
+			platformmw.NewRateLimiter(cfg.RateLimits.Ingest, ingest.DataSourceKey),
+			opts...,
+		)
+		go func() {
+			log.Printf("gRPC server starting on port %s", cfg.GRPCPort)
+			serverErrors <- grpcServer.Serve(lis)
+		}()
+	}
+
 	// Wait for shutdown signal or server error
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
@@ -88,19 +171,67 @@ func run() error {
 	case sig := <-shutdown:
 		log.Printf("Received signal %v, starting graceful shutdown", sig)
 
+		// Report not ready and keep serving until load balancers have
+		// stopped routing here
+		drainer.Start()
+		time.Sleep(cfg.ShutdownDelay)
+
 		// Create shutdown context with timeout
-		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 30*time.Second)
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 		defer shutdownCancel()
 
-		// Gracefully shutdown the server
+		// Stop accepting gRPC calls, force-closing streams still open at the deadline
+		if grpcServer != nil {
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-shutdownCtx.Done():
+				grpcServer.Stop()
+			}
+		}
+
+		// Gracefully shutdown the server, letting in-flight ingest and
+		// compute requests finish
 		if err := server.Shutdown(shutdownCtx); err != nil {
 			// Force shutdown if graceful shutdown fails
 			server.Close()
 			return fmt.Errorf("graceful shutdown failed: %w", err)
 		}
 
+		// Stop background work, let running compute jobs and scheduled jobs
+		// finish, and write out buffered updates before the database closes
+		cancel()
+		if err := drainer.Wait(shutdownCtx); err != nil {
+			return fmt.Errorf("draining background work: %w", err)
+		}
+
 		log.Println("Server stopped gracefully")
 	}
 
 	return nil
 }
+
+// checkSchema compares the database schema with this build's migrations. A
+// mismatch is logged, or returned as an error if required is set.
+func checkSchema(ctx context.Context, db *database.DB, required bool) error {
+	status, err := db.SchemaStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("checking schema version: %w", err)
+	}
+	if status.State == database.SchemaUpToDate {
+		log.Printf("Database schema is at version %d", status.Version)
+		return nil
+	}
+
+	mismatch := fmt.Errorf("database schema is %s: at version %d (dirty: %t), this build expects version %d with %d pending migrations",
+		status.State, status.Version, status.Dirty, status.ExpectedVersion, len(status.Pending))
+	if required {
+		return mismatch
+	}
+	log.Printf("Warning: %v", mismatch)
+	return nil
+}
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrReasonRequired       = errors.New("reason is required")
+	ErrSessionNotFound      = errors.New("impersonation session not found")
+)
+
+// Audit actions recorded for instance operator activity.
+const (
+	ActionDisableOrganization = "disable_organization"
+	ActionEnableOrganization  = "enable_organization"
+	ActionImpersonateUser     = "impersonate_user"
+	ActionEndImpersonation    = "end_impersonation"
+)
+
+// OrganizationSummary is an organization as seen by the instance operator.
+type OrganizationSummary struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Users       int64      `json:"users"`
+	DataSources int64      `json:"dataSources"`
+	Dashboards  int64      `json:"dashboards"`
+	DisabledAt  *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// AuditEntry is a single record in the instance audit log.
+type AuditEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	Action         string     `json:"action"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	UserID         *uuid.UUID `json:"userId,omitempty"`
+	Reason         string     `json:"reason"`
+	RemoteAddr     string     `json:"remoteAddr"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// ImpersonationSession is a time-limited session in which an instance
+// operator acts as a user.
+type ImpersonationSession struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"userId"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	Reason         string     `json:"reason"`
+	RemoteAddr     string     `json:"remoteAddr"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	EndedAt        *time.Time `json:"endedAt,omitempty"`
+}
+
+// ListOrganizationsResponse is the response for listing organizations.
+type ListOrganizationsResponse struct {
+	Organizations []OrganizationSummary `json:"organizations"`
+}
+
+// ListAuditLogResponse is the response for listing the audit log.
+type ListAuditLogResponse struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// ActionRequest is the request body for audited operator actions.
+type ActionRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// ImpersonateResponse is the response for impersonating a user.
+type ImpersonateResponse struct {
+	User    auth.User            `json:"user"`
+	Token   string               `json:"token"`
+	Session ImpersonationSession `json:"session"`
+}
+
+// ListImpersonationsResponse is the response for listing impersonation sessions.
+type ListImpersonationsResponse struct {
+	Sessions []ImpersonationSession `json:"sessions"`
+}
+
+// ListJobsResponse is the response for listing background jobs.
+type ListJobsResponse struct {
+	Jobs []jobs.Status `json:"jobs"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,376 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// Handler handles HTTP requests for instance administration.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new admin handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListOrganizations handles listing all organizations on the instance.
+//
+//	@Summary		List organizations
+//	@Description	Get all organizations on the instance with member and resource counts
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Success		200	{object}	ListOrganizationsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/organizations [get]
+func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.service.ListOrganizations(r.Context())
+	if err != nil {
+		log.Printf("admin list organizations error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list organizations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListOrganizationsResponse{Organizations: orgs})
+}
+
+// GetOrganizationUsage handles returning an organization's current-period usage.
+//
+//	@Summary		Get organization usage
+//	@Description	Get current-period usage and quotas for any organization
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			id	path		string	true	"Organization ID"
+//	@Success		200	{object}	usage.Usage
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/organizations/{id}/usage [get]
+func (h *Handler) GetOrganizationUsage(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	u, err := h.service.GetOrganizationUsage(r.Context(), orgID)
+	if err != nil {
+		if errors.Is(err, ErrOrganizationNotFound) {
+			respondError(w, http.StatusNotFound, "organization not found")
+			return
+		}
+		log.Printf("admin get usage error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get usage")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, u)
+}
+
+// DisableOrganization handles disabling an organization.
+//
+//	@Summary		Disable organization
+//	@Description	Block logins, sessions, data source API keys, and MCP keys of an organization. The action is audited.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			id		path	string			true	"Organization ID"
+//	@Param			request	body	ActionRequest	true	"Reason for the action"
+//	@Success		204
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/organizations/{id}/disable [post]
+func (h *Handler) DisableOrganization(w http.ResponseWriter, r *http.Request) {
+	h.setOrganizationDisabled(w, r, true)
+}
+
+// EnableOrganization handles re-enabling a disabled organization.
+//
+//	@Summary		Enable organization
+//	@Description	Lift a previous disable of an organization. The action is audited.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			id		path	string			true	"Organization ID"
+//	@Param			request	body	ActionRequest	true	"Reason for the action"
+//	@Success		204
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/organizations/{id}/enable [post]
+func (h *Handler) EnableOrganization(w http.ResponseWriter, r *http.Request) {
+	h.setOrganizationDisabled(w, r, false)
+}
+
+func (h *Handler) setOrganizationDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	var req ActionRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	if disabled {
+		err = h.service.DisableOrganization(r.Context(), orgID, req.Reason, r.RemoteAddr)
+	} else {
+		err = h.service.EnableOrganization(r.Context(), orgID, req.Reason, r.RemoteAddr)
+	}
+	if err != nil {
+		if errors.Is(err, ErrReasonRequired) {
+			respondError(w, http.StatusBadRequest, "reason is required")
+			return
+		}
+		if errors.Is(err, ErrOrganizationNotFound) {
+			respondError(w, http.StatusNotFound, "organization not found")
+			return
+		}
+		log.Printf("admin update organization error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update organization")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImpersonateUser handles starting an impersonation session for a user.
+//
+//	@Summary		Impersonate user
+//	@Description	Start an impersonation session for any user and issue its token. A reason is required and the action is written to the audit log before the token is issued. The token carries impersonated and impersonationId claims, expires after IMPERSONATION_TTL, and every request made with it is logged.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			id		path		string			true	"User ID"
+//	@Param			request	body		ActionRequest	true	"Reason for the impersonation"
+//	@Success		200		{object}	ImpersonateResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/admin/users/{id}/impersonate [post]
+func (h *Handler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req ActionRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	resp, err := h.service.ImpersonateUser(r.Context(), userID, req.Reason, r.RemoteAddr)
+	if err != nil {
+		if errors.Is(err, ErrReasonRequired) {
+			respondError(w, http.StatusBadRequest, "reason is required")
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			respondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		log.Printf("admin impersonate error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to impersonate user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ListImpersonations handles listing recent impersonation sessions.
+//
+//	@Summary		List impersonation sessions
+//	@Description	Get the most recent impersonation sessions, including expired and ended ones
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Success		200	{object}	ListImpersonationsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/impersonations [get]
+func (h *Handler) ListImpersonations(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.service.ListImpersonations(r.Context())
+	if err != nil {
+		log.Printf("admin list impersonations error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list impersonation sessions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListImpersonationsResponse{Sessions: sessions})
+}
+
+// EndImpersonation handles ending an impersonation session early.
+//
+//	@Summary		End impersonation session
+//	@Description	End an impersonation session before it expires; its token stops working immediately. The action is audited.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			id	path		string	true	"Impersonation session ID"
+//	@Success		200	{object}	ImpersonationSession
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/impersonations/{id}/end [post]
+func (h *Handler) EndImpersonation(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session ID")
+		return
+	}
+
+	session, err := h.service.EndImpersonation(r.Context(), sessionID, r.RemoteAddr)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			respondError(w, http.StatusNotFound, "impersonation session not found")
+			return
+		}
+		log.Printf("admin end impersonation error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to end impersonation session")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, session)
+}
+
+// ListAuditLog handles listing recent operator actions.
+//
+//	@Summary		List audit log
+//	@Description	Get the most recent instance operator actions, optionally filtered by organization
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			organizationId	query		string	false	"Organization ID"
+//	@Success		200				{object}	ListAuditLogResponse
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/admin/audit-log [get]
+func (h *Handler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	var orgID *uuid.UUID
+	if v := r.URL.Query().Get("organizationId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid organization ID")
+			return
+		}
+		orgID = &id
+	}
+
+	entries, err := h.service.ListAuditLog(r.Context(), orgID)
+	if err != nil {
+		log.Printf("admin list audit log error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list audit log")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListAuditLogResponse{Entries: entries})
+}
+
+// GetSchemaStatus handles reporting the database schema version.
+//
+//	@Summary		Get schema status
+//	@Description	Get the applied database schema version and the migrations this build expects but the database lacks. state is up_to_date, pending, ahead (migrated by a newer build) or dirty (a migration failed halfway).
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Success		200	{object}	database.SchemaStatus
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/schema [get]
+func (h *Handler) GetSchemaStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.GetSchemaStatus(r.Context())
+	if err != nil {
+		log.Printf("admin get schema status error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get schema status")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// ListJobs handles listing the background jobs of the instance.
+//
+//	@Summary		List background jobs
+//	@Description	Get every background job with its schedule and the outcome of its last run. state is idle, running (with the replica running it) or failed (a retry is scheduled, see lastError).
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Success		200	{object}	ListJobsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/jobs [get]
+func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.service.ListJobs(r.Context())
+	if err != nil {
+		log.Printf("admin list jobs error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list jobs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListJobsResponse{Jobs: statuses})
+}
+
+// RunJob handles making a background job due now.
+//
+//	@Summary		Run background job
+//	@Description	Make a background job due now, for example to retry a failed run without waiting for its backoff. It starts within seconds on one replica, unless it is already running.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		AdminToken
+//	@Param			name	path	string	true	"Job name"
+//	@Success		202
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/admin/jobs/{name}/run [post]
+func (h *Handler) RunJob(w http.ResponseWriter, r *http.Request) {
+	err := h.service.RunJob(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			respondError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		log.Printf("admin run job error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to run job")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
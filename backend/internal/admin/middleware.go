@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// TokenMiddleware creates a middleware that requires the instance admin token.
+// The token is a separate credential from user sessions so that operators can
+// administer tenants without belonging to any organization.
+func TokenMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get("X-Admin-Token")
+			if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				respondError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/internal/platform/database"
+)
+
+// auditLogLimit caps the number of audit entries returned in one listing.
+const auditLogLimit = 200
+
+// Repository handles database operations for instance administration.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new admin repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// ListOrganizations retrieves all organizations with their resource counts.
+func (r *Repository) ListOrganizations(ctx context.Context) ([]OrganizationSummary, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT o.id, o.name,
+		        (SELECT COUNT(*) FROM users u WHERE u.organization_id = o.id),
+		        (SELECT COUNT(*) FROM data_sources ds WHERE ds.organization_id = o.id),
+		        (SELECT COUNT(*) FROM dashboards d WHERE d.organization_id = o.id AND d.deleted_at IS NULL),
+		        o.disabled_at, o.created_at
+		FROM organizations o
+		ORDER BY o.created_at`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []OrganizationSummary
+	for rows.Next() {
+		var o OrganizationSummary
+		if err := rows.Scan(&o.ID, &o.Name, &o.Users, &o.DataSources, &o.Dashboards, &o.DisabledAt, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+// OrganizationExists reports whether an organization exists.
+func (r *Repository) OrganizationExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM organizations WHERE id = $1)`,
+		id,
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetUserOrganizationID retrieves the organization a user belongs to.
+func (r *Repository) GetUserOrganizationID(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error) {
+	var orgID uuid.UUID
+	err := r.pool.QueryRow(ctx,
+		`SELECT organization_id FROM users WHERE id = $1`,
+		userID,
+	).Scan(&orgID)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &orgID, nil
+}
+
+// SetOrganizationDisabled disables or re-enables an organization.
+func (r *Repository) SetOrganizationDisabled(ctx context.Context, id uuid.UUID, disabled bool) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE organizations SET disabled_at = CASE WHEN $1 THEN COALESCE(disabled_at, NOW()) END WHERE id = $2`,
+		disabled, id,
+	)
+	return err
+}
+
+// CreateAuditEntry records an operator action in the audit log.
+func (r *Repository) CreateAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO instance_audit_log (id, action, organization_id, user_id, reason, remote_addr, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.ID, entry.Action, entry.OrganizationID, entry.UserID, entry.Reason, entry.RemoteAddr, entry.CreatedAt,
+	)
+	return err
+}
+
+// CreateImpersonationSession records the start of an impersonation session.
+func (r *Repository) CreateImpersonationSession(ctx context.Context, session *ImpersonationSession) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO impersonation_sessions (id, user_id, organization_id, reason, remote_addr, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		session.ID, session.UserID, session.OrganizationID, session.Reason, session.RemoteAddr, session.CreatedAt, session.ExpiresAt,
+	)
+	return err
+}
+
+// GetImpersonationSession retrieves an impersonation session by ID.
+func (r *Repository) GetImpersonationSession(ctx context.Context, id uuid.UUID) (*ImpersonationSession, error) {
+	var s ImpersonationSession
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, user_id, organization_id, reason, remote_addr, created_at, expires_at, ended_at
+		FROM impersonation_sessions WHERE id = $1`,
+		id,
+	).Scan(&s.ID, &s.UserID, &s.OrganizationID, &s.Reason, &s.RemoteAddr, &s.CreatedAt, &s.ExpiresAt, &s.EndedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// EndImpersonationSession marks an impersonation session as ended. Ending a
+// session that already ended keeps the original end time.
+func (r *Repository) EndImpersonationSession(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE impersonation_sessions SET ended_at = COALESCE(ended_at, NOW()) WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+// ListImpersonationSessions retrieves the most recent impersonation sessions.
+func (r *Repository) ListImpersonationSessions(ctx context.Context) ([]ImpersonationSession, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, user_id, organization_id, reason, remote_addr, created_at, expires_at, ended_at
+		FROM impersonation_sessions
+		ORDER BY created_at DESC
+		LIMIT $1`,
+		auditLogLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []ImpersonationSession
+	for rows.Next() {
+		var s ImpersonationSession
+		if err := rows.Scan(&s.ID, &s.UserID, &s.OrganizationID, &s.Reason, &s.RemoteAddr, &s.CreatedAt, &s.ExpiresAt, &s.EndedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// ListAuditEntries retrieves the most recent audit log entries, optionally filtered by organization.
+func (r *Repository) ListAuditEntries(ctx context.Context, orgID *uuid.UUID) ([]AuditEntry, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, action, organization_id, user_id, reason, remote_addr, created_at
+		FROM instance_audit_log
+		WHERE $1::uuid IS NULL OR organization_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		orgID, auditLogLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.OrganizationID, &e.UserID, &e.Reason, &e.RemoteAddr, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetSchemaStatus reads the applied schema version.
+func (r *Repository) GetSchemaStatus(ctx context.Context) (*database.SchemaStatus, error) {
+	return database.GetSchemaStatus(ctx, r.pool)
+}
@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the instance admin routes. Nothing is registered
+// when no admin token is configured.
+func (h *Handler) RegisterRoutes(r chi.Router, token string) {
+	if token == "" {
+		return
+	}
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(TokenMiddleware(token))
+
+		r.Get("/organizations", h.ListOrganizations)
+		r.Get("/organizations/{id}/usage", h.GetOrganizationUsage)
+		r.Post("/organizations/{id}/disable", h.DisableOrganization)
+		r.Post("/organizations/{id}/enable", h.EnableOrganization)
+		r.Post("/users/{id}/impersonate", h.ImpersonateUser)
+		r.Get("/impersonations", h.ListImpersonations)
+		r.Post("/impersonations/{id}/end", h.EndImpersonation)
+		r.Get("/audit-log", h.ListAuditLog)
+		r.Get("/schema", h.GetSchemaStatus)
+		r.Get("/jobs", h.ListJobs)
+		r.Post("/jobs/{name}/run", h.RunJob)
+	})
+}
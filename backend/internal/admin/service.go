@@ -0,0 +1,239 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/database"
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+	"github.com/devbydaniel/litekpi/internal/usage"
+)
+
+// Service handles instance administration business logic.
+type Service struct {
+	repo             *Repository
+	authService      *auth.Service
+	usageService     *usage.Service
+	scheduler        *jobs.Scheduler
+	impersonationTTL time.Duration
+}
+
+// NewService creates a new admin service. Impersonation sessions expire after
+// impersonationTTL.
+func NewService(repo *Repository, authService *auth.Service, usageService *usage.Service, scheduler *jobs.Scheduler, impersonationTTL time.Duration) *Service {
+	return &Service{
+		repo:             repo,
+		authService:      authService,
+		usageService:     usageService,
+		scheduler:        scheduler,
+		impersonationTTL: impersonationTTL,
+	}
+}
+
+// ListOrganizations returns all organizations on the instance.
+func (s *Service) ListOrganizations(ctx context.Context) ([]OrganizationSummary, error) {
+	orgs, err := s.repo.ListOrganizations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	if orgs == nil {
+		orgs = []OrganizationSummary{}
+	}
+	return orgs, nil
+}
+
+// GetOrganizationUsage returns the current-period usage of an organization.
+func (s *Service) GetOrganizationUsage(ctx context.Context, orgID uuid.UUID) (*usage.Usage, error) {
+	if err := s.requireOrganization(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return s.usageService.GetUsage(ctx, orgID)
+}
+
+// DisableOrganization blocks all logins, sessions, and API keys of an organization.
+func (s *Service) DisableOrganization(ctx context.Context, orgID uuid.UUID, reason, remoteAddr string) error {
+	return s.setOrganizationDisabled(ctx, orgID, true, reason, remoteAddr)
+}
+
+// EnableOrganization lifts a previous disable.
+func (s *Service) EnableOrganization(ctx context.Context, orgID uuid.UUID, reason, remoteAddr string) error {
+	return s.setOrganizationDisabled(ctx, orgID, false, reason, remoteAddr)
+}
+
+func (s *Service) setOrganizationDisabled(ctx context.Context, orgID uuid.UUID, disabled bool, reason, remoteAddr string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrReasonRequired
+	}
+	if err := s.requireOrganization(ctx, orgID); err != nil {
+		return err
+	}
+
+	action := ActionEnableOrganization
+	if disabled {
+		action = ActionDisableOrganization
+	}
+	if err := s.audit(ctx, action, &orgID, nil, reason, remoteAddr); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetOrganizationDisabled(ctx, orgID, disabled); err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	return nil
+}
+
+// ImpersonateUser starts an impersonation session for a user and issues a
+// token flagged as impersonated that expires with the session. The audit entry
+// and session are written before the token so that no impersonation goes
+// unrecorded.
+func (s *Service) ImpersonateUser(ctx context.Context, userID uuid.UUID, reason, remoteAddr string) (*ImpersonateResponse, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	orgID, err := s.repo.GetUserOrganizationID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if orgID == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := s.audit(ctx, ActionImpersonateUser, orgID, &userID, reason, remoteAddr); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &ImpersonationSession{
+		ID:             uuid.New(),
+		UserID:         userID,
+		OrganizationID: *orgID,
+		Reason:         reason,
+		RemoteAddr:     remoteAddr,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.impersonationTTL),
+	}
+	if err := s.repo.CreateImpersonationSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create impersonation session: %w", err)
+	}
+
+	resp, err := s.authService.IssueImpersonationToken(ctx, userID, session.ID, session.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &ImpersonateResponse{User: resp.User, Token: resp.Token, Session: *session}, nil
+}
+
+// EndImpersonation ends an impersonation session before it expires, revoking
+// its token.
+func (s *Service) EndImpersonation(ctx context.Context, sessionID uuid.UUID, remoteAddr string) (*ImpersonationSession, error) {
+	session, err := s.repo.GetImpersonationSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impersonation session: %w", err)
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	if session.EndedAt != nil {
+		return session, nil
+	}
+
+	if err := s.audit(ctx, ActionEndImpersonation, &session.OrganizationID, &session.UserID, "", remoteAddr); err != nil {
+		return nil, err
+	}
+	if err := s.repo.EndImpersonationSession(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to end impersonation session: %w", err)
+	}
+
+	now := time.Now()
+	session.EndedAt = &now
+	return session, nil
+}
+
+// ListImpersonations returns the most recent impersonation sessions.
+func (s *Service) ListImpersonations(ctx context.Context) ([]ImpersonationSession, error) {
+	sessions, err := s.repo.ListImpersonationSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list impersonation sessions: %w", err)
+	}
+	if sessions == nil {
+		sessions = []ImpersonationSession{}
+	}
+	return sessions, nil
+}
+
+// ListAuditLog returns recent operator actions, optionally for a single organization.
+func (s *Service) ListAuditLog(ctx context.Context, orgID *uuid.UUID) ([]AuditEntry, error) {
+	entries, err := s.repo.ListAuditEntries(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+	return entries, nil
+}
+
+// GetSchemaStatus returns the applied schema version and the migrations
+// still pending for this build.
+func (s *Service) GetSchemaStatus(ctx context.Context) (*database.SchemaStatus, error) {
+	status, err := s.repo.GetSchemaStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema status: %w", err)
+	}
+	return status, nil
+}
+
+// ListJobs returns the schedule and last outcome of every background job.
+func (s *Service) ListJobs(ctx context.Context) ([]jobs.Status, error) {
+	statuses, err := s.scheduler.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return statuses, nil
+}
+
+// RunJob makes a background job due now.
+func (s *Service) RunJob(ctx context.Context, name string) error {
+	return s.scheduler.Trigger(ctx, name)
+}
+
+func (s *Service) requireOrganization(ctx context.Context, orgID uuid.UUID) error {
+	exists, err := s.repo.OrganizationExists(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	if !exists {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+func (s *Service) audit(ctx context.Context, action string, orgID, userID *uuid.UUID, reason, remoteAddr string) error {
+	entry := &AuditEntry{
+		ID:             uuid.New(),
+		Action:         action,
+		OrganizationID: orgID,
+		UserID:         userID,
+		Reason:         reason,
+		RemoteAddr:     remoteAddr,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repo.CreateAuditEntry(ctx, entry); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
@@ -17,10 +17,11 @@ const (
 
 // Organization represents an organization in the system.
 type Organization struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
 }
 
 // User represents a user in the system.
@@ -33,6 +34,7 @@ type User struct {
 	OrganizationID uuid.UUID     `json:"organizationId"`
 	Organization   *Organization `json:"organization,omitempty"`
 	Role           Role          `json:"role"`
+	Language       *string       `json:"language,omitempty"` // Language of emails to the user; unset uses the organization's
 	CreatedAt      time.Time     `json:"createdAt"`
 	UpdatedAt      time.Time     `json:"updatedAt"`
 }
@@ -52,7 +54,7 @@ type OAuthAccount struct {
 type EmailVerificationToken struct {
 	ID        uuid.UUID
 	UserID    uuid.UUID
-	Token     string
+	Token     string // Hash of the token, as stored
 	ExpiresAt time.Time
 	CreatedAt time.Time
 }
@@ -61,7 +63,7 @@ type EmailVerificationToken struct {
 type PasswordResetToken struct {
 	ID        uuid.UUID
 	UserID    uuid.UUID
-	Token     string
+	Token     string // Hash of the token, as stored
 	ExpiresAt time.Time
 	Used      bool
 	CreatedAt time.Time
@@ -69,10 +71,10 @@ type PasswordResetToken struct {
 
 // RegisterRequest is the request body for user registration.
 type RegisterRequest struct {
-	Email            string `json:"email"`
-	Password         string `json:"password"`
-	Name             string `json:"name"`
-	OrganizationName string `json:"organizationName"`
+	Email            string `json:"email" validate:"required"`
+	Password         string `json:"password" validate:"required,min=8"`
+	Name             string `json:"name" validate:"required"`
+	OrganizationName string `json:"organizationName"` // Not needed when the email domain is verified by an organization
 }
 
 // LoginRequest is the request body for user login.
@@ -89,23 +91,23 @@ type AuthResponse struct {
 
 // ForgotPasswordRequest is the request body for initiating password reset.
 type ForgotPasswordRequest struct {
-	Email string `json:"email"`
+	Email string `json:"email" validate:"required"`
 }
 
 // ResetPasswordRequest is the request body for resetting password.
 type ResetPasswordRequest struct {
-	Token       string `json:"token"`
-	NewPassword string `json:"newPassword"`
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=8"`
 }
 
 // VerifyEmailRequest is the request body for email verification.
 type VerifyEmailRequest struct {
-	Token string `json:"token"`
+	Token string `json:"token" validate:"required"`
 }
 
 // ResendVerificationRequest is the request body for resending verification email.
 type ResendVerificationRequest struct {
-	Email string `json:"email"`
+	Email string `json:"email" validate:"required"`
 }
 
 // MessageResponse is a generic response with a message.
@@ -133,9 +135,9 @@ type OAuthUserInfo struct {
 
 // CompleteOAuthSetupRequest is the request body for completing OAuth registration.
 type CompleteOAuthSetupRequest struct {
-	Token            string `json:"token"`
-	Name             string `json:"name"`
-	OrganizationName string `json:"organizationName"`
+	Token            string `json:"token" validate:"required"`
+	Name             string `json:"name" validate:"required"`
+	OrganizationName string `json:"organizationName"` // Not needed when the email domain is verified by an organization
 }
 
 // OAuthPendingSetupResponse is returned when OAuth user needs to complete setup.
@@ -146,13 +148,54 @@ type OAuthPendingSetupResponse struct {
 	ProviderName string `json:"providerName,omitempty"`
 }
 
+// OAuthPendingLinkResponse is returned when an OAuth login matches the email
+// of an existing account, which has to confirm the link first.
+type OAuthPendingLinkResponse struct {
+	PendingLink bool   `json:"pendingLink"`
+	Token       string `json:"token"`
+	Email       string `json:"email"`
+	Provider    string `json:"provider"`
+	HasPassword bool   `json:"hasPassword"` // Whether the account can confirm with its password
+}
+
+// OAuthLinkRequest is a pending request to link an OAuth identity to the
+// existing account with its email.
+type OAuthLinkRequest struct {
+	ID             uuid.UUID `json:"id"`
+	OAuthAccountID uuid.UUID `json:"-"`
+	UserID         uuid.UUID `json:"-"`
+	Provider       string    `json:"provider"`
+	ProviderEmail  string    `json:"providerEmail"`
+	ProviderName   string    `json:"providerName,omitempty"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// ConfirmOAuthLinkRequest is the request body for confirming an OAuth link
+// with the account's password.
+type ConfirmOAuthLinkRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// OAuthLinkTokenRequest is the request body for emailing or verifying an
+// OAuth link confirmation.
+type OAuthLinkTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// OAuthLinkRequestsResponse is the response body for listing pending OAuth links.
+type OAuthLinkRequestsResponse struct {
+	Requests []OAuthLinkRequest `json:"requests"`
+}
+
 // Invite represents a pending user invitation.
 type Invite struct {
 	ID             uuid.UUID  `json:"id"`
 	OrganizationID uuid.UUID  `json:"organizationId"`
 	Email          string     `json:"email"`
 	Role           Role       `json:"role"`
-	Token          string     `json:"-"` // Never expose token in API responses
+	Token          string     `json:"-"` // Never expose token in API responses; the stored hash unless just created
 	InvitedBy      uuid.UUID  `json:"invitedBy"`
 	ExpiresAt      time.Time  `json:"expiresAt"`
 	AcceptedAt     *time.Time `json:"acceptedAt,omitempty"`
@@ -185,11 +228,66 @@ type CreateInviteResponse struct {
 	InviteURL *string `json:"inviteUrl,omitempty"` // Only present if email not configured
 }
 
+// MaxBulkInvites is the maximum number of invites created in one request.
+const MaxBulkInvites = 100
+
+// BulkInviteRequest is the request body for creating several invites at once.
+type BulkInviteRequest struct {
+	Invites []CreateInviteRequest `json:"invites"`
+}
+
+// BulkInviteResponse is the response body for creating several invites.
+type BulkInviteResponse struct {
+	Invites []CreateInviteResponse `json:"invites"`
+}
+
+// BulkInviteRowError describes why one row of a bulk invite was rejected.
+type BulkInviteRowError struct {
+	Row   int    `json:"row"` // 1-based position among the invites, not counting a CSV header
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+// BulkInviteErrorResponse is returned when any row of a bulk invite is invalid.
+type BulkInviteErrorResponse struct {
+	Error string               `json:"error"`
+	Rows  []BulkInviteRowError `json:"rows"`
+}
+
 // ListInvitesResponse is the response body for listing invites.
 type ListInvitesResponse struct {
 	Invites []InviteWithInviter `json:"invites"`
 }
 
+// OrganizationDomain is an email domain whose new users join the organization
+// instead of creating their own, once ownership is verified through DNS.
+type OrganizationDomain struct {
+	ID                 uuid.UUID  `json:"id"`
+	OrganizationID     uuid.UUID  `json:"organizationId"`
+	Domain             string     `json:"domain"`
+	DefaultRole        Role       `json:"defaultRole"`
+	VerificationToken  string     `json:"-"`
+	VerificationRecord string     `json:"verificationRecord"` // TXT record value to publish on the domain
+	VerifiedAt         *time.Time `json:"verifiedAt,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+// AddDomainRequest is the request body for adding an organization domain.
+type AddDomainRequest struct {
+	Domain      string `json:"domain"`
+	DefaultRole Role   `json:"defaultRole"` // editor or viewer (default)
+}
+
+// UpdateDomainRequest is the request body for changing a domain's default role.
+type UpdateDomainRequest struct {
+	DefaultRole Role `json:"defaultRole"`
+}
+
+// ListDomainsResponse is the response body for listing organization domains.
+type ListDomainsResponse struct {
+	Domains []OrganizationDomain `json:"domains"`
+}
+
 // ListUsersResponse is the response body for listing organization users.
 type ListUsersResponse struct {
 	Users []User `json:"users"`
@@ -216,6 +314,20 @@ type UpdateUserRoleRequest struct {
 	Role Role `json:"role" validate:"required,oneof=admin editor viewer"`
 }
 
+// language returns the user's email language, or "" when unset.
+func (u *User) language() string {
+	if u.Language == nil {
+		return ""
+	}
+	return *u.Language
+}
+
+// UpdateLanguageRequest is the request body for choosing the language of
+// one's emails. Null falls back to the organization's language.
+type UpdateLanguageRequest struct {
+	Language *string `json:"language"`
+}
+
 // EmailConfigResponse indicates whether email is configured.
 type EmailConfigResponse struct {
 	Enabled bool `json:"enabled"`
@@ -1,23 +1,34 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/google/uuid"
+
 	"github.com/devbydaniel/litekpi/internal/platform/email"
 )
 
+// EmailCustomizer provides an organization's email branding and template overrides.
+// It is implemented by the branding service.
+type EmailCustomizer interface {
+	EmailCustomization(ctx context.Context, orgID uuid.UUID) (*email.Customization, error)
+}
+
 // AuthEmailer handles sending auth-related emails.
 type AuthEmailer struct {
-	svc    *email.Service
-	appURL string
+	svc        *email.Service
+	customizer EmailCustomizer
+	appURL     string
 }
 
 // NewAuthEmailer creates a new auth emailer.
-func NewAuthEmailer(svc *email.Service, appURL string) *AuthEmailer {
+func NewAuthEmailer(svc *email.Service, customizer EmailCustomizer, appURL string) *AuthEmailer {
 	return &AuthEmailer{
-		svc:    svc,
-		appURL: strings.TrimSuffix(appURL, "/"),
+		svc:        svc,
+		customizer: customizer,
+		appURL:     strings.TrimSuffix(appURL, "/"),
 	}
 }
 
@@ -26,65 +37,67 @@ func (e *AuthEmailer) IsEnabled() bool {
 	return e.svc.IsEnabled()
 }
 
-// SendVerificationEmail sends an email verification link.
-func (e *AuthEmailer) SendVerificationEmail(to, token string) error {
-	subject := "Verify your LiteKPI account"
-	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", e.appURL, token)
-
-	body := fmt.Sprintf(`Hi,
-
-Thanks for signing up for LiteKPI! Please verify your email address by clicking the link below:
-
-%s
-
-This link will expire in 24 hours.
-
-If you didn't create a LiteKPI account, you can safely ignore this email.
-
-Thanks,
-The LiteKPI Team`, verifyURL)
-
-	return e.svc.Send(to, subject, body)
+// SendVerificationEmail sends an email verification link in the given
+// language, or the organization's when empty.
+func (e *AuthEmailer) SendVerificationEmail(ctx context.Context, orgID uuid.UUID, to, language, token string) error {
+	return e.send(ctx, orgID, to, language, email.TemplateVerification, map[string]string{
+		"URL": fmt.Sprintf("%s/verify-email?token=%s", e.appURL, token),
+	})
 }
 
-// SendPasswordResetEmail sends a password reset link.
-func (e *AuthEmailer) SendPasswordResetEmail(to, token string) error {
-	subject := "Reset your LiteKPI password"
-	resetURL := fmt.Sprintf("%s/new-password?token=%s", e.appURL, token)
-
-	body := fmt.Sprintf(`Hi,
-
-We received a request to reset your password. Click the link below to create a new password:
-
-%s
-
-This link will expire in 1 hour.
-
-If you didn't request a password reset, you can safely ignore this email.
-
-Thanks,
-The LiteKPI Team`, resetURL)
+// SendPasswordResetEmail sends a password reset link in the given language,
+// or the organization's when empty.
+func (e *AuthEmailer) SendPasswordResetEmail(ctx context.Context, orgID uuid.UUID, to, language, token string) error {
+	return e.send(ctx, orgID, to, language, email.TemplatePasswordReset, map[string]string{
+		"URL": fmt.Sprintf("%s/new-password?token=%s", e.appURL, token),
+	})
+}
 
-	return e.svc.Send(to, subject, body)
+// SendOAuthLinkEmail sends a link confirming that an OAuth account may sign
+// in to the recipient's account, in the given language, or the
+// organization's when empty.
+func (e *AuthEmailer) SendOAuthLinkEmail(ctx context.Context, orgID uuid.UUID, to, language, provider, token string) error {
+	return e.send(ctx, orgID, to, language, email.TemplateAccountLink, map[string]string{
+		"URL":      fmt.Sprintf("%s/auth/link-account/verify?token=%s", e.appURL, token),
+		"Provider": provider,
+	})
 }
 
 // SendInviteEmail sends an invitation email.
-func (e *AuthEmailer) SendInviteEmail(to, token, inviterName, orgName string) error {
-	subject := fmt.Sprintf("You've been invited to join %s on LiteKPI", orgName)
-	inviteURL := fmt.Sprintf("%s/accept-invite?token=%s", e.appURL, token)
-
-	body := fmt.Sprintf(`Hi,
-
-%s has invited you to join %s on LiteKPI.
+func (e *AuthEmailer) SendInviteEmail(ctx context.Context, orgID uuid.UUID, to, token, inviterName, orgName string) error {
+	return e.send(ctx, orgID, to, "", email.TemplateInvite, map[string]string{
+		"URL":         fmt.Sprintf("%s/accept-invite?token=%s", e.appURL, token),
+		"InviterName": inviterName,
+		"OrgName":     orgName,
+	})
+}
 
-Click the link below to accept the invitation and create your account:
+// send renders a template with the organization's branding and sends it.
+// If the branding cannot be loaded the built-in template is used, so that
+// auth emails are never blocked by customization.
+func (e *AuthEmailer) send(ctx context.Context, orgID uuid.UUID, to, language, template string, data map[string]string) error {
+	if !e.svc.IsEnabled() {
+		return nil
+	}
 
-%s
+	c, err := e.customizer.EmailCustomization(ctx, orgID)
+	if err != nil {
+		c = nil
+	}
 
-This invitation will expire in 7 days.
+	if language == "" && c != nil {
+		language = c.Language
+	}
 
-Thanks,
-The LiteKPI Team`, inviterName, orgName, inviteURL)
+	msg, err := email.RenderIn(template, language, c, data)
+	if err != nil && c != nil {
+		// A broken override must not lock users out; fall back to the default
+		msg, err = email.RenderIn(template, language, nil, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
 
-	return e.svc.Send(to, subject, body)
+	msg.To = to
+	return e.svc.SendMessage(msg)
 }
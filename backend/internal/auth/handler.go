@@ -3,17 +3,36 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
+	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+
+	platformmw "github.com/devbydaniel/litekpi/internal/platform/middleware"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
 )
 
+// domainValidationErrors are the domain errors caused by invalid input.
+var domainValidationErrors = []error{
+	ErrInvalidDomain,
+	ErrPublicEmailDomain,
+	ErrInvalidDefaultRole,
+	ErrDomainVerificationFailed,
+}
+
+// maxInviteCSVBytes caps the size of an uploaded invite CSV.
+const maxInviteCSVBytes = 1 << 20
+
 // Handler handles HTTP requests for authentication.
 type Handler struct {
 	service *Service
@@ -27,42 +46,21 @@ func NewHandler(service *Service) *Handler {
 // Register handles user registration.
 //
 //	@Summary		Register a new user
-//	@Description	Create a new user account with email, password, name, and organization
+//	@Description	Create a new user account with email, password, name, and organization. Users whose email domain is verified by an organization join it with the domain's default role instead, and need no organization name.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		RegisterRequest		true	"Registration data"
 //	@Success		201		{object}	RegisterResponse
 //	@Failure		400		{object}	ErrorResponse
+//	@Failure		402		{object}	ErrorResponse	"User quota of the joined organization reached"
 //	@Failure		409		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
 //	@Router			/auth/register [post]
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	// Validate input
-	if req.Email == "" {
-		respondError(w, http.StatusBadRequest, "email is required")
-		return
-	}
-	if req.Password == "" {
-		respondError(w, http.StatusBadRequest, "password is required")
-		return
-	}
-	if len(req.Password) < 8 {
-		respondError(w, http.StatusBadRequest, "password must be at least 8 characters")
-		return
-	}
-	if req.Name == "" {
-		respondError(w, http.StatusBadRequest, "name is required")
-		return
-	}
-	if req.OrganizationName == "" {
-		respondError(w, http.StatusBadRequest, "organization name is required")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -72,6 +70,14 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusConflict, "email already exists")
 			return
 		}
+		if errors.Is(err, ErrOrganizationNameRequired) {
+			respondError(w, http.StatusBadRequest, "organization name is required")
+			return
+		}
+		if errors.Is(err, ErrSeatLimitReached) {
+			respondError(w, http.StatusPaymentRequired, "organization has reached its user limit")
+			return
+		}
 		log.Printf("registration error: %v", err)
 		respondError(w, http.StatusInternalServerError, "failed to register user")
 		return
@@ -99,8 +105,8 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 //	@Router			/auth/login [post]
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -120,6 +126,10 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusForbidden, "please verify your email before logging in")
 			return
 		}
+		if errors.Is(err, ErrOrganizationDisabled) {
+			respondError(w, http.StatusForbidden, "organization disabled")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "failed to login")
 		return
 	}
@@ -138,22 +148,19 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 //	@Success		200		{object}	MessageResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse	"Too many failed attempts"
 //	@Router			/auth/verify-email [post]
 func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	var req VerifyEmailRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Token == "" {
-		respondError(w, http.StatusBadRequest, "token is required")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
 	err := h.service.VerifyEmail(r.Context(), req.Token)
 	if err != nil {
 		if errors.Is(err, ErrInvalidToken) {
+			platformmw.ReportFailure(r.Context())
 			respondError(w, http.StatusBadRequest, "invalid verification token")
 			return
 		}
@@ -181,13 +188,8 @@ func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 //	@Router			/auth/forgot-password [post]
 func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 	var req ForgotPasswordRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Email == "" {
-		respondError(w, http.StatusBadRequest, "email is required")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -208,30 +210,19 @@ func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 //	@Success		200		{object}	MessageResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse	"Too many failed attempts"
 //	@Router			/auth/reset-password [post]
 func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	var req ResetPasswordRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Token == "" {
-		respondError(w, http.StatusBadRequest, "token is required")
-		return
-	}
-	if req.NewPassword == "" {
-		respondError(w, http.StatusBadRequest, "new password is required")
-		return
-	}
-	if len(req.NewPassword) < 8 {
-		respondError(w, http.StatusBadRequest, "password must be at least 8 characters")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
 	err := h.service.ResetPassword(r.Context(), req.Token, req.NewPassword)
 	if err != nil {
 		if errors.Is(err, ErrInvalidToken) {
+			platformmw.ReportFailure(r.Context())
 			respondError(w, http.StatusBadRequest, "invalid reset token")
 			return
 		}
@@ -263,13 +254,8 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 //	@Router			/auth/resend-verification [post]
 func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
 	var req ResendVerificationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Email == "" {
-		respondError(w, http.StatusBadRequest, "email is required")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -392,39 +378,34 @@ func (h *Handler) GithubCallback(w http.ResponseWriter, r *http.Request) {
 // CompleteOAuthSetup handles completing OAuth registration with org/name.
 //
 //	@Summary		Complete OAuth setup
-//	@Description	Complete OAuth registration by providing name and organization
+//	@Description	Complete OAuth registration by providing name and organization. Users whose email domain is verified by an organization join it instead, and need no organization name.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		CompleteOAuthSetupRequest	true	"Setup data"
 //	@Success		200		{object}	AuthResponse
 //	@Failure		400		{object}	ErrorResponse
+//	@Failure		402		{object}	ErrorResponse	"User quota of the joined organization reached"
 //	@Failure		409		{object}	ErrorResponse
 //	@Failure		500		{object}	ErrorResponse
 //	@Router			/auth/complete-oauth-setup [post]
 func (h *Handler) CompleteOAuthSetup(w http.ResponseWriter, r *http.Request) {
 	var req CompleteOAuthSetupRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	// Validate input
-	if req.Token == "" {
-		respondError(w, http.StatusBadRequest, "token is required")
-		return
-	}
-	if req.Name == "" {
-		respondError(w, http.StatusBadRequest, "name is required")
-		return
-	}
-	if req.OrganizationName == "" {
-		respondError(w, http.StatusBadRequest, "organization name is required")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
 	resp, err := h.service.CompleteOAuthSetup(r.Context(), req)
 	if err != nil {
+		if errors.Is(err, ErrOrganizationNameRequired) {
+			respondError(w, http.StatusBadRequest, "organization name is required")
+			return
+		}
+		if errors.Is(err, ErrSeatLimitReached) {
+			respondError(w, http.StatusPaymentRequired, "organization has reached its user limit")
+			return
+		}
 		if errors.Is(err, ErrInvalidToken) {
 			respondError(w, http.StatusBadRequest, "invalid or expired setup token")
 			return
@@ -445,6 +426,215 @@ func (h *Handler) CompleteOAuthSetup(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
+// ConfirmOAuthLink links an OAuth identity to the existing account with its
+// email, confirmed with the account's password.
+//
+//	@Summary		Confirm OAuth link with password
+//	@Description	Link the OAuth identity of a pending link to the existing account with its email, confirmed with the account's password, and log in
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ConfirmOAuthLinkRequest	true	"Link token and password"
+//	@Success		200		{object}	AuthResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse	"Too many failed attempts"
+//	@Router			/auth/oauth-link/confirm [post]
+func (h *Handler) ConfirmOAuthLink(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmOAuthLinkRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	resp, err := h.service.ConfirmOAuthLink(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			platformmw.ReportFailure(r.Context())
+			respondError(w, http.StatusUnauthorized, "invalid password")
+			return
+		}
+		h.respondOAuthLinkError(w, r, err, "confirm oauth link")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// SendOAuthLinkEmail emails the account owner a link confirming an OAuth link.
+//
+//	@Summary		Confirm OAuth link by email
+//	@Description	Email the owner of the account an OAuth login matched a link that confirms linking the OAuth identity
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		OAuthLinkTokenRequest	true	"Link token"
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse	"Too many failed attempts"
+//	@Router			/auth/oauth-link/email [post]
+func (h *Handler) SendOAuthLinkEmail(w http.ResponseWriter, r *http.Request) {
+	var req OAuthLinkTokenRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	if err := h.service.SendOAuthLinkEmail(r.Context(), req.Token); err != nil {
+		if errors.Is(err, ErrEmailNotConfigured) {
+			respondError(w, http.StatusBadRequest, "email is not configured")
+			return
+		}
+		h.respondOAuthLinkError(w, r, err, "send oauth link email")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "A confirmation link has been sent to the account's email address"})
+}
+
+// VerifyOAuthLink links an OAuth identity using the token emailed to the
+// account owner.
+//
+//	@Summary		Verify OAuth link
+//	@Description	Link the OAuth identity of a pending link with the token emailed to the account owner, and log in
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		OAuthLinkTokenRequest	true	"Emailed token"
+//	@Success		200		{object}	AuthResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse	"Too many failed attempts"
+//	@Router			/auth/oauth-link/verify [post]
+func (h *Handler) VerifyOAuthLink(w http.ResponseWriter, r *http.Request) {
+	var req OAuthLinkTokenRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	resp, err := h.service.VerifyOAuthLink(r.Context(), req.Token)
+	if err != nil {
+		h.respondOAuthLinkError(w, r, err, "verify oauth link")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ListOAuthLinks lists the pending OAuth links of the current user.
+//
+//	@Summary		List pending OAuth links
+//	@Description	List the OAuth identities waiting for the current user to approve linking them to their account
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	OAuthLinkRequestsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/me/oauth-links [get]
+func (h *Handler) ListOAuthLinks(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	requests, err := h.service.ListOAuthLinks(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("list oauth links error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list oauth links")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, OAuthLinkRequestsResponse{Requests: requests})
+}
+
+// ApproveOAuthLink links the OAuth identity of a pending link to the current user.
+//
+//	@Summary		Approve OAuth link
+//	@Description	Link the OAuth identity of a pending link to the current user's account
+//	@Tags			auth
+//	@Produce		json
+//	@Param			id	path		string	true	"Link request ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/me/oauth-links/{id}/approve [post]
+func (h *Handler) ApproveOAuthLink(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid link request id")
+		return
+	}
+
+	if err := h.service.ApproveOAuthLink(r.Context(), id, user); err != nil {
+		h.respondOAuthLinkError(w, r, err, "approve oauth link")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "Account linked"})
+}
+
+// RejectOAuthLink deletes a pending OAuth link of the current user.
+//
+//	@Summary		Reject OAuth link
+//	@Description	Delete a pending link without linking its OAuth identity
+//	@Tags			auth
+//	@Produce		json
+//	@Param			id	path		string	true	"Link request ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/me/oauth-links/{id} [delete]
+func (h *Handler) RejectOAuthLink(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid link request id")
+		return
+	}
+
+	if err := h.service.RejectOAuthLink(r.Context(), id, user); err != nil {
+		h.respondOAuthLinkError(w, r, err, "reject oauth link")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "Link request rejected"})
+}
+
+// respondOAuthLinkError responds with the error of an OAuth link operation.
+func (h *Handler) respondOAuthLinkError(w http.ResponseWriter, r *http.Request, err error, op string) {
+	switch {
+	case errors.Is(err, ErrInvalidToken):
+		platformmw.ReportFailure(r.Context())
+		respondError(w, http.StatusBadRequest, "invalid link token")
+	case errors.Is(err, ErrTokenExpired):
+		respondError(w, http.StatusBadRequest, "link request has expired")
+	case errors.Is(err, ErrOAuthLinkNotFound):
+		respondError(w, http.StatusNotFound, "link request not found")
+	default:
+		log.Printf("%s error: %v", op, err)
+		respondError(w, http.StatusInternalServerError, "failed to "+op)
+	}
+}
+
 // Me returns the current user.
 //
 //	@Summary		Get current user
@@ -465,6 +655,46 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, user)
 }
 
+// UpdateLanguage sets the language of the current user's emails.
+//
+//	@Summary		Update email language
+//	@Description	Set the language of the current user's emails, or null to use the organization's
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		UpdateLanguageRequest	true	"Language"
+//	@Success		200		{object}	User
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/me/language [put]
+func (h *Handler) UpdateLanguage(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateLanguageRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	updated, err := h.service.UpdateLanguage(r.Context(), user.ID, req.Language)
+	if err != nil {
+		if errors.Is(err, ErrInvalidLanguage) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("update language error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update language")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, updated)
+}
+
 // Logout handles user logout (client-side token invalidation).
 //
 //	@Summary		Logout user
@@ -491,17 +721,18 @@ func (h *Handler) GetEmailConfig(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, EmailConfigResponse{Enabled: h.service.IsEmailEnabled()})
 }
 
-// CreateInvite creates a new invite.
+// CreateInvite creates one or several invites.
 //
-//	@Summary		Create invite
-//	@Description	Create a new user invite
+//	@Summary		Create invites
+//	@Description	Create a user invite. To invite several people at once, send {"invites": [{email, role}, ...]} as JSON, or a CSV with email and role columns (optional header row) as text/csv or as the "file" field of a multipart form. Bulk invites are created in one transaction: if any row is invalid, none are created and the 400 response lists the failing rows. At most 100 invites per request.
 //	@Tags			auth
-//	@Accept			json
+//	@Accept			json,text/csv,mpfd
 //	@Produce		json
-//	@Param			request	body		CreateInviteRequest	true	"Invite data"
-//	@Success		201		{object}	CreateInviteResponse
-//	@Failure		400		{object}	ErrorResponse
+//	@Param			request	body		CreateInviteRequest	true	"Invite data, or BulkInviteRequest"
+//	@Success		201		{object}	CreateInviteResponse	"Single invite; BulkInviteResponse for bulk invites"
+//	@Failure		400		{object}	BulkInviteErrorResponse
 //	@Failure		401		{object}	ErrorResponse
+//	@Failure		402		{object}	ErrorResponse	"User quota reached"
 //	@Failure		403		{object}	ErrorResponse
 //	@Failure		409		{object}	ErrorResponse
 //	@Security		BearerAuth
@@ -513,26 +744,51 @@ func (h *Handler) CreateInvite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req CreateInviteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		reqs, err := parseInviteCSV(r.Body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid CSV")
+			return
+		}
+		h.createInvites(w, r, reqs, user)
+		return
+	case "multipart/form-data":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		reqs, err := parseInviteCSV(file)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid CSV")
+			return
+		}
+		h.createInvites(w, r, reqs, user)
 		return
 	}
 
-	if req.Email == "" {
-		respondError(w, http.StatusBadRequest, "email is required")
+	var req struct {
+		CreateInviteRequest
+		Invites []CreateInviteRequest `json:"invites"`
+	}
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
-	if req.Role == "" {
-		respondError(w, http.StatusBadRequest, "role is required")
+	if req.Invites != nil {
+		h.createInvites(w, r, req.Invites, user)
 		return
 	}
-	if req.Role != RoleAdmin && req.Role != RoleEditor && req.Role != RoleViewer {
-		respondError(w, http.StatusBadRequest, "invalid role")
+
+	if err := validate.Struct(req.CreateInviteRequest); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
-	resp, err := h.service.CreateInvite(r.Context(), req, user)
+	resp, err := h.service.CreateInvite(r.Context(), req.CreateInviteRequest, user)
 	if err != nil {
 		if errors.Is(err, ErrUserAlreadyExists) {
 			respondError(w, http.StatusConflict, "user with this email already exists")
@@ -542,6 +798,10 @@ func (h *Handler) CreateInvite(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusConflict, "a pending invite already exists for this email")
 			return
 		}
+		if errors.Is(err, ErrSeatLimitReached) {
+			respondError(w, http.StatusPaymentRequired, "organization has reached its user limit")
+			return
+		}
 		log.Printf("create invite error: %v", err)
 		respondError(w, http.StatusInternalServerError, "failed to create invite")
 		return
@@ -550,6 +810,56 @@ func (h *Handler) CreateInvite(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusCreated, resp)
 }
 
+func (h *Handler) createInvites(w http.ResponseWriter, r *http.Request, reqs []CreateInviteRequest, user *User) {
+	resp, err := h.service.CreateInvites(r.Context(), reqs, user)
+	if err != nil {
+		var bulkErr *BulkInviteError
+		if errors.As(err, &bulkErr) {
+			respondJSON(w, http.StatusBadRequest, BulkInviteErrorResponse{Error: "some invites are invalid; none were created", Rows: bulkErr.Rows})
+			return
+		}
+		if errors.Is(err, ErrNoInvites) || errors.Is(err, ErrTooManyInvites) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, ErrSeatLimitReached) {
+			respondError(w, http.StatusPaymentRequired, "organization has reached its user limit")
+			return
+		}
+		log.Printf("create invites error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to create invites")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// parseInviteCSV reads email,role rows, skipping a leading header row and
+// blank lines. Rows missing a role are kept so that they are reported.
+func parseInviteCSV(body io.Reader) ([]CreateInviteRequest, error) {
+	reader := csv.NewReader(io.LimitReader(body, maxInviteCSVBytes))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "email") {
+		records = records[1:]
+	}
+
+	reqs := make([]CreateInviteRequest, 0, len(records))
+	for _, record := range records {
+		req := CreateInviteRequest{Email: strings.TrimSpace(record[0])}
+		if len(record) > 1 {
+			req.Role = Role(strings.ToLower(strings.TrimSpace(record[1])))
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
 // ListInvites lists pending invites.
 //
 //	@Summary		List invites
@@ -631,6 +941,7 @@ func (h *Handler) CancelInvite(w http.ResponseWriter, r *http.Request) {
 //	@Param			token	query		string	true	"Invite token"
 //	@Success		200		{object}	ValidateInviteResponse
 //	@Failure		400		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse	"Too many failed attempts"
 //	@Router			/auth/invites/validate [get]
 func (h *Handler) ValidateInvite(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
@@ -645,6 +956,9 @@ func (h *Handler) ValidateInvite(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to validate invite")
 		return
 	}
+	if !resp.Valid {
+		platformmw.ReportFailure(r.Context())
+	}
 
 	respondJSON(w, http.StatusOK, resp)
 }
@@ -660,34 +974,19 @@ func (h *Handler) ValidateInvite(w http.ResponseWriter, r *http.Request) {
 //	@Success		201		{object}	MessageResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		409		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse	"Too many failed attempts"
 //	@Router			/auth/invites/accept [post]
 func (h *Handler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
 	var req AcceptInviteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Token == "" {
-		respondError(w, http.StatusBadRequest, "token is required")
-		return
-	}
-	if req.Name == "" {
-		respondError(w, http.StatusBadRequest, "name is required")
-		return
-	}
-	if req.Password == "" {
-		respondError(w, http.StatusBadRequest, "password is required")
-		return
-	}
-	if len(req.Password) < 8 {
-		respondError(w, http.StatusBadRequest, "password must be at least 8 characters")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
 	_, err := h.service.AcceptInvite(r.Context(), req)
 	if err != nil {
 		if errors.Is(err, ErrInviteNotFound) {
+			platformmw.ReportFailure(r.Context())
 			respondError(w, http.StatusBadRequest, "invalid invite token")
 			return
 		}
@@ -768,17 +1067,8 @@ func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req UpdateUserRoleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Role == "" {
-		respondError(w, http.StatusBadRequest, "role is required")
-		return
-	}
-	if req.Role != RoleAdmin && req.Role != RoleEditor && req.Role != RoleViewer {
-		respondError(w, http.StatusBadRequest, "invalid role")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -860,6 +1150,17 @@ func (h *Handler) redirectWithOAuthResult(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if result.PendingLink != nil {
+		// Redirect to the page confirming the link to the existing account
+		redirectURL := h.service.GetAppURL() + "/auth/link-account" +
+			"?token=" + url.QueryEscape(result.PendingLink.Token) +
+			"&email=" + url.QueryEscape(result.PendingLink.Email) +
+			"&provider=" + url.QueryEscape(result.PendingLink.Provider) +
+			"&hasPassword=" + strconv.FormatBool(result.PendingLink.HasPassword)
+		http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
+		return
+	}
+
 	// Existing user - redirect with auth
 	h.redirectWithAuth(w, r, result.AuthResponse)
 }
@@ -884,6 +1185,212 @@ func generateOAuthState() string {
 	return hex.EncodeToString(bytes)
 }
 
+// ListDomains lists the organization's email domains.
+//
+//	@Summary		List domains
+//	@Description	List the organization's email domains with their verification status
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	ListDomainsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/domains [get]
+func (h *Handler) ListDomains(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domains, err := h.service.ListDomains(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("list domains error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list domains")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListDomainsResponse{Domains: domains})
+}
+
+// AddDomain adds an email domain to the organization.
+//
+//	@Summary		Add domain
+//	@Description	Add an email domain to the organization. Publish the returned verificationRecord as a TXT record on the domain, then verify it; from then on, new users registering with that domain join the organization with the default role. Public email providers are rejected.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		AddDomainRequest	true	"Domain data"
+//	@Success		201		{object}	OrganizationDomain
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/domains [post]
+func (h *Handler) AddDomain(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req AddDomainRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	d, err := h.service.AddDomain(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		respondDomainError(w, err, "add domain", "failed to add domain")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, d)
+}
+
+// VerifyDomain checks a domain's verification TXT record.
+//
+//	@Summary		Verify domain
+//	@Description	Look up the domain's TXT records and enable auto-join if the verification record is published. DNS changes can take a while to propagate; retry if verification fails.
+//	@Tags			auth
+//	@Produce		json
+//	@Param			id	path		string	true	"Domain ID"
+//	@Success		200	{object}	OrganizationDomain
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/domains/{id}/verify [post]
+func (h *Handler) VerifyDomain(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid domain id")
+		return
+	}
+
+	d, err := h.service.VerifyDomain(r.Context(), user.OrganizationID, domainID)
+	if err != nil {
+		respondDomainError(w, err, "verify domain", "failed to verify domain")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, d)
+}
+
+// UpdateDomain changes a domain's default role.
+//
+//	@Summary		Update domain
+//	@Description	Change the role given to new users joining through the domain
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Domain ID"
+//	@Param			request	body		UpdateDomainRequest	true	"Domain data"
+//	@Success		200		{object}	OrganizationDomain
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/domains/{id} [patch]
+func (h *Handler) UpdateDomain(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid domain id")
+		return
+	}
+
+	var req UpdateDomainRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	d, err := h.service.UpdateDomain(r.Context(), user.OrganizationID, domainID, req)
+	if err != nil {
+		respondDomainError(w, err, "update domain", "failed to update domain")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, d)
+}
+
+// RemoveDomain removes an email domain from the organization.
+//
+//	@Summary		Remove domain
+//	@Description	Remove an email domain; new users with it no longer join the organization. Existing members are unaffected.
+//	@Tags			auth
+//	@Produce		json
+//	@Param			id	path		string	true	"Domain ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Security		BearerAuth
+//	@Router			/auth/domains/{id} [delete]
+func (h *Handler) RemoveDomain(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid domain id")
+		return
+	}
+
+	if err := h.service.RemoveDomain(r.Context(), user.OrganizationID, domainID); err != nil {
+		respondDomainError(w, err, "remove domain", "failed to remove domain")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "Domain removed"})
+}
+
+// respondDomainError writes the response for an error from a domain
+// operation, logging unexpected errors under op.
+func respondDomainError(w http.ResponseWriter, err error, op, message string) {
+	switch {
+	case errors.Is(err, ErrDomainNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrDomainExists), errors.Is(err, ErrDomainClaimed):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		for _, target := range domainValidationErrors {
+			if errors.Is(err, target) {
+				respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		log.Printf("%s error: %v", op, err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
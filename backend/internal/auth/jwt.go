@@ -36,6 +36,11 @@ type Claims struct {
 	Email          string `json:"email"`
 	OrganizationID string `json:"organizationId"`
 	Role           string `json:"role"`
+
+	// Set on tokens issued to an instance operator impersonating the user
+	Impersonated    bool   `json:"impersonated,omitempty"`
+	ImpersonationID string `json:"impersonationId,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
@@ -65,6 +70,29 @@ func (j *JWTService) GenerateToken(userID uuid.UUID, email string, organizationI
 	return token.SignedString(j.secret)
 }
 
+// GenerateImpersonationToken generates a token for an impersonation session.
+// The token is flagged as impersonated and expires with the session.
+func (j *JWTService) GenerateImpersonationToken(userID uuid.UUID, email string, organizationID uuid.UUID, role Role, sessionID uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := &Claims{
+		UserID:          userID.String(),
+		Email:           email,
+		OrganizationID:  organizationID.String(),
+		Role:            string(role),
+		Impersonated:    true,
+		ImpersonationID: sessionID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "litekpi",
+			Subject:   userID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secret)
+}
+
 // ValidateToken validates a JWT token and returns the claims.
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
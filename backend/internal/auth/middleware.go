@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"strings"
 
@@ -10,7 +11,10 @@ import (
 
 type contextKey string
 
-const UserContextKey contextKey = "user"
+const (
+	UserContextKey          contextKey = "user"
+	ImpersonationContextKey contextKey = "impersonation"
+)
 
 // AuthMiddleware creates a middleware that validates JWT tokens.
 func AuthMiddleware(jwt *JWTService, repo *Repository) func(http.Handler) http.Handler {
@@ -47,8 +51,39 @@ func AuthMiddleware(jwt *JWTService, repo *Repository) func(http.Handler) http.H
 				return
 			}
 
+			// Reject members of organizations disabled by the instance operator
+			disabled, err := repo.IsOrganizationDisabled(r.Context(), user.OrganizationID)
+			if err != nil {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			if disabled {
+				http.Error(w, `{"error":"organization disabled"}`, http.StatusForbidden)
+				return
+			}
+
+			ctx := r.Context()
+
+			// Impersonation tokens stop working as soon as the session ends,
+			// and every request made with one is logged
+			if claims.Impersonated {
+				sessionID, err := uuid.Parse(claims.ImpersonationID)
+				if err != nil {
+					http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+					return
+				}
+				active, err := repo.IsImpersonationActive(ctx, sessionID, user.ID)
+				if err != nil || !active {
+					http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+					return
+				}
+				log.Printf("impersonation %s: %s %s as user %s", sessionID, r.Method, r.URL.Path, user.ID)
+				w.Header().Set("X-Impersonation-Session", sessionID.String())
+				ctx = context.WithValue(ctx, ImpersonationContextKey, sessionID)
+			}
+
 			// Add user to context
-			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			ctx = context.WithValue(ctx, UserContextKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -60,6 +95,16 @@ func UserFromContext(ctx context.Context) *User {
 	return user
 }
 
+// ImpersonationFromContext returns the impersonation session of the request,
+// or nil if the user is not being impersonated.
+func ImpersonationFromContext(ctx context.Context) *uuid.UUID {
+	sessionID, ok := ctx.Value(ImpersonationContextKey).(uuid.UUID)
+	if !ok {
+		return nil
+	}
+	return &sessionID
+}
+
 // AdminMiddleware creates a middleware that requires admin role.
 func AdminMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
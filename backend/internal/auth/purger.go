@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const (
+	tokenPurgeInterval = time.Hour
+
+	// expiredTokenGrace keeps expired tokens for a while, so that following
+	// an expired link says so rather than counting as a guess.
+	expiredTokenGrace = 7 * 24 * time.Hour
+)
+
+// TokenPurger deletes expired email verification tokens, password reset
+// tokens and OAuth link requests, so that no token outlives its use.
+type TokenPurger struct {
+	service *Service
+}
+
+// NewTokenPurger creates a new expired token purger.
+func NewTokenPurger(service *Service) *TokenPurger {
+	return &TokenPurger{service: service}
+}
+
+// Job returns the scheduled job that purges expired tokens.
+func (p *TokenPurger) Job() jobs.Job {
+	return jobs.Job{Name: "auth_token_purge", Interval: tokenPurgeInterval, Run: p.RunOnce}
+}
+
+// RunOnce purges the tokens that expired more than expiredTokenGrace ago.
+func (p *TokenPurger) RunOnce(ctx context.Context) error {
+	n, err := p.service.PurgeExpiredTokens(ctx, time.Now().Add(-expiredTokenGrace))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("purged %d expired auth tokens", n)
+	}
+	return nil
+}
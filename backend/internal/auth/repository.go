@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -20,6 +22,15 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
+// hashToken returns the form in which verification, password reset and
+// invite tokens are stored and looked up. Lookups by hash take the same time
+// however much of a guessed token is right, and the stored hashes cannot be
+// used as tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // CreateOrganization creates a new organization.
 func (r *Repository) CreateOrganization(ctx context.Context, name string) (*Organization, error) {
 	org := &Organization{
@@ -45,9 +56,9 @@ func (r *Repository) CreateOrganization(ctx context.Context, name string) (*Orga
 func (r *Repository) GetOrganizationByID(ctx context.Context, id uuid.UUID) (*Organization, error) {
 	org := &Organization{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, name, created_at, updated_at FROM organizations WHERE id = $1`,
+		`SELECT id, name, disabled_at, created_at, updated_at FROM organizations WHERE id = $1`,
 		id,
-	).Scan(&org.ID, &org.Name, &org.CreatedAt, &org.UpdatedAt)
+	).Scan(&org.ID, &org.Name, &org.DisabledAt, &org.CreatedAt, &org.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -59,6 +70,34 @@ func (r *Repository) GetOrganizationByID(ctx context.Context, id uuid.UUID) (*Or
 	return org, nil
 }
 
+// IsImpersonationActive reports whether an impersonation session of a user
+// has neither expired nor been ended.
+func (r *Repository) IsImpersonationActive(ctx context.Context, sessionID, userID uuid.UUID) (bool, error) {
+	var active bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS(
+			SELECT 1 FROM impersonation_sessions
+			WHERE id = $1 AND user_id = $2 AND ended_at IS NULL AND expires_at > NOW()
+		)`,
+		sessionID, userID,
+	).Scan(&active)
+	return active, err
+}
+
+// IsOrganizationDisabled reports whether an instance operator has disabled the organization.
+func (r *Repository) IsOrganizationDisabled(ctx context.Context, id uuid.UUID) (bool, error) {
+	var disabled bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT disabled_at IS NOT NULL FROM organizations WHERE id = $1`,
+		id,
+	).Scan(&disabled)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return disabled, err
+}
+
 // CreateUserWithOrg creates a new organization and user in a single transaction.
 func (r *Repository) CreateUserWithOrg(ctx context.Context, email, name string, passwordHash *string, orgName string) (*User, error) {
 	tx, err := r.pool.Begin(ctx)
@@ -144,10 +183,10 @@ func (r *Repository) CreateUser(ctx context.Context, email, name string, passwor
 func (r *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	user := &User{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, email, name, password_hash, email_verified, organization_id, role, created_at, updated_at
+		`SELECT id, email, name, password_hash, email_verified, organization_id, role, language, created_at, updated_at
 		FROM users WHERE id = $1`,
 		id,
-	).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.EmailVerified, &user.OrganizationID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.EmailVerified, &user.OrganizationID, &user.Role, &user.Language, &user.CreatedAt, &user.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -163,10 +202,10 @@ func (r *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (*User, erro
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	user := &User{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, email, name, password_hash, email_verified, organization_id, role, created_at, updated_at
+		`SELECT id, email, name, password_hash, email_verified, organization_id, role, language, created_at, updated_at
 		FROM users WHERE email = $1`,
 		email,
-	).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.EmailVerified, &user.OrganizationID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.EmailVerified, &user.OrganizationID, &user.Role, &user.Language, &user.CreatedAt, &user.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -182,15 +221,15 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, e
 func (r *Repository) GetUserWithOrganization(ctx context.Context, id uuid.UUID) (*User, error) {
 	user := &User{Organization: &Organization{}}
 	err := r.pool.QueryRow(ctx,
-		`SELECT u.id, u.email, u.name, u.password_hash, u.email_verified, u.organization_id, u.role, u.created_at, u.updated_at,
-		        o.id, o.name, o.created_at, o.updated_at
+		`SELECT u.id, u.email, u.name, u.password_hash, u.email_verified, u.organization_id, u.role, u.language, u.created_at, u.updated_at,
+		        o.id, o.name, o.disabled_at, o.created_at, o.updated_at
 		FROM users u
 		JOIN organizations o ON u.organization_id = o.id
 		WHERE u.id = $1`,
 		id,
 	).Scan(
-		&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.EmailVerified, &user.OrganizationID, &user.Role, &user.CreatedAt, &user.UpdatedAt,
-		&user.Organization.ID, &user.Organization.Name, &user.Organization.CreatedAt, &user.Organization.UpdatedAt,
+		&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.EmailVerified, &user.OrganizationID, &user.Role, &user.Language, &user.CreatedAt, &user.UpdatedAt,
+		&user.Organization.ID, &user.Organization.Name, &user.Organization.DisabledAt, &user.Organization.CreatedAt, &user.Organization.UpdatedAt,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -233,14 +272,16 @@ func (r *Repository) CreatePendingOAuthAccount(ctx context.Context, provider, pr
 		CreatedAt:      time.Now(),
 	}
 
-	_, err := r.pool.Exec(ctx,
+	// An earlier attempt may have created the account; return its ID
+	err := r.pool.QueryRow(ctx,
 		`INSERT INTO oauth_accounts (id, user_id, provider, provider_user_id, provider_email, provider_name, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		ON CONFLICT (provider, provider_user_id) DO UPDATE SET
 			provider_email = EXCLUDED.provider_email,
-			provider_name = EXCLUDED.provider_name`,
+			provider_name = EXCLUDED.provider_name
+		RETURNING id, created_at`,
 		account.ID, account.UserID, account.Provider, account.ProviderUserID, account.ProviderEmail, account.ProviderName, account.CreatedAt,
-	)
+	).Scan(&account.ID, &account.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -248,16 +289,6 @@ func (r *Repository) CreatePendingOAuthAccount(ctx context.Context, provider, pr
 	return account, nil
 }
 
-// CreateOAuthAccount creates a new OAuth account linked to a user.
-func (r *Repository) CreateOAuthAccount(ctx context.Context, account *OAuthAccount) error {
-	_, err := r.pool.Exec(ctx,
-		`INSERT INTO oauth_accounts (id, user_id, provider, provider_user_id, provider_email, provider_name, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		account.ID, account.UserID, account.Provider, account.ProviderUserID, account.ProviderEmail, account.ProviderName, account.CreatedAt,
-	)
-	return err
-}
-
 // GetOAuthAccount retrieves an OAuth account by provider and provider user ID.
 func (r *Repository) GetOAuthAccount(ctx context.Context, provider, providerUserID string) (*OAuthAccount, error) {
 	account := &OAuthAccount{}
@@ -305,13 +336,149 @@ func (r *Repository) LinkOAuthAccountToUser(ctx context.Context, oauthID, userID
 	return err
 }
 
+// UpsertOAuthLinkRequest creates the request to link a pending OAuth account
+// to a user, replacing an earlier request for the account.
+func (r *Repository) UpsertOAuthLinkRequest(ctx context.Context, oauthID, userID uuid.UUID, token string, expiresAt time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO oauth_link_requests (oauth_account_id, user_id, token, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (oauth_account_id) DO UPDATE
+		SET user_id = EXCLUDED.user_id,
+		    token = EXCLUDED.token,
+		    email_token = NULL,
+		    expires_at = EXCLUDED.expires_at,
+		    created_at = NOW()`,
+		oauthID, userID, hashToken(token), expiresAt,
+	)
+	return err
+}
+
+// GetOAuthLinkRequest retrieves an OAuth link request by ID.
+func (r *Repository) GetOAuthLinkRequest(ctx context.Context, id uuid.UUID) (*OAuthLinkRequest, error) {
+	return r.getOAuthLinkRequest(ctx, `l.id = $1`, id)
+}
+
+// GetOAuthLinkRequestByToken retrieves an OAuth link request by the token
+// given to the browser that signed in.
+func (r *Repository) GetOAuthLinkRequestByToken(ctx context.Context, token string) (*OAuthLinkRequest, error) {
+	return r.getOAuthLinkRequest(ctx, `l.token = $1`, hashToken(token))
+}
+
+// GetOAuthLinkRequestByEmailToken retrieves an OAuth link request by the
+// token emailed to the account owner.
+func (r *Repository) GetOAuthLinkRequestByEmailToken(ctx context.Context, token string) (*OAuthLinkRequest, error) {
+	return r.getOAuthLinkRequest(ctx, `l.email_token = $1`, hashToken(token))
+}
+
+func (r *Repository) getOAuthLinkRequest(ctx context.Context, where string, arg any) (*OAuthLinkRequest, error) {
+	l := &OAuthLinkRequest{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT l.id, l.oauth_account_id, l.user_id, a.provider, a.provider_email, COALESCE(a.provider_name, ''), l.expires_at, l.created_at
+		FROM oauth_link_requests l
+		JOIN oauth_accounts a ON a.id = l.oauth_account_id
+		WHERE `+where,
+		arg,
+	).Scan(&l.ID, &l.OAuthAccountID, &l.UserID, &l.Provider, &l.ProviderEmail, &l.ProviderName, &l.ExpiresAt, &l.CreatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// ListOAuthLinkRequestsByUser lists the unexpired OAuth link requests of a
+// user, newest first.
+func (r *Repository) ListOAuthLinkRequestsByUser(ctx context.Context, userID uuid.UUID) ([]OAuthLinkRequest, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT l.id, l.oauth_account_id, l.user_id, a.provider, a.provider_email, COALESCE(a.provider_name, ''), l.expires_at, l.created_at
+		FROM oauth_link_requests l
+		JOIN oauth_accounts a ON a.id = l.oauth_account_id
+		WHERE l.user_id = $1 AND l.expires_at > NOW()
+		ORDER BY l.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []OAuthLinkRequest{}
+	for rows.Next() {
+		var l OAuthLinkRequest
+		if err := rows.Scan(&l.ID, &l.OAuthAccountID, &l.UserID, &l.Provider, &l.ProviderEmail, &l.ProviderName, &l.ExpiresAt, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		requests = append(requests, l)
+	}
+
+	return requests, rows.Err()
+}
+
+// SetOAuthLinkEmailToken sets the token emailed to the account owner of an
+// OAuth link request, replacing an earlier one.
+func (r *Repository) SetOAuthLinkEmailToken(ctx context.Context, id uuid.UUID, token string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE oauth_link_requests SET email_token = $2 WHERE id = $1`,
+		id, hashToken(token),
+	)
+	return err
+}
+
+// DeleteOAuthLinkRequest deletes an OAuth link request.
+func (r *Repository) DeleteOAuthLinkRequest(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM oauth_link_requests WHERE id = $1`, id)
+	return err
+}
+
+// CompleteOAuthLink links the OAuth account of a request to its user, marks
+// the user's email verified and deletes the request, in one transaction.
+// With clearPassword, the user's password is removed. It returns
+// ErrOAuthLinkNotFound if the account was linked in the meantime.
+func (r *Repository) CompleteOAuthLink(ctx context.Context, l *OAuthLinkRequest, clearPassword bool) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE oauth_accounts SET user_id = $1 WHERE id = $2 AND user_id IS NULL`,
+		l.UserID, l.OAuthAccountID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOAuthLinkNotFound
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET email_verified = true,
+		    password_hash = CASE WHEN $2 THEN NULL ELSE password_hash END
+		WHERE id = $1`,
+		l.UserID, clearPassword,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM oauth_link_requests WHERE id = $1`, l.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 // CreateEmailVerificationToken creates a new email verification token.
 func (r *Repository) CreateEmailVerificationToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error {
 	id := uuid.New()
 	_, err := r.pool.Exec(ctx,
 		`INSERT INTO email_verification_tokens (id, user_id, token, expires_at, created_at)
 		VALUES ($1, $2, $3, $4, $5)`,
-		id, userID, token, expiresAt, time.Now(),
+		id, userID, hashToken(token), expiresAt, time.Now(),
 	)
 	return err
 }
@@ -322,7 +489,7 @@ func (r *Repository) GetEmailVerificationToken(ctx context.Context, token string
 	err := r.pool.QueryRow(ctx,
 		`SELECT id, user_id, token, expires_at, created_at
 		FROM email_verification_tokens WHERE token = $1`,
-		token,
+		hashToken(token),
 	).Scan(&evt.ID, &evt.UserID, &evt.Token, &evt.ExpiresAt, &evt.CreatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -350,7 +517,7 @@ func (r *Repository) CreatePasswordResetToken(ctx context.Context, userID uuid.U
 	_, err := r.pool.Exec(ctx,
 		`INSERT INTO password_reset_tokens (id, user_id, token, expires_at, created_at)
 		VALUES ($1, $2, $3, $4, $5)`,
-		id, userID, token, expiresAt, time.Now(),
+		id, userID, hashToken(token), expiresAt, time.Now(),
 	)
 	return err
 }
@@ -361,7 +528,7 @@ func (r *Repository) GetPasswordResetToken(ctx context.Context, token string) (*
 	err := r.pool.QueryRow(ctx,
 		`SELECT id, user_id, token, expires_at, used, created_at
 		FROM password_reset_tokens WHERE token = $1`,
-		token,
+		hashToken(token),
 	).Scan(&prt.ID, &prt.UserID, &prt.Token, &prt.ExpiresAt, &prt.Used, &prt.CreatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -374,11 +541,11 @@ func (r *Repository) GetPasswordResetToken(ctx context.Context, token string) (*
 	return prt, nil
 }
 
-// MarkPasswordResetTokenUsed marks a password reset token as used.
-func (r *Repository) MarkPasswordResetTokenUsed(ctx context.Context, id uuid.UUID) error {
+// MarkPasswordResetTokensUsed marks all password reset tokens for a user as used.
+func (r *Repository) MarkPasswordResetTokensUsed(ctx context.Context, userID uuid.UUID) error {
 	_, err := r.pool.Exec(ctx,
-		`UPDATE password_reset_tokens SET used = true WHERE id = $1`,
-		id,
+		`UPDATE password_reset_tokens SET used = true WHERE user_id = $1`,
+		userID,
 	)
 	return err
 }
@@ -392,6 +559,21 @@ func (r *Repository) DeletePasswordResetTokensByUserID(ctx context.Context, user
 	return err
 }
 
+// DeleteExpiredTokens deletes the email verification tokens, password reset
+// tokens and OAuth link requests that expired before the given time, and
+// returns how many it deleted.
+func (r *Repository) DeleteExpiredTokens(ctx context.Context, before time.Time) (int64, error) {
+	var total int64
+	for _, table := range []string{"email_verification_tokens", "password_reset_tokens", "oauth_link_requests"} {
+		tag, err := r.pool.Exec(ctx, `DELETE FROM `+table+` WHERE expires_at < $1`, before)
+		if err != nil {
+			return total, err
+		}
+		total += tag.RowsAffected()
+	}
+	return total, nil
+}
+
 // CreateInvite creates a new invite.
 func (r *Repository) CreateInvite(ctx context.Context, orgID uuid.UUID, email string, role Role, token string, invitedBy uuid.UUID, expiresAt time.Time) (*Invite, error) {
 	invite := &Invite{
@@ -408,7 +590,7 @@ func (r *Repository) CreateInvite(ctx context.Context, orgID uuid.UUID, email st
 	_, err := r.pool.Exec(ctx,
 		`INSERT INTO invites (id, organization_id, email, role, token, invited_by, expires_at, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		invite.ID, invite.OrganizationID, invite.Email, invite.Role, invite.Token, invite.InvitedBy, invite.ExpiresAt, invite.CreatedAt,
+		invite.ID, invite.OrganizationID, invite.Email, invite.Role, hashToken(invite.Token), invite.InvitedBy, invite.ExpiresAt, invite.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -417,13 +599,35 @@ func (r *Repository) CreateInvite(ctx context.Context, orgID uuid.UUID, email st
 	return invite, nil
 }
 
+// CreateInvites creates several invites in a single transaction.
+func (r *Repository) CreateInvites(ctx context.Context, invites []Invite) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, invite := range invites {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO invites (id, organization_id, email, role, token, invited_by, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			invite.ID, invite.OrganizationID, invite.Email, invite.Role, hashToken(invite.Token), invite.InvitedBy, invite.ExpiresAt, invite.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
 // GetInviteByToken retrieves an invite by its token.
 func (r *Repository) GetInviteByToken(ctx context.Context, token string) (*Invite, error) {
 	invite := &Invite{}
 	err := r.pool.QueryRow(ctx,
 		`SELECT id, organization_id, email, role, token, invited_by, expires_at, accepted_at, created_at
 		FROM invites WHERE token = $1`,
-		token,
+		hashToken(token),
 	).Scan(&invite.ID, &invite.OrganizationID, &invite.Email, &invite.Role, &invite.Token, &invite.InvitedBy, &invite.ExpiresAt, &invite.AcceptedAt, &invite.CreatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -531,7 +735,7 @@ func (r *Repository) DeleteInvite(ctx context.Context, id uuid.UUID) error {
 // ListUsersByOrg retrieves all users for an organization.
 func (r *Repository) ListUsersByOrg(ctx context.Context, orgID uuid.UUID) ([]User, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, email, name, password_hash, email_verified, organization_id, role, created_at, updated_at
+		`SELECT id, email, name, password_hash, email_verified, organization_id, role, language, created_at, updated_at
 		FROM users WHERE organization_id = $1
 		ORDER BY created_at ASC`,
 		orgID,
@@ -546,7 +750,7 @@ func (r *Repository) ListUsersByOrg(ctx context.Context, orgID uuid.UUID) ([]Use
 		var user User
 		if err := rows.Scan(
 			&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.EmailVerified,
-			&user.OrganizationID, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+			&user.OrganizationID, &user.Role, &user.Language, &user.CreatedAt, &user.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -560,6 +764,15 @@ func (r *Repository) ListUsersByOrg(ctx context.Context, orgID uuid.UUID) ([]Use
 	return users, nil
 }
 
+// UpdateUserLanguage sets the language of a user's emails.
+func (r *Repository) UpdateUserLanguage(ctx context.Context, id uuid.UUID, language *string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE users SET language = $2, updated_at = NOW() WHERE id = $1`,
+		id, language,
+	)
+	return err
+}
+
 // UpdateUserRole updates a user's role.
 func (r *Repository) UpdateUserRole(ctx context.Context, id uuid.UUID, role Role) error {
 	_, err := r.pool.Exec(ctx,
@@ -587,3 +800,121 @@ func (r *Repository) CountAdmins(ctx context.Context, orgID uuid.UUID) (int, err
 	).Scan(&count)
 	return count, err
 }
+
+const domainColumns = `id, organization_id, domain, default_role, verification_token, verified_at, created_at`
+
+// CreateDomain adds an unverified email domain to an organization.
+func (r *Repository) CreateDomain(ctx context.Context, d *OrganizationDomain) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO organization_domains (`+domainColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		d.ID, d.OrganizationID, d.Domain, d.DefaultRole, d.VerificationToken, d.VerifiedAt, d.CreatedAt,
+	)
+	return err
+}
+
+// GetDomainByID retrieves an organization domain by ID.
+func (r *Repository) GetDomainByID(ctx context.Context, id uuid.UUID) (*OrganizationDomain, error) {
+	d, err := scanDomain(r.pool.QueryRow(ctx,
+		`SELECT `+domainColumns+` FROM organization_domains WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetOrganizationDomain retrieves a domain of an organization by name.
+func (r *Repository) GetOrganizationDomain(ctx context.Context, orgID uuid.UUID, domain string) (*OrganizationDomain, error) {
+	d, err := scanDomain(r.pool.QueryRow(ctx,
+		`SELECT `+domainColumns+` FROM organization_domains WHERE organization_id = $1 AND domain = $2`,
+		orgID, domain,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetVerifiedDomain retrieves the verified domain of the given name, if an
+// enabled organization has one.
+func (r *Repository) GetVerifiedDomain(ctx context.Context, domain string) (*OrganizationDomain, error) {
+	d, err := scanDomain(r.pool.QueryRow(ctx,
+		`SELECT d.id, d.organization_id, d.domain, d.default_role, d.verification_token, d.verified_at, d.created_at
+		FROM organization_domains d
+		JOIN organizations o ON o.id = d.organization_id
+		WHERE d.domain = $1 AND d.verified_at IS NOT NULL AND o.disabled_at IS NULL`,
+		domain,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListDomains retrieves the email domains of an organization.
+func (r *Repository) ListDomains(ctx context.Context, orgID uuid.UUID) ([]OrganizationDomain, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+domainColumns+` FROM organization_domains WHERE organization_id = $1 ORDER BY domain`,
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []OrganizationDomain
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, *d)
+	}
+
+	return domains, rows.Err()
+}
+
+// MarkDomainVerified records that ownership of a domain was verified.
+func (r *Repository) MarkDomainVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE organization_domains SET verified_at = $1 WHERE id = $2`,
+		verifiedAt, id,
+	)
+	return err
+}
+
+// UpdateDomainDefaultRole changes the role given to users joining through a domain.
+func (r *Repository) UpdateDomainDefaultRole(ctx context.Context, id uuid.UUID, role Role) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE organization_domains SET default_role = $1 WHERE id = $2`,
+		role, id,
+	)
+	return err
+}
+
+// DeleteDomain removes an organization domain.
+func (r *Repository) DeleteDomain(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM organization_domains WHERE id = $1`, id)
+	return err
+}
+
+func scanDomain(row pgx.Row) (*OrganizationDomain, error) {
+	d := &OrganizationDomain{}
+	err := row.Scan(&d.ID, &d.OrganizationID, &d.Domain, &d.DefaultRole, &d.VerificationToken, &d.VerifiedAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.VerificationRecord = domainVerificationPrefix + d.VerificationToken
+	return d, nil
+}
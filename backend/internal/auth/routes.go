@@ -6,21 +6,28 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-// RegisterRoutes registers all auth routes.
-func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+// RegisterRoutes registers all auth routes. Routes that take a verification,
+// password reset, invite or OAuth link token are limited with tokenLimit,
+// which throttles clients guessing tokens.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware, tokenLimit func(next http.Handler) http.Handler) {
 	r.Route("/auth", func(r chi.Router) {
 		// Public routes
 		r.Post("/register", h.Register)
 		r.Post("/login", h.Login)
-		r.Post("/verify-email", h.VerifyEmail)
+		r.With(tokenLimit).Post("/verify-email", h.VerifyEmail)
 		r.Post("/forgot-password", h.ForgotPassword)
-		r.Post("/reset-password", h.ResetPassword)
+		r.With(tokenLimit).Post("/reset-password", h.ResetPassword)
 		r.Post("/resend-verification", h.ResendVerification)
 		r.Post("/complete-oauth-setup", h.CompleteOAuthSetup)
 
+		// Public routes confirming the link of an OAuth login to an existing account
+		r.With(tokenLimit).Post("/oauth-link/confirm", h.ConfirmOAuthLink)
+		r.With(tokenLimit).Post("/oauth-link/email", h.SendOAuthLinkEmail)
+		r.With(tokenLimit).Post("/oauth-link/verify", h.VerifyOAuthLink)
+
 		// Public invite routes
-		r.Get("/invites/validate", h.ValidateInvite)
-		r.Post("/invites/accept", h.AcceptInvite)
+		r.With(tokenLimit).Get("/invites/validate", h.ValidateInvite)
+		r.With(tokenLimit).Post("/invites/accept", h.AcceptInvite)
 
 		// OAuth routes
 		r.Get("/google", h.GoogleAuth)
@@ -32,19 +39,25 @@ func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Han
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware)
 			r.Get("/me", h.Me)
+			r.Put("/me/language", h.UpdateLanguage)
+			r.Get("/me/oauth-links", h.ListOAuthLinks)
+			r.Post("/me/oauth-links/{id}/approve", h.ApproveOAuthLink)
+			r.Delete("/me/oauth-links/{id}", h.RejectOAuthLink)
 			r.Post("/logout", h.Logout)
 			r.Get("/email-config", h.GetEmailConfig)
 			r.Get("/users", h.ListUsers)
 
 			// Admin-only routes
-			r.Group(func(r chi.Router) {
-				r.Use(AdminMiddleware)
-				r.Post("/invites", h.CreateInvite)
-				r.Get("/invites", h.ListInvites)
-				r.Delete("/invites/{id}", h.CancelInvite)
-				r.Patch("/users/{id}/role", h.UpdateUserRole)
-				r.Delete("/users/{id}", h.RemoveUser)
-			})
+			r.Post("/invites", h.CreateInvite)
+			r.Get("/invites", h.ListInvites)
+			r.Delete("/invites/{id}", h.CancelInvite)
+			r.Patch("/users/{id}/role", h.UpdateUserRole)
+			r.Delete("/users/{id}", h.RemoveUser)
+			r.Get("/domains", h.ListDomains)
+			r.Post("/domains", h.AddDomain)
+			r.Post("/domains/{id}/verify", h.VerifyDomain)
+			r.Patch("/domains/{id}", h.UpdateDomain)
+			r.Delete("/domains/{id}", h.RemoveDomain)
 		})
 	})
 }
@@ -8,7 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"golang.org/x/oauth2/google"
 
 	"github.com/devbydaniel/litekpi/internal/platform/config"
+	"github.com/devbydaniel/litekpi/internal/platform/email"
 )
 
 var (
@@ -29,6 +32,7 @@ var (
 	ErrTokenExpired       = errors.New("token has expired")
 	ErrTokenUsed          = errors.New("token has already been used")
 	ErrOAuthAccountNotFound = errors.New("oauth account not found")
+	ErrInvalidLanguage    = errors.New("unsupported language")
 )
 
 const (
@@ -38,22 +42,51 @@ const (
 	tokenLength              = 32
 )
 
+// SeatChecker reports whether an organization may add more members.
+// It is implemented by the usage service; auth cannot import it directly
+// because usage-facing handlers depend on this package.
+type SeatChecker interface {
+	HasSeatsAvailable(ctx context.Context, orgID uuid.UUID, n int64) (bool, error)
+}
+
+// WriteRecorder records writes to an organization's invites, users and
+// domains. It is implemented by the write audit; auth cannot import it
+// directly because the write audit depends on this package.
+type WriteRecorder interface {
+	RecordWrite(ctx context.Context, entityType string, entityID uuid.UUID, action string, before, after any)
+}
+
+// Entity types and actions of the writes auth records.
+const (
+	auditEntityInvite = "invite"
+	auditEntityUser   = "user"
+	auditEntityDomain = "organization_domain"
+
+	auditCreate = "create"
+	auditUpdate = "update"
+	auditDelete = "delete"
+)
+
 // Service handles authentication business logic.
 type Service struct {
 	repo        *Repository
 	jwt         *JWTService
 	email       *AuthEmailer
+	seats       SeatChecker
+	audit       WriteRecorder
 	googleOAuth *oauth2.Config
 	githubOAuth *oauth2.Config
 	appURL      string
 }
 
 // NewService creates a new auth service.
-func NewService(repo *Repository, jwt *JWTService, email *AuthEmailer, cfg *config.Config) *Service {
+func NewService(repo *Repository, jwt *JWTService, email *AuthEmailer, seats SeatChecker, audit WriteRecorder, cfg *config.Config) *Service {
 	svc := &Service{
 		repo:   repo,
 		jwt:    jwt,
 		email:  email,
+		seats:  seats,
+		audit:  audit,
 		appURL: strings.TrimSuffix(cfg.AppURL, "/"),
 	}
 
@@ -100,8 +133,11 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*User, err
 	}
 	passwordHash := string(hashedPassword)
 
-	// Create organization and user in a transaction
-	user, err := s.repo.CreateUserWithOrg(ctx, strings.ToLower(req.Email), req.Name, &passwordHash, req.OrganizationName)
+	// Join the organization owning the email domain, or create one
+	user, err := s.createUser(ctx, strings.ToLower(req.Email), req.Name, &passwordHash, req.OrganizationName)
+	if errors.Is(err, ErrOrganizationNameRequired) || errors.Is(err, ErrSeatLimitReached) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -138,6 +174,15 @@ func (s *Service) Login(ctx context.Context, req LoginRequest) (*AuthResponse, e
 		return nil, ErrEmailNotVerified
 	}
 
+	// Check organization status
+	disabled, err := s.repo.IsOrganizationDisabled(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check organization: %w", err)
+	}
+	if disabled {
+		return nil, ErrOrganizationDisabled
+	}
+
 	// Generate token
 	token, err := s.jwt.GenerateToken(user.ID, user.Email, user.OrganizationID, user.Role)
 	if err != nil {
@@ -178,11 +223,15 @@ func (s *Service) SendVerificationEmail(ctx context.Context, userID uuid.UUID) e
 	}
 
 	// Send email
-	return s.email.SendVerificationEmail(user.Email, token)
+	return s.email.SendVerificationEmail(ctx, user.OrganizationID, user.Email, user.language(), token)
 }
 
 // VerifyEmail verifies a user's email using a token.
 func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	if !isWellFormedToken(token) {
+		return ErrInvalidToken
+	}
+
 	evt, err := s.repo.GetEmailVerificationToken(ctx, token)
 	if err != nil {
 		return fmt.Errorf("failed to get token: %w", err)
@@ -253,11 +302,15 @@ func (s *Service) RequestPasswordReset(ctx context.Context, email string) error
 	}
 
 	// Send email
-	return s.email.SendPasswordResetEmail(user.Email, token)
+	return s.email.SendPasswordResetEmail(ctx, user.OrganizationID, user.Email, user.language(), token)
 }
 
 // ResetPassword resets a user's password using a token.
 func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if !isWellFormedToken(token) {
+		return ErrInvalidToken
+	}
+
 	prt, err := s.repo.GetPasswordResetToken(ctx, token)
 	if err != nil {
 		return fmt.Errorf("failed to get token: %w", err)
@@ -287,9 +340,9 @@ func (s *Service) ResetPassword(ctx context.Context, token, newPassword string)
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
-	// Mark token as used
-	if err := s.repo.MarkPasswordResetTokenUsed(ctx, prt.ID); err != nil {
-		return fmt.Errorf("failed to mark token used: %w", err)
+	// Mark this and any other outstanding reset tokens as used
+	if err := s.repo.MarkPasswordResetTokensUsed(ctx, prt.UserID); err != nil {
+		return fmt.Errorf("failed to mark tokens used: %w", err)
 	}
 
 	// Also verify email if not already verified
@@ -332,6 +385,8 @@ type OAuthResult struct {
 	AuthResponse *AuthResponse
 	// If user needs setup, PendingSetup is set
 	PendingSetup *OAuthPendingSetupResponse
+	// If the email belongs to an account that has to confirm the link, PendingLink is set
+	PendingLink *OAuthPendingLinkResponse
 }
 
 // HandleGoogleCallback processes the Google OAuth callback.
@@ -507,40 +562,13 @@ func (s *Service) handleOAuthUser(ctx context.Context, provider string, userInfo
 	}
 
 	if existingUser != nil {
-		// Link OAuth account to existing user
-		newOAuthAccount := &OAuthAccount{
-			ID:             uuid.New(),
-			UserID:         &existingUser.ID,
-			Provider:       provider,
-			ProviderUserID: userInfo.ID,
-			ProviderEmail:  userInfo.Email,
-			ProviderName:   userInfo.Name,
-			CreatedAt:      time.Now(),
-		}
-		if err := s.repo.CreateOAuthAccount(ctx, newOAuthAccount); err != nil {
-			return nil, fmt.Errorf("failed to create oauth account: %w", err)
-		}
-
-		// Mark email as verified (OAuth provider verified it)
-		if !existingUser.EmailVerified {
-			if err := s.repo.UpdateUserEmailVerified(ctx, existingUser.ID, true); err != nil {
-				return nil, fmt.Errorf("failed to verify email: %w", err)
-			}
-			existingUser.EmailVerified = true
-		}
-
-		// Generate JWT token
-		jwtToken, err := s.jwt.GenerateToken(existingUser.ID, existingUser.Email, existingUser.OrganizationID, existingUser.Role)
+		// Matching emails alone must not grant access to the account, so
+		// the owner has to confirm the link first
+		pending, err := s.requestOAuthLink(ctx, provider, userInfo, oauthAccount, existingUser)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate token: %w", err)
+			return nil, err
 		}
-
-		return &OAuthResult{
-			AuthResponse: &AuthResponse{
-				User:  *existingUser,
-				Token: jwtToken,
-			},
-		}, nil
+		return &OAuthResult{PendingLink: pending}, nil
 	}
 
 	// New OAuth user - create pending OAuth account and require setup
@@ -565,7 +593,8 @@ func (s *Service) handleOAuthUser(ctx context.Context, provider string, userInfo
 	}, nil
 }
 
-// CompleteOAuthSetup finishes OAuth registration by creating org and user.
+// CompleteOAuthSetup finishes OAuth registration by creating the user and
+// either joining the organization owning the email domain or creating one.
 func (s *Service) CompleteOAuthSetup(ctx context.Context, req CompleteOAuthSetupRequest) (*AuthResponse, error) {
 	// Validate setup token
 	oauthAccountID, err := s.jwt.ValidateOAuthSetupToken(req.Token)
@@ -596,8 +625,12 @@ func (s *Service) CompleteOAuthSetup(ctx context.Context, req CompleteOAuthSetup
 		return nil, ErrEmailAlreadyExists
 	}
 
-	// Create organization and user (no password for OAuth users)
-	user, err := s.repo.CreateUserWithOrg(ctx, strings.ToLower(oauthAccount.ProviderEmail), req.Name, nil, req.OrganizationName)
+	// Join the organization owning the email domain, or create one (no
+	// password for OAuth users)
+	user, err := s.createUser(ctx, strings.ToLower(oauthAccount.ProviderEmail), req.Name, nil, req.OrganizationName)
+	if errors.Is(err, ErrOrganizationNameRequired) || errors.Is(err, ErrSeatLimitReached) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -625,16 +658,273 @@ func (s *Service) CompleteOAuthSetup(ctx context.Context, req CompleteOAuthSetup
 	}, nil
 }
 
+const oauthLinkExpiry = 1 * time.Hour
+
+var (
+	ErrOAuthLinkNotFound  = errors.New("oauth link request not found")
+	ErrEmailNotConfigured = errors.New("email is not configured")
+)
+
+// oauthProviderNames are the display names of the OAuth providers.
+var oauthProviderNames = map[string]string{
+	"google": "Google",
+	"github": "GitHub",
+}
+
+// requestOAuthLink creates a request to link an OAuth identity to the
+// existing user with its email, and returns the token with which the browser
+// that signed in can confirm it.
+func (s *Service) requestOAuthLink(ctx context.Context, provider string, userInfo *OAuthUserInfo, oauthAccount *OAuthAccount, user *User) (*OAuthPendingLinkResponse, error) {
+	if oauthAccount == nil {
+		var err error
+		oauthAccount, err = s.repo.CreatePendingOAuthAccount(ctx, provider, userInfo.ID, userInfo.Email, userInfo.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pending oauth account: %w", err)
+		}
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	if err := s.repo.UpsertOAuthLinkRequest(ctx, oauthAccount.ID, user.ID, token, time.Now().Add(oauthLinkExpiry)); err != nil {
+		return nil, fmt.Errorf("failed to create oauth link request: %w", err)
+	}
+
+	return &OAuthPendingLinkResponse{
+		PendingLink: true,
+		Token:       token,
+		Email:       user.Email,
+		Provider:    provider,
+		HasPassword: user.PasswordHash != nil,
+	}, nil
+}
+
+// ConfirmOAuthLink links an OAuth identity to the existing account with its
+// email after checking the account's password, and logs the user in.
+func (s *Service) ConfirmOAuthLink(ctx context.Context, req ConfirmOAuthLinkRequest) (*AuthResponse, error) {
+	link, err := s.oauthLinkByToken(ctx, req.Token, s.repo.GetOAuthLinkRequestByToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, link.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || user.PasswordHash == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.completeOAuthLink(ctx, link, false); err != nil {
+		return nil, err
+	}
+	return s.oauthLinkLogin(ctx, link.UserID)
+}
+
+// SendOAuthLinkEmail emails the owner of the account an OAuth login matched a
+// link with which to confirm linking it, for accounts without a password or
+// users who don't remember it.
+func (s *Service) SendOAuthLinkEmail(ctx context.Context, token string) error {
+	if !s.email.IsEnabled() {
+		return ErrEmailNotConfigured
+	}
+
+	link, err := s.oauthLinkByToken(ctx, token, s.repo.GetOAuthLinkRequestByToken)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, link.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return ErrOAuthLinkNotFound
+	}
+
+	emailToken, err := generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	if err := s.repo.SetOAuthLinkEmailToken(ctx, link.ID, emailToken); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	provider := oauthProviderNames[link.Provider]
+	return s.email.SendOAuthLinkEmail(ctx, user.OrganizationID, user.Email, user.language(), provider, emailToken)
+}
+
+// VerifyOAuthLink links an OAuth identity to the existing account with its
+// email using the token emailed to the account owner, and logs the user in.
+func (s *Service) VerifyOAuthLink(ctx context.Context, token string) (*AuthResponse, error) {
+	link, err := s.oauthLinkByToken(ctx, token, s.repo.GetOAuthLinkRequestByEmailToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, link.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrOAuthLinkNotFound
+	}
+
+	// The password of an unverified account was chosen by whoever signed up
+	// with the email, who need not be its owner; remove it so that only the
+	// owner can sign in
+	if err := s.completeOAuthLink(ctx, link, !user.EmailVerified); err != nil {
+		return nil, err
+	}
+	return s.oauthLinkLogin(ctx, link.UserID)
+}
+
+// ListOAuthLinks lists the pending requests to link OAuth identities to a user.
+func (s *Service) ListOAuthLinks(ctx context.Context, userID uuid.UUID) ([]OAuthLinkRequest, error) {
+	requests, err := s.repo.ListOAuthLinkRequestsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth link requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ApproveOAuthLink links the OAuth identity of a pending request to the
+// logged-in user it belongs to.
+func (s *Service) ApproveOAuthLink(ctx context.Context, id uuid.UUID, user *User) error {
+	link, err := s.userOAuthLink(ctx, id, user)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(link.ExpiresAt) {
+		return ErrTokenExpired
+	}
+
+	return s.completeOAuthLink(ctx, link, false)
+}
+
+// RejectOAuthLink deletes a pending request to link an OAuth identity to the
+// logged-in user it belongs to.
+func (s *Service) RejectOAuthLink(ctx context.Context, id uuid.UUID, user *User) error {
+	link, err := s.userOAuthLink(ctx, id, user)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteOAuthLinkRequest(ctx, link.ID); err != nil {
+		return fmt.Errorf("failed to delete oauth link request: %w", err)
+	}
+	return nil
+}
+
+// oauthLinkByToken looks up an unexpired OAuth link request by a token.
+func (s *Service) oauthLinkByToken(ctx context.Context, token string, lookup func(ctx context.Context, token string) (*OAuthLinkRequest, error)) (*OAuthLinkRequest, error) {
+	if !isWellFormedToken(token) {
+		return nil, ErrInvalidToken
+	}
+
+	link, err := lookup(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth link request: %w", err)
+	}
+	if link == nil {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().After(link.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+	return link, nil
+}
+
+// userOAuthLink looks up an OAuth link request of a user.
+func (s *Service) userOAuthLink(ctx context.Context, id uuid.UUID, user *User) (*OAuthLinkRequest, error) {
+	link, err := s.repo.GetOAuthLinkRequest(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth link request: %w", err)
+	}
+	if link == nil || link.UserID != user.ID {
+		return nil, ErrOAuthLinkNotFound
+	}
+	return link, nil
+}
+
+// completeOAuthLink links the OAuth identity of a confirmed request.
+func (s *Service) completeOAuthLink(ctx context.Context, link *OAuthLinkRequest, clearPassword bool) error {
+	if err := s.repo.CompleteOAuthLink(ctx, link, clearPassword); err != nil {
+		if errors.Is(err, ErrOAuthLinkNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to link oauth account: %w", err)
+	}
+	return nil
+}
+
+// oauthLinkLogin logs in the user of a confirmed OAuth link.
+func (s *Service) oauthLinkLogin(ctx context.Context, userID uuid.UUID) (*AuthResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	jwtToken, err := s.jwt.GenerateToken(user.ID, user.Email, user.OrganizationID, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &AuthResponse{
+		User:  *user,
+		Token: jwtToken,
+	}, nil
+}
+
 // GetUserByID retrieves a user by ID.
 func (s *Service) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	return s.repo.GetUserByID(ctx, id)
 }
 
+// IssueImpersonationToken generates a token for an impersonation session of an
+// existing user without credentials. It is used by the instance admin API;
+// callers are responsible for authorizing, recording and auditing the session.
+func (s *Service) IssueImpersonationToken(ctx context.Context, userID, sessionID uuid.UUID, expiresAt time.Time) (*AuthResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	token, err := s.jwt.GenerateImpersonationToken(user.ID, user.Email, user.OrganizationID, user.Role, sessionID, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &AuthResponse{
+		User:  *user,
+		Token: token,
+	}, nil
+}
+
 // GetAppURL returns the configured app URL.
 func (s *Service) GetAppURL() string {
 	return s.appURL
 }
 
+// PurgeExpiredTokens deletes the email verification tokens, password reset
+// tokens and OAuth link requests that expired before the given time.
+func (s *Service) PurgeExpiredTokens(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.repo.DeleteExpiredTokens(ctx, before)
+	if err != nil {
+		return n, fmt.Errorf("failed to delete expired tokens: %w", err)
+	}
+	return n, nil
+}
+
 func generateSecureToken() (string, error) {
 	bytes := make([]byte, tokenLength)
 	if _, err := rand.Read(bytes); err != nil {
@@ -643,6 +933,17 @@ func generateSecureToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// isWellFormedToken reports whether token could have been generated by
+// generateSecureToken, so that malformed guesses are turned away without a
+// database lookup.
+func isWellFormedToken(token string) bool {
+	if len(token) != hex.EncodedLen(tokenLength) {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil
+}
+
 // Middleware returns the auth middleware function.
 func (s *Service) Middleware(next http.Handler) http.Handler {
 	return AuthMiddleware(s.jwt, s.repo)(next)
@@ -659,8 +960,21 @@ var (
 	ErrCannotRemoveSelf   = errors.New("cannot remove yourself")
 	ErrUserAlreadyExists  = errors.New("user with this email already exists")
 	ErrPendingInviteExists = errors.New("a pending invite already exists for this email")
+	ErrSeatLimitReached    = errors.New("organization has reached its user limit")
+	ErrOrganizationDisabled = errors.New("organization is disabled")
+	ErrNoInvites            = errors.New("at least one invite is required")
+	ErrTooManyInvites       = fmt.Errorf("at most %d invites can be created at once", MaxBulkInvites)
 )
 
+// BulkInviteError reports the rows of a bulk invite that could not be created.
+type BulkInviteError struct {
+	Rows []BulkInviteRowError
+}
+
+func (e *BulkInviteError) Error() string {
+	return fmt.Sprintf("%d invites are invalid", len(e.Rows))
+}
+
 // IsEmailEnabled returns whether email is configured.
 func (s *Service) IsEmailEnabled() bool {
 	return s.email.IsEnabled()
@@ -688,6 +1002,11 @@ func (s *Service) CreateInvite(ctx context.Context, req CreateInviteRequest, inv
 		return nil, ErrPendingInviteExists
 	}
 
+	// Pending invites occupy a seat, so check the user quota up front
+	if err := s.checkSeatsAvailable(ctx, inviter.OrganizationID, 1); err != nil {
+		return nil, err
+	}
+
 	// Generate token
 	token, err := generateSecureToken()
 	if err != nil {
@@ -700,23 +1019,143 @@ func (s *Service) CreateInvite(ctx context.Context, req CreateInviteRequest, inv
 	if err != nil {
 		return nil, fmt.Errorf("failed to create invite: %w", err)
 	}
+	s.audit.RecordWrite(ctx, auditEntityInvite, invite.ID, auditCreate, nil, invite)
+
+	resps, err := s.deliverInvites(ctx, []Invite{*invite}, inviter)
+	if err != nil {
+		return nil, err
+	}
+	return &resps[0], nil
+}
+
+// CreateInvites creates several invites in one transaction. Every row is
+// validated first; if any is invalid, no invite is created and a
+// *BulkInviteError lists the failing rows.
+func (s *Service) CreateInvites(ctx context.Context, reqs []CreateInviteRequest, inviter *User) (*BulkInviteResponse, error) {
+	if len(reqs) == 0 {
+		return nil, ErrNoInvites
+	}
+	if len(reqs) > MaxBulkInvites {
+		return nil, ErrTooManyInvites
+	}
+
+	var rowErrs []BulkInviteRowError
+	seen := make(map[string]bool, len(reqs))
+	invites := make([]Invite, 0, len(reqs))
+	expiresAt := time.Now().Add(inviteExpiry)
+	for i, req := range reqs {
+		email := strings.ToLower(strings.TrimSpace(req.Email))
+		fail := func(msg string) {
+			rowErrs = append(rowErrs, BulkInviteRowError{Row: i + 1, Email: email, Error: msg})
+		}
+
+		switch {
+		case email == "":
+			fail("email is required")
+			continue
+		case req.Role == "":
+			fail("role is required")
+			continue
+		case req.Role != RoleAdmin && req.Role != RoleEditor && req.Role != RoleViewer:
+			fail("invalid role")
+			continue
+		case seen[email]:
+			fail("email appears more than once")
+			continue
+		}
+		seen[email] = true
+
+		existingUser, err := s.repo.GetUserByEmail(ctx, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing user: %w", err)
+		}
+		if existingUser != nil {
+			fail(ErrUserAlreadyExists.Error())
+			continue
+		}
+		existingInvite, err := s.repo.GetPendingInviteByEmail(ctx, inviter.OrganizationID, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing invite: %w", err)
+		}
+		if existingInvite != nil {
+			fail(ErrPendingInviteExists.Error())
+			continue
+		}
+
+		token, err := generateSecureToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate token: %w", err)
+		}
+		invites = append(invites, Invite{
+			ID:             uuid.New(),
+			OrganizationID: inviter.OrganizationID,
+			Email:          email,
+			Role:           req.Role,
+			Token:          token,
+			InvitedBy:      inviter.ID,
+			ExpiresAt:      expiresAt,
+			CreatedAt:      time.Now(),
+		})
+	}
+	if len(rowErrs) > 0 {
+		return nil, &BulkInviteError{Rows: rowErrs}
+	}
+
+	if err := s.checkSeatsAvailable(ctx, inviter.OrganizationID, int64(len(invites))); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateInvites(ctx, invites); err != nil {
+		return nil, fmt.Errorf("failed to create invites: %w", err)
+	}
+	for _, invite := range invites {
+		s.audit.RecordWrite(ctx, auditEntityInvite, invite.ID, auditCreate, nil, invite)
+	}
+
+	resps, err := s.deliverInvites(ctx, invites, inviter)
+	if err != nil {
+		return nil, err
+	}
+	return &BulkInviteResponse{Invites: resps}, nil
+}
+
+// deliverInvites emails created invites, or returns their accept URLs when
+// email is not configured.
+func (s *Service) deliverInvites(ctx context.Context, invites []Invite, inviter *User) ([]CreateInviteResponse, error) {
+	resps := make([]CreateInviteResponse, len(invites))
 
-	// Try to send email
 	if s.email.IsEnabled() {
 		org, err := s.repo.GetOrganizationByID(ctx, inviter.OrganizationID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get organization: %w", err)
 		}
-		if err := s.email.SendInviteEmail(email, token, inviter.Name, org.Name); err != nil {
-			// Log but don't fail if email fails
-			fmt.Printf("failed to send invite email: %v\n", err)
+		for i, invite := range invites {
+			if err := s.email.SendInviteEmail(ctx, inviter.OrganizationID, invite.Email, invite.Token, inviter.Name, org.Name); err != nil {
+				// Log but don't fail if email fails
+				fmt.Printf("failed to send invite email: %v\n", err)
+			}
+			resps[i] = CreateInviteResponse{Invite: invite}
 		}
-		return &CreateInviteResponse{Invite: *invite}, nil
+		return resps, nil
 	}
 
-	// Email not configured - return invite URL
-	inviteURL := fmt.Sprintf("%s/accept-invite?token=%s", s.appURL, token)
-	return &CreateInviteResponse{Invite: *invite, InviteURL: &inviteURL}, nil
+	// Email not configured - return invite URLs
+	for i, invite := range invites {
+		inviteURL := fmt.Sprintf("%s/accept-invite?token=%s", s.appURL, invite.Token)
+		resps[i] = CreateInviteResponse{Invite: invite, InviteURL: &inviteURL}
+	}
+	return resps, nil
+}
+
+func (s *Service) checkSeatsAvailable(ctx context.Context, orgID uuid.UUID, n int64) error {
+	available, err := s.seats.HasSeatsAvailable(ctx, orgID, n)
+	if err != nil {
+		return fmt.Errorf("failed to check user quota: %w", err)
+	}
+	if !available {
+		return ErrSeatLimitReached
+	}
+	return nil
 }
 
 // ListInvites lists pending invites for an organization.
@@ -750,11 +1189,16 @@ func (s *Service) CancelInvite(ctx context.Context, inviteID uuid.UUID, orgID uu
 	if err := s.repo.DeleteInvite(ctx, inviteID); err != nil {
 		return fmt.Errorf("failed to delete invite: %w", err)
 	}
+	s.audit.RecordWrite(ctx, auditEntityInvite, inviteID, auditDelete, invite, nil)
 	return nil
 }
 
 // ValidateInvite validates an invite token and returns info.
 func (s *Service) ValidateInvite(ctx context.Context, token string) (*ValidateInviteResponse, error) {
+	if !isWellFormedToken(token) {
+		return &ValidateInviteResponse{Valid: false}, nil
+	}
+
 	invite, err := s.repo.GetInviteByToken(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get invite: %w", err)
@@ -790,6 +1234,10 @@ func (s *Service) ValidateInvite(ctx context.Context, token string) (*ValidateIn
 
 // AcceptInvite accepts an invite and creates the user.
 func (s *Service) AcceptInvite(ctx context.Context, req AcceptInviteRequest) (*User, error) {
+	if !isWellFormedToken(req.Token) {
+		return nil, ErrInviteNotFound
+	}
+
 	invite, err := s.repo.GetInviteByToken(ctx, req.Token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get invite: %w", err)
@@ -852,6 +1300,30 @@ func (s *Service) ListUsers(ctx context.Context, orgID uuid.UUID) ([]User, error
 	return users, nil
 }
 
+// UpdateLanguage sets the language of the user's emails. A nil or empty
+// language clears it so the organization's language applies.
+func (s *Service) UpdateLanguage(ctx context.Context, userID uuid.UUID, language *string) (*User, error) {
+	if language != nil && *language == "" {
+		language = nil
+	}
+	if language != nil && !email.IsSupportedLanguage(*language) {
+		return nil, ErrInvalidLanguage
+	}
+
+	if err := s.repo.UpdateUserLanguage(ctx, userID, language); err != nil {
+		return nil, fmt.Errorf("failed to update language: %w", err)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
 // UpdateUserRole updates a user's role.
 func (s *Service) UpdateUserRole(ctx context.Context, userID uuid.UUID, role Role, requestingUser *User) error {
 	// Get target user
@@ -880,6 +1352,9 @@ func (s *Service) UpdateUserRole(ctx context.Context, userID uuid.UUID, role Rol
 	if err := s.repo.UpdateUserRole(ctx, userID, role); err != nil {
 		return fmt.Errorf("failed to update user role: %w", err)
 	}
+	updated := *targetUser
+	updated.Role = role
+	s.audit.RecordWrite(ctx, auditEntityUser, userID, auditUpdate, targetUser, updated)
 	return nil
 }
 
@@ -916,5 +1391,211 @@ func (s *Service) RemoveUser(ctx context.Context, userID uuid.UUID, requestingUs
 	if err := s.repo.DeleteUser(ctx, userID); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
+	s.audit.RecordWrite(ctx, auditEntityUser, userID, auditDelete, targetUser, nil)
+	return nil
+}
+
+// domainVerificationPrefix precedes the token in a domain's verification TXT record.
+const domainVerificationPrefix = "litekpi-verification="
+
+var (
+	ErrInvalidDomain            = errors.New("invalid domain")
+	ErrPublicEmailDomain        = errors.New("public email providers cannot be added as organization domains")
+	ErrInvalidDefaultRole       = errors.New("default role must be editor or viewer")
+	ErrDomainExists             = errors.New("domain has already been added")
+	ErrDomainNotFound           = errors.New("domain not found")
+	ErrDomainClaimed            = errors.New("domain is verified by another organization")
+	ErrDomainVerificationFailed = errors.New("verification TXT record not found on domain")
+	ErrOrganizationNameRequired = errors.New("organization name is required")
+)
+
+// publicEmailDomains are shared email providers whose users must never be
+// joined to one organization.
+var publicEmailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "outlook.com": true, "hotmail.com": true,
+	"live.com": true, "msn.com": true, "yahoo.com": true, "icloud.com": true, "me.com": true,
+	"aol.com": true, "proton.me": true, "protonmail.com": true, "gmx.com": true, "gmx.de": true,
+	"web.de": true, "mail.com": true, "yandex.com": true, "zoho.com": true,
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// ListDomains lists the email domains of an organization.
+func (s *Service) ListDomains(ctx context.Context, orgID uuid.UUID) ([]OrganizationDomain, error) {
+	domains, err := s.repo.ListDomains(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	if domains == nil {
+		return []OrganizationDomain{}, nil
+	}
+	return domains, nil
+}
+
+// AddDomain adds an unverified email domain to an organization. New users
+// join through it only after VerifyDomain finds its TXT record.
+func (s *Service) AddDomain(ctx context.Context, orgID uuid.UUID, req AddDomainRequest) (*OrganizationDomain, error) {
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(req.Domain)), ".")
+	if len(domain) > 253 || !domainPattern.MatchString(domain) {
+		return nil, ErrInvalidDomain
+	}
+	if publicEmailDomains[domain] {
+		return nil, ErrPublicEmailDomain
+	}
+	role := req.DefaultRole
+	if role == "" {
+		role = RoleViewer
+	}
+	if role != RoleEditor && role != RoleViewer {
+		return nil, ErrInvalidDefaultRole
+	}
+
+	existing, err := s.repo.GetOrganizationDomain(ctx, orgID, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing domain: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrDomainExists
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	d := &OrganizationDomain{
+		ID:                 uuid.New(),
+		OrganizationID:     orgID,
+		Domain:             domain,
+		DefaultRole:        role,
+		VerificationToken:  token,
+		VerificationRecord: domainVerificationPrefix + token,
+		CreatedAt:          time.Now(),
+	}
+	if err := s.repo.CreateDomain(ctx, d); err != nil {
+		return nil, fmt.Errorf("failed to create domain: %w", err)
+	}
+	s.audit.RecordWrite(ctx, auditEntityDomain, d.ID, auditCreate, nil, d)
+	return d, nil
+}
+
+// VerifyDomain checks that the domain publishes its verification TXT record
+// and, if so, enables auto-join for it.
+func (s *Service) VerifyDomain(ctx context.Context, orgID, domainID uuid.UUID) (*OrganizationDomain, error) {
+	d, err := s.getOwnedDomain(ctx, orgID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if d.VerifiedAt != nil {
+		return d, nil
+	}
+
+	claimed, err := s.repo.GetVerifiedDomain(ctx, d.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check domain: %w", err)
+	}
+	if claimed != nil {
+		return nil, ErrDomainClaimed
+	}
+
+	records, err := net.DefaultResolver.LookupTXT(ctx, d.Domain)
+	if err != nil {
+		return nil, ErrDomainVerificationFailed
+	}
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == d.VerificationRecord {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrDomainVerificationFailed
+	}
+
+	now := time.Now()
+	if err := s.repo.MarkDomainVerified(ctx, d.ID, now); err != nil {
+		return nil, fmt.Errorf("failed to verify domain: %w", err)
+	}
+	before := *d
+	d.VerifiedAt = &now
+	s.audit.RecordWrite(ctx, auditEntityDomain, d.ID, auditUpdate, before, d)
+	return d, nil
+}
+
+// UpdateDomain changes the role given to users joining through a domain.
+func (s *Service) UpdateDomain(ctx context.Context, orgID, domainID uuid.UUID, req UpdateDomainRequest) (*OrganizationDomain, error) {
+	if req.DefaultRole != RoleEditor && req.DefaultRole != RoleViewer {
+		return nil, ErrInvalidDefaultRole
+	}
+	d, err := s.getOwnedDomain(ctx, orgID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateDomainDefaultRole(ctx, d.ID, req.DefaultRole); err != nil {
+		return nil, fmt.Errorf("failed to update domain: %w", err)
+	}
+	before := *d
+	d.DefaultRole = req.DefaultRole
+	s.audit.RecordWrite(ctx, auditEntityDomain, d.ID, auditUpdate, before, d)
+	return d, nil
+}
+
+// RemoveDomain removes an email domain from an organization. Users who
+// already joined through it keep their membership.
+func (s *Service) RemoveDomain(ctx context.Context, orgID, domainID uuid.UUID) error {
+	d, err := s.getOwnedDomain(ctx, orgID, domainID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteDomain(ctx, d.ID); err != nil {
+		return fmt.Errorf("failed to delete domain: %w", err)
+	}
+	s.audit.RecordWrite(ctx, auditEntityDomain, d.ID, auditDelete, d, nil)
 	return nil
 }
+
+func (s *Service) getOwnedDomain(ctx context.Context, orgID, domainID uuid.UUID) (*OrganizationDomain, error) {
+	d, err := s.repo.GetDomainByID(ctx, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if d == nil || d.OrganizationID != orgID {
+		return nil, ErrDomainNotFound
+	}
+	return d, nil
+}
+
+// createUser creates a user for a new sign-up. Users whose email domain is
+// verified by an organization join it with the domain's default role;
+// everyone else gets a new organization named orgName.
+func (s *Service) createUser(ctx context.Context, email, name string, passwordHash *string, orgName string) (*User, error) {
+	var joinDomain *OrganizationDomain
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		d, err := s.repo.GetVerifiedDomain(ctx, email[at+1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to check organization domain: %w", err)
+		}
+		joinDomain = d
+	}
+
+	if joinDomain == nil {
+		if strings.TrimSpace(orgName) == "" {
+			return nil, ErrOrganizationNameRequired
+		}
+		return s.repo.CreateUserWithOrg(ctx, email, name, passwordHash, orgName)
+	}
+
+	if err := s.checkSeatsAvailable(ctx, joinDomain.OrganizationID, 1); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.CreateUser(ctx, email, name, passwordHash, joinDomain.OrganizationID, joinDomain.DefaultRole)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.repo.GetOrganizationByID(ctx, joinDomain.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	user.Organization = org
+	return user, nil
+}
@@ -0,0 +1,107 @@
+package branding
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+)
+
+var (
+	ErrInvalidLogoURL     = errors.New("logo URL must be an absolute http(s) URL")
+	ErrInvalidAccentColor = errors.New("accent color must be a hex color like #2563eb")
+	ErrInvalidFromName    = errors.New("from name must be at most 100 characters on a single line")
+	ErrInvalidProductName = errors.New("product name must be at most 100 characters on a single line")
+	ErrInvalidLogo        = errors.New("logo must be a PNG, JPEG, GIF or WebP image of at most 512 KB")
+	ErrInvalidTemplate    = errors.New("invalid email template")
+	ErrTemplateNotFound   = errors.New("email template not found")
+	ErrLogoNotFound       = errors.New("logo not found")
+	ErrInvalidLanguage    = errors.New("unsupported email language")
+)
+
+const (
+	maxFromNameLength    = 100 // Matches the from_name column
+	maxProductNameLength = 100 // Matches the product_name column
+	maxLogoBytes         = 512 << 10
+)
+
+// logoContentTypes are the accepted logo image types. SVG is excluded because
+// logos are served from the API origin, where scripts in an SVG would run.
+var logoContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Branding holds an organization's branding and email template overrides.
+type Branding struct {
+	OrganizationID uuid.UUID                 `json:"organizationId"`
+	LogoURL        *string                   `json:"logoUrl"` // Points to the public logo endpoint after an upload
+	AccentColor    *string                   `json:"accentColor"`
+	FromName       *string                   `json:"fromName"`
+	ProductName    *string                   `json:"productName"`    // Replaces "LiteKPI" in emails and public pages
+	EmailTemplates map[string]email.Template `json:"emailTemplates"` // Overrides keyed by template name
+	EmailLanguage  *string                   `json:"emailLanguage"`  // For members who have not chosen one; unset sends English
+	UpdatedAt      *time.Time                `json:"updatedAt,omitempty"`
+}
+
+// UpdateBrandingRequest is the request body for updating branding.
+// Omitted or empty fields reset to the defaults.
+type UpdateBrandingRequest struct {
+	LogoURL        *string                   `json:"logoUrl"`
+	AccentColor    *string                   `json:"accentColor"`
+	FromName       *string                   `json:"fromName" validate:"max=100"`
+	ProductName    *string                   `json:"productName" validate:"max=100"`
+	EmailTemplates map[string]email.Template `json:"emailTemplates"`
+	EmailLanguage  *string                   `json:"emailLanguage"`
+}
+
+// PublicBranding is the branding shown on an organization's public share
+// pages and embeds, which viewers load without signing in.
+type PublicBranding struct {
+	ProductName string  `json:"productName"`
+	LogoURL     *string `json:"logoUrl,omitempty"`
+	AccentColor string  `json:"accentColor"`
+}
+
+// Logo is an uploaded logo image.
+type Logo struct {
+	Data        []byte
+	ContentType string
+}
+
+// EmailTemplateInfo describes a built-in email template.
+type EmailTemplateInfo struct {
+	Name     string          `json:"name"`
+	Default  email.Template  `json:"default"`
+	Keys     []string        `json:"keys"` // Data keys the template may reference, e.g. {{.URL}}
+	Override *email.Template `json:"override,omitempty"`
+}
+
+// ListEmailTemplatesResponse is the response for listing email templates.
+type ListEmailTemplatesResponse struct {
+	Templates []EmailTemplateInfo `json:"templates"`
+}
+
+// PreviewEmailRequest is the request body for previewing an email template.
+// When Template is nil the saved override (or the default) is previewed, in
+// Language if set, or else the organization's email language.
+type PreviewEmailRequest struct {
+	Template *email.Template `json:"template"`
+	Language *string         `json:"language,omitempty"`
+}
+
+// PreviewEmailResponse is a rendered email.
+type PreviewEmailResponse struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,323 @@
+package branding
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// Handler handles HTTP requests for organization branding.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new branding handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetBranding handles returning the organization's branding.
+//
+//	@Summary		Get organization branding
+//	@Description	Get the organization's branding (logo, accent color, product name, email from-name) and email template overrides
+//	@Tags			organization
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Branding
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/branding [get]
+func (h *Handler) GetBranding(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.service.GetBranding(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("get branding error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, b)
+}
+
+// UpdateBranding handles replacing the organization's branding.
+//
+//	@Summary		Update organization branding
+//	@Description	Replace the organization's branding and email template overrides (admin only). The product name replaces "LiteKPI" in emails and on public share pages and embeds. Changing logoUrl away from an uploaded logo deletes the upload.
+//	@Tags			organization
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		UpdateBrandingRequest	true	"Branding"
+//	@Success		200		{object}	Branding
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/organization/branding [put]
+func (h *Handler) UpdateBranding(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateBrandingRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	b, err := h.service.UpdateBranding(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidLogoURL) || errors.Is(err, ErrInvalidAccentColor) ||
+			errors.Is(err, ErrInvalidFromName) || errors.Is(err, ErrInvalidProductName) ||
+			errors.Is(err, ErrInvalidTemplate) || errors.Is(err, ErrInvalidLanguage) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("update branding error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update branding")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, b)
+}
+
+// UploadLogo handles uploading the organization's logo.
+//
+//	@Summary		Upload organization logo
+//	@Description	Upload a PNG, JPEG, GIF or WebP logo of at most 512 KB as the raw request body (admin only). The logo URL is set to a public endpoint serving the image.
+//	@Tags			organization
+//	@Accept			image/png,image/jpeg,image/gif,image/webp
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Branding
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/branding/logo [put]
+func (h *Handler) UploadLogo(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Read one byte past the limit so that oversized uploads are rejected
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxLogoBytes+1))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	b, err := h.service.UploadLogo(r.Context(), user.OrganizationID, data)
+	if err != nil {
+		if errors.Is(err, ErrInvalidLogo) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("upload logo error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to upload logo")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, b)
+}
+
+// DeleteLogo handles removing the organization's logo.
+//
+//	@Summary		Delete organization logo
+//	@Description	Remove the organization's logo, uploaded or linked (admin only)
+//	@Tags			organization
+//	@Security		BearerAuth
+//	@Success		204
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/branding/logo [delete]
+func (h *Handler) DeleteLogo(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteLogo(r.Context(), user.OrganizationID); err != nil {
+		log.Printf("delete logo error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to delete logo")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPublicBranding handles returning the branding for public pages.
+//
+//	@Summary		Get public branding
+//	@Description	Get the product name, logo and accent color shown on an organization's public share pages and embeds. No authentication required.
+//	@Tags			organization
+//	@Produce		json
+//	@Param			id	path		string	true	"Organization ID"
+//	@Success		200	{object}	PublicBranding
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/public/organizations/{id}/branding [get]
+func (h *Handler) GetPublicBranding(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	b, err := h.service.GetPublicBranding(r.Context(), orgID)
+	if err != nil {
+		log.Printf("get public branding error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, b)
+}
+
+// GetLogo handles serving an organization's uploaded logo.
+//
+//	@Summary		Get organization logo
+//	@Description	Serve an organization's uploaded logo image. No authentication required, so that emails and public pages can show it.
+//	@Tags			organization
+//	@Produce		image/png,image/jpeg,image/gif,image/webp
+//	@Param			id	path	string	true	"Organization ID"
+//	@Success		200
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/public/organizations/{id}/logo [get]
+func (h *Handler) GetLogo(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+
+	logo, err := h.service.GetLogo(r.Context(), orgID)
+	if err != nil {
+		if errors.Is(err, ErrLogoNotFound) {
+			respondError(w, http.StatusNotFound, "logo not found")
+			return
+		}
+		log.Printf("get logo error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get logo")
+		return
+	}
+
+	w.Header().Set("Content-Type", logo.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	w.Write(logo.Data)
+}
+
+// ListEmailTemplates handles listing the email templates.
+//
+//	@Summary		List email templates
+//	@Description	Get the built-in email templates, the data keys they may reference, and the organization's overrides
+//	@Tags			organization
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	ListEmailTemplatesResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/branding/email-templates [get]
+func (h *Handler) ListEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	templates, err := h.service.ListEmailTemplates(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("list email templates error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list email templates")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListEmailTemplatesResponse{Templates: templates})
+}
+
+// PreviewEmail handles rendering an email template with sample data.
+//
+//	@Summary		Preview email template
+//	@Description	Render an email template with the organization's branding and sample data. An unsaved template, or a language to preview the built-in template in, may be supplied in the body.
+//	@Tags			organization
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			name	path		string				true	"Template name"
+//	@Param			request	body		PreviewEmailRequest	false	"Unsaved template"
+//	@Success		200		{object}	PreviewEmailResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/organization/branding/email-templates/{name}/preview [post]
+func (h *Handler) PreviewEmail(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req PreviewEmailRequest
+	if r.ContentLength != 0 {
+		if err := validate.DecodeJSON(r, &req); err != nil {
+			validate.RespondError(w, err)
+			return
+		}
+	}
+
+	var language string
+	if req.Language != nil {
+		language = *req.Language
+	}
+	preview, err := h.service.PreviewEmail(r.Context(), user.OrganizationID, chi.URLParam(r, "name"), req.Template, language)
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			respondError(w, http.StatusNotFound, "email template not found")
+			return
+		}
+		if errors.Is(err, ErrInvalidTemplate) || errors.Is(err, ErrInvalidLanguage) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("preview email error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to preview email")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preview)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,118 @@
+package branding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for organization branding.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new branding repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// GetBranding retrieves the branding of an organization.
+func (r *Repository) GetBranding(ctx context.Context, orgID uuid.UUID) (*Branding, error) {
+	b := &Branding{}
+	var templatesJSON []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT organization_id, logo_url, accent_color, from_name, product_name, email_templates, email_language, updated_at
+		FROM organization_branding WHERE organization_id = $1`,
+		orgID,
+	).Scan(&b.OrganizationID, &b.LogoURL, &b.AccentColor, &b.FromName, &b.ProductName, &templatesJSON, &b.EmailLanguage, &b.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(templatesJSON, &b.EmailTemplates); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// UpsertBranding creates or replaces the branding of an organization.
+func (r *Repository) UpsertBranding(ctx context.Context, b *Branding) error {
+	templatesJSON, err := json.Marshal(b.EmailTemplates)
+	if err != nil {
+		return err
+	}
+
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO organization_branding (organization_id, logo_url, accent_color, from_name, product_name, email_templates, email_language)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET logo_url = EXCLUDED.logo_url,
+		    accent_color = EXCLUDED.accent_color,
+		    from_name = EXCLUDED.from_name,
+		    product_name = EXCLUDED.product_name,
+		    email_templates = EXCLUDED.email_templates,
+		    email_language = EXCLUDED.email_language
+		RETURNING updated_at`,
+		b.OrganizationID, b.LogoURL, b.AccentColor, b.FromName, b.ProductName, templatesJSON, b.EmailLanguage,
+	).Scan(&b.UpdatedAt)
+}
+
+// GetLogo retrieves the uploaded logo of an organization.
+func (r *Repository) GetLogo(ctx context.Context, orgID uuid.UUID) (*Logo, error) {
+	logo := &Logo{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT logo_data, logo_content_type FROM organization_branding
+		WHERE organization_id = $1 AND logo_data IS NOT NULL`,
+		orgID,
+	).Scan(&logo.Data, &logo.ContentType)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return logo, nil
+}
+
+// SetLogo stores an uploaded logo and points the logo URL at it. A nil logo
+// removes the upload and clears the URL.
+func (r *Repository) SetLogo(ctx context.Context, orgID uuid.UUID, logo *Logo, logoURL *string) error {
+	var data []byte
+	var contentType *string
+	if logo != nil {
+		data = logo.Data
+		contentType = &logo.ContentType
+	}
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO organization_branding (organization_id, logo_url, logo_data, logo_content_type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET logo_url = EXCLUDED.logo_url,
+		    logo_data = EXCLUDED.logo_data,
+		    logo_content_type = EXCLUDED.logo_content_type`,
+		orgID, logoURL, data, contentType,
+	)
+	return err
+}
+
+// ClearLogoData removes an uploaded logo without changing the logo URL.
+func (r *Repository) ClearLogoData(ctx context.Context, orgID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE organization_branding SET logo_data = NULL, logo_content_type = NULL
+		WHERE organization_id = $1 AND logo_data IS NOT NULL`,
+		orgID,
+	)
+	return err
+}
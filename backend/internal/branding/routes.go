@@ -0,0 +1,30 @@
+package branding
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the organization branding routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/organization/branding", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// Read operations (all authenticated users)
+		r.Get("/", h.GetBranding)
+		r.Get("/email-templates", h.ListEmailTemplates)
+
+		// Write operations (admin only)
+		r.Put("/", h.UpdateBranding)
+		r.Put("/logo", h.UploadLogo)
+		r.Delete("/logo", h.DeleteLogo)
+		r.Post("/email-templates/{name}/preview", h.PreviewEmail)
+	})
+
+	// Public routes for share pages and embeds
+	r.Route("/public/organizations/{id}", func(r chi.Router) {
+		r.Get("/branding", h.GetPublicBranding)
+		r.Get("/logo", h.GetLogo)
+	})
+}
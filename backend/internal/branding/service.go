@@ -0,0 +1,295 @@
+package branding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+var accentColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// previewData is sample data used to render template previews.
+var previewData = map[string]string{
+	"URL":         "https://app.example.com/link?token=preview",
+	"OrgName":     "Acme Inc.",
+	"InviterName": "Jane Doe",
+	"ReportName":  "Weekly KPIs",
+	"Summary":     "Revenue: 12,400 (+8%)",
+	"PeriodLabel": "Jan 5, 2026",
+}
+
+// Service handles organization branding business logic.
+type Service struct {
+	repo   *Repository
+	audit  *writeaudit.Service
+	apiURL string
+}
+
+// NewService creates a new branding service. Uploaded logos are served from
+// apiURL.
+func NewService(repo *Repository, audit *writeaudit.Service, apiURL string) *Service {
+	return &Service{repo: repo, audit: audit, apiURL: strings.TrimSuffix(apiURL, "/")}
+}
+
+// GetBranding returns an organization's branding, or empty branding if none is set.
+func (s *Service) GetBranding(ctx context.Context, orgID uuid.UUID) (*Branding, error) {
+	b, err := s.repo.GetBranding(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branding: %w", err)
+	}
+	if b == nil {
+		b = &Branding{OrganizationID: orgID}
+	}
+	if b.EmailTemplates == nil {
+		b.EmailTemplates = map[string]email.Template{}
+	}
+	return b, nil
+}
+
+// UpdateBranding validates and replaces an organization's branding.
+func (s *Service) UpdateBranding(ctx context.Context, orgID uuid.UUID, req UpdateBrandingRequest) (*Branding, error) {
+	b := &Branding{
+		OrganizationID: orgID,
+		LogoURL:        emptyToNil(req.LogoURL),
+		AccentColor:    emptyToNil(req.AccentColor),
+		FromName:       emptyToNil(req.FromName),
+		ProductName:    emptyToNil(req.ProductName),
+		EmailTemplates: map[string]email.Template{},
+		EmailLanguage:  emptyToNil(req.EmailLanguage),
+	}
+
+	if b.LogoURL != nil {
+		u, err := url.Parse(*b.LogoURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, ErrInvalidLogoURL
+		}
+	}
+	if b.AccentColor != nil && !accentColorPattern.MatchString(*b.AccentColor) {
+		return nil, ErrInvalidAccentColor
+	}
+	if b.FromName != nil {
+		// Reject line breaks so the name cannot inject mail headers
+		if utf8.RuneCountInString(*b.FromName) > maxFromNameLength || strings.ContainsAny(*b.FromName, "\r\n") {
+			return nil, ErrInvalidFromName
+		}
+	}
+	if b.ProductName != nil {
+		if utf8.RuneCountInString(*b.ProductName) > maxProductNameLength || strings.ContainsAny(*b.ProductName, "\r\n") {
+			return nil, ErrInvalidProductName
+		}
+	}
+
+	if b.EmailLanguage != nil && !email.IsSupportedLanguage(*b.EmailLanguage) {
+		return nil, ErrInvalidLanguage
+	}
+
+	for name, tmpl := range req.EmailTemplates {
+		if _, ok := email.DefaultTemplate(name); !ok {
+			return nil, fmt.Errorf("%w: unknown template %q", ErrInvalidTemplate, name)
+		}
+		if strings.TrimSpace(tmpl.Subject) == "" || strings.TrimSpace(tmpl.Body) == "" {
+			return nil, fmt.Errorf("%w: %s requires a subject and body", ErrInvalidTemplate, name)
+		}
+		if err := email.ValidateTemplate(name, tmpl); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, name, err)
+		}
+		b.EmailTemplates[name] = tmpl
+	}
+
+	before, err := s.GetBranding(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertBranding(ctx, b); err != nil {
+		return nil, fmt.Errorf("failed to save branding: %w", err)
+	}
+
+	// Drop an uploaded logo once the URL no longer points at it
+	if b.LogoURL == nil || !strings.HasPrefix(*b.LogoURL, s.uploadedLogoURL(orgID)) {
+		if err := s.repo.ClearLogoData(ctx, orgID); err != nil {
+			return nil, fmt.Errorf("failed to remove uploaded logo: %w", err)
+		}
+	}
+	s.audit.Record(ctx, writeaudit.EntityBranding, orgID, writeaudit.ActionUpdate, before, b)
+
+	return b, nil
+}
+
+// UploadLogo stores a logo image and sets the organization's logo URL to the
+// public endpoint serving it.
+func (s *Service) UploadLogo(ctx context.Context, orgID uuid.UUID, data []byte) (*Branding, error) {
+	if len(data) == 0 || len(data) > maxLogoBytes {
+		return nil, ErrInvalidLogo
+	}
+	contentType := http.DetectContentType(data)
+	if !logoContentTypes[contentType] {
+		return nil, ErrInvalidLogo
+	}
+
+	// Vary the URL on every upload so that cached copies of the old logo are not shown
+	logoURL := fmt.Sprintf("%s?v=%d", s.uploadedLogoURL(orgID), time.Now().Unix())
+	before, err := s.GetBranding(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetLogo(ctx, orgID, &Logo{Data: data, ContentType: contentType}, &logoURL); err != nil {
+		return nil, fmt.Errorf("failed to save logo: %w", err)
+	}
+
+	b, err := s.GetBranding(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, writeaudit.EntityBranding, orgID, writeaudit.ActionUpdate, before, b)
+	return b, nil
+}
+
+// DeleteLogo removes the organization's logo, uploaded or linked.
+func (s *Service) DeleteLogo(ctx context.Context, orgID uuid.UUID) error {
+	before, err := s.GetBranding(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetLogo(ctx, orgID, nil, nil); err != nil {
+		return fmt.Errorf("failed to remove logo: %w", err)
+	}
+	after := *before
+	after.LogoURL = nil
+	s.audit.Record(ctx, writeaudit.EntityBranding, orgID, writeaudit.ActionUpdate, before, after)
+	return nil
+}
+
+// GetLogo returns the organization's uploaded logo.
+func (s *Service) GetLogo(ctx context.Context, orgID uuid.UUID) (*Logo, error) {
+	logo, err := s.repo.GetLogo(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logo: %w", err)
+	}
+	if logo == nil {
+		return nil, ErrLogoNotFound
+	}
+	return logo, nil
+}
+
+// GetPublicBranding returns the branding for an organization's public share
+// pages and embeds, with defaults filled in.
+func (s *Service) GetPublicBranding(ctx context.Context, orgID uuid.UUID) (*PublicBranding, error) {
+	b, err := s.GetBranding(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := &PublicBranding{
+		ProductName: email.DefaultProductName,
+		LogoURL:     b.LogoURL,
+		AccentColor: email.DefaultAccentColor,
+	}
+	if b.ProductName != nil {
+		pb.ProductName = *b.ProductName
+	}
+	if b.AccentColor != nil {
+		pb.AccentColor = *b.AccentColor
+	}
+	return pb, nil
+}
+
+// uploadedLogoURL is the public URL of an organization's uploaded logo.
+func (s *Service) uploadedLogoURL(orgID uuid.UUID) string {
+	return fmt.Sprintf("%s/api/v1/public/organizations/%s/logo", s.apiURL, orgID)
+}
+
+// ListEmailTemplates returns the built-in templates with the organization's overrides.
+func (s *Service) ListEmailTemplates(ctx context.Context, orgID uuid.UUID) ([]EmailTemplateInfo, error) {
+	b, err := s.GetBranding(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []EmailTemplateInfo
+	for _, name := range email.TemplateNames() {
+		def, _ := email.DefaultTemplate(name)
+		info := EmailTemplateInfo{Name: name, Default: def, Keys: email.TemplateKeys(name)}
+		if override, ok := b.EmailTemplates[name]; ok {
+			info.Override = &override
+		}
+		templates = append(templates, info)
+	}
+	return templates, nil
+}
+
+// PreviewEmail renders a template with the organization's branding and sample data.
+// A non-nil override is rendered instead of the saved template. An empty
+// language previews the organization's email language.
+func (s *Service) PreviewEmail(ctx context.Context, orgID uuid.UUID, name string, override *email.Template, language string) (*PreviewEmailResponse, error) {
+	if _, ok := email.DefaultTemplate(name); !ok {
+		return nil, ErrTemplateNotFound
+	}
+	if language != "" && !email.IsSupportedLanguage(language) {
+		return nil, ErrInvalidLanguage
+	}
+
+	c, err := s.EmailCustomization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		if err := email.ValidateTemplate(name, *override); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+		}
+		c.Templates[name] = *override
+	}
+
+	msg, err := email.RenderIn(name, language, c, previewData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	return &PreviewEmailResponse{Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML}, nil
+}
+
+// EmailCustomization returns the organization's branding in the form used to render emails.
+func (s *Service) EmailCustomization(ctx context.Context, orgID uuid.UUID) (*email.Customization, error) {
+	b, err := s.GetBranding(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &email.Customization{Templates: b.EmailTemplates}
+	if b.EmailLanguage != nil {
+		c.Language = *b.EmailLanguage
+	}
+	if b.LogoURL != nil {
+		c.Branding.LogoURL = *b.LogoURL
+	}
+	if b.AccentColor != nil {
+		c.Branding.AccentColor = *b.AccentColor
+	}
+	if b.FromName != nil {
+		c.Branding.FromName = *b.FromName
+	}
+	if b.ProductName != nil {
+		c.Branding.ProductName = *b.ProductName
+	}
+	return c, nil
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
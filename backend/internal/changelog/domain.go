@@ -0,0 +1,57 @@
+package changelog
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidWindow  = errors.New("from must be before to")
+	ErrWindowTooLarge = errors.New("window exceeds maximum of 90 days")
+)
+
+const (
+	defaultWindow = 7 * 24 * time.Hour
+	maxWindow     = 90 * 24 * time.Hour
+)
+
+// EntryType identifies the kind of configuration change.
+type EntryType string
+
+const (
+	EntryTypeMetricCreated         EntryType = "metric_created"
+	EntryTypeMetricEdited          EntryType = "metric_edited"
+	EntryTypeMetricDeleted         EntryType = "metric_deleted"
+	EntryTypeDashboardCreated      EntryType = "dashboard_created"
+	EntryTypeDashboardDeleted      EntryType = "dashboard_deleted"
+	EntryTypeDataSourceCreated     EntryType = "data_source_created"
+	EntryTypeTransformRulesChanged EntryType = "transform_rules_changed"
+	EntryTypeSamplingChanged       EntryType = "sampling_changed"
+)
+
+// Entry is a single configuration change.
+type Entry struct {
+	Type          EntryType  `json:"type"`
+	At            time.Time  `json:"at"`
+	ResourceID    *uuid.UUID `json:"resourceId,omitempty"` // Unset for sampling changes, which are keyed by measurement
+	Name          string     `json:"name"`                 // Metric label, dashboard, data source or measurement name
+	DashboardID   *uuid.UUID `json:"dashboardId,omitempty"`
+	DataSourceID  *uuid.UUID `json:"dataSourceId,omitempty"`
+	ChangedFields []string   `json:"changedFields,omitempty"` // Metric configuration fields changed by an edit
+	UserID        *uuid.UUID `json:"userId,omitempty"`        // Set for metric edits
+}
+
+// ChangelogResponse lists the configuration changes in a time window.
+type ChangelogResponse struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Entries []Entry           `json:"entries"` // Newest first
+	Counts  map[EntryType]int `json:"counts"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,84 @@
+package changelog
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+)
+
+// Handler handles HTTP requests for the changelog.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new changelog handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetChangelog handles listing the organization's configuration changes.
+//
+//	@Summary		Configuration changelog
+//	@Description	List configuration changes in the organization within a time window: metrics created, edited (with the changed fields) or deleted, dashboards created or deleted, data sources created, and changes to ingest transform rules and sampling rates. Use it to tell definitional shifts in numbers from organic ones. Transform rule and sampling entries record only the latest change.
+//	@Tags			changelog
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			from	query		string	false	"Window start (RFC3339, default a week before to)"
+//	@Param			to		query		string	false	"Window end, exclusive (RFC3339, default now); at most 90 days after from"
+//	@Success		200		{object}	ChangelogResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/changelog [get]
+func (h *Handler) GetChangelog(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var from, to time.Time
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid from, expected RFC3339")
+			return
+		}
+		from = t
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid to, expected RFC3339")
+			return
+		}
+		to = t
+	}
+
+	resp, err := h.service.List(r.Context(), user.OrganizationID, from, to)
+	if err != nil {
+		if errors.Is(err, ErrInvalidWindow) || errors.Is(err, ErrWindowTooLarge) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("get changelog error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get changelog")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
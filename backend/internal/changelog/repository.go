@@ -0,0 +1,116 @@
+package changelog
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles changelog queries across entities.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new changelog repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// MetricsCreated lists the metrics created in [from, to).
+func (r *Repository) MetricsCreated(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	return r.entries(ctx, EntryTypeMetricCreated,
+		`SELECT m.id, m.label, m.dashboard_id, m.data_source_id, m.created_at
+		FROM metrics m
+		JOIN dashboards d ON d.id = m.dashboard_id
+		WHERE d.organization_id = $1 AND m.created_at >= $2 AND m.created_at < $3`,
+		orgID, from, to,
+	)
+}
+
+// MetricsDeleted lists the metrics moved to the trash in [from, to).
+func (r *Repository) MetricsDeleted(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	return r.entries(ctx, EntryTypeMetricDeleted,
+		`SELECT m.id, m.label, m.dashboard_id, m.data_source_id, m.deleted_at
+		FROM metrics m
+		JOIN dashboards d ON d.id = m.dashboard_id
+		WHERE d.organization_id = $1 AND m.deleted_at >= $2 AND m.deleted_at < $3`,
+		orgID, from, to,
+	)
+}
+
+// DashboardsCreated lists the dashboards created in [from, to).
+func (r *Repository) DashboardsCreated(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	return r.entries(ctx, EntryTypeDashboardCreated,
+		`SELECT id, name, id, NULL::uuid, created_at
+		FROM dashboards
+		WHERE organization_id = $1 AND created_at >= $2 AND created_at < $3`,
+		orgID, from, to,
+	)
+}
+
+// DashboardsDeleted lists the dashboards moved to the trash in [from, to).
+func (r *Repository) DashboardsDeleted(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	return r.entries(ctx, EntryTypeDashboardDeleted,
+		`SELECT id, name, id, NULL::uuid, deleted_at
+		FROM dashboards
+		WHERE organization_id = $1 AND deleted_at >= $2 AND deleted_at < $3`,
+		orgID, from, to,
+	)
+}
+
+// DataSourcesCreated lists the data sources created in [from, to).
+func (r *Repository) DataSourcesCreated(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	return r.entries(ctx, EntryTypeDataSourceCreated,
+		`SELECT id, name, NULL::uuid, id, created_at
+		FROM data_sources
+		WHERE organization_id = $1 AND created_at >= $2 AND created_at < $3`,
+		orgID, from, to,
+	)
+}
+
+// TransformRulesChanged lists the data sources whose ingest transform rules
+// were last changed in [from, to). Only the latest change is recorded.
+func (r *Repository) TransformRulesChanged(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	return r.entries(ctx, EntryTypeTransformRulesChanged,
+		`SELECT ds.id, ds.name, NULL::uuid, ds.id, t.updated_at
+		FROM ingest_transforms t
+		JOIN data_sources ds ON ds.id = t.data_source_id
+		WHERE ds.organization_id = $1 AND t.updated_at >= $2 AND t.updated_at < $3`,
+		orgID, from, to,
+	)
+}
+
+// SamplingChanged lists the measurements whose sampling rate was last
+// changed in [from, to). Only the latest change is recorded.
+func (r *Repository) SamplingChanged(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	return r.entries(ctx, EntryTypeSamplingChanged,
+		`SELECT NULL::uuid, s.measurement_name, NULL::uuid, s.data_source_id, s.updated_at
+		FROM measurement_sampling s
+		JOIN data_sources ds ON ds.id = s.data_source_id
+		WHERE ds.organization_id = $1 AND s.updated_at >= $2 AND s.updated_at < $3`,
+		orgID, from, to,
+	)
+}
+
+// entries runs a query selecting the resource ID, name, dashboard ID, data
+// source ID and time of each change.
+func (r *Repository) entries(ctx context.Context, entryType EntryType, query string, args ...any) ([]Entry, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		e := Entry{Type: entryType}
+		if err := rows.Scan(&e.ResourceID, &e.Name, &e.DashboardID, &e.DataSourceID, &e.At); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,15 @@
+package changelog
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the changelog routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/changelog", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.GetChangelog)
+	})
+}
@@ -0,0 +1,96 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/metric"
+)
+
+// Service summarizes configuration changes across an organization.
+type Service struct {
+	repo          *Repository
+	metricService *metric.Service
+}
+
+// NewService creates a new changelog service.
+func NewService(repo *Repository, metricService *metric.Service) *Service {
+	return &Service{repo: repo, metricService: metricService}
+}
+
+// List returns the configuration changes of an organization in [from, to),
+// newest first. A zero to defaults to now and a zero from to a week before to.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID, from, to time.Time) (*ChangelogResponse, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultWindow)
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidWindow
+	}
+	if to.Sub(from) > maxWindow {
+		return nil, ErrWindowTooLarge
+	}
+
+	sources := []struct {
+		entryType EntryType
+		fn        func(context.Context, uuid.UUID, time.Time, time.Time) ([]Entry, error)
+	}{
+		{EntryTypeMetricCreated, s.repo.MetricsCreated},
+		{EntryTypeMetricEdited, s.metricEdits},
+		{EntryTypeMetricDeleted, s.repo.MetricsDeleted},
+		{EntryTypeDashboardCreated, s.repo.DashboardsCreated},
+		{EntryTypeDashboardDeleted, s.repo.DashboardsDeleted},
+		{EntryTypeDataSourceCreated, s.repo.DataSourcesCreated},
+		{EntryTypeTransformRulesChanged, s.repo.TransformRulesChanged},
+		{EntryTypeSamplingChanged, s.repo.SamplingChanged},
+	}
+
+	resp := &ChangelogResponse{
+		From:    from,
+		To:      to,
+		Entries: []Entry{},
+		Counts:  make(map[EntryType]int, len(sources)),
+	}
+	for _, source := range sources {
+		entries, err := source.fn(ctx, orgID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s changes: %w", source.entryType, err)
+		}
+		resp.Counts[source.entryType] = len(entries)
+		resp.Entries = append(resp.Entries, entries...)
+	}
+
+	sort.SliceStable(resp.Entries, func(i, j int) bool {
+		return resp.Entries[i].At.After(resp.Entries[j].At)
+	})
+	return resp, nil
+}
+
+// metricEdits lists the metric configuration updates in [from, to).
+func (s *Service) metricEdits(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]Entry, error) {
+	changes, err := s.metricService.ListConfigChanges(ctx, orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(changes))
+	for i, c := range changes {
+		entries[i] = Entry{
+			Type:          EntryTypeMetricEdited,
+			At:            c.ChangedAt,
+			ResourceID:    &c.MetricID,
+			Name:          c.Label,
+			DashboardID:   &c.DashboardID,
+			ChangedFields: c.ChangedFields,
+			UserID:        c.ChangedBy,
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,76 @@
+package comment
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrForbidden       = errors.New("only the author or an admin can modify this comment")
+	ErrBodyEmpty       = errors.New("comment body is required")
+	ErrBodyTooLong     = errors.New("comment body exceeds maximum length of 10000 characters")
+	ErrMetricNotFound  = errors.New("metric not found on this dashboard")
+	ErrParentNotFound  = errors.New("parent comment not found on this dashboard")
+	ErrInvalidMention  = errors.New("mentioned users must belong to the organization")
+	ErrTooManyMentions = errors.New("a comment can mention at most 20 users")
+)
+
+// Limits on comments.
+const (
+	MaxBodyLength = 10000
+	MaxMentions   = 20
+)
+
+// Comment is a message in a discussion thread on a dashboard, or on one of
+// its metrics when MetricID is set.
+type Comment struct {
+	ID               uuid.UUID   `json:"id"`
+	DashboardID      uuid.UUID   `json:"dashboardId"`
+	MetricID         *uuid.UUID  `json:"metricId,omitempty"` // The widget the thread is about
+	ParentID         *uuid.UUID  `json:"parentId,omitempty"` // First comment of the thread, for replies
+	AuthorID         *uuid.UUID  `json:"authorId,omitempty"` // Unset once the author's account is deleted
+	AuthorName       string      `json:"authorName"`
+	Body             string      `json:"body"`
+	MentionedUserIDs []uuid.UUID `json:"mentionedUserIds"`
+	CreatedAt        time.Time   `json:"createdAt"`
+	UpdatedAt        time.Time   `json:"updatedAt"`
+}
+
+// Thread is the first comment of a discussion with its replies, oldest first.
+type Thread struct {
+	Comment
+	Replies []Comment `json:"replies"`
+}
+
+// CreateCommentRequest is the request body for commenting on a dashboard.
+type CreateCommentRequest struct {
+	Body             string      `json:"body" validate:"required,max=10000"`
+	MetricID         *uuid.UUID  `json:"metricId,omitempty"`         // Start a thread on a metric of the dashboard
+	ParentID         *uuid.UUID  `json:"parentId,omitempty"`         // Reply to a thread; the thread's metric is kept
+	MentionedUserIDs []uuid.UUID `json:"mentionedUserIds,omitempty"` // Users of the organization to notify
+}
+
+// UpdateCommentRequest is the request body for editing a comment. Users
+// newly mentioned are notified.
+type UpdateCommentRequest struct {
+	Body             string      `json:"body" validate:"required,max=10000"`
+	MentionedUserIDs []uuid.UUID `json:"mentionedUserIds,omitempty"`
+}
+
+// ListThreadsResponse is the response for listing the threads of a dashboard.
+type ListThreadsResponse struct {
+	Threads []Thread `json:"threads"`
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
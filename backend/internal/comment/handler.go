@@ -0,0 +1,253 @@
+package comment
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/platform/httperr"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// errorResponses are the responses to the errors of the comment handlers.
+var errorResponses = httperr.Registry{
+	{Err: dashboard.ErrDashboardNotFound, Status: http.StatusNotFound, Message: "dashboard not found"},
+	{Err: dashboard.ErrUnauthorized, Status: http.StatusForbidden, Message: "unauthorized"},
+	{Err: ErrCommentNotFound, Status: http.StatusNotFound},
+	{Err: ErrForbidden, Status: http.StatusForbidden},
+	{Err: ErrBodyEmpty, Status: http.StatusBadRequest},
+	{Err: ErrBodyTooLong, Status: http.StatusBadRequest},
+	{Err: ErrMetricNotFound, Status: http.StatusBadRequest},
+	{Err: ErrParentNotFound, Status: http.StatusBadRequest},
+	{Err: ErrInvalidMention, Status: http.StatusBadRequest},
+	{Err: ErrTooManyMentions, Status: http.StatusBadRequest},
+}
+
+// Handler handles HTTP requests for comments.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new comment handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListThreads handles listing the discussion threads of a dashboard.
+//
+//	@Summary		List dashboard comments
+//	@Description	Get the discussion threads of a dashboard with their replies, oldest first. Set metricId to get only the threads on one metric.
+//	@Tags			comments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Dashboard ID"
+//	@Param			metricId	query		string	false	"Metric ID"
+//	@Success		200			{object}	ListThreadsResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/comments [get]
+func (h *Handler) ListThreads(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	var metricID *uuid.UUID
+	if v := r.URL.Query().Get("metricId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid metric ID")
+			return
+		}
+		metricID = &id
+	}
+
+	threads, err := h.service.ListThreads(r.Context(), user, dashboardID, metricID)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("list comments error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list comments")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListThreadsResponse{Threads: threads})
+}
+
+// CreateComment handles commenting on a dashboard.
+//
+//	@Summary		Create dashboard comment
+//	@Description	Start a discussion thread on a dashboard or one of its metrics, or reply to a thread with parentId. Mentioned users are notified by email unless they turned off the mentions notification category. Any member may comment.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Dashboard ID"
+//	@Param			request	body		CreateCommentRequest	true	"Comment"
+//	@Success		201		{object}	Comment
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/dashboards/{id}/comments [post]
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	var req CreateCommentRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	c, err := h.service.Create(r.Context(), user, dashboardID, req)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("create comment error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to create comment")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, c)
+}
+
+// UpdateComment handles editing a comment.
+//
+//	@Summary		Update dashboard comment
+//	@Description	Edit the body and mentions of your own comment. Users newly mentioned are notified.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string					true	"Dashboard ID"
+//	@Param			commentId	path		string					true	"Comment ID"
+//	@Param			request		body		UpdateCommentRequest	true	"Comment"
+//	@Success		200			{object}	Comment
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/comments/{commentId} [put]
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	commentID, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	var req UpdateCommentRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	c, err := h.service.Update(r.Context(), user, dashboardID, commentID, req)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("update comment error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update comment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// DeleteComment handles deleting a comment.
+//
+//	@Summary		Delete dashboard comment
+//	@Description	Delete a comment; deleting the first comment of a thread deletes its replies. Authors may delete their own comments, admins any comment.
+//	@Tags			comments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Dashboard ID"
+//	@Param			commentId	path		string	true	"Comment ID"
+//	@Success		200			{object}	MessageResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/comments/{commentId} [delete]
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	commentID, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), user, dashboardID, commentID); err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("delete comment error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to delete comment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "comment deleted"})
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
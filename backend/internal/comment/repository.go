@@ -0,0 +1,100 @@
+package comment
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for comments.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new comment repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+const selectComment = `SELECT c.id, c.dashboard_id, c.metric_id, c.parent_id, c.user_id, COALESCE(u.name, ''), c.body, c.mentioned_user_ids, c.created_at, c.updated_at
+	FROM dashboard_comments c
+	LEFT JOIN users u ON u.id = c.user_id`
+
+func scanComment(row pgx.Row) (*Comment, error) {
+	c := &Comment{}
+	err := row.Scan(&c.ID, &c.DashboardID, &c.MetricID, &c.ParentID, &c.AuthorID, &c.AuthorName, &c.Body, &c.MentionedUserIDs, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Create creates a comment.
+func (r *Repository) Create(ctx context.Context, c *Comment) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO dashboard_comments (dashboard_id, metric_id, parent_id, user_id, body, mentioned_user_ids)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`,
+		c.DashboardID, c.MetricID, c.ParentID, c.AuthorID, c.Body, c.MentionedUserIDs,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+// GetByID retrieves a comment by its ID. Returns nil if it does not exist.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Comment, error) {
+	c, err := scanComment(r.pool.QueryRow(ctx, selectComment+` WHERE c.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListByDashboard retrieves the comments of a dashboard, oldest first,
+// optionally only those on one metric.
+func (r *Repository) ListByDashboard(ctx context.Context, dashboardID uuid.UUID, metricID *uuid.UUID) ([]Comment, error) {
+	rows, err := r.pool.Query(ctx,
+		selectComment+` WHERE c.dashboard_id = $1 AND ($2::uuid IS NULL OR c.metric_id = $2)
+		ORDER BY c.created_at ASC, c.id ASC`,
+		dashboardID, metricID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// Update updates the body and mentions of a comment.
+func (r *Repository) Update(ctx context.Context, c *Comment) error {
+	return r.pool.QueryRow(ctx,
+		`UPDATE dashboard_comments SET body = $1, mentioned_user_ids = $2
+		WHERE id = $3
+		RETURNING updated_at`,
+		c.Body, c.MentionedUserIDs, c.ID,
+	).Scan(&c.UpdatedAt)
+}
+
+// Delete deletes a comment and its replies.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM dashboard_comments WHERE id = $1`, id)
+	return err
+}
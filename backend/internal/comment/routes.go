@@ -0,0 +1,19 @@
+package comment
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the dashboard comment routes. Any member may
+// comment; authors and admins manage their comments in the service.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/dashboards/{id}/comments", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.ListThreads)
+		r.Post("/", h.CreateComment)
+		r.Put("/{commentId}", h.UpdateComment)
+		r.Delete("/{commentId}", h.DeleteComment)
+	})
+}
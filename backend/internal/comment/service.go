@@ -0,0 +1,276 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/branding"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/notification"
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+)
+
+// Service handles comment business logic.
+type Service struct {
+	repo                *Repository
+	dashboardService    *dashboard.Service
+	metricService       *metric.Service
+	authService         *auth.Service
+	notificationService *notification.Service
+	brandingService     *branding.Service
+	appURL              string
+}
+
+// NewService creates a new comment service.
+func NewService(repo *Repository, dashboardService *dashboard.Service, metricService *metric.Service, authService *auth.Service, notificationService *notification.Service, brandingService *branding.Service, appURL string) *Service {
+	return &Service{
+		repo:                repo,
+		dashboardService:    dashboardService,
+		metricService:       metricService,
+		authService:         authService,
+		notificationService: notificationService,
+		brandingService:     brandingService,
+		appURL:              strings.TrimSuffix(appURL, "/"),
+	}
+}
+
+// ListThreads returns the discussion threads of a dashboard, oldest first,
+// optionally only those on one metric.
+func (s *Service) ListThreads(ctx context.Context, user *auth.User, dashboardID uuid.UUID, metricID *uuid.UUID) ([]Thread, error) {
+	if _, err := s.dashboardService.VerifyDashboardOwnership(ctx, user.OrganizationID, dashboardID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repo.ListByDashboard(ctx, dashboardID, metricID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	threads := []Thread{}
+	index := make(map[uuid.UUID]int)
+	for _, c := range comments {
+		if c.ParentID == nil {
+			index[c.ID] = len(threads)
+			threads = append(threads, Thread{Comment: c, Replies: []Comment{}})
+			continue
+		}
+		if i, ok := index[*c.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, c)
+		}
+	}
+	return threads, nil
+}
+
+// Create comments on a dashboard, starting a thread or replying to one, and
+// notifies the mentioned users.
+func (s *Service) Create(ctx context.Context, user *auth.User, dashboardID uuid.UUID, req CreateCommentRequest) (*Comment, error) {
+	body, err := validateBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.dashboardService.VerifyDashboardOwnership(ctx, user.OrganizationID, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.validateMentions(ctx, user.OrganizationID, req.MentionedUserIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Comment{
+		DashboardID:      dashboardID,
+		AuthorID:         &user.ID,
+		AuthorName:       user.Name,
+		Body:             body,
+		MentionedUserIDs: mentions,
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.repo.GetByID(ctx, *req.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent comment: %w", err)
+		}
+		if parent == nil || parent.DashboardID != dashboardID {
+			return nil, ErrParentNotFound
+		}
+		// Replies to a reply join the thread it belongs to
+		c.ParentID = &parent.ID
+		if parent.ParentID != nil {
+			c.ParentID = parent.ParentID
+		}
+		c.MetricID = parent.MetricID
+	} else if req.MetricID != nil {
+		m, err := s.metricService.GetByID(ctx, *req.MetricID)
+		if err != nil && !errors.Is(err, metric.ErrMetricNotFound) {
+			return nil, err
+		}
+		if m == nil || m.DashboardID != dashboardID {
+			return nil, ErrMetricNotFound
+		}
+		c.MetricID = &m.ID
+	}
+
+	if err := s.repo.Create(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	s.notifyMentions(ctx, user, d, c, mentions)
+	return c, nil
+}
+
+// Update edits a comment of its author and notifies the users it newly
+// mentions.
+func (s *Service) Update(ctx context.Context, user *auth.User, dashboardID, id uuid.UUID, req UpdateCommentRequest) (*Comment, error) {
+	body, err := validateBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.dashboardService.VerifyDashboardOwnership(ctx, user.OrganizationID, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.get(ctx, dashboardID, id)
+	if err != nil {
+		return nil, err
+	}
+	// Only authors edit their words; admins may delete but not rewrite them
+	if c.AuthorID == nil || *c.AuthorID != user.ID {
+		return nil, ErrForbidden
+	}
+	mentions, err := s.validateMentions(ctx, user.OrganizationID, req.MentionedUserIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []uuid.UUID
+	for _, userID := range mentions {
+		if !slices.Contains(c.MentionedUserIDs, userID) {
+			added = append(added, userID)
+		}
+	}
+
+	c.Body = body
+	c.MentionedUserIDs = mentions
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	s.notifyMentions(ctx, user, d, c, added)
+	return c, nil
+}
+
+// Delete deletes a comment and, for the first comment of a thread, its
+// replies. Authors and admins may delete comments.
+func (s *Service) Delete(ctx context.Context, user *auth.User, dashboardID, id uuid.UUID) error {
+	if _, err := s.dashboardService.VerifyDashboardOwnership(ctx, user.OrganizationID, dashboardID); err != nil {
+		return err
+	}
+	c, err := s.get(ctx, dashboardID, id)
+	if err != nil {
+		return err
+	}
+	if user.Role != auth.RoleAdmin && (c.AuthorID == nil || *c.AuthorID != user.ID) {
+		return ErrForbidden
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
+// get returns a comment of a dashboard.
+func (s *Service) get(ctx context.Context, dashboardID, id uuid.UUID) (*Comment, error) {
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	if c == nil || c.DashboardID != dashboardID {
+		return nil, ErrCommentNotFound
+	}
+	return c, nil
+}
+
+// validateMentions checks that the mentioned users belong to the
+// organization and returns them without duplicates.
+func (s *Service) validateMentions(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	mentions := []uuid.UUID{}
+	for _, id := range ids {
+		if !slices.Contains(mentions, id) {
+			mentions = append(mentions, id)
+		}
+	}
+	if len(mentions) > MaxMentions {
+		return nil, ErrTooManyMentions
+	}
+
+	for _, id := range mentions {
+		u, err := s.authService.GetUserByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get mentioned user: %w", err)
+		}
+		if u == nil || u.OrganizationID != orgID {
+			return nil, ErrInvalidMention
+		}
+	}
+	return mentions, nil
+}
+
+// notifyMentions emails the mentioned users, except the author, about a
+// comment. Failures are logged; the comment is saved either way.
+func (s *Service) notifyMentions(ctx context.Context, author *auth.User, d *dashboard.Dashboard, c *Comment, mentions []uuid.UUID) {
+	recipients := slices.DeleteFunc(slices.Clone(mentions), func(id uuid.UUID) bool { return id == author.ID })
+	if len(recipients) == 0 || !s.notificationService.IsEmailEnabled() {
+		return
+	}
+
+	custom, err := s.brandingService.EmailCustomization(ctx, author.OrganizationID)
+	if err != nil {
+		custom = nil
+	}
+	data := map[string]string{
+		"URL":           s.appURL + "/dashboards/" + d.ID.String(),
+		"AuthorName":    author.Name,
+		"DashboardName": d.Name,
+		"Comment":       c.Body,
+	}
+	msg, err := email.Render(email.TemplateMention, custom, data)
+	if err != nil && custom != nil {
+		msg, err = email.Render(email.TemplateMention, nil, data)
+	}
+	if err != nil {
+		log.Printf("render mention email error: %v", err)
+		return
+	}
+
+	for _, userID := range recipients {
+		_, err := s.notificationService.Dispatch(ctx, notification.Notification{
+			UserID:     userID,
+			Category:   notification.CategoryMentions,
+			ResourceID: &d.ID,
+			Email:      msg,
+		})
+		if err != nil {
+			log.Printf("mention email error for user %s: %v", userID, err)
+		}
+	}
+}
+
+func validateBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", ErrBodyEmpty
+	}
+	if utf8.RuneCountInString(body) > MaxBodyLength {
+		return "", ErrBodyTooLong
+	}
+	return body, nil
+}
@@ -0,0 +1,77 @@
+package computejob
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/metric"
+)
+
+var (
+	ErrJobNotFound    = errors.New("compute job not found")
+	ErrInvalidTarget  = errors.New("exactly one of dashboardId or query is required")
+	ErrTooManyJobs    = errors.New("too many unfinished compute jobs")
+	ErrDashboardEmpty = errors.New("dashboard has no metrics")
+)
+
+// Job error messages returned to clients.
+const (
+	errJobQueueFull   = "compute job queue is full"
+	errJobInterrupted = "compute job was interrupted"
+)
+
+// MaxActiveJobsPerOrg limits how many jobs an organization may have pending or running.
+const MaxActiveJobsPerOrg = 5
+
+// Status is the lifecycle state of a compute job.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Job computes a dashboard or an explorer query in the background.
+type Job struct {
+	ID             uuid.UUID            `json:"id"`
+	OrganizationID uuid.UUID            `json:"organizationId"`
+	UserID         uuid.UUID            `json:"userId"`
+	Status         Status               `json:"status"`
+	DashboardID    *uuid.UUID           `json:"dashboardId,omitempty"`
+	Query          *metric.ExploreQuery `json:"query,omitempty"`
+	Progress       Progress             `json:"progress"`
+	Result         *Result              `json:"result,omitempty"` // Set once the job succeeded
+	Error          *string              `json:"error,omitempty"`  // Set once the job failed
+	StartedAt      *time.Time           `json:"startedAt,omitempty"`
+	FinishedAt     *time.Time           `json:"finishedAt,omitempty"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	UpdatedAt      time.Time            `json:"updatedAt"`
+}
+
+// Progress counts the metrics of a job computed so far.
+type Progress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// Result holds the computed metrics of a finished job.
+// Metrics that failed individually carry their own error message.
+type Result struct {
+	Metrics []metric.ComputedMetric `json:"metrics"`
+}
+
+// CreateJobRequest is the request body for starting a compute job.
+// Exactly one of DashboardID and Query must be set.
+type CreateJobRequest struct {
+	DashboardID *uuid.UUID           `json:"dashboardId,omitempty"`
+	Query       *metric.ExploreQuery `json:"query,omitempty"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
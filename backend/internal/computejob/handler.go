@@ -0,0 +1,131 @@
+package computejob
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/platform/httperr"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// errorResponses are the responses to the errors of the compute job
+// handlers.
+var errorResponses = slices.Concat(httperr.Registry{
+	{Err: ErrJobNotFound, Status: http.StatusNotFound, Message: "compute job not found"},
+	{Err: ErrInvalidTarget, Status: http.StatusBadRequest},
+	{Err: ErrDashboardEmpty, Status: http.StatusBadRequest},
+	{Err: ErrTooManyJobs, Status: http.StatusTooManyRequests},
+	{Err: dashboard.ErrDashboardNotFound, Status: http.StatusNotFound, Message: "dashboard not found"},
+	{Err: dashboard.ErrUnauthorized, Status: http.StatusForbidden, Message: "unauthorized"},
+}, metric.QueryErrors)
+
+// Handler handles HTTP requests for compute jobs.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new compute job handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateJob handles starting a compute job.
+//
+//	@Summary		Create compute job
+//	@Description	Compute a dashboard or an explorer query in the background. Use this for expensive queries instead of the synchronous compute endpoints, then poll the job for progress and result. Results are kept for 24 hours.
+//	@Tags			compute-jobs
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateJobRequest	true	"Dashboard ID or query"
+//	@Success		202		{object}	Job
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/compute-jobs [post]
+func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateJobRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	job, err := h.service.Create(r.Context(), user, req)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("create compute job error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to create compute job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// GetJob handles polling a compute job.
+//
+//	@Summary		Get compute job
+//	@Description	Get the status and progress of a compute job started by the current user. The result is included once the job has succeeded.
+//	@Tags			compute-jobs
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	Job
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/compute-jobs/{id} [get]
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	job, err := h.service.Get(r.Context(), user, id)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("get compute job error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get compute job")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,156 @@
+package computejob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for compute jobs.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new compute job repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Create creates a new pending job.
+func (r *Repository) Create(ctx context.Context, job *Job) error {
+	var queryJSON []byte
+	if job.Query != nil {
+		var err error
+		if queryJSON, err = json.Marshal(job.Query); err != nil {
+			return err
+		}
+	}
+
+	job.ID = uuid.New()
+	job.Status = StatusPending
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO compute_jobs (id, organization_id, user_id, status, dashboard_id, query, progress_total, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		job.ID, job.OrganizationID, job.UserID, job.Status, job.DashboardID, queryJSON, job.Progress.Total, job.CreatedAt, job.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a job by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job := &Job{}
+	var queryJSON, resultJSON []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, organization_id, user_id, status, dashboard_id, query, progress_completed, progress_total,
+		        result, error, started_at, finished_at, created_at, updated_at
+		FROM compute_jobs WHERE id = $1`,
+		id,
+	).Scan(&job.ID, &job.OrganizationID, &job.UserID, &job.Status, &job.DashboardID, &queryJSON, &job.Progress.Completed, &job.Progress.Total,
+		&resultJSON, &job.Error, &job.StartedAt, &job.FinishedAt, &job.CreatedAt, &job.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if queryJSON != nil {
+		if err := json.Unmarshal(queryJSON, &job.Query); err != nil {
+			return nil, err
+		}
+	}
+	if resultJSON != nil {
+		if err := json.Unmarshal(resultJSON, &job.Result); err != nil {
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
+
+// CountActive counts the pending and running jobs of an organization.
+func (r *Repository) CountActive(ctx context.Context, orgID uuid.UUID) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM compute_jobs WHERE organization_id = $1 AND status IN ('pending', 'running')`,
+		orgID,
+	).Scan(&count)
+	return count, err
+}
+
+// MarkRunning marks a job as started.
+func (r *Repository) MarkRunning(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE compute_jobs SET status = 'running', started_at = NOW() WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+// UpdateProgress records how many metrics of a job have been computed.
+func (r *Repository) UpdateProgress(ctx context.Context, id uuid.UUID, completed int) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE compute_jobs SET progress_completed = $1 WHERE id = $2`,
+		completed, id,
+	)
+	return err
+}
+
+// Complete stores the result of a job and marks it as succeeded.
+func (r *Repository) Complete(ctx context.Context, id uuid.UUID, result *Result) error {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`UPDATE compute_jobs SET status = 'succeeded', progress_completed = progress_total, result = $1, finished_at = NOW()
+		WHERE id = $2`,
+		resultJSON, id,
+	)
+	return err
+}
+
+// Fail marks a job as failed with a client-facing message.
+func (r *Repository) Fail(ctx context.Context, id uuid.UUID, message string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE compute_jobs SET status = 'failed', error = $1, finished_at = NOW() WHERE id = $2`,
+		message, id,
+	)
+	return err
+}
+
+// FailStale fails pending or running jobs created before the given time,
+// such as jobs orphaned by a server restart.
+func (r *Repository) FailStale(ctx context.Context, before time.Time, message string) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE compute_jobs SET status = 'failed', error = $1, finished_at = NOW()
+		WHERE status IN ('pending', 'running') AND created_at < $2`,
+		message, before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteFinishedBefore deletes jobs that finished before the given time.
+func (r *Repository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM compute_jobs WHERE finished_at < $1`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
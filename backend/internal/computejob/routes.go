@@ -0,0 +1,16 @@
+package computejob
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the compute job routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/compute-jobs", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Post("/", h.CreateJob)
+		r.Get("/{id}", h.GetJob)
+	})
+}
@@ -0,0 +1,102 @@
+package computejob
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const (
+	cleanupInterval = time.Hour
+	jobRetention    = 24 * time.Hour // How long finished jobs and their results are kept
+)
+
+// Runner computes queued jobs with a fixed number of workers and removes
+// finished jobs after the retention period. Workers run on every replica, as
+// the queue is in memory; cleanup is a scheduled job.
+type Runner struct {
+	repo    *Repository
+	service *Service
+	workers int
+}
+
+// NewRunner creates a new compute job runner.
+func NewRunner(repo *Repository, service *Service, workers int) *Runner {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Runner{
+		repo:    repo,
+		service: service,
+		workers: workers,
+	}
+}
+
+// Run processes jobs until ctx is cancelled. It returns once the jobs being
+// computed have finished, failing the jobs still queued so that their
+// clients can submit them again instead of waiting for the timeout.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for {
+		select {
+		case job := <-r.service.queue:
+			if err := r.repo.Fail(context.Background(), job.id, errJobInterrupted); err != nil {
+				log.Printf("compute job %s error: %v", job.id, err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Job returns the scheduled job that cleans up after compute jobs.
+func (r *Runner) Job() jobs.Job {
+	return jobs.Job{Name: "compute_job_cleanup", Interval: cleanupInterval, Run: r.RunOnce}
+}
+
+// RunOnce fails jobs that outlived their timeout without finishing, such as
+// jobs lost in a restart, and deletes expired results.
+func (r *Runner) RunOnce(ctx context.Context) error {
+	now := time.Now()
+
+	// Allow the full timeout on top of a worker wait before giving up on a job
+	n, err := r.repo.FailStale(ctx, now.Add(-2*r.service.timeout), errJobInterrupted)
+	if err != nil {
+		return fmt.Errorf("failed to fail stale jobs: %w", err)
+	}
+	if n > 0 {
+		log.Printf("failed %d interrupted compute jobs", n)
+	}
+
+	if _, err := r.repo.DeleteFinishedBefore(ctx, now.Add(-jobRetention)); err != nil {
+		return fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	return nil
+}
+
+func (r *Runner) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-r.service.queue:
+			if err := r.service.execute(job); err != nil {
+				log.Printf("compute job %s error: %v", job.id, err)
+				_ = r.repo.Fail(context.Background(), job.id, errJobInterrupted)
+			}
+		}
+	}
+}
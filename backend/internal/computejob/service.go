@@ -0,0 +1,143 @@
+package computejob
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+)
+
+// queueSize bounds how many accepted jobs may wait for a worker.
+const queueSize = 100
+
+// queuedJob is a created job waiting for a worker.
+type queuedJob struct {
+	id      uuid.UUID
+	orgID   uuid.UUID
+	metrics []metric.Metric
+}
+
+// Service handles compute job business logic.
+type Service struct {
+	repo             *Repository
+	metricService    *metric.Service
+	dashboardService *dashboard.Service
+	timeout          time.Duration // Time allowed to compute one job
+	queue            chan queuedJob
+}
+
+// NewService creates a new compute job service.
+func NewService(repo *Repository, metricService *metric.Service, dashboardService *dashboard.Service, cfg *config.Config) *Service {
+	return &Service{
+		repo:             repo,
+		metricService:    metricService,
+		dashboardService: dashboardService,
+		timeout:          cfg.ComputeJobTimeout,
+		queue:            make(chan queuedJob, queueSize),
+	}
+}
+
+// Create validates a compute request and queues it as a job.
+// The job is computed by the Runner; clients poll Get for its progress and result.
+func (s *Service) Create(ctx context.Context, user *auth.User, req CreateJobRequest) (*Job, error) {
+	if (req.DashboardID == nil) == (req.Query == nil) {
+		return nil, ErrInvalidTarget
+	}
+
+	metrics, err := s.resolveMetrics(ctx, user.OrganizationID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.repo.CountActive(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count compute jobs: %w", err)
+	}
+	if active >= MaxActiveJobsPerOrg {
+		return nil, ErrTooManyJobs
+	}
+
+	job := &Job{
+		OrganizationID: user.OrganizationID,
+		UserID:         user.ID,
+		DashboardID:    req.DashboardID,
+		Query:          req.Query,
+		Progress:       Progress{Total: len(metrics)},
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create compute job: %w", err)
+	}
+
+	select {
+	case s.queue <- queuedJob{id: job.ID, orgID: job.OrganizationID, metrics: metrics}:
+	default:
+		_ = s.repo.Fail(ctx, job.ID, errJobQueueFull)
+		return nil, ErrTooManyJobs
+	}
+
+	return job, nil
+}
+
+// Get returns a job started by the user.
+func (s *Service) Get(ctx context.Context, user *auth.User, id uuid.UUID) (*Job, error) {
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compute job: %w", err)
+	}
+	// Jobs of other users are reported as missing
+	if job == nil || job.OrganizationID != user.OrganizationID || job.UserID != user.ID {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// resolveMetrics returns the metrics a job request computes.
+func (s *Service) resolveMetrics(ctx context.Context, orgID uuid.UUID, req CreateJobRequest) ([]metric.Metric, error) {
+	if req.Query != nil {
+		m, err := s.metricService.ExploreMetric(ctx, orgID, *req.Query)
+		if err != nil {
+			return nil, err
+		}
+		return []metric.Metric{*m}, nil
+	}
+
+	if _, err := s.dashboardService.VerifyDashboardOwnership(ctx, orgID, *req.DashboardID); err != nil {
+		return nil, err
+	}
+	metrics, err := s.metricService.GetByDashboardID(ctx, *req.DashboardID)
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, ErrDashboardEmpty
+	}
+	return metrics, nil
+}
+
+// execute computes a queued job and stores its result.
+func (s *Service) execute(job queuedJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	if err := s.repo.MarkRunning(ctx, job.id); err != nil {
+		return fmt.Errorf("failed to mark compute job running: %w", err)
+	}
+
+	computed := s.metricService.ComputeWithBudget(ctx, job.orgID, job.metrics, s.timeout, func(completed int) {
+		// Progress is informational; a failed update must not fail the job
+		_ = s.repo.UpdateProgress(ctx, job.id, completed)
+	})
+
+	// Store the result even if the budget ran out, so the caller can see
+	// which metrics completed; use a fresh context for the final write.
+	if err := s.repo.Complete(context.Background(), job.id, &Result{Metrics: computed}); err != nil {
+		return fmt.Errorf("failed to store compute job result: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // Error definitions
@@ -13,33 +15,110 @@ var (
 	ErrUnauthorized        = errors.New("unauthorized")
 	ErrDashboardNameEmpty  = errors.New("dashboard name is required")
 	ErrCannotDeleteDefault = errors.New("cannot delete default dashboard")
+	ErrPreconditionFailed  = errors.New("dashboard was modified since it was read")
+	ErrInvalidConcurrency  = errors.New("invalid compute concurrency")
+	ErrInvalidCacheTTL     = errors.New("invalid cache TTL")
+	ErrInvalidSections     = errors.New("collapsed sections must be at most 100 non-empty keys of up to 100 characters")
+	ErrInvalidTimeframe    = errors.New("preferred timeframe must be last_7_days, last_30_days, this_month, last_month or a fiscal quarter or year")
+)
+
+// Limits on personal preferences, which are stored per user and dashboard.
+const (
+	MaxCollapsedSections = 100
+	MaxSectionKeyLength  = 100
 )
 
+// preferenceTimeframes are the relative timeframes a user may prefer over
+// the ones saved on a dashboard's metrics.
+var preferenceTimeframes = map[string]bool{
+	"last_7_days":         true,
+	"last_30_days":        true,
+	"this_month":          true,
+	"last_month":          true,
+	"this_fiscal_quarter": true,
+	"last_fiscal_quarter": true,
+	"this_fiscal_year":    true,
+	"last_fiscal_year":    true,
+}
+
 // Dashboard represents a dashboard in the system.
 type Dashboard struct {
-	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	OrganizationID uuid.UUID `json:"organizationId"`
-	IsDefault      bool      `json:"isDefault"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
+	ID                 uuid.UUID `json:"id"`
+	Name               string    `json:"name"`
+	OrganizationID     uuid.UUID `json:"organizationId"`
+	IsDefault          bool      `json:"isDefault"`
+	Starred            bool      `json:"starred"`                      // Whether the requesting user starred it
+	ExternalID         *string   `json:"externalId,omitempty"`         // Client-supplied key, unique within the organization
+	ComputeConcurrency *int      `json:"computeConcurrency,omitempty"` // Metrics computed in parallel; unset computes one at a time
+	CacheTTLSeconds    *int      `json:"cacheTtlSeconds,omitempty"`    // How long computed values are reused; unset disables caching
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
+
+// TrashedDashboard is a deleted dashboard that can still be restored.
+type TrashedDashboard struct {
+	ID        uuid.UUID
+	Name      string
+	DeletedAt time.Time
 }
 
 // Request/Response types
 
 // CreateDashboardRequest is the request body for creating a dashboard.
 type CreateDashboardRequest struct {
-	Name string `json:"name"`
+	Name       string  `json:"name" validate:"required,max=255"`
+	ExternalID *string `json:"externalId,omitempty"`
 }
 
 // UpdateDashboardRequest is the request body for updating a dashboard.
 type UpdateDashboardRequest struct {
-	Name string `json:"name"`
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// UpdateComputeSettingsRequest is the request body for updating how a
+// dashboard's metrics are computed. Null restores the server default.
+type UpdateComputeSettingsRequest struct {
+	ComputeConcurrency *int `json:"computeConcurrency"` // 1 up to the server limit
+	CacheTTLSeconds    *int `json:"cacheTtlSeconds"`    // 0 disables caching; at most the server limit
+}
+
+// Preferences are a user's personal overrides of a dashboard's layout. They
+// are merged into the dashboard when that user reads it and leave the shared
+// dashboard unchanged.
+type Preferences struct {
+	CollapsedSections []string    `json:"collapsedSections"`   // Section keys chosen by the client
+	HiddenMetricIDs   []uuid.UUID `json:"hiddenMetricIds"`     // Left out when the user computes the dashboard
+	Timeframe         *string     `json:"timeframe,omitempty"` // Replaces the timeframe of every metric
+	UpdatedAt         *time.Time  `json:"updatedAt,omitempty"` // Unset until the user saves preferences
+}
+
+// Hides reports whether the preferences hide a metric.
+func (p *Preferences) Hides(metricID uuid.UUID) bool {
+	if p == nil {
+		return false
+	}
+	for _, id := range p.HiddenMetricIDs {
+		if id == metricID {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatePreferencesRequest is the request body for saving a user's
+// preferences for a dashboard. It replaces any preferences saved before.
+type UpdatePreferencesRequest struct {
+	CollapsedSections []string    `json:"collapsedSections"`
+	HiddenMetricIDs   []uuid.UUID `json:"hiddenMetricIds"`
+	Timeframe         *string     `json:"timeframe"` // A relative timeframe, fiscal ones included; null keeps each metric's own
 }
 
 // DashboardWithData is a dashboard (metrics are fetched separately via /metrics endpoints).
 type DashboardWithData struct {
-	Dashboard Dashboard `json:"dashboard"`
+	Dashboard Dashboard          `json:"dashboard"`
+	History   []writeaudit.Entry `json:"history,omitempty"` // With includeHistory=true, newest first
+
+	Preferences *Preferences `json:"preferences,omitempty"` // The requesting user's overrides
 }
 
 // ListDashboardsResponse is the response for listing dashboards.
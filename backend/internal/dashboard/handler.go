@@ -10,6 +10,11 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
+	"github.com/devbydaniel/litekpi/internal/platform/precondition"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // Handler handles HTTP requests for dashboards.
@@ -27,7 +32,7 @@ func NewHandler(service *Service) *Handler {
 // ListDashboards handles listing all dashboards for the organization.
 //
 //	@Summary		List dashboards
-//	@Description	Get all dashboards for the authenticated user's organization
+//	@Description	Get all dashboards for the authenticated user's organization, marking the ones the user starred
 //	@Tags			dashboards
 //	@Produce		json
 //	@Security		BearerAuth
@@ -42,7 +47,7 @@ func (h *Handler) ListDashboards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dashboards, err := h.service.ListDashboards(r.Context(), user.OrganizationID)
+	dashboards, err := h.service.ListDashboardsForUser(r.Context(), user.OrganizationID, user.ID)
 	if err != nil {
 		log.Printf("list dashboards error: %v", err)
 		respondError(w, http.StatusInternalServerError, "failed to list dashboards")
@@ -55,16 +60,17 @@ func (h *Handler) ListDashboards(w http.ResponseWriter, r *http.Request) {
 // GetDashboard handles getting a dashboard.
 //
 //	@Summary		Get dashboard
-//	@Description	Get a dashboard by ID. Metrics are fetched separately via /metrics endpoints.
+//	@Description	Get a dashboard by ID with the current user's preferences. Metrics are fetched separately via /metrics endpoints. With includeHistory=true, the response lists who created, changed or deleted the dashboard and which fields changed.
 //	@Tags			dashboards
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id	path		string	true	"Dashboard ID"
-//	@Success		200	{object}	DashboardWithData
-//	@Failure		401	{object}	ErrorResponse
-//	@Failure		403	{object}	ErrorResponse
-//	@Failure		404	{object}	ErrorResponse
-//	@Failure		500	{object}	ErrorResponse
+//	@Param			id				path		string	true	"Dashboard ID"
+//	@Param			includeHistory	query		bool	false	"Include the write history"
+//	@Success		200				{object}	DashboardWithData
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		403				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
 //	@Router			/dashboards/{id} [get]
 func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
@@ -94,13 +100,29 @@ func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	result.Preferences, err = h.service.GetPreferences(r.Context(), user.OrganizationID, user.ID, dashboardID)
+	if err != nil {
+		log.Printf("get dashboard preferences error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get dashboard")
+		return
+	}
+
+	if writeaudit.IncludeHistory(r) {
+		result.History, err = h.service.History(r.Context(), user.OrganizationID, dashboardID)
+		if err != nil {
+			log.Printf("get dashboard history error: %v", err)
+			respondError(w, http.StatusInternalServerError, "failed to get dashboard history")
+			return
+		}
+	}
+
 	respondJSON(w, http.StatusOK, result)
 }
 
 // GetDefaultDashboard handles getting the default dashboard.
 //
 //	@Summary		Get default dashboard
-//	@Description	Get the default dashboard. Metrics are fetched separately via /metrics endpoints.
+//	@Description	Get the default dashboard with the current user's preferences. Metrics are fetched separately via /metrics endpoints.
 //	@Tags			dashboards
 //	@Produce		json
 //	@Security		BearerAuth
@@ -122,13 +144,20 @@ func (h *Handler) GetDefaultDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	result.Preferences, err = h.service.GetPreferences(r.Context(), user.OrganizationID, user.ID, result.Dashboard.ID)
+	if err != nil {
+		log.Printf("get dashboard preferences error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get default dashboard")
+		return
+	}
+
 	respondJSON(w, http.StatusOK, result)
 }
 
 // CreateDashboard handles creating a new dashboard.
 //
 //	@Summary		Create dashboard
-//	@Description	Create a new dashboard. Requires editor or admin role.
+//	@Description	Create a new dashboard, optionally with an external ID unique within the organization. Requires editor or admin role.
 //	@Tags			dashboards
 //	@Accept			json
 //	@Produce		json
@@ -137,7 +166,9 @@ func (h *Handler) GetDefaultDashboard(w http.ResponseWriter, r *http.Request) {
 //	@Success		201		{object}	Dashboard
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		401		{object}	ErrorResponse
+//	@Failure		402		{object}	ErrorResponse	"Dashboard quota reached"
 //	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse	"External ID in use"
 //	@Failure		500		{object}	ErrorResponse
 //	@Router			/dashboards [post]
 func (h *Handler) CreateDashboard(w http.ResponseWriter, r *http.Request) {
@@ -148,8 +179,8 @@ func (h *Handler) CreateDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req CreateDashboardRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -159,6 +190,18 @@ func (h *Handler) CreateDashboard(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "dashboard name is required")
 			return
 		}
+		if errors.Is(err, externalid.ErrInvalid) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, externalid.ErrTaken) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondError(w, http.StatusPaymentRequired, err.Error())
+			return
+		}
 		log.Printf("create dashboard error: %v", err)
 		respondError(w, http.StatusInternalServerError, "failed to create dashboard")
 		return
@@ -170,19 +213,21 @@ func (h *Handler) CreateDashboard(w http.ResponseWriter, r *http.Request) {
 // UpdateDashboard handles updating a dashboard.
 //
 //	@Summary		Update dashboard
-//	@Description	Update a dashboard's name. Requires editor or admin role.
+//	@Description	Update a dashboard's name. Send the updatedAt value last read as If-Match to fail with 412 instead of overwriting someone else's change. Requires editor or admin role.
 //	@Tags			dashboards
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id		path		string					true	"Dashboard ID"
-//	@Param			request	body		UpdateDashboardRequest	true	"Dashboard data"
-//	@Success		200		{object}	Dashboard
-//	@Failure		400		{object}	ErrorResponse
-//	@Failure		401		{object}	ErrorResponse
-//	@Failure		403		{object}	ErrorResponse
-//	@Failure		404		{object}	ErrorResponse
-//	@Failure		500		{object}	ErrorResponse
+//	@Param			id			path		string					true	"Dashboard ID"
+//	@Param			If-Match	header		string					false	"updatedAt of the dashboard as last read"
+//	@Param			request		body		UpdateDashboardRequest	true	"Dashboard data"
+//	@Success		200			{object}	Dashboard
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/dashboards/{id} [put]
 func (h *Handler) UpdateDashboard(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
@@ -197,13 +242,19 @@ func (h *Handler) UpdateDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var req UpdateDashboardRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
-	dashboard, err := h.service.UpdateDashboard(r.Context(), user.OrganizationID, dashboardID, req)
+	dashboard, err := h.service.UpdateDashboard(r.Context(), user.OrganizationID, dashboardID, req, ifUnmodified)
 	if err != nil {
 		if errors.Is(err, ErrDashboardNotFound) {
 			respondError(w, http.StatusNotFound, "dashboard not found")
@@ -217,6 +268,10 @@ func (h *Handler) UpdateDashboard(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "dashboard name is required")
 			return
 		}
+		if errors.Is(err, ErrPreconditionFailed) {
+			respondError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
 		log.Printf("update dashboard error: %v", err)
 		respondError(w, http.StatusInternalServerError, "failed to update dashboard")
 		return
@@ -225,10 +280,164 @@ func (h *Handler) UpdateDashboard(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, dashboard)
 }
 
+// UpdateComputeSettings handles updating how a dashboard's metrics are computed.
+//
+//	@Summary		Update dashboard compute settings
+//	@Description	Set how many metrics of a dashboard are computed in parallel and how long computed values are reused, trading freshness for load on heavy dashboards. Both are bounded by server limits; null restores the default of computing one metric at a time without caching. Requires editor or admin role.
+//	@Tags			dashboards
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string							true	"Dashboard ID"
+//	@Param			request	body		UpdateComputeSettingsRequest	true	"Compute settings"
+//	@Success		200		{object}	Dashboard
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/dashboards/{id}/compute-settings [put]
+func (h *Handler) UpdateComputeSettings(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	var req UpdateComputeSettingsRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	dashboard, err := h.service.UpdateComputeSettings(r.Context(), user.OrganizationID, dashboardID, req)
+	if err != nil {
+		if errors.Is(err, ErrDashboardNotFound) {
+			respondError(w, http.StatusNotFound, "dashboard not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		if errors.Is(err, ErrInvalidConcurrency) || errors.Is(err, ErrInvalidCacheTTL) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("update compute settings error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update compute settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, dashboard)
+}
+
+// GetDashboardByExternalID handles getting a dashboard by its external ID.
+//
+//	@Summary		Get dashboard by external ID
+//	@Description	Get a dashboard by the external ID it was created or put with
+//	@Tags			dashboards
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			externalId	path		string	true	"External ID"
+//	@Success		200			{object}	Dashboard
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/external/{externalId} [get]
+func (h *Handler) GetDashboardByExternalID(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboard, err := h.service.GetDashboardByExternalID(r.Context(), user.OrganizationID, chi.URLParam(r, "externalId"))
+	if err != nil {
+		if errors.Is(err, externalid.ErrInvalid) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, ErrDashboardNotFound) {
+			respondError(w, http.StatusNotFound, "dashboard not found")
+			return
+		}
+		log.Printf("get dashboard by external ID error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get dashboard")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, dashboard)
+}
+
+// PutDashboard handles creating or updating a dashboard by its external ID.
+//
+//	@Summary		Create or update dashboard by external ID
+//	@Description	Create the dashboard with the given external ID, or update it if it exists, so that clients such as Terraform providers can converge it without tracking its ID. Putting the same request again changes nothing. Requires editor or admin role.
+//	@Tags			dashboards
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			externalId	path		string					true	"External ID"
+//	@Param			request		body		UpdateDashboardRequest	true	"Dashboard data"
+//	@Success		200			{object}	Dashboard				"Updated or unchanged"
+//	@Success		201			{object}	Dashboard				"Created"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		402			{object}	ErrorResponse	"Dashboard quota reached"
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/external/{externalId} [put]
+func (h *Handler) PutDashboard(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateDashboardRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	dashboard, created, err := h.service.PutDashboard(r.Context(), user.OrganizationID, chi.URLParam(r, "externalId"), req)
+	if err != nil {
+		if errors.Is(err, externalid.ErrInvalid) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, ErrDashboardNameEmpty) {
+			respondError(w, http.StatusBadRequest, "dashboard name is required")
+			return
+		}
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondError(w, http.StatusPaymentRequired, err.Error())
+			return
+		}
+		log.Printf("put dashboard error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to put dashboard")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondJSON(w, status, dashboard)
+}
+
 // DeleteDashboard handles deleting a dashboard.
 //
 //	@Summary		Delete dashboard
-//	@Description	Delete a dashboard. Cannot delete the default dashboard. Requires editor or admin role.
+//	@Description	Move a dashboard to the trash, where it can be restored for 30 days. Cannot delete the default dashboard. Requires editor or admin role.
 //	@Tags			dashboards
 //	@Produce		json
 //	@Security		BearerAuth
@@ -275,6 +484,216 @@ func (h *Handler) DeleteDashboard(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, MessageResponse{Message: "dashboard deleted"})
 }
 
+// StarDashboard handles starring a dashboard for the current user.
+//
+//	@Summary		Star dashboard
+//	@Description	Star a dashboard for the current user. Starred dashboards are included in the weekly digest.
+//	@Tags			dashboards
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/dashboards/{id}/star [post]
+func (h *Handler) StarDashboard(w http.ResponseWriter, r *http.Request) {
+	h.setStarred(w, r, true)
+}
+
+// UnstarDashboard handles removing the current user's star from a dashboard.
+//
+//	@Summary		Unstar dashboard
+//	@Description	Remove the current user's star from a dashboard
+//	@Tags			dashboards
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/dashboards/{id}/star [delete]
+func (h *Handler) UnstarDashboard(w http.ResponseWriter, r *http.Request) {
+	h.setStarred(w, r, false)
+}
+
+func (h *Handler) setStarred(w http.ResponseWriter, r *http.Request, starred bool) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	message := "dashboard starred"
+	if starred {
+		err = h.service.StarDashboard(r.Context(), user.OrganizationID, user.ID, dashboardID)
+	} else {
+		err = h.service.UnstarDashboard(r.Context(), user.OrganizationID, user.ID, dashboardID)
+		message = "dashboard unstarred"
+	}
+	if err != nil {
+		if errors.Is(err, ErrDashboardNotFound) {
+			respondError(w, http.StatusNotFound, "dashboard not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		log.Printf("star dashboard error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update star")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: message})
+}
+
+// GetPreferences handles getting the current user's preferences for a dashboard.
+//
+//	@Summary		Get dashboard preferences
+//	@Description	Get the current user's personal overrides of a dashboard: collapsed sections, hidden metrics and a preferred timeframe. Users without saved preferences get empty ones.
+//	@Tags			dashboards
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	Preferences
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/dashboards/{id}/preferences [get]
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(r.Context(), user.OrganizationID, user.ID, dashboardID)
+	if err != nil {
+		respondPreferencesError(w, err, "get")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles saving the current user's preferences for a dashboard.
+//
+//	@Summary		Update dashboard preferences
+//	@Description	Save the current user's personal overrides of a dashboard, replacing any saved before. They are merged into the dashboard when this user reads it: hidden metrics are left out and the preferred timeframe replaces each metric's own when computing. The shared dashboard is not changed, so any member may save preferences.
+//	@Tags			dashboards
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string						true	"Dashboard ID"
+//	@Param			request	body		UpdatePreferencesRequest	true	"Preferences"
+//	@Success		200		{object}	Preferences
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/dashboards/{id}/preferences [put]
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(r.Context(), user.OrganizationID, user.ID, dashboardID, req)
+	if err != nil {
+		respondPreferencesError(w, err, "update")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// ResetPreferences handles removing the current user's preferences for a dashboard.
+//
+//	@Summary		Reset dashboard preferences
+//	@Description	Remove the current user's personal overrides of a dashboard, so it is shown as saved
+//	@Tags			dashboards
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/dashboards/{id}/preferences [delete]
+func (h *Handler) ResetPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	if err := h.service.ResetPreferences(r.Context(), user.OrganizationID, user.ID, dashboardID); err != nil {
+		respondPreferencesError(w, err, "reset")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "dashboard preferences reset"})
+}
+
+func respondPreferencesError(w http.ResponseWriter, err error, action string) {
+	if errors.Is(err, ErrDashboardNotFound) {
+		respondError(w, http.StatusNotFound, "dashboard not found")
+		return
+	}
+	if errors.Is(err, ErrUnauthorized) {
+		respondError(w, http.StatusForbidden, "unauthorized")
+		return
+	}
+	if errors.Is(err, ErrInvalidSections) || errors.Is(err, ErrInvalidTimeframe) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("%s dashboard preferences error: %v", action, err)
+	respondError(w, http.StatusInternalServerError, "failed to "+action+" dashboard preferences")
+}
+
 // VerifyDashboardOwnership verifies that a dashboard belongs to an organization.
 // This is used by other handlers (e.g., metric handler) to check ownership.
 func (h *Handler) VerifyDashboardOwnership(w http.ResponseWriter, r *http.Request) (*Dashboard, bool) {
@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
 )
 
 // Repository handles database operations for dashboards.
@@ -20,22 +22,27 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
-// CreateDashboard creates a new dashboard.
-func (r *Repository) CreateDashboard(ctx context.Context, orgID uuid.UUID, name string, isDefault bool) (*Dashboard, error) {
+// CreateDashboard creates a new dashboard. It returns externalid.ErrTaken
+// if another dashboard of the organization has the external ID.
+func (r *Repository) CreateDashboard(ctx context.Context, orgID uuid.UUID, name string, externalID *string, isDefault bool) (*Dashboard, error) {
 	dashboard := &Dashboard{
 		ID:             uuid.New(),
 		Name:           name,
 		OrganizationID: orgID,
 		IsDefault:      isDefault,
+		ExternalID:     externalID,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 
 	_, err := r.pool.Exec(ctx,
-		`INSERT INTO dashboards (id, name, organization_id, is_default, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
-		dashboard.ID, dashboard.Name, dashboard.OrganizationID, dashboard.IsDefault, dashboard.CreatedAt, dashboard.UpdatedAt,
+		`INSERT INTO dashboards (id, name, organization_id, is_default, external_id, compute_concurrency, cache_ttl_seconds, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		dashboard.ID, dashboard.Name, dashboard.OrganizationID, dashboard.IsDefault, dashboard.ExternalID, dashboard.CreatedAt, dashboard.UpdatedAt,
 	)
+	if externalid.IsTaken(err, "idx_dashboards_external_id") {
+		return nil, externalid.ErrTaken
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -47,10 +54,29 @@ func (r *Repository) CreateDashboard(ctx context.Context, orgID uuid.UUID, name
 func (r *Repository) GetDashboardByID(ctx context.Context, id uuid.UUID) (*Dashboard, error) {
 	dashboard := &Dashboard{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, name, organization_id, is_default, created_at, updated_at
-		FROM dashboards WHERE id = $1`,
+		`SELECT id, name, organization_id, is_default, external_id, compute_concurrency, cache_ttl_seconds, created_at, updated_at
+		FROM dashboards WHERE id = $1 AND deleted_at IS NULL`,
 		id,
-	).Scan(&dashboard.ID, &dashboard.Name, &dashboard.OrganizationID, &dashboard.IsDefault, &dashboard.CreatedAt, &dashboard.UpdatedAt)
+	).Scan(&dashboard.ID, &dashboard.Name, &dashboard.OrganizationID, &dashboard.IsDefault, &dashboard.ExternalID, &dashboard.ComputeConcurrency, &dashboard.CacheTTLSeconds, &dashboard.CreatedAt, &dashboard.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dashboard, nil
+}
+
+// GetDashboardByExternalID retrieves a dashboard of an organization by its external ID.
+func (r *Repository) GetDashboardByExternalID(ctx context.Context, orgID uuid.UUID, externalID string) (*Dashboard, error) {
+	dashboard := &Dashboard{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, name, organization_id, is_default, external_id, compute_concurrency, cache_ttl_seconds, created_at, updated_at
+		FROM dashboards WHERE organization_id = $1 AND external_id = $2 AND deleted_at IS NULL`,
+		orgID, externalID,
+	).Scan(&dashboard.ID, &dashboard.Name, &dashboard.OrganizationID, &dashboard.IsDefault, &dashboard.ExternalID, &dashboard.ComputeConcurrency, &dashboard.CacheTTLSeconds, &dashboard.CreatedAt, &dashboard.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -66,10 +92,10 @@ func (r *Repository) GetDashboardByID(ctx context.Context, id uuid.UUID) (*Dashb
 func (r *Repository) GetDefaultDashboard(ctx context.Context, orgID uuid.UUID) (*Dashboard, error) {
 	dashboard := &Dashboard{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, name, organization_id, is_default, created_at, updated_at
-		FROM dashboards WHERE organization_id = $1 AND is_default = TRUE`,
+		`SELECT id, name, organization_id, is_default, external_id, compute_concurrency, cache_ttl_seconds, created_at, updated_at
+		FROM dashboards WHERE organization_id = $1 AND is_default = TRUE AND deleted_at IS NULL`,
 		orgID,
-	).Scan(&dashboard.ID, &dashboard.Name, &dashboard.OrganizationID, &dashboard.IsDefault, &dashboard.CreatedAt, &dashboard.UpdatedAt)
+	).Scan(&dashboard.ID, &dashboard.Name, &dashboard.OrganizationID, &dashboard.IsDefault, &dashboard.ExternalID, &dashboard.ComputeConcurrency, &dashboard.CacheTTLSeconds, &dashboard.CreatedAt, &dashboard.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -84,8 +110,8 @@ func (r *Repository) GetDefaultDashboard(ctx context.Context, orgID uuid.UUID) (
 // GetDashboardsByOrganizationID retrieves all dashboards for an organization.
 func (r *Repository) GetDashboardsByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]Dashboard, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, name, organization_id, is_default, created_at, updated_at
-		FROM dashboards WHERE organization_id = $1
+		`SELECT id, name, organization_id, is_default, external_id, compute_concurrency, cache_ttl_seconds, created_at, updated_at
+		FROM dashboards WHERE organization_id = $1 AND deleted_at IS NULL
 		ORDER BY is_default DESC, created_at ASC`,
 		orgID,
 	)
@@ -97,7 +123,7 @@ func (r *Repository) GetDashboardsByOrganizationID(ctx context.Context, orgID uu
 	var dashboards []Dashboard
 	for rows.Next() {
 		var d Dashboard
-		if err := rows.Scan(&d.ID, &d.Name, &d.OrganizationID, &d.IsDefault, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		if err := rows.Scan(&d.ID, &d.Name, &d.OrganizationID, &d.IsDefault, &d.ExternalID, &d.ComputeConcurrency, &d.CacheTTLSeconds, &d.CreatedAt, &d.UpdatedAt); err != nil {
 			return nil, err
 		}
 		dashboards = append(dashboards, d)
@@ -114,20 +140,209 @@ func (r *Repository) GetDashboardsByOrganizationID(ctx context.Context, orgID uu
 	return dashboards, nil
 }
 
-// UpdateDashboard updates a dashboard's name.
-func (r *Repository) UpdateDashboard(ctx context.Context, id uuid.UUID, name string) error {
+// UpdateDashboard updates a dashboard's name and returns its new update
+// time. If ifUnmodified is set, the update only applies while the dashboard's
+// update time still equals it; otherwise nil is returned.
+func (r *Repository) UpdateDashboard(ctx context.Context, id uuid.UUID, name string, ifUnmodified *time.Time) (*time.Time, error) {
+	var updatedAt time.Time
+	err := r.pool.QueryRow(ctx,
+		`UPDATE dashboards SET name = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL AND ($3::timestamptz IS NULL OR updated_at = $3)
+		RETURNING updated_at`,
+		name, id, ifUnmodified,
+	).Scan(&updatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedAt, nil
+}
+
+// UpdateComputeSettings sets how a dashboard's metrics are computed and
+// returns its new update time; nil values restore the server defaults.
+func (r *Repository) UpdateComputeSettings(ctx context.Context, id uuid.UUID, concurrency, cacheTTLSeconds *int) (time.Time, error) {
+	var updatedAt time.Time
+	err := r.pool.QueryRow(ctx,
+		`UPDATE dashboards SET compute_concurrency = $1, cache_ttl_seconds = $2, updated_at = NOW()
+		WHERE id = $3 AND deleted_at IS NULL
+		RETURNING updated_at`,
+		concurrency, cacheTTLSeconds, id,
+	).Scan(&updatedAt)
+	return updatedAt, err
+}
+
+// DeleteDashboard moves a dashboard to the trash. Its metrics and stars are
+// kept so that restoring it brings them back; its external ID is released.
+func (r *Repository) DeleteDashboard(ctx context.Context, id uuid.UUID) error {
 	_, err := r.pool.Exec(ctx,
-		`UPDATE dashboards SET name = $1, updated_at = NOW() WHERE id = $2`,
-		name, id,
+		`UPDATE dashboards SET deleted_at = NOW(), external_id = NULL WHERE id = $1 AND deleted_at IS NULL`,
+		id,
 	)
 	return err
 }
 
-// DeleteDashboard deletes a dashboard by its ID.
-func (r *Repository) DeleteDashboard(ctx context.Context, id uuid.UUID) error {
+// GetDeletedDashboards retrieves the dashboards of an organization that are in the trash.
+func (r *Repository) GetDeletedDashboards(ctx context.Context, orgID uuid.UUID) ([]TrashedDashboard, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, name, deleted_at
+		FROM dashboards WHERE organization_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`,
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dashboards []TrashedDashboard
+	for rows.Next() {
+		var d TrashedDashboard
+		if err := rows.Scan(&d.ID, &d.Name, &d.DeletedAt); err != nil {
+			return nil, err
+		}
+		dashboards = append(dashboards, d)
+	}
+
+	return dashboards, rows.Err()
+}
+
+// RestoreDashboard takes a dashboard out of the trash. It reports whether a
+// trashed dashboard of the organization was found.
+func (r *Repository) RestoreDashboard(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE dashboards SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NOT NULL`,
+		id, orgID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// PurgeDeletedDashboards permanently deletes dashboards trashed before the
+// given time, along with their metrics, and returns the organization IDs of
+// the deleted dashboards by ID.
+func (r *Repository) PurgeDeletedDashboards(ctx context.Context, before time.Time) (map[uuid.UUID]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx,
+		`DELETE FROM dashboards WHERE deleted_at < $1 RETURNING id, organization_id`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purged := make(map[uuid.UUID]uuid.UUID)
+	for rows.Next() {
+		var id, orgID uuid.UUID
+		if err := rows.Scan(&id, &orgID); err != nil {
+			return nil, err
+		}
+		purged[id] = orgID
+	}
+	return purged, rows.Err()
+}
+
+// StarDashboard stars a dashboard for a user.
+func (r *Repository) StarDashboard(ctx context.Context, userID, dashboardID uuid.UUID) error {
 	_, err := r.pool.Exec(ctx,
-		`DELETE FROM dashboards WHERE id = $1`,
-		id,
+		`INSERT INTO dashboard_stars (user_id, dashboard_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		userID, dashboardID,
 	)
 	return err
 }
+
+// UnstarDashboard removes a user's star from a dashboard.
+func (r *Repository) UnstarDashboard(ctx context.Context, userID, dashboardID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM dashboard_stars WHERE user_id = $1 AND dashboard_id = $2`,
+		userID, dashboardID,
+	)
+	return err
+}
+
+// GetPreferences retrieves a user's preferences for a dashboard.
+// Returns nil if the user has not saved any.
+func (r *Repository) GetPreferences(ctx context.Context, userID, dashboardID uuid.UUID) (*Preferences, error) {
+	p := &Preferences{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT collapsed_sections, hidden_metric_ids, timeframe, updated_at
+		FROM dashboard_preferences WHERE user_id = $1 AND dashboard_id = $2`,
+		userID, dashboardID,
+	).Scan(&p.CollapsedSections, &p.HiddenMetricIDs, &p.Timeframe, &p.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// UpsertPreferences saves a user's preferences for a dashboard, replacing
+// any saved before.
+func (r *Repository) UpsertPreferences(ctx context.Context, userID, dashboardID uuid.UUID, p Preferences) (*Preferences, error) {
+	saved := &Preferences{}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO dashboard_preferences (user_id, dashboard_id, collapsed_sections, hidden_metric_ids, timeframe)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, dashboard_id) DO UPDATE SET
+			collapsed_sections = EXCLUDED.collapsed_sections,
+			hidden_metric_ids = EXCLUDED.hidden_metric_ids,
+			timeframe = EXCLUDED.timeframe
+		RETURNING collapsed_sections, hidden_metric_ids, timeframe, updated_at`,
+		userID, dashboardID, p.CollapsedSections, p.HiddenMetricIDs, p.Timeframe,
+	).Scan(&saved.CollapsedSections, &saved.HiddenMetricIDs, &saved.Timeframe, &saved.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// DeletePreferences removes a user's preferences for a dashboard.
+func (r *Repository) DeletePreferences(ctx context.Context, userID, dashboardID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM dashboard_preferences WHERE user_id = $1 AND dashboard_id = $2`,
+		userID, dashboardID,
+	)
+	return err
+}
+
+// GetStarredDashboards retrieves the dashboards a user has starred in an organization.
+func (r *Repository) GetStarredDashboards(ctx context.Context, userID, orgID uuid.UUID) ([]Dashboard, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT d.id, d.name, d.organization_id, d.is_default, d.external_id, d.compute_concurrency, d.cache_ttl_seconds, d.created_at, d.updated_at
+		FROM dashboards d
+		JOIN dashboard_stars s ON s.dashboard_id = d.id
+		WHERE s.user_id = $1 AND d.organization_id = $2 AND d.deleted_at IS NULL
+		ORDER BY d.is_default DESC, d.created_at ASC`,
+		userID, orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dashboards []Dashboard
+	for rows.Next() {
+		d := Dashboard{Starred: true}
+		if err := rows.Scan(&d.ID, &d.Name, &d.OrganizationID, &d.IsDefault, &d.ExternalID, &d.ComputeConcurrency, &d.CacheTTLSeconds, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		dashboards = append(dashboards, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dashboards, nil
+}
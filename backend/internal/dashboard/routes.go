@@ -4,8 +4,6 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-
-	"github.com/devbydaniel/litekpi/internal/auth"
 )
 
 // RegisterRoutes registers all dashboard routes.
@@ -17,15 +15,23 @@ func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Han
 		// Read operations (all authenticated users)
 		r.Get("/", h.ListDashboards)
 		r.Get("/default", h.GetDefaultDashboard)
+		r.Get("/external/{externalId}", h.GetDashboardByExternalID)
 		r.Get("/{id}", h.GetDashboard)
 
-		// Write operations (editor and admin only)
-		r.Group(func(r chi.Router) {
-			r.Use(auth.EditorMiddleware)
+		// Stars are personal, so any member may set them
+		r.Post("/{id}/star", h.StarDashboard)
+		r.Delete("/{id}/star", h.UnstarDashboard)
 
-			r.Post("/", h.CreateDashboard)
-			r.Put("/{id}", h.UpdateDashboard)
-			r.Delete("/{id}", h.DeleteDashboard)
-		})
+		// Preferences only change the dashboard for the user saving them
+		r.Get("/{id}/preferences", h.GetPreferences)
+		r.Put("/{id}/preferences", h.UpdatePreferences)
+		r.Delete("/{id}/preferences", h.ResetPreferences)
+
+		// Write operations (editor and admin only)
+		r.Post("/", h.CreateDashboard)
+		r.Put("/{id}", h.UpdateDashboard)
+		r.Put("/{id}/compute-settings", h.UpdateComputeSettings)
+		r.Put("/external/{externalId}", h.PutDashboard)
+		r.Delete("/{id}", h.DeleteDashboard)
 	})
 }
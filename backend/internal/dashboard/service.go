@@ -2,21 +2,36 @@ package dashboard
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // Service handles dashboard business logic.
 type Service struct {
-	repo *Repository
+	repo           *Repository
+	usageService   *usage.Service
+	audit          *writeaudit.Service
+	maxConcurrency int           // Upper bound for a dashboard's compute concurrency
+	maxCacheTTL    time.Duration // Upper bound for a dashboard's cache TTL
 }
 
 // NewService creates a new dashboard service.
-func NewService(repo *Repository) *Service {
+func NewService(repo *Repository, usageService *usage.Service, audit *writeaudit.Service, cfg *config.Config) *Service {
 	return &Service{
-		repo: repo,
+		repo:           repo,
+		usageService:   usageService,
+		audit:          audit,
+		maxConcurrency: cfg.ComputeMaxConcurrency,
+		maxCacheTTL:    cfg.ComputeCacheMaxTTL,
 	}
 }
 
@@ -26,15 +41,70 @@ func (s *Service) CreateDashboard(ctx context.Context, orgID uuid.UUID, req Crea
 	if name == "" {
 		return nil, ErrDashboardNameEmpty
 	}
+	if req.ExternalID != nil {
+		if err := externalid.Validate(*req.ExternalID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.usageService.CheckDashboardQuota(ctx, orgID); err != nil {
+		return nil, err
+	}
 
-	dashboard, err := s.repo.CreateDashboard(ctx, orgID, name, false)
+	dashboard, err := s.repo.CreateDashboard(ctx, orgID, name, req.ExternalID, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create dashboard: %w", err)
 	}
 
+	s.audit.Record(ctx, writeaudit.EntityDashboard, dashboard.ID, writeaudit.ActionCreate, nil, dashboard)
+	return dashboard, nil
+}
+
+// GetDashboardByExternalID returns the dashboard of an organization with the
+// given external ID.
+func (s *Service) GetDashboardByExternalID(ctx context.Context, orgID uuid.UUID, externalID string) (*Dashboard, error) {
+	if err := externalid.Validate(externalID); err != nil {
+		return nil, err
+	}
+	dashboard, err := s.repo.GetDashboardByExternalID(ctx, orgID, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dashboard: %w", err)
+	}
+	if dashboard == nil {
+		return nil, ErrDashboardNotFound
+	}
 	return dashboard, nil
 }
 
+// PutDashboard creates the dashboard with the given external ID, or updates
+// it to match req if it exists. It reports whether the dashboard was created.
+// Putting the same request again changes nothing.
+func (s *Service) PutDashboard(ctx context.Context, orgID uuid.UUID, externalID string, req UpdateDashboardRequest) (*Dashboard, bool, error) {
+	dashboard, err := s.GetDashboardByExternalID(ctx, orgID, externalID)
+	if errors.Is(err, ErrDashboardNotFound) {
+		dashboard, err = s.CreateDashboard(ctx, orgID, CreateDashboardRequest{Name: req.Name, ExternalID: &externalID})
+		if !errors.Is(err, externalid.ErrTaken) {
+			return dashboard, err == nil, err
+		}
+		// Created by a concurrent request since the lookup
+		dashboard, err = s.GetDashboardByExternalID(ctx, orgID, externalID)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, false, ErrDashboardNameEmpty
+	}
+	if name == dashboard.Name {
+		return dashboard, false, nil
+	}
+
+	dashboard, err = s.UpdateDashboard(ctx, orgID, dashboard.ID, req, nil)
+	return dashboard, false, err
+}
+
 // ListDashboards returns all dashboards for an organization.
 func (s *Service) ListDashboards(ctx context.Context, orgID uuid.UUID) ([]Dashboard, error) {
 	dashboards, err := s.repo.GetDashboardsByOrganizationID(ctx, orgID)
@@ -45,6 +115,131 @@ func (s *Service) ListDashboards(ctx context.Context, orgID uuid.UUID) ([]Dashbo
 	return dashboards, nil
 }
 
+// ListDashboardsForUser returns all dashboards for an organization, marking
+// the ones the user has starred.
+func (s *Service) ListDashboardsForUser(ctx context.Context, orgID, userID uuid.UUID) ([]Dashboard, error) {
+	dashboards, err := s.ListDashboards(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	starred, err := s.repo.GetStarredDashboards(ctx, userID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starred dashboards: %w", err)
+	}
+	starredIDs := make(map[uuid.UUID]bool, len(starred))
+	for _, d := range starred {
+		starredIDs[d.ID] = true
+	}
+	for i := range dashboards {
+		dashboards[i].Starred = starredIDs[dashboards[i].ID]
+	}
+
+	return dashboards, nil
+}
+
+// ListStarredDashboards returns the dashboards a user has starred.
+func (s *Service) ListStarredDashboards(ctx context.Context, orgID, userID uuid.UUID) ([]Dashboard, error) {
+	dashboards, err := s.repo.GetStarredDashboards(ctx, userID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starred dashboards: %w", err)
+	}
+	return dashboards, nil
+}
+
+// StarDashboard stars a dashboard for a user after verifying organization ownership.
+func (s *Service) StarDashboard(ctx context.Context, orgID, userID, dashboardID uuid.UUID) error {
+	if _, err := s.VerifyDashboardOwnership(ctx, orgID, dashboardID); err != nil {
+		return err
+	}
+	if err := s.repo.StarDashboard(ctx, userID, dashboardID); err != nil {
+		return fmt.Errorf("failed to star dashboard: %w", err)
+	}
+	return nil
+}
+
+// UnstarDashboard removes a user's star from a dashboard.
+func (s *Service) UnstarDashboard(ctx context.Context, orgID, userID, dashboardID uuid.UUID) error {
+	if _, err := s.VerifyDashboardOwnership(ctx, orgID, dashboardID); err != nil {
+		return err
+	}
+	if err := s.repo.UnstarDashboard(ctx, userID, dashboardID); err != nil {
+		return fmt.Errorf("failed to unstar dashboard: %w", err)
+	}
+	return nil
+}
+
+// GetPreferences returns a user's preferences for a dashboard after
+// verifying organization ownership. Users without saved preferences get
+// empty ones, which leave the dashboard as it is.
+func (s *Service) GetPreferences(ctx context.Context, orgID, userID, dashboardID uuid.UUID) (*Preferences, error) {
+	if _, err := s.VerifyDashboardOwnership(ctx, orgID, dashboardID); err != nil {
+		return nil, err
+	}
+	prefs, err := s.repo.GetPreferences(ctx, userID, dashboardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dashboard preferences: %w", err)
+	}
+	if prefs == nil {
+		prefs = &Preferences{}
+	}
+	if prefs.CollapsedSections == nil {
+		prefs.CollapsedSections = []string{}
+	}
+	if prefs.HiddenMetricIDs == nil {
+		prefs.HiddenMetricIDs = []uuid.UUID{}
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences saves a user's preferences for a dashboard after
+// verifying organization ownership.
+func (s *Service) UpdatePreferences(ctx context.Context, orgID, userID, dashboardID uuid.UUID, req UpdatePreferencesRequest) (*Preferences, error) {
+	if len(req.CollapsedSections) > MaxCollapsedSections {
+		return nil, ErrInvalidSections
+	}
+	for _, key := range req.CollapsedSections {
+		if key == "" || len(key) > MaxSectionKeyLength {
+			return nil, ErrInvalidSections
+		}
+	}
+	if req.Timeframe != nil && !preferenceTimeframes[*req.Timeframe] {
+		return nil, ErrInvalidTimeframe
+	}
+	if _, err := s.VerifyDashboardOwnership(ctx, orgID, dashboardID); err != nil {
+		return nil, err
+	}
+
+	prefs := Preferences{
+		CollapsedSections: req.CollapsedSections,
+		HiddenMetricIDs:   req.HiddenMetricIDs,
+		Timeframe:         req.Timeframe,
+	}
+	if prefs.CollapsedSections == nil {
+		prefs.CollapsedSections = []string{}
+	}
+	if prefs.HiddenMetricIDs == nil {
+		prefs.HiddenMetricIDs = []uuid.UUID{}
+	}
+	saved, err := s.repo.UpsertPreferences(ctx, userID, dashboardID, prefs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dashboard preferences: %w", err)
+	}
+	return saved, nil
+}
+
+// ResetPreferences removes a user's preferences for a dashboard, so it is
+// shown as saved.
+func (s *Service) ResetPreferences(ctx context.Context, orgID, userID, dashboardID uuid.UUID) error {
+	if _, err := s.VerifyDashboardOwnership(ctx, orgID, dashboardID); err != nil {
+		return err
+	}
+	if err := s.repo.DeletePreferences(ctx, userID, dashboardID); err != nil {
+		return fmt.Errorf("failed to reset dashboard preferences: %w", err)
+	}
+	return nil
+}
+
 // GetDashboard returns a dashboard after verifying organization ownership.
 // Metrics are fetched separately via /metrics endpoints.
 func (s *Service) GetDashboard(ctx context.Context, orgID, dashboardID uuid.UUID) (*DashboardWithData, error) {
@@ -72,7 +267,7 @@ func (s *Service) GetDefaultDashboard(ctx context.Context, orgID uuid.UUID) (*Da
 	}
 	if dashboard == nil {
 		// Create default dashboard if it doesn't exist
-		dashboard, err = s.repo.CreateDashboard(ctx, orgID, "Dashboard", true)
+		dashboard, err = s.repo.CreateDashboard(ctx, orgID, "Dashboard", nil, true)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create default dashboard: %w", err)
 		}
@@ -83,8 +278,10 @@ func (s *Service) GetDefaultDashboard(ctx context.Context, orgID uuid.UUID) (*Da
 	}, nil
 }
 
-// UpdateDashboard updates a dashboard's name.
-func (s *Service) UpdateDashboard(ctx context.Context, orgID, dashboardID uuid.UUID, req UpdateDashboardRequest) (*Dashboard, error) {
+// UpdateDashboard updates a dashboard's name. If ifUnmodified is set, the
+// update fails with ErrPreconditionFailed unless the dashboard was last
+// updated at that time.
+func (s *Service) UpdateDashboard(ctx context.Context, orgID, dashboardID uuid.UUID, req UpdateDashboardRequest, ifUnmodified *time.Time) (*Dashboard, error) {
 	dashboard, err := s.repo.GetDashboardByID(ctx, dashboardID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get dashboard: %w", err)
@@ -100,16 +297,52 @@ func (s *Service) UpdateDashboard(ctx context.Context, orgID, dashboardID uuid.U
 	if name == "" {
 		return nil, ErrDashboardNameEmpty
 	}
+	before := *dashboard
 
-	if err := s.repo.UpdateDashboard(ctx, dashboardID, name); err != nil {
+	updatedAt, err := s.repo.UpdateDashboard(ctx, dashboardID, name, ifUnmodified)
+	if err != nil {
 		return nil, fmt.Errorf("failed to update dashboard: %w", err)
 	}
+	if updatedAt == nil {
+		return nil, ErrPreconditionFailed
+	}
 
 	dashboard.Name = name
+	dashboard.UpdatedAt = *updatedAt
+	s.audit.Record(ctx, writeaudit.EntityDashboard, dashboardID, writeaudit.ActionUpdate, before, dashboard)
 	return dashboard, nil
 }
 
-// DeleteDashboard deletes a dashboard.
+// UpdateComputeSettings sets how a dashboard's metrics are computed, within
+// the server limits.
+func (s *Service) UpdateComputeSettings(ctx context.Context, orgID, dashboardID uuid.UUID, req UpdateComputeSettingsRequest) (*Dashboard, error) {
+	dashboard, err := s.VerifyDashboardOwnership(ctx, orgID, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if c := req.ComputeConcurrency; c != nil && (*c < 1 || *c > s.maxConcurrency) {
+		return nil, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidConcurrency, s.maxConcurrency)
+	}
+	maxTTL := int(s.maxCacheTTL / time.Second)
+	if ttl := req.CacheTTLSeconds; ttl != nil && (*ttl < 0 || *ttl > maxTTL) {
+		return nil, fmt.Errorf("%w: must be between 0 and %d seconds", ErrInvalidCacheTTL, maxTTL)
+	}
+
+	updatedAt, err := s.repo.UpdateComputeSettings(ctx, dashboardID, req.ComputeConcurrency, req.CacheTTLSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update compute settings: %w", err)
+	}
+
+	before := *dashboard
+	dashboard.ComputeConcurrency = req.ComputeConcurrency
+	dashboard.CacheTTLSeconds = req.CacheTTLSeconds
+	dashboard.UpdatedAt = updatedAt
+	s.audit.Record(ctx, writeaudit.EntityDashboard, dashboardID, writeaudit.ActionUpdate, before, dashboard)
+	return dashboard, nil
+}
+
+// DeleteDashboard moves a dashboard to the trash.
 func (s *Service) DeleteDashboard(ctx context.Context, orgID, dashboardID uuid.UUID) error {
 	dashboard, err := s.repo.GetDashboardByID(ctx, dashboardID)
 	if err != nil {
@@ -129,9 +362,57 @@ func (s *Service) DeleteDashboard(ctx context.Context, orgID, dashboardID uuid.U
 		return fmt.Errorf("failed to delete dashboard: %w", err)
 	}
 
+	s.audit.Record(ctx, writeaudit.EntityDashboard, dashboardID, writeaudit.ActionDelete, dashboard, nil)
+	return nil
+}
+
+// ListDeletedDashboards returns the dashboards of an organization that are in the trash.
+func (s *Service) ListDeletedDashboards(ctx context.Context, orgID uuid.UUID) ([]TrashedDashboard, error) {
+	dashboards, err := s.repo.GetDeletedDashboards(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted dashboards: %w", err)
+	}
+	if dashboards == nil {
+		dashboards = []TrashedDashboard{}
+	}
+	return dashboards, nil
+}
+
+// RestoreDashboard takes a dashboard out of the trash. Restored dashboards
+// count towards the dashboard quota again.
+func (s *Service) RestoreDashboard(ctx context.Context, orgID, dashboardID uuid.UUID) error {
+	if err := s.usageService.CheckDashboardQuota(ctx, orgID); err != nil {
+		return err
+	}
+
+	restored, err := s.repo.RestoreDashboard(ctx, orgID, dashboardID)
+	if err != nil {
+		return fmt.Errorf("failed to restore dashboard: %w", err)
+	}
+	if !restored {
+		return ErrDashboardNotFound
+	}
+	s.audit.Record(ctx, writeaudit.EntityDashboard, dashboardID, writeaudit.ActionRestore, nil, nil)
 	return nil
 }
 
+// PurgeDeletedDashboards permanently deletes dashboards trashed before the given time.
+func (s *Service) PurgeDeletedDashboards(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.repo.PurgeDeletedDashboards(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted dashboards: %w", err)
+	}
+	for id, orgID := range purged {
+		s.audit.RecordPurge(ctx, orgID, writeaudit.EntityDashboard, id)
+	}
+	return int64(len(purged)), nil
+}
+
+// History returns the most recent writes to a dashboard of an organization.
+func (s *Service) History(ctx context.Context, orgID, dashboardID uuid.UUID) ([]writeaudit.Entry, error) {
+	return s.audit.History(ctx, orgID, writeaudit.EntityDashboard, dashboardID)
+}
+
 // VerifyDashboardOwnership verifies that a dashboard belongs to an organization.
 // This is used by handlers to check ownership before delegating to metric services.
 func (s *Service) VerifyDashboardOwnership(ctx context.Context, orgID, dashboardID uuid.UUID) (*Dashboard, error) {
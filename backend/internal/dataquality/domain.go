@@ -0,0 +1,94 @@
+package dataquality
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Thresholds of the checks. A week is compared with the baselineWeeks before
+// it; rates are reported when they exceed their threshold and grew by at
+// least spikeFactor over the baseline.
+const (
+	baselineWeeks           = 4
+	minCardinality          = 50 // Distinct values of a key below which growth is not reported
+	cardinalityGrowthFactor = 2  // Growth of a key's distinct values over the previous week
+	duplicateRateThreshold  = 0.01
+	lateDataDelay           = 24 // Hours after its timestamp a measurement counts as late
+	lateRateThreshold       = 0.05
+	spikeFactor             = 2
+)
+
+// Error definitions
+var (
+	ErrReportNotFound = errors.New("data quality report not found")
+	ErrInvalidWeek    = errors.New("week must be a date (YYYY-MM-DD)")
+)
+
+// Check is a kind of data quality anomaly.
+type Check string
+
+const (
+	CheckCardinality Check = "cardinality_growth" // A metadata key gained many distinct values
+	CheckSchemaDrift Check = "schema_drift"       // Measurements or metadata keys appeared or disappeared
+	CheckDuplicates  Check = "duplicate_spike"    // More measurements repeat an earlier one
+	CheckLateData    Check = "late_data"          // More measurements arrived long after their timestamp
+)
+
+// Finding is an anomaly in the data of a data source.
+type Finding struct {
+	Check           Check    `json:"check"`
+	MeasurementName string   `json:"measurementName,omitempty"`
+	Key             string   `json:"key,omitempty"` // Metadata key, for cardinality growth and schema drift
+	Message         string   `json:"message"`
+	Current         *float64 `json:"current,omitempty"`  // The week's distinct values, or rate from 0 to 1
+	Baseline        *float64 `json:"baseline,omitempty"` // The same before the week
+}
+
+// DataSourceReport is the analysis of one data source.
+type DataSourceReport struct {
+	DataSourceID   uuid.UUID `json:"dataSourceId"`
+	DataSourceName string    `json:"dataSourceName"`
+	Measurements   int64     `json:"measurements"` // Measurements timestamped in the week
+	Findings       []Finding `json:"findings"`
+}
+
+// Report is the data quality report of an organization for one week.
+type Report struct {
+	OrganizationID uuid.UUID          `json:"-"`
+	Period         time.Time          `json:"period"` // Monday 00:00 UTC the analyzed week starts
+	DataSources    []DataSourceReport `json:"dataSources"`
+	GeneratedAt    time.Time          `json:"generatedAt"`
+}
+
+// Organization is an organization whose data sources are analyzed.
+type Organization struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// keyProfile is a metadata key of a measurement over the analyzed window. Key
+// is nil for measurements without metadata.
+type keyProfile struct {
+	Name           string
+	Key            *string
+	CurrentValues  int64 // Distinct values in the week
+	PreviousValues int64 // Distinct values in the week before
+	InCurrent      bool  // Seen in the week
+	InBaseline     bool  // Seen in the baseline weeks
+}
+
+// volume counts the measurements of a data source in the week and in the
+// baseline weeks.
+type volume struct {
+	Current, Baseline                 int64 // Timestamped in the period
+	CurrentDistinct, BaselineDistinct int64 // Distinct by name, timestamp, value and metadata
+	CurrentReceived, BaselineReceived int64 // Received in the period
+	CurrentLate, BaselineLate         int64 // Received in the period more than lateDataDelay after their timestamp
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
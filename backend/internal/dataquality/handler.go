@@ -0,0 +1,76 @@
+package dataquality
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+)
+
+// Handler handles HTTP requests for data quality reports.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new data quality handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetReport handles getting a weekly data quality report.
+//
+//	@Summary		Get data quality report
+//	@Description	Get the organization's weekly data quality report. Every Monday each data source is checked for anomalies in the past week compared with the four weeks before: metadata keys whose distinct values doubled, measurements and metadata keys that appeared or disappeared, and spikes in duplicate or late-arriving measurements. Users who turn on the data_quality notification category are emailed reports with findings.
+//	@Tags			data-quality
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			week	query		string	false	"A date in the analyzed week (YYYY-MM-DD); defaults to the latest report"
+//	@Success		200		{object}	Report
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/data-quality/report [get]
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var week *time.Time
+	if s := r.URL.Query().Get("week"); s != "" {
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, ErrInvalidWeek.Error())
+			return
+		}
+		week = &t
+	}
+
+	report, err := h.service.GetReport(r.Context(), user.OrganizationID, week)
+	if err != nil {
+		if errors.Is(err, ErrReportNotFound) {
+			respondError(w, http.StatusNotFound, "data quality report not found")
+			return
+		}
+		log.Printf("get data quality report error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get data quality report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,217 @@
+package dataquality
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for data quality reports.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new data quality repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// ListOrganizations retrieves the enabled organizations that have at least
+// one data source.
+func (r *Repository) ListOrganizations(ctx context.Context) ([]Organization, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT o.id, o.name
+		FROM organizations o
+		WHERE o.disabled_at IS NULL
+		  AND EXISTS (SELECT 1 FROM data_sources ds WHERE ds.organization_id = o.id)
+		ORDER BY o.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []Organization
+	for rows.Next() {
+		var o Organization
+		if err := rows.Scan(&o.ID, &o.Name); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+// ListRecipients retrieves the verified users of an organization who turned on
+// the data quality email.
+func (r *Repository) ListRecipients(ctx context.Context, orgID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT u.id
+		FROM users u
+		JOIN notification_preferences p ON p.user_id = u.id
+		WHERE u.organization_id = $1 AND u.email_verified
+		  AND p.category = 'data_quality' AND p.channel = 'email' AND p.resource_id IS NULL AND p.enabled
+		ORDER BY u.id`,
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ClaimReport records that an organization's report for a period is being
+// generated. It returns false if it was already claimed, which keeps each
+// week's email at most once across restarts and replicas.
+func (r *Repository) ClaimReport(ctx context.Context, orgID uuid.UUID, period time.Time) (bool, error) {
+	var claimed uuid.UUID
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO data_quality_reports (organization_id, period)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING organization_id`,
+		orgID, period,
+	).Scan(&claimed)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompleteReport stores the analysis of a claimed report.
+func (r *Repository) CompleteReport(ctx context.Context, report *Report) error {
+	dataSourcesJSON, err := json.Marshal(report.DataSources)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`UPDATE data_quality_reports SET data_sources = $3, generated_at = $4
+		WHERE organization_id = $1 AND period = $2`,
+		report.OrganizationID, report.Period, dataSourcesJSON, report.GeneratedAt,
+	)
+	return err
+}
+
+// ReleaseReport removes the claim of a report that failed to generate, so it
+// is retried.
+func (r *Repository) ReleaseReport(ctx context.Context, orgID uuid.UUID, period time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM data_quality_reports WHERE organization_id = $1 AND period = $2 AND generated_at IS NULL`,
+		orgID, period,
+	)
+	return err
+}
+
+// GetReport retrieves the generated report of an organization for a period,
+// or the latest one if period is nil.
+func (r *Repository) GetReport(ctx context.Context, orgID uuid.UUID, period *time.Time) (*Report, error) {
+	report := &Report{OrganizationID: orgID}
+	var dataSourcesJSON []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT period, data_sources, generated_at
+		FROM data_quality_reports
+		WHERE organization_id = $1 AND generated_at IS NOT NULL
+		  AND ($2::date IS NULL OR period = $2)
+		ORDER BY period DESC
+		LIMIT 1`,
+		orgID, period,
+	).Scan(&report.Period, &dataSourcesJSON, &report.GeneratedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(dataSourcesJSON, &report.DataSources); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ProfileKeys retrieves the metadata keys of a data source's measurements
+// timestamped between baselineFrom and to, with their distinct values in the
+// week starting at from and in the week before it.
+func (r *Repository) ProfileKeys(ctx context.Context, dataSourceID uuid.UUID, baselineFrom, from, to time.Time) ([]keyProfile, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.name, kv.key,
+			COUNT(DISTINCT kv.value) FILTER (WHERE m.timestamp >= $3),
+			COUNT(DISTINCT kv.value) FILTER (WHERE m.timestamp >= $5 AND m.timestamp < $3),
+			bool_or(m.timestamp >= $3),
+			bool_or(m.timestamp < $3)
+		FROM measurements m
+		LEFT JOIN LATERAL jsonb_each_text(
+			CASE WHEN jsonb_typeof(m.metadata) = 'object' THEN m.metadata ELSE '{}'::jsonb END
+		) kv ON true
+		WHERE m.data_source_id = $1 AND m.timestamp >= $2 AND m.timestamp < $4
+		GROUP BY m.name, kv.key
+		ORDER BY m.name, kv.key`,
+		dataSourceID, baselineFrom, from, to, from.AddDate(0, 0, -7),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []keyProfile
+	for rows.Next() {
+		var p keyProfile
+		if err := rows.Scan(&p.Name, &p.Key, &p.CurrentValues, &p.PreviousValues, &p.InCurrent, &p.InBaseline); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// CountVolume counts a data source's measurements in the week starting at
+// from and in the baseline weeks since baselineFrom. Measurements received
+// in a period count towards lateness only if their timestamp is in the
+// window, which bounds the scan to the window's partitions.
+func (r *Repository) CountVolume(ctx context.Context, dataSourceID uuid.UUID, baselineFrom, from, to time.Time) (*volume, error) {
+	v := &volume{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT
+			COUNT(*) FILTER (WHERE timestamp >= $3),
+			COUNT(*) FILTER (WHERE timestamp < $3),
+			COUNT(DISTINCT (name, timestamp, value, metadata)) FILTER (WHERE timestamp >= $3),
+			COUNT(DISTINCT (name, timestamp, value, metadata)) FILTER (WHERE timestamp < $3),
+			COUNT(*) FILTER (WHERE created_at >= $3 AND created_at < $4),
+			COUNT(*) FILTER (WHERE created_at >= $2 AND created_at < $3),
+			COUNT(*) FILTER (WHERE created_at >= $3 AND created_at < $4 AND created_at - timestamp > make_interval(hours => $5)),
+			COUNT(*) FILTER (WHERE created_at >= $2 AND created_at < $3 AND created_at - timestamp > make_interval(hours => $5))
+		FROM measurements
+		WHERE data_source_id = $1 AND timestamp >= $2 AND timestamp < $4`,
+		dataSourceID, baselineFrom, from, to, lateDataDelay,
+	).Scan(
+		&v.Current, &v.Baseline,
+		&v.CurrentDistinct, &v.BaselineDistinct,
+		&v.CurrentReceived, &v.BaselineReceived,
+		&v.CurrentLate, &v.BaselineLate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
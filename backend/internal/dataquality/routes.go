@@ -0,0 +1,16 @@
+package dataquality
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the data quality routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/data-quality", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		r.Get("/report", h.GetReport)
+	})
+}
@@ -0,0 +1,170 @@
+package dataquality
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/branding"
+	"github.com/devbydaniel/litekpi/internal/notification"
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const (
+	reportCheckInterval = time.Hour
+	reportHour          = 6 // Reports are generated on Mondays from 06:00 UTC
+)
+
+// Runner generates the weekly data quality reports. Every Monday it analyzes
+// the data sources of each organization for the past week and emails the
+// findings to the users who turned on the data quality email.
+type Runner struct {
+	repo                *Repository
+	service             *Service
+	notificationService *notification.Service
+	brandingService     *branding.Service
+	appURL              string
+}
+
+// NewRunner creates a new data quality report runner.
+func NewRunner(repo *Repository, service *Service, notificationService *notification.Service, brandingService *branding.Service, appURL string) *Runner {
+	return &Runner{
+		repo:                repo,
+		service:             service,
+		notificationService: notificationService,
+		brandingService:     brandingService,
+		appURL:              strings.TrimSuffix(appURL, "/"),
+	}
+}
+
+// Job returns the scheduled job that checks for due reports.
+func (r *Runner) Job() jobs.Job {
+	return jobs.Job{Name: "data_quality_reports", Interval: reportCheckInterval, Run: r.RunOnce}
+}
+
+// RunOnce generates last week's report of every organization that does not
+// have it yet.
+func (r *Runner) RunOnce(ctx context.Context) error {
+	now := time.Now().UTC()
+	thisWeek := weekStart(now)
+	if now.Before(thisWeek.Add(reportHour * time.Hour)) {
+		return nil
+	}
+	period := thisWeek.AddDate(0, 0, -7)
+
+	orgs, err := r.repo.ListOrganizations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	for _, org := range orgs {
+		claimed, err := r.repo.ClaimReport(ctx, org.ID, period)
+		if err != nil {
+			return fmt.Errorf("failed to claim report: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		report, err := r.generate(ctx, org, period)
+		if err != nil {
+			log.Printf("data quality report error for organization %s: %v", org.ID, err)
+			if err := r.repo.ReleaseReport(ctx, org.ID, period); err != nil {
+				log.Printf("data quality report release error for organization %s: %v", org.ID, err)
+			}
+			continue
+		}
+
+		if err := r.send(ctx, org, report); err != nil {
+			log.Printf("data quality email error for organization %s: %v", org.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// generate analyzes and stores an organization's report.
+func (r *Runner) generate(ctx context.Context, org Organization, period time.Time) (*Report, error) {
+	report, err := r.service.Analyze(ctx, org.ID, period)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.repo.CompleteReport(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to store report: %w", err)
+	}
+	return report, nil
+}
+
+// send emails a report with findings to the organization's recipients.
+func (r *Runner) send(ctx context.Context, org Organization, report *Report) error {
+	if !r.notificationService.IsEmailEnabled() {
+		return nil
+	}
+
+	summary := formatSummary(report)
+	if summary == "" {
+		return nil
+	}
+
+	recipients, err := r.repo.ListRecipients(ctx, org.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list recipients: %w", err)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	c, err := r.brandingService.EmailCustomization(ctx, org.ID)
+	if err != nil {
+		c = nil
+	}
+	data := map[string]string{
+		"URL":         r.appURL,
+		"OrgName":     org.Name,
+		"PeriodLabel": report.Period.Format("Jan 2, 2006"),
+		"Summary":     summary,
+	}
+	msg, err := email.Render(email.TemplateDataQuality, c, data)
+	if err != nil && c != nil {
+		msg, err = email.Render(email.TemplateDataQuality, nil, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	for _, userID := range recipients {
+		_, err := r.notificationService.Dispatch(ctx, notification.Notification{
+			UserID:   userID,
+			Category: notification.CategoryDataQuality,
+			Email:    msg,
+		})
+		if err != nil {
+			log.Printf("data quality email error for user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// formatSummary renders the findings of a report by data source, or "" if
+// there are none.
+func formatSummary(report *Report) string {
+	var sections []string
+	for _, ds := range report.DataSources {
+		if len(ds.Findings) == 0 {
+			continue
+		}
+		lines := []string{ds.DataSourceName}
+		for _, f := range ds.Findings {
+			if f.MeasurementName != "" {
+				lines = append(lines, fmt.Sprintf("- %s: %s", f.MeasurementName, f.Message))
+			} else {
+				lines = append(lines, "- "+f.Message)
+			}
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,185 @@
+package dataquality
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/datasource"
+)
+
+// Service analyzes the data of data sources and returns data quality reports.
+type Service struct {
+	repo              *Repository
+	dataSourceService *datasource.Service
+}
+
+// NewService creates a new data quality service.
+func NewService(repo *Repository, dataSourceService *datasource.Service) *Service {
+	return &Service{
+		repo:              repo,
+		dataSourceService: dataSourceService,
+	}
+}
+
+// GetReport returns the report of an organization for the week containing
+// week, or the latest report if week is nil.
+func (s *Service) GetReport(ctx context.Context, orgID uuid.UUID, week *time.Time) (*Report, error) {
+	var period *time.Time
+	if week != nil {
+		start := weekStart(*week)
+		period = &start
+	}
+
+	report, err := s.repo.GetReport(ctx, orgID, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data quality report: %w", err)
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+// Analyze checks every data source of an organization for anomalies in the
+// week starting at period, compared with the weeks before it.
+func (s *Service) Analyze(ctx context.Context, orgID uuid.UUID, period time.Time) (*Report, error) {
+	dataSources, err := s.dataSourceService.ListDataSources(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		OrganizationID: orgID,
+		Period:         period,
+		DataSources:    make([]DataSourceReport, 0, len(dataSources)),
+	}
+	for _, ds := range dataSources {
+		dsReport, err := s.analyzeDataSource(ctx, ds, period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze data source %s: %w", ds.ID, err)
+		}
+		report.DataSources = append(report.DataSources, *dsReport)
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+func (s *Service) analyzeDataSource(ctx context.Context, ds datasource.DataSource, period time.Time) (*DataSourceReport, error) {
+	baselineFrom := period.AddDate(0, 0, -7*baselineWeeks)
+	to := period.AddDate(0, 0, 7)
+
+	v, err := s.repo.CountVolume(ctx, ds.ID, baselineFrom, period, to)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := s.repo.ProfileKeys(ctx, ds.ID, baselineFrom, period, to)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := profileFindings(profiles, v.Current > 0 && v.Baseline > 0)
+	if f := rateFinding(CheckDuplicates, "of measurements repeat an earlier one",
+		v.Current-v.CurrentDistinct, v.Current, v.Baseline-v.BaselineDistinct, v.Baseline, duplicateRateThreshold); f != nil {
+		findings = append(findings, *f)
+	}
+	if f := rateFinding(CheckLateData, fmt.Sprintf("of measurements arrived more than %d hours after their timestamp", lateDataDelay),
+		v.CurrentLate, v.CurrentReceived, v.BaselineLate, v.BaselineReceived, lateRateThreshold); f != nil {
+		findings = append(findings, *f)
+	}
+
+	return &DataSourceReport{
+		DataSourceID:   ds.ID,
+		DataSourceName: ds.Name,
+		Measurements:   v.Current,
+		Findings:       findings,
+	}, nil
+}
+
+// profileFindings returns the cardinality growth and schema drift of the
+// profiled keys. Measurements and keys appearing or disappearing are only
+// reported when the data source has data both in the week and before it, so
+// new and abandoned data sources don't report every measurement.
+func profileFindings(profiles []keyProfile, compareNames bool) []Finding {
+	type presence struct{ current, baseline bool }
+	names := make(map[string]presence)
+	for _, p := range profiles {
+		n := names[p.Name]
+		names[p.Name] = presence{current: n.current || p.InCurrent, baseline: n.baseline || p.InBaseline}
+	}
+
+	findings := []Finding{}
+	reported := make(map[string]bool)
+	for _, p := range profiles {
+		n := names[p.Name]
+		if compareNames && !reported[p.Name] {
+			reported[p.Name] = true
+			switch {
+			case n.current && !n.baseline:
+				findings = append(findings, Finding{Check: CheckSchemaDrift, MeasurementName: p.Name, Message: "new measurement"})
+			case n.baseline && !n.current:
+				findings = append(findings, Finding{Check: CheckSchemaDrift, MeasurementName: p.Name, Message: "no measurements this week"})
+			}
+		}
+		if p.Key == nil {
+			continue
+		}
+
+		switch {
+		case p.InCurrent && !p.InBaseline && n.baseline:
+			findings = append(findings, Finding{
+				Check: CheckSchemaDrift, MeasurementName: p.Name, Key: *p.Key,
+				Message: fmt.Sprintf("new metadata key %q", *p.Key),
+			})
+		case p.InBaseline && !p.InCurrent && n.current:
+			findings = append(findings, Finding{
+				Check: CheckSchemaDrift, MeasurementName: p.Name, Key: *p.Key,
+				Message: fmt.Sprintf("metadata key %q is no longer sent", *p.Key),
+			})
+		}
+
+		if p.CurrentValues >= minCardinality && p.PreviousValues > 0 && p.CurrentValues >= cardinalityGrowthFactor*p.PreviousValues {
+			current, previous := float64(p.CurrentValues), float64(p.PreviousValues)
+			findings = append(findings, Finding{
+				Check: CheckCardinality, MeasurementName: p.Name, Key: *p.Key,
+				Message:  fmt.Sprintf("metadata key %q has %d distinct values, up from %d the week before", *p.Key, p.CurrentValues, p.PreviousValues),
+				Current:  &current,
+				Baseline: &previous,
+			})
+		}
+	}
+	return findings
+}
+
+// rateFinding returns a finding if the week's rate of count in total exceeds
+// threshold and grew by spikeFactor over the baseline rate.
+func rateFinding(check Check, what string, count, total, baselineCount, baselineTotal int64, threshold float64) *Finding {
+	if total == 0 {
+		return nil
+	}
+	current := float64(count) / float64(total)
+	baseline := 0.0
+	if baselineTotal > 0 {
+		baseline = float64(baselineCount) / float64(baselineTotal)
+	}
+	if current < threshold || current < spikeFactor*baseline {
+		return nil
+	}
+
+	return &Finding{
+		Check:    check,
+		Message:  fmt.Sprintf("%.1f%% %s, up from %.1f%% in the previous %d weeks", current*100, what, baseline*100, baselineWeeks),
+		Current:  &current,
+		Baseline: &baseline,
+	}
+}
+
+// weekStart returns midnight UTC of the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
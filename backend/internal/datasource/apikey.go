@@ -0,0 +1,119 @@
+package datasource
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	apiKeyPrefix       = "lk_"
+	apiKeyBytes        = 32
+	apiKeyLookupLength = 12 // Leading characters of the key stored in plain text for lookup
+
+	publicKeyPrefix = "lkp_"
+	publicKeyBytes  = 18
+
+	// Argon2id parameters (OWASP minimum recommendation).
+	argon2Time    = 2
+	argon2Memory  = 19 * 1024 // KiB
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// generateAPIKey generates a new API key, its lookup prefix and its hash.
+func generateAPIKey() (plainKey, prefix, hash string, err error) {
+	bytes := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", "", err
+	}
+
+	plainKey = apiKeyPrefix + base64.URLEncoding.EncodeToString(bytes)
+
+	hash, err = hashAPIKey(plainKey)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return plainKey, apiKeyLookupPrefix(plainKey), hash, nil
+}
+
+// generatePublicKey generates a new public key for browser ingestion. Public
+// keys are not secret, so they are stored as is.
+func generatePublicKey() (string, error) {
+	bytes := make([]byte, publicKeyBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return publicKeyPrefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// apiKeyLookupPrefix returns the indexed, non-secret part of an API key.
+func apiKeyLookupPrefix(plainKey string) string {
+	if len(plainKey) < apiKeyLookupLength {
+		return plainKey
+	}
+	return plainKey[:apiKeyLookupLength]
+}
+
+// hashAPIKey hashes an API key with Argon2id and returns it in PHC string format.
+func hashAPIKey(plainKey string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(plainKey), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// verifyAPIKey checks a plain API key against a stored Argon2id hash in constant time.
+func verifyAPIKey(plainKey, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false
+	}
+
+	actual := argon2.IDKey([]byte(plainKey), salt, time, memory, threads, uint32(len(expected)))
+
+	return subtle.ConstantTimeCompare(actual, expected) == 1
+}
+
+// legacyAPIKeyHash returns the unsalted SHA-256 hash used for keys created
+// before Argon2id hashing was introduced.
+func legacyAPIKeyHash(plainKey string) string {
+	hashBytes := sha256.Sum256([]byte(plainKey))
+	return hex.EncodeToString(hashBytes[:])
+}
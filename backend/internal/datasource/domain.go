@@ -2,19 +2,35 @@ package datasource
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // DataSource represents a data source in the system.
 type DataSource struct {
-	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	OrganizationID uuid.UUID `json:"organizationId"`
-	APIKeyHash     string    `json:"-"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
+	ID                uuid.UUID          `json:"id"`
+	Name              string             `json:"name"`
+	OrganizationID    uuid.UUID          `json:"organizationId"`
+	APIKeyPrefix      *string            `json:"-"`
+	APIKeyHash        string             `json:"-"`
+	AllowedCIDRs      []string           `json:"allowedCidrs"`         // Empty allows any network
+	RelaxedUniqueness bool               `json:"relaxedUniqueness"`    // Measurements may share a name and timestamp; only event IDs deduplicate
+	PublicKey         *string            `json:"publicKey,omitempty"`  // Key for ingesting from browsers; safe to embed in web pages
+	AllowedOrigins    []string           `json:"allowedOrigins"`       // Origins that may use the public key; empty allows none
+	ExternalID        *string            `json:"externalId,omitempty"` // Client-supplied key, unique within the organization
+	LastUsedAt        *time.Time         `json:"lastUsedAt,omitempty"`
+	MaxTimestamp      *time.Time         `json:"maxTimestamp,omitempty"`   // Newest measurement timestamp ingested; data is complete through it
+	LastReceivedAt    *time.Time         `json:"lastReceivedAt,omitempty"` // When measurements were last ingested
+	BackfilledAt      *time.Time         `json:"backfilledAt,omitempty"`   // When late measurements last landed before MaxTimestamp
+	CreatedAt         time.Time          `json:"createdAt"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+	History           []writeaudit.Entry `json:"history,omitempty"` // With includeHistory=true, newest first
 }
 
 // Error definitions
@@ -22,11 +38,138 @@ var (
 	ErrDataSourceNotFound  = errors.New("data source not found")
 	ErrUnauthorized        = errors.New("unauthorized")
 	ErrDataSourceNameEmpty = errors.New("data source name is required")
+	ErrInvalidAPIKey       = errors.New("invalid API key")
+	ErrInvalidCIDR         = errors.New("invalid CIDR")
+	ErrTooManyCIDRs        = errors.New("too many CIDR entries")
+	ErrNoDefaultDataSource = errors.New("dataSourceId is required when the organization has no default data source")
+	ErrInvalidDeletionMode = errors.New("mode must be cascade or orphan")
+	ErrInvalidPublicKey    = errors.New("invalid public key")
+	ErrInvalidOrigin       = errors.New("invalid origin")
+	ErrTooManyOrigins      = errors.New("too many origins")
+
+	ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+	ErrInvalidMaintenanceWindow  = errors.New("endsAt must be after startsAt")
+	ErrMaintenanceReasonTooLong  = errors.New("reason must be at most 255 characters")
+)
+
+// MaxAllowedCIDRs is the maximum number of entries in a data source's CIDR allowlist.
+const MaxAllowedCIDRs = 50
+
+// MaxAllowedOrigins is the maximum number of entries in a data source's origin allowlist.
+const MaxAllowedOrigins = 50
+
+// AllowsIP reports whether the data source's API key may be used from ip.
+// An empty allowlist allows every address.
+func (ds *DataSource) AllowsIP(ip net.IP) bool {
+	if len(ds.AllowedCIDRs) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range ds.AllowedCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsOrigin reports whether the data source's public key may be used by
+// pages served from origin. Entries match exactly, or, written as
+// https://*.example.com, any subdomain. An empty allowlist allows no origin.
+func (ds *DataSource) AllowsOrigin(origin string) bool {
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	if origin == "" || origin == "null" {
+		return false
+	}
+	for _, allowed := range ds.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+		scheme, host, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeletionMode chooses what happens to the dependents of a deleted data source.
+type DeletionMode string
+
+const (
+	DeletionModeCascade DeletionMode = "cascade" // Delete dependent metrics, their goals, and saved queries
+	DeletionModeOrphan  DeletionMode = "orphan"  // Keep dependent metrics, flagged as orphaned
 )
 
+// IsValid checks if the deletion mode is valid. The empty mode only deletes
+// data sources without dependents.
+func (m DeletionMode) IsValid() bool {
+	switch m {
+	case "", DeletionModeCascade, DeletionModeOrphan:
+		return true
+	}
+	return false
+}
+
+// DeletionImpact lists what depends on a data source.
+type DeletionImpact struct {
+	Metrics      []DependentMetric `json:"metrics"`
+	Goals        []Dependent       `json:"goals"` // Goals tracking the dependent metrics
+	SavedQueries []Dependent       `json:"savedQueries"`
+}
+
+// IsEmpty reports whether nothing depends on the data source.
+func (i *DeletionImpact) IsEmpty() bool {
+	return len(i.Metrics) == 0 && len(i.Goals) == 0 && len(i.SavedQueries) == 0
+}
+
+// DependentMetric is a dashboard metric that queries a data source.
+type DependentMetric struct {
+	ID            uuid.UUID `json:"id"`
+	Label         string    `json:"label"`
+	DashboardID   uuid.UUID `json:"dashboardId"`
+	DashboardName string    `json:"dashboardName"`
+}
+
+// Dependent is a named resource that depends on a data source.
+type Dependent struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// DependentsError is returned when deleting a data source that has
+// dependents without choosing a deletion mode.
+type DependentsError struct {
+	Impact DeletionImpact
+}
+
+func (e *DependentsError) Error() string {
+	return fmt.Sprintf("data source has %d metrics, %d goals and %d saved queries depending on it", len(e.Impact.Metrics), len(e.Impact.Goals), len(e.Impact.SavedQueries))
+}
+
+// MaintenanceWindow is a scheduled period of maintenance on a data source.
+// Charts of metrics querying the data source are annotated with it.
+type MaintenanceWindow struct {
+	ID           uuid.UUID  `json:"id"`
+	DataSourceID uuid.UUID  `json:"dataSourceId"`
+	StartsAt     time.Time  `json:"startsAt"`
+	EndsAt       time.Time  `json:"endsAt"`
+	Reason       string     `json:"reason"`
+	CreatedBy    *uuid.UUID `json:"createdBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
 // CreateDataSourceRequest is the request body for creating a data source.
 type CreateDataSourceRequest struct {
-	Name string `json:"name"`
+	Name       string  `json:"name" validate:"required,max=255"`
+	ExternalID *string `json:"externalId,omitempty"`
 }
 
 // CreateDataSourceResponse is the response body for data source creation.
@@ -35,6 +178,34 @@ type CreateDataSourceResponse struct {
 	APIKey     string     `json:"apiKey"`
 }
 
+// PutDataSourceRequest is the request body for creating or updating a data
+// source by its external ID. Omitted settings are left as they are.
+type PutDataSourceRequest struct {
+	Name              string   `json:"name" validate:"required,max=255"`
+	AllowedCIDRs      []string `json:"allowedCidrs,omitempty"`
+	RelaxedUniqueness *bool    `json:"relaxedUniqueness,omitempty"`
+}
+
+// UpdateAllowedCIDRsRequest is the request body for updating a data source's CIDR allowlist.
+type UpdateAllowedCIDRsRequest struct {
+	AllowedCIDRs []string `json:"allowedCidrs"` // CIDRs or single IP addresses; empty allows any network
+}
+
+// UpdateUniquenessRequest is the request body for updating how a data source deduplicates measurements.
+type UpdateUniquenessRequest struct {
+	RelaxedUniqueness bool `json:"relaxedUniqueness"` // Allow measurements with the same name and timestamp
+}
+
+// UpdateAllowedOriginsRequest is the request body for updating the origin allowlist of a data source's public key.
+type UpdateAllowedOriginsRequest struct {
+	AllowedOrigins []string `json:"allowedOrigins"` // e.g. https://example.com or https://*.example.com
+}
+
+// PublicKeyResponse is the response body for public key generation.
+type PublicKeyResponse struct {
+	PublicKey string `json:"publicKey"`
+}
+
 // RegenerateKeyResponse is the response body for API key regeneration.
 type RegenerateKeyResponse struct {
 	APIKey string `json:"apiKey"`
@@ -42,7 +213,30 @@ type RegenerateKeyResponse struct {
 
 // ListDataSourcesResponse is the response body for listing data sources.
 type ListDataSourcesResponse struct {
-	DataSources []DataSource `json:"dataSources"`
+	DataSources         []DataSource `json:"dataSources"`
+	DefaultDataSourceID *uuid.UUID   `json:"defaultDataSourceId,omitempty"`
+}
+
+// DefaultDataSourceRequest is the request body for setting the default data source.
+type DefaultDataSourceRequest struct {
+	DataSourceID *uuid.UUID `json:"dataSourceId"` // Null clears the default
+}
+
+// DefaultDataSourceResponse is the response body for the default data source.
+type DefaultDataSourceResponse struct {
+	DataSourceID *uuid.UUID `json:"dataSourceId"` // Null when no default is set
+}
+
+// MaintenanceWindowRequest is the request body for creating or updating a maintenance window.
+type MaintenanceWindowRequest struct {
+	StartsAt time.Time `json:"startsAt" validate:"required"`
+	EndsAt   time.Time `json:"endsAt" validate:"required"`
+	Reason   string    `json:"reason" validate:"max=255"`
+}
+
+// ListMaintenanceWindowsResponse is the response for listing maintenance windows.
+type ListMaintenanceWindowsResponse struct {
+	Windows []MaintenanceWindow `json:"windows"` // Latest start first
 }
 
 // MessageResponse is a generic response with a message.
@@ -54,3 +248,10 @@ type MessageResponse struct {
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// DependentsErrorResponse is the response for deleting a data source that
+// still has dependents.
+type DependentsErrorResponse struct {
+	Error  string         `json:"error"`
+	Impact DeletionImpact `json:"impact"`
+}
@@ -10,6 +10,10 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // Handler handles HTTP requests for data sources.
@@ -47,7 +51,84 @@ func (h *Handler) ListDataSources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, ListDataSourcesResponse{DataSources: dataSources})
+	defaultID, err := h.service.GetDefaultDataSourceID(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("get default data source error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list data sources")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListDataSourcesResponse{DataSources: dataSources, DefaultDataSourceID: defaultID})
+}
+
+// GetDefaultDataSource handles getting the organization's default data source.
+//
+//	@Summary		Get default data source
+//	@Description	Get the data source used when widget APIs omit dataSourceId. Null when no default is set.
+//	@Tags			data-sources
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	DefaultDataSourceResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/data-sources/default [get]
+func (h *Handler) GetDefaultDataSource(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := h.service.GetDefaultDataSourceID(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("get default data source error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get default data source")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, DefaultDataSourceResponse{DataSourceID: id})
+}
+
+// SetDefaultDataSource handles setting the organization's default data source.
+//
+//	@Summary		Set default data source
+//	@Description	Set the data source used when widget APIs omit dataSourceId, or clear it with null. Admin only.
+//	@Tags			data-sources
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		DefaultDataSourceRequest	true	"Default data source"
+//	@Success		200		{object}	DefaultDataSourceResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/data-sources/default [put]
+func (h *Handler) SetDefaultDataSource(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req DefaultDataSourceRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	if err := h.service.SetDefaultDataSource(r.Context(), user.OrganizationID, req.DataSourceID); err != nil {
+		if errors.Is(err, ErrDataSourceNotFound) || errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		log.Printf("set default data source error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to set default data source")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, DefaultDataSourceResponse{DataSourceID: req.DataSourceID})
 }
 
 // GetDataSource handles getting a single data source by ID.
@@ -57,12 +138,13 @@ func (h *Handler) ListDataSources(w http.ResponseWriter, r *http.Request) {
 //	@Tags			data-sources
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id	path		string	true	"Data Source ID"
-//	@Success		200	{object}	DataSource
-//	@Failure		401	{object}	ErrorResponse
-//	@Failure		403	{object}	ErrorResponse
-//	@Failure		404	{object}	ErrorResponse
-//	@Failure		500	{object}	ErrorResponse
+//	@Param			id				path		string	true	"Data Source ID"
+//	@Param			includeHistory	query		bool	false	"Include the write history of the data source"
+//	@Success		200				{object}	DataSource
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		403				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
 //	@Router			/data-sources/{id} [get]
 func (h *Handler) GetDataSource(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
@@ -92,13 +174,22 @@ func (h *Handler) GetDataSource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if writeaudit.IncludeHistory(r) {
+		ds.History, err = h.service.History(r.Context(), user.OrganizationID, dataSourceID)
+		if err != nil {
+			log.Printf("get data source history error: %v", err)
+			respondError(w, http.StatusInternalServerError, "failed to get data source history")
+			return
+		}
+	}
+
 	respondJSON(w, http.StatusOK, ds)
 }
 
 // CreateDataSource handles creating a new data source.
 //
 //	@Summary		Create data source
-//	@Description	Create a new data source and return its API key (shown only once). Requires admin role.
+//	@Description	Create a new data source, optionally with an external ID unique within the organization, and return its API key (shown only once). Requires admin role.
 //	@Tags			data-sources
 //	@Accept			json
 //	@Produce		json
@@ -107,7 +198,9 @@ func (h *Handler) GetDataSource(w http.ResponseWriter, r *http.Request) {
 //	@Success		201		{object}	CreateDataSourceResponse
 //	@Failure		400		{object}	ErrorResponse
 //	@Failure		401		{object}	ErrorResponse
+//	@Failure		402		{object}	ErrorResponse	"Data source quota reached"
 //	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse	"External ID in use"
 //	@Failure		500		{object}	ErrorResponse
 //	@Router			/data-sources [post]
 func (h *Handler) CreateDataSource(w http.ResponseWriter, r *http.Request) {
@@ -118,8 +211,8 @@ func (h *Handler) CreateDataSource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req CreateDataSourceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -129,6 +222,18 @@ func (h *Handler) CreateDataSource(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "data source name is required")
 			return
 		}
+		if errors.Is(err, externalid.ErrInvalid) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, externalid.ErrTaken) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondError(w, http.StatusPaymentRequired, err.Error())
+			return
+		}
 		log.Printf("create data source error: %v", err)
 		respondError(w, http.StatusInternalServerError, "failed to create data source")
 		return
@@ -137,19 +242,164 @@ func (h *Handler) CreateDataSource(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusCreated, response)
 }
 
-// DeleteDataSource handles deleting a data source.
+// GetDataSourceByExternalID handles getting a data source by its external ID.
 //
-//	@Summary		Delete data source
-//	@Description	Delete a data source by ID. Requires admin role.
+//	@Summary		Get data source by external ID
+//	@Description	Get a data source by the external ID it was created or put with
+//	@Tags			data-sources
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			externalId	path		string	true	"External ID"
+//	@Success		200			{object}	DataSource
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/data-sources/external/{externalId} [get]
+func (h *Handler) GetDataSourceByExternalID(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	ds, err := h.service.GetDataSourceByExternalID(r.Context(), user.OrganizationID, chi.URLParam(r, "externalId"))
+	if err != nil {
+		if errors.Is(err, externalid.ErrInvalid) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, ErrDataSourceNotFound) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		log.Printf("get data source by external ID error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get data source")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ds)
+}
+
+// PutDataSource handles creating or updating a data source by its external ID.
+//
+//	@Summary		Create or update data source by external ID
+//	@Description	Create the data source with the given external ID, or update its name and the settings given if it exists, so that clients such as Terraform providers can converge it without tracking its ID. A created data source is returned with its API key (shown only once); an existing one without. Putting the same request again changes nothing. Requires admin role.
+//	@Tags			data-sources
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			externalId	path		string						true	"External ID"
+//	@Param			request		body		PutDataSourceRequest		true	"Data source data"
+//	@Success		200			{object}	DataSource					"Updated or unchanged"
+//	@Success		201			{object}	CreateDataSourceResponse	"Created"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		402			{object}	ErrorResponse	"Data source quota reached"
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/data-sources/external/{externalId} [put]
+func (h *Handler) PutDataSource(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req PutDataSourceRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	response, created, err := h.service.PutDataSource(r.Context(), user.OrganizationID, chi.URLParam(r, "externalId"), req)
+	if err != nil {
+		if errors.Is(err, externalid.ErrInvalid) || errors.Is(err, ErrInvalidCIDR) || errors.Is(err, ErrTooManyCIDRs) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, ErrDataSourceNameEmpty) {
+			respondError(w, http.StatusBadRequest, "data source name is required")
+			return
+		}
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondError(w, http.StatusPaymentRequired, err.Error())
+			return
+		}
+		log.Printf("put data source error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to put data source")
+		return
+	}
+
+	if created {
+		respondJSON(w, http.StatusCreated, response)
+		return
+	}
+	respondJSON(w, http.StatusOK, response.DataSource)
+}
+
+// GetDeletionImpact handles listing what depends on a data source.
+//
+//	@Summary		Get data source deletion impact
+//	@Description	List the dashboard metrics, goals and saved queries that depend on a data source, to review before deleting it. Requires admin role.
 //	@Tags			data-sources
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			id	path		string	true	"Data Source ID"
-//	@Success		200	{object}	MessageResponse
+//	@Success		200	{object}	DeletionImpact
+//	@Failure		400	{object}	ErrorResponse
 //	@Failure		401	{object}	ErrorResponse
 //	@Failure		403	{object}	ErrorResponse
 //	@Failure		404	{object}	ErrorResponse
 //	@Failure		500	{object}	ErrorResponse
+//	@Router			/data-sources/{id}/deletion-impact [get]
+func (h *Handler) GetDeletionImpact(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	impact, err := h.service.GetDeletionImpact(r.Context(), user.OrganizationID, dataSourceID)
+	if err != nil {
+		if errors.Is(err, ErrDataSourceNotFound) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		log.Printf("get deletion impact error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get deletion impact")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, impact)
+}
+
+// DeleteDataSource handles deleting a data source.
+//
+//	@Summary		Delete data source
+//	@Description	Delete a data source by ID. If metrics, goals or saved queries depend on it, the request fails with 409 and lists them unless mode is set: cascade deletes them along with the data source, orphan keeps the metrics flagged with orphanedAt (they compute to a "data source deleted" error). Requires admin role.
+//	@Tags			data-sources
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Data Source ID"
+//	@Param			mode	query		string	false	"What to do with dependents"	Enums(cascade, orphan)
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	DependentsErrorResponse
+//	@Failure		500		{object}	ErrorResponse
 //	@Router			/data-sources/{id} [delete]
 func (h *Handler) DeleteDataSource(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
@@ -164,8 +414,21 @@ func (h *Handler) DeleteDataSource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeleteDataSource(r.Context(), user.OrganizationID, dataSourceID)
+	mode := DeletionMode(r.URL.Query().Get("mode"))
+	err = h.service.DeleteDataSource(r.Context(), user.OrganizationID, dataSourceID, mode)
 	if err != nil {
+		var dependentsErr *DependentsError
+		if errors.As(err, &dependentsErr) {
+			respondJSON(w, http.StatusConflict, DependentsErrorResponse{
+				Error:  dependentsErr.Error() + "; pass mode=cascade or mode=orphan to delete it",
+				Impact: dependentsErr.Impact,
+			})
+			return
+		}
+		if errors.Is(err, ErrInvalidDeletionMode) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if errors.Is(err, ErrDataSourceNotFound) {
 			respondError(w, http.StatusNotFound, "data source not found")
 			return
@@ -227,6 +490,462 @@ func (h *Handler) RegenerateAPIKey(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, response)
 }
 
+// UpdateAllowedCIDRs handles replacing the CIDR allowlist for a data source's API key.
+//
+//	@Summary		Update allowed networks
+//	@Description	Restrict the data source's API key to a list of CIDRs or IP addresses. An empty list allows any network. Requires admin role.
+//	@Tags			data-sources
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string						true	"Data Source ID"
+//	@Param			request	body		UpdateAllowedCIDRsRequest	true	"Allowed networks"
+//	@Success		200		{object}	DataSource
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/data-sources/{id}/allowed-cidrs [put]
+func (h *Handler) UpdateAllowedCIDRs(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	var req UpdateAllowedCIDRsRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	ds, err := h.service.UpdateAllowedCIDRs(r.Context(), user.OrganizationID, dataSourceID, req)
+	if err != nil {
+		if errors.Is(err, ErrDataSourceNotFound) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		if errors.Is(err, ErrInvalidCIDR) || errors.Is(err, ErrTooManyCIDRs) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("update allowed CIDRs error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update allowed networks")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ds)
+}
+
+// GeneratePublicKey handles creating or rotating the public key of a data source.
+//
+//	@Summary		Generate public key
+//	@Description	Create or rotate the data source's public key for browser ingestion. Public keys are meant to be embedded in web pages and only work from the data source's allowed origins. The previous public key stops working. Requires admin role.
+//	@Tags			data-sources
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Data Source ID"
+//	@Success		200	{object}	PublicKeyResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/data-sources/{id}/public-key [post]
+func (h *Handler) GeneratePublicKey(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	response, err := h.service.GeneratePublicKey(r.Context(), user.OrganizationID, dataSourceID)
+	if err != nil {
+		if errors.Is(err, ErrDataSourceNotFound) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		log.Printf("generate public key error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to generate public key")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// RevokePublicKey handles removing the public key of a data source.
+//
+//	@Summary		Revoke public key
+//	@Description	Remove the data source's public key, disabling browser ingestion. Requires admin role.
+//	@Tags			data-sources
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Data Source ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/data-sources/{id}/public-key [delete]
+func (h *Handler) RevokePublicKey(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	if err := h.service.RevokePublicKey(r.Context(), user.OrganizationID, dataSourceID); err != nil {
+		if errors.Is(err, ErrDataSourceNotFound) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		log.Printf("revoke public key error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to revoke public key")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "public key revoked"})
+}
+
+// UpdateAllowedOrigins handles replacing the origin allowlist of a data source's public key.
+//
+//	@Summary		Update allowed origins
+//	@Description	Set the origins (e.g. https://example.com or https://*.example.com) whose pages may ingest with the data source's public key. An empty list blocks browser ingestion. Requires admin role.
+//	@Tags			data-sources
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string						true	"Data Source ID"
+//	@Param			request	body		UpdateAllowedOriginsRequest	true	"Allowed origins"
+//	@Success		200		{object}	DataSource
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/data-sources/{id}/allowed-origins [put]
+func (h *Handler) UpdateAllowedOrigins(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	var req UpdateAllowedOriginsRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	ds, err := h.service.UpdateAllowedOrigins(r.Context(), user.OrganizationID, dataSourceID, req)
+	if err != nil {
+		if errors.Is(err, ErrDataSourceNotFound) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		if errors.Is(err, ErrInvalidOrigin) || errors.Is(err, ErrTooManyOrigins) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("update allowed origins error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update allowed origins")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ds)
+}
+
+// UpdateUniqueness handles setting whether a data source's measurements must be unique by name and timestamp.
+//
+//	@Summary		Update measurement uniqueness
+//	@Description	By default a data source rejects a measurement with the same name and timestamp as a stored one. With relaxed uniqueness, identical measurements (e.g. two purchases in the same millisecond) are all stored, and only those sent with the same eventId are rejected as duplicates. Requires admin role.
+//	@Tags			data-sources
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Data Source ID"
+//	@Param			request	body		UpdateUniquenessRequest	true	"Uniqueness setting"
+//	@Success		200		{object}	DataSource
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/data-sources/{id}/uniqueness [put]
+func (h *Handler) UpdateUniqueness(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	var req UpdateUniquenessRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	ds, err := h.service.UpdateUniqueness(r.Context(), user.OrganizationID, dataSourceID, req)
+	if err != nil {
+		if errors.Is(err, ErrDataSourceNotFound) {
+			respondError(w, http.StatusNotFound, "data source not found")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			respondError(w, http.StatusForbidden, "unauthorized")
+			return
+		}
+		log.Printf("update uniqueness error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update uniqueness")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ds)
+}
+
+// ListMaintenanceWindows handles listing the maintenance windows of a data source.
+//
+//	@Summary		List maintenance windows
+//	@Description	Get the scheduled maintenance windows of a data source, latest start first
+//	@Tags			data-sources
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Data Source ID"
+//	@Success		200	{object}	ListMaintenanceWindowsResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/data-sources/{id}/maintenance-windows [get]
+func (h *Handler) ListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	windows, err := h.service.ListMaintenanceWindows(r.Context(), user.OrganizationID, dataSourceID)
+	if err != nil {
+		respondMaintenanceWindowError(w, err, "list maintenance windows")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListMaintenanceWindowsResponse{Windows: windows})
+}
+
+// CreateMaintenanceWindow handles scheduling a maintenance window on a data source.
+//
+//	@Summary		Create maintenance window
+//	@Description	Schedule maintenance on a data source. Charts of metrics querying the data source are annotated with the window. Requires admin role.
+//	@Tags			data-sources
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string						true	"Data Source ID"
+//	@Param			request	body		MaintenanceWindowRequest	true	"Maintenance window"
+//	@Success		201		{object}	MaintenanceWindow
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/data-sources/{id}/maintenance-windows [post]
+func (h *Handler) CreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	var req MaintenanceWindowRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	window, err := h.service.CreateMaintenanceWindow(r.Context(), user.OrganizationID, dataSourceID, user.ID, req)
+	if err != nil {
+		respondMaintenanceWindowError(w, err, "create maintenance window")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, window)
+}
+
+// UpdateMaintenanceWindow handles rescheduling a maintenance window.
+//
+//	@Summary		Update maintenance window
+//	@Description	Change the schedule or reason of a maintenance window. Requires admin role.
+//	@Tags			data-sources
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string						true	"Data Source ID"
+//	@Param			windowId	path		string						true	"Maintenance Window ID"
+//	@Param			request		body		MaintenanceWindowRequest	true	"Maintenance window"
+//	@Success		200			{object}	MaintenanceWindow
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/data-sources/{id}/maintenance-windows/{windowId} [put]
+func (h *Handler) UpdateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	windowID, err := uuid.Parse(chi.URLParam(r, "windowId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid maintenance window ID")
+		return
+	}
+
+	var req MaintenanceWindowRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	window, err := h.service.UpdateMaintenanceWindow(r.Context(), user.OrganizationID, dataSourceID, windowID, req)
+	if err != nil {
+		respondMaintenanceWindowError(w, err, "update maintenance window")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, window)
+}
+
+// DeleteMaintenanceWindow handles deleting a maintenance window.
+//
+//	@Summary		Delete maintenance window
+//	@Description	Delete a maintenance window of a data source. Requires admin role.
+//	@Tags			data-sources
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Data Source ID"
+//	@Param			windowId	path		string	true	"Maintenance Window ID"
+//	@Success		200			{object}	MessageResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/data-sources/{id}/maintenance-windows/{windowId} [delete]
+func (h *Handler) DeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataSourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid data source ID")
+		return
+	}
+
+	windowID, err := uuid.Parse(chi.URLParam(r, "windowId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid maintenance window ID")
+		return
+	}
+
+	if err := h.service.DeleteMaintenanceWindow(r.Context(), user.OrganizationID, dataSourceID, windowID); err != nil {
+		respondMaintenanceWindowError(w, err, "delete maintenance window")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "maintenance window deleted"})
+}
+
+// respondMaintenanceWindowError maps errors of the maintenance window
+// operations to responses; action names the operation for logs and messages.
+func respondMaintenanceWindowError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, ErrDataSourceNotFound):
+		respondError(w, http.StatusNotFound, "data source not found")
+	case errors.Is(err, ErrMaintenanceWindowNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "unauthorized")
+	case errors.Is(err, ErrInvalidMaintenanceWindow), errors.Is(err, ErrMaintenanceReasonTooLong):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("%s error: %v", action, err)
+		respondError(w, http.StatusInternalServerError, "failed to "+action)
+	}
+}
+
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,94 @@
+package datasource
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// verifiedKeyTTL is how long a verified API key is trusted without
+	// running Argon2id again.
+	verifiedKeyTTL = time.Minute
+
+	// lastUsedInterval is how often the last used timestamp of a data
+	// source is written at most.
+	lastUsedInterval = time.Minute
+)
+
+// keyCache remembers the API keys that recently passed Argon2id
+// verification, so that a busy producer is not verified on every request.
+// Only keys that verified are remembered, so guessing cannot grow it. An
+// entry is tied to the stored hash it verified against, so a regenerated or
+// revoked key stops matching at once.
+type keyCache struct {
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]verifiedKey
+	lastUsed map[uuid.UUID]time.Time
+	swept    time.Time
+}
+
+type verifiedKey struct {
+	hash    string // Stored Argon2id hash the key verified against
+	expires time.Time
+}
+
+func newKeyCache() *keyCache {
+	return &keyCache{
+		verified: make(map[[sha256.Size]byte]verifiedKey),
+		lastUsed: make(map[uuid.UUID]time.Time),
+		swept:    time.Now(),
+	}
+}
+
+// matches reports whether plainKey recently verified against storedHash.
+func (c *keyCache) matches(plainKey, storedHash string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.verified[sha256.Sum256([]byte(plainKey))]
+	return ok && now.Before(v.expires) && v.hash == storedHash
+}
+
+// remember records that plainKey verified against storedHash.
+func (c *keyCache) remember(plainKey, storedHash string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(now)
+	c.verified[sha256.Sum256([]byte(plainKey))] = verifiedKey{hash: storedHash, expires: now.Add(verifiedKeyTTL)}
+}
+
+// touch reports whether the last used timestamp of a data source is due to
+// be written, and if so, records it as written.
+func (c *keyCache) touch(id uuid.UUID, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(now)
+	if last, ok := c.lastUsed[id]; ok && now.Sub(last) < lastUsedInterval {
+		return false
+	}
+	c.lastUsed[id] = now
+	return true
+}
+
+// sweep drops expired entries once per TTL. The caller must hold c.mu.
+func (c *keyCache) sweep(now time.Time) {
+	if now.Sub(c.swept) < verifiedKeyTTL {
+		return
+	}
+	for key, v := range c.verified {
+		if !now.Before(v.expires) {
+			delete(c.verified, key)
+		}
+	}
+	for id, last := range c.lastUsed {
+		if now.Sub(last) >= lastUsedInterval {
+			delete(c.lastUsed, id)
+		}
+	}
+	c.swept = now
+}
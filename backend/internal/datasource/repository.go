@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
 )
 
 // Repository handles database operations for data sources.
@@ -20,22 +22,30 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
-// CreateDataSource creates a new data source.
-func (r *Repository) CreateDataSource(ctx context.Context, orgID uuid.UUID, name, apiKeyHash string) (*DataSource, error) {
+// CreateDataSource creates a new data source. It returns externalid.ErrTaken
+// if another data source of the organization has the external ID.
+func (r *Repository) CreateDataSource(ctx context.Context, orgID uuid.UUID, name string, externalID *string, apiKeyPrefix, apiKeyHash string) (*DataSource, error) {
 	ds := &DataSource{
 		ID:             uuid.New(),
 		Name:           name,
 		OrganizationID: orgID,
+		APIKeyPrefix:   &apiKeyPrefix,
 		APIKeyHash:     apiKeyHash,
+		AllowedCIDRs:   []string{},
+		AllowedOrigins: []string{},
+		ExternalID:     externalID,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 
 	_, err := r.pool.Exec(ctx,
-		`INSERT INTO data_sources (id, name, organization_id, api_key_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
-		ds.ID, ds.Name, ds.OrganizationID, ds.APIKeyHash, ds.CreatedAt, ds.UpdatedAt,
+		`INSERT INTO data_sources (id, name, organization_id, api_key_prefix, api_key_hash, external_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		ds.ID, ds.Name, ds.OrganizationID, ds.APIKeyPrefix, ds.APIKeyHash, ds.ExternalID, ds.CreatedAt, ds.UpdatedAt,
 	)
+	if externalid.IsTaken(err, "idx_data_sources_external_id") {
+		return nil, externalid.ErrTaken
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -47,10 +57,29 @@ func (r *Repository) CreateDataSource(ctx context.Context, orgID uuid.UUID, name
 func (r *Repository) GetDataSourceByID(ctx context.Context, id uuid.UUID) (*DataSource, error) {
 	ds := &DataSource{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, name, organization_id, api_key_hash, created_at, updated_at
+		`SELECT id, name, organization_id, api_key_prefix, api_key_hash, allowed_cidrs, relaxed_uniqueness, public_key, allowed_origins, external_id, last_used_at, max_timestamp, last_received_at, backfilled_at, created_at, updated_at
 		FROM data_sources WHERE id = $1`,
 		id,
-	).Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyHash, &ds.CreatedAt, &ds.UpdatedAt)
+	).Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyPrefix, &ds.APIKeyHash, &ds.AllowedCIDRs, &ds.RelaxedUniqueness, &ds.PublicKey, &ds.AllowedOrigins, &ds.ExternalID, &ds.LastUsedAt, &ds.MaxTimestamp, &ds.LastReceivedAt, &ds.BackfilledAt, &ds.CreatedAt, &ds.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+// GetDataSourceByExternalID retrieves a data source of an organization by its external ID.
+func (r *Repository) GetDataSourceByExternalID(ctx context.Context, orgID uuid.UUID, externalID string) (*DataSource, error) {
+	ds := &DataSource{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, name, organization_id, api_key_prefix, api_key_hash, allowed_cidrs, relaxed_uniqueness, public_key, allowed_origins, external_id, last_used_at, max_timestamp, last_received_at, backfilled_at, created_at, updated_at
+		FROM data_sources WHERE organization_id = $1 AND external_id = $2`,
+		orgID, externalID,
+	).Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyPrefix, &ds.APIKeyHash, &ds.AllowedCIDRs, &ds.RelaxedUniqueness, &ds.PublicKey, &ds.AllowedOrigins, &ds.ExternalID, &ds.LastUsedAt, &ds.MaxTimestamp, &ds.LastReceivedAt, &ds.BackfilledAt, &ds.CreatedAt, &ds.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -65,7 +94,7 @@ func (r *Repository) GetDataSourceByID(ctx context.Context, id uuid.UUID) (*Data
 // GetDataSourcesByOrganizationID retrieves all data sources for an organization.
 func (r *Repository) GetDataSourcesByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]DataSource, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, name, organization_id, api_key_hash, created_at, updated_at
+		`SELECT id, name, organization_id, api_key_prefix, api_key_hash, allowed_cidrs, relaxed_uniqueness, public_key, allowed_origins, external_id, last_used_at, max_timestamp, last_received_at, backfilled_at, created_at, updated_at
 		FROM data_sources WHERE organization_id = $1
 		ORDER BY created_at DESC`,
 		orgID,
@@ -78,7 +107,7 @@ func (r *Repository) GetDataSourcesByOrganizationID(ctx context.Context, orgID u
 	var dataSources []DataSource
 	for rows.Next() {
 		var ds DataSource
-		if err := rows.Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyHash, &ds.CreatedAt, &ds.UpdatedAt); err != nil {
+		if err := rows.Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyPrefix, &ds.APIKeyHash, &ds.AllowedCIDRs, &ds.RelaxedUniqueness, &ds.PublicKey, &ds.AllowedOrigins, &ds.ExternalID, &ds.LastUsedAt, &ds.MaxTimestamp, &ds.LastReceivedAt, &ds.BackfilledAt, &ds.CreatedAt, &ds.UpdatedAt); err != nil {
 			return nil, err
 		}
 		dataSources = append(dataSources, ds)
@@ -91,32 +120,207 @@ func (r *Repository) GetDataSourcesByOrganizationID(ctx context.Context, orgID u
 	return dataSources, nil
 }
 
-// UpdateAPIKeyHash updates the API key hash for a data source.
-func (r *Repository) UpdateAPIKeyHash(ctx context.Context, id uuid.UUID, newHash string) error {
+// UpdateName renames a data source.
+func (r *Repository) UpdateName(ctx context.Context, id uuid.UUID, name string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE data_sources SET name = $1 WHERE id = $2`,
+		name, id,
+	)
+	return err
+}
+
+// UpdateAPIKey updates the API key prefix and hash for a data source.
+func (r *Repository) UpdateAPIKey(ctx context.Context, id uuid.UUID, newPrefix, newHash string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE data_sources SET api_key_prefix = $1, api_key_hash = $2 WHERE id = $3`,
+		newPrefix, newHash, id,
+	)
+	return err
+}
+
+// UpdateAllowedCIDRs replaces the CIDR allowlist for a data source.
+func (r *Repository) UpdateAllowedCIDRs(ctx context.Context, id uuid.UUID, cidrs []string) error {
 	_, err := r.pool.Exec(ctx,
-		`UPDATE data_sources SET api_key_hash = $1 WHERE id = $2`,
-		newHash, id,
+		`UPDATE data_sources SET allowed_cidrs = $1 WHERE id = $2`,
+		cidrs, id,
 	)
 	return err
 }
 
-// DeleteDataSource deletes a data source by its ID.
-func (r *Repository) DeleteDataSource(ctx context.Context, id uuid.UUID) error {
+// UpdateRelaxedUniqueness sets whether a data source opts out of the
+// uniqueness of measurements by name and timestamp.
+func (r *Repository) UpdateRelaxedUniqueness(ctx context.Context, id uuid.UUID, relaxed bool) error {
 	_, err := r.pool.Exec(ctx,
-		`DELETE FROM data_sources WHERE id = $1`,
+		`UPDATE data_sources SET relaxed_uniqueness = $1 WHERE id = $2`,
+		relaxed, id,
+	)
+	return err
+}
+
+// UpdatePublicKey sets or, with nil, removes the public key of a data source.
+func (r *Repository) UpdatePublicKey(ctx context.Context, id uuid.UUID, publicKey *string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE data_sources SET public_key = $1 WHERE id = $2`,
+		publicKey, id,
+	)
+	return err
+}
+
+// UpdateAllowedOrigins replaces the origin allowlist of a data source's public key.
+func (r *Repository) UpdateAllowedOrigins(ctx context.Context, id uuid.UUID, origins []string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE data_sources SET allowed_origins = $1 WHERE id = $2`,
+		origins, id,
+	)
+	return err
+}
+
+// UpdateLastUsed sets the last used timestamp of a data source's API key to now.
+func (r *Repository) UpdateLastUsed(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE data_sources SET last_used_at = NOW() WHERE id = $1`,
 		id,
 	)
 	return err
 }
 
-// GetDataSourceByAPIKeyHash retrieves a data source by its API key hash.
-func (r *Repository) GetDataSourceByAPIKeyHash(ctx context.Context, keyHash string) (*DataSource, error) {
+// GetDeletionImpact retrieves the metrics, goals and saved queries that
+// depend on a data source. Metrics in the trash are left out.
+func (r *Repository) GetDeletionImpact(ctx context.Context, id uuid.UUID) (*DeletionImpact, error) {
+	impact := &DeletionImpact{Metrics: []DependentMetric{}, Goals: []Dependent{}, SavedQueries: []Dependent{}}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.id, m.label, d.id, d.name
+		FROM metrics m JOIN dashboards d ON d.id = m.dashboard_id
+		WHERE m.data_source_id = $1 AND m.deleted_at IS NULL AND d.deleted_at IS NULL
+		ORDER BY d.name, m.position`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var m DependentMetric
+		if err := rows.Scan(&m.ID, &m.Label, &m.DashboardID, &m.DashboardName); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		impact.Metrics = append(impact.Metrics, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if impact.Goals, err = r.getDependents(ctx,
+		`SELECT g.id, g.name FROM goals g JOIN metrics m ON m.id = g.metric_id
+		WHERE m.data_source_id = $1 AND m.deleted_at IS NULL
+		ORDER BY g.name`,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	if impact.SavedQueries, err = r.getDependents(ctx,
+		`SELECT id, name FROM saved_queries WHERE query->>'dataSourceId' = $1::text ORDER BY name`,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	return impact, nil
+}
+
+func (r *Repository) getDependents(ctx context.Context, query string, id uuid.UUID) ([]Dependent, error) {
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dependents := []Dependent{}
+	for rows.Next() {
+		var d Dependent
+		if err := rows.Scan(&d.ID, &d.Name); err != nil {
+			return nil, err
+		}
+		dependents = append(dependents, d)
+	}
+	return dependents, rows.Err()
+}
+
+// DeleteDataSource deletes a data source by its ID. Dependent metrics,
+// including those in the trash, are deleted with it in cascade mode and
+// flagged as orphaned in orphan mode; dependent saved queries are deleted in
+// cascade mode.
+func (r *Repository) DeleteDataSource(ctx context.Context, id uuid.UUID, mode DeletionMode) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	switch mode {
+	case DeletionModeCascade:
+		if _, err := tx.Exec(ctx, `DELETE FROM metrics WHERE data_source_id = $1`, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM saved_queries WHERE query->>'dataSourceId' = $1::text`, id); err != nil {
+			return err
+		}
+	case DeletionModeOrphan:
+		if _, err := tx.Exec(ctx, `UPDATE metrics SET orphaned_at = NOW() WHERE data_source_id = $1`, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM data_sources WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetDataSourcesByAPIKeyPrefix retrieves the data sources whose API key starts with the given prefix.
+// Data sources of disabled organizations are excluded.
+func (r *Repository) GetDataSourcesByAPIKeyPrefix(ctx context.Context, prefix string) ([]DataSource, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, name, organization_id, api_key_prefix, api_key_hash, allowed_cidrs, relaxed_uniqueness, public_key, allowed_origins, external_id, last_used_at, max_timestamp, last_received_at, backfilled_at, created_at, updated_at
+		FROM data_sources WHERE api_key_prefix = $1
+		  AND organization_id NOT IN (SELECT id FROM organizations WHERE disabled_at IS NOT NULL)`,
+		prefix,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataSources []DataSource
+	for rows.Next() {
+		var ds DataSource
+		if err := rows.Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyPrefix, &ds.APIKeyHash, &ds.AllowedCIDRs, &ds.RelaxedUniqueness, &ds.PublicKey, &ds.AllowedOrigins, &ds.ExternalID, &ds.LastUsedAt, &ds.MaxTimestamp, &ds.LastReceivedAt, &ds.BackfilledAt, &ds.CreatedAt, &ds.UpdatedAt); err != nil {
+			return nil, err
+		}
+		dataSources = append(dataSources, ds)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dataSources, nil
+}
+
+// GetDataSourceByLegacyAPIKeyHash retrieves a data source whose API key has not
+// yet been migrated to a prefixed Argon2id hash.
+func (r *Repository) GetDataSourceByLegacyAPIKeyHash(ctx context.Context, keyHash string) (*DataSource, error) {
 	ds := &DataSource{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, name, organization_id, api_key_hash, created_at, updated_at
-		FROM data_sources WHERE api_key_hash = $1`,
+		`SELECT id, name, organization_id, api_key_prefix, api_key_hash, allowed_cidrs, relaxed_uniqueness, public_key, allowed_origins, external_id, last_used_at, max_timestamp, last_received_at, backfilled_at, created_at, updated_at
+		FROM data_sources WHERE api_key_hash = $1 AND api_key_prefix IS NULL
+		  AND organization_id NOT IN (SELECT id FROM organizations WHERE disabled_at IS NOT NULL)`,
 		keyHash,
-	).Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyHash, &ds.CreatedAt, &ds.UpdatedAt)
+	).Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyPrefix, &ds.APIKeyHash, &ds.AllowedCIDRs, &ds.RelaxedUniqueness, &ds.PublicKey, &ds.AllowedOrigins, &ds.ExternalID, &ds.LastUsedAt, &ds.MaxTimestamp, &ds.LastReceivedAt, &ds.BackfilledAt, &ds.CreatedAt, &ds.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -126,3 +330,119 @@ func (r *Repository) GetDataSourceByAPIKeyHash(ctx context.Context, keyHash stri
 	}
 	return ds, nil
 }
+
+// GetDataSourceByPublicKey retrieves the data source with the given public key.
+// Data sources of disabled organizations are excluded.
+func (r *Repository) GetDataSourceByPublicKey(ctx context.Context, publicKey string) (*DataSource, error) {
+	ds := &DataSource{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, name, organization_id, api_key_prefix, api_key_hash, allowed_cidrs, relaxed_uniqueness, public_key, allowed_origins, external_id, last_used_at, max_timestamp, last_received_at, backfilled_at, created_at, updated_at
+		FROM data_sources WHERE public_key = $1
+		  AND organization_id NOT IN (SELECT id FROM organizations WHERE disabled_at IS NOT NULL)`,
+		publicKey,
+	).Scan(&ds.ID, &ds.Name, &ds.OrganizationID, &ds.APIKeyPrefix, &ds.APIKeyHash, &ds.AllowedCIDRs, &ds.RelaxedUniqueness, &ds.PublicKey, &ds.AllowedOrigins, &ds.ExternalID, &ds.LastUsedAt, &ds.MaxTimestamp, &ds.LastReceivedAt, &ds.BackfilledAt, &ds.CreatedAt, &ds.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// CreateMaintenanceWindow creates a maintenance window.
+func (r *Repository) CreateMaintenanceWindow(ctx context.Context, w *MaintenanceWindow) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO maintenance_windows (id, data_source_id, starts_at, ends_at, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at`,
+		w.ID, w.DataSourceID, w.StartsAt, w.EndsAt, w.Reason, w.CreatedBy,
+	).Scan(&w.CreatedAt, &w.UpdatedAt)
+}
+
+// GetMaintenanceWindowByID retrieves a maintenance window by its ID.
+func (r *Repository) GetMaintenanceWindowByID(ctx context.Context, id uuid.UUID) (*MaintenanceWindow, error) {
+	w := &MaintenanceWindow{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, data_source_id, starts_at, ends_at, reason, created_by, created_at, updated_at
+		FROM maintenance_windows WHERE id = $1`,
+		id,
+	).Scan(&w.ID, &w.DataSourceID, &w.StartsAt, &w.EndsAt, &w.Reason, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// GetMaintenanceWindows retrieves the maintenance windows of a data source
+// that overlap [from, to), latest start first. A nil bound is open.
+func (r *Repository) GetMaintenanceWindows(ctx context.Context, dataSourceID uuid.UUID, from, to *time.Time) ([]MaintenanceWindow, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, data_source_id, starts_at, ends_at, reason, created_by, created_at, updated_at
+		FROM maintenance_windows
+		WHERE data_source_id = $1
+		  AND ($2::timestamptz IS NULL OR ends_at > $2)
+		  AND ($3::timestamptz IS NULL OR starts_at < $3)
+		ORDER BY starts_at DESC`,
+		dataSourceID, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []MaintenanceWindow{}
+	for rows.Next() {
+		var w MaintenanceWindow
+		if err := rows.Scan(&w.ID, &w.DataSourceID, &w.StartsAt, &w.EndsAt, &w.Reason, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// UpdateMaintenanceWindow updates the schedule and reason of a maintenance window.
+func (r *Repository) UpdateMaintenanceWindow(ctx context.Context, w *MaintenanceWindow) error {
+	return r.pool.QueryRow(ctx,
+		`UPDATE maintenance_windows SET starts_at = $1, ends_at = $2, reason = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`,
+		w.StartsAt, w.EndsAt, w.Reason, w.ID,
+	).Scan(&w.UpdatedAt)
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window.
+func (r *Repository) DeleteMaintenanceWindow(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM maintenance_windows WHERE id = $1`, id)
+	return err
+}
+
+// GetDefaultDataSourceID retrieves the default data source of an organization, or nil if none is set.
+func (r *Repository) GetDefaultDataSourceID(ctx context.Context, orgID uuid.UUID) (*uuid.UUID, error) {
+	var id *uuid.UUID
+	err := r.pool.QueryRow(ctx,
+		`SELECT default_data_source_id FROM organizations WHERE id = $1`,
+		orgID,
+	).Scan(&id)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return id, err
+}
+
+// SetDefaultDataSourceID sets or clears the default data source of an organization.
+func (r *Repository) SetDefaultDataSourceID(ctx context.Context, orgID uuid.UUID, dataSourceID *uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE organizations SET default_data_source_id = $1 WHERE id = $2`,
+		dataSourceID, orgID,
+	)
+	return err
+}
@@ -4,8 +4,6 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-
-	"github.com/devbydaniel/litekpi/internal/auth"
 )
 
 // RegisterRoutes registers all data source routes.
@@ -15,14 +13,25 @@ func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Han
 
 		// Read operations (all authenticated users)
 		r.Get("/", h.ListDataSources)
+		r.Get("/default", h.GetDefaultDataSource)
+		r.Get("/external/{externalId}", h.GetDataSourceByExternalID)
 		r.Get("/{id}", h.GetDataSource)
+		r.Get("/{id}/maintenance-windows", h.ListMaintenanceWindows)
 
 		// Write operations (admin only)
-		r.Group(func(r chi.Router) {
-			r.Use(auth.AdminMiddleware)
-			r.Post("/", h.CreateDataSource)
-			r.Delete("/{id}", h.DeleteDataSource)
-			r.Post("/{id}/regenerate-key", h.RegenerateAPIKey)
-		})
+		r.Post("/", h.CreateDataSource)
+		r.Put("/external/{externalId}", h.PutDataSource)
+		r.Put("/default", h.SetDefaultDataSource)
+		r.Get("/{id}/deletion-impact", h.GetDeletionImpact)
+		r.Delete("/{id}", h.DeleteDataSource)
+		r.Post("/{id}/regenerate-key", h.RegenerateAPIKey)
+		r.Put("/{id}/allowed-cidrs", h.UpdateAllowedCIDRs)
+		r.Post("/{id}/public-key", h.GeneratePublicKey)
+		r.Delete("/{id}/public-key", h.RevokePublicKey)
+		r.Put("/{id}/allowed-origins", h.UpdateAllowedOrigins)
+		r.Put("/{id}/uniqueness", h.UpdateUniqueness)
+		r.Post("/{id}/maintenance-windows", h.CreateMaintenanceWindow)
+		r.Put("/{id}/maintenance-windows/{windowId}", h.UpdateMaintenanceWindow)
+		r.Delete("/{id}/maintenance-windows/{windowId}", h.DeleteMaintenanceWindow)
 	})
 }
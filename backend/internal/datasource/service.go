@@ -2,29 +2,40 @@ package datasource
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/hex"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
-)
 
-const (
-	apiKeyPrefix = "lk_"
-	apiKeyBytes  = 32
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // Service handles data source business logic.
 type Service struct {
-	repo *Repository
+	repo         *Repository
+	usageService *usage.Service
+	audit        *writeaudit.Service
+	keys         *keyCache
+	pending      sync.WaitGroup // Last used updates still being written
 }
 
 // NewService creates a new data source service.
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *Repository, usageService *usage.Service, audit *writeaudit.Service) *Service {
+	return &Service{
+		repo:         repo,
+		usageService: usageService,
+		audit:        audit,
+		keys:         newKeyCache(),
+	}
 }
 
 // CreateDataSource creates a new data source and returns the plain API key.
@@ -33,16 +44,26 @@ func (s *Service) CreateDataSource(ctx context.Context, orgID uuid.UUID, req Cre
 	if name == "" {
 		return nil, ErrDataSourceNameEmpty
 	}
+	if req.ExternalID != nil {
+		if err := externalid.Validate(*req.ExternalID); err != nil {
+			return nil, err
+		}
+	}
 
-	plainKey, keyHash, err := generateAPIKey()
+	if err := s.usageService.CheckDataSourceQuota(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	plainKey, keyPrefix, keyHash, err := generateAPIKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate API key: %w", err)
 	}
 
-	ds, err := s.repo.CreateDataSource(ctx, orgID, name, keyHash)
+	ds, err := s.repo.CreateDataSource(ctx, orgID, name, req.ExternalID, keyPrefix, keyHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create data source: %w", err)
 	}
+	s.audit.Record(ctx, writeaudit.EntityDataSource, ds.ID, writeaudit.ActionCreate, nil, ds)
 
 	return &CreateDataSourceResponse{
 		DataSource: *ds,
@@ -50,6 +71,83 @@ func (s *Service) CreateDataSource(ctx context.Context, orgID uuid.UUID, req Cre
 	}, nil
 }
 
+// GetDataSourceByExternalID returns the data source of an organization with
+// the given external ID.
+func (s *Service) GetDataSourceByExternalID(ctx context.Context, orgID uuid.UUID, externalID string) (*DataSource, error) {
+	if err := externalid.Validate(externalID); err != nil {
+		return nil, err
+	}
+	ds, err := s.repo.GetDataSourceByExternalID(ctx, orgID, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data source: %w", err)
+	}
+	if ds == nil {
+		return nil, ErrDataSourceNotFound
+	}
+	return ds, nil
+}
+
+// PutDataSource creates the data source with the given external ID, or
+// updates it to match req if it exists. The API key is only returned when the
+// data source is created. Putting the same request again changes nothing.
+func (s *Service) PutDataSource(ctx context.Context, orgID uuid.UUID, externalID string, req PutDataSourceRequest) (*CreateDataSourceResponse, bool, error) {
+	var cidrs []string
+	if req.AllowedCIDRs != nil {
+		var err error
+		if cidrs, err = NormalizeCIDRs(req.AllowedCIDRs); err != nil {
+			return nil, false, err
+		}
+	}
+
+	created := false
+	response := &CreateDataSourceResponse{}
+	ds, err := s.GetDataSourceByExternalID(ctx, orgID, externalID)
+	if errors.Is(err, ErrDataSourceNotFound) {
+		response, err = s.CreateDataSource(ctx, orgID, CreateDataSourceRequest{Name: req.Name, ExternalID: &externalID})
+		switch {
+		case err == nil:
+			created = true
+			ds = &response.DataSource
+		case errors.Is(err, externalid.ErrTaken):
+			// Created by a concurrent request since the lookup
+			response = &CreateDataSourceResponse{}
+			ds, err = s.GetDataSourceByExternalID(ctx, orgID, externalID)
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	before := *ds
+
+	if name := strings.TrimSpace(req.Name); name != ds.Name {
+		if name == "" {
+			return nil, false, ErrDataSourceNameEmpty
+		}
+		if err := s.repo.UpdateName(ctx, ds.ID, name); err != nil {
+			return nil, false, fmt.Errorf("failed to rename data source: %w", err)
+		}
+		ds.Name = name
+	}
+	if cidrs != nil && !slices.Equal(cidrs, ds.AllowedCIDRs) {
+		if err := s.repo.UpdateAllowedCIDRs(ctx, ds.ID, cidrs); err != nil {
+			return nil, false, fmt.Errorf("failed to update allowed CIDRs: %w", err)
+		}
+		ds.AllowedCIDRs = cidrs
+	}
+	if req.RelaxedUniqueness != nil && *req.RelaxedUniqueness != ds.RelaxedUniqueness {
+		if err := s.repo.UpdateRelaxedUniqueness(ctx, ds.ID, *req.RelaxedUniqueness); err != nil {
+			return nil, false, fmt.Errorf("failed to update uniqueness: %w", err)
+		}
+		ds.RelaxedUniqueness = *req.RelaxedUniqueness
+	}
+
+	if !created {
+		s.audit.Record(ctx, writeaudit.EntityDataSource, ds.ID, writeaudit.ActionUpdate, before, ds)
+	}
+	response.DataSource = *ds
+	return response, created, nil
+}
+
 // ListDataSources returns all data sources for an organization.
 func (s *Service) ListDataSources(ctx context.Context, orgID uuid.UUID) ([]DataSource, error) {
 	dataSources, err := s.repo.GetDataSourcesByOrganizationID(ctx, orgID)
@@ -80,22 +178,100 @@ func (s *Service) GetDataSource(ctx context.Context, orgID, dataSourceID uuid.UU
 	return ds, nil
 }
 
-// DeleteDataSource deletes a data source after verifying organization ownership.
-func (s *Service) DeleteDataSource(ctx context.Context, orgID, dataSourceID uuid.UUID) error {
-	ds, err := s.repo.GetDataSourceByID(ctx, dataSourceID)
+// History returns the most recent writes to a data source of an organization.
+func (s *Service) History(ctx context.Context, orgID, dataSourceID uuid.UUID) ([]writeaudit.Entry, error) {
+	return s.audit.History(ctx, orgID, writeaudit.EntityDataSource, dataSourceID)
+}
+
+// GetDefaultDataSourceID returns the default data source of an organization, or nil if none is set.
+func (s *Service) GetDefaultDataSourceID(ctx context.Context, orgID uuid.UUID) (*uuid.UUID, error) {
+	id, err := s.repo.GetDefaultDataSourceID(ctx, orgID)
 	if err != nil {
-		return fmt.Errorf("failed to get data source: %w", err)
+		return nil, fmt.Errorf("failed to get default data source: %w", err)
 	}
-	if ds == nil {
-		return ErrDataSourceNotFound
+	return id, nil
+}
+
+// SetDefaultDataSource sets the default data source of an organization.
+// A nil ID clears the default.
+func (s *Service) SetDefaultDataSource(ctx context.Context, orgID uuid.UUID, dataSourceID *uuid.UUID) error {
+	if dataSourceID != nil {
+		if _, err := s.GetDataSource(ctx, orgID, *dataSourceID); err != nil {
+			return err
+		}
 	}
-	if ds.OrganizationID != orgID {
-		return ErrUnauthorized
+
+	if err := s.repo.SetDefaultDataSourceID(ctx, orgID, dataSourceID); err != nil {
+		return fmt.Errorf("failed to set default data source: %w", err)
+	}
+	return nil
+}
+
+// ResolveDataSourceID returns dataSourceID, or the organization's default
+// data source when it is uuid.Nil. An organization without a default but
+// with a single data source uses that one.
+func (s *Service) ResolveDataSourceID(ctx context.Context, orgID, dataSourceID uuid.UUID) (uuid.UUID, error) {
+	if dataSourceID != uuid.Nil {
+		return dataSourceID, nil
 	}
 
-	if err := s.repo.DeleteDataSource(ctx, dataSourceID); err != nil {
+	id, err := s.GetDefaultDataSourceID(ctx, orgID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if id != nil {
+		return *id, nil
+	}
+
+	dataSources, err := s.repo.GetDataSourcesByOrganizationID(ctx, orgID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to list data sources: %w", err)
+	}
+	if len(dataSources) == 1 {
+		return dataSources[0].ID, nil
+	}
+	return uuid.Nil, ErrNoDefaultDataSource
+}
+
+// GetDeletionImpact returns what depends on a data source of the organization.
+func (s *Service) GetDeletionImpact(ctx context.Context, orgID, dataSourceID uuid.UUID) (*DeletionImpact, error) {
+	if _, err := s.GetDataSource(ctx, orgID, dataSourceID); err != nil {
+		return nil, err
+	}
+
+	impact, err := s.repo.GetDeletionImpact(ctx, dataSourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deletion impact: %w", err)
+	}
+	return impact, nil
+}
+
+// DeleteDataSource deletes a data source after verifying organization ownership.
+// Without a mode, a data source that metrics, goals or saved queries depend on
+// is not deleted and a *DependentsError lists them.
+func (s *Service) DeleteDataSource(ctx context.Context, orgID, dataSourceID uuid.UUID, mode DeletionMode) error {
+	if !mode.IsValid() {
+		return ErrInvalidDeletionMode
+	}
+
+	ds, err := s.GetDataSource(ctx, orgID, dataSourceID)
+	if err != nil {
+		return err
+	}
+	if mode == "" {
+		impact, err := s.repo.GetDeletionImpact(ctx, dataSourceID)
+		if err != nil {
+			return fmt.Errorf("failed to get deletion impact: %w", err)
+		}
+		if !impact.IsEmpty() {
+			return &DependentsError{Impact: *impact}
+		}
+	}
+
+	if err := s.repo.DeleteDataSource(ctx, dataSourceID, mode); err != nil {
 		return fmt.Errorf("failed to delete data source: %w", err)
 	}
+	s.audit.Record(ctx, writeaudit.EntityDataSource, dataSourceID, writeaudit.ActionDelete, ds, nil)
 
 	return nil
 }
@@ -113,31 +289,406 @@ func (s *Service) RegenerateAPIKey(ctx context.Context, orgID, dataSourceID uuid
 		return nil, ErrUnauthorized
 	}
 
-	plainKey, keyHash, err := generateAPIKey()
+	plainKey, keyPrefix, keyHash, err := generateAPIKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate API key: %w", err)
 	}
 
-	if err := s.repo.UpdateAPIKeyHash(ctx, dataSourceID, keyHash); err != nil {
+	if err := s.repo.UpdateAPIKey(ctx, dataSourceID, keyPrefix, keyHash); err != nil {
 		return nil, fmt.Errorf("failed to update API key: %w", err)
 	}
+	// Keys are secret, so the history only tells that the key changed
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "apiKey", nil, "regenerated")
 
 	return &RegenerateKeyResponse{
 		APIKey: plainKey,
 	}, nil
 }
 
-// generateAPIKey generates a new API key and its hash.
-func generateAPIKey() (plainKey, hash string, err error) {
-	bytes := make([]byte, apiKeyBytes)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", "", err
+// UpdateAllowedCIDRs validates and replaces the CIDR allowlist of a data source.
+// Single IP addresses are normalized to host CIDRs (/32 or /128).
+func (s *Service) UpdateAllowedCIDRs(ctx context.Context, orgID, dataSourceID uuid.UUID, req UpdateAllowedCIDRsRequest) (*DataSource, error) {
+	ds, err := s.GetDataSource(ctx, orgID, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cidrs, err := NormalizeCIDRs(req.AllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateAllowedCIDRs(ctx, dataSourceID, cidrs); err != nil {
+		return nil, fmt.Errorf("failed to update allowed CIDRs: %w", err)
+	}
+
+	before := *ds
+	ds.AllowedCIDRs = cidrs
+	s.audit.Record(ctx, writeaudit.EntityDataSource, dataSourceID, writeaudit.ActionUpdate, before, ds)
+	return ds, nil
+}
+
+// GeneratePublicKey creates or rotates the public key of a data source. The
+// previous key stops working immediately.
+func (s *Service) GeneratePublicKey(ctx context.Context, orgID, dataSourceID uuid.UUID) (*PublicKeyResponse, error) {
+	ds, err := s.GetDataSource(ctx, orgID, dataSourceID)
+	if err != nil {
+		return nil, err
 	}
 
-	plainKey = apiKeyPrefix + base64.URLEncoding.EncodeToString(bytes)
+	publicKey, err := generatePublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate public key: %w", err)
+	}
 
-	hashBytes := sha256.Sum256([]byte(plainKey))
-	hash = hex.EncodeToString(hashBytes[:])
+	if err := s.repo.UpdatePublicKey(ctx, dataSourceID, &publicKey); err != nil {
+		return nil, fmt.Errorf("failed to update public key: %w", err)
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "publicKey", ds.PublicKey, publicKey)
+
+	return &PublicKeyResponse{PublicKey: publicKey}, nil
+}
+
+// RevokePublicKey removes the public key of a data source, disabling browser ingestion.
+func (s *Service) RevokePublicKey(ctx context.Context, orgID, dataSourceID uuid.UUID) error {
+	ds, err := s.GetDataSource(ctx, orgID, dataSourceID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePublicKey(ctx, dataSourceID, nil); err != nil {
+		return fmt.Errorf("failed to revoke public key: %w", err)
+	}
+	if ds.PublicKey != nil {
+		s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "publicKey", ds.PublicKey, nil)
+	}
+	return nil
+}
+
+// UpdateAllowedOrigins replaces the origin allowlist of a data source's public key.
+func (s *Service) UpdateAllowedOrigins(ctx context.Context, orgID, dataSourceID uuid.UUID, req UpdateAllowedOriginsRequest) (*DataSource, error) {
+	ds, err := s.GetDataSource(ctx, orgID, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.AllowedOrigins) > MaxAllowedOrigins {
+		return nil, ErrTooManyOrigins
+	}
+
+	origins := make([]string, 0, len(req.AllowedOrigins))
+	for _, entry := range req.AllowedOrigins {
+		origin, err := normalizeOrigin(entry)
+		if err != nil {
+			return nil, err
+		}
+		origins = append(origins, origin)
+	}
+
+	if err := s.repo.UpdateAllowedOrigins(ctx, dataSourceID, origins); err != nil {
+		return nil, fmt.Errorf("failed to update allowed origins: %w", err)
+	}
+
+	before := *ds
+	ds.AllowedOrigins = origins
+	s.audit.Record(ctx, writeaudit.EntityDataSource, dataSourceID, writeaudit.ActionUpdate, before, ds)
+	return ds, nil
+}
+
+// normalizeOrigin validates an origin allowlist entry and returns it in the
+// lowercase scheme://host[:port] form browsers send. A leading "*." in the
+// host allows any subdomain.
+func normalizeOrigin(entry string) (string, error) {
+	entry = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(entry), "/"))
+	u, err := url.Parse(entry)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidOrigin, entry)
+	}
 
-	return plainKey, hash, nil
+	host := strings.TrimPrefix(u.Hostname(), "*.")
+	if host == "" || strings.Contains(host, "*") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidOrigin, entry)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// UpdateUniqueness sets whether a data source opts out of the uniqueness of
+// measurements by name and timestamp. Relaxed data sources store identical
+// measurements and only deduplicate those sent with the same event ID.
+func (s *Service) UpdateUniqueness(ctx context.Context, orgID, dataSourceID uuid.UUID, req UpdateUniquenessRequest) (*DataSource, error) {
+	ds, err := s.GetDataSource(ctx, orgID, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateRelaxedUniqueness(ctx, dataSourceID, req.RelaxedUniqueness); err != nil {
+		return nil, fmt.Errorf("failed to update uniqueness: %w", err)
+	}
+
+	before := *ds
+	ds.RelaxedUniqueness = req.RelaxedUniqueness
+	s.audit.Record(ctx, writeaudit.EntityDataSource, dataSourceID, writeaudit.ActionUpdate, before, ds)
+	return ds, nil
+}
+
+// ListMaintenanceWindows returns the maintenance windows of a data source, latest start first.
+func (s *Service) ListMaintenanceWindows(ctx context.Context, orgID, dataSourceID uuid.UUID) ([]MaintenanceWindow, error) {
+	if _, err := s.GetDataSource(ctx, orgID, dataSourceID); err != nil {
+		return nil, err
+	}
+
+	windows, err := s.repo.GetMaintenanceWindows(ctx, dataSourceID, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+// GetMaintenanceWindowsInRange returns the maintenance windows of a data
+// source that overlap [from, to). The caller is responsible for verifying
+// data source ownership.
+func (s *Service) GetMaintenanceWindowsInRange(ctx context.Context, dataSourceID uuid.UUID, from, to time.Time) ([]MaintenanceWindow, error) {
+	windows, err := s.repo.GetMaintenanceWindows(ctx, dataSourceID, &from, &to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+// CreateMaintenanceWindow schedules a maintenance window on a data source.
+// Windows may lie in the past, to annotate maintenance after the fact.
+func (s *Service) CreateMaintenanceWindow(ctx context.Context, orgID, dataSourceID, userID uuid.UUID, req MaintenanceWindowRequest) (*MaintenanceWindow, error) {
+	if _, err := s.GetDataSource(ctx, orgID, dataSourceID); err != nil {
+		return nil, err
+	}
+
+	w := &MaintenanceWindow{
+		ID:           uuid.New(),
+		DataSourceID: dataSourceID,
+		CreatedBy:    &userID,
+	}
+	if err := applyMaintenanceWindow(w, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateMaintenanceWindow(ctx, w); err != nil {
+		return nil, fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "maintenanceWindow", nil, w)
+	return w, nil
+}
+
+// UpdateMaintenanceWindow reschedules a maintenance window of a data source.
+func (s *Service) UpdateMaintenanceWindow(ctx context.Context, orgID, dataSourceID, windowID uuid.UUID, req MaintenanceWindowRequest) (*MaintenanceWindow, error) {
+	w, err := s.getMaintenanceWindow(ctx, orgID, dataSourceID, windowID)
+	if err != nil {
+		return nil, err
+	}
+	before := *w
+	if err := applyMaintenanceWindow(w, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateMaintenanceWindow(ctx, w); err != nil {
+		return nil, fmt.Errorf("failed to update maintenance window: %w", err)
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "maintenanceWindow", before, w)
+	return w, nil
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window of a data source.
+func (s *Service) DeleteMaintenanceWindow(ctx context.Context, orgID, dataSourceID, windowID uuid.UUID) error {
+	w, err := s.getMaintenanceWindow(ctx, orgID, dataSourceID, windowID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteMaintenanceWindow(ctx, windowID); err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "maintenanceWindow", w, nil)
+	return nil
+}
+
+// getMaintenanceWindow returns a maintenance window of a data source of the
+// organization. Windows of other data sources are reported as missing.
+func (s *Service) getMaintenanceWindow(ctx context.Context, orgID, dataSourceID, windowID uuid.UUID) (*MaintenanceWindow, error) {
+	if _, err := s.GetDataSource(ctx, orgID, dataSourceID); err != nil {
+		return nil, err
+	}
+
+	w, err := s.repo.GetMaintenanceWindowByID(ctx, windowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	if w == nil || w.DataSourceID != dataSourceID {
+		return nil, ErrMaintenanceWindowNotFound
+	}
+	return w, nil
+}
+
+// applyMaintenanceWindow validates a request and copies it onto w.
+func applyMaintenanceWindow(w *MaintenanceWindow, req MaintenanceWindowRequest) error {
+	if req.StartsAt.IsZero() || !req.EndsAt.After(req.StartsAt) {
+		return ErrInvalidMaintenanceWindow
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 255 {
+		return ErrMaintenanceReasonTooLong
+	}
+
+	w.StartsAt = req.StartsAt.UTC()
+	w.EndsAt = req.EndsAt.UTC()
+	w.Reason = reason
+	return nil
+}
+
+// NormalizeCIDRs validates a CIDR allowlist and returns it in the form it
+// is stored in, with single addresses as /32 or /128 networks.
+func NormalizeCIDRs(entries []string) ([]string, error) {
+	if len(entries) > MaxAllowedCIDRs {
+		return nil, ErrTooManyCIDRs
+	}
+
+	cidrs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		cidr, err := normalizeCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+func normalizeCIDR(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if !strings.Contains(entry, "/") {
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCIDR, entry)
+		}
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCIDR, entry)
+	}
+	return network.String(), nil
+}
+
+// AuthenticatePublicKey validates a public key and returns its data source.
+func (s *Service) AuthenticatePublicKey(ctx context.Context, publicKey string) (*DataSource, error) {
+	if !strings.HasPrefix(publicKey, publicKeyPrefix) {
+		return nil, ErrInvalidPublicKey
+	}
+
+	ds, err := s.repo.GetDataSourceByPublicKey(ctx, publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up public key: %w", err)
+	}
+	if ds == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return ds, nil
+}
+
+// AuthenticateAPIKey returns the data source owning the given API key.
+// Candidates are looked up by key prefix and verified against the stored
+// Argon2id hash, unless the key verified against it within the last minute.
+// Keys created before prefixes existed are matched by their legacy SHA-256
+// hash and transparently upgraded on first use.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, plainKey string) (*DataSource, error) {
+	if !strings.HasPrefix(plainKey, apiKeyPrefix) || len(plainKey) < apiKeyLookupLength {
+		return nil, ErrInvalidAPIKey
+	}
+
+	candidates, err := s.repo.GetDataSourcesByAPIKeyPrefix(ctx, apiKeyLookupPrefix(plainKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	now := time.Now()
+	var match *DataSource
+	for i := range candidates {
+		if s.keys.matches(plainKey, candidates[i].APIKeyHash, now) {
+			match = &candidates[i]
+			break
+		}
+	}
+	if match == nil {
+		for i := range candidates {
+			if verifyAPIKey(plainKey, candidates[i].APIKeyHash) {
+				match = &candidates[i]
+				break
+			}
+		}
+
+		if match == nil {
+			match, err = s.authenticateLegacyAPIKey(ctx, plainKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		s.keys.remember(plainKey, match.APIKeyHash, now)
+	}
+
+	// Update last used timestamp asynchronously (fire and forget), at most
+	// once a minute per data source
+	if !s.keys.touch(match.ID, now) {
+		return match, nil
+	}
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		_ = s.repo.UpdateLastUsed(context.Background(), match.ID)
+	}()
+
+	return match, nil
+}
+
+// Flush waits until the last used timestamps of authenticated keys are
+// written, or ctx is done.
+func (s *Service) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Service) authenticateLegacyAPIKey(ctx context.Context, plainKey string) (*DataSource, error) {
+	legacyHash := legacyAPIKeyHash(plainKey)
+
+	ds, err := s.repo.GetDataSourceByLegacyAPIKeyHash(ctx, legacyHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	if ds == nil || subtle.ConstantTimeCompare([]byte(ds.APIKeyHash), []byte(legacyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	keyHash, err := hashAPIKey(plainKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash API key: %w", err)
+	}
+	keyPrefix := apiKeyLookupPrefix(plainKey)
+	if err := s.repo.UpdateAPIKey(ctx, ds.ID, keyPrefix, keyHash); err != nil {
+		return nil, fmt.Errorf("failed to upgrade API key hash: %w", err)
+	}
+	ds.APIKeyPrefix = &keyPrefix
+	ds.APIKeyHash = keyHash
+
+	return ds, nil
 }
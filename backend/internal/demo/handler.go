@@ -2,11 +2,13 @@ package demo
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/devbydaniel/litekpi/internal/auth"
 	"github.com/devbydaniel/litekpi/internal/datasource"
+	"github.com/devbydaniel/litekpi/internal/usage"
 )
 
 // Handler handles HTTP requests for demo operations.
@@ -28,6 +30,7 @@ func NewHandler(service *Service) *Handler {
 //	@Security		BearerAuth
 //	@Success		201	{object}	datasource.CreateDataSourceResponse
 //	@Failure		401	{object}	ErrorResponse
+//	@Failure		402	{object}	ErrorResponse	"Quota reached"
 //	@Failure		403	{object}	ErrorResponse
 //	@Failure		500	{object}	ErrorResponse
 //	@Router			/data-sources/demo [post]
@@ -40,6 +43,10 @@ func (h *Handler) CreateDemoDataSource(w http.ResponseWriter, r *http.Request) {
 
 	response, err := h.service.CreateDemoDataSource(r.Context(), user.OrganizationID)
 	if err != nil {
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondError(w, http.StatusPaymentRequired, err.Error())
+			return
+		}
 		log.Printf("create demo data source error: %v", err)
 		respondError(w, http.StatusInternalServerError, "failed to create demo data source")
 		return
@@ -38,9 +38,9 @@ func (s *Service) CreateDemoDataSource(ctx context.Context, orgID uuid.UUID) (*d
 	}
 
 	// Generate demo measurements for last 30 days
-	if err := s.createDemoMeasurements(ctx, response.DataSource.ID); err != nil {
+	if err := s.createDemoMeasurements(ctx, orgID, response.DataSource.ID); err != nil {
 		// Rollback: delete the data source if measurements fail
-		s.dataSourceService.DeleteDataSource(ctx, orgID, response.DataSource.ID)
+		s.dataSourceService.DeleteDataSource(ctx, orgID, response.DataSource.ID, datasource.DeletionModeCascade)
 		return nil, fmt.Errorf("failed to create demo measurements: %w", err)
 	}
 
@@ -48,7 +48,7 @@ func (s *Service) CreateDemoDataSource(ctx context.Context, orgID uuid.UUID) (*d
 }
 
 // createDemoMeasurements generates realistic demo data for the last 30 days.
-func (s *Service) createDemoMeasurements(ctx context.Context, dataSourceID uuid.UUID) error {
+func (s *Service) createDemoMeasurements(ctx context.Context, orgID, dataSourceID uuid.UUID) error {
 	now := time.Now().UTC()
 	var metrics []ingest.IngestRequest
 
@@ -111,7 +111,7 @@ func (s *Service) createDemoMeasurements(ctx context.Context, dataSourceID uuid.
 			end = len(metrics)
 		}
 		batch := ingest.BatchIngestRequest{Metrics: metrics[i:end]}
-		if _, err := s.ingestService.IngestBatch(ctx, dataSourceID, batch); err != nil {
+		if _, err := s.ingestService.IngestBatch(ctx, orgID, dataSourceID, batch); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,12 @@
+package digest
+
+import (
+	"github.com/google/uuid"
+)
+
+// Recipient is a user who opted in to the weekly digest.
+type Recipient struct {
+	UserID           uuid.UUID
+	OrganizationID   uuid.UUID
+	OrganizationName string
+}
@@ -0,0 +1,81 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for the weekly digest.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new digest repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// ListRecipients retrieves verified users in enabled organizations who turned
+// on the digest email and have at least one starred dashboard.
+func (r *Repository) ListRecipients(ctx context.Context) ([]Recipient, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT u.id, o.id, o.name
+		FROM users u
+		JOIN organizations o ON o.id = u.organization_id
+		JOIN notification_preferences p ON p.user_id = u.id
+		WHERE p.category = 'digest' AND p.channel = 'email' AND p.resource_id IS NULL AND p.enabled
+		  AND u.email_verified
+		  AND o.disabled_at IS NULL
+		  AND EXISTS (SELECT 1 FROM dashboard_stars s WHERE s.user_id = u.id)
+		ORDER BY u.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []Recipient
+	for rows.Next() {
+		var rc Recipient
+		if err := rows.Scan(&rc.UserID, &rc.OrganizationID, &rc.OrganizationName); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, rc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// ClaimDelivery records that a user's digest for a period is being sent.
+// It returns false if the digest for that period was already claimed, which
+// keeps delivery at most once across restarts and replicas.
+func (r *Repository) ClaimDelivery(ctx context.Context, userID uuid.UUID, period time.Time) (bool, error) {
+	var claimed uuid.UUID
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO digest_deliveries (user_id, last_period, sent_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET last_period = EXCLUDED.last_period, sent_at = NOW()
+		WHERE digest_deliveries.last_period < EXCLUDED.last_period
+		RETURNING user_id`,
+		userID, period,
+	).Scan(&claimed)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,224 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/branding"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/goal"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/notification"
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const (
+	digestCheckInterval = time.Hour
+	digestSendHour      = 8 // Digests go out on Mondays from 08:00 UTC
+	maxDigestMetrics    = 8 // Metrics per dashboard included in a digest
+	maxDigestGoals      = 8 // Open goals included in a digest
+)
+
+// Runner sends the weekly KPI digest. Every Monday it emails each opted-in
+// user the week-over-week change of the metrics on their starred dashboards
+// and the progress of the organization's open goals.
+type Runner struct {
+	repo                *Repository
+	dashboardService    *dashboard.Service
+	metricService       *metric.Service
+	goalService         *goal.Service
+	notificationService *notification.Service
+	brandingService     *branding.Service
+	appURL              string
+}
+
+// NewRunner creates a new digest runner.
+func NewRunner(repo *Repository, dashboardService *dashboard.Service, metricService *metric.Service, goalService *goal.Service, notificationService *notification.Service, brandingService *branding.Service, appURL string) *Runner {
+	return &Runner{
+		repo:                repo,
+		dashboardService:    dashboardService,
+		metricService:       metricService,
+		goalService:         goalService,
+		notificationService: notificationService,
+		brandingService:     brandingService,
+		appURL:              strings.TrimSuffix(appURL, "/"),
+	}
+}
+
+// Job returns the scheduled job that checks for due digests.
+func (r *Runner) Job() jobs.Job {
+	return jobs.Job{Name: "weekly_digest", Interval: digestCheckInterval, Run: r.RunOnce}
+}
+
+// RunOnce sends this week's digest to every recipient who has not received it
+// yet. It also purges the notification deliveries past their retention.
+func (r *Runner) RunOnce(ctx context.Context) error {
+	if n, err := r.notificationService.PurgeDeliveries(ctx); err != nil {
+		log.Printf("notification delivery purge error: %v", err)
+	} else if n > 0 {
+		log.Printf("purged %d notification deliveries", n)
+	}
+
+	if !r.notificationService.IsEmailEnabled() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	period := weekStart(now)
+	if now.Before(period.Add(digestSendHour * time.Hour)) {
+		return nil
+	}
+
+	recipients, err := r.repo.ListRecipients(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list recipients: %w", err)
+	}
+
+	for _, rc := range recipients {
+		claimed, err := r.repo.ClaimDelivery(ctx, rc.UserID, period)
+		if err != nil {
+			return fmt.Errorf("failed to claim delivery: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := r.send(ctx, rc, period.AddDate(0, 0, -7)); err != nil {
+			log.Printf("weekly digest error for user %s: %v", rc.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+// send builds and dispatches the digest for the week starting at week.
+func (r *Runner) send(ctx context.Context, rc Recipient, week time.Time) error {
+	dashboards, err := r.dashboardService.ListStarredDashboards(ctx, rc.OrganizationID, rc.UserID)
+	if err != nil {
+		return err
+	}
+
+	var sections []string
+	for _, d := range dashboards {
+		metrics, err := r.metricService.GetByDashboardID(ctx, d.ID)
+		if err != nil {
+			return err
+		}
+		if len(metrics) > maxDigestMetrics {
+			metrics = metrics[:maxDigestMetrics]
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+
+		lines := []string{d.Name}
+		for _, c := range r.metricService.ComputeWeekOverWeek(ctx, rc.OrganizationID, metrics, week) {
+			lines = append(lines, "- "+formatMetric(c))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	goals, err := r.goalService.List(ctx, rc.OrganizationID, nil)
+	if err != nil {
+		return err
+	}
+	lines := []string{"Goals"}
+	for _, g := range goals {
+		if g.Progress.Status == goal.StatusAchieved || g.Progress.Status == goal.StatusMissed {
+			continue
+		}
+		if len(lines) > maxDigestGoals {
+			break
+		}
+		lines = append(lines, "- "+formatGoal(g))
+	}
+	if len(lines) > 1 {
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(sections) == 0 {
+		return nil
+	}
+
+	c, err := r.brandingService.EmailCustomization(ctx, rc.OrganizationID)
+	if err != nil {
+		c = nil
+	}
+	data := map[string]string{
+		"URL":         r.appURL,
+		"OrgName":     rc.OrganizationName,
+		"PeriodLabel": week.Format("Jan 2, 2006"),
+		"Summary":     strings.Join(sections, "\n\n"),
+	}
+	msg, err := email.Render(email.TemplateDigest, c, data)
+	if err != nil && c != nil {
+		msg, err = email.Render(email.TemplateDigest, nil, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	_, err = r.notificationService.Dispatch(ctx, notification.Notification{
+		UserID:   rc.UserID,
+		Category: notification.CategoryDigest,
+		Email:    msg,
+	})
+	return err
+}
+
+// weekStart returns midnight UTC of the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// formatMetric renders a computed metric as "Label: value (change)".
+func formatMetric(c metric.ComputedMetric) string {
+	if c.Error != nil || c.Value == nil {
+		msg := "unavailable"
+		if c.Error != nil {
+			msg = *c.Error
+		}
+		return fmt.Sprintf("%s: %s", c.Label, msg)
+	}
+
+	change := "no previous data"
+	switch {
+	case c.ChangePercent != nil:
+		change = fmt.Sprintf("%+.1f%%", *c.ChangePercent)
+	case c.Change != nil && *c.Change == 0:
+		change = "no change"
+	case c.Change != nil:
+		change = "new this week"
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", c.Label, formatNumber(*c.Value), change)
+}
+
+// formatGoal renders a goal as "Name: value of target (percent, due date)".
+func formatGoal(g goal.GoalWithProgress) string {
+	due := g.DueDate.Format("Jan 2, 2006")
+	if g.Progress.CurrentValue == nil {
+		return fmt.Sprintf("%s: unavailable (due %s)", g.Name, due)
+	}
+
+	progress := ""
+	if g.Progress.Percent != nil {
+		progress = fmt.Sprintf("%.0f%%, ", *g.Progress.Percent)
+	}
+	return fmt.Sprintf("%s: %s of %s (%sdue %s)", g.Name, formatNumber(*g.Progress.CurrentValue), formatNumber(g.TargetValue), progress, due)
+}
+
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
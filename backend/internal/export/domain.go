@@ -0,0 +1,88 @@
+package export
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrExportNotConfigured = errors.New("measurement export is not configured")
+	ErrInvalidFormat       = errors.New("format must be csv")
+	ErrInvalidEndpoint     = errors.New("endpoint must be an absolute http(s) URL")
+	ErrInvalidRegion       = errors.New("region is required and may contain only lowercase letters, digits, and dashes")
+	ErrInvalidBucket       = errors.New("bucket must be 3 to 63 characters")
+	ErrInvalidPrefix       = errors.New("prefix must be at most 200 characters")
+	ErrCredentialsRequired = errors.New("access key ID and secret access key are required")
+)
+
+// Format is the file format of exported measurements.
+type Format string
+
+const (
+	FormatCSV Format = "csv" // Gzip-compressed CSV
+)
+
+// maxCatchUpDays bounds how many missed days a single run exports per organization.
+const maxCatchUpDays = 7
+
+// Config is an organization's scheduled export to S3-compatible object storage.
+// Each day's measurements are written to
+// {prefix}measurements/date=YYYY-MM-DD/measurements.csv.gz.
+type Config struct {
+	OrganizationID  uuid.UUID  `json:"organizationId"`
+	Enabled         bool       `json:"enabled"`
+	Format          Format     `json:"format"`
+	Endpoint        *string    `json:"endpoint"` // Defaults to AWS S3 for the region
+	Region          string     `json:"region"`
+	Bucket          string     `json:"bucket"`
+	Prefix          string     `json:"prefix"`
+	AccessKeyID     string     `json:"accessKeyId"`
+	SecretAccessKey string     `json:"-"`
+	LastExportedDay *time.Time `json:"lastExportedDay,omitempty"`
+	LastRunAt       *time.Time `json:"lastRunAt,omitempty"`
+	LastError       *string    `json:"lastError,omitempty"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// UpdateConfigRequest is the request body for configuring the export.
+// An empty secret access key keeps the stored one.
+type UpdateConfigRequest struct {
+	Enabled         bool    `json:"enabled"`
+	Format          Format  `json:"format"`
+	Endpoint        *string `json:"endpoint"`
+	Region          string  `json:"region"`
+	Bucket          string  `json:"bucket"`
+	Prefix          string  `json:"prefix"`
+	AccessKeyID     string  `json:"accessKeyId"`
+	SecretAccessKey string  `json:"secretAccessKey"`
+}
+
+// RunExportResponse is the response for running an export immediately.
+type RunExportResponse struct {
+	Day       string `json:"day"` // YYYY-MM-DD
+	ObjectKey string `json:"objectKey"`
+	Rows      int    `json:"rows"`
+}
+
+// Row is a single exported measurement.
+type Row struct {
+	Timestamp      time.Time
+	DataSourceID   uuid.UUID
+	DataSourceName string
+	Name           string
+	Value          float64
+	Weight         int64
+	Metadata       []byte // JSON
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// Handler handles HTTP requests for measurement exports.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new export handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetConfig handles getting the organization's export configuration.
+//
+//	@Summary		Get measurement export
+//	@Description	Get the organization's scheduled measurement export to S3-compatible storage and its last run status. The secret access key is never returned. (admin only)
+//	@Tags			organization
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Config
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/export [get]
+func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	c, err := h.service.GetConfig(r.Context(), user.OrganizationID)
+	if err != nil {
+		if errors.Is(err, ErrExportNotConfigured) {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("get export config error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get export config")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// UpdateConfig handles configuring the organization's export.
+//
+//	@Summary		Configure measurement export
+//	@Description	Create or replace the organization's daily measurement export. Each UTC day is written as gzip-compressed CSV to {prefix}measurements/date=YYYY-MM-DD/measurements.csv.gz. Leave secretAccessKey empty to keep the stored one. (admin only)
+//	@Tags			organization
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		UpdateConfigRequest	true	"Export configuration"
+//	@Success		200		{object}	Config
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/organization/export [put]
+func (h *Handler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateConfigRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	c, err := h.service.UpdateConfig(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFormat) || errors.Is(err, ErrInvalidEndpoint) || errors.Is(err, ErrInvalidRegion) ||
+			errors.Is(err, ErrInvalidBucket) || errors.Is(err, ErrInvalidPrefix) || errors.Is(err, ErrCredentialsRequired) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("update export config error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update export config")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// DeleteConfig handles removing the organization's export.
+//
+//	@Summary		Remove measurement export
+//	@Description	Stop the organization's scheduled measurement export and delete its credentials (admin only)
+//	@Tags			organization
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	MessageResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/export [delete]
+func (h *Handler) DeleteConfig(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteConfig(r.Context(), user.OrganizationID); err != nil {
+		if errors.Is(err, ErrExportNotConfigured) {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("delete export config error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to delete export config")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "export removed"})
+}
+
+// RunExport handles exporting yesterday's measurements immediately.
+//
+//	@Summary		Run measurement export
+//	@Description	Export yesterday's measurements now, e.g. to verify the bucket and credentials (admin only)
+//	@Tags			organization
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	RunExportResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		502	{object}	ErrorResponse	"Export failed"
+//	@Router			/organization/export/run [post]
+func (h *Handler) RunExport(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	resp, err := h.service.RunNow(r.Context(), user.OrganizationID)
+	if err != nil {
+		if errors.Is(err, ErrExportNotConfigured) {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("run export error: %v", err)
+		respondError(w, http.StatusBadGateway, "export failed: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
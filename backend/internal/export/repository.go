@@ -0,0 +1,220 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/internal/platform/secrets"
+)
+
+// Repository handles database operations for measurement exports.
+// Secret access keys are encrypted with the keyring when stored.
+type Repository struct {
+	pool    *pgxpool.Pool
+	keyring *secrets.Keyring
+}
+
+// NewRepository creates a new export repository.
+func NewRepository(pool *pgxpool.Pool, keyring *secrets.Keyring) *Repository {
+	return &Repository{pool: pool, keyring: keyring}
+}
+
+// GetConfig retrieves the export configuration of an organization.
+func (r *Repository) GetConfig(ctx context.Context, orgID uuid.UUID) (*Config, error) {
+	c := &Config{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT organization_id, enabled, format, endpoint, region, bucket, prefix, access_key_id, secret_access_key,
+		        last_exported_day, last_run_at, last_error, updated_at
+		FROM export_configs WHERE organization_id = $1`,
+		orgID,
+	).Scan(&c.OrganizationID, &c.Enabled, &c.Format, &c.Endpoint, &c.Region, &c.Bucket, &c.Prefix, &c.AccessKeyID, &c.SecretAccessKey,
+		&c.LastExportedDay, &c.LastRunAt, &c.LastError, &c.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.SecretAccessKey, err = r.keyring.Open(c.SecretAccessKey); err != nil {
+		return nil, fmt.Errorf("decrypting secret access key: %w", err)
+	}
+
+	return c, nil
+}
+
+// ListEnabledConfigs retrieves all enabled export configurations of organizations
+// that are not disabled.
+func (r *Repository) ListEnabledConfigs(ctx context.Context) ([]Config, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT c.organization_id, c.enabled, c.format, c.endpoint, c.region, c.bucket, c.prefix, c.access_key_id, c.secret_access_key,
+		        c.last_exported_day, c.last_run_at, c.last_error, c.updated_at
+		FROM export_configs c
+		JOIN organizations o ON o.id = c.organization_id
+		WHERE c.enabled
+		  AND o.disabled_at IS NULL`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []Config
+	for rows.Next() {
+		var c Config
+		if err := rows.Scan(&c.OrganizationID, &c.Enabled, &c.Format, &c.Endpoint, &c.Region, &c.Bucket, &c.Prefix, &c.AccessKeyID, &c.SecretAccessKey,
+			&c.LastExportedDay, &c.LastRunAt, &c.LastError, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		secret, err := r.keyring.Open(c.SecretAccessKey)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting secret access key of organization %s: %w", c.OrganizationID, err)
+		}
+		c.SecretAccessKey = secret
+		configs = append(configs, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+// UpsertConfig creates or replaces the export configuration of an organization.
+// The export progress is kept.
+func (r *Repository) UpsertConfig(ctx context.Context, c *Config) error {
+	secret, err := r.keyring.Seal(c.SecretAccessKey)
+	if err != nil {
+		return fmt.Errorf("encrypting secret access key: %w", err)
+	}
+
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO export_configs (organization_id, enabled, format, endpoint, region, bucket, prefix, access_key_id, secret_access_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled,
+		    format = EXCLUDED.format,
+		    endpoint = EXCLUDED.endpoint,
+		    region = EXCLUDED.region,
+		    bucket = EXCLUDED.bucket,
+		    prefix = EXCLUDED.prefix,
+		    access_key_id = EXCLUDED.access_key_id,
+		    secret_access_key = EXCLUDED.secret_access_key
+		RETURNING last_exported_day, last_run_at, last_error, updated_at`,
+		c.OrganizationID, c.Enabled, c.Format, c.Endpoint, c.Region, c.Bucket, c.Prefix, c.AccessKeyID, secret,
+	).Scan(&c.LastExportedDay, &c.LastRunAt, &c.LastError, &c.UpdatedAt)
+}
+
+// DeleteConfig deletes the export configuration of an organization.
+func (r *Repository) DeleteConfig(ctx context.Context, orgID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM export_configs WHERE organization_id = $1`,
+		orgID,
+	)
+	return err
+}
+
+// ReencryptSecrets encrypts the secret access keys that are stored in plain
+// text or under an old key with the current key. It returns how many it
+// encrypted.
+func (r *Repository) ReencryptSecrets(ctx context.Context) (int, error) {
+	rows, err := r.pool.Query(ctx, `SELECT organization_id, secret_access_key FROM export_configs`)
+	if err != nil {
+		return 0, err
+	}
+	stale := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var orgID uuid.UUID
+		var secret string
+		if err := rows.Scan(&orgID, &secret); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if r.keyring.Stale(secret) {
+			stale[orgID] = secret
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for orgID, stored := range stale {
+		secret, err := r.keyring.Open(stored)
+		if err != nil {
+			return count, fmt.Errorf("decrypting secret access key of organization %s: %w", orgID, err)
+		}
+		sealed, err := r.keyring.Seal(secret)
+		if err != nil {
+			return count, fmt.Errorf("encrypting secret access key: %w", err)
+		}
+		// Leave keys alone that were replaced in the meantime
+		tag, err := r.pool.Exec(ctx,
+			`UPDATE export_configs SET secret_access_key = $3 WHERE organization_id = $1 AND secret_access_key = $2`,
+			orgID, stored, sealed,
+		)
+		if err != nil {
+			return count, err
+		}
+		count += int(tag.RowsAffected())
+	}
+	return count, nil
+}
+
+// RecordSuccess records that a day was exported.
+func (r *Repository) RecordSuccess(ctx context.Context, orgID uuid.UUID, day time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE export_configs
+		SET last_exported_day = GREATEST(last_exported_day, $2::date), last_run_at = NOW(), last_error = NULL
+		WHERE organization_id = $1`,
+		orgID, day,
+	)
+	return err
+}
+
+// RecordFailure records a failed export run.
+func (r *Repository) RecordFailure(ctx context.Context, orgID uuid.UUID, message string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE export_configs SET last_run_at = NOW(), last_error = $2 WHERE organization_id = $1`,
+		orgID, message,
+	)
+	return err
+}
+
+// ForEachMeasurement calls fn for each measurement of the organization in [start, end),
+// ordered by timestamp. Downsampled days are exported as their daily rollups.
+func (r *Repository) ForEachMeasurement(ctx context.Context, orgID uuid.UUID, start, end time.Time, fn func(Row) error) error {
+	rows, err := r.pool.Query(ctx,
+		`SELECT mp.timestamp, mp.data_source_id, ds.name, mp.name, mp.value, mp.weight, COALESCE(mp.metadata, '{}'::jsonb)
+		FROM measurement_points mp
+		JOIN data_sources ds ON ds.id = mp.data_source_id
+		WHERE ds.organization_id = $1 AND mp.timestamp >= $2 AND mp.timestamp < $3
+		ORDER BY mp.timestamp, mp.data_source_id, mp.name`,
+		orgID, start, end,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row Row
+		if err := rows.Scan(&row.Timestamp, &row.DataSourceID, &row.DataSourceName, &row.Name, &row.Value, &row.Weight, &row.Metadata); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
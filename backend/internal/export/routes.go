@@ -0,0 +1,20 @@
+package export
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the measurement export routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/organization/export", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// Admin only
+		r.Get("/", h.GetConfig)
+		r.Put("/", h.UpdateConfig)
+		r.Delete("/", h.DeleteConfig)
+		r.Post("/run", h.RunExport)
+	})
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const exportCheckInterval = time.Hour
+
+// Runner exports each completed UTC day of measurements for every
+// organization with an enabled export, catching up on missed days.
+type Runner struct {
+	repo    *Repository
+	service *Service
+}
+
+// NewRunner creates a new export runner.
+func NewRunner(repo *Repository, service *Service) *Runner {
+	return &Runner{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Job returns the scheduled job that checks for due exports.
+func (r *Runner) Job() jobs.Job {
+	return jobs.Job{Name: "measurement_export", Interval: exportCheckInterval, Run: r.RunOnce}
+}
+
+// RunOnce exports the days each organization has not exported yet, up to yesterday.
+// A new export starts with yesterday.
+func (r *Runner) RunOnce(ctx context.Context) error {
+	configs, err := r.repo.ListEnabledConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list export configs: %w", err)
+	}
+
+	last := yesterday(time.Now())
+	for i := range configs {
+		c := &configs[i]
+
+		day := last
+		if c.LastExportedDay != nil {
+			day = c.LastExportedDay.AddDate(0, 0, 1)
+			if earliest := last.AddDate(0, 0, -(maxCatchUpDays - 1)); day.Before(earliest) {
+				day = earliest
+			}
+		}
+
+		for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if _, err := r.service.exportDay(ctx, c, day); err != nil {
+				log.Printf("measurement export error for organization %s on %s: %v", c.OrganizationID, day.Format("2006-01-02"), err)
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/objectstore"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+var regionPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// csvHeader is the column layout of exported files.
+var csvHeader = []string{"timestamp", "data_source_id", "data_source_name", "name", "value", "weight", "metadata"}
+
+// Service handles measurement export business logic.
+type Service struct {
+	repo  *Repository
+	audit *writeaudit.Service
+}
+
+// NewService creates a new export service.
+func NewService(repo *Repository, audit *writeaudit.Service) *Service {
+	return &Service{repo: repo, audit: audit}
+}
+
+// GetConfig returns an organization's export configuration.
+func (s *Service) GetConfig(ctx context.Context, orgID uuid.UUID) (*Config, error) {
+	c, err := s.repo.GetConfig(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export config: %w", err)
+	}
+	if c == nil {
+		return nil, ErrExportNotConfigured
+	}
+	return c, nil
+}
+
+// UpdateConfig validates and saves an organization's export configuration.
+func (s *Service) UpdateConfig(ctx context.Context, orgID uuid.UUID, req UpdateConfigRequest) (*Config, error) {
+	existing, err := s.repo.GetConfig(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export config: %w", err)
+	}
+
+	c := &Config{
+		OrganizationID:  orgID,
+		Enabled:         req.Enabled,
+		Format:          req.Format,
+		Endpoint:        req.Endpoint,
+		Region:          strings.TrimSpace(req.Region),
+		Bucket:          strings.TrimSpace(req.Bucket),
+		Prefix:          normalizePrefix(req.Prefix),
+		AccessKeyID:     strings.TrimSpace(req.AccessKeyID),
+		SecretAccessKey: req.SecretAccessKey,
+	}
+	if c.Format == "" {
+		c.Format = FormatCSV
+	}
+	if c.Endpoint != nil && strings.TrimSpace(*c.Endpoint) == "" {
+		c.Endpoint = nil
+	}
+	if c.SecretAccessKey == "" && existing != nil {
+		c.SecretAccessKey = existing.SecretAccessKey
+	}
+
+	if c.Format != FormatCSV {
+		return nil, ErrInvalidFormat
+	}
+	if c.Endpoint != nil {
+		u, err := url.Parse(*c.Endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, ErrInvalidEndpoint
+		}
+	}
+	if !regionPattern.MatchString(c.Region) {
+		return nil, ErrInvalidRegion
+	}
+	if len(c.Bucket) < 3 || len(c.Bucket) > 63 {
+		return nil, ErrInvalidBucket
+	}
+	if len(c.Prefix) > 200 {
+		return nil, ErrInvalidPrefix
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, ErrCredentialsRequired
+	}
+
+	if err := s.repo.UpsertConfig(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to save export config: %w", err)
+	}
+	if existing == nil {
+		s.audit.Record(ctx, writeaudit.EntityExport, orgID, writeaudit.ActionCreate, nil, c)
+	} else {
+		s.audit.Record(ctx, writeaudit.EntityExport, orgID, writeaudit.ActionUpdate, existing, c)
+	}
+	return c, nil
+}
+
+// DeleteConfig removes an organization's export configuration.
+func (s *Service) DeleteConfig(ctx context.Context, orgID uuid.UUID) error {
+	c, err := s.GetConfig(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteConfig(ctx, orgID); err != nil {
+		return fmt.Errorf("failed to delete export config: %w", err)
+	}
+	s.audit.Record(ctx, writeaudit.EntityExport, orgID, writeaudit.ActionDelete, c, nil)
+	return nil
+}
+
+// RunNow exports yesterday's measurements immediately, e.g. to verify the credentials.
+func (s *Service) RunNow(ctx context.Context, orgID uuid.UUID) (*RunExportResponse, error) {
+	c, err := s.GetConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return s.exportDay(ctx, c, yesterday(time.Now()))
+}
+
+// exportDay writes one UTC day of measurements to the configured bucket and
+// records the outcome.
+func (s *Service) exportDay(ctx context.Context, c *Config, day time.Time) (*RunExportResponse, error) {
+	resp, err := s.upload(ctx, c, day)
+	if err != nil {
+		// Recording the failure is best effort; the export error is what matters
+		_ = s.repo.RecordFailure(ctx, c.OrganizationID, err.Error())
+		return nil, err
+	}
+	if err := s.repo.RecordSuccess(ctx, c.OrganizationID, day); err != nil {
+		return nil, fmt.Errorf("failed to record export: %w", err)
+	}
+	return resp, nil
+}
+
+func (s *Service) upload(ctx context.Context, c *Config, day time.Time) (*RunExportResponse, error) {
+	endpoint := ""
+	if c.Endpoint != nil {
+		endpoint = *c.Endpoint
+	}
+	client, err := objectstore.NewClient(objectstore.Config{
+		Endpoint:        endpoint,
+		Region:          c.Region,
+		Bucket:          c.Bucket,
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := csv.NewWriter(gz)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	count := 0
+	err = s.repo.ForEachMeasurement(ctx, c.OrganizationID, day, day.AddDate(0, 0, 1), func(row Row) error {
+		count++
+		return w.Write([]string{
+			row.Timestamp.UTC().Format(time.RFC3339Nano),
+			row.DataSourceID.String(),
+			row.DataSourceName,
+			row.Name,
+			strconv.FormatFloat(row.Value, 'g', -1, 64),
+			strconv.FormatInt(row.Weight, 10),
+			string(row.Metadata),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read measurements: %w", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	key := objectKey(c.Prefix, day)
+	if err := client.PutObject(ctx, key, "application/gzip", buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to upload export: %w", err)
+	}
+
+	return &RunExportResponse{
+		Day:       day.Format("2006-01-02"),
+		ObjectKey: key,
+		Rows:      count,
+	}, nil
+}
+
+// objectKey returns the Hive-style partitioned key of a day's export.
+func objectKey(prefix string, day time.Time) string {
+	return prefix + "measurements/date=" + day.Format("2006-01-02") + "/measurements.csv.gz"
+}
+
+// normalizePrefix trims slashes and ensures a non-empty prefix ends in one.
+func normalizePrefix(prefix string) string {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// yesterday returns the start of the UTC day before t.
+func yesterday(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+}
@@ -0,0 +1,124 @@
+package goal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// Error definitions
+var (
+	ErrGoalNotFound       = errors.New("goal not found")
+	ErrGoalNameEmpty      = errors.New("goal name is required")
+	ErrGoalNameTooLong    = errors.New("goal name exceeds maximum length of 255 characters")
+	ErrInvalidTarget      = errors.New("targetValue must be a finite number")
+	ErrInvalidStart       = errors.New("startValue must be a finite number different from targetValue")
+	ErrDueDateRequired    = errors.New("dueDate is required")
+	ErrMetricNotFound     = errors.New("metric not found")
+	ErrMetricNotScalar    = errors.New("goals can only track scalar metrics")
+	ErrOwnerNotFound      = errors.New("owner must be a member of the organization")
+	ErrPreconditionFailed = errors.New("goal was modified since it was read")
+)
+
+// Status is where a goal stands relative to its target and due date.
+type Status string
+
+const (
+	StatusAchieved   Status = "achieved"
+	StatusInProgress Status = "in_progress"
+	StatusMissed     Status = "missed"  // Past the due date without reaching the target
+	StatusUnknown    Status = "unknown" // The metric could not be computed
+)
+
+// Pace is whether a goal's metric is ahead of or behind where it usually is
+// at this point of its timeframe.
+type Pace string
+
+const (
+	PaceAhead  Pace = "ahead"
+	PaceBehind Pace = "behind"
+)
+
+// Goal is a target value for a scalar metric. Progress is measured on the
+// metric's current value over its own timeframe.
+type Goal struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	MetricID       uuid.UUID  `json:"metricId"`
+	Name           string     `json:"name"`
+	TargetValue    float64    `json:"targetValue"`
+	StartValue     *float64   `json:"startValue,omitempty"` // Baseline progress is measured from; defaults to zero
+	DueDate        time.Time  `json:"dueDate"`
+	OwnerID        *uuid.UUID `json:"ownerId,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// Progress is a goal's computed standing.
+type Progress struct {
+	CurrentValue *float64 `json:"currentValue,omitempty"`
+	Percent      *float64 `json:"percent,omitempty"` // Share of the way from start to target; exceeds 100 past the target
+	Status       Status   `json:"status"`
+	Error        *string  `json:"error,omitempty"` // Why the status is unknown
+
+	// For goals in progress on sums and counts over this month or a custom
+	// range: the value expected by now, from how the metric's total accrued
+	// over previous periods rather than linearly
+	ExpectedValue *float64         `json:"expectedValue,omitempty"`
+	Pace          Pace             `json:"pace,omitempty"`
+	PaceBasis     metric.PaceBasis `json:"paceBasis,omitempty"`
+}
+
+// GoalWithProgress is a goal with its computed progress.
+type GoalWithProgress struct {
+	Goal
+	Progress Progress           `json:"progress"`
+	History  []writeaudit.Entry `json:"history,omitempty"` // With includeHistory=true, newest first
+}
+
+// Request/Response types
+
+// CreateGoalRequest is the request body for creating a goal.
+type CreateGoalRequest struct {
+	MetricID    uuid.UUID  `json:"metricId" validate:"required"`
+	Name        string     `json:"name" validate:"required,max=255"`
+	TargetValue float64    `json:"targetValue"`
+	StartValue  *float64   `json:"startValue,omitempty"`
+	DueDate     time.Time  `json:"dueDate" validate:"required"`
+	OwnerID     *uuid.UUID `json:"ownerId,omitempty"`
+}
+
+// UpdateGoalRequest is the request body for updating a goal.
+type UpdateGoalRequest struct {
+	MetricID    uuid.UUID  `json:"metricId" validate:"required"`
+	Name        string     `json:"name" validate:"required,max=255"`
+	TargetValue float64    `json:"targetValue"`
+	StartValue  *float64   `json:"startValue,omitempty"`
+	DueDate     time.Time  `json:"dueDate" validate:"required"`
+	OwnerID     *uuid.UUID `json:"ownerId,omitempty"`
+}
+
+// ListGoalsResponse is the response for listing goals.
+type ListGoalsResponse struct {
+	Goals []GoalWithProgress `json:"goals"`
+}
+
+// StatusSummaryResponse counts an organization's goals by status.
+type StatusSummaryResponse struct {
+	Total    int            `json:"total"`
+	ByStatus map[Status]int `json:"byStatus"`
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,308 @@
+package goal
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/precondition"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// validationErrors are the goal errors caused by invalid input.
+var validationErrors = []error{
+	ErrGoalNameEmpty,
+	ErrGoalNameTooLong,
+	ErrInvalidTarget,
+	ErrInvalidStart,
+	ErrDueDateRequired,
+	ErrMetricNotFound,
+	ErrMetricNotScalar,
+	ErrOwnerNotFound,
+}
+
+// Handler handles HTTP requests for goals.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new goal handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListGoals handles listing the organization's goals.
+//
+//	@Summary		List goals
+//	@Description	List the organization's goals with their progress, soonest due first
+//	@Tags			goals
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			ownerId	query		string	false	"Only goals owned by this user"
+//	@Success		200		{object}	ListGoalsResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/goals [get]
+func (h *Handler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var ownerID *uuid.UUID
+	if v := r.URL.Query().Get("ownerId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid ownerId")
+			return
+		}
+		ownerID = &id
+	}
+
+	goals, err := h.service.List(r.Context(), user.OrganizationID, ownerID)
+	if err != nil {
+		log.Printf("list goals error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list goals")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListGoalsResponse{Goals: goals})
+}
+
+// GetStatusSummary handles counting the organization's goals by status.
+//
+//	@Summary		Goal status summary
+//	@Description	Count the organization's goals by status: achieved, in_progress, missed (past due without reaching the target) or unknown (the metric could not be computed)
+//	@Tags			goals
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	StatusSummaryResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/goals/status [get]
+func (h *Handler) GetStatusSummary(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	summary, err := h.service.StatusSummary(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("goal status summary error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to summarize goals")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, summary)
+}
+
+// GetGoal handles getting a goal with its progress.
+//
+//	@Summary		Get goal
+//	@Description	Get a goal with its progress, computed from the current value of its metric over the metric's timeframe
+//	@Tags			goals
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		string	true	"Goal ID"
+//	@Param			includeHistory	query		bool	false	"Include the write history of the goal"
+//	@Success		200				{object}	GoalWithProgress
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/goals/{id} [get]
+func (h *Handler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid goal ID")
+		return
+	}
+
+	g, err := h.service.Get(r.Context(), user.OrganizationID, id)
+	if err != nil {
+		respondServiceError(w, err, "get goal", "failed to get goal")
+		return
+	}
+
+	if writeaudit.IncludeHistory(r) {
+		g.History, err = h.service.History(r.Context(), user.OrganizationID, id)
+		if err != nil {
+			log.Printf("get goal history error: %v", err)
+			respondError(w, http.StatusInternalServerError, "failed to get goal history")
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, g)
+}
+
+// CreateGoal handles creating a goal.
+//
+//	@Summary		Create goal
+//	@Description	Create a goal for a scalar metric. Progress runs from startValue (default 0) to targetValue; a target below the start tracks a decrease. Requires editor or admin role.
+//	@Tags			goals
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateGoalRequest	true	"Goal data"
+//	@Success		201		{object}	GoalWithProgress
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/goals [post]
+func (h *Handler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateGoalRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	g, err := h.service.Create(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		respondServiceError(w, err, "create goal", "failed to create goal")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, g)
+}
+
+// UpdateGoal handles updating a goal.
+//
+//	@Summary		Update goal
+//	@Description	Update a goal. Send the updatedAt value last read as If-Match to fail with 412 instead of overwriting someone else's change. Requires editor or admin role.
+//	@Tags			goals
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string				true	"Goal ID"
+//	@Param			If-Match	header		string				false	"updatedAt of the goal as last read"
+//	@Param			request		body		UpdateGoalRequest	true	"Goal data"
+//	@Success		200			{object}	GoalWithProgress
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/goals/{id} [put]
+func (h *Handler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid goal ID")
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req UpdateGoalRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	g, err := h.service.Update(r.Context(), user.OrganizationID, id, req, ifUnmodified)
+	if err != nil {
+		respondServiceError(w, err, "update goal", "failed to update goal")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, g)
+}
+
+// DeleteGoal handles deleting a goal.
+//
+//	@Summary		Delete goal
+//	@Description	Delete a goal. Requires editor or admin role.
+//	@Tags			goals
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Goal ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/goals/{id} [delete]
+func (h *Handler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid goal ID")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), user.OrganizationID, id); err != nil {
+		respondServiceError(w, err, "delete goal", "failed to delete goal")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "goal deleted"})
+}
+
+// respondServiceError writes the response for an error from the goal
+// service, logging unexpected errors under op.
+func respondServiceError(w http.ResponseWriter, err error, op, message string) {
+	switch {
+	case errors.Is(err, ErrGoalNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrPreconditionFailed):
+		respondError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		for _, target := range validationErrors {
+			if errors.Is(err, target) {
+				respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		log.Printf("%s error: %v", op, err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,117 @@
+package goal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const goalColumns = `id, organization_id, metric_id, name, target_value, start_value, due_date, owner_id, created_at, updated_at`
+
+// Repository handles database operations for goals.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new goal repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Create creates a new goal.
+func (r *Repository) Create(ctx context.Context, g *Goal) error {
+	now := time.Now()
+	g.ID = uuid.New()
+	g.CreatedAt = now
+	g.UpdatedAt = now
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO goals (`+goalColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		g.ID, g.OrganizationID, g.MetricID, g.Name, g.TargetValue, g.StartValue, g.DueDate, g.OwnerID, g.CreatedAt, g.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a goal by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Goal, error) {
+	g, err := scanGoal(r.pool.QueryRow(ctx,
+		`SELECT `+goalColumns+` FROM goals WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// ListByOrganizationID retrieves the goals of an organization, soonest due
+// first. If ownerID is set, only that user's goals are returned.
+func (r *Repository) ListByOrganizationID(ctx context.Context, orgID uuid.UUID, ownerID *uuid.UUID) ([]Goal, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+goalColumns+` FROM goals
+		WHERE organization_id = $1 AND ($2::uuid IS NULL OR owner_id = $2)
+		ORDER BY due_date ASC, name ASC`,
+		orgID, ownerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []Goal
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *g)
+	}
+
+	return goals, rows.Err()
+}
+
+// Update updates a goal and returns its new update time. If ifUnmodified is
+// set, the update only applies while the goal's update time still equals it;
+// otherwise nil is returned.
+func (r *Repository) Update(ctx context.Context, g *Goal, ifUnmodified *time.Time) (*time.Time, error) {
+	var updatedAt time.Time
+	err := r.pool.QueryRow(ctx,
+		`UPDATE goals
+		SET metric_id = $1, name = $2, target_value = $3, start_value = $4, due_date = $5, owner_id = $6
+		WHERE id = $7 AND ($8::timestamptz IS NULL OR updated_at = $8)
+		RETURNING updated_at`,
+		g.MetricID, g.Name, g.TargetValue, g.StartValue, g.DueDate, g.OwnerID, g.ID, ifUnmodified,
+	).Scan(&updatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedAt, nil
+}
+
+// Delete deletes a goal.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM goals WHERE id = $1`, id)
+	return err
+}
+
+func scanGoal(row pgx.Row) (*Goal, error) {
+	g := &Goal{}
+	err := row.Scan(&g.ID, &g.OrganizationID, &g.MetricID, &g.Name, &g.TargetValue, &g.StartValue, &g.DueDate, &g.OwnerID, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
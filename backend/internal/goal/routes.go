@@ -0,0 +1,24 @@
+package goal
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the goal routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/goals", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// Read operations (all authenticated users)
+		r.Get("/", h.ListGoals)
+		r.Get("/status", h.GetStatusSummary)
+		r.Get("/{id}", h.GetGoal)
+
+		// Write operations (editor and admin only)
+		r.Post("/", h.CreateGoal)
+		r.Put("/{id}", h.UpdateGoal)
+		r.Delete("/{id}", h.DeleteGoal)
+	})
+}
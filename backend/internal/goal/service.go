@@ -0,0 +1,325 @@
+package goal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// Service handles goal business logic.
+type Service struct {
+	repo             *Repository
+	metricService    *metric.Service
+	dashboardService *dashboard.Service
+	authService      *auth.Service
+	audit            *writeaudit.Service
+}
+
+// NewService creates a new goal service.
+func NewService(repo *Repository, metricService *metric.Service, dashboardService *dashboard.Service, authService *auth.Service, audit *writeaudit.Service) *Service {
+	return &Service{
+		repo:             repo,
+		metricService:    metricService,
+		dashboardService: dashboardService,
+		authService:      authService,
+		audit:            audit,
+	}
+}
+
+// Create creates a goal for a scalar metric of the organization.
+func (s *Service) Create(ctx context.Context, orgID uuid.UUID, req CreateGoalRequest) (*GoalWithProgress, error) {
+	g := &Goal{OrganizationID: orgID}
+	if err := s.apply(ctx, g, UpdateGoalRequest(req)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, g); err != nil {
+		return nil, fmt.Errorf("failed to create goal: %w", err)
+	}
+	s.audit.Record(ctx, writeaudit.EntityGoal, g.ID, writeaudit.ActionCreate, nil, g)
+
+	return &s.withProgress(ctx, orgID, []Goal{*g})[0], nil
+}
+
+// List returns the goals of an organization with their progress. If ownerID
+// is set, only that user's goals are returned.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID, ownerID *uuid.UUID) ([]GoalWithProgress, error) {
+	goals, err := s.repo.ListByOrganizationID(ctx, orgID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list goals: %w", err)
+	}
+	return s.withProgress(ctx, orgID, goals), nil
+}
+
+// Get returns a goal of the organization with its progress.
+func (s *Service) Get(ctx context.Context, orgID, goalID uuid.UUID) (*GoalWithProgress, error) {
+	g, err := s.getOwned(ctx, orgID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	return &s.withProgress(ctx, orgID, []Goal{*g})[0], nil
+}
+
+// VerifyGoalOwnership verifies that a goal belongs to an organization
+// without computing its progress.
+func (s *Service) VerifyGoalOwnership(ctx context.Context, orgID, goalID uuid.UUID) error {
+	_, err := s.getOwned(ctx, orgID, goalID)
+	return err
+}
+
+// StatusSummary counts the goals of an organization by status.
+func (s *Service) StatusSummary(ctx context.Context, orgID uuid.UUID) (*StatusSummaryResponse, error) {
+	goals, err := s.List(ctx, orgID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &StatusSummaryResponse{
+		Total: len(goals),
+		ByStatus: map[Status]int{
+			StatusAchieved:   0,
+			StatusInProgress: 0,
+			StatusMissed:     0,
+			StatusUnknown:    0,
+		},
+	}
+	for _, g := range goals {
+		summary.ByStatus[g.Progress.Status]++
+	}
+	return summary, nil
+}
+
+// Update updates a goal. If ifUnmodified is set, the update fails with
+// ErrPreconditionFailed unless the goal was last updated at that time.
+func (s *Service) Update(ctx context.Context, orgID, goalID uuid.UUID, req UpdateGoalRequest, ifUnmodified *time.Time) (*GoalWithProgress, error) {
+	g, err := s.getOwned(ctx, orgID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	before := *g
+	if err := s.apply(ctx, g, req); err != nil {
+		return nil, err
+	}
+
+	updatedAt, err := s.repo.Update(ctx, g, ifUnmodified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update goal: %w", err)
+	}
+	if updatedAt == nil {
+		return nil, ErrPreconditionFailed
+	}
+	g.UpdatedAt = *updatedAt
+	s.audit.Record(ctx, writeaudit.EntityGoal, goalID, writeaudit.ActionUpdate, before, g)
+
+	return &s.withProgress(ctx, orgID, []Goal{*g})[0], nil
+}
+
+// Delete deletes a goal.
+func (s *Service) Delete(ctx context.Context, orgID, goalID uuid.UUID) error {
+	g, err := s.getOwned(ctx, orgID, goalID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, goalID); err != nil {
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	s.audit.Record(ctx, writeaudit.EntityGoal, goalID, writeaudit.ActionDelete, g, nil)
+	return nil
+}
+
+// History returns the most recent writes to a goal of an organization.
+func (s *Service) History(ctx context.Context, orgID, goalID uuid.UUID) ([]writeaudit.Entry, error) {
+	return s.audit.History(ctx, orgID, writeaudit.EntityGoal, goalID)
+}
+
+// getOwned returns a goal of the organization. Goals of other organizations
+// are reported as missing.
+func (s *Service) getOwned(ctx context.Context, orgID, goalID uuid.UUID) (*Goal, error) {
+	g, err := s.repo.GetByID(ctx, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+	if g == nil || g.OrganizationID != orgID {
+		return nil, ErrGoalNotFound
+	}
+	return g, nil
+}
+
+// apply validates a request and copies it onto g.
+func (s *Service) apply(ctx context.Context, g *Goal, req UpdateGoalRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ErrGoalNameEmpty
+	}
+	if len(name) > 255 {
+		return ErrGoalNameTooLong
+	}
+	if math.IsNaN(req.TargetValue) || math.IsInf(req.TargetValue, 0) {
+		return ErrInvalidTarget
+	}
+	if req.StartValue != nil && (math.IsNaN(*req.StartValue) || math.IsInf(*req.StartValue, 0) || *req.StartValue == req.TargetValue) {
+		return ErrInvalidStart
+	}
+	if req.DueDate.IsZero() {
+		return ErrDueDateRequired
+	}
+
+	m, err := s.metricService.GetByID(ctx, req.MetricID)
+	if errors.Is(err, metric.ErrMetricNotFound) {
+		return ErrMetricNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := s.dashboardService.VerifyDashboardOwnership(ctx, g.OrganizationID, m.DashboardID); err != nil {
+		if errors.Is(err, dashboard.ErrDashboardNotFound) || errors.Is(err, dashboard.ErrUnauthorized) {
+			return ErrMetricNotFound
+		}
+		return err
+	}
+	if m.DisplayMode != metric.DisplayModeScalar {
+		return ErrMetricNotScalar
+	}
+
+	if req.OwnerID != nil {
+		owner, err := s.authService.GetUserByID(ctx, *req.OwnerID)
+		if err != nil {
+			return fmt.Errorf("failed to get owner: %w", err)
+		}
+		if owner == nil || owner.OrganizationID != g.OrganizationID {
+			return ErrOwnerNotFound
+		}
+	}
+
+	g.MetricID = req.MetricID
+	g.Name = name
+	g.TargetValue = req.TargetValue
+	g.StartValue = req.StartValue
+	g.DueDate = req.DueDate
+	g.OwnerID = req.OwnerID
+	return nil
+}
+
+// withProgress computes the progress of goals from their metrics' current
+// values. Goals whose metric cannot be computed get StatusUnknown.
+func (s *Service) withProgress(ctx context.Context, orgID uuid.UUID, goals []Goal) []GoalWithProgress {
+	// Compute each metric once, even if several goals track it
+	var metrics []metric.Metric
+	index := make(map[uuid.UUID]int)
+	for _, g := range goals {
+		if _, ok := index[g.MetricID]; ok {
+			continue
+		}
+		m, err := s.metricService.GetByID(ctx, g.MetricID)
+		if err != nil {
+			continue
+		}
+		index[g.MetricID] = len(metrics)
+		metrics = append(metrics, *m)
+	}
+	var computed []metric.ComputedMetric
+	if len(metrics) > 0 {
+		computed = s.metricService.Compute(ctx, orgID, metrics)
+	}
+
+	// Pacing is best effort; goals without it still report their progress
+	now := time.Now()
+	paces := make([]*metric.Pace, len(metrics))
+	for j, m := range metrics {
+		if computed[j].Error != nil {
+			continue
+		}
+		pace, err := s.metricService.ExpectedPace(ctx, m, now)
+		if err != nil {
+			log.Printf("goal pacing error for metric %s: %v", m.ID, err)
+			continue
+		}
+		paces[j] = pace
+	}
+
+	result := make([]GoalWithProgress, len(goals))
+	for i, g := range goals {
+		result[i] = GoalWithProgress{Goal: g}
+
+		j, ok := index[g.MetricID]
+		if !ok {
+			msg := "metric is no longer available"
+			result[i].Progress = Progress{Status: StatusUnknown, Error: &msg}
+			continue
+		}
+		c := computed[j]
+		if c.Error != nil {
+			result[i].Progress = Progress{Status: StatusUnknown, Error: c.Error}
+			continue
+		}
+		current := 0.0
+		if c.Value != nil {
+			current = *c.Value
+		}
+		result[i].Progress = progressOf(g, current, now)
+		if paces[j] != nil && result[i].Progress.Status == StatusInProgress {
+			applyPace(&result[i].Progress, g, current, paces[j])
+		}
+	}
+	return result
+}
+
+// applyPace sets the value a goal's metric is expected to have reached by now
+// and whether it is ahead of or behind that, in the direction of the target.
+func applyPace(p *Progress, g Goal, current float64, pace *metric.Pace) {
+	start := 0.0
+	if g.StartValue != nil {
+		start = *g.StartValue
+	}
+	expected := start + (g.TargetValue-start)*pace.ExpectedShare
+	p.ExpectedValue = &expected
+	p.PaceBasis = pace.Basis
+
+	ahead := current >= expected
+	if g.TargetValue < start {
+		ahead = current <= expected
+	}
+	p.Pace = PaceBehind
+	if ahead {
+		p.Pace = PaceAhead
+	}
+}
+
+// progressOf computes a goal's progress given its metric's current value.
+// A goal is achieved once the value reaches the target from the side of the
+// start value, so targets below the start track decreases.
+func progressOf(g Goal, current float64, now time.Time) Progress {
+	start := 0.0
+	if g.StartValue != nil {
+		start = *g.StartValue
+	}
+
+	p := Progress{CurrentValue: &current, Status: StatusInProgress}
+	if span := g.TargetValue - start; span != 0 {
+		percent := math.Round((current-start)/span*1000) / 10
+		p.Percent = &percent
+	}
+
+	achieved := current >= g.TargetValue
+	if g.TargetValue < start {
+		achieved = current <= g.TargetValue
+	}
+	switch {
+	case achieved:
+		p.Status = StatusAchieved
+	case now.After(g.DueDate):
+		p.Status = StatusMissed
+	}
+	return p
+}
@@ -0,0 +1,149 @@
+package ingest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Endpoints for no-code automation platforms such as Zapier and Make. They
+// authenticate with a data source API key like the ingest API, but return
+// bare arrays from polling triggers and accept loosely typed action input.
+
+// GetAutomationAccount handles testing an automation platform's connection.
+//
+//	@Summary		Test automation connection
+//	@Description	Return the data source the API key belongs to. Automation platforms call this to test and label a connection.
+//	@Tags			automations
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	AutomationAccountResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/automations/me [get]
+func (h *Handler) GetAutomationAccount(w http.ResponseWriter, r *http.Request) {
+	ds := DataSourceFromContext(r.Context())
+	if ds == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized", "missing or invalid API key")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, AutomationAccountResponse{
+		DataSourceID:   ds.ID,
+		DataSourceName: ds.Name,
+	})
+}
+
+// NewMeasurementNamesTrigger handles polling for new measurement names.
+//
+//	@Summary		New measurement names trigger
+//	@Description	Polling trigger listing the measurement names first seen most recently for the data source, newest first. Each entry's id is the name, so platforms that deduplicate by id fire once per new name.
+//	@Tags			automations
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		NewMeasurementName
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/automations/triggers/new-measurement-names [get]
+func (h *Handler) NewMeasurementNamesTrigger(w http.ResponseWriter, r *http.Request) {
+	ds := DataSourceFromContext(r.Context())
+	if ds == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized", "missing or invalid API key")
+		return
+	}
+
+	names, err := h.service.GetNewMeasurementNames(r.Context(), ds.ID)
+	if err != nil {
+		log.Printf("new measurement names trigger error: %v", err)
+		respondError(w, http.StatusInternalServerError, "internal_error", "failed to get new measurement names")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, names)
+}
+
+// RecordMeasurementAction handles recording a measurement from an automation.
+//
+//	@Summary		Record measurement action
+//	@Description	Record a single measurement like POST /ingest, accepting the value as a number or numeric string and metadata values of any scalar type. Omit the value or leave it empty to record an event counted as 1.
+//	@Tags			automations
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			request	body		RecordMeasurementAction	true	"Measurement"
+//	@Success		200		{object}	IngestResponse	"Retry of a stored event ID"
+//	@Success		201		{object}	IngestResponse
+//	@Failure		400		{object}	ErrorResponse	"Validation error"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		409		{object}	ErrorResponse	"Duplicate measurement"
+//	@Failure		429		{object}	ErrorResponse	"Monthly event quota exceeded"
+//	@Failure		500		{object}	ErrorResponse	"Internal error"
+//	@Router			/automations/actions/record-measurement [post]
+func (h *Handler) RecordMeasurementAction(w http.ResponseWriter, r *http.Request) {
+	ds := DataSourceFromContext(r.Context())
+	if ds == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized", "missing or invalid API key")
+		return
+	}
+
+	var action RecordMeasurementAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		respondError(w, http.StatusBadRequest, "validation_failed", "invalid request body")
+		return
+	}
+
+	req, err := action.ingestRequest()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "validation_failed", err.Error())
+		return
+	}
+
+	h.ingestSingle(w, r, ds, req)
+}
+
+// ingestRequest converts the action's loosely typed fields to an ingest request.
+func (a RecordMeasurementAction) ingestRequest() (IngestRequest, error) {
+	req := IngestRequest{
+		Name:      a.Name,
+		Value:     EventValue,
+		Timestamp: strings.TrimSpace(a.Timestamp),
+		EventID:   a.EventID,
+		event:     true,
+	}
+
+	switch v := a.Value.(type) {
+	case nil:
+	case float64:
+		req.Value, req.event = v, false
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return IngestRequest{}, &validationError{errorType: "validation_failed", message: "value must be a number"}
+			}
+			req.Value, req.event = f, false
+		}
+	default:
+		return IngestRequest{}, &validationError{errorType: "validation_failed", message: "value must be a number"}
+	}
+
+	if len(a.Metadata) > 0 {
+		req.Metadata = make(map[string]string, len(a.Metadata))
+		for key, value := range a.Metadata {
+			switch v := value.(type) {
+			case nil:
+			case string:
+				req.Metadata[key] = v
+			case float64:
+				req.Metadata[key] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				req.Metadata[key] = strconv.FormatBool(v)
+			default:
+				return IngestRequest{}, &validationError{errorType: "validation_failed", message: "metadata values must be strings, numbers or booleans"}
+			}
+		}
+	}
+
+	return req, nil
+}
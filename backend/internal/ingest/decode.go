@@ -0,0 +1,88 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Content types accepted for ingest request bodies besides JSON.
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeMsgpack  = "application/msgpack"
+)
+
+// decodeIngestBody decodes an ingest request body according to its
+// Content-Type. Protobuf bodies follow proto/litekpi/ingest/v1/ingest.proto;
+// MessagePack bodies use the same field names as JSON. Any other type is
+// decoded as JSON, which keeps clients that omit the header working.
+func decodeIngestBody(r *http.Request, v any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case contentTypeProtobuf, "application/protobuf":
+		m, ok := v.(protoUnmarshaler)
+		if !ok {
+			return fmt.Errorf("cannot unmarshal protobuf into %T", v)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		return m.unmarshalProto(body)
+
+	case contentTypeMsgpack, "application/x-msgpack":
+		dec := msgpack.NewDecoder(r.Body)
+		dec.SetCustomStructTag("json")
+		return dec.Decode(v)
+
+	default:
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+}
+
+// DecodeMsgpack defaults Value to EventValue when it is omitted or nil, and
+// accepts the timestamp as a number of epoch milliseconds, like UnmarshalJSON.
+func (r *IngestRequest) DecodeMsgpack(dec *msgpack.Decoder) error {
+	var aux struct {
+		Name      string            `msgpack:"name"`
+		Value     *float64          `msgpack:"value"`
+		Timestamp any               `msgpack:"timestamp"` // RFC 3339 string or epoch milliseconds
+		Metadata  map[string]string `msgpack:"metadata"`
+		EventID   string            `msgpack:"eventId"`
+	}
+	if err := dec.Decode(&aux); err != nil {
+		return err
+	}
+
+	*r = IngestRequest{
+		Name:      aux.Name,
+		Value:     EventValue,
+		Timestamp: timestampString(aux.Timestamp),
+		Metadata:  aux.Metadata,
+		EventID:   aux.EventID,
+		event:     aux.Value == nil,
+	}
+	if aux.Value != nil {
+		r.Value = *aux.Value
+	}
+	return nil
+}
+
+// timestampString returns a msgpack timestamp as a string for parseTimestamp.
+// Numbers are kept as their decimal form, so epoch milliseconds parse and
+// anything else fails validation.
+func timestampString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
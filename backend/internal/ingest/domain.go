@@ -1,7 +1,9 @@
 package ingest
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
 	"time"
 
@@ -15,8 +17,15 @@ const (
 	MaxMetadataKeys        = 20
 	MaxMetadataKeyLength   = 64
 	MaxMetadataValueLength = 256
+	MaxEventIDLength       = 128
+	MaxStreamIDLength      = 128
 )
 
+// BackfillTolerance is how far before a data source's newest timestamp
+// measurements may arrive without counting as a backfill, which allows for
+// producers that send slightly out of order.
+const BackfillTolerance = time.Hour
+
 // MetricNameRegex defines the valid pattern for metric names (snake_case).
 var MetricNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
 
@@ -27,8 +36,27 @@ var (
 	ErrBatchTooLarge         = errors.New("batch exceeds maximum size")
 	ErrEmptyBatch            = errors.New("batch must contain at least one measurement")
 	ErrBatchDuplicates       = errors.New("batch contains duplicate measurements")
+	ErrSamplingNotFound      = errors.New("sampling configuration not found")
+	ErrTypeNotFound          = errors.New("measurement type not found")
+	ErrFreshnessNotFound     = errors.New("freshness SLA not found")
+	ErrNotArchived           = errors.New("measurement name is not archived")
+	ErrEventNotFound         = errors.New("event not found")
+	ErrEventRolledUp         = errors.New("event has been downsampled and can no longer be changed")
+	ErrBatchReplayed         = errors.New("batch has already been accepted")
 )
 
+// SequenceGapError is returned when a batch's sequence number skips ahead of
+// the next one expected on its stream. Accepting it would acknowledge the
+// batches in between without storing them.
+type SequenceGapError struct {
+	Sequence        int64
+	AcceptedThrough int64
+}
+
+func (e *SequenceGapError) Error() string {
+	return fmt.Sprintf("batch sequence %d does not follow accepted sequence %d", e.Sequence, e.AcceptedThrough)
+}
+
 // Measurement represents a stored measurement data point.
 type Measurement struct {
 	ID           uuid.UUID         `json:"id"`
@@ -37,34 +65,101 @@ type Measurement struct {
 	Value        float64           `json:"value"`
 	Timestamp    time.Time         `json:"timestamp"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
+	EventID      *string           `json:"eventId,omitempty"`
 	CreatedAt    time.Time         `json:"createdAt"`
 }
 
 // IngestRequest represents a single metric ingestion request.
 type IngestRequest struct {
 	Name      string            `json:"name"`
-	Value     float64           `json:"value"`
-	Timestamp string            `json:"timestamp,omitempty"`
+	Value     float64           `json:"value"`               // Defaults to 1 when omitted, for pure events
+	Timestamp string            `json:"timestamp,omitempty"` // RFC 3339 with offset, or epoch milliseconds; defaults to the time of ingestion
 	Metadata  map[string]string `json:"metadata,omitempty"`
+	EventID   string            `json:"eventId,omitempty"` // Unique per data source; a retry with a stored event ID is not ingested again
+
+	event bool // Value was omitted
+}
+
+// EventValue is the value stored for a measurement sent without one.
+const EventValue = 1
+
+// UnmarshalJSON defaults Value to EventValue when it is omitted or null,
+// so events like user_signed_up don't need a meaningless value. The
+// timestamp may also be sent as a number of epoch milliseconds.
+func (r *IngestRequest) UnmarshalJSON(data []byte) error {
+	type plain IngestRequest
+	aux := struct {
+		*plain
+		Value     *float64        `json:"value"`
+		Timestamp json.RawMessage `json:"timestamp"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.Timestamp = ""
+	if len(aux.Timestamp) > 0 && string(aux.Timestamp) != "null" {
+		if aux.Timestamp[0] == '"' {
+			if err := json.Unmarshal(aux.Timestamp, &r.Timestamp); err != nil {
+				return err
+			}
+		} else {
+			// Kept as sent, so parseTimestamp accepts integers and rejects the rest
+			r.Timestamp = string(aux.Timestamp)
+		}
+	}
+
+	r.event = aux.Value == nil
+	r.Value = EventValue
+	if aux.Value != nil {
+		r.Value = *aux.Value
+	}
+	return nil
 }
 
 // IngestResponse represents the response for a successful single metric ingestion.
 type IngestResponse struct {
-	ID        uuid.UUID         `json:"id"`
-	Name      string            `json:"name"`
-	Value     float64           `json:"value"`
-	Timestamp time.Time         `json:"timestamp"`
-	Metadata  map[string]string `json:"metadata,omitempty"`
+	ID              uuid.UUID         `json:"id"`
+	Name            string            `json:"name"`
+	Value           float64           `json:"value"`
+	Timestamp       time.Time         `json:"timestamp"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	SampledOut      bool              `json:"sampledOut,omitempty"`      // Discarded by sampling; ID is empty
+	Duplicate       bool              `json:"duplicate,omitempty"`       // The event ID was already stored; ID is the stored measurement
+	ReceivedAt      time.Time         `json:"receivedAt"`                // When the server received the request
+	Transformations []string          `json:"transformations,omitempty"` // Transformation rules that changed the measurement
+	Warnings        []string          `json:"warnings,omitempty"`        // Data-quality issues that did not prevent ingestion
 }
 
 // BatchIngestRequest represents a batch metric ingestion request.
 type BatchIngestRequest struct {
-	Metrics []IngestRequest `json:"metrics"`
+	Metrics  []IngestRequest `json:"metrics"`
+	StreamID string          `json:"streamId,omitempty"` // Client buffer the batch comes from; enables acknowledgment by sequence
+	Sequence int64           `json:"sequence,omitempty"` // Position of the batch in its stream, counting from 1
 }
 
 // BatchIngestResponse represents the response for a successful batch ingestion.
 type BatchIngestResponse struct {
-	Count int `json:"count"`
+	Count           int          `json:"count"`
+	SampledOut      int          `json:"sampledOut,omitempty"`      // Measurements discarded by sampling
+	Duplicates      int          `json:"duplicates,omitempty"`      // Measurements skipped because their event ID was already stored
+	ReceivedAt      time.Time    `json:"receivedAt"`                // When the server received the request
+	Transformations []IngestNote `json:"transformations,omitempty"` // Transformation rules that changed a measurement
+	Warnings        []IngestNote `json:"warnings,omitempty"`        // Data-quality issues that did not prevent ingestion
+	AcceptedThrough *int64       `json:"acceptedThrough,omitempty"` // Highest sequence of the stream stored; set for batches with a stream ID
+	Replayed        bool         `json:"replayed,omitempty"`        // The sequence was already accepted; nothing was stored again
+}
+
+// StreamResponse is the acknowledgment state of a client's batch stream.
+type StreamResponse struct {
+	StreamID        string `json:"streamId"`
+	AcceptedThrough int64  `json:"acceptedThrough"` // Highest sequence stored; 0 for a new stream
+}
+
+// IngestNote is a message about one measurement of a batch.
+type IngestNote struct {
+	Index   int    `json:"index"` // Position of the measurement in the batch
+	Message string `json:"message"`
 }
 
 // ValidationError represents an API validation error response.
@@ -73,6 +168,14 @@ type ValidationError struct {
 	Message string `json:"message"`
 }
 
+// SequenceGapResponse is the error response for a batch whose sequence skips
+// ahead of its stream.
+type SequenceGapResponse struct {
+	Error           string `json:"error"`
+	Message         string `json:"message"`
+	AcceptedThrough int64  `json:"acceptedThrough"` // Resend from the batch after this sequence
+}
+
 // ErrorResponse represents a generic API error response.
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -81,14 +184,99 @@ type ErrorResponse struct {
 
 // MeasurementSummary represents a unique measurement name for a product.
 type MeasurementSummary struct {
-	Name         string   `json:"name"`
-	MetadataKeys []string `json:"metadataKeys"`
+	Name          string        `json:"name"`
+	MetadataKeys  []string      `json:"metadataKeys"`
+	SemanticType  *SemanticType `json:"semanticType,omitempty"` // Set if a type was declared
+	Unit          *string       `json:"unit,omitempty"`
+	LastSeenAt    time.Time     `json:"lastSeenAt"`    // Timestamp of the latest event
+	EventCount30d int64         `json:"eventCount30d"` // Events over the last 30 days
+	ArchivedAt    *time.Time    `json:"archivedAt,omitempty"`
+}
+
+// NewMeasurementName is an entry of the new measurement names trigger.
+type NewMeasurementName struct {
+	ID          string    `json:"id"` // The name, so polling clients can tell entries they have seen
+	Name        string    `json:"name"`
+	FirstSeenAt time.Time `json:"firstSeenAt"`
+}
+
+// automationTriggerLimit is how many entries a polling trigger returns.
+const automationTriggerLimit = 50
+
+// AutomationAccountResponse identifies the data source an automation
+// platform connected with, for testing and labeling the connection.
+type AutomationAccountResponse struct {
+	DataSourceID   uuid.UUID `json:"dataSourceId"`
+	DataSourceName string    `json:"dataSourceName"`
+}
+
+// RecordMeasurementAction is the body of the record measurement action.
+// No-code platforms often send every field as a string, so the value may be
+// a number or a numeric string, and metadata values may be of any type.
+type RecordMeasurementAction struct {
+	Name      string         `json:"name"`
+	Value     any            `json:"value"`     // Omit or leave empty to record an event
+	Timestamp string         `json:"timestamp"` // RFC 3339 with offset, or epoch milliseconds; empty means now
+	Metadata  map[string]any `json:"metadata"`
+	EventID   string         `json:"eventId"`
+}
+
+// MaxMeasurementNamesLimit caps the page size of a measurement name listing.
+const MaxMeasurementNamesLimit = 500
+
+// MeasurementNameQuery narrows a listing of measurement names.
+type MeasurementNameQuery struct {
+	Prefix   string         // Case-insensitive name prefix
+	After    string         // Only names sorting after this one, for paging
+	Limit    int            // 0 returns all names
+	Archived ArchivedFilter // Archived names are left out by default
+}
+
+// ArchivedFilter selects measurement names by whether they are archived.
+type ArchivedFilter string
+
+const (
+	ArchivedExclude ArchivedFilter = ""
+	ArchivedInclude ArchivedFilter = "include"
+	ArchivedOnly    ArchivedFilter = "only"
+)
+
+// IsValid checks if the archived filter is valid.
+func (f ArchivedFilter) IsValid() bool {
+	switch f {
+	case ArchivedExclude, ArchivedInclude, ArchivedOnly:
+		return true
+	}
+	return false
+}
+
+// ArchivedMeasurement is a measurement name hidden from pickers. Its data is
+// kept and can still be queried, and new data is still ingested.
+type ArchivedMeasurement struct {
+	MeasurementName string    `json:"measurementName"`
+	ArchivedAt      time.Time `json:"archivedAt"`
 }
 
 // MetadataValues represents available values for a metadata key.
 type MetadataValues struct {
-	Key    string   `json:"key"`
-	Values []string `json:"values"`
+	Key        string   `json:"key"`
+	Values     []string `json:"values"`
+	NextCursor *string  `json:"nextCursor,omitempty"` // Pass as cursor with this key for more values; omitted on the last page
+}
+
+// DefaultMetadataValuesLimit and MaxMetadataValuesLimit bound the number of
+// values returned per metadata key.
+const (
+	DefaultMetadataValuesLimit = 100
+	MaxMetadataValuesLimit     = 1000
+)
+
+// MetadataValuesQuery narrows a listing of metadata values.
+type MetadataValuesQuery struct {
+	Key    string // Only this key; required with After
+	Prefix string // Case-insensitive value prefix
+	After  string // Only values sorting after this one, for paging
+	Limit  int    // Values per key
 }
 
 // AggregatedDataPoint represents a daily aggregated measurement.
@@ -96,11 +284,17 @@ type AggregatedDataPoint struct {
 	Date  string  `json:"date"`  // YYYY-MM-DD format
 	Sum   float64 `json:"sum"`   // Sum of values for the day
 	Count int     `json:"count"` // Number of measurements
+
+	// Set when requested with spread=true
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	StdDev *float64 `json:"stddev,omitempty"` // Population standard deviation, weighted like sum
 }
 
 // ListMeasurementNamesResponse for listing unique measurement names.
 type ListMeasurementNamesResponse struct {
 	Measurements []MeasurementSummary `json:"measurements"`
+	NextCursor   *string              `json:"nextCursor,omitempty"` // Pass as cursor for the next page; omitted on the last page
 }
 
 // GetMetadataValuesResponse for metadata filter options.
@@ -127,3 +321,171 @@ type GetMeasurementDataSplitResponse struct {
 	Series  []SplitSeries `json:"series"`
 }
 
+// MaxTransformRules is the maximum number of transformation rules per data source.
+const MaxTransformRules = 50
+
+// TransformType identifies what a transformation rule does.
+type TransformType string
+
+const (
+	TransformRenameMeasurement TransformType = "rename_measurement"
+	TransformDropMetadata      TransformType = "drop_metadata"
+	TransformMapMetadata       TransformType = "map_metadata"
+	TransformScaleValue        TransformType = "scale_value"
+	TransformDeriveMeasurement TransformType = "derive_measurement"
+)
+
+// TransformRule is a single ingest-time transformation. Rules run in order on
+// every incoming measurement whose name matches Measurement.
+type TransformRule struct {
+	Type        TransformType     `json:"type"`
+	Measurement string            `json:"measurement,omitempty"` // Empty matches every measurement
+	To          string            `json:"to,omitempty"`          // New measurement name (rename, derive) or new metadata key (map)
+	Key         string            `json:"key,omitempty"`         // Metadata key (drop, map)
+	Values      map[string]string `json:"values,omitempty"`      // Metadata value mapping (map)
+	Factor      *float64          `json:"factor,omitempty"`      // Value multiplier (scale, derive; derive defaults to 1)
+}
+
+// TransformRulesResponse lists a data source's transformation rules.
+type TransformRulesResponse struct {
+	Rules []TransformRule `json:"rules"`
+}
+
+// UpdateTransformRulesRequest replaces a data source's transformation rules.
+type UpdateTransformRulesRequest struct {
+	Rules []TransformRule `json:"rules"`
+}
+
+// MaxSamplingRate is the largest accepted 1-in-N sampling rate.
+const MaxSamplingRate = 10000
+
+// SamplingConfig stores 1 in Rate measurements of a name, each weighted by
+// Rate so sums and counts stay statistically correct.
+type SamplingConfig struct {
+	MeasurementName string    `json:"measurementName"`
+	Rate            int       `json:"rate"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// ListSamplingConfigsResponse lists a data source's sampling configurations.
+type ListSamplingConfigsResponse struct {
+	Sampling []SamplingConfig `json:"sampling"`
+}
+
+// UpdateSamplingRequest sets the sampling rate for a measurement.
+type UpdateSamplingRequest struct {
+	Rate int `json:"rate" validate:"required,min=1,max=10000"`
+}
+
+// IngestFrequency is how often new data of a measurement is expected.
+type IngestFrequency string
+
+const (
+	FrequencyHourly IngestFrequency = "hourly"
+	FrequencyDaily  IngestFrequency = "daily"
+	FrequencyWeekly IngestFrequency = "weekly"
+)
+
+// IsValid checks if the ingest frequency is valid.
+func (f IngestFrequency) IsValid() bool {
+	switch f {
+	case FrequencyHourly, FrequencyDaily, FrequencyWeekly:
+		return true
+	}
+	return false
+}
+
+// FreshnessSLA declares how often a measurement is expected to be ingested.
+// Computed metrics of the measurement are flagged stale when its newest data
+// is older than that.
+type FreshnessSLA struct {
+	MeasurementName   string          `json:"measurementName"`
+	ExpectedFrequency IngestFrequency `json:"expectedFrequency"`
+	UpdatedAt         time.Time       `json:"updatedAt"`
+}
+
+// ListFreshnessSLAsResponse lists a data source's freshness SLAs.
+type ListFreshnessSLAsResponse struct {
+	Freshness []FreshnessSLA `json:"freshness"`
+}
+
+// UpdateFreshnessSLARequest sets the expected ingest frequency of a measurement.
+type UpdateFreshnessSLARequest struct {
+	ExpectedFrequency IngestFrequency `json:"expectedFrequency" validate:"required,oneof=hourly daily weekly"`
+}
+
+// SemanticType describes what the values of a measurement represent.
+type SemanticType string
+
+const (
+	SemanticTypeCount      SemanticType = "count"
+	SemanticTypeCurrency   SemanticType = "currency"
+	SemanticTypeDurationMs SemanticType = "duration_ms"
+	SemanticTypePercent    SemanticType = "percent"
+)
+
+// IsValid checks if the semantic type is valid.
+func (t SemanticType) IsValid() bool {
+	switch t {
+	case SemanticTypeCount, SemanticTypeCurrency, SemanticTypeDurationMs, SemanticTypePercent:
+		return true
+	}
+	return false
+}
+
+// MaxUnitLength is the maximum length of a measurement unit.
+const MaxUnitLength = 32
+
+// MeasurementType declares the semantic type and unit of a measurement name,
+// e.g. currency in EUR, so widgets can format its values by default.
+type MeasurementType struct {
+	MeasurementName string       `json:"measurementName"`
+	SemanticType    SemanticType `json:"semanticType"`
+	Unit            *string      `json:"unit,omitempty"` // e.g. an ISO 4217 code for currency
+	UpdatedAt       time.Time    `json:"updatedAt"`
+}
+
+// UpdateMeasurementTypeRequest declares the type of a measurement.
+type UpdateMeasurementTypeRequest struct {
+	SemanticType SemanticType `json:"semanticType" validate:"required,oneof=count currency duration_ms percent"`
+	Unit         *string      `json:"unit,omitempty"`
+}
+
+// Limits of duplicate scans, which compare each measurement with those
+// shortly before it.
+const (
+	DefaultDuplicateWindowSeconds = 1
+	MaxDuplicateWindowSeconds     = 3600
+	MaxDuplicateScanRange         = 31 * 24 * time.Hour
+)
+
+// DuplicateQuery selects the measurements scanned for duplicates: those from
+// Start up to End, each compared with the measurements up to WindowSeconds
+// before it.
+type DuplicateQuery struct {
+	Start         time.Time `json:"start" validate:"required"`
+	End           time.Time `json:"end" validate:"required"`
+	WindowSeconds int       `json:"windowSeconds"` // Defaults to 1
+}
+
+// DuplicateSummary counts the probable duplicates of one measurement name.
+type DuplicateSummary struct {
+	MeasurementName string    `json:"measurementName"`
+	Duplicates      int64     `json:"duplicates"`
+	FirstAt         time.Time `json:"firstAt"`
+	LastAt          time.Time `json:"lastAt"`
+}
+
+// DuplicateReport lists the probable duplicates in a time range: measurements
+// with the same name, value and metadata as an earlier one within the window.
+// The earliest of each group is not counted and is kept by a dedupe.
+type DuplicateReport struct {
+	DuplicateQuery
+	Duplicates   int64              `json:"duplicates"`
+	Measurements []DuplicateSummary `json:"measurements"`
+}
+
+// DedupeResponse reports how many duplicates a dedupe deleted.
+type DedupeResponse struct {
+	Deleted int64 `json:"deleted"`
+}
@@ -0,0 +1,65 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const downsampleInterval = 6 * time.Hour
+
+// Downsampler replaces raw measurements older than a threshold with per-day
+// rollups (sum, count, min, and max per metadata combination). Queries read
+// both through the measurement_points view, so results stay the same at
+// daily and coarser granularity.
+type Downsampler struct {
+	repo      *Repository
+	afterDays int
+}
+
+// NewDownsampler creates a new downsampler.
+// An afterDays of 0 disables downsampling.
+func NewDownsampler(repo *Repository, afterDays int) *Downsampler {
+	return &Downsampler{
+		repo:      repo,
+		afterDays: afterDays,
+	}
+}
+
+// Job returns the scheduled job that downsamples old measurements.
+func (d *Downsampler) Job() jobs.Job {
+	return jobs.Job{Name: "measurement_downsampling", Interval: downsampleInterval, Run: d.RunOnce}
+}
+
+// RunOnce rolls up every day of raw measurements older than the threshold,
+// one day per transaction, oldest first. It does nothing when downsampling
+// is disabled.
+func (d *Downsampler) RunOnce(ctx context.Context) error {
+	if d.afterDays <= 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -d.afterDays)
+
+	for ctx.Err() == nil {
+		day, ok, err := d.repo.GetOldestMeasurementDayBefore(ctx, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to find measurements to downsample: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		replaced, err := d.repo.RollupMeasurementsDay(ctx, day)
+		if err != nil {
+			return fmt.Errorf("failed to downsample measurements for %s: %w", day.Format("2006-01-02"), err)
+		}
+		log.Printf("Downsampled %d measurements for %s", replaced, day.Format("2006-01-02"))
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,260 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/devbydaniel/litekpi/internal/datasource"
+	platformmw "github.com/devbydaniel/litekpi/internal/platform/middleware"
+	"github.com/devbydaniel/litekpi/internal/usage"
+)
+
+// GRPCServer serves the gRPC ingestion API described in
+// proto/litekpi/ingest/v1/ingest.proto. It validates and stores
+// measurements through the same service as the HTTP endpoints.
+type GRPCServer struct {
+	service           *Service
+	dataSourceService *datasource.Service
+	limiter           *platformmw.RateLimiter // Keyed per data source; nil is unlimited
+}
+
+// NewGRPCServer creates a gRPC server with the ingestion service registered.
+// Each call, and each batch of a stream, spends a request of limiter.
+func NewGRPCServer(service *Service, dataSourceService *datasource.Service, limiter *platformmw.RateLimiter, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append([]grpc.ServerOption{grpc.ForceServerCodec(protoCodec{})}, opts...)...)
+	server.RegisterService(&ingestServiceDesc, &GRPCServer{
+		service:           service,
+		dataSourceService: dataSourceService,
+		limiter:           limiter,
+	})
+	return server
+}
+
+// ingestService is the handler type of ingestServiceDesc.
+type ingestService interface {
+	Ingest(ctx context.Context, req *IngestRequest) (*IngestResponse, error)
+	IngestBatch(ctx context.Context, req *BatchIngestRequest) (*BatchIngestResponse, error)
+	IngestStream(stream grpc.ServerStream) error
+}
+
+var ingestServiceDesc = grpc.ServiceDesc{
+	ServiceName: "litekpi.ingest.v1.IngestService",
+	HandlerType: (*ingestService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ingest",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				var req IngestRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(ingestService).Ingest(ctx, &req)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/litekpi.ingest.v1.IngestService/Ingest"}
+				return interceptor(ctx, &req, info, func(ctx context.Context, req any) (any, error) {
+					return srv.(ingestService).Ingest(ctx, req.(*IngestRequest))
+				})
+			},
+		},
+		{
+			MethodName: "IngestBatch",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				var req BatchIngestRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(ingestService).IngestBatch(ctx, &req)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/litekpi.ingest.v1.IngestService/IngestBatch"}
+				return interceptor(ctx, &req, info, func(ctx context.Context, req any) (any, error) {
+					return srv.(ingestService).IngestBatch(ctx, req.(*BatchIngestRequest))
+				})
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "IngestStream",
+			ClientStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				return srv.(ingestService).IngestStream(stream)
+			},
+		},
+	},
+	Metadata: "litekpi/ingest/v1/ingest.proto",
+}
+
+// Ingest stores a single measurement.
+func (s *GRPCServer) Ingest(ctx context.Context, req *IngestRequest) (*IngestResponse, error) {
+	ds, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.limit(ds); err != nil {
+		return nil, err
+	}
+
+	response, err := s.service.IngestSingle(ctx, ds.OrganizationID, ds.ID, *req)
+	if err != nil {
+		return nil, grpcError(err, "ingest single")
+	}
+	return response, nil
+}
+
+// IngestBatch stores a batch of measurements atomically.
+func (s *GRPCServer) IngestBatch(ctx context.Context, req *BatchIngestRequest) (*BatchIngestResponse, error) {
+	ds, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.limit(ds); err != nil {
+		return nil, err
+	}
+
+	response, err := s.service.IngestBatch(ctx, ds.OrganizationID, ds.ID, *req)
+	if err != nil {
+		return nil, grpcError(err, "ingest batch")
+	}
+	return response, nil
+}
+
+// IngestStream stores measurements as they arrive, committing them in
+// batches of MaxBatchSize. A failing batch ends the stream; the batches
+// committed before it are kept and reported in the error message.
+func (s *GRPCServer) IngestStream(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	ds, err := s.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	total := &BatchIngestResponse{ReceivedAt: time.Now().UTC()}
+	var pending []IngestRequest
+	received := 0
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		if err := s.limit(ds); err != nil {
+			st := status.Convert(err)
+			return status.Errorf(st.Code(), "%s (batch starting at message %d; %d measurements stored before it)",
+				st.Message(), received-len(pending), total.Count)
+		}
+		response, err := s.service.IngestBatch(ctx, ds.OrganizationID, ds.ID, BatchIngestRequest{Metrics: pending})
+		if err != nil {
+			st := status.Convert(grpcError(err, "ingest stream"))
+			return status.Errorf(st.Code(), "%s (batch starting at message %d; %d measurements stored before it)",
+				st.Message(), received-len(pending), total.Count)
+		}
+		total.Count += response.Count
+		total.SampledOut += response.SampledOut
+		total.Duplicates += response.Duplicates
+		// Notes are indexed by message within the stream
+		offset := received - len(pending)
+		for _, n := range response.Transformations {
+			total.Transformations = append(total.Transformations, IngestNote{Index: offset + n.Index, Message: n.Message})
+		}
+		for _, n := range response.Warnings {
+			total.Warnings = append(total.Warnings, IngestNote{Index: offset + n.Index, Message: n.Message})
+		}
+		pending = nil
+		return nil
+	}
+
+	for {
+		var req IngestRequest
+		if err := stream.RecvMsg(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		pending = append(pending, req)
+		received++
+
+		if len(pending) == MaxBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	return stream.SendMsg(total)
+}
+
+// authenticate resolves the data source from the "x-api-key" metadata entry,
+// mirroring APIKeyMiddleware.
+func (s *GRPCServer) authenticate(ctx context.Context) (*datasource.DataSource, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get("x-api-key")
+	if len(keys) == 0 || keys[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing x-api-key metadata")
+	}
+
+	ds, err := s.dataSourceService.AuthenticateAPIKey(ctx, keys[0])
+	if err != nil {
+		if errors.Is(err, datasource.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		log.Printf("grpc authenticate error: %v", err)
+		return nil, status.Error(codes.Internal, "failed to validate API key")
+	}
+
+	// Enforce the key's network allowlist
+	var ip net.IP
+	if p, ok := peer.FromContext(ctx); ok {
+		if addr, ok := p.Addr.(*net.TCPAddr); ok {
+			ip = addr.IP
+		}
+	}
+	if !ds.AllowsIP(ip) {
+		return nil, status.Error(codes.PermissionDenied, "API key not allowed from this network")
+	}
+
+	return ds, nil
+}
+
+// limit spends a request of the data source's rate limit, like the ingest
+// rate limit of the HTTP endpoints.
+func (s *GRPCServer) limit(ds *datasource.DataSource) error {
+	if wait := s.limiter.Take(dataSourceKey(ds)); wait > 0 {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded; retry in %s", wait.Round(time.Second))
+	}
+	return nil
+}
+
+// grpcError maps an ingest service error to a gRPC status, like the HTTP
+// handlers map it to a response.
+func grpcError(err error, op string) error {
+	if ve, ok := IsValidationError(err); ok {
+		return status.Error(codes.InvalidArgument, ve.message)
+	}
+	if errors.Is(err, ErrDuplicateMeasurement) {
+		return status.Error(codes.AlreadyExists, "a measurement with this name and timestamp already exists")
+	}
+	var gap *SequenceGapError
+	if errors.As(err, &gap) {
+		return status.Error(codes.FailedPrecondition, gap.Error())
+	}
+	if errors.Is(err, usage.ErrQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	log.Printf("%s error: %v", op, err)
+	return status.Error(codes.Internal, "failed to ingest measurements")
+}
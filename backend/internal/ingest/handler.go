@@ -3,8 +3,11 @@ package ingest
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +16,8 @@ import (
 
 	"github.com/devbydaniel/litekpi/internal/auth"
 	"github.com/devbydaniel/litekpi/internal/datasource"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+	"github.com/devbydaniel/litekpi/internal/usage"
 )
 
 // Handler handles HTTP requests for measurement ingestion.
@@ -29,16 +34,18 @@ func NewHandler(service *Service, dataSourceService *datasource.Service) *Handle
 // IngestSingle handles single measurement ingestion.
 //
 //	@Summary		Ingest single measurement
-//	@Description	Ingest a single measurement data point
+//	@Description	Ingest a single measurement data point, sent as JSON, protobuf or MessagePack. Omit value to record a pure event counted as 1; this is rejected for measurements declared with a semantic type other than count. With an eventId already stored for the data source, the request is a retry: nothing is stored and the stored measurement is returned with 200 and duplicate set.
 //	@Tags			ingest
-//	@Accept			json
+//	@Accept			json,application/x-protobuf,application/msgpack
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			request	body		IngestRequest	true	"Measurement data"
+//	@Success		200		{object}	IngestResponse	"Retry of a stored event ID"
 //	@Success		201		{object}	IngestResponse
 //	@Failure		400		{object}	ErrorResponse	"Validation error"
 //	@Failure		401		{object}	ErrorResponse	"Unauthorized"
 //	@Failure		409		{object}	ErrorResponse	"Duplicate measurement"
+//	@Failure		429		{object}	ErrorResponse	"Monthly event quota exceeded"
 //	@Failure		500		{object}	ErrorResponse	"Internal error"
 //	@Router			/ingest [post]
 func (h *Handler) IngestSingle(w http.ResponseWriter, r *http.Request) {
@@ -52,7 +59,7 @@ func (h *Handler) IngestSingle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req IngestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeIngestBody(r, &req); err != nil {
 		respondJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error:   "validation_failed",
 			Message: "invalid request body",
@@ -60,7 +67,12 @@ func (h *Handler) IngestSingle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.service.IngestSingle(r.Context(), ds.ID, req)
+	h.ingestSingle(w, r, ds, req)
+}
+
+// ingestSingle ingests one decoded measurement and writes the response.
+func (h *Handler) ingestSingle(w http.ResponseWriter, r *http.Request, ds *datasource.DataSource, req IngestRequest) {
+	response, err := h.service.IngestSingle(r.Context(), ds.OrganizationID, ds.ID, req)
 	if err != nil {
 		// Check for validation errors
 		if ve, ok := IsValidationError(err); ok {
@@ -80,6 +92,15 @@ func (h *Handler) IngestSingle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Check for exhausted monthly event quota
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondJSON(w, http.StatusTooManyRequests, ErrorResponse{
+				Error:   "quota_exceeded",
+				Message: err.Error(),
+			})
+			return
+		}
+
 		log.Printf("ingest single error: %v", err)
 		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_error",
@@ -88,23 +109,31 @@ func (h *Handler) IngestSingle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A retry of a stored event is acknowledged without creating anything
+	if response.Duplicate {
+		respondJSON(w, http.StatusOK, response)
+		return
+	}
+
 	respondJSON(w, http.StatusCreated, response)
 }
 
 // IngestBatch handles batch measurement ingestion.
 //
 //	@Summary		Ingest batch of measurements
-//	@Description	Ingest multiple measurement data points atomically (max 100)
+//	@Description	Ingest multiple measurement data points atomically (max 100), sent as JSON, protobuf or MessagePack. Measurements with an eventId already stored for the data source are retries; they are skipped and counted as duplicates. Buffering clients can number their batches with a streamId and a sequence starting at 1: the response's acceptedThrough acknowledges every batch up to it, a batch resent after it was stored is acknowledged with 200 and replayed set, and a batch that skips a sequence is rejected with 409 and the acceptedThrough to resume from.
 //	@Tags			ingest
-//	@Accept			json
+//	@Accept			json,application/x-protobuf,application/msgpack
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			request	body		BatchIngestRequest	true	"Batch of measurements"
 //	@Success		201		{object}	BatchIngestResponse
-//	@Failure		400		{object}	ErrorResponse	"Validation error"
-//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
-//	@Failure		409		{object}	ErrorResponse	"Duplicate measurement"
-//	@Failure		500		{object}	ErrorResponse	"Internal error"
+//	@Success		200		{object}	BatchIngestResponse	"Batch already accepted"
+//	@Failure		400		{object}	ErrorResponse		"Validation error"
+//	@Failure		401		{object}	ErrorResponse		"Unauthorized"
+//	@Failure		409		{object}	SequenceGapResponse	"Duplicate measurement or sequence gap"
+//	@Failure		429		{object}	ErrorResponse		"Monthly event quota exceeded"
+//	@Failure		500		{object}	ErrorResponse		"Internal error"
 //	@Router			/ingest/batch [post]
 func (h *Handler) IngestBatch(w http.ResponseWriter, r *http.Request) {
 	ds := DataSourceFromContext(r.Context())
@@ -117,7 +146,7 @@ func (h *Handler) IngestBatch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req BatchIngestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeIngestBody(r, &req); err != nil {
 		respondJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error:   "validation_failed",
 			Message: "invalid request body",
@@ -125,7 +154,7 @@ func (h *Handler) IngestBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.service.IngestBatch(r.Context(), ds.ID, req)
+	response, err := h.service.IngestBatch(r.Context(), ds.OrganizationID, ds.ID, req)
 	if err != nil {
 		// Check for validation errors
 		if ve, ok := IsValidationError(err); ok {
@@ -145,6 +174,26 @@ func (h *Handler) IngestBatch(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Check for a batch that skips ahead of its stream
+		var gap *SequenceGapError
+		if errors.As(err, &gap) {
+			respondJSON(w, http.StatusConflict, SequenceGapResponse{
+				Error:           "sequence_gap",
+				Message:         gap.Error(),
+				AcceptedThrough: gap.AcceptedThrough,
+			})
+			return
+		}
+
+		// Check for exhausted monthly event quota
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondJSON(w, http.StatusTooManyRequests, ErrorResponse{
+				Error:   "quota_exceeded",
+				Message: err.Error(),
+			})
+			return
+		}
+
 		log.Printf("ingest batch error: %v", err)
 		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_error",
@@ -153,9 +202,210 @@ func (h *Handler) IngestBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A resent batch of a stream is acknowledged without storing anything
+	if response.Replayed {
+		respondJSON(w, http.StatusOK, response)
+		return
+	}
+
 	respondJSON(w, http.StatusCreated, response)
 }
 
+// GetStream handles getting the acknowledgment state of a batch stream.
+//
+//	@Summary		Get batch stream
+//	@Description	Get the highest batch sequence stored for a client's batch stream (0 for a new stream). A client resuming after a crash drops its buffered batches up to acceptedThrough and resends the rest.
+//	@Tags			ingest
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			streamId	path		string	true	"Stream ID"
+//	@Success		200			{object}	StreamResponse
+//	@Failure		400			{object}	ErrorResponse	"Invalid stream ID"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500			{object}	ErrorResponse	"Internal error"
+//	@Router			/ingest/streams/{streamId} [get]
+func (h *Handler) GetStream(w http.ResponseWriter, r *http.Request) {
+	ds := DataSourceFromContext(r.Context())
+	if ds == nil {
+		respondJSON(w, http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "missing or invalid API key",
+		})
+		return
+	}
+
+	streamID, err := url.PathUnescape(chi.URLParam(r, "streamId"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: "invalid stream ID",
+		})
+		return
+	}
+
+	response, err := h.service.GetStream(r.Context(), ds.ID, streamID)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("get stream error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to get stream",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// CorrectEvent handles replacing the measurement stored under an event ID.
+//
+//	@Summary		Correct event
+//	@Description	Replace the measurement stored under a client-supplied event ID, and any measurements derived from it by transformation rules. The correction is not sampled; it keeps the weight of the measurement it replaces. Events whose day has been downsampled can no longer be corrected.
+//	@Tags			ingest
+//	@Accept			json,application/x-protobuf,application/msgpack
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			eventId	path		string			true	"Event ID"
+//	@Param			request	body		IngestRequest	true	"Corrected measurement"
+//	@Success		200		{object}	IngestResponse
+//	@Failure		400		{object}	ErrorResponse	"Validation error"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404		{object}	ErrorResponse	"Event not found"
+//	@Failure		409		{object}	ErrorResponse	"Duplicate measurement or event downsampled"
+//	@Failure		500		{object}	ErrorResponse	"Internal error"
+//	@Router			/ingest/events/{eventId} [put]
+func (h *Handler) CorrectEvent(w http.ResponseWriter, r *http.Request) {
+	ds := DataSourceFromContext(r.Context())
+	if ds == nil {
+		respondJSON(w, http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "missing or invalid API key",
+		})
+		return
+	}
+
+	eventID, err := url.PathUnescape(chi.URLParam(r, "eventId"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: "invalid event ID",
+		})
+		return
+	}
+
+	var req IngestRequest
+	if err := decodeIngestBody(r, &req); err != nil {
+		respondJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: "invalid request body",
+		})
+		return
+	}
+
+	response, err := h.service.CorrectEvent(r.Context(), ds.ID, eventID, req)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		if errors.Is(err, ErrDuplicateMeasurement) {
+			respondJSON(w, http.StatusConflict, ErrorResponse{
+				Error:   "duplicate_measurement",
+				Message: "a measurement with this name and timestamp already exists",
+			})
+			return
+		}
+		if respondEventError(w, err) {
+			return
+		}
+		log.Printf("correct event error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to correct event",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// DeleteEvent handles deleting the measurement stored under an event ID.
+//
+//	@Summary		Delete event
+//	@Description	Delete the measurement stored under a client-supplied event ID, and any measurements derived from it by transformation rules. The event ID can then be ingested again. Events whose day has been downsampled can no longer be deleted.
+//	@Tags			ingest
+//	@Security		ApiKeyAuth
+//	@Param			eventId	path	string	true	"Event ID"
+//	@Success		204
+//	@Failure		400	{object}	ErrorResponse	"Invalid event ID"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	ErrorResponse	"Event not found"
+//	@Failure		409	{object}	ErrorResponse	"Event downsampled"
+//	@Failure		500	{object}	ErrorResponse	"Internal error"
+//	@Router			/ingest/events/{eventId} [delete]
+func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	ds := DataSourceFromContext(r.Context())
+	if ds == nil {
+		respondJSON(w, http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "missing or invalid API key",
+		})
+		return
+	}
+
+	eventID, err := url.PathUnescape(chi.URLParam(r, "eventId"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: "invalid event ID",
+		})
+		return
+	}
+
+	if err := h.service.DeleteEvent(r.Context(), ds.ID, eventID); err != nil {
+		if respondEventError(w, err) {
+			return
+		}
+		log.Printf("delete event error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to delete event",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondEventError writes the response for an unknown or downsampled event.
+// It reports whether err was one of those.
+func respondEventError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		respondJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:   "event_not_found",
+			Message: err.Error(),
+		})
+	case errors.Is(err, ErrEventRolledUp):
+		respondJSON(w, http.StatusConflict, ErrorResponse{
+			Error:   "event_rolled_up",
+			Message: err.Error(),
+		})
+	default:
+		return false
+	}
+	return true
+}
+
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -192,12 +442,17 @@ func (h *Handler) validateDataSourceOwnership(r *http.Request) (*datasource.Data
 // ListMeasurementNames handles listing unique measurement names for a data source.
 //
 //	@Summary		List measurement names
-//	@Description	Get all unique measurement names for a data source with their metadata keys
+//	@Description	Get the unique measurement names for a data source, ordered by name, with their metadata keys, declared semantic type and unit, last-seen time and event count over the last 30 days. Filter by name prefix for autocomplete; with limit set, pass the returned nextCursor as cursor to get the next page. Archived names are left out unless archived is include or only.
 //	@Tags			measurements
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			dataSourceId	path		string	true	"Data Source ID"
+//	@Param			prefix			query		string	false	"Case-insensitive name prefix"
+//	@Param			limit			query		int		false	"Page size (max 500); omit to list all names"
+//	@Param			cursor			query		string	false	"nextCursor of the previous page"
+//	@Param			archived		query		string	false	"Archived names (include, only)"
 //	@Success		200				{object}	ListMeasurementNamesResponse
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
 //	@Failure		401				{object}	ErrorResponse	"Unauthorized"
 //	@Failure		404				{object}	ErrorResponse	"Data source not found"
 //	@Failure		500				{object}	ErrorResponse	"Internal error"
@@ -227,7 +482,31 @@ func (h *Handler) ListMeasurementNames(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	measurements, err := h.service.GetMeasurementNames(r.Context(), ds.ID)
+	query := MeasurementNameQuery{
+		Prefix:   r.URL.Query().Get("prefix"),
+		After:    r.URL.Query().Get("cursor"),
+		Archived: ArchivedFilter(r.URL.Query().Get("archived")),
+	}
+	if !query.Archived.IsValid() {
+		respondJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: "archived must be include or only",
+		})
+		return
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxMeasurementNamesLimit {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_failed",
+				Message: fmt.Sprintf("limit must be between 1 and %d", MaxMeasurementNamesLimit),
+			})
+			return
+		}
+		query.Limit = limit
+	}
+
+	measurements, nextCursor, err := h.service.ListMeasurementNames(r.Context(), ds.ID, query)
 	if err != nil {
 		log.Printf("get measurement names error: %v", err)
 		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
@@ -237,19 +516,24 @@ func (h *Handler) ListMeasurementNames(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, ListMeasurementNamesResponse{Measurements: measurements})
+	respondJSON(w, http.StatusOK, ListMeasurementNamesResponse{Measurements: measurements, NextCursor: nextCursor})
 }
 
 // GetMetadataValues handles getting metadata filter options for a measurement.
 //
 //	@Summary		Get metadata values
-//	@Description	Get all unique metadata key-value options for filtering a measurement
+//	@Description	Get the unique metadata key-value options for filtering a measurement, ordered by key and value, with at most limit values per key. Keys with more values carry a nextCursor; pass it as cursor together with key to page through that key's values.
 //	@Tags			measurements
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			dataSourceId	path		string	true	"Data Source ID"
 //	@Param			name			path		string	true	"Measurement name"
+//	@Param			key				query		string	false	"Only values of this metadata key"
+//	@Param			prefix			query		string	false	"Case-insensitive value prefix"
+//	@Param			limit			query		int		false	"Values per key (default 100, max 1000)"
+//	@Param			cursor			query		string	false	"nextCursor of the key's previous page (requires key)"
 //	@Success		200				{object}	GetMetadataValuesResponse
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
 //	@Failure		401				{object}	ErrorResponse	"Unauthorized"
 //	@Failure		404				{object}	ErrorResponse	"Data source not found"
 //	@Failure		500				{object}	ErrorResponse	"Internal error"
@@ -288,7 +572,31 @@ func (h *Handler) GetMetadataValues(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metadata, err := h.service.GetMetadataValues(r.Context(), ds.ID, measurementName)
+	query := MetadataValuesQuery{
+		Key:    r.URL.Query().Get("key"),
+		Prefix: r.URL.Query().Get("prefix"),
+		After:  r.URL.Query().Get("cursor"),
+	}
+	if query.After != "" && query.Key == "" {
+		respondJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: "cursor requires key",
+		})
+		return
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxMetadataValuesLimit {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_failed",
+				Message: fmt.Sprintf("limit must be between 1 and %d", MaxMetadataValuesLimit),
+			})
+			return
+		}
+		query.Limit = limit
+	}
+
+	metadata, err := h.service.GetMetadataValues(r.Context(), ds.ID, measurementName, query)
 	if err != nil {
 		log.Printf("get metadata values error: %v", err)
 		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
@@ -304,7 +612,7 @@ func (h *Handler) GetMetadataValues(w http.ResponseWriter, r *http.Request) {
 // GetMeasurementData handles getting aggregated chart data for a measurement.
 //
 //	@Summary		Get measurement data
-//	@Description	Get daily aggregated data points for a measurement with optional metadata filtering
+//	@Description	Get daily aggregated data points for a measurement with optional metadata filtering. With spread=true each day also carries the minimum, maximum and standard deviation of its values, for rendering error bands.
 //	@Tags			measurements
 //	@Produce		json
 //	@Security		BearerAuth
@@ -312,6 +620,7 @@ func (h *Handler) GetMetadataValues(w http.ResponseWriter, r *http.Request) {
 //	@Param			name			path		string	true	"Measurement name"
 //	@Param			start			query		string	true	"Start date (ISO 8601)"
 //	@Param			end				query		string	true	"End date (ISO 8601)"
+//	@Param			spread			query		bool	false	"Include min, max and stddev per day"
 //	@Success		200				{object}	GetMeasurementDataResponse
 //	@Failure		400				{object}	ErrorResponse	"Validation error"
 //	@Failure		401				{object}	ErrorResponse	"Unauthorized"
@@ -363,7 +672,9 @@ func (h *Handler) GetMeasurementData(w http.ResponseWriter, r *http.Request) {
 
 	metadataFilters := h.parseMetadataFilters(r)
 
-	dataPoints, err := h.service.GetAggregatedMeasurements(r.Context(), ds.ID, measurementName, startDate, endDate, metadataFilters)
+	spread := r.URL.Query().Get("spread") == "true"
+
+	dataPoints, err := h.service.GetAggregatedMeasurements(r.Context(), ds.ID, measurementName, startDate, endDate, metadataFilters, spread)
 	if err != nil {
 		log.Printf("get measurement data error: %v", err)
 		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
@@ -510,3 +821,645 @@ func (h *Handler) parseMetadataFilters(r *http.Request) map[string]string {
 	}
 	return metadataFilters
 }
+
+// GetTransformRules handles getting the ingest transformation rules of a data source.
+//
+//	@Summary		Get transformation rules
+//	@Description	Get the ingest-time transformation rules of a data source
+//	@Tags			measurements
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string	true	"Data Source ID"
+//	@Success		200				{object}	TransformRulesResponse
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/transforms [get]
+func (h *Handler) GetTransformRules(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	rules, err := h.service.GetTransformRules(r.Context(), ds.ID)
+	if err != nil {
+		log.Printf("get transform rules error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to get transformation rules",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, TransformRulesResponse{Rules: rules})
+}
+
+// UpdateTransformRules handles replacing the ingest transformation rules of a data source.
+//
+//	@Summary		Update transformation rules
+//	@Description	Replace the ingest-time transformation rules of a data source. Rules run in order on each incoming measurement: rename_measurement, drop_metadata, map_metadata, scale_value, and derive_measurement (stores an extra measurement). Requires admin role.
+//	@Tags			measurements
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string						true	"Data Source ID"
+//	@Param			request			body		UpdateTransformRulesRequest	true	"Transformation rules"
+//	@Success		200				{object}	TransformRulesResponse
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403				{object}	ErrorResponse	"Forbidden"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/transforms [put]
+func (h *Handler) UpdateTransformRules(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	var req UpdateTransformRulesRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	rules, err := h.service.UpdateTransformRules(r.Context(), ds.ID, req)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("update transform rules error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to update transformation rules",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, TransformRulesResponse{Rules: rules})
+}
+
+// ListSamplingConfigs handles listing the sampling configurations of a data source.
+//
+//	@Summary		List sampling configurations
+//	@Description	Get the per-measurement sampling rates of a data source
+//	@Tags			measurements
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string	true	"Data Source ID"
+//	@Success		200				{object}	ListSamplingConfigsResponse
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/sampling [get]
+func (h *Handler) ListSamplingConfigs(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	configs, err := h.service.ListSamplingConfigs(r.Context(), ds.ID)
+	if err != nil {
+		log.Printf("list sampling configs error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to list sampling configurations",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListSamplingConfigsResponse{Sampling: configs})
+}
+
+// UpdateSamplingConfig handles setting the sampling rate of a measurement.
+//
+//	@Summary		Set sampling rate
+//	@Description	Store 1 in rate incoming measurements of this name, each weighted by rate so sums, counts, and averages stay statistically correct. Requires admin role.
+//	@Tags			measurements
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string					true	"Data Source ID"
+//	@Param			name			path		string					true	"Measurement name"
+//	@Param			request			body		UpdateSamplingRequest	true	"Sampling rate"
+//	@Success		200				{object}	SamplingConfig
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403				{object}	ErrorResponse	"Forbidden"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/sampling/{name} [put]
+func (h *Handler) UpdateSamplingConfig(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	var req UpdateSamplingRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	config, err := h.service.UpdateSamplingConfig(r.Context(), ds.ID, chi.URLParam(r, "name"), req)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("update sampling config error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to update sampling configuration",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, config)
+}
+
+// DeleteSamplingConfig handles removing the sampling configuration of a measurement.
+//
+//	@Summary		Remove sampling rate
+//	@Description	Stop sampling a measurement so every incoming measurement is stored again. Requires admin role.
+//	@Tags			measurements
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path	string	true	"Data Source ID"
+//	@Param			name			path	string	true	"Measurement name"
+//	@Success		204
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Forbidden"
+//	@Failure		404	{object}	ErrorResponse	"Data source or sampling configuration not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/sampling/{name} [delete]
+func (h *Handler) DeleteSamplingConfig(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	if err := h.service.DeleteSamplingConfig(r.Context(), ds.ID, chi.URLParam(r, "name")); err != nil {
+		if errors.Is(err, ErrSamplingNotFound) {
+			respondJSON(w, http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "sampling configuration not found",
+			})
+			return
+		}
+		log.Printf("delete sampling config error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to delete sampling configuration",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListFreshnessSLAs handles listing the freshness SLAs of a data source.
+//
+//	@Summary		List freshness SLAs
+//	@Description	Get the expected ingest frequency of each measurement of a data source that declares one
+//	@Tags			measurements
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string	true	"Data Source ID"
+//	@Success		200				{object}	ListFreshnessSLAsResponse
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/freshness [get]
+func (h *Handler) ListFreshnessSLAs(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	slas, err := h.service.ListFreshnessSLAs(r.Context(), ds.ID)
+	if err != nil {
+		log.Printf("list freshness SLAs error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to list freshness SLAs",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListFreshnessSLAsResponse{Freshness: slas})
+}
+
+// UpdateFreshnessSLA handles setting the expected ingest frequency of a measurement.
+//
+//	@Summary		Set freshness SLA
+//	@Description	Declare how often new data of a measurement is expected (hourly, daily, weekly). Computed metrics of the measurement are flagged stale when its newest data is older than that. Requires admin role.
+//	@Tags			measurements
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string						true	"Data Source ID"
+//	@Param			name			path		string						true	"Measurement name"
+//	@Param			request			body		UpdateFreshnessSLARequest	true	"Expected frequency"
+//	@Success		200				{object}	FreshnessSLA
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403				{object}	ErrorResponse	"Forbidden"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/freshness/{name} [put]
+func (h *Handler) UpdateFreshnessSLA(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	var req UpdateFreshnessSLARequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	sla, err := h.service.UpdateFreshnessSLA(r.Context(), ds.ID, chi.URLParam(r, "name"), req)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("update freshness SLA error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to update freshness SLA",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sla)
+}
+
+// DeleteFreshnessSLA handles removing the freshness SLA of a measurement.
+//
+//	@Summary		Remove freshness SLA
+//	@Description	Stop flagging computed metrics of a measurement as stale. Requires admin role.
+//	@Tags			measurements
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path	string	true	"Data Source ID"
+//	@Param			name			path	string	true	"Measurement name"
+//	@Success		204
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Forbidden"
+//	@Failure		404	{object}	ErrorResponse	"Data source or freshness SLA not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/freshness/{name} [delete]
+func (h *Handler) DeleteFreshnessSLA(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	if err := h.service.DeleteFreshnessSLA(r.Context(), ds.ID, chi.URLParam(r, "name")); err != nil {
+		if errors.Is(err, ErrFreshnessNotFound) {
+			respondJSON(w, http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "freshness SLA not found",
+			})
+			return
+		}
+		log.Printf("delete freshness SLA error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to delete freshness SLA",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FindDuplicates handles scanning a data source for duplicate measurements.
+//
+//	@Summary		Find duplicate measurements
+//	@Description	Scan a time range of up to 31 days for probable duplicates: measurements with the same name, value and metadata as an earlier one at most windowSeconds before, as sent by accidentally doubled reporting agents. Measurements with an event ID are never counted. The earliest of each group is not counted.
+//	@Tags			measurements
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string	true	"Data Source ID"
+//	@Param			start			query		string	true	"Start date (ISO 8601)"
+//	@Param			end				query		string	true	"End date (ISO 8601)"
+//	@Param			windowSeconds	query		int		false	"Seconds between duplicates"	default(1)
+//	@Success		200				{object}	DuplicateReport
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/duplicates [get]
+func (h *Handler) FindDuplicates(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	var q DuplicateQuery
+	q.Start, q.End, err = h.parseDateRange(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	if v := r.URL.Query().Get("windowSeconds"); v != "" {
+		q.WindowSeconds, err = strconv.Atoi(v)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_failed",
+				Message: "windowSeconds must be an integer",
+			})
+			return
+		}
+	}
+
+	report, err := h.service.FindDuplicates(r.Context(), ds.ID, q)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("find duplicates error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to find duplicates",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// Dedupe handles deleting the duplicate measurements of a data source.
+//
+//	@Summary		Delete duplicate measurements
+//	@Description	Delete the probable duplicates that a scan with the same range and window reports, keeping the earliest measurement of each group. Computed metrics and trends of the data source are recomputed. Measurements already downsampled are not affected. Requires admin role.
+//	@Tags			measurements
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string			true	"Data Source ID"
+//	@Param			request			body		DuplicateQuery	true	"Range and window"
+//	@Success		200				{object}	DedupeResponse
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403				{object}	ErrorResponse	"Forbidden"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/duplicates/dedupe [post]
+func (h *Handler) Dedupe(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	var q DuplicateQuery
+	if err := validate.DecodeJSON(r, &q); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	resp, err := h.service.Dedupe(r.Context(), ds.ID, q)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("dedupe error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to delete duplicates",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// UpdateMeasurementType handles declaring the type of a measurement.
+//
+//	@Summary		Set measurement type
+//	@Description	Declare the semantic type (count, currency, duration_ms, percent) and unit of a measurement. It is listed with the measurement names and used as the default format of computed widget values. Requires admin role.
+//	@Tags			measurements
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string							true	"Data Source ID"
+//	@Param			name			path		string							true	"Measurement name"
+//	@Param			request			body		UpdateMeasurementTypeRequest	true	"Measurement type"
+//	@Success		200				{object}	MeasurementType
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403				{object}	ErrorResponse	"Forbidden"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/measurements/{name}/type [put]
+func (h *Handler) UpdateMeasurementType(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	var req UpdateMeasurementTypeRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	t, err := h.service.UpdateMeasurementType(r.Context(), ds.ID, chi.URLParam(r, "name"), req)
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("update measurement type error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to update measurement type",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, t)
+}
+
+// DeleteMeasurementType handles removing the declared type of a measurement.
+//
+//	@Summary		Remove measurement type
+//	@Description	Remove the declared semantic type and unit of a measurement. Requires admin role.
+//	@Tags			measurements
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path	string	true	"Data Source ID"
+//	@Param			name			path	string	true	"Measurement name"
+//	@Success		204
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Forbidden"
+//	@Failure		404	{object}	ErrorResponse	"Data source or measurement type not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/measurements/{name}/type [delete]
+func (h *Handler) DeleteMeasurementType(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	if err := h.service.DeleteMeasurementType(r.Context(), ds.ID, chi.URLParam(r, "name")); err != nil {
+		if errors.Is(err, ErrTypeNotFound) {
+			respondJSON(w, http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "measurement type not found",
+			})
+			return
+		}
+		log.Printf("delete measurement type error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to delete measurement type",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ArchiveMeasurement handles archiving a measurement name.
+//
+//	@Summary		Archive measurement name
+//	@Description	Hide an obsolete measurement name from measurement name listings and pickers without deleting its data. Archived names can still be queried and keep receiving data; list them with archived=only. Requires admin role.
+//	@Tags			measurements
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path		string	true	"Data Source ID"
+//	@Param			name			path		string	true	"Measurement name"
+//	@Success		200				{object}	ArchivedMeasurement
+//	@Failure		400				{object}	ErrorResponse	"Validation error"
+//	@Failure		401				{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403				{object}	ErrorResponse	"Forbidden"
+//	@Failure		404				{object}	ErrorResponse	"Data source not found"
+//	@Failure		500				{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/measurements/{name}/archive [put]
+func (h *Handler) ArchiveMeasurement(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	archived, err := h.service.ArchiveMeasurement(r.Context(), ds.ID, chi.URLParam(r, "name"))
+	if err != nil {
+		if ve, ok := IsValidationError(err); ok {
+			respondJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   ve.errorType,
+				Message: ve.message,
+			})
+			return
+		}
+		log.Printf("archive measurement error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to archive measurement name",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, archived)
+}
+
+// UnarchiveMeasurement handles restoring an archived measurement name.
+//
+//	@Summary		Unarchive measurement name
+//	@Description	Show an archived measurement name in listings and pickers again. Requires admin role.
+//	@Tags			measurements
+//	@Security		BearerAuth
+//	@Param			dataSourceId	path	string	true	"Data Source ID"
+//	@Param			name			path	string	true	"Measurement name"
+//	@Success		204
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Forbidden"
+//	@Failure		404	{object}	ErrorResponse	"Data source not found or name not archived"
+//	@Failure		500	{object}	ErrorResponse	"Internal error"
+//	@Router			/data-sources/{dataSourceId}/measurements/{name}/archive [delete]
+func (h *Handler) UnarchiveMeasurement(w http.ResponseWriter, r *http.Request) {
+	ds, err := h.validateDataSourceOwnership(r)
+	if err != nil {
+		respondOwnershipError(w, err)
+		return
+	}
+
+	if err := h.service.UnarchiveMeasurement(r.Context(), ds.ID, chi.URLParam(r, "name")); err != nil {
+		if errors.Is(err, ErrNotArchived) {
+			respondJSON(w, http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "measurement name is not archived",
+			})
+			return
+		}
+		log.Printf("unarchive measurement error: %v", err)
+		respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "failed to unarchive measurement name",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondOwnershipError writes the response for a validateDataSourceOwnership error.
+func respondOwnershipError(w http.ResponseWriter, err error) {
+	if err.Error() == "unauthorized" {
+		respondJSON(w, http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "unauthorized",
+		})
+		return
+	}
+	if err.Error() == "data source not found" || err.Error() == "invalid data source ID" {
+		respondJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "data source not found",
+		})
+		return
+	}
+	log.Printf("validate data source ownership error: %v", err)
+	respondJSON(w, http.StatusInternalServerError, ErrorResponse{
+		Error:   "internal_error",
+		Message: "failed to validate data source",
+	})
+}
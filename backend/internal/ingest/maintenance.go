@@ -0,0 +1,77 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const (
+	partitionMaintenanceInterval = 6 * time.Hour
+	partitionMonthsAhead         = 2 // Future monthly partitions kept ready
+)
+
+// PartitionMaintainer keeps the monthly measurements partitions up to date.
+// It creates partitions ahead of time and, when a retention period is set,
+// drops whole partitions that have aged out instead of deleting row by row.
+type PartitionMaintainer struct {
+	repo            *Repository
+	retentionMonths int
+}
+
+// NewPartitionMaintainer creates a new partition maintainer.
+// A retentionMonths of 0 keeps all history.
+func NewPartitionMaintainer(repo *Repository, retentionMonths int) *PartitionMaintainer {
+	return &PartitionMaintainer{
+		repo:            repo,
+		retentionMonths: retentionMonths,
+	}
+}
+
+// Job returns the scheduled job that maintains the partitions.
+func (m *PartitionMaintainer) Job() jobs.Job {
+	return jobs.Job{Name: "measurement_partitions", Interval: partitionMaintenanceInterval, Run: m.RunOnce}
+}
+
+// RunOnce creates upcoming partitions and drops expired ones.
+func (m *PartitionMaintainer) RunOnce(ctx context.Context) error {
+	now := time.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= partitionMonthsAhead; i++ {
+		if _, err := m.repo.EnsureMeasurementPartition(ctx, currentMonth.AddDate(0, i, 0)); err != nil {
+			return fmt.Errorf("failed to create measurements partition: %w", err)
+		}
+	}
+
+	if m.retentionMonths > 0 {
+		cutoff := currentMonth.AddDate(0, -m.retentionMonths, 0)
+		dropped, err := m.repo.DropMeasurementPartitionsBefore(ctx, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to drop expired measurements partitions: %w", err)
+		}
+		for _, name := range dropped {
+			log.Printf("Dropped expired measurements partition %s", name)
+		}
+
+		// Downsampled rollups age out with the partitions they replaced
+		if _, err := m.repo.DeleteRollupsBefore(ctx, cutoff); err != nil {
+			return fmt.Errorf("failed to delete expired measurement rollups: %w", err)
+		}
+
+		// So do the event IDs of the dropped measurements
+		if _, err := m.repo.DeleteEventsBefore(ctx, cutoff); err != nil {
+			return fmt.Errorf("failed to delete expired event IDs: %w", err)
+		}
+
+		// And the cursors of streams idle for as long
+		if _, err := m.repo.DeleteStreamsIdleSince(ctx, cutoff); err != nil {
+			return fmt.Errorf("failed to delete idle ingest streams: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -2,11 +2,12 @@ package ingest
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
+	"net"
 	"net/http"
 
 	"github.com/devbydaniel/litekpi/internal/datasource"
+	platformmw "github.com/devbydaniel/litekpi/internal/platform/middleware"
 )
 
 type contextKey string
@@ -15,7 +16,7 @@ type contextKey string
 const DataSourceContextKey contextKey = "dataSource"
 
 // APIKeyMiddleware creates a middleware that validates API keys.
-func APIKeyMiddleware(dsRepo *datasource.Repository) func(http.Handler) http.Handler {
+func APIKeyMiddleware(dsService *datasource.Service) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			apiKey := r.Header.Get("X-API-Key")
@@ -24,18 +25,19 @@ func APIKeyMiddleware(dsRepo *datasource.Repository) func(http.Handler) http.Han
 				return
 			}
 
-			// Hash the API key
-			hashBytes := sha256.Sum256([]byte(apiKey))
-			keyHash := hex.EncodeToString(hashBytes[:])
-
-			// Look up data source by hash
-			ds, err := dsRepo.GetDataSourceByAPIKeyHash(r.Context(), keyHash)
+			ds, err := dsService.AuthenticateAPIKey(r.Context(), apiKey)
 			if err != nil {
+				if errors.Is(err, datasource.ErrInvalidAPIKey) {
+					respondError(w, http.StatusUnauthorized, "unauthorized", "invalid API key")
+					return
+				}
 				respondError(w, http.StatusInternalServerError, "internal_error", "failed to validate API key")
 				return
 			}
-			if ds == nil {
-				respondError(w, http.StatusUnauthorized, "unauthorized", "invalid API key")
+
+			// Enforce the key's network allowlist
+			if !ds.AllowsIP(clientIP(r)) {
+				respondError(w, http.StatusForbidden, "forbidden", "API key not allowed from this network")
 				return
 			}
 
@@ -46,6 +48,70 @@ func APIKeyMiddleware(dsRepo *datasource.Repository) func(http.Handler) http.Han
 	}
 }
 
+// PublicKeyMiddleware creates a middleware that validates public keys for
+// ingestion from browsers. The key is read from the X-Public-Key header or,
+// for navigator.sendBeacon, which cannot set headers, the key query
+// parameter. Requests must come from one of the key's allowed origins.
+func PublicKeyMiddleware(dsService *datasource.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			publicKey := r.Header.Get("X-Public-Key")
+			if publicKey == "" {
+				publicKey = r.URL.Query().Get("key")
+			}
+			if publicKey == "" {
+				respondError(w, http.StatusUnauthorized, "unauthorized", "missing X-Public-Key header")
+				return
+			}
+
+			ds, err := dsService.AuthenticatePublicKey(r.Context(), publicKey)
+			if err != nil {
+				if errors.Is(err, datasource.ErrInvalidPublicKey) {
+					respondError(w, http.StatusUnauthorized, "unauthorized", "invalid public key")
+					return
+				}
+				respondError(w, http.StatusInternalServerError, "internal_error", "failed to validate public key")
+				return
+			}
+
+			// Browsers always send the page's origin on cross-origin POSTs
+			if !ds.AllowsOrigin(r.Header.Get("Origin")) {
+				respondError(w, http.StatusForbidden, "forbidden", "public key not allowed from this origin")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), DataSourceContextKey, ds)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// clientIP returns the client address of the request. It relies on the
+// platform RealIP middleware, which only resolves forwarding headers set by
+// trusted proxies into RemoteAddr.
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// DataSourceKey keys rate limits by the authenticated data source, so that
+// sources sharing an address are limited independently. Requests without
+// one are keyed by client IP.
+func DataSourceKey(r *http.Request) string {
+	if ds := DataSourceFromContext(r.Context()); ds != nil {
+		return dataSourceKey(ds)
+	}
+	return platformmw.ClientIP(r)
+}
+
+// dataSourceKey is the rate limit key of a data source.
+func dataSourceKey(ds *datasource.DataSource) string {
+	return "ds:" + ds.ID.String()
+}
+
 // DataSourceFromContext retrieves the data source from the request context.
 func DataSourceFromContext(ctx context.Context) *datasource.DataSource {
 	ds, _ := ctx.Value(DataSourceContextKey).(*datasource.DataSource)
@@ -0,0 +1,250 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The ingest messages are encoded by hand following the contract in
+// proto/litekpi/ingest/v1/ingest.proto. They are few and small, which keeps
+// generated code and a protoc toolchain out of the build.
+
+// protoMarshaler is implemented by messages sent in protobuf encoding.
+type protoMarshaler interface {
+	marshalProto() []byte
+}
+
+// protoUnmarshaler is implemented by messages received in protobuf encoding.
+type protoUnmarshaler interface {
+	unmarshalProto(b []byte) error
+}
+
+var errInvalidProto = errors.New("invalid protobuf message")
+
+// protoCodec is the gRPC codec for the ingest messages.
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(protoMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal %T as protobuf", v)
+	}
+	return m.marshalProto(), nil
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(protoUnmarshaler)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal protobuf into %T", v)
+	}
+	return m.unmarshalProto(data)
+}
+
+func (protoCodec) Name() string {
+	return "proto"
+}
+
+func (r *IngestRequest) unmarshalProto(b []byte) error {
+	*r = IngestRequest{Value: EventValue, event: true}
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			r.Name = v
+			return n, nil
+		case num == 2 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			r.Value = math.Float64frombits(v)
+			r.event = false
+			return n, nil
+		case num == 3 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			r.Timestamp = v
+			return n, nil
+		case num == 4 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			if r.Metadata == nil {
+				r.Metadata = make(map[string]string)
+			}
+			return n, consumeMapEntry(v, r.Metadata)
+		case num == 5 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			r.EventID = v
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func (r *BatchIngestRequest) unmarshalProto(b []byte) error {
+	*r = BatchIngestRequest{}
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var m IngestRequest
+			if err := m.unmarshalProto(v); err != nil {
+				return n, err
+			}
+			r.Metrics = append(r.Metrics, m)
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			r.StreamID = v
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			r.Sequence = int64(v)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func (r *IngestResponse) marshalProto() []byte {
+	var b []byte
+	if r.ID != uuid.Nil {
+		b = appendString(b, 1, r.ID.String())
+	}
+	b = appendString(b, 2, r.Name)
+	if r.Value != 0 {
+		b = protowire.AppendTag(b, 3, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(r.Value))
+	}
+	b = appendString(b, 4, r.Timestamp.Format(time.RFC3339Nano))
+	b = appendMap(b, 5, r.Metadata)
+	if r.SampledOut {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	if r.Duplicate {
+		b = protowire.AppendTag(b, 7, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = appendString(b, 8, r.ReceivedAt.Format(time.RFC3339Nano))
+	for _, t := range r.Transformations {
+		b = appendString(b, 9, t)
+	}
+	for _, w := range r.Warnings {
+		b = appendString(b, 10, w)
+	}
+	return b
+}
+
+func (r *BatchIngestResponse) marshalProto() []byte {
+	var b []byte
+	if r.Count != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(r.Count))
+	}
+	if r.SampledOut != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(r.SampledOut))
+	}
+	if r.Duplicates != 0 {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(r.Duplicates))
+	}
+	b = appendString(b, 4, r.ReceivedAt.Format(time.RFC3339Nano))
+	for _, t := range r.Transformations {
+		b = appendNote(b, 5, t)
+	}
+	for _, w := range r.Warnings {
+		b = appendNote(b, 6, w)
+	}
+	if r.AcceptedThrough != nil {
+		b = protowire.AppendTag(b, 7, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*r.AcceptedThrough))
+	}
+	if r.Replayed {
+		b = protowire.AppendTag(b, 8, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	return b
+}
+
+func appendNote(b []byte, num protowire.Number, n IngestNote) []byte {
+	var note []byte
+	if n.Index != 0 {
+		note = protowire.AppendTag(note, 1, protowire.VarintType)
+		note = protowire.AppendVarint(note, uint64(n.Index))
+	}
+	note = appendString(note, 2, n.Message)
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, note)
+}
+
+// consumeFields walks the fields of a message, handing each one to fn.
+// fn returns the number of bytes it consumed, or a negative protowire code.
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errInvalidProto
+		}
+		b = b[n:]
+
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return errInvalidProto
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// consumeMapEntry decodes a map<string, string> entry into m.
+func consumeMapEntry(b []byte, m map[string]string) error {
+	var key, value string
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			key = v
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			value = v
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	if err != nil {
+		return err
+	}
+	m[key] = value
+	return nil
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendMap(b []byte, num protowire.Number, m map[string]string) []byte {
+	for k, v := range m {
+		var entry []byte
+		entry = appendString(entry, 1, k)
+		entry = appendString(entry, 2, v)
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b
+}
@@ -24,77 +24,753 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
-// CreateMeasurement creates a single measurement in the database.
-func (r *Repository) CreateMeasurement(ctx context.Context, dataSourceID uuid.UUID, name string, value float64, timestamp time.Time, metadata map[string]string) (*Measurement, error) {
-	measurement := &Measurement{
-		ID:           uuid.New(),
-		DataSourceID: dataSourceID,
-		Name:         name,
-		Value:        value,
-		Timestamp:    timestamp,
-		Metadata:     metadata,
-		CreatedAt:    time.Now(),
-	}
-
-	_, err := r.pool.Exec(ctx,
-		`INSERT INTO measurements (id, data_source_id, name, value, timestamp, metadata, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		measurement.ID, measurement.DataSourceID, measurement.Name, measurement.Value, measurement.Timestamp, measurement.Metadata, measurement.CreatedAt,
-	)
+// StoredMeasurement is the outcome of storing one measurement of a batch.
+type StoredMeasurement struct {
+	ID    uuid.UUID
+	Retry bool // The event ID was already stored; ID is the measurement stored for it
+}
+
+// StreamPosition is the position of a batch in a client's batch stream.
+type StreamPosition struct {
+	StreamID string
+	Sequence int64
+}
+
+// CreateMeasurementsBatch creates multiple measurements in a single transaction.
+// Weights are the number of measurements each stands for when sampled, and
+// dedupe keys must be unique together with the name and timestamp.
+// A measurement whose event ID is already stored for the data source is a
+// retry and is skipped, along with the measurements derived from it.
+// If stream is set, its cursor advances to the batch's sequence in the same
+// transaction; see advanceStream.
+// Returns the outcome for each measurement in request order or an error.
+func (r *Repository) CreateMeasurementsBatch(ctx context.Context, dataSourceID uuid.UUID, stream *StreamPosition, requests []IngestRequest, timestamps []time.Time, weights []int, dedupeKeys []*string) ([]StoredMeasurement, error) {
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		// Check for unique constraint violation (duplicate measurement)
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return nil, ErrDuplicateMeasurement
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if stream != nil {
+		if err := advanceStream(ctx, tx, dataSourceID, *stream); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]StoredMeasurement, 0, len(requests))
+	events := make(map[string]StoredMeasurement) // event ID -> outcome of its first measurement
+	var names []string
+	for i, req := range requests {
+		id := uuid.New()
+		if req.EventID != "" {
+			event, seen := events[req.EventID]
+			if !seen {
+				existing, err := claimEvent(ctx, tx, dataSourceID, req.EventID, id, timestamps[i])
+				if err != nil {
+					return nil, err
+				}
+				event = StoredMeasurement{ID: id}
+				if existing != uuid.Nil {
+					event = StoredMeasurement{ID: existing, Retry: true}
+				}
+				events[req.EventID] = event
+			}
+			if event.Retry {
+				results = append(results, event)
+				continue
+			}
 		}
+
+		if err := insertMeasurement(ctx, tx, id, dataSourceID, req, timestamps[i], weights[i], dedupeKeys[i]); err != nil {
+			return nil, err
+		}
+		results = append(results, StoredMeasurement{ID: id})
+		names = append(names, req.Name)
+	}
+
+	if err := registerNames(ctx, tx, dataSourceID, names); err != nil {
 		return nil, err
 	}
 
-	return measurement, nil
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return results, nil
 }
 
-// CreateMeasurementsBatch creates multiple measurements in a single transaction.
-// Returns the count of inserted measurements or an error.
-func (r *Repository) CreateMeasurementsBatch(ctx context.Context, dataSourceID uuid.UUID, requests []IngestRequest, timestamps []time.Time) (int, error) {
+// ReplaceEvent replaces the measurements stored under an event ID in a single
+// transaction. The replacements keep the weight of the event's measurement.
+// Returns the IDs of the new measurements in request order, ErrEventNotFound
+// if the event ID is unknown, or ErrEventRolledUp if its measurements have
+// been downsampled.
+func (r *Repository) ReplaceEvent(ctx context.Context, dataSourceID uuid.UUID, eventID string, requests []IngestRequest, timestamp time.Time, dedupeKeys []*string) ([]uuid.UUID, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
-	count := 0
+	weight, previous, err := deleteEventMeasurements(ctx, tx, dataSourceID, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(requests))
+	names := make([]string, 0, len(requests))
 	for i, req := range requests {
-		_, err := tx.Exec(ctx,
-			`INSERT INTO measurements (id, data_source_id, name, value, timestamp, metadata, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-			uuid.New(), dataSourceID, req.Name, req.Value, timestamps[i], req.Metadata, time.Now(),
-		)
-		if err != nil {
-			// Check for unique constraint violation (duplicate measurement)
-			var pgErr *pgconn.PgError
-			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-				return 0, ErrDuplicateMeasurement
-			}
-			return 0, err
+		id := uuid.New()
+		if err := insertMeasurement(ctx, tx, id, dataSourceID, req, timestamp, weight, dedupeKeys[i]); err != nil {
+			return nil, err
 		}
-		count++
+		ids = append(ids, id)
+		names = append(names, req.Name)
+	}
+
+	if err := registerNames(ctx, tx, dataSourceID, names); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE measurement_events SET measurement_id = $1, timestamp = $2
+		WHERE data_source_id = $3 AND event_id = $4`,
+		ids[0], timestamp, dataSourceID, eventID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	oldest := previous
+	if timestamp.Before(oldest) {
+		oldest = timestamp
+	}
+	if _, err := tx.Exec(ctx, recordArrivalQuery, dataSourceID, oldest, timestamp, BackfillTolerance.Seconds()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// DeleteEvent deletes the measurements stored under an event ID, releasing
+// the event ID. Returns ErrEventNotFound if the event ID is unknown, or
+// ErrEventRolledUp if its measurements have been downsampled.
+func (r *Repository) DeleteEvent(ctx context.Context, dataSourceID uuid.UUID, eventID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, timestamp, err := deleteEventMeasurements(ctx, tx, dataSourceID, eventID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM measurement_events WHERE data_source_id = $1 AND event_id = $2`,
+		dataSourceID, eventID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, recordArrivalQuery, dataSourceID, timestamp, timestamp, BackfillTolerance.Seconds()); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RecordArrival advances a data source's ingest watermark to newest and
+// records the arrival time. If oldest is more than BackfillTolerance before
+// the previous watermark, the data source is marked backfilled: the
+// measurements landed in buckets that may already have been computed.
+func (r *Repository) RecordArrival(ctx context.Context, dataSourceID uuid.UUID, oldest, newest time.Time) error {
+	_, err := r.pool.Exec(ctx, recordArrivalQuery, dataSourceID, oldest, newest, BackfillTolerance.Seconds())
+	return err
+}
+
+// recordArrivalQuery updates the ingest watermark of a data source; see
+// RecordArrival. SET expressions see the row's previous values.
+const recordArrivalQuery = `UPDATE data_sources SET
+	backfilled_at = CASE WHEN $2 < max_timestamp - make_interval(secs => $4) THEN NOW() ELSE backfilled_at END,
+	max_timestamp = GREATEST(max_timestamp, $3),
+	last_received_at = NOW()
+WHERE id = $1`
+
+// duplicateCondition matches the measurements m of data source $1 from $2
+// up to $3 that repeat the name, value and metadata of an earlier one at most
+// $4 seconds before. Measurements with an event ID are distinct events by
+// the client's account and never match.
+const duplicateCondition = `m.data_source_id = $1 AND m.timestamp >= $2 AND m.timestamp < $3
+	AND m.event_id IS NULL
+	AND EXISTS (
+		SELECT 1 FROM measurements p
+		WHERE p.data_source_id = m.data_source_id
+			AND p.name = m.name
+			AND p.value = m.value
+			AND p.metadata IS NOT DISTINCT FROM m.metadata
+			AND p.timestamp BETWEEN m.timestamp - make_interval(secs => $4) AND m.timestamp
+			AND (p.timestamp, p.id) < (m.timestamp, m.id)
+	)`
+
+// GetDuplicates counts the probable duplicates of a data source in a time
+// range by measurement name, most duplicated first.
+func (r *Repository) GetDuplicates(ctx context.Context, dataSourceID uuid.UUID, q DuplicateQuery) ([]DuplicateSummary, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.name, COUNT(*), MIN(m.timestamp), MAX(m.timestamp)
+		FROM measurements m
+		WHERE `+duplicateCondition+`
+		GROUP BY m.name
+		ORDER BY COUNT(*) DESC, m.name`,
+		dataSourceID, q.Start, q.End, q.WindowSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []DuplicateSummary
+	for rows.Next() {
+		var d DuplicateSummary
+		if err := rows.Scan(&d.MeasurementName, &d.Duplicates, &d.FirstAt, &d.LastAt); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// DeleteDuplicates deletes the probable duplicates of a data source in a
+// time range, keeping the earliest measurement of each group, and marks the
+// data source backfilled so values computed from the duplicates are
+// recomputed.
+func (r *Repository) DeleteDuplicates(ctx context.Context, dataSourceID uuid.UUID, q DuplicateQuery) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM measurements m WHERE `+duplicateCondition,
+		dataSourceID, q.Start, q.End, q.WindowSeconds,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE data_sources SET backfilled_at = NOW() WHERE id = $1`, dataSourceID); err != nil {
+		return 0, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return 0, err
 	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteEventsBefore deletes the event IDs of measurements before cutoff.
+func (r *Repository) DeleteEventsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM measurement_events WHERE timestamp < $1`,
+		cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetStreamAcceptedThrough retrieves the highest batch sequence stored for a
+// stream, or 0 if the stream is unknown.
+func (r *Repository) GetStreamAcceptedThrough(ctx context.Context, dataSourceID uuid.UUID, streamID string) (int64, error) {
+	var acceptedThrough int64
+	err := r.pool.QueryRow(ctx,
+		`SELECT accepted_through FROM ingest_streams WHERE data_source_id = $1 AND stream_id = $2`,
+		dataSourceID, streamID,
+	).Scan(&acceptedThrough)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return acceptedThrough, err
+}
+
+// DeleteStreamsIdleSince deletes the cursors of streams without a batch since cutoff.
+func (r *Repository) DeleteStreamsIdleSince(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM ingest_streams WHERE updated_at < $1`,
+		cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// advanceStream moves a stream's cursor to pos.Sequence, locking it until
+// the transaction ends so that concurrent batches of a stream serialize.
+// A new stream may start at any sequence; after that, each batch must take
+// the next one. Returns ErrBatchReplayed if the sequence was already
+// accepted, or a *SequenceGapError if it skips ahead.
+func advanceStream(ctx context.Context, tx pgx.Tx, dataSourceID uuid.UUID, pos StreamPosition) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO ingest_streams (data_source_id, stream_id) VALUES ($1, $2)
+		ON CONFLICT (data_source_id, stream_id) DO NOTHING`,
+		dataSourceID, pos.StreamID,
+	)
+	if err != nil {
+		return err
+	}
+
+	var acceptedThrough int64
+	err = tx.QueryRow(ctx,
+		`SELECT accepted_through FROM ingest_streams
+		WHERE data_source_id = $1 AND stream_id = $2
+		FOR UPDATE`,
+		dataSourceID, pos.StreamID,
+	).Scan(&acceptedThrough)
+	if err != nil {
+		return err
+	}
+
+	if acceptedThrough > 0 {
+		if pos.Sequence <= acceptedThrough {
+			return ErrBatchReplayed
+		}
+		if pos.Sequence > acceptedThrough+1 {
+			return &SequenceGapError{Sequence: pos.Sequence, AcceptedThrough: acceptedThrough}
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE ingest_streams SET accepted_through = $1, updated_at = NOW()
+		WHERE data_source_id = $2 AND stream_id = $3`,
+		pos.Sequence, dataSourceID, pos.StreamID,
+	)
+	return err
+}
+
+// insertMeasurement inserts a measurement within tx.
+func insertMeasurement(ctx context.Context, tx pgx.Tx, id, dataSourceID uuid.UUID, req IngestRequest, timestamp time.Time, weight int, dedupeKey *string) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO measurements (id, data_source_id, name, value, timestamp, metadata, weight, dedupe_key, event_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)`,
+		id, dataSourceID, req.Name, req.Value, timestamp, req.Metadata, weight, dedupeKey, req.EventID, time.Now(),
+	)
+	if err != nil {
+		// Check for unique constraint violation (duplicate measurement)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateMeasurement
+		}
+		return err
+	}
+	return nil
+}
+
+// registerNames records the measurement names not seen before for the data
+// source as first seen now.
+func registerNames(ctx context.Context, tx pgx.Tx, dataSourceID uuid.UUID, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO measurement_names (data_source_id, name)
+		SELECT DISTINCT $1::uuid, name FROM unnest($2::text[]) AS name
+		ON CONFLICT DO NOTHING`,
+		dataSourceID, names,
+	)
+	return err
+}
+
+// claimEvent records an event ID for the measurement with the given ID.
+// If the event ID is already stored, it returns the ID of the measurement
+// stored for it instead, and uuid.Nil otherwise.
+func claimEvent(ctx context.Context, tx pgx.Tx, dataSourceID uuid.UUID, eventID string, measurementID uuid.UUID, timestamp time.Time) (uuid.UUID, error) {
+	tag, err := tx.Exec(ctx,
+		`INSERT INTO measurement_events (data_source_id, event_id, measurement_id, timestamp)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (data_source_id, event_id) DO NOTHING`,
+		dataSourceID, eventID, measurementID, timestamp,
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if tag.RowsAffected() > 0 {
+		return uuid.Nil, nil
+	}
+
+	var existing uuid.UUID
+	err = tx.QueryRow(ctx,
+		`SELECT measurement_id FROM measurement_events WHERE data_source_id = $1 AND event_id = $2`,
+		dataSourceID, eventID,
+	).Scan(&existing)
+	return existing, err
+}
+
+// deleteEventMeasurements deletes the measurements stored under an event ID
+// within tx, locking the event ID. Returns the weight and timestamp of the
+// event's measurement.
+func deleteEventMeasurements(ctx context.Context, tx pgx.Tx, dataSourceID uuid.UUID, eventID string) (int, time.Time, error) {
+	var measurementID uuid.UUID
+	var timestamp time.Time
+	err := tx.QueryRow(ctx,
+		`SELECT measurement_id, timestamp FROM measurement_events
+		WHERE data_source_id = $1 AND event_id = $2
+		FOR UPDATE`,
+		dataSourceID, eventID,
+	).Scan(&measurementID, &timestamp)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, time.Time{}, ErrEventNotFound
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	rows, err := tx.Query(ctx,
+		`DELETE FROM measurements
+		WHERE data_source_id = $1 AND event_id = $2 AND timestamp = $3
+		RETURNING id, weight`,
+		dataSourceID, eventID, timestamp,
+	)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer rows.Close()
+
+	weight := 0
+	for rows.Next() {
+		var id uuid.UUID
+		var w int
+		if err := rows.Scan(&id, &w); err != nil {
+			return 0, time.Time{}, err
+		}
+		if weight == 0 || id == measurementID {
+			weight = w
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	// Downsampling replaces raw measurements, and their event IDs with them
+	if weight == 0 {
+		return 0, time.Time{}, ErrEventRolledUp
+	}
+
+	return weight, timestamp, nil
+}
+
+// GetRelaxedUniqueness reports whether a data source opted out of the
+// uniqueness of measurements by name and timestamp.
+func (r *Repository) GetRelaxedUniqueness(ctx context.Context, dataSourceID uuid.UUID) (bool, error) {
+	var relaxed bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT relaxed_uniqueness FROM data_sources WHERE id = $1`,
+		dataSourceID,
+	).Scan(&relaxed)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return relaxed, nil
+}
+
+// GetTransformRules retrieves the transformation rules for a data source.
+func (r *Repository) GetTransformRules(ctx context.Context, dataSourceID uuid.UUID) ([]TransformRule, error) {
+	var rulesJSON []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT rules FROM ingest_transforms WHERE data_source_id = $1`,
+		dataSourceID,
+	).Scan(&rulesJSON)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []TransformRule
+	if err := json.Unmarshal(rulesJSON, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// SetTransformRules replaces the transformation rules for a data source.
+func (r *Repository) SetTransformRules(ctx context.Context, dataSourceID uuid.UUID, rules []TransformRule) error {
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO ingest_transforms (data_source_id, rules)
+		VALUES ($1, $2)
+		ON CONFLICT (data_source_id) DO UPDATE SET rules = EXCLUDED.rules`,
+		dataSourceID, rulesJSON,
+	)
+	return err
+}
+
+// GetSamplingRates retrieves the sampling rates for a data source keyed by measurement name.
+func (r *Repository) GetSamplingRates(ctx context.Context, dataSourceID uuid.UUID) (map[string]int, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT measurement_name, rate FROM measurement_sampling WHERE data_source_id = $1`,
+		dataSourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var rate int
+		if err := rows.Scan(&name, &rate); err != nil {
+			return nil, err
+		}
+		rates[name] = rate
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// ListSamplingConfigs retrieves the sampling configurations for a data source.
+func (r *Repository) ListSamplingConfigs(ctx context.Context, dataSourceID uuid.UUID) ([]SamplingConfig, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT measurement_name, rate, updated_at
+		FROM measurement_sampling WHERE data_source_id = $1
+		ORDER BY measurement_name`,
+		dataSourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []SamplingConfig
+	for rows.Next() {
+		var c SamplingConfig
+		if err := rows.Scan(&c.MeasurementName, &c.Rate, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		configs = append(configs, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+// UpsertSamplingConfig sets the sampling rate for a measurement.
+func (r *Repository) UpsertSamplingConfig(ctx context.Context, dataSourceID uuid.UUID, name string, rate int) (*SamplingConfig, error) {
+	c := &SamplingConfig{MeasurementName: name, Rate: rate}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO measurement_sampling (data_source_id, measurement_name, rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (data_source_id, measurement_name) DO UPDATE SET rate = EXCLUDED.rate
+		RETURNING updated_at`,
+		dataSourceID, name, rate,
+	).Scan(&c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteSamplingConfig removes the sampling configuration for a measurement.
+// Returns false if none existed.
+func (r *Repository) DeleteSamplingConfig(ctx context.Context, dataSourceID uuid.UUID, name string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM measurement_sampling WHERE data_source_id = $1 AND measurement_name = $2`,
+		dataSourceID, name,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListFreshnessSLAs retrieves the freshness SLAs for a data source.
+func (r *Repository) ListFreshnessSLAs(ctx context.Context, dataSourceID uuid.UUID) ([]FreshnessSLA, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT measurement_name, expected_frequency, updated_at
+		FROM measurement_freshness WHERE data_source_id = $1
+		ORDER BY measurement_name`,
+		dataSourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slas []FreshnessSLA
+	for rows.Next() {
+		var sla FreshnessSLA
+		if err := rows.Scan(&sla.MeasurementName, &sla.ExpectedFrequency, &sla.UpdatedAt); err != nil {
+			return nil, err
+		}
+		slas = append(slas, sla)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slas, nil
+}
+
+// UpsertFreshnessSLA sets the expected ingest frequency of a measurement.
+func (r *Repository) UpsertFreshnessSLA(ctx context.Context, dataSourceID uuid.UUID, name string, frequency IngestFrequency) (*FreshnessSLA, error) {
+	sla := &FreshnessSLA{MeasurementName: name, ExpectedFrequency: frequency}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO measurement_freshness (data_source_id, measurement_name, expected_frequency)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (data_source_id, measurement_name) DO UPDATE SET expected_frequency = EXCLUDED.expected_frequency
+		RETURNING updated_at`,
+		dataSourceID, name, frequency,
+	).Scan(&sla.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return sla, nil
+}
+
+// DeleteFreshnessSLA removes the freshness SLA of a measurement.
+// Returns false if none existed.
+func (r *Repository) DeleteFreshnessSLA(ctx context.Context, dataSourceID uuid.UUID, name string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM measurement_freshness WHERE data_source_id = $1 AND measurement_name = $2`,
+		dataSourceID, name,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetSemanticTypes retrieves the declared semantic types for a data source keyed by measurement name.
+func (r *Repository) GetSemanticTypes(ctx context.Context, dataSourceID uuid.UUID) (map[string]SemanticType, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT measurement_name, semantic_type FROM measurement_types WHERE data_source_id = $1`,
+		dataSourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := make(map[string]SemanticType)
+	for rows.Next() {
+		var name string
+		var t SemanticType
+		if err := rows.Scan(&name, &t); err != nil {
+			return nil, err
+		}
+		types[name] = t
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return types, nil
+}
+
+// UpsertMeasurementType sets the semantic type and unit of a measurement.
+func (r *Repository) UpsertMeasurementType(ctx context.Context, dataSourceID uuid.UUID, name string, semanticType SemanticType, unit *string) (*MeasurementType, error) {
+	t := &MeasurementType{MeasurementName: name, SemanticType: semanticType, Unit: unit}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO measurement_types (data_source_id, measurement_name, semantic_type, unit)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (data_source_id, measurement_name) DO UPDATE SET semantic_type = EXCLUDED.semantic_type, unit = EXCLUDED.unit
+		RETURNING updated_at`,
+		dataSourceID, name, semanticType, unit,
+	).Scan(&t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
 
-	return count, nil
+// DeleteMeasurementType removes the declared type of a measurement.
+// Returns false if none existed.
+func (r *Repository) DeleteMeasurementType(ctx context.Context, dataSourceID uuid.UUID, name string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM measurement_types WHERE data_source_id = $1 AND measurement_name = $2`,
+		dataSourceID, name,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ArchiveMeasurement hides a measurement name of a data source from
+// pickers. Archiving an archived name keeps its archive time.
+func (r *Repository) ArchiveMeasurement(ctx context.Context, dataSourceID uuid.UUID, name string) (*ArchivedMeasurement, error) {
+	a := &ArchivedMeasurement{MeasurementName: name}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO archived_measurements (data_source_id, measurement_name)
+		VALUES ($1, $2)
+		ON CONFLICT (data_source_id, measurement_name) DO UPDATE SET measurement_name = EXCLUDED.measurement_name
+		RETURNING archived_at`,
+		dataSourceID, name,
+	).Scan(&a.ArchivedAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// UnarchiveMeasurement shows an archived measurement name in pickers again.
+// Returns false if the name was not archived.
+func (r *Repository) UnarchiveMeasurement(ctx context.Context, dataSourceID uuid.UUID, name string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM archived_measurements WHERE data_source_id = $1 AND measurement_name = $2`,
+		dataSourceID, name,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
 }
 
 // GetMeasurementByID retrieves a measurement by its ID.
 func (r *Repository) GetMeasurementByID(ctx context.Context, id uuid.UUID) (*Measurement, error) {
 	measurement := &Measurement{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, data_source_id, name, value, timestamp, metadata, created_at
+		`SELECT id, data_source_id, name, value, timestamp, metadata, event_id, created_at
 		FROM measurements WHERE id = $1`,
 		id,
-	).Scan(&measurement.ID, &measurement.DataSourceID, &measurement.Name, &measurement.Value, &measurement.Timestamp, &measurement.Metadata, &measurement.CreatedAt)
+	).Scan(&measurement.ID, &measurement.DataSourceID, &measurement.Name, &measurement.Value, &measurement.Timestamp, &measurement.Metadata, &measurement.EventID, &measurement.CreatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -106,25 +782,60 @@ func (r *Repository) GetMeasurementByID(ctx context.Context, id uuid.UUID) (*Mea
 	return measurement, nil
 }
 
-// GetMeasurementNames retrieves distinct measurement names with their metadata keys for a data source.
-func (r *Repository) GetMeasurementNames(ctx context.Context, dataSourceID uuid.UUID) ([]MeasurementSummary, error) {
-	// Query to get distinct names and all unique metadata keys per name
+// GetMeasurementNames retrieves the measurement names of a data source matching
+// q, ordered by name, with their metadata keys, last-seen time and event count
+// over the last 30 days.
+func (r *Repository) GetMeasurementNames(ctx context.Context, dataSourceID uuid.UUID, q MeasurementNameQuery) ([]MeasurementSummary, error) {
+	var limit *int
+	if q.Limit > 0 {
+		limit = &q.Limit
+	}
+
+	var archived string
+	switch q.Archived {
+	case ArchivedExclude:
+		archived = `AND NOT EXISTS (SELECT 1 FROM archived_measurements a WHERE a.data_source_id = $1 AND a.measurement_name = mp.name)`
+	case ArchivedOnly:
+		archived = `AND EXISTS (SELECT 1 FROM archived_measurements a WHERE a.data_source_id = $1 AND a.measurement_name = mp.name)`
+	}
+
 	rows, err := r.pool.Query(ctx,
-		`SELECT
-			name,
-			COALESCE(
+		`WITH points AS (
+			SELECT name, timestamp, weight, metadata
+			FROM measurement_points mp
+			WHERE data_source_id = $1
+			  AND starts_with(lower(name), lower($2))
+			  AND name > $3
+			  `+archived+`
+		)
+		SELECT
+			n.name, k.metadata_keys, n.last_seen_at, n.event_count_30d, t.semantic_type, t.unit, a.archived_at
+		FROM (
+			SELECT
+				name,
+				MAX(timestamp) as last_seen_at,
+				COALESCE(SUM(weight) FILTER (WHERE timestamp >= NOW() - INTERVAL '30 days'), 0) as event_count_30d
+			FROM points
+			GROUP BY name
+			ORDER BY name
+			LIMIT $4
+		) n
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(
 				array_agg(DISTINCT key ORDER BY key) FILTER (WHERE key IS NOT NULL),
 				'{}'::text[]
 			) as metadata_keys
-		FROM measurements
-		LEFT JOIN LATERAL (
-			SELECT jsonb_object_keys(metadata) as key
-			WHERE metadata IS NOT NULL AND metadata != 'null'::jsonb
-		) keys ON true
-		WHERE data_source_id = $1
-		GROUP BY name
-		ORDER BY name`,
-		dataSourceID,
+			FROM points p
+			LEFT JOIN LATERAL (
+				SELECT jsonb_object_keys(p.metadata) as key
+				WHERE p.metadata IS NOT NULL AND p.metadata != 'null'::jsonb
+			) keys ON true
+			WHERE p.name = n.name
+		) k
+		LEFT JOIN measurement_types t ON t.data_source_id = $1 AND t.measurement_name = n.name
+		LEFT JOIN archived_measurements a ON a.data_source_id = $1 AND a.measurement_name = n.name
+		ORDER BY n.name`,
+		dataSourceID, q.Prefix, q.After, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -134,7 +845,7 @@ func (r *Repository) GetMeasurementNames(ctx context.Context, dataSourceID uuid.
 	var summaries []MeasurementSummary
 	for rows.Next() {
 		var summary MeasurementSummary
-		if err := rows.Scan(&summary.Name, &summary.MetadataKeys); err != nil {
+		if err := rows.Scan(&summary.Name, &summary.MetadataKeys, &summary.LastSeenAt, &summary.EventCount30d, &summary.SemanticType, &summary.Unit, &summary.ArchivedAt); err != nil {
 			return nil, err
 		}
 		summaries = append(summaries, summary)
@@ -151,78 +862,91 @@ func (r *Repository) GetMeasurementNames(ctx context.Context, dataSourceID uuid.
 	return summaries, nil
 }
 
-// GetMetadataValues retrieves all unique metadata key-value combinations for a specific measurement.
-func (r *Repository) GetMetadataValues(ctx context.Context, dataSourceID uuid.UUID, measurementName string) ([]MetadataValues, error) {
+// GetNewestMeasurementNames retrieves up to limit measurement names of a
+// data source, most recently first seen first.
+func (r *Repository) GetNewestMeasurementNames(ctx context.Context, dataSourceID uuid.UUID, limit int) ([]NewMeasurementName, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT DISTINCT metadata
-		FROM measurements
-		WHERE data_source_id = $1 AND name = $2 AND metadata IS NOT NULL AND metadata != 'null'::jsonb`,
-		dataSourceID, measurementName,
+		`SELECT name, first_seen_at FROM measurement_names
+		WHERE data_source_id = $1
+		ORDER BY first_seen_at DESC, name
+		LIMIT $2`,
+		dataSourceID, limit,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	// Collect all metadata values
-	keyValues := make(map[string]map[string]struct{})
+	names := []NewMeasurementName{}
 	for rows.Next() {
-		var metadataJSON []byte
-		if err := rows.Scan(&metadataJSON); err != nil {
+		var n NewMeasurementName
+		if err := rows.Scan(&n.Name, &n.FirstSeenAt); err != nil {
 			return nil, err
 		}
-
-		var metadata map[string]string
-		if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
-			continue // Skip invalid metadata
-		}
-
-		for k, v := range metadata {
-			if keyValues[k] == nil {
-				keyValues[k] = make(map[string]struct{})
-			}
-			keyValues[k][v] = struct{}{}
-		}
+		n.ID = n.Name
+		names = append(names, n)
 	}
+	return names, rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
+// GetMetadataValues retrieves the unique values of each metadata key of a
+// measurement matching q, ordered by key and value, with at most q.Limit
+// values per key.
+func (r *Repository) GetMetadataValues(ctx context.Context, dataSourceID uuid.UUID, measurementName string, q MetadataValuesQuery) ([]MetadataValues, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT key, value FROM (
+			SELECT key, value, ROW_NUMBER() OVER (PARTITION BY key ORDER BY value) as rn
+			FROM (
+				SELECT DISTINCT kv.key, kv.value
+				FROM measurement_points p, jsonb_each_text(p.metadata) kv
+				WHERE p.data_source_id = $1 AND p.name = $2 AND jsonb_typeof(p.metadata) = 'object'
+				  AND ($3 = '' OR kv.key = $3)
+				  AND starts_with(lower(kv.value), lower($4))
+				  AND kv.value > $5
+			) kv
+		) ranked
+		WHERE rn <= $6
+		ORDER BY key, value`,
+		dataSourceID, measurementName, q.Key, q.Prefix, q.After, q.Limit,
+	)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	// Convert to response format
-	var result []MetadataValues
-	for key, valuesSet := range keyValues {
-		values := make([]string, 0, len(valuesSet))
-		for v := range valuesSet {
-			values = append(values, v)
+	result := []MetadataValues{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
 		}
-		sort.Strings(values)
-		result = append(result, MetadataValues{
-			Key:    key,
-			Values: values,
-		})
-	}
-
-	// Sort by key
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Key < result[j].Key
-	})
-
-	if result == nil {
-		result = []MetadataValues{}
+		if len(result) == 0 || result[len(result)-1].Key != key {
+			result = append(result, MetadataValues{Key: key, Values: []string{}})
+		}
+		last := &result[len(result)-1]
+		last.Values = append(last.Values, value)
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 // GetAggregatedMeasurements retrieves daily aggregated values with optional metadata filtering.
-func (r *Repository) GetAggregatedMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string) ([]AggregatedDataPoint, error) {
+// With spread set, the minimum, maximum and weighted standard deviation of
+// each day are computed by the same query.
+func (r *Repository) GetAggregatedMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, spread bool) ([]AggregatedDataPoint, error) {
 	// Build the query with optional metadata filtering
+	spreadColumns := ""
+	if spread {
+		spreadColumns = `,
+		MIN(value_min) as min,
+		MAX(value_max) as max,
+		SQRT(GREATEST(SUM(value_sum_sq) / SUM(weight) - POWER(SUM(value * weight) / SUM(weight), 2), 0)) as stddev`
+	}
 	query := `SELECT
 		DATE(timestamp) as date,
-		SUM(value) as sum,
-		COUNT(*) as count
-	FROM measurements
+		SUM(value * weight) as sum,
+		SUM(weight) as count` + spreadColumns + `
+	FROM measurement_points
 	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4`
 
 	args := []interface{}{dataSourceID, name, startDate, endDate}
@@ -249,7 +973,11 @@ func (r *Repository) GetAggregatedMeasurements(ctx context.Context, dataSourceID
 	for rows.Next() {
 		var dp AggregatedDataPoint
 		var date time.Time
-		if err := rows.Scan(&date, &dp.Sum, &dp.Count); err != nil {
+		dest := []any{&date, &dp.Sum, &dp.Count}
+		if spread {
+			dest = append(dest, &dp.Min, &dp.Max, &dp.StdDev)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
 		dp.Date = date.Format("2006-01-02")
@@ -269,7 +997,7 @@ func (r *Repository) GetAggregatedMeasurements(ctx context.Context, dataSourceID
 
 // GetRawMeasurements retrieves raw measurement data points with optional metadata filtering.
 func (r *Repository) GetRawMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, limit int) ([]Measurement, error) {
-	query := `SELECT id, data_source_id, name, value, timestamp, metadata, created_at
+	query := `SELECT id, data_source_id, name, value, timestamp, metadata, event_id, created_at
 		FROM measurements
 		WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4`
 
@@ -301,7 +1029,7 @@ func (r *Repository) GetRawMeasurements(ctx context.Context, dataSourceID uuid.U
 	for rows.Next() {
 		var m Measurement
 		var metadataJSON []byte
-		if err := rows.Scan(&m.ID, &m.DataSourceID, &m.Name, &m.Value, &m.Timestamp, &metadataJSON, &m.CreatedAt); err != nil {
+		if err := rows.Scan(&m.ID, &m.DataSourceID, &m.Name, &m.Value, &m.Timestamp, &metadataJSON, &m.EventID, &m.CreatedAt); err != nil {
 			return nil, err
 		}
 		if metadataJSON != nil {
@@ -331,9 +1059,9 @@ func (r *Repository) GetAggregatedMeasurementsSplitBy(ctx context.Context, dataS
 	query := `SELECT
 		metadata->>$5 as split_key,
 		DATE(timestamp) as date,
-		SUM(value) as sum,
-		COUNT(*) as count
-	FROM measurements
+		SUM(value * weight) as sum,
+		SUM(weight) as count
+	FROM measurement_points
 	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4
 	  AND metadata ? $5`
 
@@ -394,3 +1122,124 @@ func (r *Repository) GetAggregatedMeasurementsSplitBy(ctx context.Context, dataS
 
 	return series, nil
 }
+
+// EnsureMeasurementPartition creates the monthly measurements partition containing month if it does not exist.
+func (r *Repository) EnsureMeasurementPartition(ctx context.Context, month time.Time) (string, error) {
+	var name string
+	err := r.pool.QueryRow(ctx,
+		`SELECT create_measurements_partition($1::date)`,
+		month,
+	).Scan(&name)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// DropMeasurementPartitionsBefore drops monthly measurements partitions that end on or before cutoff
+// and deletes the rows before cutoff from the default partition.
+func (r *Repository) DropMeasurementPartitionsBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT drop_measurements_partitions_before($1::date)`,
+		cutoff,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dropped []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		dropped = append(dropped, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dropped, nil
+}
+
+// GetOldestMeasurementDayBefore returns the oldest UTC day holding raw measurements before cutoff.
+// Returns false if there is none.
+func (r *Repository) GetOldestMeasurementDayBefore(ctx context.Context, cutoff time.Time) (time.Time, bool, error) {
+	var oldest *time.Time
+	err := r.pool.QueryRow(ctx,
+		`SELECT MIN(timestamp) FROM measurements WHERE timestamp < $1`,
+		cutoff,
+	).Scan(&oldest)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if oldest == nil {
+		return time.Time{}, false, nil
+	}
+
+	t := oldest.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true, nil
+}
+
+// RollupMeasurementsDay replaces the raw measurements of one UTC day with per-day
+// aggregates per data source, name, and metadata combination. It merges into
+// existing rollups, so late data for an already downsampled day is folded in.
+// Returns the number of raw measurements replaced.
+func (r *Repository) RollupMeasurementsDay(ctx context.Context, day time.Time) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	start := day
+	end := day.AddDate(0, 0, 1)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO measurement_rollups (data_source_id, name, day, metadata, value_sum, value_count, value_min, value_max, value_sum_sq)
+		SELECT data_source_id, name, $1::date, COALESCE(NULLIF(metadata, 'null'::jsonb), '{}'::jsonb),
+		       SUM(value * weight), SUM(weight), MIN(value), MAX(value), SUM(value * value * weight)
+		FROM measurements
+		WHERE timestamp >= $2 AND timestamp < $3
+		GROUP BY data_source_id, name, COALESCE(NULLIF(metadata, 'null'::jsonb), '{}'::jsonb)
+		ON CONFLICT (data_source_id, name, day, metadata) DO UPDATE SET
+			value_sum = measurement_rollups.value_sum + EXCLUDED.value_sum,
+			value_count = measurement_rollups.value_count + EXCLUDED.value_count,
+			value_min = LEAST(measurement_rollups.value_min, EXCLUDED.value_min),
+			value_max = GREATEST(measurement_rollups.value_max, EXCLUDED.value_max),
+			value_sum_sq = COALESCE(measurement_rollups.value_sum_sq, measurement_rollups.value_sum * measurement_rollups.value_sum / measurement_rollups.value_count)
+				+ EXCLUDED.value_sum_sq`,
+		start, start, end,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM measurements WHERE timestamp >= $1 AND timestamp < $2`,
+		start, end,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DeleteRollupsBefore deletes rollups for days before cutoff.
+func (r *Repository) DeleteRollupsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM measurement_rollups WHERE day < $1::date`,
+		cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -8,10 +8,38 @@ import (
 	"github.com/devbydaniel/litekpi/internal/datasource"
 )
 
-// RegisterRoutes registers the ingest routes on the given router.
-func (h *Handler) RegisterRoutes(r chi.Router, dsRepo *datasource.Repository) {
+// RegisterRoutes registers the ingest routes on the given router. Requests
+// are limited by limit once their API key is authenticated.
+func (h *Handler) RegisterRoutes(r chi.Router, dsService *datasource.Service, limit func(next http.Handler) http.Handler) {
 	r.Route("/ingest", func(r chi.Router) {
-		r.Use(APIKeyMiddleware(dsRepo))
+		r.Use(APIKeyMiddleware(dsService))
+		r.Use(limit)
+		r.Post("/", h.IngestSingle)
+		r.Post("/batch", h.IngestBatch)
+		r.Put("/events/{eventId}", h.CorrectEvent)
+		r.Delete("/events/{eventId}", h.DeleteEvent)
+		r.Get("/streams/{streamId}", h.GetStream)
+	})
+}
+
+// RegisterAutomationRoutes registers the routes for no-code automation
+// platforms, which authenticate with a data source's API key. Requests are
+// limited by limit once their API key is authenticated.
+func (h *Handler) RegisterAutomationRoutes(r chi.Router, dsService *datasource.Service, limit func(next http.Handler) http.Handler) {
+	r.Route("/automations", func(r chi.Router) {
+		r.Use(APIKeyMiddleware(dsService))
+		r.Use(limit)
+		r.Get("/me", h.GetAutomationAccount)
+		r.Get("/triggers/new-measurement-names", h.NewMeasurementNamesTrigger)
+		r.Post("/actions/record-measurement", h.RecordMeasurementAction)
+	})
+}
+
+// RegisterBrowserRoutes registers the ingest routes for browsers, which
+// authenticate with a data source's public key instead of its API key.
+func (h *Handler) RegisterBrowserRoutes(r chi.Router, dsService *datasource.Service) {
+	r.Route("/ingest/browser", func(r chi.Router) {
+		r.Use(PublicKeyMiddleware(dsService))
 		r.Post("/", h.IngestSingle)
 		r.Post("/batch", h.IngestBatch)
 	})
@@ -25,5 +53,47 @@ func (h *Handler) RegisterMeasurementRoutes(r chi.Router, authMiddleware func(ne
 		r.Get("/{name}/metadata", h.GetMetadataValues)
 		r.Get("/{name}/data", h.GetMeasurementData)
 		r.Get("/{name}/data/split", h.GetMeasurementDataSplit)
+		r.Put("/{name}/type", h.UpdateMeasurementType)
+		r.Delete("/{name}/type", h.DeleteMeasurementType)
+		r.Put("/{name}/archive", h.ArchiveMeasurement)
+		r.Delete("/{name}/archive", h.UnarchiveMeasurement)
+	})
+}
+
+// RegisterTransformRoutes registers the ingest transformation rule routes on the given router.
+func (h *Handler) RegisterTransformRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/data-sources/{dataSourceId}/transforms", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.GetTransformRules)
+		r.Put("/", h.UpdateTransformRules)
+	})
+}
+
+// RegisterSamplingRoutes registers the measurement sampling routes on the given router.
+func (h *Handler) RegisterSamplingRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/data-sources/{dataSourceId}/sampling", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.ListSamplingConfigs)
+		r.Put("/{name}", h.UpdateSamplingConfig)
+		r.Delete("/{name}", h.DeleteSamplingConfig)
+	})
+}
+
+// RegisterFreshnessRoutes registers the measurement freshness SLA routes on the given router.
+func (h *Handler) RegisterFreshnessRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/data-sources/{dataSourceId}/freshness", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.ListFreshnessSLAs)
+		r.Put("/{name}", h.UpdateFreshnessSLA)
+		r.Delete("/{name}", h.DeleteFreshnessSLA)
+	})
+}
+
+// RegisterDuplicateRoutes registers the duplicate measurement routes on the given router.
+func (h *Handler) RegisterDuplicateRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/data-sources/{dataSourceId}/duplicates", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.FindDuplicates)
+		r.Post("/dedupe", h.Dedupe)
 	})
 }
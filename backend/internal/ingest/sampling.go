@@ -0,0 +1,19 @@
+package ingest
+
+import (
+	"math/rand"
+)
+
+// sampleWeight decides whether to store a measurement under the data
+// source's sampling rates. It returns the weight to store the measurement
+// with, or 0 when it is discarded.
+func sampleWeight(rates map[string]int, name string) int {
+	rate := rates[name]
+	if rate <= 1 {
+		return 1
+	}
+	if rand.Intn(rate) != 0 {
+		return 0
+	}
+	return rate
+}
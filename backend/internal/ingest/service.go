@@ -2,26 +2,46 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // Service handles measurement ingestion business logic.
 type Service struct {
-	repo *Repository
+	repo          *Repository
+	usageService  *usage.Service
+	audit         *writeaudit.Service
+	maxFutureSkew time.Duration // 0 accepts any future timestamp
+	maxPastAge    time.Duration // 0 accepts any past timestamp
 }
 
 // NewService creates a new ingest service.
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *Repository, usageService *usage.Service, audit *writeaudit.Service, cfg *config.Config) *Service {
+	return &Service{
+		repo:          repo,
+		usageService:  usageService,
+		audit:         audit,
+		maxFutureSkew: cfg.IngestMaxFutureSkew,
+		maxPastAge:    cfg.IngestMaxPastAge,
+	}
 }
 
 // IngestSingle validates and ingests a single measurement.
-func (s *Service) IngestSingle(ctx context.Context, dataSourceID uuid.UUID, req IngestRequest) (*IngestResponse, error) {
+func (s *Service) IngestSingle(ctx context.Context, orgID, dataSourceID uuid.UUID, req IngestRequest) (*IngestResponse, error) {
+	receivedAt := time.Now().UTC()
+
 	// Validate metric name
 	if err := validateMetricName(req.Name); err != nil {
 		return nil, err
@@ -33,33 +53,112 @@ func (s *Service) IngestSingle(ctx context.Context, dataSourceID uuid.UUID, req
 	}
 
 	// Parse or default timestamp
-	timestamp, err := parseTimestamp(req.Timestamp)
+	timestamp, warnings, err := s.parseTimestamp(req.Timestamp)
 	if err != nil {
 		return nil, err
 	}
 
 	// Validate metadata
-	if err := validateMetadata(req.Metadata); err != nil {
+	metadataWarnings, err := validateMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, metadataWarnings...)
+
+	// Validate event ID
+	if err := validateEventID(req.EventID); err != nil {
 		return nil, err
 	}
 
-	// Create measurement
-	measurement, err := s.repo.CreateMeasurement(ctx, dataSourceID, req.Name, req.Value, timestamp, req.Metadata)
+	// Events without a value only make sense for count-oriented measurements
+	if req.event {
+		types, err := s.repo.GetSemanticTypes(ctx, dataSourceID)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateEvent(types, req.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := s.repo.GetTransformRules(ctx, dataSourceID)
 	if err != nil {
 		return nil, err
 	}
+	measurements, transformations := applyTransforms(rules, req)
 
-	return &IngestResponse{
-		ID:        measurement.ID,
-		Name:      measurement.Name,
-		Value:     measurement.Value,
-		Timestamp: measurement.Timestamp,
-		Metadata:  measurement.Metadata,
-	}, nil
+	// Apply sampling to the measurement and any derived ones
+	rates, err := s.repo.GetSamplingRates(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	var stored []IngestRequest
+	var weights []int
+	primaryStored := false
+	for i, m := range measurements {
+		weight := sampleWeight(rates, m.Name)
+		if weight == 0 {
+			continue
+		}
+		if i == 0 {
+			primaryStored = true
+		}
+		stored = append(stored, m)
+		weights = append(weights, weight)
+	}
+
+	primary := measurements[0]
+	response := &IngestResponse{
+		Name:            primary.Name,
+		Value:           primary.Value,
+		Timestamp:       timestamp,
+		Metadata:        primary.Metadata,
+		SampledOut:      !primaryStored,
+		ReceivedAt:      receivedAt,
+		Transformations: transformations,
+		Warnings:        warnings,
+	}
+	if len(stored) == 0 {
+		return response, nil
+	}
+
+	if err := s.usageService.CheckEventQuota(ctx, orgID, len(stored)); err != nil {
+		return nil, err
+	}
+
+	relaxed, err := s.repo.GetRelaxedUniqueness(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create measurement, together with any derived ones
+	timestamps := make([]time.Time, len(stored))
+	dedupeKeys := make([]*string, len(stored))
+	for i := range stored {
+		timestamps[i] = timestamp
+		dedupeKeys[i] = dedupeKey(relaxed, stored[i].EventID)
+	}
+	results, err := s.repo.CreateMeasurementsBatch(ctx, dataSourceID, nil, stored, timestamps, weights, dedupeKeys)
+	if err != nil {
+		return nil, err
+	}
+	if primaryStored {
+		response.ID = results[0].ID
+		response.Duplicate = results[0].Retry
+	}
+
+	// Metering and the watermark are best effort; the measurements are
+	// already stored
+	_ = s.usageService.RecordEvents(ctx, orgID, storedCount(results))
+	_ = s.recordArrival(ctx, dataSourceID, timestamps, results)
+
+	return response, nil
 }
 
 // IngestBatch validates and ingests multiple measurements atomically.
-func (s *Service) IngestBatch(ctx context.Context, dataSourceID uuid.UUID, req BatchIngestRequest) (*BatchIngestResponse, error) {
+func (s *Service) IngestBatch(ctx context.Context, orgID, dataSourceID uuid.UUID, req BatchIngestRequest) (*BatchIngestResponse, error) {
+	response := &BatchIngestResponse{ReceivedAt: time.Now().UTC()}
+
 	// Validate batch size
 	if len(req.Metrics) == 0 {
 		return nil, &validationError{
@@ -74,9 +173,35 @@ func (s *Service) IngestBatch(ctx context.Context, dataSourceID uuid.UUID, req B
 		}
 	}
 
+	// Acknowledge batches of a stream that were already stored without
+	// storing them again; a client may resend them after a crash
+	stream, err := streamPosition(req)
+	if err != nil {
+		return nil, err
+	}
+	if stream != nil {
+		acceptedThrough, err := s.repo.GetStreamAcceptedThrough(ctx, dataSourceID, stream.StreamID)
+		if err != nil {
+			return nil, err
+		}
+		if acceptedThrough > 0 && stream.Sequence <= acceptedThrough {
+			return replayedResponse(response, acceptedThrough), nil
+		}
+		if acceptedThrough > 0 && stream.Sequence > acceptedThrough+1 {
+			return nil, &SequenceGapError{Sequence: stream.Sequence, AcceptedThrough: acceptedThrough}
+		}
+	}
+
+	// Relaxed data sources only reject measurements sent with the same event ID
+	relaxed, err := s.repo.GetRelaxedUniqueness(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse timestamps and check for internal duplicates
 	timestamps := make([]time.Time, len(req.Metrics))
-	seen := make(map[string]int) // key: "name|timestamp" -> index
+	seen := make(map[string]int)       // key: "name|timestamp" -> index
+	seenEvents := make(map[string]int) // event ID -> index
 
 	for i, m := range req.Metrics {
 		// Validate metric name
@@ -96,7 +221,7 @@ func (s *Service) IngestBatch(ctx context.Context, dataSourceID uuid.UUID, req B
 		}
 
 		// Parse timestamp
-		ts, err := parseTimestamp(m.Timestamp)
+		ts, warnings, err := s.parseTimestamp(m.Timestamp)
 		if err != nil {
 			return nil, &validationError{
 				errorType: "validation_failed",
@@ -106,14 +231,40 @@ func (s *Service) IngestBatch(ctx context.Context, dataSourceID uuid.UUID, req B
 		timestamps[i] = ts
 
 		// Validate metadata
-		if err := validateMetadata(m.Metadata); err != nil {
+		metadataWarnings, err := validateMetadata(m.Metadata)
+		if err != nil {
 			return nil, &validationError{
 				errorType: "validation_failed",
 				message:   fmt.Sprintf("Measurement at index %d: %s", i, err.Error()),
 			}
 		}
+		for _, w := range append(warnings, metadataWarnings...) {
+			response.Warnings = append(response.Warnings, IngestNote{Index: i, Message: w})
+		}
+
+		// Validate event ID
+		if err := validateEventID(m.EventID); err != nil {
+			return nil, &validationError{
+				errorType: "validation_failed",
+				message:   fmt.Sprintf("Measurement at index %d: %s", i, err.Error()),
+			}
+		}
+
+		// Check for event IDs sent twice
+		if m.EventID != "" {
+			if prevIdx, exists := seenEvents[m.EventID]; exists {
+				return nil, &validationError{
+					errorType: "validation_failed",
+					message:   fmt.Sprintf("Batch contains duplicate event IDs at indices %d and %d", prevIdx, i),
+				}
+			}
+			seenEvents[m.EventID] = i
+		}
 
 		// Check for internal duplicates
+		if relaxed {
+			continue
+		}
 		key := fmt.Sprintf("%s|%s", m.Name, ts.Format(time.RFC3339Nano))
 		if prevIdx, exists := seen[key]; exists {
 			return nil, &validationError{
@@ -124,17 +275,251 @@ func (s *Service) IngestBatch(ctx context.Context, dataSourceID uuid.UUID, req B
 		seen[key] = i
 	}
 
+	// Events without a value only make sense for count-oriented measurements
+	var types map[string]SemanticType
+	for i, m := range req.Metrics {
+		if !m.event {
+			continue
+		}
+		if types == nil {
+			var err error
+			if types, err = s.repo.GetSemanticTypes(ctx, dataSourceID); err != nil {
+				return nil, err
+			}
+		}
+		if err := validateEvent(types, m.Name); err != nil {
+			return nil, &validationError{
+				errorType: "validation_failed",
+				message:   fmt.Sprintf("Measurement at index %d: %s", i, err.Error()),
+			}
+		}
+	}
+
+	// Apply the data source's transformation rules and sampling
+	rules, err := s.repo.GetTransformRules(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := s.repo.GetSamplingRates(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	measurements := make([]IngestRequest, 0, len(req.Metrics))
+	measurementTimestamps := make([]time.Time, 0, len(req.Metrics))
+	weights := make([]int, 0, len(req.Metrics))
+	dedupeKeys := make([]*string, 0, len(req.Metrics))
+	sampledOut := 0
+	for i, m := range req.Metrics {
+		transformedMeasurements, transformations := applyTransforms(rules, m)
+		for _, t := range transformations {
+			response.Transformations = append(response.Transformations, IngestNote{Index: i, Message: t})
+		}
+		for _, transformed := range transformedMeasurements {
+			weight := sampleWeight(rates, transformed.Name)
+			if weight == 0 {
+				sampledOut++
+				continue
+			}
+			measurements = append(measurements, transformed)
+			measurementTimestamps = append(measurementTimestamps, timestamps[i])
+			weights = append(weights, weight)
+			dedupeKeys = append(dedupeKeys, dedupeKey(relaxed, transformed.EventID))
+		}
+	}
+
+	response.SampledOut = sampledOut
+	// A batch of a stream is stored even if sampled out entirely, to
+	// advance the stream
+	if len(measurements) == 0 && stream == nil {
+		return response, nil
+	}
+
+	if len(measurements) > 0 {
+		if err := s.usageService.CheckEventQuota(ctx, orgID, len(measurements)); err != nil {
+			return nil, err
+		}
+	}
+
 	// Insert all measurements
-	count, err := s.repo.CreateMeasurementsBatch(ctx, dataSourceID, req.Metrics, timestamps)
+	results, err := s.repo.CreateMeasurementsBatch(ctx, dataSourceID, stream, measurements, measurementTimestamps, weights, dedupeKeys)
+	if errors.Is(err, ErrBatchReplayed) {
+		// A concurrent request stored the same batch first
+		acceptedThrough, err := s.repo.GetStreamAcceptedThrough(ctx, dataSourceID, stream.StreamID)
+		if err != nil {
+			return nil, err
+		}
+		return replayedResponse(response, acceptedThrough), nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	response.Count = storedCount(results)
+	response.Duplicates = len(results) - response.Count
+	if stream != nil {
+		response.AcceptedThrough = &stream.Sequence
+	}
 
-	return &BatchIngestResponse{
-		Count: count,
+	// Metering and the watermark are best effort; the measurements are
+	// already stored
+	_ = s.usageService.RecordEvents(ctx, orgID, response.Count)
+	_ = s.recordArrival(ctx, dataSourceID, measurementTimestamps, results)
+
+	return response, nil
+}
+
+// CorrectEvent replaces the measurement stored under an event ID, and any
+// measurements derived from it, with a corrected one. The correction goes
+// through the data source's transformation rules but not through sampling:
+// it keeps the weight of the measurement it replaces.
+func (s *Service) CorrectEvent(ctx context.Context, dataSourceID uuid.UUID, eventID string, req IngestRequest) (*IngestResponse, error) {
+	receivedAt := time.Now().UTC()
+	req.EventID = eventID
+	if err := validateEventID(eventID); err != nil {
+		return nil, err
+	}
+	if err := validateMetricName(req.Name); err != nil {
+		return nil, err
+	}
+	if err := validateValue(req.Value); err != nil {
+		return nil, err
+	}
+	timestamp, warnings, err := s.parseTimestamp(req.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	metadataWarnings, err := validateMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, metadataWarnings...)
+	if req.event {
+		types, err := s.repo.GetSemanticTypes(ctx, dataSourceID)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateEvent(types, req.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := s.repo.GetTransformRules(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	measurements, transformations := applyTransforms(rules, req)
+
+	relaxed, err := s.repo.GetRelaxedUniqueness(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	dedupeKeys := make([]*string, len(measurements))
+	for i, m := range measurements {
+		dedupeKeys[i] = dedupeKey(relaxed, m.EventID)
+	}
+
+	ids, err := s.repo.ReplaceEvent(ctx, dataSourceID, eventID, measurements, timestamp, dedupeKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := measurements[0]
+	return &IngestResponse{
+		ID:              ids[0],
+		Name:            primary.Name,
+		Value:           primary.Value,
+		Timestamp:       timestamp,
+		Metadata:        primary.Metadata,
+		ReceivedAt:      receivedAt,
+		Transformations: transformations,
+		Warnings:        warnings,
 	}, nil
 }
 
+// DeleteEvent deletes the measurement stored under an event ID, along with
+// any measurements derived from it. The event ID can then be used again.
+func (s *Service) DeleteEvent(ctx context.Context, dataSourceID uuid.UUID, eventID string) error {
+	return s.repo.DeleteEvent(ctx, dataSourceID, eventID)
+}
+
+// GetStream returns the acknowledgment state of a client's batch stream. A
+// client resuming after a crash drops the buffered batches up to its
+// AcceptedThrough and resends the rest.
+func (s *Service) GetStream(ctx context.Context, dataSourceID uuid.UUID, streamID string) (*StreamResponse, error) {
+	if err := validateStreamID(streamID); err != nil {
+		return nil, err
+	}
+	acceptedThrough, err := s.repo.GetStreamAcceptedThrough(ctx, dataSourceID, streamID)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamResponse{StreamID: streamID, AcceptedThrough: acceptedThrough}, nil
+}
+
+// storedCount returns the number of measurements that were stored rather
+// than skipped as retries.
+// recordArrival advances the data source's ingest watermark over the
+// timestamps of the measurements stored, skipping retries.
+func (s *Service) recordArrival(ctx context.Context, dataSourceID uuid.UUID, timestamps []time.Time, results []StoredMeasurement) error {
+	var oldest, newest time.Time
+	for i, r := range results {
+		if r.Retry {
+			continue
+		}
+		if oldest.IsZero() || timestamps[i].Before(oldest) {
+			oldest = timestamps[i]
+		}
+		if timestamps[i].After(newest) {
+			newest = timestamps[i]
+		}
+	}
+	if oldest.IsZero() {
+		return nil
+	}
+	return s.repo.RecordArrival(ctx, dataSourceID, oldest, newest)
+}
+
+func storedCount(results []StoredMeasurement) int {
+	count := 0
+	for _, r := range results {
+		if !r.Retry {
+			count++
+		}
+	}
+	return count
+}
+
+// GetTransformRules retrieves the transformation rules for a data source.
+func (s *Service) GetTransformRules(ctx context.Context, dataSourceID uuid.UUID) ([]TransformRule, error) {
+	rules, err := s.repo.GetTransformRules(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []TransformRule{}
+	}
+	return rules, nil
+}
+
+// UpdateTransformRules validates and replaces the transformation rules for a data source.
+func (s *Service) UpdateTransformRules(ctx context.Context, dataSourceID uuid.UUID, req UpdateTransformRulesRequest) ([]TransformRule, error) {
+	rules := req.Rules
+	if rules == nil {
+		rules = []TransformRule{}
+	}
+	if err := validateTransformRules(rules); err != nil {
+		return nil, err
+	}
+	before, err := s.GetTransformRules(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTransformRules(ctx, dataSourceID, rules); err != nil {
+		return nil, err
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "transformRules", before, rules)
+	return rules, nil
+}
+
 // validationError is a custom error type for validation failures.
 type validationError struct {
 	errorType string
@@ -174,6 +559,18 @@ func validateMetricName(name string) error {
 	return nil
 }
 
+// validateEvent rejects a measurement sent without a value if its name is
+// declared with a semantic type other than count.
+func validateEvent(types map[string]SemanticType, name string) error {
+	if t, ok := types[name]; ok && t != SemanticTypeCount {
+		return &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Measurement '%s' is declared as %s and requires a value", name, t),
+		}
+	}
+	return nil
+}
+
 // validateValue validates the metric value.
 func validateValue(value float64) error {
 	if math.IsNaN(value) || math.IsInf(value, 0) {
@@ -185,72 +582,232 @@ func validateValue(value float64) error {
 	return nil
 }
 
-// parseTimestamp parses an ISO 8601 timestamp or returns current time if empty.
-func parseTimestamp(ts string) (time.Time, error) {
+// parseTimestamp parses an RFC 3339 timestamp with a timezone offset, or a
+// number of Unix epoch milliseconds, or returns the current time if empty.
+// Timestamps are normalized to UTC and truncated to microseconds, the
+// precision they are stored with, and must lie within the configured skew.
+// A warning is returned if the truncation lost precision.
+func (s *Service) parseTimestamp(ts string) (time.Time, []string, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
 	if ts == "" {
-		return time.Now().UTC(), nil
+		return now, nil, nil
 	}
 
-	parsed, err := time.Parse(time.RFC3339, ts)
-	if err != nil {
-		return time.Time{}, &validationError{
+	var parsed time.Time
+	if millis, err := strconv.ParseInt(ts, 10, 64); err == nil {
+		parsed = time.UnixMilli(millis)
+	} else if parsed, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+		return time.Time{}, nil, &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Invalid timestamp '%s': must be RFC 3339 with a timezone offset (e.g., 2024-01-15T10:30:00.123Z or 2024-01-15T11:30:00+01:00) or Unix epoch milliseconds", ts),
+		}
+	}
+
+	var warnings []string
+	if truncated := parsed.Truncate(time.Microsecond); !truncated.Equal(parsed) {
+		warnings = append(warnings, fmt.Sprintf("Timestamp '%s' truncated to microsecond precision", ts))
+		parsed = truncated
+	}
+	parsed = parsed.UTC()
+
+	if s.maxFutureSkew > 0 && parsed.After(now.Add(s.maxFutureSkew)) {
+		return time.Time{}, nil, &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Invalid timestamp '%s': more than %s in the future", ts, s.maxFutureSkew),
+		}
+	}
+	if s.maxPastAge > 0 && parsed.Before(now.Add(-s.maxPastAge)) {
+		return time.Time{}, nil, &validationError{
 			errorType: "validation_failed",
-			message:   "Invalid timestamp format: must be ISO 8601 (e.g., 2024-01-15T10:30:00Z)",
+			message:   fmt.Sprintf("Invalid timestamp '%s': more than %s in the past", ts, s.maxPastAge),
 		}
 	}
-	return parsed, nil
+	return parsed, warnings, nil
 }
 
-// validateMetadata validates the metadata map.
-func validateMetadata(metadata map[string]string) error {
+// validateMetadata validates the metadata map. Values longer than
+// MaxMetadataValueLength are truncated in place rather than rejected, with
+// a warning for each.
+func validateMetadata(metadata map[string]string) ([]string, error) {
 	if metadata == nil {
-		return nil
+		return nil, nil
 	}
 
 	if len(metadata) > MaxMetadataKeys {
-		return &validationError{
+		return nil, &validationError{
 			errorType: "validation_failed",
 			message:   fmt.Sprintf("Metadata exceeds maximum of %d keys", MaxMetadataKeys),
 		}
 	}
 
+	var warnings []string
 	for key, value := range metadata {
 		if key == "" {
-			return &validationError{
+			return nil, &validationError{
 				errorType: "validation_failed",
 				message:   "Metadata key cannot be empty",
 			}
 		}
 		if len(key) > MaxMetadataKeyLength {
-			return &validationError{
+			return nil, &validationError{
 				errorType: "validation_failed",
 				message:   fmt.Sprintf("Metadata key '%s' exceeds maximum length of %d characters", key, MaxMetadataKeyLength),
 			}
 		}
 		if len(value) > MaxMetadataValueLength {
-			return &validationError{
-				errorType: "validation_failed",
-				message:   fmt.Sprintf("Metadata value for key '%s' exceeds maximum length of %d characters", key, MaxMetadataValueLength),
-			}
+			metadata[key] = truncateUTF8(value, MaxMetadataValueLength)
+			warnings = append(warnings, fmt.Sprintf("Metadata value for key '%s' truncated to %d characters", key, MaxMetadataValueLength))
+		}
+	}
+	sort.Strings(warnings)
+
+	return warnings, nil
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// validateEventID validates the optional client-supplied event ID.
+func validateEventID(eventID string) error {
+	if len(eventID) > MaxEventIDLength {
+		return &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Event ID exceeds maximum length of %d characters", MaxEventIDLength),
+		}
+	}
+	return nil
+}
+
+// streamPosition validates the optional stream of a batch and returns its
+// position, or nil if the batch is not part of a stream.
+func streamPosition(req BatchIngestRequest) (*StreamPosition, error) {
+	if req.StreamID == "" && req.Sequence == 0 {
+		return nil, nil
+	}
+	if err := validateStreamID(req.StreamID); err != nil {
+		return nil, err
+	}
+	if req.Sequence < 1 {
+		return nil, &validationError{
+			errorType: "validation_failed",
+			message:   "Sequence must be at least 1 for a batch with a stream ID",
 		}
 	}
+	return &StreamPosition{StreamID: req.StreamID, Sequence: req.Sequence}, nil
+}
 
+// validateStreamID validates the ID of a client's batch stream.
+func validateStreamID(streamID string) error {
+	if streamID == "" {
+		return &validationError{
+			errorType: "validation_failed",
+			message:   "Stream ID is required for a batch with a sequence",
+		}
+	}
+	if len(streamID) > MaxStreamIDLength {
+		return &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Stream ID exceeds maximum length of %d characters", MaxStreamIDLength),
+		}
+	}
 	return nil
 }
 
-// GetMeasurementNames retrieves distinct measurement names with their metadata keys for a data source.
+// replayedResponse acknowledges a batch whose sequence was already accepted.
+func replayedResponse(response *BatchIngestResponse, acceptedThrough int64) *BatchIngestResponse {
+	return &BatchIngestResponse{
+		ReceivedAt:      response.ReceivedAt,
+		AcceptedThrough: &acceptedThrough,
+		Replayed:        true,
+	}
+}
+
+// dedupeKey returns the key that must be unique together with a
+// measurement's name and timestamp. Strict data sources use the same key for
+// every measurement, so name and timestamp alone are unique. Relaxed ones use
+// the event ID, and nil when there is none, which never conflicts.
+func dedupeKey(relaxed bool, eventID string) *string {
+	if !relaxed {
+		key := ""
+		return &key
+	}
+	if eventID == "" {
+		return nil
+	}
+	return &eventID
+}
+
+// GetMeasurementNames retrieves all measurement names of a data source.
 func (s *Service) GetMeasurementNames(ctx context.Context, dataSourceID uuid.UUID) ([]MeasurementSummary, error) {
-	return s.repo.GetMeasurementNames(ctx, dataSourceID)
+	return s.repo.GetMeasurementNames(ctx, dataSourceID, MeasurementNameQuery{})
 }
 
-// GetMetadataValues retrieves all unique metadata key-value combinations for a specific measurement.
-func (s *Service) GetMetadataValues(ctx context.Context, dataSourceID uuid.UUID, measurementName string) ([]MetadataValues, error) {
-	return s.repo.GetMetadataValues(ctx, dataSourceID, measurementName)
+// GetNewMeasurementNames retrieves the measurement names of a data source
+// most recently seen for the first time, newest first.
+func (s *Service) GetNewMeasurementNames(ctx context.Context, dataSourceID uuid.UUID) ([]NewMeasurementName, error) {
+	names, err := s.repo.GetNewestMeasurementNames(ctx, dataSourceID, automationTriggerLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get new measurement names: %w", err)
+	}
+	return names, nil
+}
+
+// ListMeasurementNames retrieves a page of the measurement names of a data
+// source matching q. The returned cursor is the last name of the page, or nil
+// when there are no more names.
+func (s *Service) ListMeasurementNames(ctx context.Context, dataSourceID uuid.UUID, q MeasurementNameQuery) ([]MeasurementSummary, *string, error) {
+	if q.Limit <= 0 {
+		names, err := s.repo.GetMeasurementNames(ctx, dataSourceID, q)
+		return names, nil, err
+	}
+
+	// Fetch one extra name to learn whether another page follows
+	limit := q.Limit
+	q.Limit++
+	names, err := s.repo.GetMeasurementNames(ctx, dataSourceID, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(names) <= limit {
+		return names, nil, nil
+	}
+	names = names[:limit]
+	return names, &names[limit-1].Name, nil
+}
+
+// GetMetadataValues retrieves the unique values of each metadata key of a
+// measurement matching q. Keys with more values than q.Limit carry the cursor
+// for their next page.
+func (s *Service) GetMetadataValues(ctx context.Context, dataSourceID uuid.UUID, measurementName string, q MetadataValuesQuery) ([]MetadataValues, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultMetadataValuesLimit
+	}
+
+	// Fetch one extra value per key to learn whether another page follows
+	limit := q.Limit
+	q.Limit++
+	metadata, err := s.repo.GetMetadataValues(ctx, dataSourceID, measurementName, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range metadata {
+		if values := metadata[i].Values; len(values) > limit {
+			metadata[i].Values = values[:limit]
+			metadata[i].NextCursor = &values[limit-1]
+		}
+	}
+	return metadata, nil
 }
 
 // GetAggregatedMeasurements retrieves daily aggregated values with optional metadata filtering.
-func (s *Service) GetAggregatedMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string) ([]AggregatedDataPoint, error) {
-	return s.repo.GetAggregatedMeasurements(ctx, dataSourceID, name, startDate, endDate, metadataFilters)
+// With spread set, each day also carries its minimum, maximum and standard deviation.
+func (s *Service) GetAggregatedMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, spread bool) ([]AggregatedDataPoint, error) {
+	return s.repo.GetAggregatedMeasurements(ctx, dataSourceID, name, startDate, endDate, metadataFilters, spread)
 }
 
 // GetRawMeasurements retrieves raw measurement data points with optional metadata filtering.
@@ -360,3 +917,228 @@ func sortSeriesByTotal(series []SplitSeries) []SplitSeries {
 	}
 	return result
 }
+
+// ListSamplingConfigs retrieves the sampling configurations for a data source.
+func (s *Service) ListSamplingConfigs(ctx context.Context, dataSourceID uuid.UUID) ([]SamplingConfig, error) {
+	configs, err := s.repo.ListSamplingConfigs(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	if configs == nil {
+		configs = []SamplingConfig{}
+	}
+	return configs, nil
+}
+
+// UpdateSamplingConfig sets the sampling rate for a measurement. A rate of 1 stores every measurement.
+func (s *Service) UpdateSamplingConfig(ctx context.Context, dataSourceID uuid.UUID, name string, req UpdateSamplingRequest) (*SamplingConfig, error) {
+	if err := validateMetricName(name); err != nil {
+		return nil, err
+	}
+	if req.Rate < 1 || req.Rate > MaxSamplingRate {
+		return nil, &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Sampling rate must be between 1 and %d", MaxSamplingRate),
+		}
+	}
+	c, err := s.repo.UpsertSamplingConfig(ctx, dataSourceID, name, req.Rate)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "sampling", nil, c)
+	return c, nil
+}
+
+// DeleteSamplingConfig removes the sampling configuration for a measurement.
+func (s *Service) DeleteSamplingConfig(ctx context.Context, dataSourceID uuid.UUID, name string) error {
+	deleted, err := s.repo.DeleteSamplingConfig(ctx, dataSourceID, name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSamplingNotFound
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "sampling", name, nil)
+	return nil
+}
+
+// ListFreshnessSLAs retrieves the freshness SLAs for a data source.
+func (s *Service) ListFreshnessSLAs(ctx context.Context, dataSourceID uuid.UUID) ([]FreshnessSLA, error) {
+	slas, err := s.repo.ListFreshnessSLAs(ctx, dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	if slas == nil {
+		slas = []FreshnessSLA{}
+	}
+	return slas, nil
+}
+
+// UpdateFreshnessSLA sets how often new data of a measurement is expected.
+func (s *Service) UpdateFreshnessSLA(ctx context.Context, dataSourceID uuid.UUID, name string, req UpdateFreshnessSLARequest) (*FreshnessSLA, error) {
+	if err := validateMetricName(name); err != nil {
+		return nil, err
+	}
+	if !req.ExpectedFrequency.IsValid() {
+		return nil, &validationError{
+			errorType: "validation_failed",
+			message:   "Expected frequency must be one of hourly, daily, weekly",
+		}
+	}
+	sla, err := s.repo.UpsertFreshnessSLA(ctx, dataSourceID, name, req.ExpectedFrequency)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "freshnessSla", nil, sla)
+	return sla, nil
+}
+
+// DeleteFreshnessSLA removes the freshness SLA of a measurement.
+func (s *Service) DeleteFreshnessSLA(ctx context.Context, dataSourceID uuid.UUID, name string) error {
+	deleted, err := s.repo.DeleteFreshnessSLA(ctx, dataSourceID, name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFreshnessNotFound
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "freshnessSla", name, nil)
+	return nil
+}
+
+// FindDuplicates reports the probable duplicates of a data source in a time
+// range.
+func (s *Service) FindDuplicates(ctx context.Context, dataSourceID uuid.UUID, q DuplicateQuery) (*DuplicateReport, error) {
+	q, err := validateDuplicateQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.repo.GetDuplicates(ctx, dataSourceID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DuplicateReport{DuplicateQuery: q, Measurements: []DuplicateSummary{}}
+	for _, d := range summaries {
+		report.Duplicates += d.Duplicates
+		report.Measurements = append(report.Measurements, d)
+	}
+	return report, nil
+}
+
+// Dedupe deletes the probable duplicates of a data source in a time range,
+// keeping the earliest measurement of each group.
+func (s *Service) Dedupe(ctx context.Context, dataSourceID uuid.UUID, q DuplicateQuery) (*DedupeResponse, error) {
+	q, err := validateDuplicateQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.repo.DeleteDuplicates(ctx, dataSourceID, q)
+	if err != nil {
+		return nil, err
+	}
+	return &DedupeResponse{Deleted: deleted}, nil
+}
+
+// validateDuplicateQuery checks the range and window of a duplicate scan and
+// applies the default window.
+func validateDuplicateQuery(q DuplicateQuery) (DuplicateQuery, error) {
+	if q.Start.IsZero() || q.End.IsZero() || !q.Start.Before(q.End) {
+		return q, &validationError{
+			errorType: "validation_failed",
+			message:   "Start and end are required, and start must be before end",
+		}
+	}
+	if q.End.Sub(q.Start) > MaxDuplicateScanRange {
+		return q, &validationError{
+			errorType: "validation_failed",
+			message:   "Duplicate scans can cover at most 31 days",
+		}
+	}
+	if q.WindowSeconds == 0 {
+		q.WindowSeconds = DefaultDuplicateWindowSeconds
+	}
+	if q.WindowSeconds < 0 || q.WindowSeconds > MaxDuplicateWindowSeconds {
+		return q, &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Window must be between 1 and %d seconds", MaxDuplicateWindowSeconds),
+		}
+	}
+	return q, nil
+}
+
+// ArchiveMeasurement hides a measurement name from pickers without deleting
+// its data.
+func (s *Service) ArchiveMeasurement(ctx context.Context, dataSourceID uuid.UUID, name string) (*ArchivedMeasurement, error) {
+	if err := validateMetricName(name); err != nil {
+		return nil, err
+	}
+	archived, err := s.repo.ArchiveMeasurement(ctx, dataSourceID, name)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "archivedMeasurement", nil, archived)
+	return archived, nil
+}
+
+// UnarchiveMeasurement shows an archived measurement name in pickers again.
+func (s *Service) UnarchiveMeasurement(ctx context.Context, dataSourceID uuid.UUID, name string) error {
+	unarchived, err := s.repo.UnarchiveMeasurement(ctx, dataSourceID, name)
+	if err != nil {
+		return err
+	}
+	if !unarchived {
+		return ErrNotArchived
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "archivedMeasurement", name, nil)
+	return nil
+}
+
+// UpdateMeasurementType declares the semantic type and unit of a measurement.
+func (s *Service) UpdateMeasurementType(ctx context.Context, dataSourceID uuid.UUID, name string, req UpdateMeasurementTypeRequest) (*MeasurementType, error) {
+	if err := validateMetricName(name); err != nil {
+		return nil, err
+	}
+	if !req.SemanticType.IsValid() {
+		return nil, &validationError{
+			errorType: "validation_failed",
+			message:   "Semantic type must be one of count, currency, duration_ms, percent",
+		}
+	}
+
+	unit := req.Unit
+	if unit != nil {
+		trimmed := strings.TrimSpace(*unit)
+		unit = &trimmed
+		if trimmed == "" {
+			unit = nil
+		} else if len(trimmed) > MaxUnitLength {
+			return nil, &validationError{
+				errorType: "validation_failed",
+				message:   fmt.Sprintf("Unit exceeds maximum length of %d characters", MaxUnitLength),
+			}
+		}
+	}
+
+	t, err := s.repo.UpsertMeasurementType(ctx, dataSourceID, name, req.SemanticType, unit)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "measurementType", nil, t)
+	return t, nil
+}
+
+// DeleteMeasurementType removes the declared type of a measurement.
+func (s *Service) DeleteMeasurementType(ctx context.Context, dataSourceID uuid.UUID, name string) error {
+	deleted, err := s.repo.DeleteMeasurementType(ctx, dataSourceID, name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTypeNotFound
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDataSource, dataSourceID, "measurementType", name, nil)
+	return nil
+}
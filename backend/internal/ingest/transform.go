@@ -0,0 +1,173 @@
+package ingest
+
+import (
+	"fmt"
+	"math"
+)
+
+// applyTransforms runs the rules on a measurement. It returns the transformed
+// measurement first, followed by any derived measurements, and a description
+// of each rule that changed something. Derived measurements are not
+// transformed by later rules.
+func applyTransforms(rules []TransformRule, req IngestRequest) ([]IngestRequest, []string) {
+	if len(rules) == 0 {
+		return []IngestRequest{req}, nil
+	}
+
+	// Copy metadata so the caller's request is left untouched
+	if req.Metadata != nil {
+		metadata := make(map[string]string, len(req.Metadata))
+		for k, v := range req.Metadata {
+			metadata[k] = v
+		}
+		req.Metadata = metadata
+	}
+
+	var derived []IngestRequest
+	var applied []string
+	for _, rule := range rules {
+		if rule.Measurement != "" && rule.Measurement != req.Name {
+			continue
+		}
+
+		switch rule.Type {
+		case TransformRenameMeasurement:
+			applied = append(applied, fmt.Sprintf("renamed measurement '%s' to '%s'", req.Name, rule.To))
+			req.Name = rule.To
+		case TransformDropMetadata:
+			if _, ok := req.Metadata[rule.Key]; !ok {
+				continue
+			}
+			applied = append(applied, fmt.Sprintf("dropped metadata key '%s'", rule.Key))
+			delete(req.Metadata, rule.Key)
+		case TransformMapMetadata:
+			value, ok := req.Metadata[rule.Key]
+			if !ok {
+				continue
+			}
+			if mapped, ok := rule.Values[value]; ok {
+				value = mapped
+			}
+			delete(req.Metadata, rule.Key)
+			key := rule.Key
+			if rule.To != "" {
+				key = rule.To
+			}
+			req.Metadata[key] = value
+			applied = append(applied, fmt.Sprintf("mapped metadata key '%s' to '%s' = '%s'", rule.Key, key, value))
+		case TransformScaleValue:
+			req.Value *= *rule.Factor
+			applied = append(applied, fmt.Sprintf("scaled value by %g", *rule.Factor))
+		case TransformDeriveMeasurement:
+			factor := 1.0
+			if rule.Factor != nil {
+				factor = *rule.Factor
+			}
+			var metadata map[string]string
+			if req.Metadata != nil {
+				metadata = make(map[string]string, len(req.Metadata))
+				for k, v := range req.Metadata {
+					metadata[k] = v
+				}
+			}
+			derived = append(derived, IngestRequest{
+				Name:      rule.To,
+				Value:     req.Value * factor,
+				Timestamp: req.Timestamp,
+				Metadata:  metadata,
+				EventID:   req.EventID, // Corrected and deleted along with the measurement
+			})
+			applied = append(applied, fmt.Sprintf("derived measurement '%s'", rule.To))
+		}
+	}
+
+	return append([]IngestRequest{req}, derived...), applied
+}
+
+// validateTransformRules validates rules before they are stored, so that
+// transformed measurements always pass ingest validation.
+func validateTransformRules(rules []TransformRule) error {
+	if len(rules) > MaxTransformRules {
+		return &validationError{
+			errorType: "validation_failed",
+			message:   fmt.Sprintf("Transformation rules exceed maximum of %d", MaxTransformRules),
+		}
+	}
+
+	for i, rule := range rules {
+		if err := validateTransformRule(rule); err != nil {
+			return &validationError{
+				errorType: "validation_failed",
+				message:   fmt.Sprintf("Rule at index %d: %s", i, err.Error()),
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateTransformRule(rule TransformRule) error {
+	if rule.Measurement != "" {
+		if err := validateMetricName(rule.Measurement); err != nil {
+			return err
+		}
+	}
+
+	switch rule.Type {
+	case TransformRenameMeasurement, TransformDeriveMeasurement:
+		if rule.To == "" {
+			return fmt.Errorf("'to' is required for %s", rule.Type)
+		}
+		if err := validateMetricName(rule.To); err != nil {
+			return err
+		}
+		if rule.Type == TransformDeriveMeasurement && rule.Factor != nil {
+			return validateFactor(*rule.Factor)
+		}
+	case TransformDropMetadata:
+		return validateMetadataKey(rule.Key)
+	case TransformMapMetadata:
+		if err := validateMetadataKey(rule.Key); err != nil {
+			return err
+		}
+		if rule.To != "" {
+			if err := validateMetadataKey(rule.To); err != nil {
+				return err
+			}
+		}
+		if rule.To == "" && len(rule.Values) == 0 {
+			return fmt.Errorf("map_metadata requires 'to' or 'values'")
+		}
+		for _, v := range rule.Values {
+			if len(v) > MaxMetadataValueLength {
+				return fmt.Errorf("mapped metadata value exceeds maximum length of %d characters", MaxMetadataValueLength)
+			}
+		}
+	case TransformScaleValue:
+		if rule.Factor == nil {
+			return fmt.Errorf("'factor' is required for scale_value")
+		}
+		return validateFactor(*rule.Factor)
+	default:
+		return fmt.Errorf("invalid rule type '%s'", rule.Type)
+	}
+
+	return nil
+}
+
+func validateMetadataKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("'key' is required")
+	}
+	if len(key) > MaxMetadataKeyLength {
+		return fmt.Errorf("metadata key '%s' exceeds maximum length of %d characters", key, MaxMetadataKeyLength)
+	}
+	return nil
+}
+
+func validateFactor(factor float64) error {
+	if factor == 0 || math.IsNaN(factor) || math.IsInf(factor, 0) {
+		return fmt.Errorf("'factor' must be a non-zero number")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package integrations
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Error definitions
+var (
+	ErrSlackNotConnected = errors.New("no Slack workspace is connected")
+	ErrSlackTokenEmpty   = errors.New("bot token is required")
+	ErrSlackTokenInvalid = errors.New("bot token was rejected by Slack")
+	ErrSlackTeamTaken    = errors.New("the Slack workspace is connected to another organization")
+)
+
+const (
+	maxUnfurlMetrics    = 5                       // Scalar metrics summarized when unfurling a dashboard link
+	unfurlTimeout       = 30 * time.Second        // Unfurls are posted after the event is acknowledged
+	slackCommandTimeout = 2500 * time.Millisecond // Slack gives up on commands after three seconds
+	maxSlackBodyBytes   = 1 << 20
+)
+
+// SlackConnection links a Slack workspace to an organization. Commands and
+// links from the workspace are answered with the organization's data.
+type SlackConnection struct {
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	TeamID         string     `json:"teamId"`
+	TeamName       string     `json:"teamName"`
+	BotToken       string     `json:"-"`
+	ConnectedBy    *uuid.UUID `json:"connectedBy,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// Request/Response types
+
+// ConnectSlackRequest is the request body for connecting a Slack workspace.
+type ConnectSlackRequest struct {
+	BotToken string `json:"botToken"` // Bot token (xoxb-) of the workspace's installation of the Slack app
+}
+
+// SlackCommand is a slash command invocation, as posted by Slack.
+type SlackCommand struct {
+	TeamID  string
+	Command string
+	Text    string
+}
+
+// SlackMessage is the reply to a slash command.
+type SlackMessage struct {
+	ResponseType string `json:"response_type"` // in_channel or ephemeral
+	Text         string `json:"text"`
+}
+
+// SlackEnvelope is an Events API request.
+type SlackEnvelope struct {
+	Type      string     `json:"type"`      // url_verification or event_callback
+	Challenge string     `json:"challenge"` // Echoed back for url_verification
+	TeamID    string     `json:"team_id"`
+	Event     SlackEvent `json:"event"`
+}
+
+// SlackEvent is the event of an event_callback. Only link_shared is handled.
+type SlackEvent struct {
+	Type      string            `json:"type"`
+	Channel   string            `json:"channel"`
+	MessageTS string            `json:"message_ts"`
+	UnfurlID  string            `json:"unfurl_id"` // Set for links typed in the message composer
+	Source    string            `json:"source"`
+	Links     []SlackSharedLink `json:"links"`
+}
+
+// SlackSharedLink is a link in a link_shared event.
+type SlackSharedLink struct {
+	Domain string `json:"domain"`
+	URL    string `json:"url"`
+}
+
+// SlackChallengeResponse is the response to a url_verification request.
+type SlackChallengeResponse struct {
+	Challenge string `json:"challenge"`
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
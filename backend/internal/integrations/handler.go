@@ -0,0 +1,212 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// Handler handles HTTP requests for integrations.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new integrations handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetSlackConnection handles getting the connected Slack workspace.
+//
+//	@Summary		Get Slack connection
+//	@Description	Get the Slack workspace connected to the organization. Requires admin role.
+//	@Tags			integrations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	SlackConnection
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/integrations/slack [get]
+func (h *Handler) GetSlackConnection(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	c, err := h.service.GetSlackConnection(r.Context(), user.OrganizationID)
+	if err != nil {
+		if errors.Is(err, ErrSlackNotConnected) {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("get Slack connection error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get Slack connection")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// ConnectSlack handles connecting a Slack workspace.
+//
+//	@Summary		Connect Slack workspace
+//	@Description	Connect the Slack workspace a bot token of the LiteKPI Slack app belongs to, replacing the organization's previous workspace. Requires admin role.
+//	@Tags			integrations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		ConnectSlackRequest	true	"Bot token"
+//	@Success		200		{object}	SlackConnection
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse	"Workspace connected to another organization"
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/integrations/slack [put]
+func (h *Handler) ConnectSlack(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ConnectSlackRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	c, err := h.service.ConnectSlack(r.Context(), user.OrganizationID, user.ID, req)
+	if err != nil {
+		if errors.Is(err, ErrSlackTokenEmpty) || errors.Is(err, ErrSlackTokenInvalid) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, ErrSlackTeamTaken) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		log.Printf("connect Slack error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to connect Slack")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// DisconnectSlack handles disconnecting the Slack workspace.
+//
+//	@Summary		Disconnect Slack workspace
+//	@Description	Disconnect the organization's Slack workspace. Commands and links from it are no longer answered. Requires admin role.
+//	@Tags			integrations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	MessageResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/integrations/slack [delete]
+func (h *Handler) DisconnectSlack(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.DisconnectSlack(r.Context(), user.OrganizationID); err != nil {
+		if errors.Is(err, ErrSlackNotConnected) {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("disconnect Slack error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to disconnect Slack")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "Slack disconnected"})
+}
+
+// SlackCommand handles the /kpi slash command.
+//
+//	@Summary		Slack slash command
+//	@Description	Called by Slack for the /kpi command. Replies with the current value of the best matching metric. Requests must be signed with the Slack signing secret.
+//	@Tags			integrations
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Success		200	{object}	SlackMessage
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/integrations/slack/commands [post]
+func (h *Handler) SlackCommand(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), slackCommandTimeout)
+	defer cancel()
+
+	msg, err := h.service.HandleCommand(ctx, SlackCommand{
+		TeamID:  r.PostForm.Get("team_id"),
+		Command: r.PostForm.Get("command"),
+		Text:    r.PostForm.Get("text"),
+	})
+	if err != nil {
+		// Slack shows the reply to the user; a non-200 status only shows a generic failure
+		log.Printf("slack command error: %v", err)
+		msg = ephemeral("Sorry, something went wrong looking up that metric.")
+	}
+
+	respondJSON(w, http.StatusOK, msg)
+}
+
+// SlackEvents handles Events API callbacks from Slack.
+//
+//	@Summary		Slack events
+//	@Description	Called by Slack for URL verification and link_shared events. Links to dashboards are unfurled with a summary after the event is acknowledged. Requests must be signed with the Slack signing secret.
+//	@Tags			integrations
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	SlackChallengeResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/integrations/slack/events [post]
+func (h *Handler) SlackEvents(w http.ResponseWriter, r *http.Request) {
+	var env SlackEnvelope
+	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if env.Type == "url_verification" {
+		respondJSON(w, http.StatusOK, SlackChallengeResponse{Challenge: env.Challenge})
+		return
+	}
+
+	// Slack retries events not acknowledged within three seconds
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unfurlTimeout)
+		defer cancel()
+		h.service.HandleEvent(ctx, env)
+	}()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,149 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/internal/platform/secrets"
+)
+
+// Repository handles database operations for integrations.
+// Bot tokens are encrypted with the keyring when stored.
+type Repository struct {
+	pool    *pgxpool.Pool
+	keyring *secrets.Keyring
+}
+
+// NewRepository creates a new integrations repository.
+func NewRepository(pool *pgxpool.Pool, keyring *secrets.Keyring) *Repository {
+	return &Repository{pool: pool, keyring: keyring}
+}
+
+// UpsertSlackConnection connects a Slack workspace to an organization,
+// replacing the organization's previous workspace. It returns
+// ErrSlackTeamTaken if another organization has connected the workspace.
+func (r *Repository) UpsertSlackConnection(ctx context.Context, c *SlackConnection) error {
+	token, err := r.keyring.Seal(c.BotToken)
+	if err != nil {
+		return fmt.Errorf("encrypting bot token: %w", err)
+	}
+
+	err = r.pool.QueryRow(ctx,
+		`INSERT INTO slack_connections (organization_id, team_id, team_name, bot_token, connected_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET team_id = EXCLUDED.team_id,
+		    team_name = EXCLUDED.team_name,
+		    bot_token = EXCLUDED.bot_token,
+		    connected_by = EXCLUDED.connected_by,
+		    created_at = NOW()
+		RETURNING created_at`,
+		c.OrganizationID, c.TeamID, c.TeamName, token, c.ConnectedBy,
+	).Scan(&c.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_slack_connections_team" {
+		return ErrSlackTeamTaken
+	}
+	return err
+}
+
+// GetSlackConnection retrieves the Slack connection of an organization.
+func (r *Repository) GetSlackConnection(ctx context.Context, orgID uuid.UUID) (*SlackConnection, error) {
+	return r.getSlackConnection(ctx, `organization_id = $1`, orgID)
+}
+
+// GetSlackConnectionByTeamID retrieves the Slack connection of a workspace.
+// Workspaces of disabled organizations are treated as not connected.
+func (r *Repository) GetSlackConnectionByTeamID(ctx context.Context, teamID string) (*SlackConnection, error) {
+	return r.getSlackConnection(ctx,
+		`team_id = $1
+		  AND organization_id NOT IN (SELECT id FROM organizations WHERE disabled_at IS NOT NULL)`,
+		teamID,
+	)
+}
+
+func (r *Repository) getSlackConnection(ctx context.Context, where string, arg any) (*SlackConnection, error) {
+	c := &SlackConnection{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT organization_id, team_id, team_name, bot_token, connected_by, created_at
+		FROM slack_connections WHERE `+where,
+		arg,
+	).Scan(&c.OrganizationID, &c.TeamID, &c.TeamName, &c.BotToken, &c.ConnectedBy, &c.CreatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.BotToken, err = r.keyring.Open(c.BotToken); err != nil {
+		return nil, fmt.Errorf("decrypting bot token: %w", err)
+	}
+
+	return c, nil
+}
+
+// DeleteSlackConnection disconnects the Slack workspace of an organization.
+// It reports whether a workspace was connected.
+func (r *Repository) DeleteSlackConnection(ctx context.Context, orgID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM slack_connections WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReencryptSecrets encrypts the bot tokens that are stored in plain text or
+// under an old key with the current key. It returns how many it encrypted.
+func (r *Repository) ReencryptSecrets(ctx context.Context) (int, error) {
+	rows, err := r.pool.Query(ctx, `SELECT organization_id, bot_token FROM slack_connections`)
+	if err != nil {
+		return 0, err
+	}
+	stale := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var orgID uuid.UUID
+		var token string
+		if err := rows.Scan(&orgID, &token); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if r.keyring.Stale(token) {
+			stale[orgID] = token
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for orgID, stored := range stale {
+		token, err := r.keyring.Open(stored)
+		if err != nil {
+			return count, fmt.Errorf("decrypting bot token of organization %s: %w", orgID, err)
+		}
+		sealed, err := r.keyring.Seal(token)
+		if err != nil {
+			return count, fmt.Errorf("encrypting bot token: %w", err)
+		}
+		// Leave tokens alone that were replaced in the meantime
+		tag, err := r.pool.Exec(ctx,
+			`UPDATE slack_connections SET bot_token = $3 WHERE organization_id = $1 AND bot_token = $2`,
+			orgID, stored, sealed,
+		)
+		if err != nil {
+			return count, err
+		}
+		count += int(tag.RowsAffected())
+	}
+	return count, nil
+}
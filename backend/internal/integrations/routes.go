@@ -0,0 +1,34 @@
+package integrations
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the integration routes. Nothing is registered
+// when no Slack signing secret is configured.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler, slackSigningSecret string) {
+	if slackSigningSecret == "" {
+		return
+	}
+
+	r.Route("/integrations/slack", func(r chi.Router) {
+		// Connection management (admin only)
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware)
+
+			r.Get("/", h.GetSlackConnection)
+			r.Put("/", h.ConnectSlack)
+			r.Delete("/", h.DisconnectSlack)
+		})
+
+		// Requests from Slack (signed with the signing secret)
+		r.Group(func(r chi.Router) {
+			r.Use(SlackSignatureMiddleware(slackSigningSecret))
+
+			r.Post("/commands", h.SlackCommand)
+			r.Post("/events", h.SlackEvents)
+		})
+	})
+}
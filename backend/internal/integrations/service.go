@@ -0,0 +1,289 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/search"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// Service handles integrations with chat tools.
+type Service struct {
+	repo             *Repository
+	slack            *slackClient
+	searchService    *search.Service
+	dashboardService *dashboard.Service
+	metricService    *metric.Service
+	audit            *writeaudit.Service
+	appURL           string
+}
+
+// NewService creates a new integrations service.
+func NewService(repo *Repository, searchService *search.Service, dashboardService *dashboard.Service, metricService *metric.Service, audit *writeaudit.Service, appURL string) *Service {
+	return &Service{
+		repo:             repo,
+		slack:            newSlackClient(),
+		searchService:    searchService,
+		dashboardService: dashboardService,
+		metricService:    metricService,
+		audit:            audit,
+		appURL:           strings.TrimSuffix(appURL, "/"),
+	}
+}
+
+// ConnectSlack connects the Slack workspace a bot token belongs to, replacing
+// the organization's previous workspace.
+func (s *Service) ConnectSlack(ctx context.Context, orgID, userID uuid.UUID, req ConnectSlackRequest) (*SlackConnection, error) {
+	token := strings.TrimSpace(req.BotToken)
+	if token == "" {
+		return nil, ErrSlackTokenEmpty
+	}
+
+	teamID, teamName, err := s.slack.authTest(ctx, token)
+	if err != nil {
+		var apiErr *slackAPIError
+		if errors.As(err, &apiErr) {
+			return nil, ErrSlackTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to verify bot token: %w", err)
+	}
+
+	before, err := s.repo.GetSlackConnection(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Slack connection: %w", err)
+	}
+	c := &SlackConnection{
+		OrganizationID: orgID,
+		TeamID:         teamID,
+		TeamName:       teamName,
+		BotToken:       token,
+		ConnectedBy:    &userID,
+	}
+	if err := s.repo.UpsertSlackConnection(ctx, c); err != nil {
+		if errors.Is(err, ErrSlackTeamTaken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save Slack connection: %w", err)
+	}
+	if before == nil {
+		s.audit.Record(ctx, writeaudit.EntitySlack, orgID, writeaudit.ActionCreate, nil, c)
+	} else {
+		s.audit.Record(ctx, writeaudit.EntitySlack, orgID, writeaudit.ActionUpdate, before, c)
+	}
+	return c, nil
+}
+
+// GetSlackConnection returns the Slack workspace connected to an organization.
+func (s *Service) GetSlackConnection(ctx context.Context, orgID uuid.UUID) (*SlackConnection, error) {
+	c, err := s.repo.GetSlackConnection(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Slack connection: %w", err)
+	}
+	if c == nil {
+		return nil, ErrSlackNotConnected
+	}
+	return c, nil
+}
+
+// DisconnectSlack disconnects the Slack workspace of an organization.
+func (s *Service) DisconnectSlack(ctx context.Context, orgID uuid.UUID) error {
+	c, err := s.repo.GetSlackConnection(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get Slack connection: %w", err)
+	}
+	deleted, err := s.repo.DeleteSlackConnection(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete Slack connection: %w", err)
+	}
+	if !deleted {
+		return ErrSlackNotConnected
+	}
+	s.audit.Record(ctx, writeaudit.EntitySlack, orgID, writeaudit.ActionDelete, c, nil)
+	return nil
+}
+
+// HandleCommand answers a /kpi command with the current value of the
+// organization's metric whose label best matches the command text.
+// Problems are reported to the invoking user only.
+func (s *Service) HandleCommand(ctx context.Context, cmd SlackCommand) (*SlackMessage, error) {
+	c, err := s.repo.GetSlackConnectionByTeamID(ctx, cmd.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Slack connection: %w", err)
+	}
+	if c == nil {
+		return ephemeral("This Slack workspace is not connected to an organization yet."), nil
+	}
+
+	query := strings.TrimSpace(cmd.Text)
+	if query == "" {
+		return ephemeral(fmt.Sprintf("Usage: `%s <metric>`, e.g. `%s Signups`", cmd.Command, cmd.Command)), nil
+	}
+
+	found, err := s.searchService.Search(ctx, c.OrganizationID, query, []search.ResultType{search.ResultTypeMetric})
+	if errors.Is(err, search.ErrQueryTooLong) {
+		return ephemeral("That metric name is too long."), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(found.Results) == 0 {
+		return ephemeral(fmt.Sprintf("No metric matches %q.", query)), nil
+	}
+	best := found.Results[0]
+	for _, res := range found.Results {
+		if strings.EqualFold(res.Title, query) {
+			best = res
+			break
+		}
+	}
+
+	m, err := s.metricService.GetByID(ctx, best.ID)
+	if err != nil {
+		return nil, err
+	}
+	computed := s.metricService.Compute(ctx, c.OrganizationID, []metric.Metric{*m})[0]
+
+	return &SlackMessage{
+		ResponseType: "in_channel",
+		Text:         fmt.Sprintf("%s\n<%s|Open dashboard>", formatMetric(computed), s.dashboardURL(m.DashboardID)),
+	}, nil
+}
+
+// HandleEvent handles an Events API callback. Links to dashboards of the
+// workspace's organization are unfurled with a summary of their scalar
+// metrics; other events are ignored.
+func (s *Service) HandleEvent(ctx context.Context, env SlackEnvelope) {
+	if env.Type != "event_callback" || env.Event.Type != "link_shared" {
+		return
+	}
+
+	c, err := s.repo.GetSlackConnectionByTeamID(ctx, env.TeamID)
+	if err != nil {
+		log.Printf("slack unfurl error: failed to get Slack connection: %v", err)
+		return
+	}
+	if c == nil {
+		return
+	}
+
+	unfurls := make(map[string]slackAttachment)
+	for _, link := range env.Event.Links {
+		dashboardID, ok := s.parseDashboardURL(link.URL)
+		if !ok {
+			continue
+		}
+		attachment, err := s.dashboardSummary(ctx, c.OrganizationID, dashboardID, link.URL)
+		if err != nil {
+			if !errors.Is(err, dashboard.ErrDashboardNotFound) && !errors.Is(err, dashboard.ErrUnauthorized) {
+				log.Printf("slack unfurl error: %v", err)
+			}
+			continue
+		}
+		unfurls[link.URL] = *attachment
+	}
+	if len(unfurls) == 0 {
+		return
+	}
+
+	if err := s.slack.unfurl(ctx, c.BotToken, env.Event, unfurls); err != nil {
+		log.Printf("slack unfurl error: %v", err)
+	}
+}
+
+// dashboardSummary previews a dashboard with the values of its first scalar metrics.
+func (s *Service) dashboardSummary(ctx context.Context, orgID, dashboardID uuid.UUID, link string) (*slackAttachment, error) {
+	d, err := s.dashboardService.VerifyDashboardOwnership(ctx, orgID, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := s.metricService.GetByDashboardID(ctx, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scalars []metric.Metric
+	for _, m := range metrics {
+		if m.DisplayMode == metric.DisplayModeScalar && m.OrphanedAt == nil {
+			scalars = append(scalars, m)
+		}
+		if len(scalars) == maxUnfurlMetrics {
+			break
+		}
+	}
+
+	lines := make([]string, 0, len(scalars))
+	if len(scalars) > 0 {
+		for _, c := range s.metricService.Compute(ctx, orgID, scalars) {
+			lines = append(lines, formatMetric(c))
+		}
+	}
+	text := strings.Join(lines, "\n")
+	if text == "" {
+		text = "No single-value metrics to summarize."
+	}
+
+	return &slackAttachment{
+		Title:     d.Name,
+		TitleLink: link,
+		Text:      text,
+		Footer:    "LiteKPI",
+	}, nil
+}
+
+func (s *Service) dashboardURL(dashboardID uuid.UUID) string {
+	return s.appURL + "/dashboards/" + dashboardID.String()
+}
+
+// parseDashboardURL returns the dashboard a link of this instance points to.
+func (s *Service) parseDashboardURL(link string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(link, s.appURL+"/dashboards/")
+	if !ok {
+		return uuid.Nil, false
+	}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	id, err := uuid.Parse(rest)
+	return id, err == nil
+}
+
+func ephemeral(text string) *SlackMessage {
+	return &SlackMessage{ResponseType: "ephemeral", Text: text}
+}
+
+// formatMetric renders a computed metric as "*Label*: value (change)" in
+// Slack markup. Only scalar metrics have a value to show.
+func formatMetric(c metric.ComputedMetric) string {
+	if c.Error != nil {
+		return fmt.Sprintf("*%s*: %s", c.Label, *c.Error)
+	}
+	if c.DisplayMode != metric.DisplayModeScalar {
+		return fmt.Sprintf("*%s*: open the dashboard to see this chart", c.Label)
+	}
+	if c.Value == nil {
+		return fmt.Sprintf("*%s*: no data", c.Label)
+	}
+
+	text := fmt.Sprintf("*%s*: %s", c.Label, formatNumber(*c.Value))
+	if c.ChangePercent != nil {
+		text += fmt.Sprintf(" (%+.1f%% vs previous period)", *c.ChangePercent)
+	}
+	return text
+}
+
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
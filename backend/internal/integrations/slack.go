@@ -0,0 +1,133 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	slackAPIURL         = "https://slack.com/api/"
+	slackSignatureSkew  = 5 * time.Minute // Requests timestamped further off are rejected as replays
+	slackRequestTimeout = 10 * time.Second
+)
+
+// verifySlackSignature checks the X-Slack-Signature of a request body
+// against the app's signing secret.
+func verifySlackSignature(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if math.Abs(now.Sub(time.Unix(ts, 0)).Seconds()) > slackSignatureSkew.Seconds() {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// slackAttachment is the preview of an unfurled link.
+type slackAttachment struct {
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link"`
+	Text      string `json:"text"`
+	Footer    string `json:"footer,omitempty"`
+}
+
+// slackClient calls the Slack Web API with a workspace's bot token.
+type slackClient struct {
+	httpClient *http.Client
+}
+
+func newSlackClient() *slackClient {
+	return &slackClient{httpClient: &http.Client{Timeout: slackRequestTimeout}}
+}
+
+// authTest returns the workspace a bot token belongs to.
+func (c *slackClient) authTest(ctx context.Context, token string) (teamID, teamName string, err error) {
+	var resp struct {
+		TeamID string `json:"team_id"`
+		Team   string `json:"team"`
+	}
+	if err := c.call(ctx, token, "auth.test", struct{}{}, &resp); err != nil {
+		return "", "", err
+	}
+	return resp.TeamID, resp.Team, nil
+}
+
+// unfurl attaches previews to the links of a link_shared event.
+func (c *slackClient) unfurl(ctx context.Context, token string, ev SlackEvent, unfurls map[string]slackAttachment) error {
+	body := map[string]any{"unfurls": unfurls}
+	if ev.UnfurlID != "" {
+		body["unfurl_id"] = ev.UnfurlID
+		body["source"] = ev.Source
+	} else {
+		body["channel"] = ev.Channel
+		body["ts"] = ev.MessageTS
+	}
+	return c.call(ctx, token, "chat.unfurl", body, nil)
+}
+
+// slackAPIError is an error reported by the Web API in a successful response.
+type slackAPIError struct {
+	Method string
+	Code   string // e.g. invalid_auth
+}
+
+func (e *slackAPIError) Error() string {
+	return fmt.Sprintf("slack %s: %s", e.Method, e.Code)
+}
+
+// call posts body as JSON to a Web API method and decodes the response into out.
+func (c *slackClient) call(ctx context.Context, token, method string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, slackAPIURL+method, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack %s: unexpected status %d", method, resp.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	if !status.OK {
+		return &slackAPIError{Method: method, Code: status.Error}
+	}
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+	return nil
+}
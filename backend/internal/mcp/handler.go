@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
 )
 
 // Handler handles HTTP requests for MCP API keys and MCP protocol.
@@ -45,8 +46,8 @@ func (h *Handler) CreateKey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req CreateKeyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
@@ -174,8 +175,8 @@ func (h *Handler) UpdateKey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req UpdateKeyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
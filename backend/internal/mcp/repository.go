@@ -96,7 +96,8 @@ func (r *Repository) GetByAPIKeyHash(ctx context.Context, keyHash string) (*MCPA
 	key := &MCPAPIKey{}
 	err := r.pool.QueryRow(ctx,
 		`SELECT id, organization_id, name, api_key_hash, created_by, last_used_at, created_at
-		FROM mcp_api_keys WHERE api_key_hash = $1`,
+		FROM mcp_api_keys WHERE api_key_hash = $1
+		  AND organization_id NOT IN (SELECT id FROM organizations WHERE disabled_at IS NOT NULL)`,
 		keyHash,
 	).Scan(&key.ID, &key.OrganizationID, &key.Name, &key.APIKeyHash, &key.CreatedBy, &key.LastUsedAt, &key.CreatedAt)
 
@@ -137,7 +137,7 @@ func (r *ResourceRegistry) RegisterResources(server *mcp.Server, getMCPKey func(
 			// Write data rows
 			for _, m := range measurements {
 				row := []string{
-					m.Timestamp.Format(time.RFC3339),
+					m.Timestamp.Format(time.RFC3339Nano),
 					fmt.Sprintf("%g", m.Value),
 				}
 				for _, k := range sortedMetaKeys {
@@ -4,8 +4,6 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-
-	"github.com/devbydaniel/litekpi/internal/auth"
 )
 
 // RegisterRoutes registers all MCP routes.
@@ -13,7 +11,6 @@ func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Han
 	// Key management routes (JWT auth, admin only)
 	r.Route("/mcp/keys", func(r chi.Router) {
 		r.Use(authMiddleware)
-		r.Use(auth.AdminMiddleware)
 
 		r.Post("/", h.CreateKey)
 		r.Get("/", h.ListKeys)
@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/devbydaniel/litekpi/internal/datasource"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 const (
@@ -23,11 +24,12 @@ const (
 type Service struct {
 	repo      *Repository
 	dsService *datasource.Service
+	audit     *writeaudit.Service
 }
 
 // NewService creates a new MCP service.
-func NewService(repo *Repository, dsService *datasource.Service) *Service {
-	return &Service{repo: repo, dsService: dsService}
+func NewService(repo *Repository, dsService *datasource.Service, audit *writeaudit.Service) *Service {
+	return &Service{repo: repo, dsService: dsService, audit: audit}
 }
 
 // CreateKey creates a new MCP API key and returns the plain key.
@@ -59,6 +61,7 @@ func (s *Service) CreateKey(ctx context.Context, orgID, userID uuid.UUID, req Cr
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MCP API key: %w", err)
 	}
+	s.audit.Record(ctx, writeaudit.EntityMCPKey, key.ID, writeaudit.ActionCreate, nil, key)
 
 	return &CreateKeyResponse{
 		Key:    *key,
@@ -96,6 +99,7 @@ func (s *Service) DeleteKey(ctx context.Context, orgID, keyID uuid.UUID) error {
 	if err := s.repo.Delete(ctx, keyID); err != nil {
 		return fmt.Errorf("failed to delete MCP API key: %w", err)
 	}
+	s.audit.Record(ctx, writeaudit.EntityMCPKey, keyID, writeaudit.ActionDelete, key, nil)
 
 	return nil
 }
@@ -133,7 +137,9 @@ func (s *Service) UpdateKey(ctx context.Context, orgID, keyID uuid.UUID, req Upd
 	}
 
 	// Return the updated key
+	before := *key
 	key.AllowedDataSourceIDs = req.DataSourceIDs
+	s.audit.Record(ctx, writeaudit.EntityMCPKey, keyID, writeaudit.ActionUpdate, before, key)
 	return key, nil
 }
 
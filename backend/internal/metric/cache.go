@@ -0,0 +1,68 @@
+package metric
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCacheEntries bounds the compute cache; once reached, expired entries are
+// swept and new results are not cached until there is room again.
+const maxCacheEntries = 10000
+
+// computeCache keeps computed metrics of dashboards that opt into caching.
+// Entries are keyed by metric, its last update and timeframe, so editing a
+// metric bypasses its cached value, a user's preferred timeframe does not
+// share values with the saved one, and entries computed before late data landed
+// in their data source are not served. The cache is local to the process.
+type computeCache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]ComputedMetric
+	maxAge  time.Duration // Entries older than this are never served
+}
+
+type cacheKey struct {
+	metricID  uuid.UUID
+	updatedAt time.Time
+	timeframe string
+}
+
+func newComputeCache(maxAge time.Duration) *computeCache {
+	return &computeCache{entries: make(map[cacheKey]ComputedMetric), maxAge: maxAge}
+}
+
+// get returns the cached result of m if it was computed less than ttl ago
+// and after backfilledAt, with the freshness hints set for serving it from
+// cache.
+func (c *computeCache) get(m Metric, ttl time.Duration, now, backfilledAt time.Time) (ComputedMetric, bool) {
+	c.mu.Lock()
+	cached, ok := c.entries[cacheKey{m.ID, m.UpdatedAt, m.Timeframe}]
+	c.mu.Unlock()
+
+	expiresAt := cached.ComputedAt.Add(ttl)
+	if !ok || !now.Before(expiresAt) || cached.ComputedAt.Before(backfilledAt) {
+		return ComputedMetric{}, false
+	}
+	cached.CacheStatus = CacheStatusHit
+	cached.RefreshAfterSeconds = max(int(expiresAt.Sub(now).Seconds()), 1)
+	return cached, true
+}
+
+// put caches a successfully computed metric.
+func (c *computeCache) put(computed ComputedMetric, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCacheEntries {
+		for key, entry := range c.entries {
+			if now.Sub(entry.ComputedAt) >= c.maxAge {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+	c.entries[cacheKey{computed.Metric.ID, computed.Metric.UpdatedAt, computed.Metric.Timeframe}] = computed
+}
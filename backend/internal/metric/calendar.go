@@ -0,0 +1,104 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fiscalYearStartMonth returns the month the fiscal year starts in. The
+// zero calendar follows the calendar year.
+func (c Calendar) fiscalYearStartMonth() time.Month {
+	if c.FiscalYearStartMonth < 1 || c.FiscalYearStartMonth > 12 {
+		return time.January
+	}
+	return time.Month(c.FiscalYearStartMonth)
+}
+
+// fiscalYearStart returns the first day of the fiscal year t falls in.
+func (c Calendar) fiscalYearStart(t time.Time) time.Time {
+	month := c.fiscalYearStartMonth()
+	year := t.Year()
+	if t.Month() < month {
+		year--
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// fiscalQuarterStart returns the first day of the fiscal quarter t falls in.
+func (c Calendar) fiscalQuarterStart(t time.Time) time.Time {
+	yearStart := c.fiscalYearStart(t)
+	months := (int(t.Month()) - int(yearStart.Month()) + 12) % 12
+	return yearStart.AddDate(0, months/3*3, 0)
+}
+
+// fiscalPeriodLabel names the fiscal period starting at start, such as
+// "Q3 FY2027" or "FY2027". It returns "" for other timeframes.
+func (c Calendar) fiscalPeriodLabel(timeframe string, start time.Time) string {
+	if !fiscalTimeframes[timeframe] {
+		return ""
+	}
+	yearStart := c.fiscalYearStart(start)
+	// Fiscal years are named after the calendar year they end in
+	fiscalYear := yearStart.AddDate(1, 0, -1).Year()
+	switch timeframe {
+	case "this_fiscal_quarter", "last_fiscal_quarter":
+		months := (int(start.Month()) - int(yearStart.Month()) + 12) % 12
+		return fmt.Sprintf("Q%d FY%d", months/3+1, fiscalYear)
+	default:
+		return fmt.Sprintf("FY%d", fiscalYear)
+	}
+}
+
+// weekLabel numbers the week starting at weekStart. Monday weeks follow
+// ISO 8601, whose week 1 holds the year's first Thursday; Sunday weeks
+// follow the US convention, whose week 1 holds January 1.
+func (c Calendar) weekLabel(weekStart time.Time) string {
+	if c.WeekStart == WeekStartSunday {
+		year := weekStart.AddDate(0, 0, 6).Year()
+		jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		firstWeek := jan1.AddDate(0, 0, -int(jan1.Weekday()))
+		return fmt.Sprintf("%d-W%02d", year, int(weekStart.Sub(firstWeek).Hours()/24)/7+1)
+	}
+	year, week := weekStart.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// labelWeeks sets the week number of weekly data points.
+func (c Calendar) labelWeeks(points []DataPoint) {
+	for i := range points {
+		bucket, err := parseBucketDate(points[i].Date, GranularityWeekly)
+		if err != nil {
+			continue
+		}
+		points[i].Week = c.weekLabel(bucket)
+	}
+}
+
+// GetCalendar returns an organization's calendar.
+func (s *Service) GetCalendar(ctx context.Context, orgID uuid.UUID) (*Calendar, error) {
+	cal, err := s.repo.GetCalendar(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar: %w", err)
+	}
+	return cal, nil
+}
+
+// UpdateCalendar updates an organization's calendar. Metrics follow it
+// from their next computation.
+func (s *Service) UpdateCalendar(ctx context.Context, orgID uuid.UUID, req UpdateCalendarRequest) (*Calendar, error) {
+	if req.FiscalYearStartMonth != nil && (*req.FiscalYearStartMonth < 1 || *req.FiscalYearStartMonth > 12) {
+		return nil, ErrInvalidFiscalMonth
+	}
+	if req.WeekStart != nil && !req.WeekStart.IsValid() {
+		return nil, ErrInvalidWeekStart
+	}
+
+	cal, err := s.repo.UpdateCalendar(ctx, orgID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update calendar: %w", err)
+	}
+	return cal, nil
+}
@@ -0,0 +1,124 @@
+package metric
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// heatmapWeekdays names the rows of a heatmap, Monday first.
+var heatmapWeekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// writeCSV writes a computed metric as CSV. The columns depend on the
+// display mode:
+//   - scalar: value, previous_value, change, change_percent (empty when not computed)
+//   - time_series: date, value; or date, series, value when split by a key
+//   - geo: country_code, value; the unmatched total uses an empty country code
+//   - heatmap: weekday, hour, value
+//
+// With a locale, fields are separated and decimals written the way the
+// locale's spreadsheets expect, and the file starts with a byte order mark
+// so they read it as UTF-8. Dates stay ISO 8601, which spreadsheets parse
+// in every locale.
+func writeCSV(w io.Writer, c ComputedMetric, locale *LocaleFormat) error {
+	cw := csv.NewWriter(w)
+	num := csvNumbers{decimalSeparator: "."}
+	if locale != nil {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return err
+		}
+		cw.Comma = []rune(locale.ListSeparator)[0]
+		num.decimalSeparator = locale.DecimalSeparator
+	}
+
+	var rows [][]string
+	switch c.DisplayMode {
+	case DisplayModeTimeSeries:
+		if c.Series != nil {
+			rows = append(rows, []string{"date", "series", "value"})
+			for _, s := range c.Series {
+				for _, dp := range s.DataPoints {
+					rows = append(rows, []string{dp.Date, s.Key, num.float(dp.Value)})
+				}
+			}
+		} else {
+			rows = append(rows, []string{"date", "value"})
+			for _, dp := range c.DataPoints {
+				rows = append(rows, []string{dp.Date, num.float(dp.Value)})
+			}
+		}
+
+	case DisplayModeGeo:
+		rows = append(rows, []string{"country_code", "value"})
+		if c.Geo != nil {
+			for _, cv := range c.Geo.Countries {
+				rows = append(rows, []string{cv.CountryCode, num.float(cv.Value)})
+			}
+			if c.Geo.Unmatched != nil {
+				rows = append(rows, []string{"", num.float(*c.Geo.Unmatched)})
+			}
+		}
+
+	case DisplayModeHeatmap:
+		rows = append(rows, []string{"weekday", "hour", "value"})
+		if c.Heatmap != nil {
+			for d, hours := range c.Heatmap.Values {
+				for h, v := range hours {
+					rows = append(rows, []string{heatmapWeekdays[d], strconv.Itoa(h), num.float(v)})
+				}
+			}
+		}
+
+	default: // scalar
+		rows = append(rows, []string{"value", "previous_value", "change", "change_percent"})
+		rows = append(rows, []string{
+			num.optional(c.Value),
+			num.optional(c.PreviousValue),
+			num.optional(c.Change),
+			num.optional(c.ChangePercent),
+		})
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// csvFilename is the download name of a metric's CSV, e.g. signups-2026-01-31.csv.
+func csvFilename(c ComputedMetric) string {
+	name := make([]rune, 0, len(c.Label))
+	for _, r := range c.Label {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			name = append(name, r)
+		case r >= 'A' && r <= 'Z':
+			name = append(name, r+('a'-'A'))
+		case len(name) > 0 && name[len(name)-1] != '-':
+			name = append(name, '-')
+		}
+	}
+	base := strings.Trim(string(name), "-")
+	if base == "" {
+		base = "metric"
+	}
+	return base + "-" + c.ComputedAt.Format(time.DateOnly) + ".csv"
+}
+
+// csvNumbers writes the numbers of a CSV.
+type csvNumbers struct {
+	decimalSeparator string
+}
+
+func (n csvNumbers) float(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", n.decimalSeparator, 1)
+}
+
+func (n csvNumbers) optional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return n.float(*v)
+}
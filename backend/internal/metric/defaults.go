@@ -0,0 +1,72 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetDefaults returns an organization's metric defaults, which are empty
+// until an admin saves some.
+func (s *Service) GetDefaults(ctx context.Context, orgID uuid.UUID) (*MetricDefaults, error) {
+	d, err := s.repo.GetMetricDefaults(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric defaults: %w", err)
+	}
+	if d == nil {
+		return &MetricDefaults{}, nil
+	}
+	return d, nil
+}
+
+// UpdateDefaults replaces an organization's metric defaults.
+func (s *Service) UpdateDefaults(ctx context.Context, orgID uuid.UUID, req UpdateMetricDefaultsRequest) (*MetricDefaults, error) {
+	// A custom timeframe needs dates, which a default cannot provide
+	if req.Timeframe != nil && (!IsValidTimeframe(*req.Timeframe) || *req.Timeframe == "custom") {
+		return nil, ErrInvalidTimeframe
+	}
+	if req.Granularity != nil && !req.Granularity.IsValid() {
+		return nil, ErrUnknownGranularity
+	}
+	if req.ChartType != nil && !req.ChartType.IsValid() {
+		return nil, ErrInvalidChartType
+	}
+
+	d, err := s.repo.UpsertMetricDefaults(ctx, orgID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update metric defaults: %w", err)
+	}
+	return d, nil
+}
+
+// ApplyDefaults fills in the fields req omits from the organization's
+// metric defaults. Fields the request sets are kept as they are.
+func (s *Service) ApplyDefaults(ctx context.Context, orgID uuid.UUID, req *CreateMetricRequest) error {
+	d, err := s.repo.GetMetricDefaults(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get metric defaults: %w", err)
+	}
+	if d == nil {
+		return nil
+	}
+
+	if req.Timeframe == "" && req.DateFrom == nil && req.DateTo == nil && d.Timeframe != nil {
+		req.Timeframe = *d.Timeframe
+	}
+	if req.DisplayMode == DisplayModeTimeSeries {
+		if req.Granularity == nil && d.Granularity != nil {
+			g := *d.Granularity
+			req.Granularity = &g
+		}
+		if req.ChartType == nil && d.ChartType != nil {
+			ct := *d.ChartType
+			req.ChartType = &ct
+		}
+	}
+	if req.ComparisonEnabled == nil && d.ComparisonEnabled != nil {
+		enabled := *d.ComparisonEnabled
+		req.ComparisonEnabled = &enabled
+	}
+	return nil
+}
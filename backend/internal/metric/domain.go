@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 // Error definitions
@@ -20,22 +22,98 @@ var (
 	ErrInvalidDisplayMode     = errors.New("invalid display mode")
 	ErrInvalidChartType       = errors.New("invalid chart type")
 	ErrInvalidComparisonType  = errors.New("invalid comparison display type")
-	ErrAggregationKeyRequired = errors.New("aggregation_key is required for count_unique aggregation")
+	ErrAggregationKeyRequired = errors.New("aggregation_key is required for count_unique and weighted_average aggregations")
 	ErrChartTypeRequired      = errors.New("chart_type is required for time_series display mode")
+	ErrInvalidRefreshInterval = errors.New("refresh interval must be between 10 and 86400 seconds")
+	ErrInvalidDateRange       = errors.New("date range must have a from date on or before its to date")
+	ErrSplitByRequired        = errors.New("split_by is required for geo display mode")
+	ErrInvalidGeoAggregation  = errors.New("geo display mode supports sum, average and count aggregations")
+	ErrInvalidStacking        = errors.New("invalid stacking")
+	ErrStackingNotSupported   = errors.New("stacking is only supported for split-by bar and area charts")
+	ErrShareOfNotSupported    = errors.New("share_of is only supported for scalar display mode")
+	ErrShareOfAggregation     = errors.New("share_of supports sum and count aggregations")
+	ErrInvalidDataFormat      = errors.New("format must be csv or json")
+	ErrVersionNotFound        = errors.New("metric version not found")
+	ErrPreconditionFailed     = errors.New("resource was modified since it was read")
+	ErrInvalidMetricOrder     = errors.New("metric IDs must list every metric on the dashboard exactly once")
+	ErrInvalidOtherThreshold  = errors.New("other threshold must be greater than 0 and less than 100")
+	ErrOtherThresholdSplitBy  = errors.New("other threshold is only supported for split-by time series")
+	ErrCompositeNotSupported  = errors.New("composite series are only supported for time series without split_by")
+	ErrInvalidCompositeSeries = errors.New("each composite series needs a measurement name and a unique label of at most 255 characters")
+	ErrTooManyCompositeSeries = errors.New("too many composite series")
+	ErrFixedFields            = errors.New("a metric's data source and measurement cannot be changed; delete it and create it again")
+	ErrUnknownGranularity     = errors.New("invalid granularity")
+	ErrInvalidFiscalMonth     = errors.New("fiscal year start month must be between 1 and 12")
+	ErrInvalidWeekStart       = errors.New("week start must be monday or sunday")
+	ErrUnsupportedLocale      = errors.New("unsupported locale")
+)
+
+// Per-metric compute error messages returned to clients.
+const (
+	computeErrDataSourceDeleted      = "data source deleted"
+	computeErrDataSourceInaccessible = "data source not accessible"
+	computeErrTimeout                = "query timed out"
+	computeErrBudgetExceeded         = "compute time budget exceeded"
+	computeErrFailed                 = "failed to compute metric"
+)
+
+// Refresh interval bounds for metrics, in seconds.
+const (
+	MinRefreshIntervalSeconds = 10
+	MaxRefreshIntervalSeconds = 86400
+)
+
+// CacheStatus tells clients whether a computed value was served from cache.
+type CacheStatus string
+
+const (
+	CacheStatusHit  CacheStatus = "hit"  // Reused from an earlier request, per the dashboard's cache TTL
+	CacheStatusMiss CacheStatus = "miss" // Computed for this request
 )
 
+// ComputeOptions tune how a dashboard's metrics are computed.
+type ComputeOptions struct {
+	Concurrency int           // Metrics computed in parallel, up to the server limit
+	CacheTTL    time.Duration // How long computed values are reused, up to the server limit; zero disables caching
+}
+
+// DataStatus tells clients whether a computed metric has data, and if not,
+// why, so empty widgets can explain themselves instead of showing zero.
+type DataStatus string
+
+const (
+	DataStatusOK                   DataStatus = "ok"
+	DataStatusNoDataInRange        DataStatus = "no_data_in_range"       // The measurement has data, but none in the timeframe
+	DataStatusMeasurementNeverSeen DataStatus = "measurement_never_seen" // Nothing was ever ingested under the measurement name
+	DataStatusNoRecentIngests      DataStatus = "no_recent_ingests"      // The data source has not ingested anything recently
+)
+
+// RecentIngestWindow is how long a data source may go without ingesting
+// before empty metrics are attributed to it.
+const RecentIngestWindow = 7 * 24 * time.Hour
+
+// frequencyIntervals maps the expected ingest frequencies of freshness SLAs
+// to how old a measurement's newest data may be before it is stale.
+var frequencyIntervals = map[string]time.Duration{
+	"hourly": time.Hour,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
 // DisplayMode represents how the metric is displayed.
 type DisplayMode string
 
 const (
 	DisplayModeScalar     DisplayMode = "scalar"
 	DisplayModeTimeSeries DisplayMode = "time_series"
+	DisplayModeGeo        DisplayMode = "geo"     // Totals per country of the split-by key, for map widgets
+	DisplayModeHeatmap    DisplayMode = "heatmap" // Weekday-by-hour grid of raw measurements
 )
 
 // IsValid checks if the display mode is valid.
 func (d DisplayMode) IsValid() bool {
 	switch d {
-	case DisplayModeScalar, DisplayModeTimeSeries:
+	case DisplayModeScalar, DisplayModeTimeSeries, DisplayModeGeo, DisplayModeHeatmap:
 		return true
 	}
 	return false
@@ -45,16 +123,17 @@ func (d DisplayMode) IsValid() bool {
 type Aggregation string
 
 const (
-	AggregationSum         Aggregation = "sum"
-	AggregationAverage     Aggregation = "average"
-	AggregationCount       Aggregation = "count"
-	AggregationCountUnique Aggregation = "count_unique"
+	AggregationSum             Aggregation = "sum"
+	AggregationAverage         Aggregation = "average"
+	AggregationCount           Aggregation = "count"
+	AggregationCountUnique     Aggregation = "count_unique"
+	AggregationWeightedAverage Aggregation = "weighted_average" // Weighted by the numeric metadata value under aggregation_key
 )
 
 // IsValid checks if the aggregation is valid.
 func (a Aggregation) IsValid() bool {
 	switch a {
-	case AggregationSum, AggregationAverage, AggregationCount, AggregationCountUnique:
+	case AggregationSum, AggregationAverage, AggregationCount, AggregationCountUnique, AggregationWeightedAverage:
 		return true
 	}
 	return false
@@ -62,7 +141,13 @@ func (a Aggregation) IsValid() bool {
 
 // RequiresAggregationKey returns true if the aggregation type needs an aggregation_key.
 func (a Aggregation) RequiresAggregationKey() bool {
-	return a == AggregationCountUnique
+	return a == AggregationCountUnique || a == AggregationWeightedAverage
+}
+
+// IsAverage returns true if the aggregation divides a sum by a total weight,
+// so values can only be combined by weighting them.
+func (a Aggregation) IsAverage() bool {
+	return a == AggregationAverage || a == AggregationWeightedAverage
 }
 
 // Granularity represents the time granularity for aggregation.
@@ -101,6 +186,24 @@ func (c ChartType) IsValid() bool {
 	return false
 }
 
+// Stacking represents how the series of a split-by chart are stacked.
+type Stacking string
+
+const (
+	StackingStacked        Stacking = "stacked"
+	StackingGrouped        Stacking = "grouped"
+	StackingPercentStacked Stacking = "percent_stacked" // Series are normalized to percentages of each bucket
+)
+
+// IsValid checks if the stacking is valid.
+func (s Stacking) IsValid() bool {
+	switch s {
+	case StackingStacked, StackingGrouped, StackingPercentStacked:
+		return true
+	}
+	return false
+}
+
 // ComparisonDisplayType represents how to display comparison values.
 type ComparisonDisplayType string
 
@@ -120,11 +223,24 @@ func (c ComparisonDisplayType) IsValid() bool {
 
 // Valid timeframes
 var validTimeframes = map[string]bool{
-	"last_7_days":  true,
-	"last_30_days": true,
-	"this_month":   true,
-	"last_month":   true,
-	"custom":       true,
+	"last_7_days":         true,
+	"last_30_days":        true,
+	"this_month":          true,
+	"last_month":          true,
+	"this_fiscal_quarter": true,
+	"last_fiscal_quarter": true,
+	"this_fiscal_year":    true,
+	"last_fiscal_year":    true,
+	"custom":              true,
+}
+
+// fiscalTimeframes are the timeframes that follow the organization's fiscal
+// year instead of the calendar year.
+var fiscalTimeframes = map[string]bool{
+	"this_fiscal_quarter": true,
+	"last_fiscal_quarter": true,
+	"this_fiscal_year":    true,
+	"last_fiscal_year":    true,
 }
 
 // IsValidTimeframe checks if the timeframe is valid.
@@ -138,6 +254,26 @@ type Filter struct {
 	Value string `json:"value"`
 }
 
+// ShareDenominator is what a scalar metric's value is divided by to return
+// it as a percentage. The denominator aggregates the same measurement with
+// these filters instead of the metric's own; no filters means all values.
+type ShareDenominator struct {
+	Filters []Filter `json:"filters"`
+}
+
+// MaxCompositeSeries is the maximum number of series of a composite chart.
+const MaxCompositeSeries = 10
+
+// CompositeSeries is one series of a composite time series: a measurement of
+// the metric's data source, aggregated like the metric. Its filters apply in
+// addition to the metric's, for charts whose series can't be told apart by a
+// single metadata key.
+type CompositeSeries struct {
+	Label           string   `json:"label"`
+	MeasurementName string   `json:"measurementName"`
+	Filters         []Filter `json:"filters"`
+}
+
 // Metric represents a unified metric on a dashboard.
 type Metric struct {
 	ID          uuid.UUID `json:"id"`
@@ -148,14 +284,14 @@ type Metric struct {
 	// Query fields
 	DataSourceID    uuid.UUID  `json:"dataSourceId"`
 	MeasurementName string     `json:"measurementName"`
-	Timeframe       string     `json:"timeframe"` // last_7_days, last_30_days, this_month, last_month, custom
+	Timeframe       string     `json:"timeframe"` // last_7_days, last_30_days, this_month, last_month, this/last_fiscal_quarter, this/last_fiscal_year, custom
 	DateFrom        *time.Time `json:"dateFrom,omitempty"`
 	DateTo          *time.Time `json:"dateTo,omitempty"`
 	Filters         []Filter   `json:"filters"`
 
 	// Aggregation
 	Aggregation    Aggregation  `json:"aggregation"`
-	AggregationKey *string      `json:"aggregationKey,omitempty"` // Required for count_unique
+	AggregationKey *string      `json:"aggregationKey,omitempty"` // Required for count_unique and weighted_average
 	Granularity    *Granularity `json:"granularity,omitempty"`    // Required for time_series only
 
 	// Display mode
@@ -164,13 +300,93 @@ type Metric struct {
 	// Scalar display options
 	ComparisonEnabled     bool                   `json:"comparisonEnabled"`
 	ComparisonDisplayType *ComparisonDisplayType `json:"comparisonDisplayType,omitempty"`
+	ShareOf               *ShareDenominator      `json:"shareOf,omitempty"` // Return the value as a percentage of this
 
 	// Time series display options
-	ChartType *ChartType `json:"chartType,omitempty"`
-	SplitBy   *string    `json:"splitBy,omitempty"`
+	ChartType      *ChartType `json:"chartType,omitempty"`
+	SplitBy        *string    `json:"splitBy,omitempty"`
+	Stacking       *Stacking  `json:"stacking,omitempty"`       // Split-by bar and area charts only
+	OtherThreshold *float64   `json:"otherThreshold,omitempty"` // Percent of the total below which split-by series merge into "Other"
+
+	// One series per measurement and filters, instead of splitting by a metadata key
+	CompositeSeries []CompositeSeries `json:"compositeSeries,omitempty"`
+
+	// How stale the metric may get before clients refresh it; derived from the query when nil
+	RefreshIntervalSeconds *int `json:"refreshIntervalSeconds,omitempty"`
+
+	// Set when the data source was deleted and the metric kept; it no longer computes
+	OrphanedAt *time.Time `json:"orphanedAt,omitempty"`
+
+	// Client-supplied key, unique within the dashboard
+	ExternalID *string `json:"externalId,omitempty"`
 
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Writes to the metric, newest first; only with includeHistory=true
+	History []writeaudit.Entry `json:"history,omitempty"`
+
+	calendar Calendar // The organization's calendar, set when computing
+}
+
+// MaxVersionsPerMetric is how many previous configurations are kept per metric.
+const MaxVersionsPerMetric = 50
+
+// MetricVersion is a previous configuration of a metric, recorded when it was updated.
+type MetricVersion struct {
+	Version   int                 `json:"version"` // Increases with every update
+	Config    UpdateMetricRequest `json:"config"`
+	CreatedBy *uuid.UUID          `json:"createdBy,omitempty"` // The user whose update replaced this configuration
+	CreatedAt time.Time           `json:"createdAt"`
+}
+
+// ConfigChange is an update to a metric's configuration.
+type ConfigChange struct {
+	MetricID      uuid.UUID
+	DashboardID   uuid.UUID
+	Label         string   // The label after the change
+	ChangedFields []string // JSON names of the configuration fields that changed
+	ChangedBy     *uuid.UUID
+	ChangedAt     time.Time
+}
+
+// updateRequest returns the metric's current configuration as an update request.
+func (m Metric) updateRequest() UpdateMetricRequest {
+	return UpdateMetricRequest{
+		Label:                  m.Label,
+		Timeframe:              m.Timeframe,
+		DateFrom:               m.DateFrom,
+		DateTo:                 m.DateTo,
+		Filters:                m.Filters,
+		Aggregation:            m.Aggregation,
+		AggregationKey:         m.AggregationKey,
+		Granularity:            m.Granularity,
+		DisplayMode:            m.DisplayMode,
+		ComparisonEnabled:      m.ComparisonEnabled,
+		ComparisonDisplayType:  m.ComparisonDisplayType,
+		ShareOf:                m.ShareOf,
+		ChartType:              m.ChartType,
+		SplitBy:                m.SplitBy,
+		Stacking:               m.Stacking,
+		OtherThreshold:         m.OtherThreshold,
+		CompositeSeries:        m.CompositeSeries,
+		RefreshIntervalSeconds: m.RefreshIntervalSeconds,
+	}
+}
+
+// ConfigChanges returns the JSON names of the configuration fields that
+// updating the metric with req would change.
+func (m Metric) ConfigChanges(req UpdateMetricRequest) []string {
+	return changedFields(m.updateRequest(), req)
+}
+
+// TrashedMetric is a deleted metric that can still be restored.
+type TrashedMetric struct {
+	ID            uuid.UUID
+	DashboardID   uuid.UUID
+	DashboardName string
+	Label         string
+	DeletedAt     time.Time
 }
 
 // ComputedMetric represents a metric with its calculated values.
@@ -182,15 +398,91 @@ type ComputedMetric struct {
 	Change        *float64 `json:"change,omitempty"`
 	ChangePercent *float64 `json:"changePercent,omitempty"`
 
+	// For share-of scalar display, the values the percentage was computed from
+	Numerator   *float64 `json:"numerator,omitempty"`
+	Denominator *float64 `json:"denominator,omitempty"`
+
 	// For time series display
 	DataPoints []DataPoint   `json:"dataPoints,omitempty"`
 	Series     []SplitSeries `json:"series,omitempty"` // When splitBy is used
+
+	// For time series display with comparison enabled and no splitBy, the
+	// previous period's data points aligned bucket by bucket to this period
+	ComparisonDataPoints []ComparisonDataPoint `json:"comparisonDataPoints,omitempty"`
+
+	// For time series display, periods of the chart to highlight, such as
+	// maintenance of the data source
+	Annotations []Annotation `json:"annotations,omitempty"`
+
+	// For fiscal timeframes, the period computed, such as "Q3 FY2027"
+	PeriodLabel string `json:"periodLabel,omitempty"`
+
+	// For geo display
+	Geo *GeoResult `json:"geo,omitempty"`
+
+	// For heatmap display
+	Heatmap *HeatmapResult `json:"heatmap,omitempty"`
+
+	// Error is set when this metric could not be computed. The remaining
+	// metrics in the response are unaffected.
+	Error *string `json:"error,omitempty"`
+
+	// Status explains an empty result; it is unset when Error is set
+	Status DataStatus `json:"status,omitempty"`
+
+	// Default formatting from the measurement's declared type
+	Format *ValueFormat `json:"format,omitempty"`
+
+	// Newest timestamp ingested by the data source; buckets ending after it
+	// are marked partial
+	CompleteThrough *time.Time `json:"completeThrough,omitempty"`
+
+	// Set when the measurement declares a freshness SLA: stale means its
+	// newest data is older than the expected ingest frequency, so a flat
+	// chart is not mistaken for zero
+	ExpectedFrequency *string    `json:"expectedFrequency,omitempty"`
+	LastDataAt        *time.Time `json:"lastDataAt,omitempty"`
+	Stale             bool       `json:"stale,omitempty"`
+
+	// Freshness hints so clients can refresh instead of blind polling
+	ComputedAt          time.Time   `json:"computedAt"`
+	CacheStatus         CacheStatus `json:"cacheStatus"`
+	RefreshAfterSeconds int         `json:"refreshAfterSeconds"` // Suggested delay before recomputing
+
+	err error // underlying compute error, for logging only
 }
 
 // DataPoint represents a single aggregated data point.
 type DataPoint struct {
-	Date  string  `json:"date"`
-	Value float64 `json:"value"`
+	Date    string  `json:"date"`
+	Value   float64 `json:"value"`
+	Partial bool    `json:"partial,omitempty"` // The bucket ends after the data is complete; late data may still change it
+	Week    string  `json:"week,omitempty"`    // For weekly buckets, the week number, such as "2026-W42"
+
+	weight float64 // Total weight behind an averaged value, for merging series
+}
+
+// AnnotationType is the kind of period an annotation marks.
+type AnnotationType string
+
+const (
+	AnnotationTypeMaintenance AnnotationType = "maintenance"
+)
+
+// Annotation marks a period on a chart.
+type Annotation struct {
+	Type     AnnotationType `json:"type"`
+	StartsAt time.Time      `json:"startsAt"`
+	EndsAt   time.Time      `json:"endsAt"`
+	Label    string         `json:"label,omitempty"`
+}
+
+// ComparisonDataPoint is a data point of the comparison period, placed in the
+// bucket at the same position from the start of the current period.
+type ComparisonDataPoint struct {
+	Date         string  `json:"date"`         // Bucket of the current period
+	PreviousDate string  `json:"previousDate"` // Bucket the value was aggregated over
+	Value        float64 `json:"value"`
 }
 
 // SplitSeries represents aggregated data for a single metadata value.
@@ -199,6 +491,40 @@ type SplitSeries struct {
 	DataPoints []DataPoint `json:"dataPoints"`
 }
 
+// GeoResult holds per-country values of a geo metric.
+type GeoResult struct {
+	Countries []CountryValue `json:"countries"`           // Sorted by value, highest first
+	Unmatched *float64       `json:"unmatched,omitempty"` // Value of split-by values not recognized as a country
+}
+
+// CountryValue is the value of a geo metric for one country.
+type CountryValue struct {
+	CountryCode string  `json:"countryCode"` // ISO 3166-1 alpha-2
+	Value       float64 `json:"value"`
+}
+
+// HeatmapResult holds a heatmap metric pivoted into a weekday-by-hour grid.
+// Values[0] is Monday and Values[d][h] covers hour h of that day in UTC.
+// Only raw measurements have hourly resolution, so days already
+// downsampled into daily rollups are not included.
+type HeatmapResult struct {
+	Values [][]float64 `json:"values"`
+}
+
+// HeatmapCell is the aggregate of one weekday and hour from the database.
+type HeatmapCell struct {
+	Weekday int // ISO weekday, 1 is Monday
+	Hour    int
+	Value   float64
+}
+
+// SplitTotal is the aggregate of one split-by value over a whole timeframe.
+type SplitTotal struct {
+	Key   string
+	Sum   float64
+	Count int
+}
+
 // AggregatedDataPoint represents raw aggregated data from the database.
 type AggregatedDataPoint struct {
 	Date  string
@@ -210,9 +536,9 @@ type AggregatedDataPoint struct {
 
 // CreateMetricRequest is the request body for creating a metric.
 type CreateMetricRequest struct {
-	DataSourceID    uuid.UUID   `json:"dataSourceId"`
-	Label           string      `json:"label"`
-	MeasurementName string      `json:"measurementName"`
+	DataSourceID    uuid.UUID   `json:"dataSourceId"` // Omit to use the organization's default data source
+	Label           string      `json:"label" validate:"required,max=255"`
+	MeasurementName string      `json:"measurementName" validate:"required"`
 	Timeframe       string      `json:"timeframe"`
 	DateFrom        *time.Time  `json:"dateFrom,omitempty"`
 	DateTo          *time.Time  `json:"dateTo,omitempty"`
@@ -223,17 +549,51 @@ type CreateMetricRequest struct {
 	DisplayMode     DisplayMode  `json:"displayMode"`
 
 	// Scalar options
-	ComparisonEnabled     bool                   `json:"comparisonEnabled"`
+	ComparisonEnabled     *bool                  `json:"comparisonEnabled,omitempty"` // Omit to use the organization default
 	ComparisonDisplayType *ComparisonDisplayType `json:"comparisonDisplayType,omitempty"`
+	ShareOf               *ShareDenominator      `json:"shareOf,omitempty"`
 
 	// Time series options
-	ChartType *ChartType `json:"chartType,omitempty"`
-	SplitBy   *string    `json:"splitBy,omitempty"`
+	ChartType      *ChartType `json:"chartType,omitempty"`
+	SplitBy        *string    `json:"splitBy,omitempty"`
+	Stacking       *Stacking  `json:"stacking,omitempty"`
+	OtherThreshold *float64   `json:"otherThreshold,omitempty"`
+
+	CompositeSeries []CompositeSeries `json:"compositeSeries,omitempty"`
+
+	RefreshIntervalSeconds *int `json:"refreshIntervalSeconds,omitempty"`
+
+	ExternalID *string `json:"externalId,omitempty"` // Unique within the dashboard
+}
+
+// UpdateRequest returns the part of the request that an update can change;
+// a metric's data source and measurement are fixed at creation.
+func (r CreateMetricRequest) UpdateRequest() UpdateMetricRequest {
+	return UpdateMetricRequest{
+		Label:                  r.Label,
+		Timeframe:              r.Timeframe,
+		DateFrom:               r.DateFrom,
+		DateTo:                 r.DateTo,
+		Filters:                r.Filters,
+		Aggregation:            r.Aggregation,
+		AggregationKey:         r.AggregationKey,
+		Granularity:            r.Granularity,
+		DisplayMode:            r.DisplayMode,
+		ComparisonEnabled:      r.ComparisonEnabled != nil && *r.ComparisonEnabled,
+		ComparisonDisplayType:  r.ComparisonDisplayType,
+		ShareOf:                r.ShareOf,
+		ChartType:              r.ChartType,
+		SplitBy:                r.SplitBy,
+		Stacking:               r.Stacking,
+		OtherThreshold:         r.OtherThreshold,
+		CompositeSeries:        r.CompositeSeries,
+		RefreshIntervalSeconds: r.RefreshIntervalSeconds,
+	}
 }
 
 // UpdateMetricRequest is the request body for updating a metric.
 type UpdateMetricRequest struct {
-	Label           string      `json:"label"`
+	Label           string      `json:"label" validate:"required,max=255"`
 	Timeframe       string      `json:"timeframe"`
 	DateFrom        *time.Time  `json:"dateFrom,omitempty"`
 	DateTo          *time.Time  `json:"dateTo,omitempty"`
@@ -246,10 +606,102 @@ type UpdateMetricRequest struct {
 	// Scalar options
 	ComparisonEnabled     bool                   `json:"comparisonEnabled"`
 	ComparisonDisplayType *ComparisonDisplayType `json:"comparisonDisplayType,omitempty"`
+	ShareOf               *ShareDenominator      `json:"shareOf,omitempty"`
 
 	// Time series options
-	ChartType *ChartType `json:"chartType,omitempty"`
-	SplitBy   *string    `json:"splitBy,omitempty"`
+	ChartType      *ChartType `json:"chartType,omitempty"`
+	SplitBy        *string    `json:"splitBy,omitempty"`
+	Stacking       *Stacking  `json:"stacking,omitempty"`
+	OtherThreshold *float64   `json:"otherThreshold,omitempty"`
+
+	CompositeSeries []CompositeSeries `json:"compositeSeries,omitempty"`
+
+	RefreshIntervalSeconds *int `json:"refreshIntervalSeconds,omitempty"`
+}
+
+// ExploreQuery is an ad-hoc metric query computed without creating a metric.
+type ExploreQuery struct {
+	DataSourceID      uuid.UUID         `json:"dataSourceId"` // Omit to use the organization's default data source
+	MeasurementName   string            `json:"measurementName"`
+	Timeframe         string            `json:"timeframe"`
+	DateFrom          *time.Time        `json:"dateFrom,omitempty"`
+	DateTo            *time.Time        `json:"dateTo,omitempty"`
+	Filters           []Filter          `json:"filters,omitempty"`
+	Aggregation       Aggregation       `json:"aggregation"`
+	AggregationKey    *string           `json:"aggregationKey,omitempty"`
+	Granularity       *Granularity      `json:"granularity,omitempty"` // Required for time_series only
+	DisplayMode       DisplayMode       `json:"displayMode"`
+	ComparisonEnabled bool              `json:"comparisonEnabled"`
+	SplitBy           *string           `json:"splitBy,omitempty"`
+	ShareOf           *ShareDenominator `json:"shareOf,omitempty"`        // Scalar only
+	OtherThreshold    *float64          `json:"otherThreshold,omitempty"` // Split-by time series only
+}
+
+// createRequest converts the query into a metric create request with the given label.
+func (q ExploreQuery) createRequest(label string) CreateMetricRequest {
+	return CreateMetricRequest{
+		DataSourceID:      q.DataSourceID,
+		Label:             label,
+		MeasurementName:   q.MeasurementName,
+		Timeframe:         q.Timeframe,
+		DateFrom:          q.DateFrom,
+		DateTo:            q.DateTo,
+		Filters:           q.Filters,
+		Aggregation:       q.Aggregation,
+		AggregationKey:    q.AggregationKey,
+		Granularity:       q.Granularity,
+		DisplayMode:       q.DisplayMode,
+		ComparisonEnabled: &q.ComparisonEnabled,
+		SplitBy:           q.SplitBy,
+		ShareOf:           q.ShareOf,
+		OtherThreshold:    q.OtherThreshold,
+	}
+}
+
+// ExploreResponse is the response for an exploration.
+type ExploreResponse struct {
+	Result ComputedMetric `json:"result"`
+}
+
+// DateRange is an explicit range of whole UTC days, both ends inclusive.
+type DateRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// ComparePeriodsRequest is the request body for comparing a query across two date ranges.
+type ComparePeriodsRequest struct {
+	// Timeframe, display mode, granularity and comparison settings of the query are ignored
+	Query    ExploreQuery `json:"query"`
+	Current  DateRange    `json:"current"`
+	Baseline DateRange    `json:"baseline"` // The period the current one is compared against
+}
+
+// PeriodValue is the aggregated value of a query over one date range.
+type PeriodValue struct {
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Value float64   `json:"value"`
+}
+
+// ComparePeriodsResponse is the response for a period comparison.
+type ComparePeriodsResponse struct {
+	Current       PeriodValue `json:"current"`
+	Baseline      PeriodValue `json:"baseline"`
+	Change        float64     `json:"change"`                  // Current minus baseline
+	ChangePercent *float64    `json:"changePercent,omitempty"` // Omitted when the baseline is zero
+}
+
+// SaveExplorationRequest is the request body for saving an exploration as a metric.
+type SaveExplorationRequest struct {
+	DashboardID uuid.UUID    `json:"dashboardId"`
+	Label       string       `json:"label"`
+	Query       ExploreQuery `json:"query"`
+
+	// Display options not part of the query
+	ChartType             *ChartType             `json:"chartType,omitempty"` // Required for time_series
+	Stacking              *Stacking              `json:"stacking,omitempty"`
+	ComparisonDisplayType *ComparisonDisplayType `json:"comparisonDisplayType,omitempty"`
 }
 
 // ReorderMetricsRequest is the request body for reordering metrics.
@@ -257,14 +709,101 @@ type ReorderMetricsRequest struct {
 	MetricIDs []uuid.UUID `json:"metricIds"`
 }
 
+// ListMetricVersionsResponse is the response for listing a metric's previous versions.
+type ListMetricVersionsResponse struct {
+	Versions []MetricVersion `json:"versions"` // Newest first
+}
+
+// TrendDirection is which way a scalar metric moved against its previous period.
+type TrendDirection string
+
+const (
+	TrendUp   TrendDirection = "up"
+	TrendDown TrendDirection = "down"
+	TrendFlat TrendDirection = "flat" // Changed by less than trendFlatPercent
+)
+
+// trendFlatPercent is the relative change below which a trend counts as flat.
+const trendFlatPercent = 1.0
+
+// Trend is a precomputed indicator of how a scalar metric's value changed
+// over its timeframe compared with the previous period. It is refreshed in
+// the background, so it may lag the metric's computed value.
+type Trend struct {
+	Direction     TrendDirection `json:"direction"`
+	Change        float64        `json:"change"`
+	ChangePercent *float64       `json:"changePercent,omitempty"` // Omitted when the baseline is zero
+	ComputedAt    time.Time      `json:"computedAt"`
+}
+
+// MetricWithTrend is a metric in a list, with its trend if one is available.
+type MetricWithTrend struct {
+	Metric
+	Trend *Trend `json:"trend,omitempty"` // Scalar metrics only; omitted until computed for the current configuration
+}
+
+// PaceBasis is what a metric's expected pace is derived from.
+type PaceBasis string
+
+const (
+	PaceBasisHistorical PaceBasis = "historical" // How the total accrued over the previous periods
+	PaceBasisLinear     PaceBasis = "linear"     // No history; the total is assumed to accrue evenly
+)
+
+// paceHistoryPeriods is how many previous periods the expected pace is
+// derived from.
+const paceHistoryPeriods = 3
+
+// Pace is how much of its timeframe's total a metric usually has reached at
+// a point of the timeframe, so that a back-loaded metric is not judged
+// behind early in the period.
+type Pace struct {
+	Elapsed       float64   // Share of the timeframe elapsed
+	ExpectedShare float64   // Share of the timeframe's total usually reached by then
+	Basis         PaceBasis
+}
+
 // ListMetricsResponse is the response for listing metrics.
 type ListMetricsResponse struct {
-	Metrics []Metric `json:"metrics"`
+	Metrics []MetricWithTrend `json:"metrics"`
+}
+
+// semanticTypeCount is the format of count aggregations regardless of the measurement's type.
+const semanticTypeCount = "count"
+
+// semanticTypePercent is the format of share-of metrics regardless of the measurement's type.
+const semanticTypePercent = "percent"
+
+// ValueFormat tells clients how to format computed values by default.
+// SemanticType is one of count, currency, duration_ms, percent.
+type ValueFormat struct {
+	SemanticType string  `json:"semanticType"`
+	Unit         *string `json:"unit,omitempty"`
+}
+
+// DataFormat is the format of a downloaded metric.
+type DataFormat string
+
+const (
+	DataFormatJSON DataFormat = "json"
+	DataFormatCSV  DataFormat = "csv"
+)
+
+// IsValid checks if the data format is valid.
+func (f DataFormat) IsValid() bool {
+	switch f {
+	case DataFormatJSON, DataFormatCSV:
+		return true
+	}
+	return false
 }
 
 // ComputeMetricsResponse is the response for computing metrics.
 type ComputeMetricsResponse struct {
-	Metrics []ComputedMetric `json:"metrics"`
+	Metrics             []ComputedMetric `json:"metrics"`
+	ComputedAt          time.Time        `json:"computedAt"`          // When the oldest value was computed
+	RefreshAfterSeconds int              `json:"refreshAfterSeconds"` // Shortest refresh hint of the metrics
+	Locale              *LocaleFormat    `json:"locale,omitempty"`    // How the organization writes numbers and dates
 }
 
 // MessageResponse is a generic response with a message.
@@ -272,6 +811,76 @@ type MessageResponse struct {
 	Message string `json:"message"`
 }
 
+// MetricDefaults are an organization's defaults for new metrics. Creating a
+// metric fills in the fields its request omits: the timeframe unless a date
+// range is given, granularity and chart type of time series, and whether to
+// compare with the previous period.
+type MetricDefaults struct {
+	Timeframe         *string      `json:"timeframe,omitempty"` // A relative timeframe; custom needs dates
+	Granularity       *Granularity `json:"granularity,omitempty"`
+	ChartType         *ChartType   `json:"chartType,omitempty"`
+	ComparisonEnabled *bool        `json:"comparisonEnabled,omitempty"`
+	UpdatedAt         *time.Time   `json:"updatedAt,omitempty"` // Unset until an admin saves defaults
+}
+
+// UpdateMetricDefaultsRequest is the request body for replacing an
+// organization's metric defaults. Null leaves a field without default.
+type UpdateMetricDefaultsRequest struct {
+	Timeframe         *string      `json:"timeframe"`
+	Granularity       *Granularity `json:"granularity"`
+	ChartType         *ChartType   `json:"chartType"`
+	ComparisonEnabled *bool        `json:"comparisonEnabled"`
+}
+
+// WeekStart is the day weekly buckets start on.
+type WeekStart string
+
+const (
+	WeekStartMonday WeekStart = "monday" // ISO 8601 weeks and numbering
+	WeekStartSunday WeekStart = "sunday" // US weeks; week 1 contains January 1
+)
+
+// IsValid checks if the week start is valid.
+func (w WeekStart) IsValid() bool {
+	switch w {
+	case WeekStartMonday, WeekStartSunday:
+		return true
+	}
+	return false
+}
+
+// Calendar is how an organization divides time. Fiscal timeframes start
+// their year in FiscalYearStartMonth and are named after the calendar year
+// they end in, so with July a year running into 2027 is FY2027. Weekly
+// buckets start on WeekStart.
+type Calendar struct {
+	FiscalYearStartMonth int       `json:"fiscalYearStartMonth"` // 1 (January, the calendar year) to 12
+	WeekStart            WeekStart `json:"weekStart"`
+}
+
+// UpdateCalendarRequest is the request body for updating an organization's
+// calendar. Omitted fields are left unchanged.
+type UpdateCalendarRequest struct {
+	FiscalYearStartMonth *int       `json:"fiscalYearStartMonth,omitempty"`
+	WeekStart            *WeekStart `json:"weekStart,omitempty"`
+}
+
+// LocaleFormat is how numbers and dates are written in a locale, as hints
+// for clients formatting values and for CSVs spreadsheets open correctly.
+type LocaleFormat struct {
+	Locale             string `json:"locale"` // BCP 47 tag, such as de-DE
+	DecimalSeparator   string `json:"decimalSeparator"`
+	ThousandsSeparator string `json:"thousandsSeparator"`
+	DatePattern        string `json:"datePattern"`   // Unicode date pattern, such as dd.MM.yyyy
+	ListSeparator      string `json:"listSeparator"` // Separator spreadsheets of the locale expect between CSV fields
+}
+
+// UpdateLocaleRequest is the request body for setting an organization's
+// locale.
+type UpdateLocaleRequest struct {
+	Locale string `json:"locale"`
+}
+
 // ErrorResponse represents an API error.
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -0,0 +1,92 @@
+package metric
+
+import (
+	"sort"
+	"strings"
+)
+
+// countryCodes maps normalized country codes, names and aliases to ISO 3166-1 alpha-2 codes.
+var countryCodes = buildCountryCodes()
+
+func buildCountryCodes() map[string]string {
+	codes := make(map[string]string, len(isoCountries)*4+len(countryAliases))
+	for _, c := range isoCountries {
+		codes[c.alpha2] = c.alpha2
+		codes[c.alpha3] = c.alpha2
+		for _, name := range c.names {
+			codes[normalizeCountryKey(name)] = c.alpha2
+		}
+	}
+	for alias, code := range countryAliases {
+		codes[alias] = code
+	}
+	return codes
+}
+
+// normalizeCountryKey uppercases a country value and strips punctuation
+// variants, so "u.s.a." and "USA" compare equal.
+func normalizeCountryKey(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.ReplaceAll(value, ".", "")
+	value = strings.NewReplacer("_", " ", "-", " ").Replace(value)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// countryCode returns the ISO 3166-1 alpha-2 code for a country code or name.
+func countryCode(value string) (string, bool) {
+	code, ok := countryCodes[normalizeCountryKey(value)]
+	return code, ok
+}
+
+// buildGeoResult merges split totals into per-country values. Values that
+// are not recognized as a country are combined into Unmatched.
+func buildGeoResult(totals []SplitTotal, aggregation Aggregation) *GeoResult {
+	type acc struct {
+		sum   float64
+		count int
+	}
+	byCountry := make(map[string]*acc)
+	unmatched := &acc{}
+	for _, t := range totals {
+		a := unmatched
+		if code, ok := countryCode(t.Key); ok {
+			if a = byCountry[code]; a == nil {
+				a = &acc{}
+				byCountry[code] = a
+			}
+		}
+		a.sum += t.Sum
+		a.count += t.Count
+	}
+
+	value := func(a *acc) float64 {
+		switch aggregation {
+		case AggregationCount:
+			return float64(a.count)
+		case AggregationAverage:
+			if a.count == 0 {
+				return 0
+			}
+			return a.sum / float64(a.count)
+		default:
+			return a.sum
+		}
+	}
+
+	result := &GeoResult{Countries: make([]CountryValue, 0, len(byCountry))}
+	for code, a := range byCountry {
+		result.Countries = append(result.Countries, CountryValue{CountryCode: code, Value: value(a)})
+	}
+	sort.Slice(result.Countries, func(i, j int) bool {
+		if result.Countries[i].Value != result.Countries[j].Value {
+			return result.Countries[i].Value > result.Countries[j].Value
+		}
+		return result.Countries[i].CountryCode < result.Countries[j].CountryCode
+	})
+	if unmatched.count > 0 {
+		v := value(unmatched)
+		result.Unmatched = &v
+	}
+
+	return result
+}
@@ -3,16 +3,81 @@ package metric
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/devbydaniel/litekpi/internal/auth"
 	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/datasource"
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
+	"github.com/devbydaniel/litekpi/internal/platform/httperr"
+	"github.com/devbydaniel/litekpi/internal/platform/precondition"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
+// QueryErrors are the responses to invalid queries. They are shared with
+// handlers that store or run queries.
+var QueryErrors = httperr.Registry{
+	{Err: ErrMeasurementNameEmpty, Status: http.StatusBadRequest},
+	{Err: ErrInvalidTimeframe, Status: http.StatusBadRequest},
+	{Err: ErrInvalidAggregation, Status: http.StatusBadRequest},
+	{Err: ErrAggregationKeyRequired, Status: http.StatusBadRequest},
+	{Err: ErrInvalidGranularity, Status: http.StatusBadRequest},
+	{Err: ErrInvalidDisplayMode, Status: http.StatusBadRequest},
+	{Err: ErrSplitByRequired, Status: http.StatusBadRequest},
+	{Err: ErrInvalidGeoAggregation, Status: http.StatusBadRequest},
+	{Err: ErrShareOfNotSupported, Status: http.StatusBadRequest},
+	{Err: ErrShareOfAggregation, Status: http.StatusBadRequest},
+	{Err: ErrInvalidOtherThreshold, Status: http.StatusBadRequest},
+	{Err: ErrOtherThresholdSplitBy, Status: http.StatusBadRequest},
+	{Err: ErrCompositeNotSupported, Status: http.StatusBadRequest},
+	{Err: ErrInvalidCompositeSeries, Status: http.StatusBadRequest},
+	{Err: ErrTooManyCompositeSeries, Status: http.StatusBadRequest},
+	{Err: datasource.ErrDataSourceNotFound, Status: http.StatusNotFound, Message: "data source not found"},
+	{Err: datasource.ErrUnauthorized, Status: http.StatusNotFound, Message: "data source not found"},
+	{Err: datasource.ErrNoDefaultDataSource, Status: http.StatusBadRequest},
+}
+
+// ConfigErrors are the responses to invalid metric configurations,
+// including their queries. They are shared with handlers that create
+// metrics.
+var ConfigErrors = slices.Concat(httperr.Registry{
+	{Err: ErrLabelEmpty, Status: http.StatusBadRequest},
+	{Err: ErrLabelTooLong, Status: http.StatusBadRequest},
+	{Err: ErrChartTypeRequired, Status: http.StatusBadRequest},
+	{Err: ErrInvalidChartType, Status: http.StatusBadRequest},
+	{Err: ErrInvalidStacking, Status: http.StatusBadRequest},
+	{Err: ErrStackingNotSupported, Status: http.StatusBadRequest},
+	{Err: ErrInvalidComparisonType, Status: http.StatusBadRequest},
+	{Err: ErrInvalidRefreshInterval, Status: http.StatusBadRequest},
+}, QueryErrors)
+
+// ErrorResponses are the responses to the errors of the metric handlers.
+var ErrorResponses = slices.Concat(httperr.Registry{
+	{Err: ErrMetricNotFound, Status: http.StatusNotFound},
+	{Err: ErrVersionNotFound, Status: http.StatusNotFound},
+	{Err: ErrPreconditionFailed, Status: http.StatusPreconditionFailed},
+	{Err: ErrInvalidMetricOrder, Status: http.StatusBadRequest},
+	{Err: ErrFixedFields, Status: http.StatusConflict},
+	{Err: ErrInvalidDateRange, Status: http.StatusBadRequest},
+	{Err: ErrUnknownGranularity, Status: http.StatusBadRequest},
+	{Err: ErrInvalidFiscalMonth, Status: http.StatusBadRequest},
+	{Err: ErrInvalidWeekStart, Status: http.StatusBadRequest},
+	{Err: ErrUnsupportedLocale, Status: http.StatusBadRequest},
+	{Err: externalid.ErrInvalid, Status: http.StatusBadRequest},
+	{Err: externalid.ErrTaken, Status: http.StatusConflict},
+	{Err: dashboard.ErrDashboardNotFound, Status: http.StatusNotFound, Message: "dashboard not found"},
+	{Err: dashboard.ErrUnauthorized, Status: http.StatusForbidden, Message: "unauthorized"},
+}, ConfigErrors)
+
 // Handler handles HTTP requests for metrics.
 type Handler struct {
 	service          *Service
@@ -30,7 +95,7 @@ func NewHandler(service *Service, dashboardService *dashboard.Service) *Handler
 // ListMetrics handles listing all metrics for a dashboard.
 //
 //	@Summary		List dashboard metrics
-//	@Description	Get all metrics for a dashboard
+//	@Description	Get all metrics for a dashboard. Scalar metrics include a precomputed trend against their previous period once it has been refreshed in the background.
 //	@Tags			metrics
 //	@Produce		json
 //	@Security		BearerAuth
@@ -57,23 +122,13 @@ func (h *Handler) ListMetrics(w http.ResponseWriter, r *http.Request) {
 	// Verify dashboard ownership
 	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
 	if err != nil {
-		if errors.Is(err, dashboard.ErrDashboardNotFound) {
-			respondError(w, http.StatusNotFound, "dashboard not found")
-			return
-		}
-		if errors.Is(err, dashboard.ErrUnauthorized) {
-			respondError(w, http.StatusForbidden, "unauthorized")
-			return
-		}
-		log.Printf("verify dashboard ownership error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to verify dashboard")
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
 		return
 	}
 
-	metrics, err := h.service.GetByDashboardID(r.Context(), dashboardID)
+	metrics, err := h.service.ListWithTrends(r.Context(), dashboardID)
 	if err != nil {
-		log.Printf("list metrics error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to list metrics")
+		respondServiceError(w, err, "list metrics", "failed to list metrics")
 		return
 	}
 
@@ -83,7 +138,7 @@ func (h *Handler) ListMetrics(w http.ResponseWriter, r *http.Request) {
 // CreateMetric handles creating a new metric on a dashboard.
 //
 //	@Summary		Create dashboard metric
-//	@Description	Create a new metric on a dashboard. Requires editor or admin role.
+//	@Description	Create a new metric on a dashboard, optionally with an external ID unique within the dashboard. Requires editor or admin role.
 //	@Tags			metrics
 //	@Accept			json
 //	@Produce		json
@@ -95,6 +150,7 @@ func (h *Handler) ListMetrics(w http.ResponseWriter, r *http.Request) {
 //	@Failure		401		{object}	ErrorResponse
 //	@Failure		403		{object}	ErrorResponse
 //	@Failure		404		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse	"External ID in use"
 //	@Failure		500		{object}	ErrorResponse
 //	@Router			/dashboards/{id}/metrics [post]
 func (h *Handler) CreateMetric(w http.ResponseWriter, r *http.Request) {
@@ -113,73 +169,19 @@ func (h *Handler) CreateMetric(w http.ResponseWriter, r *http.Request) {
 	// Verify dashboard ownership
 	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
 	if err != nil {
-		if errors.Is(err, dashboard.ErrDashboardNotFound) {
-			respondError(w, http.StatusNotFound, "dashboard not found")
-			return
-		}
-		if errors.Is(err, dashboard.ErrUnauthorized) {
-			respondError(w, http.StatusForbidden, "unauthorized")
-			return
-		}
-		log.Printf("verify dashboard ownership error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to verify dashboard")
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
 		return
 	}
 
 	var req CreateMetricRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
 	metric, err := h.service.Create(r.Context(), user.OrganizationID, dashboardID, req)
 	if err != nil {
-		if errors.Is(err, ErrLabelEmpty) {
-			respondError(w, http.StatusBadRequest, "label is required")
-			return
-		}
-		if errors.Is(err, ErrLabelTooLong) {
-			respondError(w, http.StatusBadRequest, "label exceeds maximum length")
-			return
-		}
-		if errors.Is(err, ErrMeasurementNameEmpty) {
-			respondError(w, http.StatusBadRequest, "measurement name is required")
-			return
-		}
-		if errors.Is(err, ErrInvalidTimeframe) {
-			respondError(w, http.StatusBadRequest, "invalid timeframe")
-			return
-		}
-		if errors.Is(err, ErrInvalidAggregation) {
-			respondError(w, http.StatusBadRequest, "invalid aggregation type")
-			return
-		}
-		if errors.Is(err, ErrAggregationKeyRequired) {
-			respondError(w, http.StatusBadRequest, "aggregation_key is required for count_unique aggregation")
-			return
-		}
-		if errors.Is(err, ErrInvalidGranularity) {
-			respondError(w, http.StatusBadRequest, "granularity is required for time_series display mode")
-			return
-		}
-		if errors.Is(err, ErrInvalidDisplayMode) {
-			respondError(w, http.StatusBadRequest, "invalid display mode")
-			return
-		}
-		if errors.Is(err, ErrChartTypeRequired) {
-			respondError(w, http.StatusBadRequest, "chart_type is required for time_series display mode")
-			return
-		}
-		if errors.Is(err, ErrInvalidChartType) {
-			respondError(w, http.StatusBadRequest, "invalid chart type")
-			return
-		}
-		if errors.Is(err, ErrInvalidComparisonType) {
-			respondError(w, http.StatusBadRequest, "invalid comparison display type")
-			return
-		}
-		log.Printf("create metric error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to create metric")
+		respondServiceError(w, err, "create metric", "failed to create metric")
 		return
 	}
 
@@ -189,19 +191,21 @@ func (h *Handler) CreateMetric(w http.ResponseWriter, r *http.Request) {
 // UpdateMetric handles updating a metric.
 //
 //	@Summary		Update dashboard metric
-//	@Description	Update a metric's configuration. Requires editor or admin role.
+//	@Description	Update a metric's configuration. Send the updatedAt value last read as If-Match to fail with 412 instead of overwriting someone else's change. Requires editor or admin role.
 //	@Tags			metrics
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			id			path		string				true	"Dashboard ID"
 //	@Param			metricId	path		string				true	"Metric ID"
+//	@Param			If-Match	header		string				false	"updatedAt of the metric as last read"
 //	@Param			request		body		UpdateMetricRequest	true	"Metric data"
 //	@Success		200			{object}	Metric
 //	@Failure		400			{object}	ErrorResponse
 //	@Failure		401			{object}	ErrorResponse
 //	@Failure		403			{object}	ErrorResponse
 //	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
 //	@Failure		500			{object}	ErrorResponse
 //	@Router			/dashboards/{id}/metrics/{metricId} [put]
 func (h *Handler) UpdateMetric(w http.ResponseWriter, r *http.Request) {
@@ -226,95 +230,171 @@ func (h *Handler) UpdateMetric(w http.ResponseWriter, r *http.Request) {
 	// Verify dashboard ownership
 	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
 	if err != nil {
-		if errors.Is(err, dashboard.ErrDashboardNotFound) {
-			respondError(w, http.StatusNotFound, "dashboard not found")
-			return
-		}
-		if errors.Is(err, dashboard.ErrUnauthorized) {
-			respondError(w, http.StatusForbidden, "unauthorized")
-			return
-		}
-		log.Printf("verify dashboard ownership error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to verify dashboard")
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	var req UpdateMetricRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
 		return
 	}
 
-	metric, err := h.service.Update(r.Context(), dashboardID, metricID, req)
+	metric, err := h.service.Update(r.Context(), dashboardID, metricID, user.ID, req, ifUnmodified)
 	if err != nil {
-		if errors.Is(err, ErrMetricNotFound) {
-			respondError(w, http.StatusNotFound, "metric not found")
-			return
-		}
-		if errors.Is(err, ErrLabelEmpty) {
-			respondError(w, http.StatusBadRequest, "label is required")
-			return
-		}
-		if errors.Is(err, ErrLabelTooLong) {
-			respondError(w, http.StatusBadRequest, "label exceeds maximum length")
-			return
-		}
-		if errors.Is(err, ErrInvalidTimeframe) {
-			respondError(w, http.StatusBadRequest, "invalid timeframe")
-			return
-		}
-		if errors.Is(err, ErrInvalidAggregation) {
-			respondError(w, http.StatusBadRequest, "invalid aggregation type")
-			return
-		}
-		if errors.Is(err, ErrAggregationKeyRequired) {
-			respondError(w, http.StatusBadRequest, "aggregation_key is required for count_unique aggregation")
-			return
-		}
-		if errors.Is(err, ErrInvalidGranularity) {
-			respondError(w, http.StatusBadRequest, "granularity is required for time_series display mode")
-			return
-		}
-		if errors.Is(err, ErrInvalidDisplayMode) {
-			respondError(w, http.StatusBadRequest, "invalid display mode")
-			return
-		}
-		if errors.Is(err, ErrChartTypeRequired) {
-			respondError(w, http.StatusBadRequest, "chart_type is required for time_series display mode")
-			return
-		}
-		if errors.Is(err, ErrInvalidChartType) {
-			respondError(w, http.StatusBadRequest, "invalid chart type")
-			return
-		}
-		if errors.Is(err, ErrInvalidComparisonType) {
-			respondError(w, http.StatusBadRequest, "invalid comparison display type")
+		respondUpdateError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, metric)
+}
+
+// GetMetricByExternalID handles getting a metric by its external ID.
+//
+//	@Summary		Get dashboard metric by external ID
+//	@Description	Get a metric of a dashboard by the external ID it was created or put with
+//	@Tags			metrics
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		string	true	"Dashboard ID"
+//	@Param			externalId		path		string	true	"External ID"
+//	@Param			includeHistory	query		bool	false	"Include the write history of the metric"
+//	@Success		200				{object}	Metric
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		403				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/dashboards/{id}/metrics/external/{externalId} [get]
+func (h *Handler) GetMetricByExternalID(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	// Verify dashboard ownership
+	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
+		return
+	}
+
+	metric, err := h.service.GetByExternalID(r.Context(), dashboardID, chi.URLParam(r, "externalId"))
+	if err != nil {
+		respondServiceError(w, err, "get metric by external ID", "failed to get metric")
+		return
+	}
+
+	if writeaudit.IncludeHistory(r) {
+		metric.History, err = h.service.History(r.Context(), user.OrganizationID, metric.ID)
+		if err != nil {
+			log.Printf("get metric history error: %v", err)
+			respondError(w, http.StatusInternalServerError, "failed to get metric history")
 			return
 		}
-		log.Printf("update metric error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to update metric")
-		return
 	}
 
 	respondJSON(w, http.StatusOK, metric)
 }
 
-// DeleteMetric handles deleting a metric.
+// PutMetric handles creating or updating a metric by its external ID.
 //
-//	@Summary		Delete dashboard metric
-//	@Description	Delete a metric from a dashboard. Requires editor or admin role.
+//	@Summary		Create or update dashboard metric by external ID
+//	@Description	Create the metric with the given external ID on a dashboard, or update it if it exists, so that clients such as Terraform providers can converge it without tracking its ID. Putting the same request again changes nothing. A metric's data source and measurement cannot be changed; delete it and put it again instead. Requires editor or admin role.
+//	@Tags			metrics
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string				true	"Dashboard ID"
+//	@Param			externalId	path		string				true	"External ID"
+//	@Param			request		body		CreateMetricRequest	true	"Metric data"
+//	@Success		200			{object}	Metric				"Updated or unchanged"
+//	@Success		201			{object}	Metric				"Created"
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse	"Data source or measurement changed"
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/metrics/external/{externalId} [put]
+func (h *Handler) PutMetric(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	// Verify dashboard ownership
+	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
+		return
+	}
+
+	var req CreateMetricRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	metric, created, err := h.service.Put(r.Context(), user.OrganizationID, dashboardID, user.ID, chi.URLParam(r, "externalId"), req)
+	if err != nil {
+		respondUpdateError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondJSON(w, status, metric)
+}
+
+// respondUpdateError writes the response for an error from updating a metric.
+func respondUpdateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrPreconditionFailed) {
+		respondError(w, http.StatusPreconditionFailed, "metric was modified since it was read")
+		return
+	}
+	respondServiceError(w, err, "update metric", "failed to update metric")
+}
+
+// ListMetricVersions handles listing the previous configurations of a metric.
+//
+//	@Summary		List metric versions
+//	@Description	Get the previous configurations of a metric, newest first. A version is recorded on every update; the last 50 are kept.
 //	@Tags			metrics
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			id			path		string	true	"Dashboard ID"
 //	@Param			metricId	path		string	true	"Metric ID"
-//	@Success		200			{object}	MessageResponse
+//	@Success		200			{object}	ListMetricVersionsResponse
+//	@Failure		400			{object}	ErrorResponse
 //	@Failure		401			{object}	ErrorResponse
 //	@Failure		403			{object}	ErrorResponse
 //	@Failure		404			{object}	ErrorResponse
 //	@Failure		500			{object}	ErrorResponse
-//	@Router			/dashboards/{id}/metrics/{metricId} [delete]
-func (h *Handler) DeleteMetric(w http.ResponseWriter, r *http.Request) {
+//	@Router			/dashboards/{id}/metrics/{metricId}/versions [get]
+func (h *Handler) ListMetricVersions(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
 	if user == nil {
 		respondError(w, http.StatusUnauthorized, "unauthorized")
@@ -336,48 +416,39 @@ func (h *Handler) DeleteMetric(w http.ResponseWriter, r *http.Request) {
 	// Verify dashboard ownership
 	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
 	if err != nil {
-		if errors.Is(err, dashboard.ErrDashboardNotFound) {
-			respondError(w, http.StatusNotFound, "dashboard not found")
-			return
-		}
-		if errors.Is(err, dashboard.ErrUnauthorized) {
-			respondError(w, http.StatusForbidden, "unauthorized")
-			return
-		}
-		log.Printf("verify dashboard ownership error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to verify dashboard")
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
 		return
 	}
 
-	err = h.service.Delete(r.Context(), dashboardID, metricID)
+	versions, err := h.service.ListVersions(r.Context(), dashboardID, metricID)
 	if err != nil {
-		if errors.Is(err, ErrMetricNotFound) {
-			respondError(w, http.StatusNotFound, "metric not found")
-			return
-		}
-		log.Printf("delete metric error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to delete metric")
+		respondServiceError(w, err, "list metric versions", "failed to list metric versions")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, MessageResponse{Message: "metric deleted"})
+	respondJSON(w, http.StatusOK, ListMetricVersionsResponse{Versions: versions})
 }
 
-// ComputeMetrics handles computing metric values for a dashboard.
+// RevertMetricVersion handles restoring a previous configuration of a metric.
 //
-//	@Summary		Compute dashboard metrics
-//	@Description	Get computed values for all metrics on a dashboard
+//	@Summary		Revert metric to version
+//	@Description	Restore a previous configuration of a metric. The configuration it replaces is recorded as a new version, so a revert can be undone. Requires editor or admin role.
 //	@Tags			metrics
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id	path		string	true	"Dashboard ID"
-//	@Success		200	{object}	ComputeMetricsResponse
-//	@Failure		401	{object}	ErrorResponse
-//	@Failure		403	{object}	ErrorResponse
-//	@Failure		404	{object}	ErrorResponse
-//	@Failure		500	{object}	ErrorResponse
-//	@Router			/dashboards/{id}/metrics/compute [get]
-func (h *Handler) ComputeMetrics(w http.ResponseWriter, r *http.Request) {
+//	@Param			id			path		string	true	"Dashboard ID"
+//	@Param			metricId	path		string	true	"Metric ID"
+//	@Param			version		path		int		true	"Version"
+//	@Param			If-Match	header		string	false	"updatedAt of the metric as last read"
+//	@Success		200			{object}	Metric
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/metrics/{metricId}/versions/{version}/revert [post]
+func (h *Handler) RevertMetricVersion(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
 	if user == nil {
 		respondError(w, http.StatusUnauthorized, "unauthorized")
@@ -390,57 +461,56 @@ func (h *Handler) ComputeMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify dashboard ownership
-	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
+	metricID, err := uuid.Parse(chi.URLParam(r, "metricId"))
 	if err != nil {
-		if errors.Is(err, dashboard.ErrDashboardNotFound) {
-			respondError(w, http.StatusNotFound, "dashboard not found")
-			return
-		}
-		if errors.Is(err, dashboard.ErrUnauthorized) {
-			respondError(w, http.StatusForbidden, "unauthorized")
-			return
-		}
-		log.Printf("verify dashboard ownership error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to verify dashboard")
+		respondError(w, http.StatusBadRequest, "invalid metric ID")
 		return
 	}
 
-	metrics, err := h.service.GetByDashboardID(r.Context(), dashboardID)
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		respondError(w, http.StatusBadRequest, "invalid version")
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Verify dashboard ownership
+	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
 	if err != nil {
-		log.Printf("list metrics error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to list metrics")
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
 		return
 	}
 
-	computed, err := h.service.Compute(r.Context(), metrics)
+	metric, err := h.service.RevertToVersion(r.Context(), dashboardID, metricID, user.ID, version, ifUnmodified)
 	if err != nil {
-		log.Printf("compute metrics error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to compute metrics")
+		respondUpdateError(w, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, ComputeMetricsResponse{Metrics: computed})
+	respondJSON(w, http.StatusOK, metric)
 }
 
-// ReorderMetrics handles reordering metrics on a dashboard.
+// DeleteMetric handles deleting a metric.
 //
-//	@Summary		Reorder dashboard metrics
-//	@Description	Reorder metrics on a dashboard. Requires editor or admin role.
+//	@Summary		Delete dashboard metric
+//	@Description	Move a metric to the trash, where it can be restored for 30 days. Requires editor or admin role.
 //	@Tags			metrics
-//	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id		path		string					true	"Dashboard ID"
-//	@Param			request	body		ReorderMetricsRequest	true	"Metric order"
-//	@Success		200		{object}	MessageResponse
-//	@Failure		400		{object}	ErrorResponse
-//	@Failure		401		{object}	ErrorResponse
-//	@Failure		403		{object}	ErrorResponse
-//	@Failure		404		{object}	ErrorResponse
-//	@Failure		500		{object}	ErrorResponse
-//	@Router			/dashboards/{id}/metrics/reorder [put]
-func (h *Handler) ReorderMetrics(w http.ResponseWriter, r *http.Request) {
+//	@Param			id			path		string	true	"Dashboard ID"
+//	@Param			metricId	path		string	true	"Metric ID"
+//	@Success		200			{object}	MessageResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/metrics/{metricId} [delete]
+func (h *Handler) DeleteMetric(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
 	if user == nil {
 		respondError(w, http.StatusUnauthorized, "unauthorized")
@@ -453,43 +523,617 @@ func (h *Handler) ReorderMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	metricID, err := uuid.Parse(chi.URLParam(r, "metricId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid metric ID")
+		return
+	}
+
 	// Verify dashboard ownership
 	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
 	if err != nil {
-		if errors.Is(err, dashboard.ErrDashboardNotFound) {
-			respondError(w, http.StatusNotFound, "dashboard not found")
-			return
-		}
-		if errors.Is(err, dashboard.ErrUnauthorized) {
-			respondError(w, http.StatusForbidden, "unauthorized")
-			return
-		}
-		log.Printf("verify dashboard ownership error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to verify dashboard")
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
 		return
 	}
 
-	var req ReorderMetricsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	err = h.service.Delete(r.Context(), dashboardID, metricID)
+	if err != nil {
+		respondServiceError(w, err, "delete metric", "failed to delete metric")
 		return
 	}
 
-	if err := h.service.Reorder(r.Context(), dashboardID, req.MetricIDs); err != nil {
-		log.Printf("reorder metrics error: %v", err)
-		respondError(w, http.StatusInternalServerError, "failed to reorder metrics")
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "metric deleted"})
+}
+
+// ComputeMetrics handles computing metric values for a dashboard.
+//
+//	@Summary		Compute dashboard metrics
+//	@Description	Get computed values for all metrics on a dashboard. Metrics that fail to compute carry an error message instead of failing the whole response. The dashboard's compute settings decide how many metrics are computed in parallel and how long values are served from cache (cacheStatus hit). The response includes computedAt, the time of the oldest value, and a suggested refreshAfterSeconds, also sent as Cache-Control max-age. The current user's dashboard preferences are merged in: hidden metrics are left out and a preferred timeframe replaces each metric's own, unless ignorePreferences is true. locale holds formatting hints from the organization's locale.
+//	@Tags			metrics
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id					path		string	true	"Dashboard ID"
+//	@Param			ignorePreferences	query		bool	false	"Compute the dashboard as saved"
+//	@Success		200					{object}	ComputeMetricsResponse
+//	@Failure		401					{object}	ErrorResponse
+//	@Failure		403					{object}	ErrorResponse
+//	@Failure		404					{object}	ErrorResponse
+//	@Failure		500					{object}	ErrorResponse
+//	@Router			/dashboards/{id}/metrics/compute [get]
+func (h *Handler) ComputeMetrics(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, MessageResponse{Message: "metrics reordered"})
-}
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
 
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
-}
+	// Verify dashboard ownership
+	d, err := h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
+		return
+	}
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, ErrorResponse{Error: message})
+	metrics, err := h.service.GetByDashboardID(r.Context(), dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "list metrics", "failed to list metrics")
+		return
+	}
+
+	if r.URL.Query().Get("ignorePreferences") != "true" {
+		prefs, err := h.dashboardService.GetPreferences(r.Context(), user.OrganizationID, user.ID, dashboardID)
+		if err != nil {
+			respondServiceError(w, err, "get dashboard preferences", "failed to get dashboard preferences")
+			return
+		}
+		metrics = applyPreferences(metrics, prefs)
+	}
+
+	var opts ComputeOptions
+	if d.ComputeConcurrency != nil {
+		opts.Concurrency = *d.ComputeConcurrency
+	}
+	if d.CacheTTLSeconds != nil {
+		opts.CacheTTL = time.Duration(*d.CacheTTLSeconds) * time.Second
+	}
+
+	computed := h.service.ComputeDashboard(r.Context(), user.OrganizationID, metrics, opts)
+	resp := ComputeMetricsResponse{Metrics: computed, ComputedAt: time.Now().UTC(), RefreshAfterSeconds: MaxRefreshIntervalSeconds}
+	for _, c := range computed {
+		if c.err != nil {
+			log.Printf("compute metric error: %v", c.err)
+		}
+		// Report the oldest value, which may come from the cache
+		if c.ComputedAt.Before(resp.ComputedAt) {
+			resp.ComputedAt = c.ComputedAt
+		}
+		resp.RefreshAfterSeconds = min(resp.RefreshAfterSeconds, c.RefreshAfterSeconds)
+	}
+	// Formatting hints are cosmetic, so lookup errors only leave them out
+	if locale, err := h.service.GetLocale(r.Context(), user.OrganizationID); err == nil {
+		resp.Locale = locale
+	} else {
+		log.Printf("get locale error: %v", err)
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", resp.RefreshAfterSeconds))
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// applyPreferences merges a user's dashboard preferences into its metrics:
+// hidden metrics are left out and a preferred timeframe replaces the saved
+// one.
+func applyPreferences(metrics []Metric, prefs *dashboard.Preferences) []Metric {
+	merged := make([]Metric, 0, len(metrics))
+	for _, m := range metrics {
+		if prefs.Hides(m.ID) {
+			continue
+		}
+		if prefs.Timeframe != nil {
+			m.Timeframe = *prefs.Timeframe
+			m.DateFrom = nil
+			m.DateTo = nil
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// GetMetricData handles downloading the computed data of a single metric.
+//
+//	@Summary		Download metric data
+//	@Description	Compute a single metric and return its series or scalar as JSON or CSV, without computing the rest of the dashboard. CSV columns depend on the display mode. With a locale, the CSV uses the field and decimal separators spreadsheets of that locale expect, such as ; and , for de-DE.
+//	@Tags			metrics
+//	@Produce		json
+//	@Produce		text/csv
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Dashboard ID"
+//	@Param			metricId	path		string	true	"Metric ID"
+//	@Param			format		query		string	false	"Response format (json, csv)"	default(json)
+//	@Param			locale		query		string	false	"Locale of the CSV, such as de-DE"
+//	@Success		200			{object}	ComputedMetric
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/metrics/{metricId}/data [get]
+func (h *Handler) GetMetricData(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	metricID, err := uuid.Parse(chi.URLParam(r, "metricId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid metric ID")
+		return
+	}
+
+	format := DataFormatJSON
+	if f := r.URL.Query().Get("format"); f != "" {
+		format = DataFormat(f)
+	}
+	if !format.IsValid() {
+		respondError(w, http.StatusBadRequest, ErrInvalidDataFormat.Error())
+		return
+	}
+	var locale *LocaleFormat
+	if l := r.URL.Query().Get("locale"); l != "" {
+		f, err := LookupLocale(l)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		locale = &f
+	}
+
+	// Verify dashboard ownership
+	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
+		return
+	}
+
+	computed, err := h.service.ComputeByID(r.Context(), user.OrganizationID, dashboardID, metricID)
+	if err != nil {
+		respondServiceError(w, err, "compute metric", "failed to compute metric")
+		return
+	}
+	if computed.err != nil {
+		log.Printf("compute metric error: %v", computed.err)
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", computed.RefreshAfterSeconds))
+	if format == DataFormatJSON {
+		respondJSON(w, http.StatusOK, computed)
+		return
+	}
+
+	// A CSV has no room for an error field, so failures are reported as errors
+	if computed.Error != nil {
+		respondError(w, http.StatusInternalServerError, *computed.Error)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", csvFilename(*computed)))
+	w.WriteHeader(http.StatusOK)
+	if err := writeCSV(w, *computed, locale); err != nil {
+		log.Printf("write metric csv error: %v", err)
+	}
+}
+
+// ReorderMetrics handles reordering metrics on a dashboard.
+//
+//	@Summary		Reorder dashboard metrics
+//	@Description	Reorder metrics on a dashboard. metricIds must list every metric on the dashboard. Send the dashboard's updatedAt as If-Match to fail with 412 if someone else changed it; reordering bumps it. Requires editor or admin role.
+//	@Tags			metrics
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string					true	"Dashboard ID"
+//	@Param			If-Match	header		string					false	"updatedAt of the dashboard as last read"
+//	@Param			request		body		ReorderMetricsRequest	true	"Metric order"
+//	@Success		200			{object}	MessageResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/dashboards/{id}/metrics/reorder [put]
+func (h *Handler) ReorderMetrics(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	// Verify dashboard ownership
+	_, err = h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req ReorderMetricsRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	if err := h.service.Reorder(r.Context(), dashboardID, req.MetricIDs, ifUnmodified); err != nil {
+		if errors.Is(err, ErrPreconditionFailed) {
+			respondError(w, http.StatusPreconditionFailed, "dashboard was modified since it was read")
+			return
+		}
+		respondServiceError(w, err, "reorder metrics", "failed to reorder metrics")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "metrics reordered"})
+}
+
+// GetMetricDefaults handles getting the organization's defaults for new metrics.
+//
+//	@Summary		Get metric defaults
+//	@Description	Get the defaults that fill in fields omitted when creating a metric
+//	@Tags			metrics
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	MetricDefaults
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/metric-defaults [get]
+func (h *Handler) GetMetricDefaults(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	defaults, err := h.service.GetDefaults(r.Context(), user.OrganizationID)
+	if err != nil {
+		respondServiceError(w, err, "get metric defaults", "failed to get metric defaults")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, defaults)
+}
+
+// UpdateMetricDefaults handles replacing the organization's defaults for new metrics.
+//
+//	@Summary		Update metric defaults
+//	@Description	Replace the default timeframe, granularity, chart type and comparison of new metrics (admin only). Null leaves a field without default.
+//	@Tags			metrics
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		UpdateMetricDefaultsRequest	true	"Metric defaults"
+//	@Success		200		{object}	MetricDefaults
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/organization/metric-defaults [put]
+func (h *Handler) UpdateMetricDefaults(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateMetricDefaultsRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	defaults, err := h.service.UpdateDefaults(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		respondServiceError(w, err, "update metric defaults", "failed to update metric defaults")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, defaults)
+}
+
+// GetCalendar handles getting the organization's calendar.
+//
+//	@Summary		Get calendar
+//	@Description	Get the month the organization's fiscal year starts in, which fiscal timeframes follow, and the day weekly buckets start on
+//	@Tags			metrics
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Calendar
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/calendar [get]
+func (h *Handler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	cal, err := h.service.GetCalendar(r.Context(), user.OrganizationID)
+	if err != nil {
+		respondServiceError(w, err, "get calendar", "failed to get calendar")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, cal)
+}
+
+// UpdateCalendar handles updating the organization's calendar.
+//
+//	@Summary		Update calendar
+//	@Description	Set the month the organization's fiscal year starts in or the day weeks start on (admin only). Omitted fields are left unchanged.
+//	@Tags			metrics
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		UpdateCalendarRequest	true	"Calendar"
+//	@Success		200		{object}	Calendar
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/organization/calendar [put]
+func (h *Handler) UpdateCalendar(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateCalendarRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	cal, err := h.service.UpdateCalendar(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		respondServiceError(w, err, "update calendar", "failed to update calendar")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, cal)
+}
+
+// GetLocale handles getting the organization's locale.
+//
+//	@Summary		Get locale
+//	@Description	Get the organization's locale with its number and date formatting
+//	@Tags			metrics
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	LocaleFormat
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/locale [get]
+func (h *Handler) GetLocale(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	locale, err := h.service.GetLocale(r.Context(), user.OrganizationID)
+	if err != nil {
+		respondServiceError(w, err, "get locale", "failed to get locale")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, locale)
+}
+
+// UpdateLocale handles setting the organization's locale.
+//
+//	@Summary		Update locale
+//	@Description	Set the organization's locale, such as de-DE, which compute responses return formatting hints for (admin only)
+//	@Tags			metrics
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		UpdateLocaleRequest	true	"Locale"
+//	@Success		200		{object}	LocaleFormat
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/organization/locale [put]
+func (h *Handler) UpdateLocale(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateLocaleRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	locale, err := h.service.UpdateLocale(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		respondServiceError(w, err, "update locale", "failed to update locale")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, locale)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
+
+// respondServiceError writes the response for an error of the metric
+// handlers, logging the errors ErrorResponses does not map.
+func respondServiceError(w http.ResponseWriter, err error, op, message string) {
+	if ErrorResponses.Respond(w, err) {
+		return
+	}
+	log.Printf("%s error: %v", op, err)
+	respondError(w, http.StatusInternalServerError, message)
+}
+
+// Explore handles computing an ad-hoc query without creating a metric.
+//
+//	@Summary		Explore measurements
+//	@Description	Compute an arbitrary measurement query (aggregation, granularity, filters, split-by) without saving it
+//	@Tags			explore
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		ExploreQuery	true	"Query"
+//	@Success		200		{object}	ExploreResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/explore [post]
+func (h *Handler) Explore(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var q ExploreQuery
+	if err := validate.DecodeJSON(r, &q); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	result, err := h.service.Explore(r.Context(), user.OrganizationID, q)
+	if err != nil {
+		respondServiceError(w, err, "explore", "failed to explore")
+		return
+	}
+	if result.err != nil {
+		log.Printf("explore compute error: %v", result.err)
+	}
+
+	respondJSON(w, http.StatusOK, ExploreResponse{Result: *result})
+}
+
+// ComparePeriods handles comparing a query across two explicit date ranges.
+//
+//	@Summary		Compare periods
+//	@Description	Compute a query as a single value for two explicit date ranges, e.g. this year's Black Friday week against last year's, and return both values with the absolute and percentage change. Both ranges are whole UTC days with inclusive ends.
+//	@Tags			explore
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		ComparePeriodsRequest	true	"Query and date ranges"
+//	@Success		200		{object}	ComparePeriodsResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Failure		504		{object}	ErrorResponse
+//	@Router			/explore/compare [post]
+func (h *Handler) ComparePeriods(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ComparePeriodsRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	resp, err := h.service.ComparePeriods(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		if isQueryTimeout(err) {
+			respondError(w, http.StatusGatewayTimeout, computeErrTimeout)
+			return
+		}
+		respondServiceError(w, err, "compare periods", "failed to compare periods")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// SaveExploration handles saving an exploration as a metric on a dashboard.
+//
+//	@Summary		Save exploration as metric
+//	@Description	Save an exploration query as a new metric on a dashboard. Requires editor or admin role.
+//	@Tags			explore
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		SaveExplorationRequest	true	"Exploration to save"
+//	@Success		201		{object}	Metric
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/explore/save [post]
+func (h *Handler) SaveExploration(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req SaveExplorationRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	// Verify dashboard ownership
+	_, err := h.dashboardService.VerifyDashboardOwnership(r.Context(), user.OrganizationID, req.DashboardID)
+	if err != nil {
+		respondServiceError(w, err, "verify dashboard ownership", "failed to verify dashboard")
+		return
+	}
+
+	metric, err := h.service.SaveExploration(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		respondServiceError(w, err, "save exploration", "failed to save exploration")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, metric)
 }
@@ -0,0 +1,60 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// DefaultLocale is the locale of organizations that have not set one.
+const DefaultLocale = "en-US"
+
+// localeFormats are the supported locales, keyed by BCP 47 tag.
+var localeFormats = map[string]LocaleFormat{
+	"en-US": {Locale: "en-US", DecimalSeparator: ".", ThousandsSeparator: ",", DatePattern: "MM/dd/yyyy", ListSeparator: ","},
+	"en-GB": {Locale: "en-GB", DecimalSeparator: ".", ThousandsSeparator: ",", DatePattern: "dd/MM/yyyy", ListSeparator: ","},
+	"de-DE": {Locale: "de-DE", DecimalSeparator: ",", ThousandsSeparator: ".", DatePattern: "dd.MM.yyyy", ListSeparator: ";"},
+	"de-CH": {Locale: "de-CH", DecimalSeparator: ".", ThousandsSeparator: "’", DatePattern: "dd.MM.yyyy", ListSeparator: ";"},
+	"fr-FR": {Locale: "fr-FR", DecimalSeparator: ",", ThousandsSeparator: " ", DatePattern: "dd/MM/yyyy", ListSeparator: ";"},
+	"es-ES": {Locale: "es-ES", DecimalSeparator: ",", ThousandsSeparator: ".", DatePattern: "dd/MM/yyyy", ListSeparator: ";"},
+	"it-IT": {Locale: "it-IT", DecimalSeparator: ",", ThousandsSeparator: ".", DatePattern: "dd/MM/yyyy", ListSeparator: ";"},
+	"nl-NL": {Locale: "nl-NL", DecimalSeparator: ",", ThousandsSeparator: ".", DatePattern: "dd-MM-yyyy", ListSeparator: ";"},
+	"pt-BR": {Locale: "pt-BR", DecimalSeparator: ",", ThousandsSeparator: ".", DatePattern: "dd/MM/yyyy", ListSeparator: ";"},
+	"sv-SE": {Locale: "sv-SE", DecimalSeparator: ",", ThousandsSeparator: " ", DatePattern: "yyyy-MM-dd", ListSeparator: ";"},
+	"ja-JP": {Locale: "ja-JP", DecimalSeparator: ".", ThousandsSeparator: ",", DatePattern: "yyyy/MM/dd", ListSeparator: ","},
+}
+
+// LookupLocale returns the format of a supported locale.
+func LookupLocale(locale string) (LocaleFormat, error) {
+	f, ok := localeFormats[locale]
+	if !ok {
+		return LocaleFormat{}, ErrUnsupportedLocale
+	}
+	return f, nil
+}
+
+// GetLocale returns the format of an organization's locale.
+func (s *Service) GetLocale(ctx context.Context, orgID uuid.UUID) (*LocaleFormat, error) {
+	locale, err := s.repo.GetLocale(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locale: %w", err)
+	}
+	f, ok := localeFormats[locale]
+	if !ok {
+		f = localeFormats[DefaultLocale]
+	}
+	return &f, nil
+}
+
+// UpdateLocale sets an organization's locale.
+func (s *Service) UpdateLocale(ctx context.Context, orgID uuid.UUID, req UpdateLocaleRequest) (*LocaleFormat, error) {
+	f, err := LookupLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateLocale(ctx, orgID, req.Locale); err != nil {
+		return nil, fmt.Errorf("failed to update locale: %w", err)
+	}
+	return &f, nil
+}
@@ -0,0 +1,92 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ExpectedPace estimates which share of its timeframe's total m usually has
+// reached at now, from how the total accrued day by day over the previous
+// periods. It returns nil if m has no pace: only sums and counts over a
+// calendar month or a custom range accrue towards a total, and only while
+// now is within the range.
+func (s *Service) ExpectedPace(ctx context.Context, m Metric, now time.Time) (*Pace, error) {
+	start, end, ok := pacePeriod(m)
+	if !ok || !now.After(start) || !now.Before(end) {
+		return nil, nil
+	}
+	if m.ShareOf != nil || (m.Aggregation != AggregationSum && m.Aggregation != AggregationCount) {
+		return nil, nil
+	}
+
+	pace := &Pace{Elapsed: float64(now.Sub(start)) / float64(end.Sub(start)), Basis: PaceBasisLinear}
+	pace.ExpectedShare = pace.Elapsed
+
+	filters := make(map[string]string)
+	for _, f := range m.Filters {
+		filters[f.Key] = f.Value
+	}
+	daily := GranularityDaily
+	hm := m
+	hm.Granularity = &daily
+
+	var reached, total float64
+	for k := 1; k <= paceHistoryPeriods; k++ {
+		periodStart, periodEnd := previousPacePeriod(m, start, end, k)
+		dataPoints, err := s.getTimeSeriesData(ctx, hm, periodStart, periodEnd, filters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get history of period %d: %w", k, err)
+		}
+
+		days := bucketsBetween(periodStart, periodEnd, daily)
+		position := pace.Elapsed * float64(days) // Days into the period, fractional
+		for _, dp := range dataPoints {
+			day, err := parseBucketDate(dp.Date, daily)
+			if err != nil {
+				continue
+			}
+			i := float64(bucketsBetween(periodStart, day, daily))
+			switch {
+			case i+1 <= position:
+				reached += dp.Value
+			case i < position:
+				reached += dp.Value * (position - i)
+			}
+			total += dp.Value
+		}
+	}
+
+	if total > 0 {
+		pace.ExpectedShare = reached / total
+		pace.Basis = PaceBasisHistorical
+	}
+	return pace, nil
+}
+
+// pacePeriod returns the whole period of m's timeframe, which for this
+// month extends past today to the end of the month.
+func pacePeriod(m Metric) (start, end time.Time, ok bool) {
+	switch m.Timeframe {
+	case "this_month":
+		start, _ = getTimeframeRange(m.Timeframe, nil, nil, m.calendar)
+		return start, start.AddDate(0, 1, 0), true
+	case "custom":
+		if m.DateFrom == nil || m.DateTo == nil {
+			return time.Time{}, time.Time{}, false
+		}
+		start, end = getTimeframeRange(m.Timeframe, m.DateFrom, m.DateTo, m.calendar)
+		return start, end, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// previousPacePeriod returns the kth period before [start, end): the kth
+// previous month, or the kth range of the same length before a custom one.
+func previousPacePeriod(m Metric, start, end time.Time, k int) (time.Time, time.Time) {
+	if m.Timeframe == "this_month" {
+		return start.AddDate(0, -k, 0), start.AddDate(0, -k+1, 0)
+	}
+	shift := time.Duration(k) * end.Sub(start)
+	return start.Add(-shift), end.Add(-shift)
+}
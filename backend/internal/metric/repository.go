@@ -10,7 +10,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
 )
 
 // Repository handles database operations for metrics.
@@ -23,7 +26,8 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
-// Create creates a new metric.
+// Create creates a new metric. It returns externalid.ErrTaken if another
+// metric of the dashboard has the external ID.
 func (r *Repository) Create(ctx context.Context, dashboardID, dataSourceID uuid.UUID, req CreateMetricRequest, position int) (*Metric, error) {
 	filtersJSON, err := json.Marshal(req.Filters)
 	if err != nil {
@@ -32,28 +36,42 @@ func (r *Repository) Create(ctx context.Context, dashboardID, dataSourceID uuid.
 	if req.Filters == nil {
 		filtersJSON = []byte("[]")
 	}
+	shareOfJSON, err := marshalShareOf(req.ShareOf)
+	if err != nil {
+		return nil, err
+	}
+	compositeJSON, err := marshalCompositeSeries(req.CompositeSeries)
+	if err != nil {
+		return nil, err
+	}
 
 	m := &Metric{
-		ID:                    uuid.New(),
-		DashboardID:           dashboardID,
-		DataSourceID:          dataSourceID,
-		Label:                 req.Label,
-		MeasurementName:       req.MeasurementName,
-		Timeframe:             req.Timeframe,
-		DateFrom:              req.DateFrom,
-		DateTo:                req.DateTo,
-		Filters:               req.Filters,
-		Aggregation:           req.Aggregation,
-		AggregationKey:        req.AggregationKey,
-		Granularity:           req.Granularity,
-		DisplayMode:           req.DisplayMode,
-		ComparisonEnabled:     req.ComparisonEnabled,
-		ComparisonDisplayType: req.ComparisonDisplayType,
-		ChartType:             req.ChartType,
-		SplitBy:               req.SplitBy,
-		Position:              position,
-		CreatedAt:             time.Now(),
-		UpdatedAt:             time.Now(),
+		ID:                     uuid.New(),
+		DashboardID:            dashboardID,
+		DataSourceID:           dataSourceID,
+		Label:                  req.Label,
+		MeasurementName:        req.MeasurementName,
+		Timeframe:              req.Timeframe,
+		DateFrom:               req.DateFrom,
+		DateTo:                 req.DateTo,
+		Filters:                req.Filters,
+		Aggregation:            req.Aggregation,
+		AggregationKey:         req.AggregationKey,
+		Granularity:            req.Granularity,
+		DisplayMode:            req.DisplayMode,
+		ComparisonEnabled:      req.ComparisonEnabled != nil && *req.ComparisonEnabled,
+		ComparisonDisplayType:  req.ComparisonDisplayType,
+		ShareOf:                unmarshalShareOf(shareOfJSON),
+		CompositeSeries:        unmarshalCompositeSeries(compositeJSON),
+		ChartType:              req.ChartType,
+		SplitBy:                req.SplitBy,
+		Stacking:               req.Stacking,
+		OtherThreshold:         req.OtherThreshold,
+		RefreshIntervalSeconds: req.RefreshIntervalSeconds,
+		ExternalID:             req.ExternalID,
+		Position:               position,
+		CreatedAt:              time.Now(),
+		UpdatedAt:              time.Now(),
 	}
 
 	if m.Filters == nil {
@@ -61,10 +79,13 @@ func (r *Repository) Create(ctx context.Context, dashboardID, dataSourceID uuid.
 	}
 
 	_, err = r.pool.Exec(ctx,
-		`INSERT INTO metrics (id, dashboard_id, data_source_id, label, measurement_name, timeframe, date_from, date_to, filters, aggregation, aggregation_key, granularity, display_mode, comparison_enabled, comparison_display_type, chart_type, split_by, position, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`,
-		m.ID, m.DashboardID, m.DataSourceID, m.Label, m.MeasurementName, m.Timeframe, m.DateFrom, m.DateTo, filtersJSON, m.Aggregation, m.AggregationKey, m.Granularity, m.DisplayMode, m.ComparisonEnabled, m.ComparisonDisplayType, m.ChartType, m.SplitBy, m.Position, m.CreatedAt, m.UpdatedAt,
+		`INSERT INTO metrics (id, dashboard_id, data_source_id, label, measurement_name, timeframe, date_from, date_to, filters, aggregation, aggregation_key, granularity, display_mode, comparison_enabled, comparison_display_type, chart_type, split_by, stacking, share_of, composite_series, other_threshold, refresh_interval_seconds, external_id, position, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)`,
+		m.ID, m.DashboardID, m.DataSourceID, m.Label, m.MeasurementName, m.Timeframe, m.DateFrom, m.DateTo, filtersJSON, m.Aggregation, m.AggregationKey, m.Granularity, m.DisplayMode, m.ComparisonEnabled, m.ComparisonDisplayType, m.ChartType, m.SplitBy, m.Stacking, shareOfJSON, compositeJSON, m.OtherThreshold, m.RefreshIntervalSeconds, m.ExternalID, m.Position, m.CreatedAt, m.UpdatedAt,
 	)
+	if externalid.IsTaken(err, "idx_metrics_external_id") {
+		return nil, externalid.ErrTaken
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -74,16 +95,26 @@ func (r *Repository) Create(ctx context.Context, dashboardID, dataSourceID uuid.
 
 // GetByID retrieves a metric by its ID.
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Metric, error) {
+	return r.getOne(ctx, `WHERE id = $1 AND deleted_at IS NULL`, id)
+}
+
+// GetByExternalID retrieves a metric of a dashboard by its external ID.
+func (r *Repository) GetByExternalID(ctx context.Context, dashboardID uuid.UUID, externalID string) (*Metric, error) {
+	return r.getOne(ctx, `WHERE dashboard_id = $1 AND external_id = $2 AND deleted_at IS NULL`, dashboardID, externalID)
+}
+
+// getOne retrieves the metric matching a WHERE clause.
+func (r *Repository) getOne(ctx context.Context, where string, args ...any) (*Metric, error) {
 	m := &Metric{}
-	var filtersJSON []byte
+	var filtersJSON, shareOfJSON, compositeJSON []byte
 	var aggregation, displayMode string
 	var granularity *string
-	var comparisonDisplayType, chartType *string
+	var comparisonDisplayType, chartType, stacking *string
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, dashboard_id, data_source_id, label, measurement_name, timeframe, date_from, date_to, filters, aggregation, aggregation_key, granularity, display_mode, comparison_enabled, comparison_display_type, chart_type, split_by, position, created_at, updated_at
-		FROM metrics WHERE id = $1`,
-		id,
-	).Scan(&m.ID, &m.DashboardID, &m.DataSourceID, &m.Label, &m.MeasurementName, &m.Timeframe, &m.DateFrom, &m.DateTo, &filtersJSON, &aggregation, &m.AggregationKey, &granularity, &displayMode, &m.ComparisonEnabled, &comparisonDisplayType, &chartType, &m.SplitBy, &m.Position, &m.CreatedAt, &m.UpdatedAt)
+		`SELECT id, dashboard_id, data_source_id, label, measurement_name, timeframe, date_from, date_to, filters, aggregation, aggregation_key, granularity, display_mode, comparison_enabled, comparison_display_type, chart_type, split_by, stacking, share_of, composite_series, other_threshold, refresh_interval_seconds, orphaned_at, external_id, position, created_at, updated_at
+		FROM metrics `+where,
+		args...,
+	).Scan(&m.ID, &m.DashboardID, &m.DataSourceID, &m.Label, &m.MeasurementName, &m.Timeframe, &m.DateFrom, &m.DateTo, &filtersJSON, &aggregation, &m.AggregationKey, &granularity, &displayMode, &m.ComparisonEnabled, &comparisonDisplayType, &chartType, &m.SplitBy, &stacking, &shareOfJSON, &compositeJSON, &m.OtherThreshold, &m.RefreshIntervalSeconds, &m.OrphanedAt, &m.ExternalID, &m.Position, &m.CreatedAt, &m.UpdatedAt)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -106,10 +137,16 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Metric, error)
 		ct := ChartType(*chartType)
 		m.ChartType = &ct
 	}
+	if stacking != nil {
+		st := Stacking(*stacking)
+		m.Stacking = &st
+	}
 
 	if err := json.Unmarshal(filtersJSON, &m.Filters); err != nil {
 		m.Filters = []Filter{}
 	}
+	m.ShareOf = unmarshalShareOf(shareOfJSON)
+	m.CompositeSeries = unmarshalCompositeSeries(compositeJSON)
 
 	return m, nil
 }
@@ -117,8 +154,8 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Metric, error)
 // GetByDashboardID retrieves all metrics for a dashboard.
 func (r *Repository) GetByDashboardID(ctx context.Context, dashboardID uuid.UUID) ([]Metric, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, dashboard_id, data_source_id, label, measurement_name, timeframe, date_from, date_to, filters, aggregation, aggregation_key, granularity, display_mode, comparison_enabled, comparison_display_type, chart_type, split_by, position, created_at, updated_at
-		FROM metrics WHERE dashboard_id = $1
+		`SELECT id, dashboard_id, data_source_id, label, measurement_name, timeframe, date_from, date_to, filters, aggregation, aggregation_key, granularity, display_mode, comparison_enabled, comparison_display_type, chart_type, split_by, stacking, share_of, composite_series, other_threshold, refresh_interval_seconds, orphaned_at, external_id, position, created_at, updated_at
+		FROM metrics WHERE dashboard_id = $1 AND deleted_at IS NULL
 		ORDER BY position ASC`,
 		dashboardID,
 	)
@@ -130,11 +167,11 @@ func (r *Repository) GetByDashboardID(ctx context.Context, dashboardID uuid.UUID
 	var metrics []Metric
 	for rows.Next() {
 		var m Metric
-		var filtersJSON []byte
+		var filtersJSON, shareOfJSON, compositeJSON []byte
 		var aggregation, displayMode string
 		var granularity *string
-		var comparisonDisplayType, chartType *string
-		if err := rows.Scan(&m.ID, &m.DashboardID, &m.DataSourceID, &m.Label, &m.MeasurementName, &m.Timeframe, &m.DateFrom, &m.DateTo, &filtersJSON, &aggregation, &m.AggregationKey, &granularity, &displayMode, &m.ComparisonEnabled, &comparisonDisplayType, &chartType, &m.SplitBy, &m.Position, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		var comparisonDisplayType, chartType, stacking *string
+		if err := rows.Scan(&m.ID, &m.DashboardID, &m.DataSourceID, &m.Label, &m.MeasurementName, &m.Timeframe, &m.DateFrom, &m.DateTo, &filtersJSON, &aggregation, &m.AggregationKey, &granularity, &displayMode, &m.ComparisonEnabled, &comparisonDisplayType, &chartType, &m.SplitBy, &stacking, &shareOfJSON, &compositeJSON, &m.OtherThreshold, &m.RefreshIntervalSeconds, &m.OrphanedAt, &m.ExternalID, &m.Position, &m.CreatedAt, &m.UpdatedAt); err != nil {
 			return nil, err
 		}
 		m.Aggregation = Aggregation(aggregation)
@@ -151,9 +188,15 @@ func (r *Repository) GetByDashboardID(ctx context.Context, dashboardID uuid.UUID
 			ct := ChartType(*chartType)
 			m.ChartType = &ct
 		}
+		if stacking != nil {
+			st := Stacking(*stacking)
+			m.Stacking = &st
+		}
 		if err := json.Unmarshal(filtersJSON, &m.Filters); err != nil {
 			m.Filters = []Filter{}
 		}
+		m.ShareOf = unmarshalShareOf(shareOfJSON)
+		m.CompositeSeries = unmarshalCompositeSeries(compositeJSON)
 		metrics = append(metrics, m)
 	}
 
@@ -168,32 +211,405 @@ func (r *Repository) GetByDashboardID(ctx context.Context, dashboardID uuid.UUID
 	return metrics, nil
 }
 
-// Update updates a metric's configuration.
-func (r *Repository) Update(ctx context.Context, id uuid.UUID, req UpdateMetricRequest) error {
+// Update updates a metric's configuration. If ifUnmodified is set, the update
+// only applies while the metric's update time still equals it. It reports
+// whether the metric was updated.
+func (r *Repository) Update(ctx context.Context, id uuid.UUID, req UpdateMetricRequest, ifUnmodified *time.Time) (bool, error) {
 	filtersJSON, err := json.Marshal(req.Filters)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if req.Filters == nil {
 		filtersJSON = []byte("[]")
 	}
+	shareOfJSON, err := marshalShareOf(req.ShareOf)
+	if err != nil {
+		return false, err
+	}
+	compositeJSON, err := marshalCompositeSeries(req.CompositeSeries)
+	if err != nil {
+		return false, err
+	}
 
-	_, err = r.pool.Exec(ctx,
-		`UPDATE metrics SET label = $1, timeframe = $2, date_from = $3, date_to = $4, filters = $5, aggregation = $6, aggregation_key = $7, granularity = $8, display_mode = $9, comparison_enabled = $10, comparison_display_type = $11, chart_type = $12, split_by = $13, updated_at = NOW() WHERE id = $14`,
-		req.Label, req.Timeframe, req.DateFrom, req.DateTo, filtersJSON, req.Aggregation, req.AggregationKey, req.Granularity, req.DisplayMode, req.ComparisonEnabled, req.ComparisonDisplayType, req.ChartType, req.SplitBy, id,
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE metrics SET label = $1, timeframe = $2, date_from = $3, date_to = $4, filters = $5, aggregation = $6, aggregation_key = $7, granularity = $8, display_mode = $9, comparison_enabled = $10, comparison_display_type = $11, chart_type = $12, split_by = $13, stacking = $14, share_of = $15, other_threshold = $16, refresh_interval_seconds = $17, composite_series = $18, updated_at = NOW()
+		WHERE id = $19 AND deleted_at IS NULL AND ($20::timestamptz IS NULL OR updated_at = $20)`,
+		req.Label, req.Timeframe, req.DateFrom, req.DateTo, filtersJSON, req.Aggregation, req.AggregationKey, req.Granularity, req.DisplayMode, req.ComparisonEnabled, req.ComparisonDisplayType, req.ChartType, req.SplitBy, req.Stacking, shareOfJSON, req.OtherThreshold, req.RefreshIntervalSeconds, compositeJSON, id, ifUnmodified,
 	)
-	return err
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// marshalShareOf encodes a share denominator, or returns nil to store NULL.
+func marshalShareOf(shareOf *ShareDenominator) ([]byte, error) {
+	if shareOf == nil {
+		return nil, nil
+	}
+	if shareOf.Filters == nil {
+		shareOf = &ShareDenominator{Filters: []Filter{}}
+	}
+	return json.Marshal(shareOf)
+}
+
+// marshalCompositeSeries encodes composite series, or returns nil to store
+// NULL when there are none.
+func marshalCompositeSeries(series []CompositeSeries) ([]byte, error) {
+	if len(series) == 0 {
+		return nil, nil
+	}
+	stored := make([]CompositeSeries, len(series))
+	for i, cs := range series {
+		stored[i] = cs
+		if cs.Filters == nil {
+			stored[i].Filters = []Filter{}
+		}
+	}
+	return json.Marshal(stored)
+}
+
+// unmarshalCompositeSeries decodes stored composite series; NULL yields nil.
+func unmarshalCompositeSeries(data []byte) []CompositeSeries {
+	if len(data) == 0 {
+		return nil
+	}
+	var series []CompositeSeries
+	if err := json.Unmarshal(data, &series); err != nil {
+		return nil
+	}
+	return series
+}
+
+// unmarshalShareOf decodes a stored share denominator; NULL yields nil.
+func unmarshalShareOf(data []byte) *ShareDenominator {
+	if len(data) == 0 {
+		return nil
+	}
+	var shareOf ShareDenominator
+	if err := json.Unmarshal(data, &shareOf); err != nil {
+		return nil
+	}
+	if shareOf.Filters == nil {
+		shareOf.Filters = []Filter{}
+	}
+	return &shareOf
+}
+
+// CreateVersion records a previous configuration of a metric as its next
+// version and drops versions beyond MaxVersionsPerMetric.
+func (r *Repository) CreateVersion(ctx context.Context, metricID uuid.UUID, config UpdateMetricRequest, createdBy uuid.UUID) error {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO metric_versions (metric_id, version, config, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3 FROM metric_versions WHERE metric_id = $1`,
+		metricID, configJSON, createdBy,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM metric_versions WHERE metric_id = $1 AND version <= (
+			SELECT MAX(version) - $2 FROM metric_versions WHERE metric_id = $1
+		)`,
+		metricID, MaxVersionsPerMetric,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetVersions retrieves the previous versions of a metric, newest first.
+func (r *Repository) GetVersions(ctx context.Context, metricID uuid.UUID) ([]MetricVersion, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT version, config, created_by, created_at
+		FROM metric_versions WHERE metric_id = $1
+		ORDER BY version DESC`,
+		metricID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []MetricVersion
+	for rows.Next() {
+		var v MetricVersion
+		var configJSON []byte
+		if err := rows.Scan(&v.Version, &configJSON, &v.CreatedBy, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(configJSON, &v.Config); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// versionChange is a recorded version with the configuration that replaced
+// it; after is nil for a metric's latest version.
+type versionChange struct {
+	metricID    uuid.UUID
+	dashboardID uuid.UUID
+	before      UpdateMetricRequest
+	after       *UpdateMetricRequest
+	createdBy   *uuid.UUID
+	createdAt   time.Time
+}
+
+// GetVersionChangesInRange retrieves the versions recorded for an
+// organization's metrics in [from, to), newest first, each paired with the
+// configuration that replaced it.
+func (r *Repository) GetVersionChangesInRange(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]versionChange, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT v.metric_id, m.dashboard_id, v.config, v.next_config, v.created_by, v.created_at
+		FROM (
+			SELECT metric_id, config, created_by, created_at,
+			       LEAD(config) OVER (PARTITION BY metric_id ORDER BY version) AS next_config
+			FROM metric_versions
+			WHERE metric_id IN (
+				SELECT m.id FROM metrics m JOIN dashboards d ON d.id = m.dashboard_id
+				WHERE d.organization_id = $1
+			)
+		) v
+		JOIN metrics m ON m.id = v.metric_id
+		WHERE v.created_at >= $2 AND v.created_at < $3
+		ORDER BY v.created_at DESC`,
+		orgID, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []versionChange
+	for rows.Next() {
+		var c versionChange
+		var beforeJSON, afterJSON []byte
+		if err := rows.Scan(&c.metricID, &c.dashboardID, &beforeJSON, &afterJSON, &c.createdBy, &c.createdAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(beforeJSON, &c.before); err != nil {
+			return nil, err
+		}
+		if afterJSON != nil {
+			c.after = &UpdateMetricRequest{}
+			if err := json.Unmarshal(afterJSON, c.after); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetVersion retrieves one previous version of a metric.
+func (r *Repository) GetVersion(ctx context.Context, metricID uuid.UUID, version int) (*MetricVersion, error) {
+	v := &MetricVersion{Version: version}
+	var configJSON []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT config, created_by, created_at
+		FROM metric_versions WHERE metric_id = $1 AND version = $2`,
+		metricID, version,
+	).Scan(&configJSON, &v.CreatedBy, &v.CreatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(configJSON, &v.Config); err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
-// Delete deletes a metric by its ID.
+// Delete moves a metric to the trash and releases its external ID.
 func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
 	_, err := r.pool.Exec(ctx,
-		`DELETE FROM metrics WHERE id = $1`,
+		`UPDATE metrics SET deleted_at = NOW(), external_id = NULL WHERE id = $1 AND deleted_at IS NULL`,
 		id,
 	)
 	return err
 }
 
+// GetDeletedByOrganizationID retrieves the trashed metrics of an organization.
+// Metrics on trashed dashboards are left out; they return with their dashboard.
+func (r *Repository) GetDeletedByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]TrashedMetric, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.id, m.dashboard_id, d.name, m.label, m.deleted_at
+		FROM metrics m
+		JOIN dashboards d ON d.id = m.dashboard_id
+		WHERE d.organization_id = $1 AND d.deleted_at IS NULL AND m.deleted_at IS NOT NULL
+		ORDER BY m.deleted_at DESC`,
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []TrashedMetric
+	for rows.Next() {
+		var m TrashedMetric
+		if err := rows.Scan(&m.ID, &m.DashboardID, &m.DashboardName, &m.Label, &m.DeletedAt); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, rows.Err()
+}
+
+// Restore takes a metric out of the trash. It reports whether a trashed
+// metric on a live dashboard of the organization was found.
+func (r *Repository) Restore(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE metrics m SET deleted_at = NULL, updated_at = NOW()
+		FROM dashboards d
+		WHERE m.id = $1 AND d.id = m.dashboard_id AND d.organization_id = $2
+		  AND d.deleted_at IS NULL AND m.deleted_at IS NOT NULL`,
+		id, orgID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// PurgeDeleted permanently deletes metrics trashed before the given time and
+// returns the organization IDs of the deleted metrics by ID.
+func (r *Repository) PurgeDeleted(ctx context.Context, before time.Time) (map[uuid.UUID]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx,
+		`DELETE FROM metrics m USING dashboards d
+		WHERE d.id = m.dashboard_id AND m.deleted_at < $1
+		RETURNING m.id, d.organization_id`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purged := make(map[uuid.UUID]uuid.UUID)
+	for rows.Next() {
+		var id, orgID uuid.UUID
+		if err := rows.Scan(&id, &orgID); err != nil {
+			return nil, err
+		}
+		purged[id] = orgID
+	}
+	return purged, rows.Err()
+}
+
+// GetTrendsByDashboardID returns the trends of a dashboard's metrics by
+// metric ID. Metrics without a value to compare, and trends computed for an
+// earlier configuration of their metric, are left out.
+func (r *Repository) GetTrendsByDashboardID(ctx context.Context, dashboardID uuid.UUID) (map[uuid.UUID]Trend, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT t.metric_id, t.direction, t.change, t.change_percent, t.computed_at
+		FROM metric_trends t
+		JOIN metrics m ON m.id = t.metric_id AND m.updated_at = t.metric_updated_at
+		WHERE m.dashboard_id = $1 AND m.deleted_at IS NULL AND t.direction IS NOT NULL`,
+		dashboardID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trends := make(map[uuid.UUID]Trend)
+	for rows.Next() {
+		var metricID uuid.UUID
+		var t Trend
+		var direction string
+		if err := rows.Scan(&metricID, &direction, &t.Change, &t.ChangePercent, &t.ComputedAt); err != nil {
+			return nil, err
+		}
+		t.Direction = TrendDirection(direction)
+		trends[metricID] = t
+	}
+	return trends, rows.Err()
+}
+
+// GetStaleTrendMetricIDs returns up to limit scalar metrics whose trend is
+// missing, was computed for an earlier configuration, or was computed before
+// the given time or before late data landed in the metric's data source.
+// Metrics without a trend come first, then the stalest.
+func (r *Repository) GetStaleTrendMetricIDs(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.id
+		FROM metrics m
+		LEFT JOIN metric_trends t ON t.metric_id = m.id
+		LEFT JOIN data_sources ds ON ds.id = m.data_source_id
+		WHERE m.display_mode = 'scalar' AND m.deleted_at IS NULL AND m.orphaned_at IS NULL
+		  AND (t.metric_id IS NULL OR t.metric_updated_at <> m.updated_at OR t.computed_at < $1
+		    OR t.computed_at < ds.backfilled_at)
+		ORDER BY t.computed_at ASC NULLS FIRST
+		LIMIT $2`,
+		before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpsertTrend stores the trend of a metric computed at computedAt for the
+// configuration last updated at metricUpdatedAt. A nil trend records that
+// the metric had no value to compare.
+func (r *Repository) UpsertTrend(ctx context.Context, metricID uuid.UUID, metricUpdatedAt, computedAt time.Time, t *Trend) error {
+	var direction *string
+	var change, changePercent *float64
+	if t != nil {
+		d := string(t.Direction)
+		direction = &d
+		change = &t.Change
+		changePercent = t.ChangePercent
+	}
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO metric_trends (metric_id, metric_updated_at, direction, change, change_percent, computed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (metric_id) DO UPDATE
+		SET metric_updated_at = EXCLUDED.metric_updated_at,
+		    direction = EXCLUDED.direction,
+		    change = EXCLUDED.change,
+		    change_percent = EXCLUDED.change_percent,
+		    computed_at = EXCLUDED.computed_at`,
+		metricID, metricUpdatedAt, direction, change, changePercent, computedAt,
+	)
+	return err
+}
+
 // GetMaxPosition gets the maximum position for metrics in a dashboard.
 func (r *Repository) GetMaxPosition(ctx context.Context, dashboardID uuid.UUID) (int, error) {
 	var maxPos *int
@@ -212,17 +628,65 @@ func (r *Repository) GetMaxPosition(ctx context.Context, dashboardID uuid.UUID)
 	return *maxPos, nil
 }
 
-// UpdatePositions updates the positions of multiple metrics.
-func (r *Repository) UpdatePositions(ctx context.Context, dashboardID uuid.UUID, metricIDs []uuid.UUID) error {
+// UpdatePositions updates the positions of all metrics on a dashboard.
+// metricIDs must list every metric on the dashboard exactly once, or
+// ErrInvalidMetricOrder is returned. The dashboard's update time is bumped;
+// if ifUnmodified is set and no longer matches it, ErrPreconditionFailed is
+// returned. Both checks run under the dashboard's row lock, so concurrent
+// reorders and metric changes cannot interleave.
+func (r *Repository) UpdatePositions(ctx context.Context, dashboardID uuid.UUID, metricIDs []uuid.UUID, ifUnmodified *time.Time) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
+	tag, err := tx.Exec(ctx,
+		`UPDATE dashboards SET updated_at = NOW()
+		WHERE id = $1 AND ($2::timestamptz IS NULL OR updated_at = $2)`,
+		dashboardID, ifUnmodified,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPreconditionFailed
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT id FROM metrics WHERE dashboard_id = $1 AND deleted_at IS NULL`,
+		dashboardID,
+	)
+	if err != nil {
+		return err
+	}
+	current := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		current[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(metricIDs) != len(current) {
+		return ErrInvalidMetricOrder
+	}
+	for _, mID := range metricIDs {
+		if !current[mID] {
+			return ErrInvalidMetricOrder
+		}
+		delete(current, mID) // Catches duplicates
+	}
+
 	for i, mID := range metricIDs {
 		_, err := tx.Exec(ctx,
-			`UPDATE metrics SET position = $1, updated_at = NOW() WHERE id = $2 AND dashboard_id = $3`,
+			`UPDATE metrics SET position = $1, updated_at = NOW() WHERE id = $2 AND dashboard_id = $3 AND deleted_at IS NULL`,
 			i, mID, dashboardID,
 		)
 		if err != nil {
@@ -233,17 +697,18 @@ func (r *Repository) UpdatePositions(ctx context.Context, dashboardID uuid.UUID,
 	return tx.Commit(ctx)
 }
 
-// Aggregation queries - these query the measurements table directly
+// Aggregation queries - these query the measurement_points view, which
+// combines raw measurements with downsampled per-day rollups
 
 // GetAggregatedMeasurements retrieves aggregated values with optional metadata filtering and granularity.
-func (r *Repository) GetAggregatedMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, granularity Granularity) ([]AggregatedDataPoint, error) {
-	dateTrunc := granularityToDateTrunc(granularity)
+func (r *Repository) GetAggregatedMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, granularity Granularity, weekStart WeekStart) ([]AggregatedDataPoint, error) {
+	dateTrunc := granularityToDateTrunc(granularity, weekStart)
 
 	query := fmt.Sprintf(`SELECT
 		%s as date,
-		SUM(value) as sum,
-		COUNT(*) as count
-	FROM measurements
+		SUM(value * weight) as sum,
+		SUM(weight) as count
+	FROM measurement_points
 	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4`, dateTrunc)
 
 	args := []interface{}{dataSourceID, name, startDate, endDate}
@@ -289,19 +754,27 @@ func (r *Repository) GetAggregatedMeasurements(ctx context.Context, dataSourceID
 }
 
 // GetAggregatedMeasurementsSplitBy retrieves aggregated values split by a metadata key.
-func (r *Repository) GetAggregatedMeasurementsSplitBy(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, splitByKey string, granularity Granularity) ([]SplitSeries, error) {
-	dateTrunc := granularityToDateTrunc(granularity)
+// Averaged values carry their total weight, so series can later be merged correctly.
+func (r *Repository) GetAggregatedMeasurementsSplitBy(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, splitByKey string, granularity Granularity, weekStart WeekStart, aggregation Aggregation, aggregationKey *string) ([]SplitSeries, error) {
+	dateTrunc := granularityToDateTrunc(granularity, weekStart)
+
+	args := []interface{}{dataSourceID, name, startDate, endDate, splitByKey}
+	sumExpr, weightExpr := "SUM(value * weight)", "SUM(weight)"
+	if aggregation == AggregationWeightedAverage {
+		args = append(args, *aggregationKey)
+		w := pointWeightExpr(len(args))
+		sumExpr = fmt.Sprintf("SUM(value * weight * %s)", w)
+		weightExpr = fmt.Sprintf("SUM(weight * %s)", w)
+	}
 
 	query := fmt.Sprintf(`SELECT
 		metadata->>$5 as split_key,
 		%s as date,
-		SUM(value) as sum,
-		COUNT(*) as count
-	FROM measurements
+		COALESCE(%s, 0) as sum,
+		COALESCE(%s, 0)::double precision as weight
+	FROM measurement_points
 	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4
-	  AND metadata ? $5`, dateTrunc)
-
-	args := []interface{}{dataSourceID, name, startDate, endDate, splitByKey}
+	  AND metadata ? $5`, dateTrunc, sumExpr, weightExpr)
 
 	// Add additional metadata filters using JSONB containment operator
 	if len(metadataFilters) > 0 {
@@ -309,8 +782,8 @@ func (r *Repository) GetAggregatedMeasurementsSplitBy(ctx context.Context, dataS
 		if err != nil {
 			return nil, err
 		}
-		query += ` AND metadata @> $6`
 		args = append(args, filterJSON)
+		query += fmt.Sprintf(` AND metadata @> $%d`, len(args))
 	}
 
 	query += fmt.Sprintf(` GROUP BY split_key, %s ORDER BY split_key, date`, dateTrunc)
@@ -325,15 +798,22 @@ func (r *Repository) GetAggregatedMeasurementsSplitBy(ctx context.Context, dataS
 	seriesMap := make(map[string][]DataPoint)
 	for rows.Next() {
 		var splitKey string
-		var dp AggregatedDataPoint
 		var date time.Time
-		if err := rows.Scan(&splitKey, &date, &dp.Sum, &dp.Count); err != nil {
+		var sum, weight float64
+		if err := rows.Scan(&splitKey, &date, &sum, &weight); err != nil {
 			return nil, err
 		}
-		seriesMap[splitKey] = append(seriesMap[splitKey], DataPoint{
+		if aggregation == AggregationWeightedAverage && weight == 0 {
+			continue // No weighted points in this bucket
+		}
+		dp := DataPoint{
 			Date:  formatDateByGranularity(date, granularity),
-			Value: dp.Sum,
-		})
+			Value: aggregateValue(aggregation, sum, weight),
+		}
+		if aggregation.IsAverage() {
+			dp.weight = weight
+		}
+		seriesMap[splitKey] = append(seriesMap[splitKey], dp)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -362,13 +842,13 @@ func (r *Repository) GetAggregatedMeasurementsSplitBy(ctx context.Context, dataS
 }
 
 // GetCountUniqueMeasurements retrieves the count of unique values for a metadata key.
-func (r *Repository) GetCountUniqueMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, aggregationKey string, granularity Granularity) ([]AggregatedDataPoint, error) {
-	dateTrunc := granularityToDateTrunc(granularity)
+func (r *Repository) GetCountUniqueMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, aggregationKey string, granularity Granularity, weekStart WeekStart) ([]AggregatedDataPoint, error) {
+	dateTrunc := granularityToDateTrunc(granularity, weekStart)
 
 	query := fmt.Sprintf(`SELECT
 		%s as date,
 		COUNT(DISTINCT metadata->>$5) as count
-	FROM measurements
+	FROM measurement_points
 	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4
 	  AND metadata ? $5`, dateTrunc)
 
@@ -415,11 +895,276 @@ func (r *Repository) GetCountUniqueMeasurements(ctx context.Context, dataSourceI
 	return dataPoints, nil
 }
 
+// GetWeightedAverageMeasurements retrieves averages weighted by the numeric value of a
+// metadata key, so pre-aggregated points (e.g. hourly averages with their sample
+// counts) combine into correct period averages. Points without a weight are skipped.
+func (r *Repository) GetWeightedAverageMeasurements(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, weightKey string, granularity Granularity, weekStart WeekStart) ([]DataPoint, error) {
+	dateTrunc := granularityToDateTrunc(granularity, weekStart)
+	w := pointWeightExpr(5)
+
+	query := fmt.Sprintf(`SELECT
+		%s as date,
+		SUM(value * weight * %s) / SUM(weight * %s) as value
+	FROM measurement_points
+	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4`, dateTrunc, w, w)
+
+	args := []interface{}{dataSourceID, name, startDate, endDate, weightKey}
+
+	// Add metadata filters using JSONB containment operator
+	if len(metadataFilters) > 0 {
+		filterJSON, err := json.Marshal(metadataFilters)
+		if err != nil {
+			return nil, err
+		}
+		query += ` AND metadata @> $6`
+		args = append(args, filterJSON)
+	}
+
+	query += fmt.Sprintf(` GROUP BY %s HAVING SUM(weight * %s) > 0 ORDER BY %s`, dateTrunc, w, dateTrunc)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataPoints []DataPoint
+	for rows.Next() {
+		var dp DataPoint
+		var date time.Time
+		if err := rows.Scan(&date, &dp.Value); err != nil {
+			return nil, err
+		}
+		dp.Date = formatDateByGranularity(date, granularity)
+		dataPoints = append(dataPoints, dp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if dataPoints == nil {
+		dataPoints = []DataPoint{}
+	}
+
+	return dataPoints, nil
+}
+
 // Helper functions
 
-func granularityToDateTrunc(g Granularity) string {
+// isQueryTimeout reports whether err was caused by a context deadline or the
+// database statement_timeout cancelling a query.
+// GetMeasurementFormats retrieves the declared measurement types of a data source keyed by measurement name.
+func (r *Repository) GetMeasurementFormats(ctx context.Context, dataSourceID uuid.UUID) (map[string]ValueFormat, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT measurement_name, semantic_type, unit FROM measurement_types WHERE data_source_id = $1`,
+		dataSourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	formats := make(map[string]ValueFormat)
+	for rows.Next() {
+		var name string
+		var f ValueFormat
+		if err := rows.Scan(&name, &f.SemanticType, &f.Unit); err != nil {
+			return nil, err
+		}
+		formats[name] = f
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return formats, nil
+}
+
+// GetCalendar retrieves an organization's calendar.
+func (r *Repository) GetCalendar(ctx context.Context, orgID uuid.UUID) (*Calendar, error) {
+	cal := &Calendar{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT fiscal_year_start_month, week_start FROM organizations WHERE id = $1`,
+		orgID,
+	).Scan(&cal.FiscalYearStartMonth, &cal.WeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// GetCalendarByDashboardID retrieves the calendar of the organization a
+// dashboard belongs to.
+func (r *Repository) GetCalendarByDashboardID(ctx context.Context, dashboardID uuid.UUID) (*Calendar, error) {
+	cal := &Calendar{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT o.fiscal_year_start_month, o.week_start
+		FROM dashboards d JOIN organizations o ON o.id = d.organization_id
+		WHERE d.id = $1`,
+		dashboardID,
+	).Scan(&cal.FiscalYearStartMonth, &cal.WeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// UpdateCalendar updates an organization's calendar.
+func (r *Repository) UpdateCalendar(ctx context.Context, orgID uuid.UUID, req UpdateCalendarRequest) (*Calendar, error) {
+	cal := &Calendar{}
+	err := r.pool.QueryRow(ctx,
+		`UPDATE organizations SET
+			fiscal_year_start_month = COALESCE($2, fiscal_year_start_month),
+			week_start = COALESCE($3, week_start)
+		WHERE id = $1
+		RETURNING fiscal_year_start_month, week_start`,
+		orgID, req.FiscalYearStartMonth, req.WeekStart,
+	).Scan(&cal.FiscalYearStartMonth, &cal.WeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// GetLocale retrieves an organization's locale.
+func (r *Repository) GetLocale(ctx context.Context, orgID uuid.UUID) (string, error) {
+	var locale string
+	err := r.pool.QueryRow(ctx,
+		`SELECT locale FROM organizations WHERE id = $1`,
+		orgID,
+	).Scan(&locale)
+	return locale, err
+}
+
+// UpdateLocale sets an organization's locale.
+func (r *Repository) UpdateLocale(ctx context.Context, orgID uuid.UUID, locale string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE organizations SET locale = $2 WHERE id = $1`,
+		orgID, locale,
+	)
+	return err
+}
+
+// GetMetricDefaults retrieves an organization's metric defaults.
+// Returns nil if none were saved.
+func (r *Repository) GetMetricDefaults(ctx context.Context, orgID uuid.UUID) (*MetricDefaults, error) {
+	d := &MetricDefaults{}
+	var granularity, chartType *string
+	err := r.pool.QueryRow(ctx,
+		`SELECT timeframe, granularity, chart_type, comparison_enabled, updated_at
+		FROM metric_defaults WHERE organization_id = $1`,
+		orgID,
+	).Scan(&d.Timeframe, &granularity, &chartType, &d.ComparisonEnabled, &d.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if granularity != nil {
+		g := Granularity(*granularity)
+		d.Granularity = &g
+	}
+	if chartType != nil {
+		ct := ChartType(*chartType)
+		d.ChartType = &ct
+	}
+	return d, nil
+}
+
+// UpsertMetricDefaults replaces an organization's metric defaults.
+func (r *Repository) UpsertMetricDefaults(ctx context.Context, orgID uuid.UUID, req UpdateMetricDefaultsRequest) (*MetricDefaults, error) {
+	d := &MetricDefaults{
+		Timeframe:         req.Timeframe,
+		Granularity:       req.Granularity,
+		ChartType:         req.ChartType,
+		ComparisonEnabled: req.ComparisonEnabled,
+	}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO metric_defaults (organization_id, timeframe, granularity, chart_type, comparison_enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id) DO UPDATE SET
+			timeframe = EXCLUDED.timeframe,
+			granularity = EXCLUDED.granularity,
+			chart_type = EXCLUDED.chart_type,
+			comparison_enabled = EXCLUDED.comparison_enabled
+		RETURNING updated_at`,
+		orgID, req.Timeframe, req.Granularity, req.ChartType, req.ComparisonEnabled,
+	).Scan(&d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetFreshnessSLAs retrieves the expected ingest frequencies of a data
+// source's measurements keyed by measurement name.
+func (r *Repository) GetFreshnessSLAs(ctx context.Context, dataSourceID uuid.UUID) (map[string]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT measurement_name, expected_frequency FROM measurement_freshness WHERE data_source_id = $1`,
+		dataSourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	frequencies := make(map[string]string)
+	for rows.Next() {
+		var name, frequency string
+		if err := rows.Scan(&name, &frequency); err != nil {
+			return nil, err
+		}
+		frequencies[name] = frequency
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return frequencies, nil
+}
+
+// GetLastDataAt retrieves the newest timestamp of a measurement, or nil if it
+// has no data.
+func (r *Repository) GetLastDataAt(ctx context.Context, dataSourceID uuid.UUID, name string) (*time.Time, error) {
+	var last *time.Time
+	err := r.pool.QueryRow(ctx,
+		`SELECT MAX(timestamp) FROM measurement_points WHERE data_source_id = $1 AND name = $2`,
+		dataSourceID, name,
+	).Scan(&last)
+	if err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+func isQueryTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "57014" // query_canceled
+}
+
+// pointWeightExpr returns the weight a point carries in a weighted average: the
+// numeric value of the metadata key bound to parameter $param, or NULL when the
+// point has none, which leaves it out of the SUMs.
+func pointWeightExpr(param int) string {
+	return fmt.Sprintf(`(CASE WHEN metadata->>$%d ~ '^[0-9]+(\.[0-9]+)?$' THEN (metadata->>$%d)::double precision END)`, param, param)
+}
+
+func granularityToDateTrunc(g Granularity, weekStart WeekStart) string {
 	switch g {
 	case GranularityWeekly:
+		if weekStart == WeekStartSunday {
+			// DATE_TRUNC weeks start on Monday; shift Sundays into the next one
+			return "(DATE_TRUNC('week', timestamp + INTERVAL '1 day') - INTERVAL '1 day')::date"
+		}
 		return "DATE_TRUNC('week', timestamp)::date"
 	case GranularityMonthly:
 		return "DATE_TRUNC('month', timestamp)::date"
@@ -443,8 +1188,8 @@ func formatDateByGranularity(t time.Time, g Granularity) string {
 
 // GetScalarAggregate returns the sum and count for the entire timeframe without grouping.
 func (r *Repository) GetScalarAggregate(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string) (sum float64, count int, err error) {
-	query := `SELECT COALESCE(SUM(value), 0), COUNT(*)
-	FROM measurements
+	query := `SELECT COALESCE(SUM(value * weight), 0), COALESCE(SUM(weight), 0)
+	FROM measurement_points
 	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4`
 
 	args := []interface{}{dataSourceID, name, startDate, endDate}
@@ -466,10 +1211,141 @@ func (r *Repository) GetScalarAggregate(ctx context.Context, dataSourceID uuid.U
 	return sum, count, nil
 }
 
+// GetScalarAggregateSplitBy returns the sum and count for the entire timeframe per value of a metadata key.
+func (r *Repository) GetScalarAggregateSplitBy(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, splitByKey string) ([]SplitTotal, error) {
+	query := `SELECT metadata->>$5 as split_key, SUM(value * weight), SUM(weight)
+	FROM measurement_points
+	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4
+	  AND metadata ? $5`
+
+	args := []interface{}{dataSourceID, name, startDate, endDate, splitByKey}
+
+	if len(metadataFilters) > 0 {
+		filterJSON, err := json.Marshal(metadataFilters)
+		if err != nil {
+			return nil, err
+		}
+		query += ` AND metadata @> $6`
+		args = append(args, filterJSON)
+	}
+
+	query += ` GROUP BY split_key`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []SplitTotal
+	for rows.Next() {
+		var t SplitTotal
+		if err := rows.Scan(&t.Key, &t.Sum, &t.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// GetMeasurementPresence reports whether a measurement has points in the
+// timeframe matching the filters, and whether it has any points at all.
+func (r *Repository) GetMeasurementPresence(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string) (inRange, everSeen bool, err error) {
+	rangeCond := `timestamp >= $3 AND timestamp < $4`
+	args := []interface{}{dataSourceID, name, startDate, endDate}
+
+	if len(metadataFilters) > 0 {
+		filterJSON, err := json.Marshal(metadataFilters)
+		if err != nil {
+			return false, false, err
+		}
+		rangeCond += ` AND metadata @> $5`
+		args = append(args, filterJSON)
+	}
+
+	query := fmt.Sprintf(`SELECT
+		EXISTS (SELECT 1 FROM measurement_points WHERE data_source_id = $1 AND name = $2 AND %s),
+		EXISTS (SELECT 1 FROM measurement_points WHERE data_source_id = $1 AND name = $2)`, rangeCond)
+
+	err = r.pool.QueryRow(ctx, query, args...).Scan(&inRange, &everSeen)
+	if err != nil {
+		return false, false, err
+	}
+
+	return inRange, everSeen, nil
+}
+
+// GetHeatmapCells aggregates raw measurements per ISO weekday and hour of day in UTC.
+// It reads the measurements table rather than measurement_points, since rollups
+// have no time of day.
+func (r *Repository) GetHeatmapCells(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, aggregation Aggregation, aggregationKey *string) ([]HeatmapCell, error) {
+	var valueExpr string
+	args := []interface{}{dataSourceID, name, startDate, endDate}
+	switch aggregation {
+	case AggregationCount:
+		valueExpr = "SUM(weight)"
+	case AggregationAverage:
+		valueExpr = "SUM(value * weight) / NULLIF(SUM(weight), 0)"
+	case AggregationCountUnique:
+		args = append(args, *aggregationKey)
+		valueExpr = fmt.Sprintf("COUNT(DISTINCT metadata->>$%d)", len(args))
+	case AggregationWeightedAverage:
+		args = append(args, *aggregationKey)
+		w := pointWeightExpr(len(args))
+		valueExpr = fmt.Sprintf("SUM(value * weight * %s) / NULLIF(SUM(weight * %s), 0)", w, w)
+	default:
+		valueExpr = "SUM(value * weight)"
+	}
+
+	query := fmt.Sprintf(`SELECT
+		EXTRACT(ISODOW FROM timestamp AT TIME ZONE 'UTC')::int as weekday,
+		EXTRACT(HOUR FROM timestamp AT TIME ZONE 'UTC')::int as hour,
+		COALESCE(%s, 0) as value
+	FROM measurements
+	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4`, valueExpr)
+
+	if len(metadataFilters) > 0 {
+		filterJSON, err := json.Marshal(metadataFilters)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, filterJSON)
+		query += fmt.Sprintf(` AND metadata @> $%d`, len(args))
+	}
+
+	query += ` GROUP BY weekday, hour`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []HeatmapCell
+	for rows.Next() {
+		var c HeatmapCell
+		if err := rows.Scan(&c.Weekday, &c.Hour, &c.Value); err != nil {
+			return nil, err
+		}
+		cells = append(cells, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cells, nil
+}
+
 // GetScalarCountUnique returns the unique count for the entire timeframe without grouping.
 func (r *Repository) GetScalarCountUnique(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, aggregationKey string) (int, error) {
 	query := `SELECT COUNT(DISTINCT metadata->>$5)
-	FROM measurements
+	FROM measurement_points
 	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4
 	  AND metadata ? $5`
 
@@ -492,3 +1368,30 @@ func (r *Repository) GetScalarCountUnique(ctx context.Context, dataSourceID uuid
 
 	return count, nil
 }
+
+// GetScalarWeightedAverage returns the weighted sum and total weight for the entire
+// timeframe, weighting each point by the numeric value of a metadata key.
+func (r *Repository) GetScalarWeightedAverage(ctx context.Context, dataSourceID uuid.UUID, name string, startDate, endDate time.Time, metadataFilters map[string]string, weightKey string) (sum, weight float64, err error) {
+	w := pointWeightExpr(5)
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(value * weight * %s), 0), COALESCE(SUM(weight * %s), 0)
+	FROM measurement_points
+	WHERE data_source_id = $1 AND name = $2 AND timestamp >= $3 AND timestamp < $4`, w, w)
+
+	args := []interface{}{dataSourceID, name, startDate, endDate, weightKey}
+
+	if len(metadataFilters) > 0 {
+		filterJSON, err := json.Marshal(metadataFilters)
+		if err != nil {
+			return 0, 0, err
+		}
+		query += ` AND metadata @> $6`
+		args = append(args, filterJSON)
+	}
+
+	err = r.pool.QueryRow(ctx, query, args...).Scan(&sum, &weight)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return sum, weight, nil
+}
@@ -1,39 +1,75 @@
 package metric
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/devbydaniel/litekpi/internal/datasource"
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+	"github.com/devbydaniel/litekpi/internal/platform/externalid"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 )
 
 const maxSplitBySeries = 10 // Maximum number of series when using split_by
 
+var errComputeBudgetExceeded = errors.New("compute time budget exceeded")
+
 // Service handles metric business logic.
 type Service struct {
 	repo              *Repository
 	dataSourceService *datasource.Service
+	usageService      *usage.Service
+	audit             *writeaudit.Service
+	computeBudget     time.Duration // Total time allowed for one Compute call
+	metricTimeout     time.Duration // Time allowed for a single metric's queries
+	maxConcurrency    int           // Upper bound for a dashboard's compute concurrency
+	maxCacheTTL       time.Duration // Upper bound for a dashboard's cache TTL
+	cache             *computeCache
 }
 
 // NewService creates a new metric service.
-func NewService(repo *Repository, dataSourceService *datasource.Service) *Service {
+func NewService(repo *Repository, dataSourceService *datasource.Service, usageService *usage.Service, audit *writeaudit.Service, cfg *config.Config) *Service {
 	return &Service{
 		repo:              repo,
 		dataSourceService: dataSourceService,
+		usageService:      usageService,
+		audit:             audit,
+		computeBudget:     cfg.ComputeBudget,
+		metricTimeout:     cfg.ComputeMetricTimeout,
+		maxConcurrency:    max(cfg.ComputeMaxConcurrency, 1),
+		maxCacheTTL:       cfg.ComputeCacheMaxTTL,
+		cache:             newComputeCache(cfg.ComputeCacheMaxTTL),
 	}
 }
 
 // Create creates a new metric.
 // The caller is responsible for verifying dashboard ownership.
 func (s *Service) Create(ctx context.Context, orgID, dashboardID uuid.UUID, req CreateMetricRequest) (*Metric, error) {
+	var err error
+	if req.DataSourceID, err = s.dataSourceService.ResolveDataSourceID(ctx, orgID, req.DataSourceID); err != nil {
+		return nil, err
+	}
+	if err := s.ApplyDefaults(ctx, orgID, &req); err != nil {
+		return nil, err
+	}
 	if err := s.validateCreateRequest(ctx, orgID, req); err != nil {
 		return nil, err
 	}
+	if req.ExternalID != nil {
+		if err := externalid.Validate(*req.ExternalID); err != nil {
+			return nil, err
+		}
+	}
 
 	maxPos, err := s.repo.GetMaxPosition(ctx, dashboardID)
 	if err != nil {
@@ -44,11 +80,21 @@ func (s *Service) Create(ctx context.Context, orgID, dashboardID uuid.UUID, req
 	if err != nil {
 		return nil, fmt.Errorf("failed to create metric: %w", err)
 	}
+	s.audit.Record(ctx, writeaudit.EntityMetric, m.ID, writeaudit.ActionCreate, nil, m)
 
 	return m, nil
 }
 
 func (s *Service) validateCreateRequest(ctx context.Context, orgID uuid.UUID, req CreateMetricRequest) error {
+	if err := ValidateConfig(req); err != nil {
+		return err
+	}
+	return s.verifyDataSource(ctx, orgID, req.DataSourceID)
+}
+
+// ValidateConfig validates a metric configuration without checking that its
+// data source exists, e.g. before the data source is created.
+func ValidateConfig(req CreateMetricRequest) error {
 	// Validate label
 	label := strings.TrimSpace(req.Label)
 	if label == "" {
@@ -58,6 +104,42 @@ func (s *Service) validateCreateRequest(ctx context.Context, orgID uuid.UUID, re
 		return ErrLabelTooLong
 	}
 
+	if err := validateQueryFields(req); err != nil {
+		return err
+	}
+
+	// Chart type is required to display a saved time series
+	if req.DisplayMode == DisplayModeTimeSeries {
+		if req.ChartType == nil || !req.ChartType.IsValid() {
+			return ErrChartTypeRequired
+		}
+	}
+
+	if err := validateStacking(req.DisplayMode, req.ChartType, req.SplitBy, req.CompositeSeries, req.Stacking); err != nil {
+		return err
+	}
+
+	// Validate comparison display type if comparison is enabled
+	if req.ComparisonEnabled != nil && *req.ComparisonEnabled && req.ComparisonDisplayType != nil {
+		if !req.ComparisonDisplayType.IsValid() {
+			return ErrInvalidComparisonType
+		}
+	}
+
+	return validateRefreshInterval(req.RefreshIntervalSeconds)
+}
+
+// validateQuery validates the query fields of a metric, i.e. everything
+// needed to compute it, and verifies data source ownership.
+func (s *Service) validateQuery(ctx context.Context, orgID uuid.UUID, req CreateMetricRequest) error {
+	if err := validateQueryFields(req); err != nil {
+		return err
+	}
+	return s.verifyDataSource(ctx, orgID, req.DataSourceID)
+}
+
+// validateQueryFields validates the query fields of a metric.
+func validateQueryFields(req CreateMetricRequest) error {
 	// Validate measurement name
 	if strings.TrimSpace(req.MeasurementName) == "" {
 		return ErrMeasurementNameEmpty
@@ -73,7 +155,7 @@ func (s *Service) validateCreateRequest(ctx context.Context, orgID uuid.UUID, re
 		return ErrInvalidAggregation
 	}
 
-	// Validate aggregation_key for count_unique
+	// Validate aggregation_key for count_unique and weighted_average
 	if req.Aggregation.RequiresAggregationKey() {
 		if req.AggregationKey == nil || strings.TrimSpace(*req.AggregationKey) == "" {
 			return ErrAggregationKeyRequired
@@ -91,26 +173,157 @@ func (s *Service) validateCreateRequest(ctx context.Context, orgID uuid.UUID, re
 		if req.Granularity == nil || !req.Granularity.IsValid() {
 			return ErrInvalidGranularity
 		}
-		if req.ChartType == nil || !req.ChartType.IsValid() {
-			return ErrChartTypeRequired
-		}
 	}
 	// For scalar, granularity should be nil (ignored if provided)
 
-	// Validate comparison display type if comparison is enabled
-	if req.ComparisonEnabled && req.ComparisonDisplayType != nil {
-		if !req.ComparisonDisplayType.IsValid() {
-			return ErrInvalidComparisonType
+	if err := validateGeoQuery(req.DisplayMode, req.Aggregation, req.SplitBy); err != nil {
+		return err
+	}
+
+	if err := validateShareOf(req.DisplayMode, req.Aggregation, req.ShareOf); err != nil {
+		return err
+	}
+
+	if err := validateCompositeSeries(req.DisplayMode, req.SplitBy, req.CompositeSeries); err != nil {
+		return err
+	}
+
+	return validateOtherThreshold(req.DisplayMode, req.SplitBy, req.OtherThreshold)
+}
+
+// verifyDataSource verifies that a data source belongs to the organization.
+func (s *Service) verifyDataSource(ctx context.Context, orgID, dataSourceID uuid.UUID) error {
+	if _, err := s.dataSourceService.GetDataSource(ctx, orgID, dataSourceID); err != nil {
+		return fmt.Errorf("failed to verify data source: %w", err)
+	}
+	return nil
+}
+
+// Explore computes an ad-hoc query without saving it.
+// A query that fails to compute is returned with its Error field set,
+// the same way as a metric on a dashboard.
+func (s *Service) Explore(ctx context.Context, orgID uuid.UUID, q ExploreQuery) (*ComputedMetric, error) {
+	m, err := s.ExploreMetric(ctx, orgID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	computed := s.Compute(ctx, orgID, []Metric{*m})
+	return &computed[0], nil
+}
+
+// ExploreMetric validates an exploration query and returns the unsaved
+// metric that computes it.
+func (s *Service) ExploreMetric(ctx context.Context, orgID uuid.UUID, q ExploreQuery) (*Metric, error) {
+	var err error
+	if q.DataSourceID, err = s.dataSourceService.ResolveDataSourceID(ctx, orgID, q.DataSourceID); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateExploreQuery(ctx, orgID, q); err != nil {
+		return nil, err
+	}
+
+	m := &Metric{
+		Label:             q.MeasurementName,
+		DataSourceID:      q.DataSourceID,
+		MeasurementName:   q.MeasurementName,
+		Timeframe:         q.Timeframe,
+		DateFrom:          q.DateFrom,
+		DateTo:            q.DateTo,
+		Filters:           q.Filters,
+		Aggregation:       q.Aggregation,
+		AggregationKey:    q.AggregationKey,
+		Granularity:       q.Granularity,
+		DisplayMode:       q.DisplayMode,
+		ComparisonEnabled: q.ComparisonEnabled,
+		SplitBy:           q.SplitBy,
+		ShareOf:           q.ShareOf,
+		OtherThreshold:    q.OtherThreshold,
+	}
+	if m.Filters == nil {
+		m.Filters = []Filter{}
+	}
+	return m, nil
+}
+
+// ComparePeriods computes a query as a scalar over two explicit date ranges
+// and returns both values with the change between them.
+func (s *Service) ComparePeriods(ctx context.Context, orgID uuid.UUID, req ComparePeriodsRequest) (*ComparePeriodsResponse, error) {
+	for _, r := range []DateRange{req.Current, req.Baseline} {
+		if r.From.IsZero() || r.To.IsZero() || r.To.Before(r.From) {
+			return nil, ErrInvalidDateRange
 		}
 	}
 
-	// Verify data source ownership
-	_, err := s.dataSourceService.GetDataSource(ctx, orgID, req.DataSourceID)
+	q := req.Query
+	q.Timeframe = "custom"
+	q.DateFrom = &req.Current.From
+	q.DateTo = &req.Current.To
+	q.DisplayMode = DisplayModeScalar
+	q.Granularity = nil
+	q.ComparisonEnabled = false
+	q.SplitBy = nil
+	m, err := s.ExploreMetric(ctx, orgID, q)
 	if err != nil {
-		return fmt.Errorf("failed to verify data source: %w", err)
+		return nil, err
 	}
 
-	return nil
+	// Metering is best effort and must not block the comparison
+	_ = s.usageService.RecordComputeRequest(ctx, orgID)
+
+	ctx, cancel := context.WithTimeout(ctx, s.computeBudget)
+	defer cancel()
+
+	filters := make(map[string]string)
+	for _, f := range m.Filters {
+		filters[f.Key] = f.Value
+	}
+
+	resp := &ComparePeriodsResponse{}
+	for _, p := range []struct {
+		period DateRange
+		value  *PeriodValue
+	}{
+		{req.Current, &resp.Current},
+		{req.Baseline, &resp.Baseline},
+	} {
+		start, end := getTimeframeRange("custom", &p.period.From, &p.period.To, Calendar{})
+		metricCtx, cancelMetric := context.WithTimeout(ctx, s.metricTimeout)
+		value, err := s.aggregateScalarValue(metricCtx, *m, start, end, filters)
+		cancelMetric()
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute period %s to %s: %w", p.period.From.Format("2006-01-02"), p.period.To.Format("2006-01-02"), err)
+		}
+		*p.value = PeriodValue{From: p.period.From, To: p.period.To, Value: value}
+	}
+
+	resp.Change = resp.Current.Value - resp.Baseline.Value
+	if resp.Baseline.Value != 0 {
+		changePercent := (resp.Change / resp.Baseline.Value) * 100
+		resp.ChangePercent = &changePercent
+	}
+
+	return resp, nil
+}
+
+// ValidateExploreQuery validates an exploration query without computing it.
+// A query without a data source is checked against the organization's default.
+func (s *Service) ValidateExploreQuery(ctx context.Context, orgID uuid.UUID, q ExploreQuery) error {
+	var err error
+	if q.DataSourceID, err = s.dataSourceService.ResolveDataSourceID(ctx, orgID, q.DataSourceID); err != nil {
+		return err
+	}
+	return s.validateQuery(ctx, orgID, q.createRequest(q.MeasurementName))
+}
+
+// SaveExploration saves an exploration as a metric on a dashboard.
+// The caller is responsible for verifying dashboard ownership.
+func (s *Service) SaveExploration(ctx context.Context, orgID uuid.UUID, req SaveExplorationRequest) (*Metric, error) {
+	create := req.Query.createRequest(req.Label)
+	create.ChartType = req.ChartType
+	create.Stacking = req.Stacking
+	create.ComparisonDisplayType = req.ComparisonDisplayType
+	return s.Create(ctx, orgID, req.DashboardID, create)
 }
 
 // GetByDashboardID retrieves all metrics for a dashboard.
@@ -134,9 +347,63 @@ func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Metric, error) {
 	return m, nil
 }
 
-// Update updates a metric's configuration.
+// GetByExternalID returns the metric of a dashboard with the given external ID.
 // The caller is responsible for verifying dashboard ownership.
-func (s *Service) Update(ctx context.Context, dashboardID, metricID uuid.UUID, req UpdateMetricRequest) (*Metric, error) {
+func (s *Service) GetByExternalID(ctx context.Context, dashboardID uuid.UUID, externalID string) (*Metric, error) {
+	if err := externalid.Validate(externalID); err != nil {
+		return nil, err
+	}
+	m, err := s.repo.GetByExternalID(ctx, dashboardID, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric: %w", err)
+	}
+	if m == nil {
+		return nil, ErrMetricNotFound
+	}
+	return m, nil
+}
+
+// Put creates the metric of a dashboard with the given external ID, or
+// updates it to match req if it exists. It reports whether the metric was
+// created. Putting the same request again changes nothing; a request that
+// changes the data source or measurement fails with ErrFixedFields.
+// The caller is responsible for verifying dashboard ownership.
+func (s *Service) Put(ctx context.Context, orgID, dashboardID, userID uuid.UUID, externalID string, req CreateMetricRequest) (*Metric, bool, error) {
+	m, err := s.GetByExternalID(ctx, dashboardID, externalID)
+	if errors.Is(err, ErrMetricNotFound) {
+		req.ExternalID = &externalID
+		m, err = s.Create(ctx, orgID, dashboardID, req)
+		if !errors.Is(err, externalid.ErrTaken) {
+			return m, err == nil, err
+		}
+		// Created by a concurrent request since the lookup
+		m, err = s.GetByExternalID(ctx, dashboardID, externalID)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if req.DataSourceID, err = s.dataSourceService.ResolveDataSourceID(ctx, orgID, req.DataSourceID); err != nil {
+		return nil, false, err
+	}
+	if req.DataSourceID != m.DataSourceID || req.MeasurementName != m.MeasurementName {
+		return nil, false, ErrFixedFields
+	}
+
+	update := req.UpdateRequest()
+	if len(m.ConfigChanges(update)) == 0 {
+		return m, false, nil
+	}
+	m, err = s.Update(ctx, dashboardID, m.ID, userID, update, nil)
+	return m, false, err
+}
+
+// Update updates a metric's configuration. The configuration it replaces is
+// recorded as a version so the change can be reverted. If ifUnmodified is
+// set, the update fails with ErrPreconditionFailed unless the metric was last
+// updated at that time.
+// The caller is responsible for verifying dashboard ownership.
+func (s *Service) Update(ctx context.Context, dashboardID, metricID, userID uuid.UUID, req UpdateMetricRequest, ifUnmodified *time.Time) (*Metric, error) {
 	// Verify metric exists and belongs to dashboard
 	m, err := s.repo.GetByID(ctx, metricID)
 	if err != nil {
@@ -148,6 +415,9 @@ func (s *Service) Update(ctx context.Context, dashboardID, metricID uuid.UUID, r
 	if m.DashboardID != dashboardID {
 		return nil, ErrMetricNotFound
 	}
+	if ifUnmodified != nil && !m.UpdatedAt.Equal(*ifUnmodified) {
+		return nil, ErrPreconditionFailed
+	}
 
 	// Validate label
 	label := strings.TrimSpace(req.Label)
@@ -168,7 +438,7 @@ func (s *Service) Update(ctx context.Context, dashboardID, metricID uuid.UUID, r
 		return nil, ErrInvalidAggregation
 	}
 
-	// Validate aggregation_key for count_unique
+	// Validate aggregation_key for count_unique and weighted_average
 	if req.Aggregation.RequiresAggregationKey() {
 		if req.AggregationKey == nil || strings.TrimSpace(*req.AggregationKey) == "" {
 			return nil, ErrAggregationKeyRequired
@@ -192,6 +462,26 @@ func (s *Service) Update(ctx context.Context, dashboardID, metricID uuid.UUID, r
 	}
 	// For scalar, granularity should be nil (ignored if provided)
 
+	if err := validateGeoQuery(req.DisplayMode, req.Aggregation, req.SplitBy); err != nil {
+		return nil, err
+	}
+
+	if err := validateShareOf(req.DisplayMode, req.Aggregation, req.ShareOf); err != nil {
+		return nil, err
+	}
+
+	if err := validateCompositeSeries(req.DisplayMode, req.SplitBy, req.CompositeSeries); err != nil {
+		return nil, err
+	}
+
+	if err := validateOtherThreshold(req.DisplayMode, req.SplitBy, req.OtherThreshold); err != nil {
+		return nil, err
+	}
+
+	if err := validateStacking(req.DisplayMode, req.ChartType, req.SplitBy, req.CompositeSeries, req.Stacking); err != nil {
+		return nil, err
+	}
+
 	// Validate comparison display type if comparison is enabled
 	if req.ComparisonEnabled && req.ComparisonDisplayType != nil {
 		if !req.ComparisonDisplayType.IsValid() {
@@ -199,15 +489,112 @@ func (s *Service) Update(ctx context.Context, dashboardID, metricID uuid.UUID, r
 		}
 	}
 
-	if err := s.repo.Update(ctx, metricID, req); err != nil {
+	if err := validateRefreshInterval(req.RefreshIntervalSeconds); err != nil {
+		return nil, err
+	}
+
+	// Unchanged saves don't clutter the history
+	previous := m.updateRequest()
+	if !sameConfig(previous, req) {
+		if err := s.repo.CreateVersion(ctx, metricID, previous, userID); err != nil {
+			return nil, fmt.Errorf("failed to record metric version: %w", err)
+		}
+	}
+
+	updated, err := s.repo.Update(ctx, metricID, req, ifUnmodified)
+	if err != nil {
 		return nil, fmt.Errorf("failed to update metric: %w", err)
 	}
+	if !updated {
+		return nil, ErrPreconditionFailed
+	}
 
 	// Return updated metric
-	return s.repo.GetByID(ctx, metricID)
+	after, err := s.repo.GetByID(ctx, metricID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, writeaudit.EntityMetric, metricID, writeaudit.ActionUpdate, m, after)
+	return after, nil
+}
+
+// ListVersions returns the previous configurations of a metric, newest first.
+// The caller is responsible for verifying dashboard ownership.
+func (s *Service) ListVersions(ctx context.Context, dashboardID, metricID uuid.UUID) ([]MetricVersion, error) {
+	m, err := s.repo.GetByID(ctx, metricID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric: %w", err)
+	}
+	if m == nil || m.DashboardID != dashboardID {
+		return nil, ErrMetricNotFound
+	}
+
+	versions, err := s.repo.GetVersions(ctx, metricID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metric versions: %w", err)
+	}
+	if versions == nil {
+		versions = []MetricVersion{}
+	}
+	return versions, nil
+}
+
+// ListConfigChanges returns the updates to an organization's metric
+// configurations in [from, to), newest first, with the fields each changed.
+// Changes to metrics in the trash are included with unknown fields when
+// they were the metric's last change.
+func (s *Service) ListConfigChanges(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]ConfigChange, error) {
+	versions, err := s.repo.GetVersionChangesInRange(ctx, orgID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metric versions: %w", err)
+	}
+
+	changes := make([]ConfigChange, 0, len(versions))
+	for _, v := range versions {
+		after := v.after
+		if after == nil {
+			// The latest version was replaced by the current configuration
+			m, err := s.repo.GetByID(ctx, v.metricID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get metric: %w", err)
+			}
+			if m != nil {
+				current := m.updateRequest()
+				after = &current
+			}
+		}
+
+		change := ConfigChange{
+			MetricID:    v.metricID,
+			DashboardID: v.dashboardID,
+			Label:       v.before.Label,
+			ChangedBy:   v.createdBy,
+			ChangedAt:   v.createdAt,
+		}
+		if after != nil {
+			change.Label = after.Label
+			change.ChangedFields = changedFields(v.before, *after)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// RevertToVersion restores a previous configuration of a metric. The revert
+// is an update itself, so the configuration it replaces becomes a new version.
+// The caller is responsible for verifying dashboard ownership.
+func (s *Service) RevertToVersion(ctx context.Context, dashboardID, metricID, userID uuid.UUID, version int, ifUnmodified *time.Time) (*Metric, error) {
+	v, err := s.repo.GetVersion(ctx, metricID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric version: %w", err)
+	}
+	if v == nil {
+		return nil, ErrVersionNotFound
+	}
+	return s.Update(ctx, dashboardID, metricID, userID, v.Config, ifUnmodified)
 }
 
-// Delete deletes a metric.
+// Delete moves a metric to the trash.
 // The caller is responsible for verifying dashboard ownership.
 func (s *Service) Delete(ctx context.Context, dashboardID, metricID uuid.UUID) error {
 	// Verify metric exists and belongs to dashboard
@@ -225,36 +612,433 @@ func (s *Service) Delete(ctx context.Context, dashboardID, metricID uuid.UUID) e
 	if err := s.repo.Delete(ctx, metricID); err != nil {
 		return fmt.Errorf("failed to delete metric: %w", err)
 	}
+	s.audit.Record(ctx, writeaudit.EntityMetric, metricID, writeaudit.ActionDelete, m, nil)
+	return nil
+}
+
+// ListDeleted returns the trashed metrics of an organization.
+func (s *Service) ListDeleted(ctx context.Context, orgID uuid.UUID) ([]TrashedMetric, error) {
+	metrics, err := s.repo.GetDeletedByOrganizationID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted metrics: %w", err)
+	}
+	if metrics == nil {
+		metrics = []TrashedMetric{}
+	}
+	return metrics, nil
+}
+
+// Restore takes a metric out of the trash.
+func (s *Service) Restore(ctx context.Context, orgID, metricID uuid.UUID) error {
+	restored, err := s.repo.Restore(ctx, orgID, metricID)
+	if err != nil {
+		return fmt.Errorf("failed to restore metric: %w", err)
+	}
+	if !restored {
+		return ErrMetricNotFound
+	}
+	s.audit.Record(ctx, writeaudit.EntityMetric, metricID, writeaudit.ActionRestore, nil, nil)
 	return nil
 }
 
-// Reorder reorders metrics on a dashboard.
+// PurgeDeleted permanently deletes metrics trashed before the given time.
+func (s *Service) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.repo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted metrics: %w", err)
+	}
+	for id, orgID := range purged {
+		s.audit.RecordPurge(ctx, orgID, writeaudit.EntityMetric, id)
+	}
+	return int64(len(purged)), nil
+}
+
+// Reorder reorders metrics on a dashboard. metricIDs must list every metric
+// on the dashboard. If ifUnmodified is set, the reorder fails with
+// ErrPreconditionFailed unless the dashboard was last updated at that time.
 // The caller is responsible for verifying dashboard ownership.
-func (s *Service) Reorder(ctx context.Context, dashboardID uuid.UUID, metricIDs []uuid.UUID) error {
-	if err := s.repo.UpdatePositions(ctx, dashboardID, metricIDs); err != nil {
+func (s *Service) Reorder(ctx context.Context, dashboardID uuid.UUID, metricIDs []uuid.UUID, ifUnmodified *time.Time) error {
+	err := s.repo.UpdatePositions(ctx, dashboardID, metricIDs, ifUnmodified)
+	if errors.Is(err, ErrPreconditionFailed) || errors.Is(err, ErrInvalidMetricOrder) {
+		return err
+	}
+	if err != nil {
 		return fmt.Errorf("failed to reorder metrics: %w", err)
 	}
+	s.audit.RecordPart(ctx, writeaudit.EntityDashboard, dashboardID, "metricOrder", nil, metricIDs)
 	return nil
 }
 
+// History returns the most recent writes to a metric of an organization.
+func (s *Service) History(ctx context.Context, orgID, metricID uuid.UUID) ([]writeaudit.Entry, error) {
+	return s.audit.History(ctx, orgID, writeaudit.EntityMetric, metricID)
+}
+
+// ComputeByID calculates the values of a single metric on a dashboard.
+// The caller is responsible for verifying dashboard ownership.
+func (s *Service) ComputeByID(ctx context.Context, orgID, dashboardID, metricID uuid.UUID) (*ComputedMetric, error) {
+	m, err := s.repo.GetByID(ctx, metricID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric: %w", err)
+	}
+	if m == nil || m.DashboardID != dashboardID {
+		return nil, ErrMetricNotFound
+	}
+
+	computed := s.Compute(ctx, orgID, []Metric{*m})
+	return &computed[0], nil
+}
+
 // Compute calculates the values for a list of metrics.
-func (s *Service) Compute(ctx context.Context, metrics []Metric) ([]ComputedMetric, error) {
+// A metric that fails to compute does not fail the others; instead its
+// Error field is set so clients can render it individually.
+// Each metric is bounded by the metric timeout and the whole call by the
+// compute budget, so one slow query cannot stall the entire dashboard.
+func (s *Service) Compute(ctx context.Context, orgID uuid.UUID, metrics []Metric) []ComputedMetric {
+	return s.compute(ctx, orgID, metrics, s.computeBudget, s.metricTimeout, 1, nil)
+}
+
+// ComputeDashboard calculates metrics like Compute, with a dashboard's
+// compute settings. Results computed less than the cache TTL ago are reused
+// instead of querying again; only metrics that computed without error are
+// cached.
+func (s *Service) ComputeDashboard(ctx context.Context, orgID uuid.UUID, metrics []Metric, opts ComputeOptions) []ComputedMetric {
+	concurrency := min(max(opts.Concurrency, 1), s.maxConcurrency)
+	ttl := min(opts.CacheTTL, s.maxCacheTTL)
+	if ttl <= 0 {
+		return s.compute(ctx, orgID, metrics, s.computeBudget, s.metricTimeout, concurrency, nil)
+	}
+
+	now := time.Now().UTC()
+	computed := make([]ComputedMetric, len(metrics))
+	var missed []Metric
+	var missedAt []int
+	backfilledAt := s.backfilledAt(ctx, orgID, metrics)
+	for i, m := range metrics {
+		if cached, ok := s.cache.get(m, ttl, now, backfilledAt[m.DataSourceID]); ok {
+			computed[i] = cached
+			continue
+		}
+		missed = append(missed, m)
+		missedAt = append(missedAt, i)
+	}
+	if len(missed) == 0 {
+		return computed
+	}
+
+	for j, c := range s.compute(ctx, orgID, missed, s.computeBudget, s.metricTimeout, concurrency, nil) {
+		if c.Error == nil {
+			// Recomputing before the TTL passes would only return the cached value
+			c.RefreshAfterSeconds = max(c.RefreshAfterSeconds, int(ttl/time.Second))
+			s.cache.put(c, now)
+		}
+		computed[missedAt[j]] = c
+	}
+	return computed
+}
+
+// backfilledAt returns when late data last landed in each data source of
+// metrics. Lookup errors are left for compute to report.
+func (s *Service) backfilledAt(ctx context.Context, orgID uuid.UUID, metrics []Metric) map[uuid.UUID]time.Time {
+	backfilled := make(map[uuid.UUID]time.Time)
+	checked := make(map[uuid.UUID]bool)
+	for _, m := range metrics {
+		if checked[m.DataSourceID] {
+			continue
+		}
+		checked[m.DataSourceID] = true
+		ds, err := s.dataSourceService.GetDataSource(ctx, orgID, m.DataSourceID)
+		if err == nil && ds.BackfilledAt != nil {
+			backfilled[m.DataSourceID] = *ds.BackfilledAt
+		}
+	}
+	return backfilled
+}
+
+// ComputeWithBudget calculates metrics like Compute, but lets both the
+// whole call and each metric run for up to budget. It is meant for work
+// outside the request path, such as compute jobs. If set, progress is
+// called after each metric with the number of metrics computed so far.
+func (s *Service) ComputeWithBudget(ctx context.Context, orgID uuid.UUID, metrics []Metric, budget time.Duration, progress func(completed int)) []ComputedMetric {
+	return s.compute(ctx, orgID, metrics, budget, budget, 1, progress)
+}
+
+func (s *Service) compute(ctx context.Context, orgID uuid.UUID, metrics []Metric, budget, metricTimeout time.Duration, concurrency int, progress func(completed int)) []ComputedMetric {
+	// Metering is best effort and must not block the dashboard
+	_ = s.usageService.RecordComputeRequest(ctx, orgID)
+
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
 	computed := make([]ComputedMetric, len(metrics))
+	dataSources := make(map[uuid.UUID]*datasource.DataSource)
+	dataSourceErrs := make(map[uuid.UUID]error)
+	now := time.Now().UTC()
 
+	// Fiscal timeframes need the organization's calendar; other metrics
+	// compute without it
+	cal, calErr := s.repo.GetCalendar(ctx, orgID)
+	if calErr != nil {
+		cal = &Calendar{}
+	}
+
+	// Look up each data source once, before metrics are computed in parallel
+	for _, m := range metrics {
+		if _, checked := dataSourceErrs[m.DataSourceID]; checked || ctx.Err() != nil {
+			continue
+		}
+		dataSources[m.DataSourceID], dataSourceErrs[m.DataSourceID] = s.dataSourceService.GetDataSource(ctx, orgID, m.DataSourceID)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex // Guards completed
+	completed := 0
+	slots := make(chan struct{}, concurrency)
 	for i, m := range metrics {
-		result, err := s.computeOne(ctx, m)
+		m.calendar = *cal
+		slots <- struct{}{}
+		if err := ctx.Err(); err != nil {
+			<-slots
+			computed[i] = failedMetric(m, errComputeBudgetExceeded)
+			continue
+		}
+		if dsErr := dataSourceErrs[m.DataSourceID]; dsErr != nil {
+			<-slots
+			computed[i] = failedMetric(m, dsErr)
+			continue
+		}
+		if calErr != nil && fiscalTimeframes[m.Timeframe] {
+			<-slots
+			computed[i] = failedMetric(m, fmt.Errorf("failed to get calendar: %w", calErr))
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, m Metric) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			metricCtx, cancelMetric := context.WithTimeout(ctx, metricTimeout)
+			result, err := s.computeOne(metricCtx, m)
+			cancelMetric()
+			if err != nil {
+				computed[i] = failedMetric(m, fmt.Errorf("failed to compute metric %s: %w", m.ID, err))
+			} else {
+				computed[i] = *result
+			}
+			if progress != nil {
+				mu.Lock()
+				completed++
+				progress(completed)
+				mu.Unlock()
+			}
+		}(i, m)
+	}
+	wg.Wait()
+
+	// Attach default formats; they are cosmetic, so lookup errors are ignored
+	formats := make(map[uuid.UUID]map[string]ValueFormat)
+	for i := range computed {
+		m := computed[i].Metric
+		if m.ShareOf != nil && m.DisplayMode == DisplayModeScalar {
+			computed[i].Format = &ValueFormat{SemanticType: semanticTypePercent}
+			continue
+		}
+		if m.Aggregation == AggregationCount || m.Aggregation == AggregationCountUnique {
+			computed[i].Format = &ValueFormat{SemanticType: semanticTypeCount}
+			continue
+		}
+		byName, ok := formats[m.DataSourceID]
+		if !ok && ctx.Err() == nil {
+			byName, _ = s.repo.GetMeasurementFormats(ctx, m.DataSourceID)
+			formats[m.DataSourceID] = byName
+		}
+		if f, ok := byName[m.MeasurementName]; ok {
+			computed[i].Format = &f
+		}
+	}
+
+	// Name the fiscal period of metrics with fiscal timeframes
+	for i := range computed {
+		m := computed[i].Metric
+		if computed[i].Error != nil || !fiscalTimeframes[m.Timeframe] {
+			continue
+		}
+		start, _ := getTimeframeRange(m.Timeframe, m.DateFrom, m.DateTo, m.calendar)
+		computed[i].PeriodLabel = m.calendar.fiscalPeriodLabel(m.Timeframe, start)
+	}
+
+	// Annotate charts with maintenance of their data source; also best effort
+	for i := range computed {
+		m := computed[i].Metric
+		if m.DisplayMode != DisplayModeTimeSeries || computed[i].Error != nil || ctx.Err() != nil {
+			continue
+		}
+		start, end := getTimeframeRange(m.Timeframe, m.DateFrom, m.DateTo, m.calendar)
+		windows, err := s.dataSourceService.GetMaintenanceWindowsInRange(ctx, m.DataSourceID, start, end)
 		if err != nil {
-			return nil, fmt.Errorf("failed to compute metric %s: %w", m.ID, err)
+			continue
+		}
+		for _, w := range windows {
+			computed[i].Annotations = append(computed[i].Annotations, Annotation{
+				Type:     AnnotationTypeMaintenance,
+				StartsAt: w.StartsAt,
+				EndsAt:   w.EndsAt,
+				Label:    w.Reason,
+			})
 		}
-		computed[i] = *result
 	}
 
-	return computed, nil
+	// Mark the buckets the data source may still receive data for
+	for i := range computed {
+		m := computed[i].Metric
+		ds := dataSources[m.DataSourceID]
+		if computed[i].Error != nil || ds == nil {
+			continue
+		}
+		computed[i].CompleteThrough = ds.MaxTimestamp
+		if m.DisplayMode != DisplayModeTimeSeries || m.Granularity == nil {
+			continue
+		}
+		markPartial(computed[i].DataPoints, ds.MaxTimestamp, *m.Granularity)
+		for j := range computed[i].Series {
+			markPartial(computed[i].Series[j].DataPoints, ds.MaxTimestamp, *m.Granularity)
+		}
+	}
+
+	// Number the weeks of weekly charts
+	for i := range computed {
+		m := computed[i].Metric
+		if computed[i].Error != nil || m.DisplayMode != DisplayModeTimeSeries || m.Granularity == nil || *m.Granularity != GranularityWeekly {
+			continue
+		}
+		m.calendar.labelWeeks(computed[i].DataPoints)
+		for j := range computed[i].Series {
+			m.calendar.labelWeeks(computed[i].Series[j].DataPoints)
+		}
+	}
+
+	// Flag metrics whose measurement missed its freshness SLA; also best effort
+	slas := make(map[uuid.UUID]map[string]string)
+	for i := range computed {
+		m := computed[i].Metric
+		if computed[i].Error != nil || ctx.Err() != nil {
+			continue
+		}
+		byName, ok := slas[m.DataSourceID]
+		if !ok {
+			byName, _ = s.repo.GetFreshnessSLAs(ctx, m.DataSourceID)
+			slas[m.DataSourceID] = byName
+		}
+		frequency, ok := byName[m.MeasurementName]
+		if !ok {
+			continue
+		}
+		last, err := s.repo.GetLastDataAt(ctx, m.DataSourceID, m.MeasurementName)
+		if err != nil {
+			continue
+		}
+		computed[i].ExpectedFrequency = &frequency
+		computed[i].LastDataAt = last
+		computed[i].Stale = last == nil || now.Sub(*last) > frequencyIntervals[frequency]
+	}
+
+	// Explain empty results; like formats, this is best effort and a status
+	// is left unset if it cannot be determined
+	for i := range computed {
+		if computed[i].Error != nil {
+			continue
+		}
+		if computed[i].hasData() {
+			computed[i].Status = DataStatusOK
+			continue
+		}
+		if ctx.Err() != nil {
+			continue
+		}
+		m := computed[i].Metric
+		if status, err := s.emptyStatus(ctx, m, dataSources[m.DataSourceID], now); err == nil {
+			computed[i].Status = status
+		}
+	}
+
+	// Attach freshness hints to every result, failed or not
+	for i := range computed {
+		computed[i].ComputedAt = now
+		computed[i].CacheStatus = CacheStatusMiss
+		computed[i].RefreshAfterSeconds = refreshAfterSeconds(computed[i].Metric, now)
+	}
+
+	return computed
+}
+
+// ComputeWeekOverWeek computes each metric as a scalar over the seven days
+// starting at weekStart, compared with the seven days before. Display
+// settings of the metrics are ignored; only their query is reused.
+func (s *Service) ComputeWeekOverWeek(ctx context.Context, orgID uuid.UUID, metrics []Metric, weekStart time.Time) []ComputedMetric {
+	dateFrom := weekStart
+	dateTo := weekStart.AddDate(0, 0, 6) // Inclusive end date
+
+	weekly := make([]Metric, len(metrics))
+	for i, m := range metrics {
+		m.DisplayMode = DisplayModeScalar
+		m.Timeframe = "custom"
+		m.DateFrom = &dateFrom
+		m.DateTo = &dateTo
+		m.ComparisonEnabled = true
+		weekly[i] = m
+	}
+
+	return s.Compute(ctx, orgID, weekly)
+}
+
+// emptyStatus explains why m computed to an empty result. The data source is
+// blamed first, since a stalled integration also explains the other cases.
+func (s *Service) emptyStatus(ctx context.Context, m Metric, ds *datasource.DataSource, now time.Time) (DataStatus, error) {
+	start, end := getTimeframeRange(m.Timeframe, m.DateFrom, m.DateTo, m.calendar)
+	filters := make(map[string]string)
+	for _, f := range m.Filters {
+		filters[f.Key] = f.Value
+	}
+
+	inRange, everSeen, err := s.repo.GetMeasurementPresence(ctx, m.DataSourceID, m.MeasurementName, start, end, filters)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case inRange:
+		return DataStatusOK, nil // The data aggregates to zero
+	case ds != nil && (ds.LastUsedAt == nil || now.Sub(*ds.LastUsedAt) > RecentIngestWindow):
+		return DataStatusNoRecentIngests, nil
+	case !everSeen:
+		return DataStatusMeasurementNeverSeen, nil
+	default:
+		return DataStatusNoDataInRange, nil
+	}
+}
+
+// failedMetric builds a ComputedMetric carrying a client-facing error message.
+func failedMetric(m Metric, err error) ComputedMetric {
+	msg := computeErrFailed
+	switch {
+	case errors.Is(err, errComputeBudgetExceeded):
+		msg = computeErrBudgetExceeded
+	case isQueryTimeout(err):
+		msg = computeErrTimeout
+	case errors.Is(err, datasource.ErrDataSourceNotFound):
+		msg = computeErrDataSourceDeleted
+	case errors.Is(err, datasource.ErrUnauthorized):
+		msg = computeErrDataSourceInaccessible
+	}
+	return ComputedMetric{Metric: m, Error: &msg, err: err}
 }
 
 func (s *Service) computeOne(ctx context.Context, m Metric) (*ComputedMetric, error) {
 	// Calculate date ranges
-	currentStart, currentEnd := getTimeframeRange(m.Timeframe, m.DateFrom, m.DateTo)
+	currentStart, currentEnd := getTimeframeRange(m.Timeframe, m.DateFrom, m.DateTo, m.calendar)
 
 	// Build metadata filters
 	filters := make(map[string]string)
@@ -269,12 +1053,20 @@ func (s *Service) computeOne(ctx context.Context, m Metric) (*ComputedMetric, er
 		return s.computeScalar(ctx, m, currentStart, currentEnd, filters)
 	case DisplayModeTimeSeries:
 		return s.computeTimeSeries(ctx, m, currentStart, currentEnd, filters)
+	case DisplayModeGeo:
+		return s.computeGeo(ctx, m, currentStart, currentEnd, filters)
+	case DisplayModeHeatmap:
+		return s.computeHeatmap(ctx, m, currentStart, currentEnd, filters)
 	}
 
 	return computed, nil
 }
 
 func (s *Service) computeScalar(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) (*ComputedMetric, error) {
+	if m.ShareOf != nil {
+		return s.computeShare(ctx, m, start, end, filters)
+	}
+
 	computed := &ComputedMetric{Metric: m}
 
 	// Get current value
@@ -306,6 +1098,69 @@ func (s *Service) computeScalar(ctx context.Context, m Metric, start, end time.T
 	return computed, nil
 }
 
+// computeShare computes a scalar metric as a percentage of its denominator.
+// The value is omitted when the denominator is zero; the comparison then
+// reports the previous share only.
+func (s *Service) computeShare(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) (*ComputedMetric, error) {
+	computed := &ComputedMetric{Metric: m}
+
+	numerator, denominator, err := s.aggregateShare(ctx, m, start, end, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current period data: %w", err)
+	}
+	computed.Numerator = &numerator
+	computed.Denominator = &denominator
+	computed.Value = sharePercent(numerator, denominator)
+
+	if m.ComparisonEnabled {
+		previousStart, previousEnd := getPreviousTimeframeRange(m.Timeframe, start, end)
+
+		previousNumerator, previousDenominator, err := s.aggregateShare(ctx, m, previousStart, previousEnd, filters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous period data: %w", err)
+		}
+		computed.PreviousValue = sharePercent(previousNumerator, previousDenominator)
+
+		// Change is in percentage points
+		if computed.Value != nil && computed.PreviousValue != nil {
+			change := *computed.Value - *computed.PreviousValue
+			computed.Change = &change
+			if *computed.PreviousValue != 0 {
+				changePercent := (change / *computed.PreviousValue) * 100
+				computed.ChangePercent = &changePercent
+			}
+		}
+	}
+
+	return computed, nil
+}
+
+// aggregateShare returns the metric's value and the value of its denominator.
+func (s *Service) aggregateShare(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) (numerator, denominator float64, err error) {
+	numerator, err = s.aggregateScalarValue(ctx, m, start, end, filters)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	denominatorFilters := make(map[string]string)
+	for _, f := range m.ShareOf.Filters {
+		denominatorFilters[f.Key] = f.Value
+	}
+	denominator, err = s.aggregateScalarValue(ctx, m, start, end, denominatorFilters)
+	if err != nil {
+		return 0, 0, err
+	}
+	return numerator, denominator, nil
+}
+
+func sharePercent(numerator, denominator float64) *float64 {
+	if denominator == 0 {
+		return nil
+	}
+	share := numerator / denominator * 100
+	return &share
+}
+
 func (s *Service) aggregateScalarValue(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) (float64, error) {
 	switch m.Aggregation {
 	case AggregationCountUnique:
@@ -333,6 +1188,13 @@ func (s *Service) aggregateScalarValue(ctx context.Context, m Metric, start, end
 		}
 		return sum / float64(count), nil
 
+	case AggregationWeightedAverage:
+		sum, weight, err := s.repo.GetScalarWeightedAverage(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, *m.AggregationKey)
+		if err != nil {
+			return 0, err
+		}
+		return aggregateValue(m.Aggregation, sum, weight), nil
+
 	default: // sum
 		sum, _, err := s.repo.GetScalarAggregate(ctx, m.DataSourceID, m.MeasurementName, start, end, filters)
 		if err != nil {
@@ -349,11 +1211,23 @@ func (s *Service) computeTimeSeries(ctx context.Context, m Metric, start, end ti
 
 	computed := &ComputedMetric{Metric: m}
 
-	if m.SplitBy != nil && *m.SplitBy != "" {
+	if len(m.CompositeSeries) > 0 {
+		series, err := s.getCompositeSeries(ctx, m, start, end, filters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get composite time series data: %w", err)
+		}
+		if m.Stacking != nil && *m.Stacking == StackingPercentStacked {
+			series = normalizeSeriesPerBucket(series)
+		}
+		computed.Series = series
+	} else if m.SplitBy != nil && *m.SplitBy != "" {
 		series, err := s.getTimeSeriesSplitBy(ctx, m, start, end, filters)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get split time series data: %w", err)
 		}
+		if m.Stacking != nil && *m.Stacking == StackingPercentStacked {
+			series = normalizeSeriesPerBucket(series)
+		}
 		computed.Series = series
 	} else {
 		dataPoints, err := s.getTimeSeriesData(ctx, m, start, end, filters)
@@ -361,17 +1235,53 @@ func (s *Service) computeTimeSeries(ctx context.Context, m Metric, start, end ti
 			return nil, fmt.Errorf("failed to get time series data: %w", err)
 		}
 		computed.DataPoints = dataPoints
+
+		if m.ComparisonEnabled {
+			previousStart, previousEnd := getPreviousTimeframeRange(m.Timeframe, start, end)
+			previous, err := s.getTimeSeriesData(ctx, m, previousStart, previousEnd, filters)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get previous period data: %w", err)
+			}
+			computed.ComparisonDataPoints = alignComparison(previous, previousStart, start, end, *m.Granularity, m.calendar.WeekStart)
+		}
 	}
 
 	return computed, nil
 }
 
+// getCompositeSeries aggregates each composite series of m like m, using
+// the series' measurement and its filters on top of m's.
+func (s *Service) getCompositeSeries(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) ([]SplitSeries, error) {
+	series := make([]SplitSeries, 0, len(m.CompositeSeries))
+	for _, cs := range m.CompositeSeries {
+		sm := m
+		sm.MeasurementName = cs.MeasurementName
+		seriesFilters := make(map[string]string, len(filters)+len(cs.Filters))
+		for k, v := range filters {
+			seriesFilters[k] = v
+		}
+		for _, f := range cs.Filters {
+			seriesFilters[f.Key] = f.Value
+		}
+
+		dataPoints, err := s.getTimeSeriesData(ctx, sm, start, end, seriesFilters)
+		if err != nil {
+			return nil, fmt.Errorf("series %q: %w", cs.Label, err)
+		}
+		if dataPoints == nil {
+			dataPoints = []DataPoint{}
+		}
+		series = append(series, SplitSeries{Key: cs.Label, DataPoints: dataPoints})
+	}
+	return series, nil
+}
+
 func (s *Service) getTimeSeriesData(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) ([]DataPoint, error) {
 	granularity := *m.Granularity // Already validated in computeTimeSeries
 
 	switch m.Aggregation {
 	case AggregationCountUnique:
-		data, err := s.repo.GetCountUniqueMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, *m.AggregationKey, granularity)
+		data, err := s.repo.GetCountUniqueMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, *m.AggregationKey, granularity, m.calendar.WeekStart)
 		if err != nil {
 			return nil, err
 		}
@@ -384,8 +1294,11 @@ func (s *Service) getTimeSeriesData(ctx context.Context, m Metric, start, end ti
 		}
 		return dataPoints, nil
 
+	case AggregationWeightedAverage:
+		return s.repo.GetWeightedAverageMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, *m.AggregationKey, granularity, m.calendar.WeekStart)
+
 	case AggregationCount:
-		data, err := s.repo.GetAggregatedMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, granularity)
+		data, err := s.repo.GetAggregatedMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, granularity, m.calendar.WeekStart)
 		if err != nil {
 			return nil, err
 		}
@@ -399,7 +1312,7 @@ func (s *Service) getTimeSeriesData(ctx context.Context, m Metric, start, end ti
 		return dataPoints, nil
 
 	case AggregationAverage:
-		data, err := s.repo.GetAggregatedMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, granularity)
+		data, err := s.repo.GetAggregatedMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, granularity, m.calendar.WeekStart)
 		if err != nil {
 			return nil, err
 		}
@@ -417,7 +1330,7 @@ func (s *Service) getTimeSeriesData(ctx context.Context, m Metric, start, end ti
 		return dataPoints, nil
 
 	default: // sum
-		data, err := s.repo.GetAggregatedMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, granularity)
+		data, err := s.repo.GetAggregatedMeasurements(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, granularity, m.calendar.WeekStart)
 		if err != nil {
 			return nil, err
 		}
@@ -432,11 +1345,41 @@ func (s *Service) getTimeSeriesData(ctx context.Context, m Metric, start, end ti
 	}
 }
 
+func (s *Service) computeGeo(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) (*ComputedMetric, error) {
+	if m.SplitBy == nil || *m.SplitBy == "" {
+		return nil, ErrSplitByRequired
+	}
+
+	totals, err := s.repo.GetScalarAggregateSplitBy(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, *m.SplitBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geo data: %w", err)
+	}
+
+	return &ComputedMetric{Metric: m, Geo: buildGeoResult(totals, m.Aggregation)}, nil
+}
+
+func (s *Service) computeHeatmap(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) (*ComputedMetric, error) {
+	cells, err := s.repo.GetHeatmapCells(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, m.Aggregation, m.AggregationKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get heatmap data: %w", err)
+	}
+
+	values := make([][]float64, 7)
+	for d := range values {
+		values[d] = make([]float64, 24)
+	}
+	for _, c := range cells {
+		values[c.Weekday-1][c.Hour] = c.Value
+	}
+
+	return &ComputedMetric{Metric: m, Heatmap: &HeatmapResult{Values: values}}, nil
+}
+
 func (s *Service) getTimeSeriesSplitBy(ctx context.Context, m Metric, start, end time.Time, filters map[string]string) ([]SplitSeries, error) {
 	granularity := *m.Granularity // Already validated in computeTimeSeries
 
 	// Note: count_unique with split_by would require different query logic
-	// For now, we only support sum/average/count with split_by
+	// For now, we only support sum/average/count/weighted_average with split_by
 	if m.Aggregation == AggregationCountUnique {
 		// Fall back to non-split behavior for count_unique
 		dataPoints, err := s.getTimeSeriesData(ctx, m, start, end, filters)
@@ -446,18 +1389,218 @@ func (s *Service) getTimeSeriesSplitBy(ctx context.Context, m Metric, start, end
 		return []SplitSeries{{Key: "total", DataPoints: dataPoints}}, nil
 	}
 
-	series, err := s.repo.GetAggregatedMeasurementsSplitBy(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, *m.SplitBy, granularity)
+	series, err := s.repo.GetAggregatedMeasurementsSplitBy(ctx, m.DataSourceID, m.MeasurementName, start, end, filters, *m.SplitBy, granularity, m.calendar.WeekStart, m.Aggregation, m.AggregationKey)
 	if err != nil {
 		return nil, err
 	}
 
 	// Apply top-N aggregation
-	return applyTopNSeries(series, maxSplitBySeries), nil
+	return applyTopNSeries(series, maxSplitBySeries, m.Aggregation, m.OtherThreshold), nil
 }
 
 // Helper functions
 
-func getTimeframeRange(timeframe string, dateFrom, dateTo *time.Time) (start, end time.Time) {
+// Default refresh hints, in seconds, for metrics without their own interval.
+const (
+	refreshAfterDaily        = 60   // Daily buckets and scalars over open periods
+	refreshAfterWeekly       = 300  // Weekly buckets
+	refreshAfterMonthly      = 900  // Monthly buckets
+	refreshAfterClosedPeriod = 3600 // Periods that have ended only change through late data
+)
+
+// refreshAfterSeconds suggests how long clients can wait before recomputing a metric.
+func refreshAfterSeconds(m Metric, now time.Time) int {
+	if m.RefreshIntervalSeconds != nil {
+		return *m.RefreshIntervalSeconds
+	}
+
+	if _, end := getTimeframeRange(m.Timeframe, m.DateFrom, m.DateTo, m.calendar); !end.After(now) {
+		return refreshAfterClosedPeriod
+	}
+
+	if m.DisplayMode == DisplayModeTimeSeries && m.Granularity != nil {
+		switch *m.Granularity {
+		case GranularityWeekly:
+			return refreshAfterWeekly
+		case GranularityMonthly:
+			return refreshAfterMonthly
+		}
+	}
+	return refreshAfterDaily
+}
+
+// hasData reports whether a computed metric holds any non-zero value.
+func (c *ComputedMetric) hasData() bool {
+	if c.Value != nil && *c.Value != 0 {
+		return true
+	}
+	if len(c.DataPoints) > 0 {
+		return true
+	}
+	for _, series := range c.Series {
+		if len(series.DataPoints) > 0 {
+			return true
+		}
+	}
+	if c.Geo != nil && (len(c.Geo.Countries) > 0 || c.Geo.Unmatched != nil) {
+		return true
+	}
+	if c.Heatmap != nil {
+		for _, row := range c.Heatmap.Values {
+			for _, v := range row {
+				if v != 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// aggregateValue turns a sum and its total weight into the value of aggregation.
+func aggregateValue(aggregation Aggregation, sum, weight float64) float64 {
+	switch {
+	case aggregation == AggregationCount:
+		return weight
+	case aggregation.IsAverage():
+		if weight == 0 {
+			return 0
+		}
+		return sum / weight
+	default:
+		return sum
+	}
+}
+
+// validateGeoQuery checks the geo display mode's requirements: a split-by
+// key holding countries and an aggregation that can be merged across
+// spelling variants of the same country.
+func validateGeoQuery(mode DisplayMode, aggregation Aggregation, splitBy *string) error {
+	if mode != DisplayModeGeo {
+		return nil
+	}
+	if splitBy == nil || strings.TrimSpace(*splitBy) == "" {
+		return ErrSplitByRequired
+	}
+	if aggregation == AggregationCountUnique || aggregation == AggregationWeightedAverage {
+		return ErrInvalidGeoAggregation
+	}
+	return nil
+}
+
+// changedFields returns the JSON names of the fields that differ between two
+// configurations, in alphabetical order.
+func changedFields(a, b UpdateMetricRequest) []string {
+	var fieldsA, fieldsB map[string]json.RawMessage
+	dataA, _ := json.Marshal(a)
+	dataB, _ := json.Marshal(b)
+	_ = json.Unmarshal(dataA, &fieldsA)
+	_ = json.Unmarshal(dataB, &fieldsB)
+
+	var changed []string
+	for k, v := range fieldsA {
+		if !bytes.Equal(v, fieldsB[k]) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range fieldsB {
+		if _, ok := fieldsA[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// sameConfig reports whether two metric configurations are identical.
+func sameConfig(a, b UpdateMetricRequest) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(aJSON, bJSON)
+}
+
+// validateShareOf checks that a share denominator is only set on scalar
+// metrics whose aggregation can be meaningfully divided by another total.
+func validateShareOf(mode DisplayMode, aggregation Aggregation, shareOf *ShareDenominator) error {
+	if shareOf == nil {
+		return nil
+	}
+	if mode != DisplayModeScalar {
+		return ErrShareOfNotSupported
+	}
+	if aggregation != AggregationSum && aggregation != AggregationCount {
+		return ErrShareOfAggregation
+	}
+	return nil
+}
+
+// validateOtherThreshold checks that an "Other" threshold, if set, is a
+// percentage on a split-by time series.
+func validateOtherThreshold(mode DisplayMode, splitBy *string, threshold *float64) error {
+	if threshold == nil {
+		return nil
+	}
+	if *threshold <= 0 || *threshold >= 100 {
+		return ErrInvalidOtherThreshold
+	}
+	if mode != DisplayModeTimeSeries || splitBy == nil || strings.TrimSpace(*splitBy) == "" {
+		return ErrOtherThresholdSplitBy
+	}
+	return nil
+}
+
+// validateCompositeSeries checks that composite series are only set on time
+// series without a split-by key, and that each has a measurement and a
+// unique label.
+func validateCompositeSeries(mode DisplayMode, splitBy *string, series []CompositeSeries) error {
+	if len(series) == 0 {
+		return nil
+	}
+	if mode != DisplayModeTimeSeries || (splitBy != nil && strings.TrimSpace(*splitBy) != "") {
+		return ErrCompositeNotSupported
+	}
+	if len(series) > MaxCompositeSeries {
+		return ErrTooManyCompositeSeries
+	}
+	labels := make(map[string]bool, len(series))
+	for _, cs := range series {
+		label := strings.TrimSpace(cs.Label)
+		if label == "" || len(label) > 255 || labels[label] || strings.TrimSpace(cs.MeasurementName) == "" {
+			return ErrInvalidCompositeSeries
+		}
+		labels[label] = true
+	}
+	return nil
+}
+
+// validateStacking checks that stacking, if set, is valid and applies to the
+// metric: only the series of a split-by or composite bar or area chart can
+// be stacked.
+func validateStacking(mode DisplayMode, chartType *ChartType, splitBy *string, composite []CompositeSeries, stacking *Stacking) error {
+	if stacking == nil {
+		return nil
+	}
+	if !stacking.IsValid() {
+		return ErrInvalidStacking
+	}
+	split := splitBy != nil && strings.TrimSpace(*splitBy) != ""
+	if mode != DisplayModeTimeSeries || (!split && len(composite) == 0) {
+		return ErrStackingNotSupported
+	}
+	if chartType == nil || (*chartType != ChartTypeBar && *chartType != ChartTypeArea) {
+		return ErrStackingNotSupported
+	}
+	return nil
+}
+
+func validateRefreshInterval(seconds *int) error {
+	if seconds != nil && (*seconds < MinRefreshIntervalSeconds || *seconds > MaxRefreshIntervalSeconds) {
+		return ErrInvalidRefreshInterval
+	}
+	return nil
+}
+
+func getTimeframeRange(timeframe string, dateFrom, dateTo *time.Time, cal Calendar) (start, end time.Time) {
 	now := time.Now().UTC()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
@@ -475,6 +1618,18 @@ func getTimeframeRange(timeframe string, dateFrom, dateTo *time.Time) (start, en
 		firstOfThisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
 		start = firstOfThisMonth.AddDate(0, -1, 0)
 		end = firstOfThisMonth
+	case "this_fiscal_quarter":
+		start = cal.fiscalQuarterStart(now)
+		end = today.AddDate(0, 0, 1)
+	case "last_fiscal_quarter":
+		end = cal.fiscalQuarterStart(now)
+		start = end.AddDate(0, -3, 0)
+	case "this_fiscal_year":
+		start = cal.fiscalYearStart(now)
+		end = today.AddDate(0, 0, 1)
+	case "last_fiscal_year":
+		end = cal.fiscalYearStart(now)
+		start = end.AddDate(-1, 0, 0)
 	case "custom":
 		if dateFrom != nil && dateTo != nil {
 			start = *dateFrom
@@ -509,6 +1664,12 @@ func getPreviousTimeframeRange(timeframe string, currentStart, currentEnd time.T
 	case "last_month":
 		start = currentStart.AddDate(0, -1, 0)
 		end = currentEnd.AddDate(0, -1, 0)
+	case "this_fiscal_quarter", "last_fiscal_quarter":
+		start = currentStart.AddDate(0, -3, 0)
+		end = currentEnd.AddDate(0, -3, 0)
+	case "this_fiscal_year", "last_fiscal_year":
+		start = currentStart.AddDate(-1, 0, 0)
+		end = currentEnd.AddDate(-1, 0, 0)
 	case "custom":
 		end = currentStart
 		start = end.Add(-duration)
@@ -520,8 +1681,101 @@ func getPreviousTimeframeRange(timeframe string, currentStart, currentEnd time.T
 	return start, end
 }
 
-func applyTopNSeries(series []SplitSeries, maxSeries int) []SplitSeries {
-	if len(series) <= maxSeries {
+// alignComparison moves the data points of the comparison period starting at
+// previousStart onto the buckets of the current period [start, end), so that
+// the nth bucket of both periods line up. Points that would fall past the end
+// of the current period are dropped.
+func alignComparison(previous []DataPoint, previousStart, start, end time.Time, g Granularity, weekStart WeekStart) []ComparisonDataPoint {
+	previousFirst := truncateToBucket(previousStart, g, weekStart)
+	first := truncateToBucket(start, g, weekStart)
+
+	aligned := make([]ComparisonDataPoint, 0, len(previous))
+	for _, dp := range previous {
+		bucket, err := parseBucketDate(dp.Date, g)
+		if err != nil {
+			continue
+		}
+		date := addBuckets(first, bucketsBetween(previousFirst, bucket, g), g)
+		if !date.Before(end) {
+			continue
+		}
+		aligned = append(aligned, ComparisonDataPoint{
+			Date:         formatDateByGranularity(date, g),
+			PreviousDate: dp.Date,
+			Value:        dp.Value,
+		})
+	}
+	return aligned
+}
+
+// markPartial flags the data points whose bucket ends after completeThrough,
+// or all of them if the data source has no data yet.
+func markPartial(points []DataPoint, completeThrough *time.Time, g Granularity) {
+	for i := range points {
+		bucket, err := parseBucketDate(points[i].Date, g)
+		if err != nil {
+			continue
+		}
+		points[i].Partial = completeThrough == nil || addBuckets(bucket, 1, g).After(*completeThrough)
+	}
+}
+
+// truncateToBucket returns the start of the bucket containing t, matching the
+// buckets of granularityToDateTrunc: days, weeks starting on weekStart, or
+// months.
+func truncateToBucket(t time.Time, g Granularity, weekStart WeekStart) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch g {
+	case GranularityWeekly:
+		if weekStart == WeekStartSunday {
+			return day.AddDate(0, 0, -int(day.Weekday()))
+		}
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GranularityMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default: // daily
+		return day
+	}
+}
+
+// parseBucketDate parses a date formatted by formatDateByGranularity.
+func parseBucketDate(date string, g Granularity) (time.Time, error) {
+	if g == GranularityMonthly {
+		return time.Parse("2006-01", date)
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+// bucketsBetween returns how many buckets bucket starts after first.
+func bucketsBetween(first, bucket time.Time, g Granularity) int {
+	switch g {
+	case GranularityWeekly:
+		return int(bucket.Sub(first).Hours()/24) / 7
+	case GranularityMonthly:
+		return (bucket.Year()-first.Year())*12 + int(bucket.Month()) - int(first.Month())
+	default: // daily
+		return int(bucket.Sub(first).Hours() / 24)
+	}
+}
+
+// addBuckets returns the start of the bucket n buckets after first.
+func addBuckets(first time.Time, n int, g Granularity) time.Time {
+	switch g {
+	case GranularityWeekly:
+		return first.AddDate(0, 0, 7*n)
+	case GranularityMonthly:
+		return first.AddDate(0, n, 0)
+	default: // daily
+		return first.AddDate(0, 0, n)
+	}
+}
+
+// applyTopNSeries keeps the largest series and merges the rest into "Other".
+// With a minShare threshold, series below that percentage of the total across
+// all series are merged; otherwise everything past the top maxSeries-1 is.
+// Either way at most maxSeries series are returned.
+func applyTopNSeries(series []SplitSeries, maxSeries int, aggregation Aggregation, minShare *float64) []SplitSeries {
+	if minShare == nil && len(series) <= maxSeries {
 		return series
 	}
 
@@ -531,12 +1785,14 @@ func applyTopNSeries(series []SplitSeries, maxSeries int) []SplitSeries {
 		total  float64
 	}
 	totals := make([]seriesTotal, len(series))
+	var grandTotal float64
 	for i, s := range series {
 		var total float64
 		for _, dp := range s.DataPoints {
 			total += dp.Value
 		}
 		totals[i] = seriesTotal{series: s, total: total}
+		grandTotal += total
 	}
 
 	// Sort by total descending
@@ -544,25 +1800,49 @@ func applyTopNSeries(series []SplitSeries, maxSeries int) []SplitSeries {
 		return totals[i].total > totals[j].total
 	})
 
-	// Take top N-1 and aggregate the rest into "Other"
-	result := make([]SplitSeries, 0, maxSeries)
-	for i := 0; i < maxSeries-1 && i < len(totals); i++ {
+	keep := maxSeries - 1
+	if minShare != nil {
+		keep = 0
+		for keep < len(totals) && grandTotal != 0 && totals[keep].total/grandTotal*100 >= *minShare {
+			keep++
+		}
+		if keep == len(totals) && len(totals) <= maxSeries {
+			return series
+		}
+		keep = min(keep, maxSeries-1)
+	}
+
+	// Take the kept series and aggregate the rest into "Other"
+	result := make([]SplitSeries, 0, keep+1)
+	for i := 0; i < keep && i < len(totals); i++ {
 		result = append(result, totals[i].series)
 	}
 
-	// Aggregate remaining into "Other"
-	if len(totals) > maxSeries-1 {
+	// Aggregate remaining into "Other"; averages are merged by weight
+	// rather than summed
+	if len(totals) > keep {
 		otherDataPoints := make(map[string]float64)
-		for i := maxSeries - 1; i < len(totals); i++ {
+		otherWeights := make(map[string]float64)
+		for i := keep; i < len(totals); i++ {
 			for _, dp := range totals[i].series.DataPoints {
-				otherDataPoints[dp.Date] += dp.Value
+				if aggregation.IsAverage() {
+					otherDataPoints[dp.Date] += dp.Value * dp.weight
+					otherWeights[dp.Date] += dp.weight
+				} else {
+					otherDataPoints[dp.Date] += dp.Value
+				}
 			}
 		}
 
 		// Convert map to sorted slice
 		var otherDps []DataPoint
 		for date, value := range otherDataPoints {
-			otherDps = append(otherDps, DataPoint{Date: date, Value: value})
+			dp := DataPoint{Date: date, Value: value}
+			if aggregation.IsAverage() {
+				dp.weight = otherWeights[date]
+				dp.Value = aggregateValue(aggregation, value, dp.weight)
+			}
+			otherDps = append(otherDps, dp)
 		}
 		sort.Slice(otherDps, func(i, j int) bool {
 			return otherDps[i].Date < otherDps[j].Date
@@ -573,3 +1853,27 @@ func applyTopNSeries(series []SplitSeries, maxSeries int) []SplitSeries {
 
 	return result
 }
+
+// normalizeSeriesPerBucket replaces each series value with its percentage of
+// the total across all series at the same date. Buckets totalling zero stay zero.
+func normalizeSeriesPerBucket(series []SplitSeries) []SplitSeries {
+	totals := make(map[string]float64)
+	for _, s := range series {
+		for _, dp := range s.DataPoints {
+			totals[dp.Date] += dp.Value
+		}
+	}
+
+	result := make([]SplitSeries, len(series))
+	for i, s := range series {
+		dataPoints := make([]DataPoint, len(s.DataPoints))
+		for j, dp := range s.DataPoints {
+			dataPoints[j] = DataPoint{Date: dp.Date}
+			if total := totals[dp.Date]; total != 0 {
+				dataPoints[j].Value = dp.Value / total * 100
+			}
+		}
+		result[i] = SplitSeries{Key: s.Key, DataPoints: dataPoints}
+	}
+	return result
+}
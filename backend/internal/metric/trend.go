@@ -0,0 +1,156 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const (
+	trendRefreshInterval = 10 * time.Minute
+	trendMaxAge          = time.Hour // Trends older than this are recomputed
+	trendBatchSize       = 500       // Metrics refreshed per run at most
+)
+
+// ListWithTrends retrieves all metrics for a dashboard with the precomputed
+// trends of its scalar metrics. It does not compute anything, so metrics
+// whose trend has not been refreshed since they were created or edited are
+// listed without one.
+func (s *Service) ListWithTrends(ctx context.Context, dashboardID uuid.UUID) ([]MetricWithTrend, error) {
+	metrics, err := s.GetByDashboardID(ctx, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	trends, err := s.repo.GetTrendsByDashboardID(ctx, dashboardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric trends: %w", err)
+	}
+
+	result := make([]MetricWithTrend, len(metrics))
+	for i, m := range metrics {
+		result[i] = MetricWithTrend{Metric: m}
+		if t, ok := trends[m.ID]; ok {
+			result[i].Trend = &t
+		}
+	}
+	return result, nil
+}
+
+// RefreshTrends recomputes the trends of scalar metrics that have none, were
+// edited since, or are older than trendMaxAge, up to trendBatchSize of them.
+// It returns the number of metrics refreshed. A metric that fails to compute
+// keeps its previous trend and is retried on the next run.
+func (s *Service) RefreshTrends(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	ids, err := s.repo.GetStaleTrendMetricIDs(ctx, now.Add(-trendMaxAge), trendBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get metrics to refresh trends for: %w", err)
+	}
+
+	refreshed := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return refreshed, ctx.Err()
+		}
+
+		m, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return refreshed, fmt.Errorf("failed to get metric: %w", err)
+		}
+		if m == nil {
+			continue // Deleted since
+		}
+		cal, err := s.repo.GetCalendarByDashboardID(ctx, m.DashboardID)
+		if err != nil {
+			return refreshed, fmt.Errorf("failed to get calendar: %w", err)
+		}
+		m.calendar = *cal
+
+		t, err := s.computeTrend(ctx, *m)
+		if err != nil {
+			log.Printf("metric trend error for %s: %v", m.ID, err)
+			continue
+		}
+		computedAt := time.Now().UTC()
+		if t != nil {
+			t.ComputedAt = computedAt
+		}
+		if err := s.repo.UpsertTrend(ctx, m.ID, m.UpdatedAt, computedAt, t); err != nil {
+			return refreshed, fmt.Errorf("failed to save metric trend: %w", err)
+		}
+		refreshed++
+	}
+	return refreshed, nil
+}
+
+// computeTrend computes a scalar metric against its previous period,
+// regardless of whether the metric shows the comparison itself. It returns
+// nil if there is no value to compare, as for a share of zero.
+func (s *Service) computeTrend(ctx context.Context, m Metric) (*Trend, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.metricTimeout)
+	defer cancel()
+
+	m.ComparisonEnabled = true
+	computed, err := s.computeOne(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	return trendOf(computed), nil
+}
+
+// trendOf derives a trend from a metric computed with comparison.
+func trendOf(c *ComputedMetric) *Trend {
+	if c.Change == nil {
+		return nil
+	}
+
+	t := &Trend{
+		Direction:     TrendFlat,
+		Change:        *c.Change,
+		ChangePercent: c.ChangePercent,
+	}
+	// Without a baseline any change counts, however small
+	significant := *c.Change != 0
+	if c.ChangePercent != nil {
+		significant = math.Abs(*c.ChangePercent) >= trendFlatPercent
+	}
+	switch {
+	case significant && *c.Change > 0:
+		t.Direction = TrendUp
+	case significant && *c.Change < 0:
+		t.Direction = TrendDown
+	}
+	return t
+}
+
+// TrendRunner keeps the trends of scalar metrics fresh, so listing metrics
+// can include them without computing.
+type TrendRunner struct {
+	service *Service
+}
+
+// NewTrendRunner creates a new metric trend runner.
+func NewTrendRunner(service *Service) *TrendRunner {
+	return &TrendRunner{service: service}
+}
+
+// Job returns the scheduled job that refreshes stale trends.
+func (r *TrendRunner) Job() jobs.Job {
+	return jobs.Job{Name: "metric_trends", Interval: trendRefreshInterval, Run: r.RunOnce}
+}
+
+// RunOnce refreshes one batch of stale trends.
+func (r *TrendRunner) RunOnce(ctx context.Context) error {
+	refreshed, err := r.service.RefreshTrends(ctx)
+	if refreshed > 0 {
+		log.Printf("Refreshed trends of %d metrics", refreshed)
+	}
+	return err
+}
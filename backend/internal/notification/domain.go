@@ -0,0 +1,135 @@
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+)
+
+var (
+	ErrInvalidCategory     = errors.New("invalid notification category")
+	ErrInvalidChannel      = errors.New("invalid notification channel")
+	ErrDuplicatePreference = errors.New("duplicate notification preference")
+	ErrInvalidStatus       = errors.New("invalid delivery status")
+	ErrDeliveryNotFound    = errors.New("delivery not found")
+	ErrDeliveryNotFailed   = errors.New("only failed deliveries can be retried")
+	ErrEmailDisabled       = errors.New("email is not configured")
+)
+
+// deliveryLogLimit caps the number of deliveries listed.
+const deliveryLogLimit = 200
+
+// DeliveryRetention is how long deliveries, and the messages kept with them,
+// are stored.
+const DeliveryRetention = 30 * 24 * time.Hour
+
+// Category groups notifications a user can opt in to or out of.
+type Category string
+
+const (
+	CategoryAlerts      Category = "alerts"
+	CategoryReports     Category = "reports"
+	CategoryDigest      Category = "digest"
+	CategoryDataQuality Category = "data_quality"
+	CategoryMentions    Category = "mentions"
+)
+
+// Channel is a delivery channel for notifications.
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+)
+
+// Categories lists all notification categories.
+var Categories = []Category{CategoryAlerts, CategoryReports, CategoryDigest, CategoryDataQuality, CategoryMentions}
+
+// Channels lists all delivery channels.
+var Channels = []Channel{ChannelEmail}
+
+// defaultEnabled reports whether a category is delivered when the user has not
+// set a preference. Digests and data quality reports are opt-in; everything
+// else is opt-out.
+func defaultEnabled(c Category) bool {
+	return c != CategoryDigest && c != CategoryDataQuality
+}
+
+// Preference enables or disables a category on a channel, optionally for a
+// single resource such as one alert or report schedule. Resource preferences
+// take precedence over the category preference.
+type Preference struct {
+	Category   Category   `json:"category"`
+	Channel    Channel    `json:"channel"`
+	ResourceID *uuid.UUID `json:"resourceId,omitempty"`
+	Enabled    bool       `json:"enabled"`
+}
+
+// PreferencesResponse is the response for getting notification preferences.
+// Defaults holds the effective category-level setting for every category and
+// channel; Preferences holds only what the user has set explicitly.
+type PreferencesResponse struct {
+	Defaults    []Preference `json:"defaults"`
+	Preferences []Preference `json:"preferences"`
+}
+
+// UpdatePreferencesRequest is the request body for replacing notification preferences.
+type UpdatePreferencesRequest struct {
+	Preferences []Preference `json:"preferences"`
+}
+
+// Notification is a message to deliver to a user, subject to their preferences.
+type Notification struct {
+	UserID     uuid.UUID
+	Category   Category
+	ResourceID *uuid.UUID     // Alert, report schedule, dashboard, etc. the notification is about
+	Email      *email.Message // Rendered email; the recipient is filled in on dispatch
+}
+
+// DeliveryStatus is the outcome of the last attempt to deliver a notification.
+type DeliveryStatus string
+
+const (
+	DeliveryStatusSent    DeliveryStatus = "sent"
+	DeliveryStatusFailed  DeliveryStatus = "failed"
+	DeliveryStatusSending DeliveryStatus = "sending" // A retry is in progress
+)
+
+// Delivery records a notification sent, or attempted, on a channel. The
+// rendered message is kept so that a failed delivery can be retried as it was.
+type Delivery struct {
+	ID            uuid.UUID      `json:"id"`
+	UserID        uuid.UUID      `json:"userId"`
+	Category      Category       `json:"category"`
+	Channel       Channel        `json:"channel"`
+	ResourceID    *uuid.UUID     `json:"resourceId,omitempty"`
+	Recipient     string         `json:"recipient"`
+	Subject       string         `json:"subject"`
+	Status        DeliveryStatus `json:"status"`
+	ResponseCode  *int           `json:"responseCode,omitempty"` // Reply code of the mail server, when it rejected the message
+	Error         *string        `json:"error,omitempty"`
+	Attempts      int            `json:"attempts"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	LastAttemptAt time.Time      `json:"lastAttemptAt"`
+
+	message email.Message
+}
+
+// DeliveryFilter narrows the delivery log. Zero fields match everything.
+type DeliveryFilter struct {
+	Status   DeliveryStatus
+	Category Category
+	UserID   *uuid.UUID
+}
+
+// DeliveriesResponse is the response for listing deliveries.
+type DeliveriesResponse struct {
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,200 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// Handler handles HTTP requests for notification preferences and deliveries.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new notification handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetPreferences handles returning the current user's notification preferences.
+//
+//	@Summary		Get notification preferences
+//	@Description	Get which alerts, report schedules, and digests the current user receives, and on which channels
+//	@Tags			notifications
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	PreferencesResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/notification-preferences [get]
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("get notification preferences error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get notification preferences")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles replacing the current user's notification preferences.
+//
+//	@Summary		Update notification preferences
+//	@Description	Replace the current user's notification preferences. Categories without a preference use their default (digests are off, everything else is on).
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		UpdatePreferencesRequest	true	"Preferences"
+//	@Success		200		{object}	PreferencesResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/notification-preferences [put]
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(r.Context(), user.ID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCategory) || errors.Is(err, ErrInvalidChannel) || errors.Is(err, ErrDuplicatePreference) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("update notification preferences error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update notification preferences")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// ListDeliveries handles listing the organization's notification deliveries.
+//
+//	@Summary		List notification deliveries
+//	@Description	List the organization's most recent notification deliveries (up to 200), newest first, with the outcome of their last attempt
+//	@Tags			notifications
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			status		query		string	false	"Filter by status (sent or failed)"
+//	@Param			category	query		string	false	"Filter by notification category"
+//	@Param			userId		query		string	false	"Filter by recipient user ID"
+//	@Success		200			{object}	DeliveriesResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/notification-deliveries [get]
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := DeliveryFilter{
+		Status:   DeliveryStatus(q.Get("status")),
+		Category: Category(q.Get("category")),
+	}
+	if v := q.Get("userId"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid user id")
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), user.OrganizationID, filter)
+	if err != nil {
+		if errors.Is(err, ErrInvalidStatus) || errors.Is(err, ErrInvalidCategory) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("list notification deliveries error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list notification deliveries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, DeliveriesResponse{Deliveries: deliveries})
+}
+
+// RetryDelivery handles sending a failed notification delivery again.
+//
+//	@Summary		Retry notification delivery
+//	@Description	Send a failed notification again, as it was first rendered and to the same recipient
+//	@Tags			notifications
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Delivery ID"
+//	@Success		200	{object}	Delivery
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/notification-deliveries/{id}/retry [post]
+func (h *Handler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid delivery id")
+		return
+	}
+
+	delivery, err := h.service.RetryDelivery(r.Context(), user.OrganizationID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDeliveryNotFound):
+			respondError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, ErrDeliveryNotFailed), errors.Is(err, ErrEmailDisabled):
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("retry notification delivery error: %v", err)
+			respondError(w, http.StatusInternalServerError, "failed to retry notification delivery")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, delivery)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,231 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for notification preferences.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new notification repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// ListPreferences retrieves all preferences a user has set.
+func (r *Repository) ListPreferences(ctx context.Context, userID uuid.UUID) ([]Preference, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT category, channel, resource_id, enabled
+		FROM notification_preferences WHERE user_id = $1
+		ORDER BY category, channel, resource_id NULLS FIRST`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []Preference
+	for rows.Next() {
+		var p Preference
+		if err := rows.Scan(&p.Category, &p.Channel, &p.ResourceID, &p.Enabled); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// ReplacePreferences replaces all of a user's preferences in a single transaction.
+func (r *Repository) ReplacePreferences(ctx context.Context, userID uuid.UUID, prefs []Preference) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM notification_preferences WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, p := range prefs {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO notification_preferences (user_id, category, channel, resource_id, enabled)
+			VALUES ($1, $2, $3, $4, $5)`,
+			userID, p.Category, p.Channel, p.ResourceID, p.Enabled,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetEnabled returns the user's explicit setting for a category and channel.
+// A resource preference is preferred over the category preference; nil means
+// the user has set neither.
+func (r *Repository) GetEnabled(ctx context.Context, userID uuid.UUID, category Category, channel Channel, resourceID *uuid.UUID) (*bool, error) {
+	var enabled bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT enabled FROM notification_preferences
+		WHERE user_id = $1 AND category = $2 AND channel = $3
+		  AND (resource_id IS NULL OR resource_id = $4)
+		ORDER BY resource_id NULLS LAST
+		LIMIT 1`,
+		userID, category, channel, resourceID,
+	).Scan(&enabled)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &enabled, nil
+}
+
+// CreateDelivery records a delivery.
+func (r *Repository) CreateDelivery(ctx context.Context, orgID uuid.UUID, d *Delivery) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO notification_deliveries
+			(organization_id, user_id, category, channel, resource_id, recipient, from_name, subject, text_body, html_body, status, response_code, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, attempts, created_at, last_attempt_at`,
+		orgID, d.UserID, d.Category, d.Channel, d.ResourceID, d.Recipient, d.message.FromName,
+		d.Subject, d.message.Text, d.message.HTML, d.Status, d.ResponseCode, d.Error,
+	).Scan(&d.ID, &d.Attempts, &d.CreatedAt, &d.LastAttemptAt)
+}
+
+// ListDeliveries retrieves an organization's most recent deliveries, newest first.
+func (r *Repository) ListDeliveries(ctx context.Context, orgID uuid.UUID, filter DeliveryFilter, limit int) ([]Delivery, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+deliveryColumns+`
+		FROM notification_deliveries
+		WHERE organization_id = $1
+		  AND ($2 = '' OR status = $2)
+		  AND ($3 = '' OR category = $3)
+		  AND ($4::uuid IS NULL OR user_id = $4)
+		ORDER BY created_at DESC
+		LIMIT $5`,
+		orgID, string(filter.Status), string(filter.Category), filter.UserID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// GetDelivery retrieves a delivery of an organization by ID.
+func (r *Repository) GetDelivery(ctx context.Context, orgID, id uuid.UUID) (*Delivery, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+deliveryColumns+`
+		FROM notification_deliveries
+		WHERE organization_id = $1 AND id = $2`,
+		orgID, id,
+	)
+
+	d, err := scanDelivery(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// ClaimRetry marks a failed delivery of an organization as sending and returns
+// it. It returns nil if the delivery does not exist or is not failed, so that
+// concurrent retries send it only once.
+func (r *Repository) ClaimRetry(ctx context.Context, orgID, id uuid.UUID) (*Delivery, error) {
+	row := r.pool.QueryRow(ctx,
+		`UPDATE notification_deliveries
+		SET status = $3
+		WHERE organization_id = $1 AND id = $2 AND status = $4
+		RETURNING `+deliveryColumns,
+		orgID, id, DeliveryStatusSending, DeliveryStatusFailed,
+	)
+
+	d, err := scanDelivery(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// DeleteDeliveriesBefore deletes the deliveries created before cutoff and
+// returns how many it deleted.
+func (r *Repository) DeleteDeliveriesBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM notification_deliveries WHERE created_at < $1`,
+		cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RecordAttempt updates a delivery with the outcome of another attempt.
+func (r *Repository) RecordAttempt(ctx context.Context, d *Delivery) error {
+	return r.pool.QueryRow(ctx,
+		`UPDATE notification_deliveries
+		SET status = $2, response_code = $3, error = $4, attempts = attempts + 1, last_attempt_at = NOW()
+		WHERE id = $1
+		RETURNING attempts, last_attempt_at`,
+		d.ID, d.Status, d.ResponseCode, d.Error,
+	).Scan(&d.Attempts, &d.LastAttemptAt)
+}
+
+const deliveryColumns = `id, user_id, category, channel, resource_id, recipient, from_name, subject, text_body, html_body,
+		status, response_code, error, attempts, created_at, last_attempt_at`
+
+func scanDelivery(row pgx.Row) (*Delivery, error) {
+	var d Delivery
+	err := row.Scan(
+		&d.ID, &d.UserID, &d.Category, &d.Channel, &d.ResourceID, &d.Recipient, &d.message.FromName,
+		&d.Subject, &d.message.Text, &d.message.HTML,
+		&d.Status, &d.ResponseCode, &d.Error, &d.Attempts, &d.CreatedAt, &d.LastAttemptAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.message.To = d.Recipient
+	d.message.Subject = d.Subject
+	return &d, nil
+}
@@ -0,0 +1,22 @@
+package notification
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the notification preference and delivery routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/notification-preferences", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.GetPreferences)
+		r.Put("/", h.UpdatePreferences)
+	})
+
+	r.Route("/notification-deliveries", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.ListDeliveries)
+		r.Post("/{id}/retry", h.RetryDelivery)
+	})
+}
@@ -0,0 +1,267 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/textproto"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/email"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// Service manages notification preferences and dispatches notifications.
+// Every notification is checked against the recipient's preferences before
+// it is sent; transactional account emails do not go through here.
+type Service struct {
+	repo        *Repository
+	authService *auth.Service
+	email       *email.Service
+	audit       *writeaudit.Service
+}
+
+// NewService creates a new notification service.
+func NewService(repo *Repository, authService *auth.Service, emailService *email.Service, audit *writeaudit.Service) *Service {
+	return &Service{
+		repo:        repo,
+		authService: authService,
+		email:       emailService,
+		audit:       audit,
+	}
+}
+
+// IsEmailEnabled returns whether email notifications can be delivered.
+func (s *Service) IsEmailEnabled() bool {
+	return s.email.IsEnabled()
+}
+
+// GetPreferences returns a user's effective category defaults and explicit preferences.
+func (s *Service) GetPreferences(ctx context.Context, userID uuid.UUID) (*PreferencesResponse, error) {
+	prefs, err := s.repo.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list preferences: %w", err)
+	}
+	if prefs == nil {
+		prefs = []Preference{}
+	}
+
+	var defaults []Preference
+	for _, category := range Categories {
+		for _, channel := range Channels {
+			enabled := defaultEnabled(category)
+			for _, p := range prefs {
+				if p.Category == category && p.Channel == channel && p.ResourceID == nil {
+					enabled = p.Enabled
+				}
+			}
+			defaults = append(defaults, Preference{Category: category, Channel: channel, Enabled: enabled})
+		}
+	}
+
+	return &PreferencesResponse{Defaults: defaults, Preferences: prefs}, nil
+}
+
+// UpdatePreferences replaces a user's explicit preferences.
+func (s *Service) UpdatePreferences(ctx context.Context, userID uuid.UUID, req UpdatePreferencesRequest) (*PreferencesResponse, error) {
+	type key struct {
+		category   Category
+		channel    Channel
+		resourceID uuid.UUID
+	}
+	seen := make(map[key]bool, len(req.Preferences))
+
+	for _, p := range req.Preferences {
+		if !validCategory(p.Category) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCategory, p.Category)
+		}
+		if !validChannel(p.Channel) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidChannel, p.Channel)
+		}
+
+		k := key{category: p.Category, channel: p.Channel}
+		if p.ResourceID != nil {
+			k.resourceID = *p.ResourceID
+		}
+		if seen[k] {
+			return nil, ErrDuplicatePreference
+		}
+		seen[k] = true
+	}
+
+	before, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplacePreferences(ctx, userID, req.Preferences); err != nil {
+		return nil, fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	after, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, writeaudit.EntityNotify, userID, writeaudit.ActionUpdate, before, after)
+	return after, nil
+}
+
+// ShouldNotify reports whether a user wants notifications of a category on a channel.
+func (s *Service) ShouldNotify(ctx context.Context, userID uuid.UUID, category Category, channel Channel, resourceID *uuid.UUID) (bool, error) {
+	enabled, err := s.repo.GetEnabled(ctx, userID, category, channel, resourceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get preference: %w", err)
+	}
+	if enabled == nil {
+		return defaultEnabled(category), nil
+	}
+	return *enabled, nil
+}
+
+// Dispatch delivers a notification on every channel the user has enabled for it.
+// It returns whether the notification was sent on at least one channel.
+func (s *Service) Dispatch(ctx context.Context, n Notification) (bool, error) {
+	if n.Email == nil || !s.email.IsEnabled() {
+		return false, nil
+	}
+
+	ok, err := s.ShouldNotify(ctx, n.UserID, n.Category, ChannelEmail, n.ResourceID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	user, err := s.authService.GetUserByID(ctx, n.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return false, nil
+	}
+
+	msg := *n.Email
+	msg.To = user.Email
+	sendErr := s.email.SendMessage(&msg)
+
+	d := &Delivery{
+		UserID:     n.UserID,
+		Category:   n.Category,
+		Channel:    ChannelEmail,
+		ResourceID: n.ResourceID,
+		Recipient:  msg.To,
+		Subject:    msg.Subject,
+		message:    msg,
+	}
+	d.setOutcome(sendErr)
+	if err := s.repo.CreateDelivery(ctx, user.OrganizationID, d); err != nil {
+		// The log is for diagnosis; it must not change whether a notification counts as sent
+		log.Printf("failed to record notification delivery: %v", err)
+	}
+
+	if sendErr != nil {
+		return false, fmt.Errorf("failed to send email: %w", sendErr)
+	}
+
+	return true, nil
+}
+
+// ListDeliveries returns an organization's most recent notification deliveries.
+func (s *Service) ListDeliveries(ctx context.Context, orgID uuid.UUID, filter DeliveryFilter) ([]Delivery, error) {
+	if filter.Status != "" && filter.Status != DeliveryStatusSent && filter.Status != DeliveryStatusFailed && filter.Status != DeliveryStatusSending {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, filter.Status)
+	}
+	if filter.Category != "" && !validCategory(filter.Category) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCategory, filter.Category)
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, orgID, filter, deliveryLogLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	if deliveries == nil {
+		return []Delivery{}, nil
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery sends a failed delivery again, as it was first rendered and to
+// the same recipient. Preferences are not checked again: retrying is an
+// explicit decision of an admin.
+func (s *Service) RetryDelivery(ctx context.Context, orgID, id uuid.UUID) (*Delivery, error) {
+	if !s.email.IsEnabled() {
+		return nil, ErrEmailDisabled
+	}
+
+	// Claim the delivery before sending, so that it is sent once however
+	// often it is retried at the same time
+	d, err := s.repo.ClaimRetry(ctx, orgID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	if d == nil {
+		existing, err := s.repo.GetDelivery(ctx, orgID, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get delivery: %w", err)
+		}
+		if existing == nil {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, ErrDeliveryNotFailed
+	}
+
+	d.setOutcome(s.email.SendMessage(&d.message))
+	if err := s.repo.RecordAttempt(ctx, d); err != nil {
+		return nil, fmt.Errorf("failed to record attempt: %w", err)
+	}
+
+	return d, nil
+}
+
+// PurgeDeliveries deletes the deliveries older than DeliveryRetention, with
+// their messages. It returns how many it deleted.
+func (s *Service) PurgeDeliveries(ctx context.Context) (int64, error) {
+	n, err := s.repo.DeleteDeliveriesBefore(ctx, time.Now().Add(-DeliveryRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete deliveries: %w", err)
+	}
+	return n, nil
+}
+
+// setOutcome sets the status of a delivery from the error of its last attempt.
+func (d *Delivery) setOutcome(err error) {
+	d.ResponseCode = nil
+	d.Error = nil
+	if err == nil {
+		d.Status = DeliveryStatusSent
+		return
+	}
+
+	d.Status = DeliveryStatusFailed
+	msg := err.Error()
+	d.Error = &msg
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		d.ResponseCode = &smtpErr.Code
+	}
+}
+
+func validCategory(c Category) bool {
+	for _, v := range Categories {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+func validChannel(c Channel) bool {
+	for _, v := range Channels {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package onboarding
+
+// Step identifies an onboarding checklist step.
+type Step string
+
+const (
+	StepDataSourceCreated   Step = "data_source_created"
+	StepMeasurementIngested Step = "measurement_ingested"
+	StepMetricCreated       Step = "metric_created"
+	StepTeammateInvited     Step = "teammate_invited"
+)
+
+// StepStatus is the completion state of a checklist step.
+type StepStatus struct {
+	Step      Step `json:"step"`
+	Completed bool `json:"completed"`
+}
+
+// Status is the onboarding checklist of an organization, derived from its data.
+type Status struct {
+	Steps     []StepStatus `json:"steps"`
+	Completed bool         `json:"completed"` // All steps are completed
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,57 @@
+package onboarding
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+)
+
+// Handler handles HTTP requests for onboarding.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new onboarding handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetStatus handles returning the organization's onboarding checklist.
+//
+//	@Summary		Get onboarding status
+//	@Description	Get the onboarding checklist (data source created, first measurement ingested, first metric created, teammate invited) derived from the organization's data
+//	@Tags			onboarding
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Status
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/onboarding [get]
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	status, err := h.service.GetStatus(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("get onboarding status error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get onboarding status")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,43 @@
+package onboarding
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles the existence queries behind the onboarding checklist.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new onboarding repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// GetStepCompletion checks every step with a single round trip. Each check is
+// an EXISTS query, so it stops at the first matching row.
+func (r *Repository) GetStepCompletion(ctx context.Context, orgID uuid.UUID) (map[Step]bool, error) {
+	var dataSource, measurement, metric, teammate bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT
+			EXISTS(SELECT 1 FROM data_sources WHERE organization_id = $1),
+			EXISTS(SELECT 1 FROM measurement_points WHERE data_source_id IN (SELECT id FROM data_sources WHERE organization_id = $1)),
+			EXISTS(SELECT 1 FROM metrics m JOIN dashboards d ON d.id = m.dashboard_id WHERE d.organization_id = $1 AND d.deleted_at IS NULL AND m.deleted_at IS NULL),
+			EXISTS(SELECT 1 FROM invites WHERE organization_id = $1)
+				OR (SELECT COUNT(*) FROM (SELECT 1 FROM users WHERE organization_id = $1 LIMIT 2) u) > 1`,
+		orgID,
+	).Scan(&dataSource, &measurement, &metric, &teammate)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[Step]bool{
+		StepDataSourceCreated:   dataSource,
+		StepMeasurementIngested: measurement,
+		StepMetricCreated:       metric,
+		StepTeammateInvited:     teammate,
+	}, nil
+}
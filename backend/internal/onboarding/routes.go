@@ -0,0 +1,15 @@
+package onboarding
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the onboarding routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/onboarding", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.GetStatus)
+	})
+}
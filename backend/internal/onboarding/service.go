@@ -0,0 +1,39 @@
+package onboarding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// steps lists the checklist in the order the UI presents it.
+var steps = []Step{StepDataSourceCreated, StepMeasurementIngested, StepMetricCreated, StepTeammateInvited}
+
+// Service handles onboarding business logic.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new onboarding service.
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// GetStatus returns the onboarding checklist of an organization.
+func (s *Service) GetStatus(ctx context.Context, orgID uuid.UUID) (*Status, error) {
+	completion, err := s.repo.GetStepCompletion(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get onboarding status: %w", err)
+	}
+
+	status := &Status{Completed: true}
+	for _, step := range steps {
+		status.Steps = append(status.Steps, StepStatus{Step: step, Completed: completion[step]})
+		if !completion[step] {
+			status.Completed = false
+		}
+	}
+
+	return status, nil
+}
@@ -1,6 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
 	"github.com/caarlos0/env/v11"
 )
 
@@ -12,8 +17,66 @@ type Config struct {
 	APIURL      string `env:"API_URL" envDefault:"http://localhost:8080"`
 	ServerPort  string `env:"SERVER_PORT" envDefault:"8080"`
 
-	SMTP  SMTPConfig  `envPrefix:"SMTP_"`
-	OAuth OAuthConfig `envPrefix:"OAUTH_"`
+	// GRPCPort serves the gRPC ingestion API on this port (empty disables it).
+	GRPCPort string `env:"GRPC_PORT"`
+
+	// GRPCTLSCert and GRPCTLSKey are the PEM certificate and key files the gRPC server
+	// serves TLS with (empty serves plaintext, for use behind a TLS-terminating proxy).
+	GRPCTLSCert string `env:"GRPC_TLS_CERT"`
+	GRPCTLSKey  string `env:"GRPC_TLS_KEY"`
+
+	// TrustedProxies lists the reverse proxies, as IPs or CIDRs, whose X-Forwarded-For and
+	// X-Real-IP headers name the client (empty trusts none and uses the connection's address).
+	TrustedProxies Networks `env:"TRUSTED_PROXIES"`
+
+	// InstanceAdminToken guards the instance admin API (empty disables it).
+	InstanceAdminToken string `env:"INSTANCE_ADMIN_TOKEN"`
+
+	// SecretsKeys encrypts stored third-party credentials, as a comma-separated list of
+	// ID:base64 32-byte keys. The first key encrypts; the others only decrypt (empty stores them in plain text).
+	SecretsKeys string `env:"SECRETS_KEYS"`
+
+	// ImpersonationTTL is how long an impersonation session started through the admin API lasts.
+	ImpersonationTTL time.Duration `env:"IMPERSONATION_TTL" envDefault:"1h"`
+
+	// ShutdownDelay is how long the server reports itself not ready before it
+	// stops accepting requests, so that load balancers can take it out first.
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" envDefault:"5s"`
+
+	// ShutdownTimeout bounds how long in-flight requests and background work may take to finish on shutdown.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+
+	// RequireCurrentSchema refuses to start unless the database schema matches this build's migrations.
+	RequireCurrentSchema bool `env:"REQUIRE_CURRENT_SCHEMA" envDefault:"false"`
+
+	// MeasurementRetentionMonths drops measurement partitions older than this many months (0 keeps all data).
+	MeasurementRetentionMonths int `env:"MEASUREMENT_RETENTION_MONTHS" envDefault:"0"`
+
+	// DownsampleAfterDays replaces raw measurements older than this many days with per-day rollups (0 disables).
+	DownsampleAfterDays int `env:"DOWNSAMPLE_AFTER_DAYS" envDefault:"0"`
+
+	// IngestMaxFutureSkew rejects measurements timestamped further ahead than this, allowing for client clock drift (0 disables).
+	IngestMaxFutureSkew time.Duration `env:"INGEST_MAX_FUTURE_SKEW" envDefault:"1h"`
+
+	// IngestMaxPastAge rejects measurements timestamped further back than this (0 disables).
+	IngestMaxPastAge time.Duration `env:"INGEST_MAX_PAST_AGE" envDefault:"87600h"`
+
+	// Query limits
+	DBStatementTimeout   time.Duration `env:"DB_STATEMENT_TIMEOUT" envDefault:"30s"`
+	ComputeBudget        time.Duration `env:"COMPUTE_BUDGET" envDefault:"12s"`
+	ComputeMetricTimeout time.Duration `env:"COMPUTE_METRIC_TIMEOUT" envDefault:"5s"`
+	ComputeJobTimeout    time.Duration `env:"COMPUTE_JOB_TIMEOUT" envDefault:"10m"`
+	ComputeJobWorkers    int           `env:"COMPUTE_JOB_WORKERS" envDefault:"2"`
+
+	// Upper bounds for the compute settings dashboards may choose.
+	ComputeMaxConcurrency int           `env:"COMPUTE_MAX_CONCURRENCY" envDefault:"4"`
+	ComputeCacheMaxTTL    time.Duration `env:"COMPUTE_CACHE_MAX_TTL" envDefault:"1h"`
+
+	SMTP       SMTPConfig      `envPrefix:"SMTP_"`
+	OAuth      OAuthConfig     `envPrefix:"OAUTH_"`
+	Quotas     QuotaConfig     `envPrefix:"QUOTA_"`
+	RateLimits RateLimitConfig `envPrefix:"RATE_LIMIT_"`
+	Slack      SlackConfig     `envPrefix:"SLACK_"`
 }
 
 // SMTPConfig holds email configuration.
@@ -33,11 +96,84 @@ type OAuthConfig struct {
 	GithubClientSecret string `env:"GITHUB_CLIENT_SECRET"`
 }
 
+// QuotaConfig holds the default per-organization quotas. Zero means unlimited.
+type QuotaConfig struct {
+	EventsPerMonth int64 `env:"EVENTS_PER_MONTH" envDefault:"0"`
+	DataSources    int64 `env:"DATA_SOURCES" envDefault:"0"`
+	Dashboards     int64 `env:"DASHBOARDS" envDefault:"0"`
+	Users          int64 `env:"USERS" envDefault:"0"`
+}
+
+// RateLimitConfig holds the per-client request limits of each route group,
+// in requests per minute unless noted. Zero disables the limit.
+type RateLimitConfig struct {
+	Auth    int `env:"AUTH" envDefault:"30"`     // Per client IP
+	Ingest  int `env:"INGEST" envDefault:"6000"` // Per data source
+	Compute int `env:"COMPUTE" envDefault:"300"` // Per client IP
+	Exports int `env:"EXPORTS" envDefault:"10"`  // Per client IP
+	Browser int `env:"BROWSER" envDefault:"120"` // Per client IP, for browser ingestion with public keys
+
+	// TokenFailures limits the requests with an unknown verification, password reset or
+	// invite token, per hour and client IP.
+	TokenFailures int `env:"TOKEN_FAILURES" envDefault:"20"`
+}
+
+// SlackConfig holds the credentials of the instance's Slack app.
+type SlackConfig struct {
+	SigningSecret string `env:"SIGNING_SECRET"` // Verifies requests from Slack (empty disables the integration)
+}
+
+// Networks is a comma-separated list of IP addresses and CIDR ranges.
+type Networks []*net.IPNet
+
+// UnmarshalText parses the list; a bare address is a network of one.
+func (n *Networks) UnmarshalText(text []byte) error {
+	var nets Networks
+	for _, entry := range strings.Split(string(text), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return fmt.Errorf("%q is not an IP address or CIDR", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("%q is not an IP address or CIDR", entry)
+		}
+		nets = append(nets, ipnet)
+	}
+	*n = nets
+	return nil
+}
+
+// Contains reports whether ip is in one of the networks.
+func (n Networks) Contains(ip net.IP) bool {
+	for _, ipnet := range n {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // Load reads configuration from environment variables.
 func Load() (*Config, error) {
 	cfg := &Config{}
 	if err := env.Parse(cfg); err != nil {
 		return nil, err
 	}
+	if (cfg.GRPCTLSCert == "") != (cfg.GRPCTLSKey == "") {
+		return nil, fmt.Errorf("GRPC_TLS_CERT and GRPC_TLS_KEY must be set together")
+	}
 	return cfg, nil
 }
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -14,7 +15,9 @@ type DB struct {
 }
 
 // New creates a new database connection pool.
-func New(ctx context.Context, databaseURL string) (*DB, error) {
+// A non-zero statementTimeout is applied to every connection so that no
+// single query can hold a pooled connection indefinitely.
+func New(ctx context.Context, databaseURL string, statementTimeout time.Duration) (*DB, error) {
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parsing database URL: %w", err)
@@ -26,6 +29,9 @@ func New(ctx context.Context, databaseURL string) (*DB, error) {
 	config.MaxConnLifetime = time.Hour
 	config.MaxConnIdleTime = 30 * time.Minute
 	config.HealthCheckPeriod = time.Minute
+	if statementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/migrations"
+)
+
+// SchemaState describes how the database schema relates to the migrations
+// this build ships with.
+type SchemaState string
+
+const (
+	SchemaUpToDate SchemaState = "up_to_date"
+	SchemaPending  SchemaState = "pending" // Migrations still need to be applied
+	SchemaAhead    SchemaState = "ahead"   // The database was migrated by a newer build
+	SchemaDirty    SchemaState = "dirty"   // A migration failed halfway and needs manual repair
+)
+
+// Migration identifies a migration file.
+type Migration struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+}
+
+// SchemaStatus is the applied schema version compared with the migrations
+// this build ships with.
+type SchemaStatus struct {
+	State           SchemaState `json:"state"`
+	Version         int64       `json:"version"`
+	Dirty           bool        `json:"dirty"`
+	ExpectedVersion int64       `json:"expectedVersion"`
+	Pending         []Migration `json:"pending"`
+}
+
+// SchemaStatus reads the applied schema version, as recorded by
+// golang-migrate, and compares it with the embedded migrations.
+func (db *DB) SchemaStatus(ctx context.Context) (*SchemaStatus, error) {
+	return GetSchemaStatus(ctx, db.Pool)
+}
+
+// GetSchemaStatus is SchemaStatus for a bare pool.
+func GetSchemaStatus(ctx context.Context, pool *pgxpool.Pool) (*SchemaStatus, error) {
+	known, err := embeddedMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("reading embedded migrations: %w", err)
+	}
+
+	status := &SchemaStatus{Pending: []Migration{}}
+	if len(known) > 0 {
+		status.ExpectedVersion = known[len(known)-1].Version
+	}
+
+	// A database that was never migrated has no schema_migrations table
+	var exists bool
+	if err := pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("checking schema_migrations: %w", err)
+	}
+	if exists {
+		err := pool.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0), COALESCE(bool_or(dirty), false) FROM schema_migrations`).
+			Scan(&status.Version, &status.Dirty)
+		if err != nil {
+			return nil, fmt.Errorf("reading schema version: %w", err)
+		}
+	}
+
+	for _, m := range known {
+		if m.Version > status.Version {
+			status.Pending = append(status.Pending, m)
+		}
+	}
+
+	switch {
+	case status.Dirty:
+		status.State = SchemaDirty
+	case status.Version > status.ExpectedVersion:
+		status.State = SchemaAhead
+	case len(status.Pending) > 0:
+		status.State = SchemaPending
+	default:
+		status.State = SchemaUpToDate
+	}
+	return status, nil
+}
+
+// embeddedMigrations lists the embedded up migrations in version order.
+func embeddedMigrations() ([]Migration, error) {
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Migration
+	for _, f := range files {
+		version, name, ok := strings.Cut(strings.TrimSuffix(f, ".up.sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", f)
+		}
+		v, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", f)
+		}
+		result = append(result, Migration{Version: v, Name: name})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
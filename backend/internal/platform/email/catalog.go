@@ -0,0 +1,345 @@
+package email
+
+import "sort"
+
+// DefaultLanguage is the language of the built-in templates.
+const DefaultLanguage = "en"
+
+// Translation is a built-in template in another language.
+type Translation struct {
+	Template
+	ActionLabel string // Label of the HTML button; "LiteKPI" is replaced with the product name
+}
+
+// Catalog provides translations of the built-in templates. Templates it has
+// no translation for are sent in DefaultLanguage.
+type Catalog interface {
+	// Translate returns the named template in a language.
+	Translate(language, name string) (Translation, bool)
+	// Languages returns the languages the catalog translates to.
+	Languages() []string
+}
+
+// MapCatalog is a Catalog of translations keyed by language, then by
+// template name.
+type MapCatalog map[string]map[string]Translation
+
+// Translate returns the named template in a language.
+func (c MapCatalog) Translate(language, name string) (Translation, bool) {
+	t, ok := c[language][name]
+	return t, ok
+}
+
+// Languages returns the languages the catalog translates to, sorted.
+func (c MapCatalog) Languages() []string {
+	languages := make([]string, 0, len(c))
+	for language := range c {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+var catalog Catalog = builtinCatalog
+
+// SetCatalog replaces the catalog emails are translated with. It is meant to
+// be called once at startup, before any email is rendered.
+func SetCatalog(c Catalog) {
+	catalog = c
+}
+
+// Languages returns the languages emails can be sent in, DefaultLanguage
+// first.
+func Languages() []string {
+	return append([]string{DefaultLanguage}, catalog.Languages()...)
+}
+
+// IsSupportedLanguage reports whether emails can be sent in a language.
+func IsSupportedLanguage(language string) bool {
+	for _, l := range Languages() {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinCatalog translates the account and report emails.
+var builtinCatalog = MapCatalog{
+	"de": {
+		TemplateVerification: {
+			Template: Template{
+				Subject: "Bestätige dein {{.ProductName}}-Konto",
+				Body: `Hallo,
+
+danke für deine Registrierung bei {{.ProductName}}! Bitte bestätige deine E-Mail-Adresse über den folgenden Link:
+
+{{.URL}}
+
+Der Link ist 24 Stunden gültig.
+
+Falls du kein {{.ProductName}}-Konto erstellt hast, kannst du diese E-Mail ignorieren.
+
+Viele Grüße
+Dein {{.ProductName}}-Team`,
+			},
+			ActionLabel: "E-Mail bestätigen",
+		},
+		TemplateInvite: {
+			Template: Template{
+				Subject: "Einladung zu {{.OrgName}} auf {{.ProductName}}",
+				Body: `Hallo,
+
+{{.InviterName}} hat dich eingeladen, {{.OrgName}} auf {{.ProductName}} beizutreten.
+
+Über den folgenden Link nimmst du die Einladung an und erstellst dein Konto:
+
+{{.URL}}
+
+Die Einladung ist 7 Tage gültig.
+
+Viele Grüße
+Dein {{.ProductName}}-Team`,
+			},
+			ActionLabel: "Einladung annehmen",
+		},
+		TemplatePasswordReset: {
+			Template: Template{
+				Subject: "Setze dein {{.ProductName}}-Passwort zurück",
+				Body: `Hallo,
+
+wir haben eine Anfrage erhalten, dein Passwort zurückzusetzen. Über den folgenden Link legst du ein neues Passwort fest:
+
+{{.URL}}
+
+Der Link ist 1 Stunde gültig.
+
+Falls du das nicht angefordert hast, kannst du diese E-Mail ignorieren.
+
+Viele Grüße
+Dein {{.ProductName}}-Team`,
+			},
+			ActionLabel: "Passwort zurücksetzen",
+		},
+		TemplateAccountLink: {
+			Template: Template{
+				Subject: "Bestätige die Anmeldung bei {{.ProductName}} mit {{.Provider}}",
+				Body: `Hallo,
+
+jemand hat sich mit einem {{.Provider}}-Konto, das deine E-Mail-Adresse verwendet, bei {{.ProductName}} angemeldet. Damit sich dieses {{.Provider}}-Konto künftig bei deinem {{.ProductName}}-Konto anmelden kann, klicke auf den folgenden Link:
+
+{{.URL}}
+
+Der Link ist 1 Stunde gültig.
+
+Falls du das nicht warst, ignoriere diese E-Mail; das {{.Provider}}-Konto wird dann nicht verknüpft.
+
+Viele Grüße
+Dein {{.ProductName}}-Team`,
+			},
+			ActionLabel: "Konto verknüpfen",
+		},
+		TemplateReport: {
+			Template: Template{
+				Subject: "{{.ReportName}} für {{.OrgName}}",
+				Body: `Hallo,
+
+dein Bericht „{{.ReportName}}“ ist fertig:
+
+{{.Summary}}
+
+Sieh ihn dir in {{.ProductName}} an:
+
+{{.URL}}
+
+Viele Grüße
+Dein {{.ProductName}}-Team`,
+			},
+			ActionLabel: "Bericht ansehen",
+		},
+	},
+	"fr": {
+		TemplateVerification: {
+			Template: Template{
+				Subject: "Confirmez votre compte {{.ProductName}}",
+				Body: `Bonjour,
+
+Merci de vous être inscrit sur {{.ProductName}} ! Veuillez confirmer votre adresse e-mail en cliquant sur le lien ci-dessous :
+
+{{.URL}}
+
+Ce lien expire dans 24 heures.
+
+Si vous n'avez pas créé de compte {{.ProductName}}, vous pouvez ignorer cet e-mail.
+
+Merci,
+L'équipe {{.ProductName}}`,
+			},
+			ActionLabel: "Confirmer l'e-mail",
+		},
+		TemplateInvite: {
+			Template: Template{
+				Subject: "Vous êtes invité à rejoindre {{.OrgName}} sur {{.ProductName}}",
+				Body: `Bonjour,
+
+{{.InviterName}} vous invite à rejoindre {{.OrgName}} sur {{.ProductName}}.
+
+Cliquez sur le lien ci-dessous pour accepter l'invitation et créer votre compte :
+
+{{.URL}}
+
+Cette invitation expire dans 7 jours.
+
+Merci,
+L'équipe {{.ProductName}}`,
+			},
+			ActionLabel: "Accepter l'invitation",
+		},
+		TemplatePasswordReset: {
+			Template: Template{
+				Subject: "Réinitialisez votre mot de passe {{.ProductName}}",
+				Body: `Bonjour,
+
+Nous avons reçu une demande de réinitialisation de votre mot de passe. Cliquez sur le lien ci-dessous pour en choisir un nouveau :
+
+{{.URL}}
+
+Ce lien expire dans 1 heure.
+
+Si vous n'êtes pas à l'origine de cette demande, vous pouvez ignorer cet e-mail.
+
+Merci,
+L'équipe {{.ProductName}}`,
+			},
+			ActionLabel: "Réinitialiser le mot de passe",
+		},
+		TemplateAccountLink: {
+			Template: Template{
+				Subject: "Confirmez la connexion à {{.ProductName}} avec {{.Provider}}",
+				Body: `Bonjour,
+
+Quelqu'un s'est connecté à {{.ProductName}} avec un compte {{.Provider}} utilisant votre adresse e-mail. Pour que ce compte {{.Provider}} puisse désormais se connecter à votre compte {{.ProductName}}, cliquez sur le lien ci-dessous :
+
+{{.URL}}
+
+Ce lien expire dans 1 heure.
+
+Si ce n'était pas vous, ignorez cet e-mail : le compte {{.Provider}} ne sera pas associé.
+
+Merci,
+L'équipe {{.ProductName}}`,
+			},
+			ActionLabel: "Associer le compte",
+		},
+		TemplateReport: {
+			Template: Template{
+				Subject: "{{.ReportName}} pour {{.OrgName}}",
+				Body: `Bonjour,
+
+Votre rapport « {{.ReportName}} » est prêt :
+
+{{.Summary}}
+
+Consultez-le dans {{.ProductName}} :
+
+{{.URL}}
+
+Merci,
+L'équipe {{.ProductName}}`,
+			},
+			ActionLabel: "Voir le rapport",
+		},
+	},
+	"es": {
+		TemplateVerification: {
+			Template: Template{
+				Subject: "Verifica tu cuenta de {{.ProductName}}",
+				Body: `Hola:
+
+¡Gracias por registrarte en {{.ProductName}}! Verifica tu dirección de correo electrónico haciendo clic en el siguiente enlace:
+
+{{.URL}}
+
+Este enlace caduca en 24 horas.
+
+Si no creaste una cuenta de {{.ProductName}}, puedes ignorar este correo.
+
+Gracias,
+El equipo de {{.ProductName}}`,
+			},
+			ActionLabel: "Verificar correo",
+		},
+		TemplateInvite: {
+			Template: Template{
+				Subject: "Te han invitado a unirte a {{.OrgName}} en {{.ProductName}}",
+				Body: `Hola:
+
+{{.InviterName}} te ha invitado a unirte a {{.OrgName}} en {{.ProductName}}.
+
+Haz clic en el siguiente enlace para aceptar la invitación y crear tu cuenta:
+
+{{.URL}}
+
+Esta invitación caduca en 7 días.
+
+Gracias,
+El equipo de {{.ProductName}}`,
+			},
+			ActionLabel: "Aceptar invitación",
+		},
+		TemplatePasswordReset: {
+			Template: Template{
+				Subject: "Restablece tu contraseña de {{.ProductName}}",
+				Body: `Hola:
+
+Hemos recibido una solicitud para restablecer tu contraseña. Haz clic en el siguiente enlace para crear una nueva:
+
+{{.URL}}
+
+Este enlace caduca en 1 hora.
+
+Si no solicitaste restablecer la contraseña, puedes ignorar este correo.
+
+Gracias,
+El equipo de {{.ProductName}}`,
+			},
+			ActionLabel: "Restablecer contraseña",
+		},
+		TemplateAccountLink: {
+			Template: Template{
+				Subject: "Confirma el inicio de sesión en {{.ProductName}} con {{.Provider}}",
+				Body: `Hola:
+
+Alguien ha iniciado sesión en {{.ProductName}} con una cuenta de {{.Provider}} que usa tu dirección de correo. Para que esa cuenta de {{.Provider}} pueda iniciar sesión en tu cuenta de {{.ProductName}} a partir de ahora, haz clic en el siguiente enlace:
+
+{{.URL}}
+
+Este enlace caduca en 1 hora.
+
+Si no fuiste tú, ignora este correo y la cuenta de {{.Provider}} no se vinculará.
+
+Gracias,
+El equipo de {{.ProductName}}`,
+			},
+			ActionLabel: "Vincular cuenta",
+		},
+		TemplateReport: {
+			Template: Template{
+				Subject: "{{.ReportName}} para {{.OrgName}}",
+				Body: `Hola:
+
+Tu informe «{{.ReportName}}» está listo:
+
+{{.Summary}}
+
+Consúltalo en {{.ProductName}}:
+
+{{.URL}}
+
+Gracias,
+El equipo de {{.ProductName}}`,
+			},
+			ActionLabel: "Ver informe",
+		},
+	},
+}
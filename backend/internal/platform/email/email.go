@@ -1,8 +1,16 @@
 package email
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"time"
 )
 
 // Config holds email service configuration.
@@ -42,14 +50,30 @@ func (s *Service) IsEnabled() bool {
 	return s.enabled
 }
 
-// Send sends an email with the given recipient, subject, and body.
+// Message is a rendered email.
+type Message struct {
+	To       string
+	FromName string // Display name for the configured From address
+	Subject  string
+	Text     string
+	HTML     string // Optional; sent as a multipart/alternative part
+}
+
+// Send sends a plain-text email with the given recipient, subject, and body.
 func (s *Service) Send(to, subject, body string) error {
+	return s.SendMessage(&Message{To: to, Subject: subject, Text: body})
+}
+
+// SendMessage sends a rendered email.
+func (s *Service) SendMessage(msg *Message) error {
 	if !s.enabled {
 		return nil // Silently skip if email not configured
 	}
 
-	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
-		s.from, to, subject, body)
+	raw, err := s.build(msg)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
 
 	addr := fmt.Sprintf("%s:%d", s.host, s.port)
 
@@ -58,5 +82,79 @@ func (s *Service) Send(to, subject, body string) error {
 		auth = smtp.PlainAuth("", s.user, s.password, s.host)
 	}
 
-	return smtp.SendMail(addr, auth, s.from, []string{to}, []byte(msg))
+	return smtp.SendMail(addr, auth, s.from, []string{msg.To}, raw)
+}
+
+// Verify connects to the mail server and authenticates the way SendMessage
+// does, without sending anything.
+func (s *Service) Verify(timeout time.Duration) error {
+	if !s.enabled {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", s.host, s.port), timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet server: %w", err)
+	}
+	defer c.Close()
+
+	if err := c.Hello("localhost"); err != nil {
+		return fmt.Errorf("failed to greet server: %w", err)
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.user != "" && s.password != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	return c.Quit()
+}
+
+func (s *Service) build(msg *Message) ([]byte, error) {
+	from := s.from
+	if msg.FromName != "" {
+		from = (&mail.Address{Name: msg.FromName, Address: s.from}).String()
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n",
+		from, msg.To, mime.QEncoding.Encode("utf-8", msg.Subject))
+
+	if msg.HTML == "" {
+		fmt.Fprintf(&buf, "Content-Type: text/plain; charset=UTF-8\r\n\r\n%s", msg.Text)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
 }
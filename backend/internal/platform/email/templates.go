@@ -0,0 +1,396 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// Template names for the emails LiteKPI sends.
+const (
+	TemplateVerification  = "verification"
+	TemplateInvite        = "invite"
+	TemplatePasswordReset = "password_reset"
+	TemplateAccountLink   = "account_link"
+	TemplateReport        = "report"
+	TemplateDigest        = "digest"
+	TemplateDataQuality   = "data_quality"
+	TemplateMention       = "mention"
+)
+
+// DefaultAccentColor is used when an organization has not set one.
+const DefaultAccentColor = "#2563eb"
+
+// DefaultProductName is used when an organization has not set one.
+const DefaultProductName = "LiteKPI"
+
+// Template is an email template. Subject and Body are text/template sources
+// rendered with the template's data; Body is plain text where paragraphs are
+// separated by blank lines.
+type Template struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Branding customizes the look and sender of outgoing emails.
+type Branding struct {
+	LogoURL     string
+	AccentColor string
+	FromName    string
+	ProductName string // Replaces "LiteKPI" in the built-in templates
+}
+
+// Customization is an organization's branding, template overrides and the
+// language its emails are sent in unless a recipient chose another.
+type Customization struct {
+	Branding  Branding
+	Templates map[string]Template
+	Language  string
+}
+
+// templateSpec describes a built-in template and the data it is rendered with.
+type templateSpec struct {
+	defaults    Template
+	actionLabel string
+	keys        []string // Data keys available to the template
+}
+
+var templateSpecs = map[string]templateSpec{
+	TemplateVerification: {
+		defaults: Template{
+			Subject: "Verify your {{.ProductName}} account",
+			Body: `Hi,
+
+Thanks for signing up for {{.ProductName}}! Please verify your email address by clicking the link below:
+
+{{.URL}}
+
+This link will expire in 24 hours.
+
+If you didn't create a {{.ProductName}} account, you can safely ignore this email.
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "Verify email",
+		keys:        []string{"URL", "ProductName"},
+	},
+	TemplateInvite: {
+		defaults: Template{
+			Subject: "You've been invited to join {{.OrgName}} on {{.ProductName}}",
+			Body: `Hi,
+
+{{.InviterName}} has invited you to join {{.OrgName}} on {{.ProductName}}.
+
+Click the link below to accept the invitation and create your account:
+
+{{.URL}}
+
+This invitation will expire in 7 days.
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "Accept invitation",
+		keys:        []string{"URL", "OrgName", "InviterName", "ProductName"},
+	},
+	TemplatePasswordReset: {
+		defaults: Template{
+			Subject: "Reset your {{.ProductName}} password",
+			Body: `Hi,
+
+We received a request to reset your password. Click the link below to create a new password:
+
+{{.URL}}
+
+This link will expire in 1 hour.
+
+If you didn't request a password reset, you can safely ignore this email.
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "Reset password",
+		keys:        []string{"URL", "ProductName"},
+	},
+	TemplateAccountLink: {
+		defaults: Template{
+			Subject: "Confirm signing in to {{.ProductName}} with {{.Provider}}",
+			Body: `Hi,
+
+Someone signed in to {{.ProductName}} with a {{.Provider}} account using your email address. To let that {{.Provider}} account sign in to your {{.ProductName}} account from now on, click the link below:
+
+{{.URL}}
+
+This link will expire in 1 hour.
+
+If this wasn't you, ignore this email and the {{.Provider}} account will not be linked.
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "Link account",
+		keys:        []string{"URL", "Provider", "ProductName"},
+	},
+	TemplateReport: {
+		defaults: Template{
+			Subject: "{{.ReportName}} for {{.OrgName}}",
+			Body: `Hi,
+
+Your report "{{.ReportName}}" is ready:
+
+{{.Summary}}
+
+View it in {{.ProductName}}:
+
+{{.URL}}
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "View report",
+		keys:        []string{"URL", "OrgName", "ReportName", "Summary", "ProductName"},
+	},
+	TemplateDigest: {
+		defaults: Template{
+			Subject: "Your weekly KPI digest for {{.PeriodLabel}}",
+			Body: `Hi,
+
+Here's how your starred dashboards did in the week of {{.PeriodLabel}} compared with the week before:
+
+{{.Summary}}
+
+Open {{.ProductName}} to dig deeper:
+
+{{.URL}}
+
+You are receiving this because you turned on the weekly digest. You can turn it off in your notification preferences.
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "Open " + DefaultProductName,
+		keys:        []string{"URL", "OrgName", "PeriodLabel", "Summary", "ProductName"},
+	},
+	TemplateDataQuality: {
+		defaults: Template{
+			Subject: "Data quality report for {{.OrgName}}, week of {{.PeriodLabel}}",
+			Body: `Hi,
+
+We found anomalies in the data {{.OrgName}} sent in the week of {{.PeriodLabel}}, compared with the four weeks before:
+
+{{.Summary}}
+
+See the full report in {{.ProductName}}:
+
+{{.URL}}
+
+You are receiving this because you turned on data quality reports. You can turn them off in your notification preferences.
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "Open " + DefaultProductName,
+		keys:        []string{"URL", "OrgName", "PeriodLabel", "Summary", "ProductName"},
+	},
+	TemplateMention: {
+		defaults: Template{
+			Subject: "{{.AuthorName}} mentioned you on {{.DashboardName}}",
+			Body: `Hi,
+
+{{.AuthorName}} mentioned you in a comment on the dashboard "{{.DashboardName}}":
+
+{{.Comment}}
+
+Reply in {{.ProductName}}:
+
+{{.URL}}
+
+You are receiving this because you were mentioned. You can turn mention emails off in your notification preferences.
+
+Thanks,
+The {{.ProductName}} Team`,
+		},
+		actionLabel: "View comment",
+		keys:        []string{"URL", "AuthorName", "DashboardName", "Comment", "ProductName"},
+	},
+}
+
+// TemplateNames returns the names of all built-in templates.
+func TemplateNames() []string {
+	return []string{TemplateVerification, TemplateInvite, TemplatePasswordReset, TemplateAccountLink, TemplateReport, TemplateDigest, TemplateDataQuality, TemplateMention}
+}
+
+// DefaultTemplate returns the built-in template with the given name.
+func DefaultTemplate(name string) (Template, bool) {
+	spec, ok := templateSpecs[name]
+	return spec.defaults, ok
+}
+
+// TemplateKeys returns the data keys a template may reference.
+func TemplateKeys(name string) []string {
+	return templateSpecs[name].keys
+}
+
+// ValidateTemplate checks that a template override parses and only references
+// data keys available to the named template.
+func ValidateTemplate(name string, tmpl Template) error {
+	spec, ok := templateSpecs[name]
+	if !ok {
+		return fmt.Errorf("unknown template %q", name)
+	}
+
+	sample := make(map[string]string, len(spec.keys))
+	for _, k := range spec.keys {
+		sample[k] = k
+	}
+	if _, err := execute(name+".subject", tmpl.Subject, sample); err != nil {
+		return err
+	}
+	if _, err := execute(name+".body", tmpl.Body, sample); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Render renders the named template with an organization's customization,
+// in the organization's language. A nil customization renders the built-in
+// template without branding.
+func Render(name string, c *Customization, data map[string]string) (*Message, error) {
+	return RenderIn(name, "", c, data)
+}
+
+// RenderIn renders the named template like Render, in a recipient's
+// language. An empty language falls back to the organization's. Templates
+// the organization overrides are sent as written, whatever the language.
+func RenderIn(name, language string, c *Customization, data map[string]string) (*Message, error) {
+	spec, ok := templateSpecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %q", name)
+	}
+
+	if language == "" && c != nil {
+		language = c.Language
+	}
+	tmpl, actionLabel := spec.defaults, spec.actionLabel
+	if t, ok := catalog.Translate(language, name); ok {
+		tmpl, actionLabel = t.Template, t.ActionLabel
+	}
+
+	var branding Branding
+	if c != nil {
+		branding = c.Branding
+		if override, ok := c.Templates[name]; ok {
+			tmpl = override
+		}
+	}
+
+	productName := branding.ProductName
+	if productName == "" {
+		productName = DefaultProductName
+	}
+	withProduct := make(map[string]string, len(data)+1)
+	for k, v := range data {
+		withProduct[k] = v
+	}
+	withProduct["ProductName"] = productName
+
+	subject, err := execute(name+".subject", tmpl.Subject, withProduct)
+	if err != nil {
+		return nil, err
+	}
+	body, err := execute(name+".body", tmpl.Body, withProduct)
+	if err != nil {
+		return nil, err
+	}
+
+	actionLabel = strings.ReplaceAll(actionLabel, DefaultProductName, productName)
+	html, err := renderHTML(body, data["URL"], actionLabel, branding)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		FromName: branding.FromName,
+		Subject:  strings.TrimSpace(subject),
+		Text:     body,
+		HTML:     html,
+	}, nil
+}
+
+func execute(name, src string, data map[string]string) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// htmlParagraph is a paragraph of the rendered text body. A paragraph that
+// consists solely of the action URL is rendered as a button.
+type htmlParagraph struct {
+	Lines  []string
+	Action bool
+}
+
+var htmlLayout = htmltemplate.Must(htmltemplate.New("layout").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background-color:#f4f4f5;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Helvetica,Arial,sans-serif;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="max-width:560px;margin:0 auto;background-color:#ffffff;border-top:4px solid {{.AccentColor}};border-radius:6px;">
+<tr><td style="padding:32px;color:#18181b;font-size:15px;line-height:1.6;">
+{{- if .LogoURL}}
+<img src="{{.LogoURL}}" alt="" style="max-height:40px;margin-bottom:24px;">
+{{- end}}
+{{- range .Paragraphs}}
+{{- if .Action}}
+<p style="margin:24px 0;"><a href="{{$.ActionURL}}" style="display:inline-block;padding:10px 20px;background-color:{{$.AccentColor}};color:#ffffff;text-decoration:none;border-radius:4px;font-weight:600;">{{$.ActionLabel}}</a></p>
+{{- else}}
+<p style="margin:0 0 16px;">{{range $i, $line := .Lines}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>
+{{- end}}
+{{- end}}
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+func renderHTML(body, actionURL, actionLabel string, branding Branding) (string, error) {
+	accent := branding.AccentColor
+	if accent == "" {
+		accent = DefaultAccentColor
+	}
+
+	var paragraphs []htmlParagraph
+	for _, p := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if actionURL != "" && p == actionURL {
+			paragraphs = append(paragraphs, htmlParagraph{Action: true})
+			continue
+		}
+		paragraphs = append(paragraphs, htmlParagraph{Lines: strings.Split(p, "\n")})
+	}
+
+	// The accent color is validated as a hex color before it is stored, so it
+	// is safe to mark as trusted CSS.
+	var buf bytes.Buffer
+	err := htmlLayout.Execute(&buf, map[string]any{
+		"AccentColor": htmltemplate.CSS(accent),
+		"LogoURL":     branding.LogoURL,
+		"ActionURL":   actionURL,
+		"ActionLabel": actionLabel,
+		"Paragraphs":  paragraphs,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
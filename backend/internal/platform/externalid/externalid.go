@@ -0,0 +1,38 @@
+package externalid
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MaxLength is the maximum length of an external ID.
+const MaxLength = 255
+
+var (
+	// ErrInvalid is returned for an external ID that is empty, too long or
+	// contains characters outside the allowed set.
+	ErrInvalid = errors.New("external ID must be 1 to 255 letters, digits or . _ - : @ ~ + characters")
+	// ErrTaken is returned when another resource already has the external ID.
+	ErrTaken = errors.New("external ID is already in use")
+)
+
+var pattern = regexp.MustCompile(`^[A-Za-z0-9._\-:@~+]{1,255}$`)
+
+// Validate checks an external ID. External IDs are chosen by API clients,
+// such as Terraform providers, to address resources by their own keys, and
+// are restricted to characters that need no escaping in a URL path.
+func Validate(id string) error {
+	if !pattern.MatchString(id) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// IsTaken reports whether err is a unique violation of the named external
+// ID index.
+func IsTaken(err error, index string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == index
+}
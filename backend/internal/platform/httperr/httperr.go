@@ -0,0 +1,52 @@
+// Package httperr maps domain errors to HTTP responses. A package lists the
+// errors its handlers can return in a Registry once, instead of repeating
+// chains of errors.Is in every handler, so an error added to the registry
+// gets the same status from every handler that uses it.
+package httperr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Mapping is the response to a domain error and the errors wrapping it.
+type Mapping struct {
+	Err     error
+	Status  int
+	Message string // Defaults to the message of the error returned
+}
+
+// Registry maps domain errors to responses. The first mapping that matches
+// an error applies, so registries can be combined with slices.Concat and the
+// mappings listed first override later ones.
+type Registry []Mapping
+
+// Lookup returns the status and message of err, and whether a mapping
+// matches it.
+func (reg Registry) Lookup(err error) (int, string, bool) {
+	for _, m := range reg {
+		if errors.Is(err, m.Err) {
+			message := m.Message
+			if message == "" {
+				message = err.Error()
+			}
+			return m.Status, message, true
+		}
+	}
+	return 0, "", false
+}
+
+// Respond writes the response to err as {"error": message} and reports
+// whether a mapping matches it. Handlers respond to unmatched errors
+// themselves, usually by logging them and responding with 500.
+func (reg Registry) Respond(w http.ResponseWriter, err error) bool {
+	status, message, ok := reg.Lookup(err)
+	if !ok {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	return true
+}
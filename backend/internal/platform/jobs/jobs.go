@@ -0,0 +1,232 @@
+// Package jobs runs the periodic background work of the server, such as
+// rollups, retention, digests and exports, on a shared schedule.
+//
+// Schedules live in the database, so with several replicas each job runs on
+// one replica at a time: a replica takes a lease on a due job before running
+// it, and the next replica to find the job due once the lease has expired
+// takes over. Failed runs are retried with backoff, and the outcome of every
+// job's last run is kept for the instance admin API.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	pollInterval = 15 * time.Second
+	retryBackoff = time.Minute // Delay of the first retry; doubled on every failure up to the job's interval
+	leaseGrace   = time.Minute // How much longer a lease lasts than its run, to record the outcome
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+// Job is a unit of periodic work.
+type Job struct {
+	Name     string
+	Interval time.Duration // Time between the end of a successful run and the next
+	Timeout  time.Duration // Longest a run may take; its lease lasts leaseGrace longer. Defaults to Interval
+	Run      func(ctx context.Context) error
+}
+
+// State is what a job is doing.
+type State string
+
+const (
+	StateIdle    State = "idle"
+	StateRunning State = "running"
+	StateFailed  State = "failed" // The last run failed; a retry is scheduled
+)
+
+// Status is the schedule and last outcome of a job.
+type Status struct {
+	Name                string     `json:"name"`
+	State               State      `json:"state"`
+	IntervalSeconds     int64      `json:"intervalSeconds"`
+	NextRunAt           time.Time  `json:"nextRunAt"`
+	RunningOn           *string    `json:"runningOn,omitempty"` // Replica holding the lease
+	LastStartedAt       *time.Time `json:"lastStartedAt,omitempty"`
+	LastFinishedAt      *time.Time `json:"lastFinishedAt,omitempty"`
+	LastSucceededAt     *time.Time `json:"lastSucceededAt,omitempty"`
+	LastDurationMs      *int64     `json:"lastDurationMs,omitempty"`
+	LastError           *string    `json:"lastError,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+}
+
+// Scheduler runs registered jobs when they are due.
+type Scheduler struct {
+	store    *store
+	instance string
+
+	mu      sync.Mutex
+	jobs    []Job
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a new scheduler.
+func NewScheduler(pool *pgxpool.Pool) *Scheduler {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &Scheduler{
+		store:    &store{pool: pool},
+		instance: fmt.Sprintf("%s-%s", host, uuid.NewString()[:8]),
+		running:  make(map[string]bool),
+	}
+}
+
+// Register adds a job. Jobs must be registered before Run is called.
+func (s *Scheduler) Register(job Job) {
+	if job.Timeout <= 0 {
+		job.Timeout = job.Interval
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// Run starts due jobs until ctx is cancelled, then waits for the runs in
+// progress to return. New jobs are due immediately.
+func (s *Scheduler) Run(ctx context.Context) {
+	for _, job := range s.registered() {
+		if err := s.store.ensure(ctx, job.Name); err != nil {
+			log.Printf("job %s registration error: %v", job.Name, err)
+		}
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, job := range s.registered() {
+			s.tryStart(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// List returns the status of every registered job.
+func (s *Scheduler) List(ctx context.Context) ([]Status, error) {
+	statuses, err := s.store.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing jobs: %w", err)
+	}
+
+	// Only report jobs this build knows, with the interval it runs them at
+	intervals := make(map[string]time.Duration)
+	for _, job := range s.registered() {
+		intervals[job.Name] = job.Interval
+	}
+	result := []Status{}
+	for _, st := range statuses {
+		interval, ok := intervals[st.Name]
+		if !ok {
+			continue
+		}
+		st.IntervalSeconds = int64(interval / time.Second)
+		result = append(result, st)
+	}
+	return result, nil
+}
+
+// Trigger makes a job due now. It starts on the next poll of whichever
+// replica gets to it first, unless it is already running.
+func (s *Scheduler) Trigger(ctx context.Context, name string) error {
+	if _, ok := s.lookup(name); !ok {
+		return ErrJobNotFound
+	}
+	if err := s.store.trigger(ctx, name); err != nil {
+		return fmt.Errorf("triggering job: %w", err)
+	}
+	return nil
+}
+
+// tryStart runs a job in the background if it is due and no replica holds its lease.
+func (s *Scheduler) tryStart(ctx context.Context, job Job) {
+	s.mu.Lock()
+	if s.running[job.Name] {
+		s.mu.Unlock()
+		return
+	}
+	s.running[job.Name] = true
+	s.mu.Unlock()
+
+	claimed, err := s.store.claim(ctx, job.Name, s.instance, job.Timeout+leaseGrace)
+	if err != nil || !claimed {
+		if err != nil && ctx.Err() == nil {
+			log.Printf("job %s claim error: %v", job.Name, err)
+		}
+		s.done(job.Name)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.done(job.Name)
+		s.execute(ctx, job)
+	}()
+}
+
+// execute runs a claimed job and records its outcome.
+func (s *Scheduler) execute(ctx context.Context, job Job) {
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := job.Run(runCtx)
+	duration := time.Since(started)
+
+	// Record the outcome even when shutting down, so the lease is released
+	recordCtx, recordCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer recordCancel()
+
+	if err == nil {
+		if err := s.store.succeed(recordCtx, job.Name, s.instance, duration, job.Interval); err != nil {
+			log.Printf("job %s record error: %v", job.Name, err)
+		}
+		return
+	}
+
+	log.Printf("job %s error: %v", job.Name, err)
+	if err := s.store.fail(recordCtx, job.Name, s.instance, duration, err.Error(), retryBackoff, job.Interval); err != nil {
+		log.Printf("job %s record error: %v", job.Name, err)
+	}
+}
+
+func (s *Scheduler) done(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
+
+func (s *Scheduler) registered() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Job(nil), s.jobs...)
+}
+
+func (s *Scheduler) lookup(name string) (Job, bool) {
+	for _, job := range s.registered() {
+		if job.Name == name {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
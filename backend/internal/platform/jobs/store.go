@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// errLeaseLost is returned when a run finishes after another replica took
+// over its lease, so its outcome is not recorded.
+var errLeaseLost = errors.New("lease held by another replica")
+
+// store keeps job schedules and leases in the scheduled_jobs table.
+type store struct {
+	pool *pgxpool.Pool
+}
+
+// ensure creates the schedule of a job that has never run, due now.
+func (s *store) ensure(ctx context.Context, name string) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO scheduled_jobs (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`,
+		name,
+	)
+	return err
+}
+
+// claim takes the lease of a due job. It returns false when the job is not
+// due or another replica holds the lease.
+func (s *store) claim(ctx context.Context, name, instance string, lease time.Duration) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE scheduled_jobs
+		SET locked_by = $2, locked_until = NOW() + make_interval(secs => $3), last_started_at = NOW()
+		WHERE name = $1 AND next_run_at <= NOW()
+		  AND (locked_until IS NULL OR locked_until < NOW())`,
+		name, instance, lease.Seconds(),
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// succeed releases the lease of a job after a successful run and schedules
+// the next one. It returns errLeaseLost if instance no longer holds the lease.
+func (s *store) succeed(ctx context.Context, name, instance string, duration, interval time.Duration) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE scheduled_jobs
+		SET locked_by = NULL, locked_until = NULL,
+			last_finished_at = NOW(), last_succeeded_at = NOW(), last_duration_ms = $2, last_error = NULL,
+			consecutive_failures = 0, next_run_at = NOW() + make_interval(secs => $3)
+		WHERE name = $1 AND locked_by = $4`,
+		name, duration.Milliseconds(), interval.Seconds(), instance,
+	)
+	return leaseHeld(tag.RowsAffected(), err)
+}
+
+// fail releases the lease of a job after a failed run and schedules a retry
+// after backoff, doubled for every earlier consecutive failure but never
+// later than interval. It returns errLeaseLost if instance no longer holds
+// the lease.
+func (s *store) fail(ctx context.Context, name, instance string, duration time.Duration, message string, backoff, interval time.Duration) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE scheduled_jobs
+		SET locked_by = NULL, locked_until = NULL,
+			last_finished_at = NOW(), last_duration_ms = $2, last_error = $3,
+			consecutive_failures = consecutive_failures + 1,
+			next_run_at = NOW() + make_interval(secs => LEAST($4 * POWER(2, LEAST(consecutive_failures, 20)), $5))
+		WHERE name = $1 AND locked_by = $6`,
+		name, duration.Milliseconds(), message, backoff.Seconds(), interval.Seconds(), instance,
+	)
+	return leaseHeld(tag.RowsAffected(), err)
+}
+
+// leaseHeld turns an update of a leased job that matched no row into
+// errLeaseLost.
+func leaseHeld(rows int64, err error) error {
+	if err == nil && rows == 0 {
+		return errLeaseLost
+	}
+	return err
+}
+
+// trigger makes a job due now.
+func (s *store) trigger(ctx context.Context, name string) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO scheduled_jobs (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET next_run_at = NOW()`,
+		name,
+	)
+	return err
+}
+
+// list returns the status of every job that has a schedule.
+func (s *store) list(ctx context.Context) ([]Status, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT name,
+			CASE
+				WHEN locked_until > NOW() THEN 'running'
+				WHEN consecutive_failures > 0 THEN 'failed'
+				ELSE 'idle'
+			END,
+			next_run_at,
+			CASE WHEN locked_until > NOW() THEN locked_by END,
+			last_started_at, last_finished_at, last_succeeded_at, last_duration_ms, last_error,
+			consecutive_failures
+		FROM scheduled_jobs
+		ORDER BY name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []Status
+	for rows.Next() {
+		var st Status
+		if err := rows.Scan(
+			&st.Name, &st.State, &st.NextRunAt, &st.RunningOn,
+			&st.LastStartedAt, &st.LastFinishedAt, &st.LastSucceededAt, &st.LastDurationMs, &st.LastError,
+			&st.ConsecutiveFailures,
+		); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+
+	return statuses, rows.Err()
+}
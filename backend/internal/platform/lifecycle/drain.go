@@ -0,0 +1,80 @@
+// Package lifecycle coordinates draining the server on shutdown, so that a
+// replica taken out of a rolling deploy finishes the work it has accepted.
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Drainer tracks the background work of the server. Once draining starts,
+// the server reports itself not ready so that load balancers stop sending
+// it traffic; Wait then lets the tracked work finish.
+type Drainer struct {
+	draining atomic.Bool
+	running  sync.WaitGroup
+
+	mu      sync.Mutex
+	flushes []func(ctx context.Context) error
+}
+
+// NewDrainer creates a new drainer.
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// Start marks the server as draining.
+func (d *Drainer) Start() {
+	d.draining.Store(true)
+}
+
+// Draining reports whether the server is draining.
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Go runs f in the background. Wait returns only once f has, so f should
+// return soon after the context it works with is cancelled.
+func (d *Drainer) Go(f func()) {
+	d.running.Add(1)
+	go func() {
+		defer d.running.Done()
+		f()
+	}()
+}
+
+// OnFlush adds a function that Wait calls once the background functions
+// have returned, such as one writing out buffered updates.
+func (d *Drainer) OnFlush(f func(ctx context.Context) error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flushes = append(d.flushes, f)
+}
+
+// Wait waits for the background functions to return and then flushes. It
+// gives up when ctx is done, returning its error.
+func (d *Drainer) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	d.mu.Lock()
+	flushes := append([]func(ctx context.Context) error(nil), d.flushes...)
+	d.mu.Unlock()
+
+	for _, flush := range flushes {
+		if err := flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
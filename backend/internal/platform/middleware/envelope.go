@@ -0,0 +1,334 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Page sizes of lists paginated by Envelope.
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// errInvalidCursor is returned for cursors Envelope did not issue.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// errorCodePattern matches the machine-readable codes some handlers already
+// return in their error field.
+var errorCodePattern = regexp.MustCompile(`^[a-z]+(_[a-z]+)*$`)
+
+// envelopeBody is the body of every JSON response of the v2 API. Exactly one
+// of Data and Error is set.
+type envelopeBody struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error *envelopeError  `json:"error,omitempty"`
+	Meta  *envelopeMeta   `json:"meta,omitempty"`
+}
+
+// envelopeError is a failed request's error. Clients should branch on Code;
+// Message is meant for people and may change.
+type envelopeError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Details json.RawMessage `json:"details,omitempty"` // Further fields of the original error, if any
+}
+
+// envelopeMeta describes a page of a list.
+type envelopeMeta struct {
+	NextCursor *string `json:"nextCursor,omitempty"` // Pass as cursor for the next page; omitted on the last page
+}
+
+// List describes a GET route whose response is a list.
+type List struct {
+	Field     string // Field of the response object holding the list; "" if the response is the list
+	Paginated bool   // The handler pages the list itself with cursor and limit and returns nextCursor
+}
+
+// Envelope wraps JSON responses of the handlers behind it in an envelope,
+// so v2 clients get one response shape from handlers written for v1:
+//
+//   - Errors get a code, taken from the handler if it returned one and
+//     derived from the status otherwise.
+//   - The lists of the routes in lists, keyed by route pattern without a
+//     trailing slash, are paginated with the cursor and limit query
+//     parameters. Handlers that paginate themselves keep doing so; their
+//     nextCursor moves into the meta. Other responses are never paginated.
+//
+// Other responses, such as exports, images and empty responses, pass
+// through unchanged.
+func Envelope(lists map[string]List) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ew := &envelopeWriter{w: w}
+			next.ServeHTTP(ew, r)
+			if !ew.buffering {
+				return
+			}
+
+			env, status := ew.envelope(r, lists)
+			body, err := json.Marshal(env)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Del("Content-Length")
+			w.WriteHeader(status)
+			w.Write(append(body, '\n'))
+		})
+	}
+}
+
+// envelopeWriter buffers JSON responses so they can be wrapped and writes
+// everything else straight through.
+type envelopeWriter struct {
+	w           http.ResponseWriter
+	status      int
+	buf         bytes.Buffer
+	wroteHeader bool
+	buffering   bool
+}
+
+func (ew *envelopeWriter) Header() http.Header {
+	return ew.w.Header()
+}
+
+func (ew *envelopeWriter) WriteHeader(status int) {
+	if ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	ew.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(ew.w.Header().Get("Content-Type"))
+	if mediaType == "application/json" && status != http.StatusNoContent && status != http.StatusNotModified {
+		ew.buffering = true
+		return
+	}
+	ew.w.WriteHeader(status)
+}
+
+func (ew *envelopeWriter) Write(b []byte) (int, error) {
+	if !ew.wroteHeader {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.buffering {
+		return ew.buf.Write(b)
+	}
+	return ew.w.Write(b)
+}
+
+// Flush lets streamed responses through as they are written.
+func (ew *envelopeWriter) Flush() {
+	if ew.buffering {
+		return
+	}
+	if f, ok := ew.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// envelope wraps the buffered response and returns it with its status.
+func (ew *envelopeWriter) envelope(r *http.Request, lists map[string]List) (*envelopeBody, int) {
+	body := bytes.TrimSpace(ew.buf.Bytes())
+	if ew.status >= 400 {
+		return &envelopeBody{Error: wrapError(ew.status, body)}, ew.status
+	}
+
+	if len(body) == 0 {
+		body = []byte("null")
+	}
+	if r.Method != http.MethodGet {
+		return &envelopeBody{Data: body}, ew.status
+	}
+	list, ok := lists[routePattern(r)]
+	if !ok {
+		return &envelopeBody{Data: body}, ew.status
+	}
+
+	data, meta, err := paginate(body, list, r)
+	if err != nil {
+		return &envelopeBody{Error: &envelopeError{Code: "validation_failed", Message: err.Error()}}, http.StatusBadRequest
+	}
+	return &envelopeBody{Data: data, Meta: meta}, ew.status
+}
+
+// wrapError turns a handler's error body into an envelopeError. Handlers
+// return {"error": "message"} or {"error": "code", "message": "message"}.
+func wrapError(status int, body []byte) *envelopeError {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return &envelopeError{Code: codeForStatus(status), Message: http.StatusText(status)}
+	}
+
+	var errorField, message string
+	json.Unmarshal(fields["error"], &errorField)
+	json.Unmarshal(fields["message"], &message)
+	delete(fields, "error")
+	delete(fields, "message")
+
+	e := &envelopeError{Code: codeForStatus(status), Message: errorField}
+	if message != "" && errorCodePattern.MatchString(errorField) {
+		e.Code = errorField
+		e.Message = message
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	if len(fields) > 0 {
+		e.Details, _ = json.Marshal(fields)
+	}
+	return e
+}
+
+// codeForStatus returns the error code of a status, for handlers that only
+// return a message.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "validation_failed"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusPreconditionFailed:
+		return "precondition_failed"
+	case http.StatusRequestEntityTooLarge:
+		return "payload_too_large"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	}
+	if status >= 500 {
+		return "internal_error"
+	}
+	return "request_failed"
+}
+
+// routePattern returns the pattern of the route that served r, without a
+// trailing slash.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	return strings.TrimSuffix(rctx.RoutePattern(), "/")
+}
+
+// paginate returns a page of a list response. Lists paginated by their
+// handler are returned whole, with their nextCursor moved into the meta.
+func paginate(body []byte, list List, r *http.Request) (json.RawMessage, *envelopeMeta, error) {
+	var fields map[string]json.RawMessage
+	if list.Paginated || list.Field != "" {
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return body, nil, nil
+		}
+	}
+
+	if list.Paginated {
+		var next *string
+		json.Unmarshal(fields["nextCursor"], &next)
+		delete(fields, "nextCursor")
+		data, err := json.Marshal(fields)
+		return data, &envelopeMeta{NextCursor: next}, err
+	}
+
+	query := r.URL.Query()
+	offset, err := decodeCursor(query.Get("cursor"))
+	if err != nil {
+		return nil, nil, err
+	}
+	limit, err := pageSize(query.Get("limit"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := body
+	if list.Field != "" {
+		raw = fields[list.Field]
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return body, nil, nil
+	}
+
+	page, next := pageOf(items, offset, limit)
+	data, err := json.Marshal(page)
+	if err != nil {
+		return nil, nil, err
+	}
+	if list.Field != "" {
+		fields[list.Field] = data
+		if data, err = json.Marshal(fields); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, &envelopeMeta{NextCursor: next}, nil
+}
+
+// pageOf returns the items from offset on, up to limit, and the cursor of
+// the next page if there are more.
+func pageOf(items []json.RawMessage, offset, limit int) ([]json.RawMessage, *string) {
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	start := min(offset, len(items))
+	end := min(start+limit, len(items))
+	if end == len(items) {
+		return items[start:end], nil
+	}
+	next := encodeCursor(end)
+	return items[start:end], &next
+}
+
+// pageSize parses the limit query parameter.
+func pageSize(v string) (int, error) {
+	if v == "" {
+		return DefaultPageSize, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > MaxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+	return limit, nil
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset of a cursor issued by Envelope. An empty
+// cursor is the first page.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+	return offset, nil
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type failureKey struct{}
+
+// failure records whether a request made a failed attempt.
+type failure struct {
+	failed bool
+}
+
+// FailureLimiter limits the failed attempts of each client, such as requests
+// with an unknown token, rather than all of its requests, so that guessing is
+// throttled without getting in the way of regular use. Handlers behind it
+// report failed attempts with ReportFailure.
+type FailureLimiter struct {
+	limiter *RateLimiter
+}
+
+// NewFailureLimiter creates a failure limiter allowing perHour failed
+// attempts per client, where clients are told apart by key. It returns nil if
+// perHour is not positive; a nil limiter lets every request through.
+func NewFailureLimiter(perHour int, key func(r *http.Request) string) *FailureLimiter {
+	limiter := newRateLimiter(perHour, time.Hour, key)
+	if limiter == nil {
+		return nil
+	}
+	return &FailureLimiter{limiter: limiter}
+}
+
+// Handler rejects the requests of clients that have used up their failed
+// attempts with 429 Too Many Requests and a Retry-After header, until the
+// allowance refills. Each request reserves an attempt before it is handled,
+// so that concurrent requests cannot exceed the allowance, and gets it back
+// unless it reports a failure.
+func (l *FailureLimiter) Handler(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := l.limiter.key(r)
+		if wait := l.limiter.take(key, time.Now()); wait > 0 {
+			tooManyRequests(w, wait, "too many failed attempts")
+			return
+		}
+
+		f := &failure{}
+		defer func() {
+			if !f.failed {
+				l.limiter.refund(key, time.Now())
+			}
+		}()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), failureKey{}, f)))
+	})
+}
+
+// ReportFailure records that the request of ctx made a failed attempt. It
+// does nothing for requests that are not behind a FailureLimiter.
+func ReportFailure(ctx context.Context) {
+	if f, ok := ctx.Value(failureKey{}).(*failure); ok {
+		f.failed = true
+	}
+}
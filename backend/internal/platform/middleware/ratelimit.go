@@ -0,0 +1,148 @@
+// Package middleware holds HTTP middleware shared across modules.
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets of idle clients are dropped.
+const sweepInterval = time.Minute
+
+// RateLimiter limits the requests of each client with a token bucket that
+// holds up to one period's allowance and refills continuously.
+type RateLimiter struct {
+	perSecond float64
+	burst     float64
+	key       func(r *http.Request) string
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter allowing perMinute requests per
+// client, where clients are told apart by key. It returns nil if perMinute is
+// not positive; a nil limiter lets every request through.
+func NewRateLimiter(perMinute int, key func(r *http.Request) string) *RateLimiter {
+	return newRateLimiter(perMinute, time.Minute, key)
+}
+
+func newRateLimiter(allowance int, period time.Duration, key func(r *http.Request) string) *RateLimiter {
+	if allowance <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		perSecond: float64(allowance) / period.Seconds(),
+		burst:     float64(allowance),
+		key:       key,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Handler rejects requests over the limit with 429 Too Many Requests and a
+// Retry-After header.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait := l.take(l.key(r), time.Now()); wait > 0 {
+			tooManyRequests(w, wait, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Take spends a token of the client's bucket, for callers that are not HTTP
+// handlers. If the bucket is empty it returns how long until the next token
+// is available; a nil limiter always returns 0.
+func (l *RateLimiter) Take(key string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	return l.take(key, time.Now())
+}
+
+// take spends a token of the client's bucket. If the bucket is empty it
+// returns how long until the next token is available.
+func (l *RateLimiter) take(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key, now)
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// refund returns a token taken from the client's bucket.
+func (l *RateLimiter) refund(key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key, now)
+	b.tokens = math.Min(l.burst, b.tokens+1)
+}
+
+// refill returns the client's bucket with the tokens added since it was last
+// used. The caller must hold l.mu.
+func (l *RateLimiter) refill(key string, now time.Time) *bucket {
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.perSecond)
+	b.last = now
+	return b
+}
+
+// sweep drops the buckets that have refilled completely, since a new bucket
+// behaves the same.
+func (l *RateLimiter) sweep(now time.Time) {
+	full := time.Duration(l.burst / l.perSecond * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// tooManyRequests responds with 429 Too Many Requests, telling the client to
+// retry after wait.
+func tooManyRequests(w http.ResponseWriter, wait time.Duration, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// ClientIP keys requests by the client's IP address. Run it after RealIP so
+// that requests forwarded by trusted proxies are attributed correctly.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
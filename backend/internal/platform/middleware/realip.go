@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+)
+
+// RealIP sets RemoteAddr to the client's address. Forwarding headers are
+// only honoured on connections from a trusted proxy, since anyone else can
+// set them: the client is the last address in X-Forwarded-For that is not a
+// trusted proxy itself, or X-Real-IP without X-Forwarded-For. Connections
+// from other peers keep their own address.
+func RealIP(trusted config.Networks) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := forwardedFor(r, trusted); ip != "" {
+				r.RemoteAddr = ip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor returns the client address the forwarding headers name, or
+// "" if the peer is not a trusted proxy or the headers name no valid address.
+func forwardedFor(r *http.Request, trusted config.Networks) string {
+	if len(trusted) == 0 || !trusted.Contains(net.ParseIP(ClientIP(r))) {
+		return ""
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		// Proxies append the address they received the request from, so
+		// the entries left of the last untrusted one may be made up
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				return ""
+			}
+			if !trusted.Contains(ip) {
+				return ip.String()
+			}
+		}
+		return ""
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
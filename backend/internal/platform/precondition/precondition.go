@@ -0,0 +1,33 @@
+package precondition
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInvalidIfMatch is returned when the If-Match header is not a timestamp.
+var ErrInvalidIfMatch = errors.New("If-Match must be the updatedAt timestamp of the resource")
+
+// IfMatch parses the If-Match header of an update request. Clients send the
+// updatedAt value they last read, optionally quoted like an ETag, and the
+// update only applies if the resource has not changed since. It returns nil
+// when the header is absent or "*", meaning the update is unconditional.
+//
+// The timestamp is truncated to microseconds, the precision the database stores.
+func IfMatch(r *http.Request) (*time.Time, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	value = strings.Trim(value, `"`)
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, ErrInvalidIfMatch
+	}
+	t = t.Truncate(time.Microsecond)
+	return &t, nil
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	platformmw "github.com/devbydaniel/litekpi/internal/platform/middleware"
+)
+
+// lists are the API routes whose responses are lists, which v2 paginates.
+// Patterns are relative to the API version, without a trailing slash. Other
+// responses are returned whole, even if they hold an array, since they may
+// be settings or reports that are only complete in one piece.
+var lists = map[string]platformmw.List{
+	"/auth/domains":                                {Field: "domains"},
+	"/auth/invites":                                {Field: "invites"},
+	"/auth/users":                                  {Field: "users"},
+	"/auth/me/oauth-links":                         {Field: "requests"},
+	"/notification-deliveries":                     {Field: "deliveries"},
+	"/data-sources":                                {Field: "dataSources"},
+	"/data-sources/{id}/maintenance-windows":       {Field: "windows"},
+	"/organization/branding/email-templates":       {Field: "templates"},
+	"/dashboards":                                  {Field: "dashboards"},
+	"/dashboards/{id}/metrics":                     {Field: "metrics"},
+	"/dashboards/{id}/metrics/{metricId}/versions": {Field: "versions"},
+	"/dashboards/{id}/comments":                    {Field: "threads"},
+	"/goals":                                       {Field: "goals"},
+	"/reports":                                     {Field: "reports"},
+	"/trash":                                       {Field: "items"},
+	"/saved-queries":                               {Field: "savedQueries"},
+	"/data-sources/{dataSourceId}/measurements":    {Paginated: true},
+	"/data-sources/{dataSourceId}/sampling":        {Field: "sampling"},
+	"/data-sources/{dataSourceId}/freshness":       {Field: "freshness"},
+	"/mcp/keys":                                    {Field: "keys"},
+	"/admin/organizations":                         {Field: "organizations"},
+	"/admin/impersonations":                        {Field: "sessions"},
+	"/admin/audit-log":                             {Field: "entries"},
+	"/admin/jobs":                                  {Field: "jobs"},
+}
+
+// listsUnder returns lists with their patterns under the prefix of an API
+// version.
+func listsUnder(prefix string) map[string]platformmw.List {
+	m := make(map[string]platformmw.List, len(lists))
+	for pattern, l := range lists {
+		m[prefix+pattern] = l
+	}
+	return m
+}
@@ -0,0 +1,292 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+)
+
+// access is who may call a route.
+type access int
+
+const (
+	accessMember access = iota // Any user of the organization
+	accessEditor               // Editors and admins
+	accessAdmin                // Admins
+	accessPublic               // Callers the route authenticates itself (API keys, tokens, signatures), or anyone
+)
+
+// rule grants access to a route. Patterns are relative to the API version,
+// without a trailing slash; method "*" matches every method.
+type rule struct {
+	method  string
+	pattern string
+	access  access
+}
+
+// policy lists who may call the API routes. Every route that changes data
+// must be listed, even if any member may call it, so that a new route is not
+// left open by omission: the router refuses to start otherwise. Read routes
+// that are not listed are open to all members.
+var policy = []rule{
+	// Auth (invites, users and domains are managed by admins)
+	{"POST", "/auth/register", accessPublic},
+	{"POST", "/auth/login", accessPublic},
+	{"POST", "/auth/verify-email", accessPublic},
+	{"POST", "/auth/forgot-password", accessPublic},
+	{"POST", "/auth/reset-password", accessPublic},
+	{"POST", "/auth/resend-verification", accessPublic},
+	{"POST", "/auth/complete-oauth-setup", accessPublic},
+	{"POST", "/auth/oauth-link/confirm", accessPublic},
+	{"POST", "/auth/oauth-link/email", accessPublic},
+	{"POST", "/auth/oauth-link/verify", accessPublic},
+	{"POST", "/auth/invites/accept", accessPublic},
+	{"POST", "/auth/logout", accessMember},
+	{"PUT", "/auth/me/language", accessMember},
+	{"POST", "/auth/me/oauth-links/{id}/approve", accessMember},
+	{"DELETE", "/auth/me/oauth-links/{id}", accessMember},
+	{"GET", "/auth/invites", accessAdmin},
+	{"POST", "/auth/invites", accessAdmin},
+	{"DELETE", "/auth/invites/{id}", accessAdmin},
+	{"PATCH", "/auth/users/{id}/role", accessAdmin},
+	{"DELETE", "/auth/users/{id}", accessAdmin},
+	{"GET", "/auth/domains", accessAdmin},
+	{"POST", "/auth/domains", accessAdmin},
+	{"POST", "/auth/domains/{id}/verify", accessAdmin},
+	{"PATCH", "/auth/domains/{id}", accessAdmin},
+	{"DELETE", "/auth/domains/{id}", accessAdmin},
+
+	// Personal settings
+	{"PUT", "/notification-preferences", accessMember},
+
+	// Data sources
+	{"POST", "/data-sources", accessAdmin},
+	{"POST", "/data-sources/demo", accessAdmin},
+	{"PUT", "/data-sources/external/{externalId}", accessAdmin},
+	{"PUT", "/data-sources/default", accessAdmin},
+	{"GET", "/data-sources/{id}/deletion-impact", accessAdmin},
+	{"DELETE", "/data-sources/{id}", accessAdmin},
+	{"POST", "/data-sources/{id}/regenerate-key", accessAdmin},
+	{"PUT", "/data-sources/{id}/allowed-cidrs", accessAdmin},
+	{"POST", "/data-sources/{id}/public-key", accessAdmin},
+	{"DELETE", "/data-sources/{id}/public-key", accessAdmin},
+	{"PUT", "/data-sources/{id}/allowed-origins", accessAdmin},
+	{"PUT", "/data-sources/{id}/uniqueness", accessAdmin},
+	{"POST", "/data-sources/{id}/maintenance-windows", accessAdmin},
+	{"PUT", "/data-sources/{id}/maintenance-windows/{windowId}", accessAdmin},
+	{"DELETE", "/data-sources/{id}/maintenance-windows/{windowId}", accessAdmin},
+	{"PUT", "/data-sources/{dataSourceId}/measurements/{name}/type", accessAdmin},
+	{"DELETE", "/data-sources/{dataSourceId}/measurements/{name}/type", accessAdmin},
+	{"PUT", "/data-sources/{dataSourceId}/measurements/{name}/archive", accessAdmin},
+	{"DELETE", "/data-sources/{dataSourceId}/measurements/{name}/archive", accessAdmin},
+	{"PUT", "/data-sources/{dataSourceId}/transforms", accessAdmin},
+	{"PUT", "/data-sources/{dataSourceId}/sampling/{name}", accessAdmin},
+	{"DELETE", "/data-sources/{dataSourceId}/sampling/{name}", accessAdmin},
+	{"PUT", "/data-sources/{dataSourceId}/freshness/{name}", accessAdmin},
+	{"DELETE", "/data-sources/{dataSourceId}/freshness/{name}", accessAdmin},
+	{"POST", "/data-sources/{dataSourceId}/duplicates/dedupe", accessAdmin},
+
+	// Organization settings
+	{"PUT", "/organization/branding", accessAdmin},
+	{"PUT", "/organization/branding/logo", accessAdmin},
+	{"DELETE", "/organization/branding/logo", accessAdmin},
+	{"POST", "/organization/branding/email-templates/{name}/preview", accessAdmin},
+	{"GET", "/organization/export", accessAdmin},
+	{"PUT", "/organization/export", accessAdmin},
+	{"DELETE", "/organization/export", accessAdmin},
+	{"POST", "/organization/export/run", accessAdmin},
+	{"PUT", "/organization/metric-defaults", accessAdmin},
+	{"PUT", "/organization/calendar", accessAdmin},
+	{"PUT", "/organization/locale", accessAdmin},
+	{"GET", "/notification-deliveries", accessAdmin},
+	{"POST", "/notification-deliveries/{id}/retry", accessAdmin},
+	{"POST", "/provisioning/diff", accessAdmin},
+	{"POST", "/provisioning/apply", accessAdmin},
+	{"GET", "/mcp/keys", accessAdmin},
+	{"POST", "/mcp/keys", accessAdmin},
+	{"PUT", "/mcp/keys/{id}", accessAdmin},
+	{"DELETE", "/mcp/keys/{id}", accessAdmin},
+	{"GET", "/integrations/slack", accessAdmin},
+	{"PUT", "/integrations/slack", accessAdmin},
+	{"DELETE", "/integrations/slack", accessAdmin},
+
+	// Dashboards and metrics (stars are personal)
+	{"POST", "/dashboards", accessEditor},
+	{"PUT", "/dashboards/{id}", accessEditor},
+	{"PUT", "/dashboards/{id}/compute-settings", accessEditor},
+	{"PUT", "/dashboards/external/{externalId}", accessEditor},
+	{"DELETE", "/dashboards/{id}", accessEditor},
+	{"POST", "/dashboards/{id}/star", accessMember},
+	{"DELETE", "/dashboards/{id}/star", accessMember},
+	{"PUT", "/dashboards/{id}/preferences", accessMember},
+	{"DELETE", "/dashboards/{id}/preferences", accessMember},
+	{"POST", "/dashboards/{id}/comments", accessMember},
+	{"PUT", "/dashboards/{id}/comments/{commentId}", accessMember},
+	{"DELETE", "/dashboards/{id}/comments/{commentId}", accessMember},
+	{"POST", "/dashboards/{id}/metrics", accessEditor},
+	{"PUT", "/dashboards/{id}/metrics/{metricId}", accessEditor},
+	{"PUT", "/dashboards/{id}/metrics/external/{externalId}", accessEditor},
+	{"DELETE", "/dashboards/{id}/metrics/{metricId}", accessEditor},
+	{"POST", "/dashboards/{id}/metrics/{metricId}/versions/{version}/revert", accessEditor},
+	{"PUT", "/dashboards/{id}/metrics/reorder", accessEditor},
+	{"PUT", "/dashboards/{id}/status-page", accessEditor},
+	{"POST", "/dashboards/{id}/status-page/rotate-token", accessEditor},
+	{"DELETE", "/dashboards/{id}/status-page", accessEditor},
+
+	// Exploration computes without saving; saving creates a metric
+	{"POST", "/explore", accessMember},
+	{"POST", "/explore/compare", accessMember},
+	{"POST", "/explore/save", accessEditor},
+	{"POST", "/compute-jobs", accessMember},
+
+	// Saved queries belong to their creator; promoting creates a metric
+	{"POST", "/saved-queries", accessMember},
+	{"PUT", "/saved-queries/{id}", accessMember},
+	{"DELETE", "/saved-queries/{id}", accessMember},
+	{"POST", "/saved-queries/{id}/run", accessMember},
+	{"POST", "/saved-queries/{id}/promote", accessEditor},
+
+	// Goals and reports
+	{"POST", "/goals", accessEditor},
+	{"PUT", "/goals/{id}", accessEditor},
+	{"DELETE", "/goals/{id}", accessEditor},
+	{"POST", "/reports", accessEditor},
+	{"PUT", "/reports/{id}", accessEditor},
+	{"DELETE", "/reports/{id}", accessEditor},
+	{"POST", "/reports/{id}/sections", accessEditor},
+	{"PUT", "/reports/{id}/sections/reorder", accessEditor},
+	{"PUT", "/reports/{id}/sections/{sectionId}", accessEditor},
+	{"DELETE", "/reports/{id}/sections/{sectionId}", accessEditor},
+	{"POST", "/trash/dashboards/{id}/restore", accessEditor},
+	{"POST", "/trash/metrics/{id}/restore", accessEditor},
+	{"POST", "/trash/reports/{id}/restore", accessEditor},
+
+	// Ingestion (data source API and public keys)
+	{"POST", "/ingest", accessPublic},
+	{"POST", "/ingest/batch", accessPublic},
+	{"PUT", "/ingest/events/{eventId}", accessPublic},
+	{"DELETE", "/ingest/events/{eventId}", accessPublic},
+	{"POST", "/ingest/browser", accessPublic},
+	{"POST", "/ingest/browser/batch", accessPublic},
+	{"POST", "/automations/actions/record-measurement", accessPublic},
+
+	// Slack (request signatures), MCP (MCP API keys) and instance admin
+	// (instance admin token)
+	{"POST", "/integrations/slack/commands", accessPublic},
+	{"POST", "/integrations/slack/events", accessPublic},
+	{"*", "/mcp/*", accessPublic},
+	{"POST", "/admin/organizations/{id}/disable", accessPublic},
+	{"POST", "/admin/organizations/{id}/enable", accessPublic},
+	{"POST", "/admin/users/{id}/impersonate", accessPublic},
+	{"POST", "/admin/impersonations/{id}/end", accessPublic},
+	{"POST", "/admin/jobs/{name}/run", accessPublic},
+}
+
+// apiPrefix is the path of every API version.
+const apiPrefix = "/api/{apiVersion}"
+
+// mutatingMethods are the methods of routes that change data.
+var mutatingMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// policyTable looks up the rules of requests with the router's own route
+// matching, so a rule applies to exactly the requests its route serves.
+type policyTable struct {
+	mux    *chi.Mux
+	access map[string]access // By method and full pattern
+}
+
+func newPolicyTable(rules []rule) *policyTable {
+	p := &policyTable{mux: chi.NewRouter(), access: make(map[string]access, len(rules))}
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for _, rl := range rules {
+		pattern := apiPrefix + rl.pattern
+		if rl.method == "*" {
+			p.mux.Handle(pattern, noop)
+		} else {
+			p.mux.Method(rl.method, pattern, noop)
+		}
+		p.access[rl.method+" "+pattern] = rl.access
+	}
+	return p
+}
+
+// lookup returns who may call a route, and whether a rule lists it.
+func (p *policyTable) lookup(method, path string) (access, bool) {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	rctx := chi.NewRouteContext()
+	if !p.mux.Match(rctx, method, path) {
+		return accessMember, false
+	}
+	pattern := rctx.RoutePattern()
+	if a, ok := p.access[method+" "+pattern]; ok {
+		return a, true
+	}
+	a, ok := p.access["* "+pattern]
+	return a, ok
+}
+
+// authorize rejects users whose role the policy does not allow to call the
+// route. It runs after authentication, which puts the user in the context.
+// Requests that change data but match no rule are rejected, since the check
+// at startup guarantees that every such route has one.
+func (p *policyTable) authorize(next http.Handler) http.Handler {
+	editor, admin := auth.EditorMiddleware(next), auth.AdminMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a, ok := p.lookup(r.Method, routingPath(r))
+		switch {
+		case !ok && mutatingMethods[r.Method]:
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		case a == accessEditor:
+			editor.ServeHTTP(w, r)
+		case a == accessAdmin:
+			admin.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// routingPath returns the path chi routes a request by: the escaped path
+// when it differs from the decoded one, so that an encoded slash in a
+// parameter, as in /dashboards/external/a%2Fb, stays within its segment.
+func routingPath(r *http.Request) string {
+	if r.URL.RawPath != "" {
+		return r.URL.RawPath
+	}
+	return r.URL.Path
+}
+
+// check returns an error listing the routes that change data but have no
+// rule.
+func (p *policyTable) check(routes chi.Routes) error {
+	var missing []string
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !mutatingMethods[method] || !strings.HasPrefix(route, "/api/") {
+			return nil
+		}
+		if _, ok := p.lookup(method, route); !ok {
+			missing = append(missing, method+" "+route)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes without an access rule: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+	"github.com/devbydaniel/litekpi/internal/platform/database"
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+	"github.com/devbydaniel/litekpi/internal/platform/lifecycle"
+	"github.com/devbydaniel/litekpi/internal/platform/secrets"
+)
+
+var routeParam = regexp.MustCompile(`\{[^}]+\}`)
+
+// buildRouter builds the router without connecting to a database; the pool
+// only connects when a query runs.
+func buildRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	pool, err := pgxpool.New(context.Background(), "postgres://litekpi@127.0.0.1:1/litekpi")
+	if err != nil {
+		t.Fatalf("creating pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	drainer := lifecycle.NewDrainer()
+	r := New(ctx, &database.DB{Pool: pool}, cfg, &secrets.Keyring{}, jobs.NewScheduler(pool), drainer)
+	cancel()
+	t.Cleanup(func() { _ = drainer.Wait(context.Background()) })
+	return r
+}
+
+// TestPolicyRejectsViewers checks that every mutating API route reserved for
+// editors or admins rejects a viewer, including when a path parameter holds
+// an encoded slash, and that the routes open to members let a viewer through.
+func TestPolicyRejectsViewers(t *testing.T) {
+	table := newPolicyTable(policy)
+	viewer := &auth.User{Role: auth.RoleViewer}
+
+	var routes [][2]string
+	err := chi.Walk(buildRouter(t), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if mutatingMethods[method] && strings.HasPrefix(route, "/api/") {
+			routes = append(routes, [2]string{method, strings.TrimSuffix(route, "/")})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking routes: %v", err)
+	}
+	if len(routes) == 0 {
+		t.Fatal("no mutating routes found")
+	}
+
+	for _, rt := range routes {
+		method, route := rt[0], rt[1]
+		a, ok := table.lookup(method, route)
+		if !ok {
+			t.Errorf("%s %s: no access rule", method, route)
+			continue
+		}
+		wantAllowed := a == accessMember || a == accessPublic
+
+		// Serve the route alone so that only its own pattern can match
+		reached := false
+		mux := chi.NewRouter()
+		mux.Method(method, route, table.authorize(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			reached = true
+		})))
+
+		paths := []string{routeParam.ReplaceAllString(route, "x")}
+		if strings.Contains(route, "{") {
+			paths = append(paths, routeParam.ReplaceAllString(route, "a%2Fb"))
+		}
+		for _, path := range paths {
+			reached = false
+			req := httptest.NewRequest(method, path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, viewer))
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			switch {
+			case wantAllowed && !reached:
+				t.Errorf("%s %s: viewer rejected with %d, want allowed", method, path, rec.Code)
+			case !wantAllowed && reached:
+				t.Errorf("%s %s: viewer allowed, want forbidden", method, path)
+			case !wantAllowed && rec.Code != http.StatusForbidden:
+				t.Errorf("%s %s: got %d, want %d", method, path, rec.Code, http.StatusForbidden)
+			}
+		}
+	}
+}
+
+// TestPolicyRejectsUnlistedWrites checks that a mutating request no rule
+// matches is rejected rather than treated as open to members.
+func TestPolicyRejectsUnlistedWrites(t *testing.T) {
+	table := newPolicyTable(policy)
+	reached := false
+	h := table.authorize(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { reached = true }))
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/not-a-route/x", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.User{Role: auth.RoleViewer}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if reached || rec.Code != http.StatusForbidden {
+		t.Errorf("got %d (handler reached: %v), want %d", rec.Code, reached, http.StatusForbidden)
+	}
+}
@@ -1,8 +1,10 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -10,40 +12,73 @@ import (
 	"github.com/go-chi/cors"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 
+	"github.com/devbydaniel/litekpi/internal/admin"
 	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/branding"
+	"github.com/devbydaniel/litekpi/internal/changelog"
+	"github.com/devbydaniel/litekpi/internal/comment"
+	"github.com/devbydaniel/litekpi/internal/computejob"
 	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/dataquality"
 	"github.com/devbydaniel/litekpi/internal/datasource"
 	"github.com/devbydaniel/litekpi/internal/demo"
+	"github.com/devbydaniel/litekpi/internal/digest"
+	"github.com/devbydaniel/litekpi/internal/export"
+	"github.com/devbydaniel/litekpi/internal/goal"
 	"github.com/devbydaniel/litekpi/internal/ingest"
+	"github.com/devbydaniel/litekpi/internal/integrations"
 	"github.com/devbydaniel/litekpi/internal/mcp"
 	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/notification"
+	"github.com/devbydaniel/litekpi/internal/onboarding"
 	"github.com/devbydaniel/litekpi/internal/platform/config"
 	"github.com/devbydaniel/litekpi/internal/platform/database"
 	"github.com/devbydaniel/litekpi/internal/platform/email"
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+	"github.com/devbydaniel/litekpi/internal/platform/lifecycle"
+	platformmw "github.com/devbydaniel/litekpi/internal/platform/middleware"
+	"github.com/devbydaniel/litekpi/internal/platform/secrets"
+	"github.com/devbydaniel/litekpi/internal/provisioning"
+	"github.com/devbydaniel/litekpi/internal/report"
+	"github.com/devbydaniel/litekpi/internal/savedquery"
+	"github.com/devbydaniel/litekpi/internal/search"
+	"github.com/devbydaniel/litekpi/internal/statuspage"
+	"github.com/devbydaniel/litekpi/internal/trash"
+	"github.com/devbydaniel/litekpi/internal/usage"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
 
 	_ "github.com/devbydaniel/litekpi/docs" // Swagger docs
 )
 
 // New creates a new Chi router with middleware and routes configured.
-func New(db *database.DB, cfg *config.Config) *chi.Mux {
+// Workers that depend on the modules are started with ctx and tracked by
+// drainer, and periodic jobs are registered with scheduler.
+func New(ctx context.Context, db *database.DB, cfg *config.Config, keyring *secrets.Keyring, scheduler *jobs.Scheduler, drainer *lifecycle.Drainer) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Middleware stack
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(platformmw.RealIP(cfg.TrustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 
 	// CORS configuration
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{cfg.AppURL},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	r.Use(corsHandler(cfg))
+
+	// Initialize usage module (quotas and metering)
+	usageRepo := usage.NewRepository(db.Pool)
+	usageService := usage.NewService(usageRepo, cfg)
+	usageHandler := usage.NewHandler(usageService)
+	scheduler.Register(usage.NewStorageEstimator(usageService).Job())
+
+	// Initialize write audit (history of entity changes)
+	writeAuditService := writeaudit.NewService(writeaudit.NewRepository(db.Pool))
+
+	// Initialize branding module (email branding and templates)
+	brandingRepo := branding.NewRepository(db.Pool)
+	brandingService := branding.NewService(brandingRepo, writeAuditService, cfg.APIURL)
+	brandingHandler := branding.NewHandler(brandingService)
 
 	// Initialize auth module
 	authRepo := auth.NewRepository(db.Pool)
@@ -55,89 +90,339 @@ func New(db *database.DB, cfg *config.Config) *chi.Mux {
 		Password: cfg.SMTP.Password,
 		From:     cfg.SMTP.From,
 	})
-	authEmailer := auth.NewAuthEmailer(emailService, cfg.AppURL)
-	authService := auth.NewService(authRepo, jwtService, authEmailer, cfg)
+	authEmailer := auth.NewAuthEmailer(emailService, brandingService, cfg.AppURL)
+	authService := auth.NewService(authRepo, jwtService, authEmailer, usageService, writeAuditService, cfg)
 	authHandler := auth.NewHandler(authService)
+	scheduler.Register(auth.NewTokenPurger(authService).Job())
+
+	// Initialize notification module (preferences and dispatch)
+	notificationRepo := notification.NewRepository(db.Pool)
+	notificationService := notification.NewService(notificationRepo, authService, emailService, writeAuditService)
+	notificationHandler := notification.NewHandler(notificationService)
 
 	// Initialize data source module
 	dsRepo := datasource.NewRepository(db.Pool)
-	dsService := datasource.NewService(dsRepo)
+	dsService := datasource.NewService(dsRepo, usageService, writeAuditService)
 	dsHandler := datasource.NewHandler(dsService)
+	drainer.OnFlush(dsService.Flush)
 
 	// Initialize ingest module
 	ingestRepo := ingest.NewRepository(db.Pool)
-	ingestService := ingest.NewService(ingestRepo)
+	ingestService := ingest.NewService(ingestRepo, usageService, writeAuditService, cfg)
 	ingestHandler := ingest.NewHandler(ingestService, dsService)
 
 	// Initialize dashboard module
 	dashboardRepo := dashboard.NewRepository(db.Pool)
-	dashboardService := dashboard.NewService(dashboardRepo)
+	dashboardService := dashboard.NewService(dashboardRepo, usageService, writeAuditService, cfg)
 	dashboardHandler := dashboard.NewHandler(dashboardService)
 
 	// Initialize metric module (unified metrics)
 	metricRepo := metric.NewRepository(db.Pool)
-	metricService := metric.NewService(metricRepo, dsService)
+	metricService := metric.NewService(metricRepo, dsService, usageService, writeAuditService, cfg)
 	metricHandler := metric.NewHandler(metricService, dashboardService)
+	scheduler.Register(metric.NewTrendRunner(metricService).Job())
+
+	// Initialize goal module (targets for metrics with progress tracking)
+	goalRepo := goal.NewRepository(db.Pool)
+	goalService := goal.NewService(goalRepo, metricService, dashboardService, authService, writeAuditService)
+	goalHandler := goal.NewHandler(goalService)
+
+	// Initialize report module (documents of sections built from metrics and goals)
+	reportRepo := report.NewRepository(db.Pool)
+	reportService := report.NewService(reportRepo, metricService, dashboardService, goalService, writeAuditService)
+	reportHandler := report.NewHandler(reportService)
+
+	// Initialize trash module (restore and purge of deleted dashboards, metrics and reports)
+	trashService := trash.NewService(dashboardService, metricService, reportService)
+	trashHandler := trash.NewHandler(trashService)
+	scheduler.Register(trash.NewRunner(trashService).Job())
+
+	// Schedule weekly digest emails
+	digestRunner := digest.NewRunner(digest.NewRepository(db.Pool), dashboardService, metricService, goalService, notificationService, brandingService, cfg.AppURL)
+	scheduler.Register(digestRunner.Job())
+
+	// Initialize data quality module (weekly anomaly reports of data sources)
+	dataQualityRepo := dataquality.NewRepository(db.Pool)
+	dataQualityService := dataquality.NewService(dataQualityRepo, dsService)
+	dataQualityHandler := dataquality.NewHandler(dataQualityService)
+	dataQualityRunner := dataquality.NewRunner(dataQualityRepo, dataQualityService, notificationService, brandingService, cfg.AppURL)
+	scheduler.Register(dataQualityRunner.Job())
+
+	// Initialize measurement export module (scheduled exports to object storage)
+	exportRepo := export.NewRepository(db.Pool, keyring)
+	exportService := export.NewService(exportRepo, writeAuditService)
+	exportHandler := export.NewHandler(exportService)
+	scheduler.Register(export.NewRunner(exportRepo, exportService).Job())
+
+	// Initialize compute job module (background compute for heavy queries)
+	computeJobRepo := computejob.NewRepository(db.Pool)
+	computeJobService := computejob.NewService(computeJobRepo, metricService, dashboardService, cfg)
+	computeJobHandler := computejob.NewHandler(computeJobService)
+	computeJobRunner := computejob.NewRunner(computeJobRepo, computeJobService, cfg.ComputeJobWorkers)
+	drainer.Go(func() { computeJobRunner.Run(ctx) })
+	scheduler.Register(computeJobRunner.Job())
+
+	// Initialize comment module (discussion threads on dashboards and metrics)
+	commentRepo := comment.NewRepository(db.Pool)
+	commentService := comment.NewService(commentRepo, dashboardService, metricService, authService, notificationService, brandingService, cfg.AppURL)
+	commentHandler := comment.NewHandler(commentService)
+
+	// Initialize saved query module
+	savedQueryRepo := savedquery.NewRepository(db.Pool)
+	savedQueryService := savedquery.NewService(savedQueryRepo, metricService, dashboardService, writeAuditService)
+	savedQueryHandler := savedquery.NewHandler(savedQueryService)
+
+	// Initialize status page module (public dashboard health pages)
+	statusPageRepo := statuspage.NewRepository(db.Pool)
+	statusPageService := statuspage.NewService(statusPageRepo, metricService, dashboardService, writeAuditService)
+	statusPageHandler := statuspage.NewHandler(statusPageService)
+
+	// Initialize search module
+	searchRepo := search.NewRepository(db.Pool)
+	searchService := search.NewService(searchRepo)
+	searchHandler := search.NewHandler(searchService)
+
+	// Initialize integrations module (Slack slash command and link unfurling)
+	integrationsRepo := integrations.NewRepository(db.Pool, keyring)
+	integrationsService := integrations.NewService(integrationsRepo, searchService, dashboardService, metricService, writeAuditService, cfg.AppURL)
+	integrationsHandler := integrations.NewHandler(integrationsService)
+
+	// Encrypt stored third-party credentials with the current key
+	scheduler.Register(secrets.NewRotator(keyring, exportRepo, integrationsRepo).Job())
+
+	// Initialize changelog module
+	changelogRepo := changelog.NewRepository(db.Pool)
+	changelogService := changelog.NewService(changelogRepo, metricService)
+	changelogHandler := changelog.NewHandler(changelogService)
+
+	// Initialize onboarding module
+	onboardingRepo := onboarding.NewRepository(db.Pool)
+	onboardingService := onboarding.NewService(onboardingRepo)
+	onboardingHandler := onboarding.NewHandler(onboardingService)
 
 	// Initialize demo module
 	demoService := demo.NewService(dsService, ingestService)
 	demoHandler := demo.NewHandler(demoService)
 
+	// Initialize provisioning module
+	provisioningService := provisioning.NewService(dsService, dashboardService, metricService)
+	provisioningHandler := provisioning.NewHandler(provisioningService)
+
 	// Initialize MCP module
 	mcpRepo := mcp.NewRepository(db.Pool)
-	mcpService := mcp.NewService(mcpRepo, dsService)
+	mcpService := mcp.NewService(mcpRepo, dsService, writeAuditService)
 	mcpHandler := mcp.NewHandler(mcpService)
 	mcpServerFactory := mcp.NewServerFactory(dsService, ingestService)
 
-	// Health check endpoint
+	// Initialize instance admin module
+	adminRepo := admin.NewRepository(db.Pool)
+	adminService := admin.NewService(adminRepo, authService, usageService, scheduler, cfg.ImpersonationTTL)
+	adminHandler := admin.NewHandler(adminService)
+
+	// Per-client rate limits of the route groups
+	authLimit := platformmw.NewRateLimiter(cfg.RateLimits.Auth, platformmw.ClientIP).Handler
+	ingestLimit := platformmw.NewRateLimiter(cfg.RateLimits.Ingest, ingest.DataSourceKey).Handler
+	computeLimit := platformmw.NewRateLimiter(cfg.RateLimits.Compute, platformmw.ClientIP).Handler
+	exportsLimit := platformmw.NewRateLimiter(cfg.RateLimits.Exports, platformmw.ClientIP).Handler
+	browserLimit := platformmw.NewRateLimiter(cfg.RateLimits.Browser, platformmw.ClientIP).Handler
+	tokenLimit := platformmw.NewFailureLimiter(cfg.RateLimits.TokenFailures, platformmw.ClientIP).Handler
+
+	// Authenticated routes check the user's role against the policy table
+	policyTable := newPolicyTable(policy)
+	authenticated := func(next http.Handler) http.Handler {
+		return authService.Middleware(policyTable.authorize(next))
+	}
+
+	// Health check endpoint, and readiness for load balancers
 	r.Get("/health", healthHandler(db))
+	r.Get("/ready", readyHandler(db, drainer))
 
 	// Swagger documentation
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	))
 
-	// API v1 routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// API status endpoint
-		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-			respondJSON(w, http.StatusOK, map[string]string{
-				"message": "LiteKPI API v1",
-				"status":  "ok",
-			})
+	// Routes served by every API version
+	apiRoutes := func(r chi.Router) {
+		// Register auth routes
+		r.Group(func(r chi.Router) {
+			r.Use(authLimit)
+			authHandler.RegisterRoutes(r, authenticated, tokenLimit)
 		})
 
-		// Register auth routes
-		authHandler.RegisterRoutes(r, authService.Middleware)
+		// Register notification preference and delivery routes
+		notificationHandler.RegisterRoutes(r, authenticated)
 
 		// Register data source routes
-		dsHandler.RegisterRoutes(r, authService.Middleware)
+		dsHandler.RegisterRoutes(r, authenticated)
+
+		// Register organization usage routes
+		usageHandler.RegisterRoutes(r, authenticated)
+
+		// Register organization branding routes
+		brandingHandler.RegisterRoutes(r, authenticated)
+
+		// Register measurement export routes
+		r.Group(func(r chi.Router) {
+			r.Use(exportsLimit)
+			exportHandler.RegisterRoutes(r, authenticated)
+		})
+
+		// Register data quality routes
+		dataQualityHandler.RegisterRoutes(r, authenticated)
 
 		// Register dashboard routes
-		dashboardHandler.RegisterRoutes(r, authService.Middleware)
+		dashboardHandler.RegisterRoutes(r, authenticated)
 
 		// Register metric routes (unified metrics)
-		registerMetricRoutes(r, authService.Middleware, metricHandler)
+		registerMetricRoutes(r, authenticated, computeLimit, metricHandler)
+
+		// Register dashboard comment routes
+		commentHandler.RegisterRoutes(r, authenticated)
+
+		// Register goal routes
+		goalHandler.RegisterRoutes(r, authenticated)
+
+		// Register report routes
+		reportHandler.RegisterRoutes(r, authenticated)
+
+		// Register trash routes
+		trashHandler.RegisterRoutes(r, authenticated)
+
+		// Register saved query routes
+		savedQueryHandler.RegisterRoutes(r, authenticated)
+
+		// Register compute job routes
+		r.Group(func(r chi.Router) {
+			r.Use(computeLimit)
+			computeJobHandler.RegisterRoutes(r, authenticated)
+		})
+
+		// Register status page routes (public pages use the page token)
+		statusPageHandler.RegisterRoutes(r, authenticated, computeLimit)
+
+		// Register search routes
+		searchHandler.RegisterRoutes(r, authenticated)
+
+		// Register changelog routes
+		changelogHandler.RegisterRoutes(r, authenticated)
+
+		// Register onboarding routes
+		onboardingHandler.RegisterRoutes(r, authenticated)
 
 		// Register demo routes
-		demoHandler.RegisterRoutes(r, authService.Middleware)
+		demoHandler.RegisterRoutes(r, authenticated)
 
-		// Register ingest routes (uses API key auth, not JWT)
-		ingestHandler.RegisterRoutes(r, dsRepo)
+		// Register provisioning routes
+		provisioningHandler.RegisterRoutes(r, authenticated)
 
-		// Register measurement query routes (uses JWT auth)
-		ingestHandler.RegisterMeasurementRoutes(r, authService.Middleware)
+		// Register ingest routes (uses API key auth, not JWT, limited per data source)
+		ingestHandler.RegisterRoutes(r, dsService, ingestLimit)
+		ingestHandler.RegisterAutomationRoutes(r, dsService, ingestLimit)
+
+		// Register measurement query and duplicate scan routes (uses JWT auth)
+		r.Group(func(r chi.Router) {
+			r.Use(computeLimit)
+			ingestHandler.RegisterMeasurementRoutes(r, authenticated)
+			ingestHandler.RegisterDuplicateRoutes(r, authenticated)
+		})
+		ingestHandler.RegisterTransformRoutes(r, authenticated)
+		ingestHandler.RegisterSamplingRoutes(r, authenticated)
+		ingestHandler.RegisterFreshnessRoutes(r, authenticated)
 
 		// Register MCP key management routes (uses JWT auth, admin only)
-		mcpHandler.RegisterRoutes(r, authService.Middleware)
+		mcpHandler.RegisterRoutes(r, authenticated)
+
+		// Register instance admin routes (uses the instance admin token)
+		adminHandler.RegisterRoutes(r, cfg.InstanceAdminToken)
+	}
+
+	// API v1 routes
+	r.Route("/api/v1", func(r chi.Router) {
+		// API status endpoint
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			respondJSON(w, http.StatusOK, map[string]string{
+				"message": "LiteKPI API v1",
+				"status":  "ok",
+			})
+		})
+
+		apiRoutes(r)
+
+		// Routes whose callers cannot read the v2 envelope (Slack, pages
+		// using the browser snippet and MCP clients) are only served by v1
+
+		// Register integration routes (Slack requests use signature auth)
+		integrationsHandler.RegisterRoutes(r, authenticated, cfg.Slack.SigningSecret)
+
+		// Register browser ingest routes (uses public key auth, open CORS)
+		r.Group(func(r chi.Router) {
+			r.Use(browserLimit)
+			ingestHandler.RegisterBrowserRoutes(r, dsService)
+		})
 
 		// Register MCP protocol routes (uses MCP API key auth)
 		mcpHandler.RegisterMCPProtocolRoutes(r, mcpServerFactory.MCPHTTPHandler())
 	})
 
+	// API v2 routes: the v1 routes with enveloped responses, error codes and
+	// cursor pagination
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Use(platformmw.Envelope(listsUnder("/api/v2")))
+
+		// API status endpoint
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			respondJSON(w, http.StatusOK, map[string]string{
+				"message": "LiteKPI API v2",
+				"status":  "ok",
+			})
+		})
+
+		apiRoutes(r)
+	})
+
+	// Refuse to start with routes that change data but have no access rule
+	if err := policyTable.check(r); err != nil {
+		panic(err)
+	}
+
 	return r
 }
 
+// browserIngestPath is the prefix of the ingest routes called from web pages.
+const browserIngestPath = "/api/v1/ingest/browser"
+
+// corsHandler allows the app to call the API with credentials. The browser
+// ingest routes may be called from any origin without credentials; their
+// public keys restrict the origins instead.
+func corsHandler(cfg *config.Config) func(http.Handler) http.Handler {
+	app := cors.Handler(cors.Options{
+		AllowedOrigins:   []string{cfg.AppURL},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key", "If-Match"},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	})
+	browser := cors.Handler(cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"POST", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "X-Public-Key"},
+		MaxAge:         3600,
+	})
+
+	return func(next http.Handler) http.Handler {
+		appNext, browserNext := app(next), browser(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, browserIngestPath) {
+				browserNext.ServeHTTP(w, r)
+				return
+			}
+			appNext.ServeHTTP(w, r)
+		})
+	}
+}
+
 // healthHandler returns a health check handler.
 func healthHandler(db *database.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -160,6 +445,31 @@ func healthHandler(db *database.DB) http.HandlerFunc {
 	}
 }
 
+// readyHandler returns a readiness check handler. A draining server is not
+// ready, so load balancers stop routing to it before it shuts down.
+func readyHandler(db *database.DB, drainer *lifecycle.Drainer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if drainer.Draining() {
+			respondJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"status": "draining",
+			})
+			return
+		}
+
+		if err := db.Health(r.Context()); err != nil {
+			respondJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"status":   "unready",
+				"database": "disconnected",
+			})
+			return
+		}
+
+		respondJSON(w, http.StatusOK, map[string]string{
+			"status": "ready",
+		})
+	}
+}
+
 // respondJSON writes a JSON response.
 func respondJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -169,23 +479,58 @@ func respondJSON(w http.ResponseWriter, status int, data any) {
 	}
 }
 
-// registerMetricRoutes registers the unified metric routes.
-func registerMetricRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler, h *metric.Handler) {
+// registerMetricRoutes registers the unified metric and exploration routes.
+func registerMetricRoutes(r chi.Router, authMiddleware, computeLimit func(next http.Handler) http.Handler, h *metric.Handler) {
 	r.Route("/dashboards/{id}/metrics", func(r chi.Router) {
 		r.Use(authMiddleware)
 
 		// Read operations
 		r.Get("/", h.ListMetrics)
-		r.Get("/compute", h.ComputeMetrics)
+		r.With(computeLimit).Get("/compute", h.ComputeMetrics)
+		r.With(computeLimit).Get("/{metricId}/data", h.GetMetricData)
+		r.Get("/{metricId}/versions", h.ListMetricVersions)
+		r.Get("/external/{externalId}", h.GetMetricByExternalID)
 
 		// Write operations (editor and admin only)
-		r.Group(func(r chi.Router) {
-			r.Use(auth.EditorMiddleware)
+		r.Post("/", h.CreateMetric)
+		r.Put("/{metricId}", h.UpdateMetric)
+		r.Put("/external/{externalId}", h.PutMetric)
+		r.Delete("/{metricId}", h.DeleteMetric)
+		r.Post("/{metricId}/versions/{version}/revert", h.RevertMetricVersion)
+		r.Put("/reorder", h.ReorderMetrics)
+	})
 
-			r.Post("/", h.CreateMetric)
-			r.Put("/{metricId}", h.UpdateMetric)
-			r.Delete("/{metricId}", h.DeleteMetric)
-			r.Put("/reorder", h.ReorderMetrics)
-		})
+	// Ad-hoc exploration (not tied to a dashboard until saved)
+	r.Route("/explore", func(r chi.Router) {
+		r.Use(computeLimit)
+		r.Use(authMiddleware)
+
+		r.Post("/", h.Explore)
+		r.Post("/compare", h.ComparePeriods)
+		r.Post("/save", h.SaveExploration)
+	})
+
+	// Defaults for new metrics (admin only to update)
+	r.Route("/organization/metric-defaults", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		r.Get("/", h.GetMetricDefaults)
+		r.Put("/", h.UpdateMetricDefaults)
+	})
+
+	// Fiscal calendar (admin only to update)
+	r.Route("/organization/calendar", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		r.Get("/", h.GetCalendar)
+		r.Put("/", h.UpdateCalendar)
+	})
+
+	// Number and date formatting (admin only to update)
+	r.Route("/organization/locale", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		r.Get("/", h.GetLocale)
+		r.Put("/", h.UpdateLocale)
 	})
 }
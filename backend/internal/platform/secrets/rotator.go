@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const rotationInterval = time.Hour
+
+// Store holds encrypted secrets.
+type Store interface {
+	// ReencryptSecrets encrypts the secrets that are stored in plain text or
+	// under an old key with the current key, returning how many it encrypted.
+	ReencryptSecrets(ctx context.Context) (int, error)
+}
+
+// Rotator encrypts stored secrets with the current key, so that secrets
+// stored before encryption was configured get encrypted and old keys can be
+// removed once a new key has been added.
+type Rotator struct {
+	keyring *Keyring
+	stores  []Store
+}
+
+// NewRotator creates a new secret rotator.
+func NewRotator(keyring *Keyring, stores ...Store) *Rotator {
+	return &Rotator{keyring: keyring, stores: stores}
+}
+
+// Job returns the scheduled job that re-encrypts stored secrets.
+func (r *Rotator) Job() jobs.Job {
+	return jobs.Job{Name: "secret_rotation", Interval: rotationInterval, Run: r.RunOnce}
+}
+
+// RunOnce re-encrypts the stored secrets of every store. It does nothing
+// when no key is configured.
+func (r *Rotator) RunOnce(ctx context.Context) error {
+	if !r.keyring.Enabled() {
+		return nil
+	}
+
+	total := 0
+	for _, store := range r.stores {
+		n, err := store.ReencryptSecrets(ctx)
+		total += n
+		if err != nil {
+			return fmt.Errorf("re-encrypting secrets: %w", err)
+		}
+	}
+	if total > 0 {
+		log.Printf("encrypted %d stored secrets with key %q", total, r.keyring.current)
+	}
+	return nil
+}
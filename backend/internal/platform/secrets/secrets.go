@@ -0,0 +1,138 @@
+// Package secrets encrypts third-party credentials, such as Slack bot tokens
+// and object storage keys, before they are stored.
+//
+// Values are sealed with AES-256-GCM under the first key of a keyring and
+// tagged with the key's ID, so that older keys can still open them after a
+// new key is added in front. Rotator re-encrypts stored values under the
+// current key, after which old keys can be removed.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks encrypted values; values without it are stored in plain text,
+// as they were before encryption was configured.
+const prefix = "enc:v1:"
+
+var (
+	ErrUnknownKey = errors.New("secret is encrypted with a key that is not configured")
+	ErrMalformed  = errors.New("malformed encrypted secret")
+)
+
+// Keyring seals and opens secrets. The zero value, and a keyring without
+// keys, stores secrets in plain text.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring parses a comma-separated list of keys, each an ID and a
+// base64-encoded 32-byte key separated by a colon, such as
+// "2026-10:q2Hk...,2025-03:Zm9v...". The first key encrypts; all of them
+// decrypt. An empty spec returns a keyring without keys.
+func NewKeyring(spec string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key %q must be an ID and a base64 key separated by a colon", entry)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("key ID %q is used twice", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, got %d", id, len(raw))
+		}
+
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+
+		if k.current == "" {
+			k.current = id
+		}
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+// Enabled reports whether the keyring encrypts secrets.
+func (k *Keyring) Enabled() bool {
+	return k != nil && k.current != ""
+}
+
+// Seal encrypts a secret under the current key. Empty secrets, and all
+// secrets when no key is configured, are returned unchanged.
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	if !k.Enabled() || plaintext == "" {
+		return plaintext, nil
+	}
+
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.current))
+	return prefix + k.current + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a stored secret. Secrets stored in plain text are returned
+// unchanged.
+func (k *Keyring) Open(stored string) (string, error) {
+	if !strings.HasPrefix(stored, prefix) {
+		return stored, nil
+	}
+
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(stored, prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	var aead cipher.AEAD
+	if k != nil {
+		aead = k.keys[id]
+	}
+	if aead == nil {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
+
+// Stale reports whether a stored secret should be sealed again: it is in
+// plain text or under a key other than the current one.
+func (k *Keyring) Stale(stored string) bool {
+	if !k.Enabled() || stored == "" {
+		return false
+	}
+	return !strings.HasPrefix(stored, prefix+k.current+":")
+}
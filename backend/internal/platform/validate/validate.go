@@ -0,0 +1,253 @@
+// Package validate checks request bodies against their validate struct tags
+// and reports every invalid field, so handlers do not repeat if-chains and
+// clients can show errors next to the fields they belong to.
+//
+// Supported rules, separated by commas:
+//
+//	required      the field must not be the zero value or empty
+//	omitempty     skip the other rules if the field is the zero value or empty
+//	email         a single email address
+//	min=N, max=N  length of strings and slices, value of numbers
+//	oneof=a b c   one of the space-separated values
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Codes of field errors.
+const (
+	CodeRequired      = "required"
+	CodeInvalidEmail  = "invalid_email"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeTooSmall      = "too_small"
+	CodeTooLarge      = "too_large"
+	CodeInvalidChoice = "invalid_choice"
+)
+
+// ErrInvalidBody is returned by DecodeJSON for bodies that are not valid
+// JSON of the expected shape.
+var ErrInvalidBody = errors.New("invalid request body")
+
+// FieldError is a rule a field of a request broke.
+type FieldError struct {
+	Field   string `json:"field"` // JSON name, dotted for nested fields
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors are the field errors of a request, in field order.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	if len(e) == 0 {
+		return "invalid request"
+	}
+	return e[0].Message
+}
+
+// ErrorResponse is the response body of a failed validation. Error holds the
+// message of the first field error, for clients that only show one.
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// DecodeJSON decodes a request body into dst, a pointer to a struct, and
+// validates it. It returns ErrInvalidBody or Errors.
+func DecodeJSON(r *http.Request, dst any) error {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		return ErrInvalidBody
+	}
+	return Struct(dst)
+}
+
+// RespondError writes a validation error returned by DecodeJSON or Struct
+// as 400 Bad Request.
+func RespondError(w http.ResponseWriter, err error) {
+	resp := ErrorResponse{Error: err.Error()}
+	var fieldErrs Errors
+	if errors.As(err, &fieldErrs) {
+		resp.Fields = fieldErrs
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Struct validates a struct, or a pointer to one, against its validate tags.
+// Embedded structs are validated as part of it and nested structs with their
+// field name as a prefix. It returns nil or Errors.
+func Struct(v any) error {
+	var errs Errors
+	validateStruct(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *Errors) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Anonymous {
+			validateStruct(fv, prefix, errs)
+			continue
+		}
+
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		if tag, ok := sf.Tag.Lookup("validate"); ok {
+			if fe := validateField(fv, prefix+name, tag); fe != nil {
+				*errs = append(*errs, *fe)
+				continue
+			}
+		}
+
+		// Nested request objects carry their own tags
+		inner := fv
+		for inner.Kind() == reflect.Pointer && !inner.IsNil() {
+			inner = inner.Elem()
+		}
+		if inner.Kind() == reflect.Struct && inner.Type().PkgPath() != "time" {
+			validateStruct(inner, prefix+name+".", errs)
+		}
+	}
+}
+
+// validateField applies a field's rules and returns the first one it breaks.
+func validateField(v reflect.Value, field, tag string) *FieldError {
+	rules := strings.Split(tag, ",")
+	if isEmpty(v) {
+		for _, rule := range rules {
+			if rule == "required" {
+				return &FieldError{Field: field, Code: CodeRequired, Message: field + " is required"}
+			}
+		}
+		return nil
+	}
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "email":
+			if v.Kind() == reflect.String {
+				if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() {
+					return &FieldError{Field: field, Code: CodeInvalidEmail, Message: field + " must be a valid email address"}
+				}
+			}
+		case "min", "max":
+			if fe := checkBound(v, field, name, param); fe != nil {
+				return fe
+			}
+		case "oneof":
+			choices := strings.Fields(param)
+			value := fmt.Sprint(v.Interface())
+			found := false
+			for _, c := range choices {
+				if c == value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return &FieldError{Field: field, Code: CodeInvalidChoice, Message: field + " must be one of " + strings.Join(choices, ", ")}
+			}
+		}
+	}
+	return nil
+}
+
+// checkBound applies a min or max rule: a length for strings and slices, a
+// value for numbers.
+func checkBound(v reflect.Value, field, rule, param string) *FieldError {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil
+	}
+	plural := "s"
+	if bound == 1 {
+		plural = ""
+	}
+
+	var n float64
+	var unit string
+	switch v.Kind() {
+	case reflect.String:
+		n, unit = float64(len([]rune(v.String()))), " character"+plural
+	case reflect.Slice, reflect.Map, reflect.Array:
+		n, unit = float64(v.Len()), " item"+plural
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return nil
+	}
+
+	if rule == "min" && n < bound {
+		code := CodeTooSmall
+		if unit != "" {
+			code = CodeTooShort
+		}
+		return &FieldError{Field: field, Code: code, Message: fmt.Sprintf("%s must be at least %s%s", field, param, unit)}
+	}
+	if rule == "max" && n > bound {
+		code := CodeTooLarge
+		if unit != "" {
+			code = CodeTooLong
+		}
+		return &FieldError{Field: field, Code: code, Message: fmt.Sprintf("%s must be at most %s%s", field, param, unit)}
+	}
+	return nil
+}
+
+// isEmpty reports whether a value counts as missing for required.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
@@ -0,0 +1,132 @@
+package provisioning
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/metric"
+)
+
+// Error definitions
+var (
+	ErrDuplicateName     = errors.New("name is declared more than once")
+	ErrAmbiguousName     = errors.New("name matches more than one existing resource")
+	ErrNameEmpty         = errors.New("name is required")
+	ErrUnknownDataSource = errors.New("data source is neither declared nor existing")
+)
+
+// Spec is the declared configuration of an organization. Data sources and
+// dashboards are matched to existing ones by name, and metrics by label
+// within their dashboard.
+type Spec struct {
+	DataSources []DataSourceSpec `json:"dataSources"`
+	Dashboards  []DashboardSpec  `json:"dashboards"`
+	// Move dashboards not declared, and metrics not declared on declared
+	// dashboards, to the trash. Data sources are never deleted.
+	Prune bool `json:"prune"`
+}
+
+// DataSourceSpec is a declared data source.
+type DataSourceSpec struct {
+	Name              string   `json:"name"`
+	AllowedCIDRs      []string `json:"allowedCidrs,omitempty"`      // Omit to leave the allowlist as is
+	RelaxedUniqueness *bool    `json:"relaxedUniqueness,omitempty"` // Omit to leave the setting as is
+}
+
+// DashboardSpec is a declared dashboard with its metrics, in display order.
+type DashboardSpec struct {
+	Name    string       `json:"name"`
+	Metrics []MetricSpec `json:"metrics"`
+}
+
+// MetricSpec is a declared metric. Its data source is referenced by name,
+// or by ID; omit both to use the organization's default data source.
+type MetricSpec struct {
+	DataSource string `json:"dataSource,omitempty"`
+	metric.CreateMetricRequest
+}
+
+// Action is what applying a spec does to a resource.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionReplace   Action = "replace" // Delete and recreate; a metric's data source and measurement are fixed
+	ActionDelete    Action = "delete"  // Move to the trash
+	ActionUnchanged Action = "unchanged"
+)
+
+// Kind is the type of a provisioned resource.
+type Kind string
+
+const (
+	KindDataSource Kind = "data_source"
+	KindDashboard  Kind = "dashboard"
+	KindMetric     Kind = "metric"
+)
+
+// Change is one step of a plan.
+type Change struct {
+	Action    Action     `json:"action"`
+	Kind      Kind       `json:"kind"`
+	Name      string     `json:"name"`                // Name of the data source or dashboard, or label of the metric
+	Dashboard string     `json:"dashboard,omitempty"` // Dashboard of a metric
+	ID        *uuid.UUID `json:"id,omitempty"`        // The existing resource, or the created one after applying
+	Fields    []string   `json:"fields,omitempty"`    // Fields an update changes
+}
+
+// PlanResponse is the response body for diffing a spec.
+type PlanResponse struct {
+	Changes []Change `json:"changes"`
+}
+
+// ApplyResponse is the response body for applying a spec.
+type ApplyResponse struct {
+	Changes []Change          `json:"changes"`
+	APIKeys map[string]string `json:"apiKeys,omitempty"` // API keys of created data sources by name, shown only once
+}
+
+// SpecError is returned for an invalid spec before anything is applied.
+type SpecError struct {
+	Path string // Location in the spec, e.g. dashboards[0].metrics[2]
+	Err  error
+}
+
+func (e *SpecError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyError is returned when applying a spec fails part way. The changes
+// before the failing one have been applied; applying again continues from
+// there.
+type ApplyError struct {
+	Applied []Change
+	Failed  Change
+	Err     error
+}
+
+func (e *ApplyError) Error() string {
+	return fmt.Sprintf("failed to %s %s %q: %v", e.Failed.Action, e.Failed.Kind, e.Failed.Name, e.Err)
+}
+
+func (e *ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ApplyErrorResponse is the response for a spec that failed to apply part way.
+type ApplyErrorResponse struct {
+	Error   string   `json:"error"`
+	Applied []Change `json:"applied"` // Changes made before the failure
+}
@@ -0,0 +1,130 @@
+package provisioning
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+	"github.com/devbydaniel/litekpi/internal/usage"
+)
+
+// Handler handles HTTP requests for provisioning.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new provisioning handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Diff handles planning a spec without applying it.
+//
+//	@Summary		Diff provisioning spec
+//	@Description	Compare a declarative spec of data sources, dashboards and metrics with the organization's current configuration and list the changes applying it would make. Nothing is changed.
+//	@Tags			provisioning
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		Spec	true	"Declared configuration"
+//	@Success		200		{object}	PlanResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/provisioning/diff [post]
+func (h *Handler) Diff(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var spec Spec
+	if err := validate.DecodeJSON(r, &spec); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	plan, err := h.service.Plan(r.Context(), user.OrganizationID, spec)
+	if err != nil {
+		var specErr *SpecError
+		if errors.As(err, &specErr) {
+			respondError(w, http.StatusBadRequest, specErr.Error())
+			return
+		}
+		log.Printf("diff provisioning spec error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to diff spec")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, plan)
+}
+
+// Apply handles converging the organization to a spec.
+//
+//	@Summary		Apply provisioning spec
+//	@Description	Create and update data sources, dashboards and metrics to match a declarative spec. Applying the same spec again changes nothing. With prune, undeclared dashboards and undeclared metrics of declared dashboards are moved to the trash. The spec is validated before any change; if a change then fails, the response lists those applied before it and applying again continues from there.
+//	@Tags			provisioning
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		Spec	true	"Declared configuration"
+//	@Success		200		{object}	ApplyResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		429		{object}	ApplyErrorResponse
+//	@Failure		500		{object}	ApplyErrorResponse
+//	@Router			/provisioning/apply [post]
+func (h *Handler) Apply(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var spec Spec
+	if err := validate.DecodeJSON(r, &spec); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	resp, err := h.service.Apply(r.Context(), user.OrganizationID, user.ID, spec)
+	if err != nil {
+		var specErr *SpecError
+		if errors.As(err, &specErr) {
+			respondError(w, http.StatusBadRequest, specErr.Error())
+			return
+		}
+		var applyErr *ApplyError
+		if !errors.As(err, &applyErr) {
+			log.Printf("apply provisioning spec error: %v", err)
+			respondError(w, http.StatusInternalServerError, "failed to apply spec")
+			return
+		}
+		status, message := http.StatusInternalServerError, "failed to apply spec"
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			status, message = http.StatusTooManyRequests, applyErr.Error()
+		} else {
+			log.Printf("apply provisioning spec error: %v", err)
+		}
+		respondJSON(w, status, ApplyErrorResponse{Error: message, Applied: applyErr.Applied})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
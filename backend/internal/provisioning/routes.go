@@ -0,0 +1,19 @@
+package provisioning
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the provisioning routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/provisioning", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// Admin only
+
+		r.Post("/diff", h.Diff)
+		r.Post("/apply", h.Apply)
+	})
+}
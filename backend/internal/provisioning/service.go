@@ -0,0 +1,506 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/datasource"
+	"github.com/devbydaniel/litekpi/internal/metric"
+)
+
+// Service handles declarative provisioning of an organization's data
+// sources, dashboards and metrics.
+type Service struct {
+	dataSourceService *datasource.Service
+	dashboardService  *dashboard.Service
+	metricService     *metric.Service
+}
+
+// NewService creates a new provisioning service.
+func NewService(dataSourceService *datasource.Service, dashboardService *dashboard.Service, metricService *metric.Service) *Service {
+	return &Service{
+		dataSourceService: dataSourceService,
+		dashboardService:  dashboardService,
+		metricService:     metricService,
+	}
+}
+
+// plan is the set of steps that converge an organization to a spec.
+type plan struct {
+	dataSources       []dataSourceStep
+	dashboards        []dashboardStep
+	deletedDashboards []Change
+}
+
+type dataSourceStep struct {
+	change Change
+	spec   DataSourceSpec
+}
+
+type dashboardStep struct {
+	change  Change
+	metrics []metricStep
+	deleted []Change // Undeclared metrics moved to the trash when pruning
+	kept    []uuid.UUID
+}
+
+type metricStep struct {
+	change     Change
+	req        metric.CreateMetricRequest
+	dataSource string // Declared data source to create the metric on, once it exists
+}
+
+// changes lists the steps of the plan in the order they are applied.
+func (p *plan) changes() []Change {
+	changes := []Change{}
+	for _, ds := range p.dataSources {
+		changes = append(changes, ds.change)
+	}
+	for _, d := range p.dashboards {
+		changes = append(changes, d.change)
+		for _, m := range d.metrics {
+			changes = append(changes, m.change)
+		}
+		changes = append(changes, d.deleted...)
+	}
+	return append(changes, p.deletedDashboards...)
+}
+
+// Plan returns the changes that applying spec would make, without making them.
+func (s *Service) Plan(ctx context.Context, orgID uuid.UUID, spec Spec) (*PlanResponse, error) {
+	p, err := s.plan(ctx, orgID, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &PlanResponse{Changes: p.changes()}, nil
+}
+
+// Apply converges the organization to spec. Changes are applied one by one;
+// if one fails, an *ApplyError reports those applied before it.
+func (s *Service) Apply(ctx context.Context, orgID, userID uuid.UUID, spec Spec) (*ApplyResponse, error) {
+	p, err := s.plan(ctx, orgID, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ApplyResponse{Changes: []Change{}}
+	fail := func(c Change, err error) error {
+		return &ApplyError{Applied: response.Changes, Failed: c, Err: err}
+	}
+
+	// Data sources first, so that metrics can reference the created ones
+	created := make(map[string]uuid.UUID)
+	for _, step := range p.dataSources {
+		c := step.change
+		if c.Action == ActionCreate {
+			ds, err := s.dataSourceService.CreateDataSource(ctx, orgID, datasource.CreateDataSourceRequest{Name: step.spec.Name})
+			if err != nil {
+				return nil, fail(c, err)
+			}
+			c.ID = &ds.DataSource.ID
+			created[step.spec.Name] = ds.DataSource.ID
+			if response.APIKeys == nil {
+				response.APIKeys = make(map[string]string)
+			}
+			response.APIKeys[step.spec.Name] = ds.APIKey
+		}
+		if c.Action != ActionUnchanged {
+			if err := s.applyDataSourceSettings(ctx, orgID, *c.ID, step.spec); err != nil {
+				return nil, fail(c, err)
+			}
+		}
+		response.Changes = append(response.Changes, c)
+	}
+
+	for _, step := range p.dashboards {
+		c := step.change
+		if c.Action == ActionCreate {
+			d, err := s.dashboardService.CreateDashboard(ctx, orgID, dashboard.CreateDashboardRequest{Name: c.Name})
+			if err != nil {
+				return nil, fail(c, err)
+			}
+			c.ID = &d.ID
+			response.Changes = append(response.Changes, c)
+		}
+		dashboardID := *c.ID
+
+		order := make([]uuid.UUID, 0, len(step.metrics)+len(step.kept))
+		for _, m := range step.metrics {
+			mc := m.change
+			req := m.req
+			if m.dataSource != "" {
+				req.DataSourceID = created[m.dataSource]
+			}
+
+			switch mc.Action {
+			case ActionCreate, ActionReplace:
+				if mc.Action == ActionReplace {
+					if err := s.metricService.Delete(ctx, dashboardID, *mc.ID); err != nil {
+						return nil, fail(mc, err)
+					}
+				}
+				m, err := s.metricService.Create(ctx, orgID, dashboardID, req)
+				if err != nil {
+					return nil, fail(mc, err)
+				}
+				mc.ID = &m.ID
+			case ActionUpdate:
+				if _, err := s.metricService.Update(ctx, dashboardID, *mc.ID, userID, req.UpdateRequest(), nil); err != nil {
+					return nil, fail(mc, err)
+				}
+			}
+			order = append(order, *mc.ID)
+			response.Changes = append(response.Changes, mc)
+		}
+
+		for _, mc := range step.deleted {
+			if err := s.metricService.Delete(ctx, dashboardID, *mc.ID); err != nil {
+				return nil, fail(mc, err)
+			}
+			response.Changes = append(response.Changes, mc)
+		}
+
+		// Declared metrics come first, in spec order
+		if c.Action == ActionUpdate {
+			order = append(order, step.kept...)
+			if err := s.metricService.Reorder(ctx, dashboardID, order, nil); err != nil {
+				return nil, fail(c, err)
+			}
+		}
+		if c.Action != ActionCreate {
+			response.Changes = append(response.Changes, c)
+		}
+	}
+
+	for _, c := range p.deletedDashboards {
+		if err := s.dashboardService.DeleteDashboard(ctx, orgID, *c.ID); err != nil {
+			return nil, fail(c, err)
+		}
+		response.Changes = append(response.Changes, c)
+	}
+
+	return response, nil
+}
+
+// applyDataSourceSettings applies the declared settings of a data source.
+func (s *Service) applyDataSourceSettings(ctx context.Context, orgID, dataSourceID uuid.UUID, spec DataSourceSpec) error {
+	if spec.AllowedCIDRs != nil {
+		req := datasource.UpdateAllowedCIDRsRequest{AllowedCIDRs: spec.AllowedCIDRs}
+		if _, err := s.dataSourceService.UpdateAllowedCIDRs(ctx, orgID, dataSourceID, req); err != nil {
+			return err
+		}
+	}
+	if spec.RelaxedUniqueness != nil {
+		req := datasource.UpdateUniquenessRequest{RelaxedUniqueness: *spec.RelaxedUniqueness}
+		if _, err := s.dataSourceService.UpdateUniqueness(ctx, orgID, dataSourceID, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planner compares a spec with an organization's current configuration.
+type planner struct {
+	*Service
+	orgID       uuid.UUID
+	prune       bool
+	dataSources map[string][]datasource.DataSource // Existing data sources by name
+	declared    map[string]*uuid.UUID              // Declared data sources by name; nil ID until created
+	defaultID   *uuid.UUID                         // Resolved default data source
+}
+
+// plan validates spec and compares it with the organization's current
+// configuration.
+func (s *Service) plan(ctx context.Context, orgID uuid.UUID, spec Spec) (*plan, error) {
+	existingDataSources, err := s.dataSourceService.ListDataSources(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	pl := &planner{
+		Service:     s,
+		orgID:       orgID,
+		prune:       spec.Prune,
+		dataSources: make(map[string][]datasource.DataSource),
+		declared:    make(map[string]*uuid.UUID),
+	}
+	for _, ds := range existingDataSources {
+		pl.dataSources[ds.Name] = append(pl.dataSources[ds.Name], ds)
+	}
+
+	p := &plan{}
+	for i, dsSpec := range spec.DataSources {
+		step, err := pl.planDataSource(fmt.Sprintf("dataSources[%d]", i), dsSpec)
+		if err != nil {
+			return nil, err
+		}
+		p.dataSources = append(p.dataSources, *step)
+	}
+
+	existingDashboards, err := s.dashboardService.ListDashboards(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	dashboardsByName := make(map[string][]dashboard.Dashboard)
+	for _, d := range existingDashboards {
+		dashboardsByName[d.Name] = append(dashboardsByName[d.Name], d)
+	}
+
+	declaredDashboards := make(map[string]bool)
+	for i, dSpec := range spec.Dashboards {
+		path := fmt.Sprintf("dashboards[%d]", i)
+		name := strings.TrimSpace(dSpec.Name)
+		if name == "" {
+			return nil, &SpecError{Path: path, Err: ErrNameEmpty}
+		}
+		if declaredDashboards[name] {
+			return nil, &SpecError{Path: path, Err: fmt.Errorf("%w: %q", ErrDuplicateName, name)}
+		}
+		declaredDashboards[name] = true
+
+		var existing *dashboard.Dashboard
+		switch matches := dashboardsByName[name]; len(matches) {
+		case 0:
+		case 1:
+			existing = &matches[0]
+		default:
+			return nil, &SpecError{Path: path, Err: fmt.Errorf("%w: %q", ErrAmbiguousName, name)}
+		}
+
+		step, err := pl.planDashboard(ctx, path, name, dSpec.Metrics, existing)
+		if err != nil {
+			return nil, err
+		}
+		p.dashboards = append(p.dashboards, *step)
+	}
+
+	// The default dashboard cannot be deleted, so it is never pruned
+	if spec.Prune {
+		for _, d := range existingDashboards {
+			if declaredDashboards[d.Name] || d.IsDefault {
+				continue
+			}
+			p.deletedDashboards = append(p.deletedDashboards, Change{Action: ActionDelete, Kind: KindDashboard, Name: d.Name, ID: &d.ID})
+		}
+	}
+
+	return p, nil
+}
+
+// planDataSource compares a declared data source with the existing one of
+// the same name.
+func (pl *planner) planDataSource(path string, spec DataSourceSpec) (*dataSourceStep, error) {
+	spec.Name = strings.TrimSpace(spec.Name)
+	if spec.Name == "" {
+		return nil, &SpecError{Path: path, Err: ErrNameEmpty}
+	}
+	if _, ok := pl.declared[spec.Name]; ok {
+		return nil, &SpecError{Path: path, Err: fmt.Errorf("%w: %q", ErrDuplicateName, spec.Name)}
+	}
+	if spec.AllowedCIDRs != nil {
+		cidrs, err := datasource.NormalizeCIDRs(spec.AllowedCIDRs)
+		if err != nil {
+			return nil, &SpecError{Path: path + ".allowedCidrs", Err: err}
+		}
+		spec.AllowedCIDRs = cidrs
+	}
+
+	step := &dataSourceStep{
+		change: Change{Action: ActionCreate, Kind: KindDataSource, Name: spec.Name},
+		spec:   spec,
+	}
+	switch matches := pl.dataSources[spec.Name]; len(matches) {
+	case 0:
+		pl.declared[spec.Name] = nil
+	case 1:
+		ds := matches[0]
+		pl.declared[spec.Name] = &ds.ID
+		step.change.ID = &ds.ID
+		step.change.Fields = dataSourceChanges(ds, spec)
+		step.change.Action = ActionUnchanged
+		if len(step.change.Fields) > 0 {
+			step.change.Action = ActionUpdate
+		}
+	default:
+		return nil, &SpecError{Path: path, Err: fmt.Errorf("%w: %q", ErrAmbiguousName, spec.Name)}
+	}
+	return step, nil
+}
+
+// planDashboard compares the declared metrics of a dashboard with its
+// current ones, matching them by label. existing is nil for a dashboard
+// that does not exist yet.
+func (pl *planner) planDashboard(ctx context.Context, path, name string, specs []MetricSpec, existing *dashboard.Dashboard) (*dashboardStep, error) {
+	step := &dashboardStep{change: Change{Action: ActionCreate, Kind: KindDashboard, Name: name}}
+
+	var current []metric.Metric
+	if existing != nil {
+		step.change.Action = ActionUnchanged
+		step.change.ID = &existing.ID
+		var err error
+		if current, err = pl.metricService.GetByDashboardID(ctx, existing.ID); err != nil {
+			return nil, err
+		}
+	}
+	currentByLabel := make(map[string][]metric.Metric)
+	for _, m := range current {
+		currentByLabel[m.Label] = append(currentByLabel[m.Label], m)
+	}
+
+	labels := make(map[string]bool)
+	for i, spec := range specs {
+		mPath := fmt.Sprintf("%s.metrics[%d]", path, i)
+		req := spec.CreateMetricRequest
+		req.Label = strings.TrimSpace(req.Label)
+		if labels[req.Label] {
+			return nil, &SpecError{Path: mPath, Err: fmt.Errorf("%w: %q", ErrDuplicateName, req.Label)}
+		}
+		labels[req.Label] = true
+		if err := pl.metricService.ApplyDefaults(ctx, pl.orgID, &req); err != nil {
+			return nil, err
+		}
+		if err := metric.ValidateConfig(req); err != nil {
+			return nil, &SpecError{Path: mPath, Err: err}
+		}
+
+		mStep := metricStep{change: Change{Action: ActionCreate, Kind: KindMetric, Name: req.Label, Dashboard: name}}
+		if err := pl.resolveDataSource(ctx, spec.DataSource, &req, &mStep); err != nil {
+			return nil, &SpecError{Path: mPath, Err: err}
+		}
+		mStep.req = req
+
+		switch matches := currentByLabel[req.Label]; len(matches) {
+		case 0:
+		case 1:
+			m := matches[0]
+			mStep.change.ID = &m.ID
+			if mStep.dataSource != "" || m.DataSourceID != req.DataSourceID || m.MeasurementName != req.MeasurementName {
+				mStep.change.Action = ActionReplace
+				break
+			}
+			mStep.change.Fields = m.ConfigChanges(req.UpdateRequest())
+			mStep.change.Action = ActionUnchanged
+			if len(mStep.change.Fields) > 0 {
+				mStep.change.Action = ActionUpdate
+			}
+		default:
+			return nil, &SpecError{Path: mPath, Err: fmt.Errorf("%w: %q", ErrAmbiguousName, req.Label)}
+		}
+		step.metrics = append(step.metrics, mStep)
+	}
+
+	// Undeclared metrics are kept after the declared ones, or pruned
+	for _, m := range current {
+		if labels[m.Label] {
+			continue
+		}
+		if pl.prune {
+			step.deleted = append(step.deleted, Change{Action: ActionDelete, Kind: KindMetric, Name: m.Label, Dashboard: name, ID: &m.ID})
+			continue
+		}
+		step.kept = append(step.kept, m.ID)
+	}
+
+	if existing != nil && !declaredOrder(current, step) {
+		step.change.Action = ActionUpdate
+		step.change.Fields = []string{"metricOrder"}
+	}
+
+	return step, nil
+}
+
+// resolveDataSource sets the data source of a declared metric from its name,
+// its ID or the organization's default. A metric on a data source that is
+// declared but not created yet gets it when applying.
+func (pl *planner) resolveDataSource(ctx context.Context, name string, req *metric.CreateMetricRequest, step *metricStep) error {
+	if name = strings.TrimSpace(name); name != "" {
+		if id, ok := pl.declared[name]; ok {
+			if id == nil {
+				step.dataSource = name
+				return nil
+			}
+			req.DataSourceID = *id
+			return nil
+		}
+		switch matches := pl.dataSources[name]; len(matches) {
+		case 0:
+			return fmt.Errorf("%w: %q", ErrUnknownDataSource, name)
+		case 1:
+			req.DataSourceID = matches[0].ID
+			return nil
+		default:
+			return fmt.Errorf("%w: %q", ErrAmbiguousName, name)
+		}
+	}
+
+	if req.DataSourceID == uuid.Nil {
+		if pl.defaultID == nil {
+			id, err := pl.dataSourceService.ResolveDataSourceID(ctx, pl.orgID, uuid.Nil)
+			if err != nil {
+				return err
+			}
+			pl.defaultID = &id
+		}
+		req.DataSourceID = *pl.defaultID
+		return nil
+	}
+	_, err := pl.dataSourceService.GetDataSource(ctx, pl.orgID, req.DataSourceID)
+	return err
+}
+
+// declaredOrder reports whether applying the step leaves the dashboard's
+// metrics in the declared order without reordering. Created and replaced
+// metrics are appended to the end of the dashboard.
+func declaredOrder(current []metric.Metric, step *dashboardStep) bool {
+	moved := make(map[uuid.UUID]bool)
+	var appended []Change
+	for _, m := range step.metrics {
+		if m.change.Action == ActionCreate || m.change.Action == ActionReplace {
+			appended = append(appended, m.change)
+			if m.change.ID != nil {
+				moved[*m.change.ID] = true
+			}
+		}
+	}
+
+	var result []string
+	for _, m := range current {
+		if !moved[m.ID] && !slices.ContainsFunc(step.deleted, func(c Change) bool { return *c.ID == m.ID }) {
+			result = append(result, m.ID.String())
+		}
+	}
+	for _, c := range appended {
+		result = append(result, "new:"+c.Name)
+	}
+
+	want := make([]string, 0, len(result))
+	for _, m := range step.metrics {
+		if m.change.Action == ActionCreate || m.change.Action == ActionReplace {
+			want = append(want, "new:"+m.change.Name)
+		} else {
+			want = append(want, m.change.ID.String())
+		}
+	}
+	for _, id := range step.kept {
+		want = append(want, id.String())
+	}
+
+	return slices.Equal(result, want)
+}
+
+// dataSourceChanges returns the declared settings that differ from the data
+// source's current ones.
+func dataSourceChanges(ds datasource.DataSource, spec DataSourceSpec) []string {
+	var fields []string
+	if spec.AllowedCIDRs != nil && !slices.Equal(ds.AllowedCIDRs, spec.AllowedCIDRs) {
+		fields = append(fields, "allowedCidrs")
+	}
+	if spec.RelaxedUniqueness != nil && ds.RelaxedUniqueness != *spec.RelaxedUniqueness {
+		fields = append(fields, "relaxedUniqueness")
+	}
+	return fields
+}
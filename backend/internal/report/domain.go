@@ -0,0 +1,166 @@
+package report
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// Limits on the size of a report.
+const (
+	MaxSections        = 50
+	MaxKPIGroupMetrics = 12
+	MaxSectionGoals    = 12
+)
+
+// Error definitions
+var (
+	ErrReportNotFound       = errors.New("report not found")
+	ErrSectionNotFound      = errors.New("section not found")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrReportNameEmpty      = errors.New("report name is required")
+	ErrReportNameTooLong    = errors.New("report name exceeds maximum length of 255 characters")
+	ErrTooManySections      = errors.New("report has reached the maximum of 50 sections")
+	ErrInvalidSectionType   = errors.New("section type must be heading, kpi_group, chart, text or goals")
+	ErrInvalidSectionWidth  = errors.New("section width must be full or half")
+	ErrSectionTitleTooLong  = errors.New("section title exceeds maximum length of 255 characters")
+	ErrHeadingTitleRequired = errors.New("heading sections require a title")
+	ErrTextBodyRequired     = errors.New("text sections require a body")
+	ErrKPIGroupMetrics      = errors.New("KPI groups require between 1 and 12 scalar metrics")
+	ErrChartMetric          = errors.New("chart sections require exactly one non-scalar metric")
+	ErrUnexpectedMetrics    = errors.New("only KPI group and chart sections reference metrics")
+	ErrGoalsSectionGoals    = errors.New("goals sections require between 1 and 12 goals")
+	ErrUnexpectedGoals      = errors.New("only goals sections reference goals")
+	ErrGoalNotFound         = errors.New("goal not found")
+	ErrInvalidColumns       = errors.New("columns must be between 1 and 4 and are only used by KPI groups")
+	ErrUnexpectedBody       = errors.New("only text sections have a body")
+	ErrMetricNotFound       = errors.New("metric not found")
+	ErrInvalidSectionOrder  = errors.New("sectionIds must list every section of the report exactly once")
+	ErrPreconditionFailed   = errors.New("report was modified since it was read")
+)
+
+// SectionType is the kind of content a section holds.
+type SectionType string
+
+const (
+	SectionTypeHeading  SectionType = "heading"   // A heading that starts a new part of the report
+	SectionTypeKPIGroup SectionType = "kpi_group" // A grid of scalar metrics
+	SectionTypeChart    SectionType = "chart"     // A single time series, geo or heatmap metric
+	SectionTypeText     SectionType = "text"      // Free text in Markdown
+	SectionTypeGoals    SectionType = "goals"     // Goals with their progress
+)
+
+// IsValid checks if the section type is valid.
+func (t SectionType) IsValid() bool {
+	switch t {
+	case SectionTypeHeading, SectionTypeKPIGroup, SectionTypeChart, SectionTypeText, SectionTypeGoals:
+		return true
+	}
+	return false
+}
+
+// SectionWidth is how much of the page width a section takes. Consecutive
+// half-width sections are laid out side by side.
+type SectionWidth string
+
+const (
+	SectionWidthFull SectionWidth = "full"
+	SectionWidthHalf SectionWidth = "half"
+)
+
+// IsValid checks if the section width is valid.
+func (w SectionWidth) IsValid() bool {
+	return w == SectionWidthFull || w == SectionWidthHalf
+}
+
+// Report is a document built from ordered sections, the source for rendered
+// PDF and email reports.
+type Report struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Name           string    `json:"name"`
+	Description    *string   `json:"description,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"` // Bumped by any change to the report or its sections
+}
+
+// Section is one block of a report. Which fields are set depends on Type:
+// headings have a Title, text sections a Body, KPI groups and charts reference
+// metrics and goals sections goals; these may have a Title shown above them.
+type Section struct {
+	ID        uuid.UUID    `json:"id"`
+	Type      SectionType  `json:"type"`
+	Position  int          `json:"position"`
+	Title     *string      `json:"title,omitempty"`
+	Body      *string      `json:"body,omitempty"`      // Markdown, text sections only
+	MetricIDs []uuid.UUID  `json:"metricIds,omitempty"` // Metrics deleted since are skipped when rendering
+	GoalIDs   []uuid.UUID  `json:"goalIds,omitempty"`   // Goals deleted since are skipped when rendering
+	Columns   *int         `json:"columns,omitempty"`   // KPI groups only; defaults to one column per metric
+	Width     SectionWidth `json:"width"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// TrashedReport is a deleted report that can still be restored.
+type TrashedReport struct {
+	ID        uuid.UUID
+	Name      string
+	DeletedAt time.Time
+}
+
+// Request/Response types
+
+// CreateReportRequest is the request body for creating a report. Sections
+// are optional and created in the given order.
+type CreateReportRequest struct {
+	Name        string           `json:"name" validate:"required,max=255"`
+	Description *string          `json:"description,omitempty"`
+	Sections    []SectionRequest `json:"sections,omitempty"`
+}
+
+// UpdateReportRequest is the request body for updating a report's details.
+type UpdateReportRequest struct {
+	Name        string  `json:"name" validate:"required,max=255"`
+	Description *string `json:"description,omitempty"`
+}
+
+// SectionRequest is the request body for creating or updating a section.
+type SectionRequest struct {
+	Type      SectionType  `json:"type" validate:"required,oneof=heading kpi_group chart text goals"`
+	Title     *string      `json:"title,omitempty" validate:"max=255"`
+	Body      *string      `json:"body,omitempty"`
+	MetricIDs []uuid.UUID  `json:"metricIds,omitempty"`
+	GoalIDs   []uuid.UUID  `json:"goalIds,omitempty"`
+	Columns   *int         `json:"columns,omitempty"`
+	Width     SectionWidth `json:"width,omitempty" validate:"omitempty,oneof=full half"` // Defaults to full
+}
+
+// ReorderSectionsRequest is the request body for reordering sections.
+type ReorderSectionsRequest struct {
+	SectionIDs []uuid.UUID `json:"sectionIds" validate:"required"`
+}
+
+// ReportWithSections is a report with its sections in order.
+type ReportWithSections struct {
+	Report   Report             `json:"report"`
+	Sections []Section          `json:"sections"`
+	History  []writeaudit.Entry `json:"history,omitempty"` // With includeHistory=true, newest first
+}
+
+// ListReportsResponse is the response for listing reports.
+type ListReportsResponse struct {
+	Reports []Report `json:"reports"`
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
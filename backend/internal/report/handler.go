@@ -0,0 +1,495 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/platform/precondition"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// validationErrors are the report errors caused by invalid input.
+var validationErrors = []error{
+	ErrReportNameEmpty,
+	ErrReportNameTooLong,
+	ErrTooManySections,
+	ErrInvalidSectionType,
+	ErrInvalidSectionWidth,
+	ErrSectionTitleTooLong,
+	ErrHeadingTitleRequired,
+	ErrTextBodyRequired,
+	ErrKPIGroupMetrics,
+	ErrChartMetric,
+	ErrUnexpectedMetrics,
+	ErrGoalsSectionGoals,
+	ErrUnexpectedGoals,
+	ErrGoalNotFound,
+	ErrInvalidColumns,
+	ErrUnexpectedBody,
+	ErrMetricNotFound,
+	ErrInvalidSectionOrder,
+}
+
+// Handler handles HTTP requests for reports.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new report handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListReports handles listing all reports of the organization.
+//
+//	@Summary		List reports
+//	@Description	Get all reports of the authenticated user's organization, without their sections
+//	@Tags			reports
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	ListReportsResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/reports [get]
+func (h *Handler) ListReports(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reports, err := h.service.ListReports(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("list reports error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list reports")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListReportsResponse{Reports: reports})
+}
+
+// GetReport handles getting a report with its sections.
+//
+//	@Summary		Get report
+//	@Description	Get a report with its sections in order
+//	@Tags			reports
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		string	true	"Report ID"
+//	@Param			includeHistory	query		bool	false	"Include the write history of the report and its sections"
+//	@Success		200				{object}	ReportWithSections
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		403				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/reports/{id} [get]
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reportID, ok := parseID(w, r, "id", "invalid report ID")
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetReport(r.Context(), user.OrganizationID, reportID)
+	if err != nil {
+		respondServiceError(w, err, "get report", "failed to get report")
+		return
+	}
+
+	if writeaudit.IncludeHistory(r) {
+		result.History, err = h.service.History(r.Context(), user.OrganizationID, reportID)
+		if err != nil {
+			log.Printf("get report history error: %v", err)
+			respondError(w, http.StatusInternalServerError, "failed to get report history")
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// CreateReport handles creating a new report.
+//
+//	@Summary		Create report
+//	@Description	Create a report, optionally with its sections. Sections are headings, KPI groups of 1-12 scalar metrics, charts of one non-scalar metric, Markdown text, or lists of 1-12 goals, laid out at full or half width. Requires editor or admin role.
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateReportRequest	true	"Report data"
+//	@Success		201		{object}	ReportWithSections
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/reports [post]
+func (h *Handler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateReportRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	result, err := h.service.CreateReport(r.Context(), user.OrganizationID, req)
+	if err != nil {
+		respondServiceError(w, err, "create report", "failed to create report")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, result)
+}
+
+// UpdateReport handles updating a report's details.
+//
+//	@Summary		Update report
+//	@Description	Update a report's name and description. Send the updatedAt value last read as If-Match to fail with 412 instead of overwriting someone else's change. Requires editor or admin role.
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string				true	"Report ID"
+//	@Param			If-Match	header		string				false	"updatedAt of the report as last read"
+//	@Param			request		body		UpdateReportRequest	true	"Report data"
+//	@Success		200			{object}	Report
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/reports/{id} [put]
+func (h *Handler) UpdateReport(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reportID, ok := parseID(w, r, "id", "invalid report ID")
+	if !ok {
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req UpdateReportRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	report, err := h.service.UpdateReport(r.Context(), user.OrganizationID, reportID, req, ifUnmodified)
+	if err != nil {
+		respondServiceError(w, err, "update report", "failed to update report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// DeleteReport handles deleting a report.
+//
+//	@Summary		Delete report
+//	@Description	Move a report to the trash, where it can be restored with its sections for 30 days. Requires editor or admin role.
+//	@Tags			reports
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Report ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/reports/{id} [delete]
+func (h *Handler) DeleteReport(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reportID, ok := parseID(w, r, "id", "invalid report ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteReport(r.Context(), user.OrganizationID, reportID); err != nil {
+		respondServiceError(w, err, "delete report", "failed to delete report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "report deleted"})
+}
+
+// CreateSection handles appending a section to a report.
+//
+//	@Summary		Add report section
+//	@Description	Append a section to a report. Send the report's updatedAt as If-Match to fail with 412 if someone else changed it; section changes bump it. Requires editor or admin role.
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string			true	"Report ID"
+//	@Param			If-Match	header		string			false	"updatedAt of the report as last read"
+//	@Param			request		body		SectionRequest	true	"Section data"
+//	@Success		201			{object}	Section
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/reports/{id}/sections [post]
+func (h *Handler) CreateSection(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reportID, ok := parseID(w, r, "id", "invalid report ID")
+	if !ok {
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req SectionRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	section, err := h.service.CreateSection(r.Context(), user.OrganizationID, reportID, req, ifUnmodified)
+	if err != nil {
+		respondServiceError(w, err, "create report section", "failed to create section")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, section)
+}
+
+// UpdateSection handles replacing the content of a report section.
+//
+//	@Summary		Update report section
+//	@Description	Replace the content of a report section, keeping its position. Send the report's updatedAt as If-Match to fail with 412 if someone else changed it. Requires editor or admin role.
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string			true	"Report ID"
+//	@Param			sectionId	path		string			true	"Section ID"
+//	@Param			If-Match	header		string			false	"updatedAt of the report as last read"
+//	@Param			request		body		SectionRequest	true	"Section data"
+//	@Success		200			{object}	Section
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/reports/{id}/sections/{sectionId} [put]
+func (h *Handler) UpdateSection(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reportID, ok := parseID(w, r, "id", "invalid report ID")
+	if !ok {
+		return
+	}
+	sectionID, ok := parseID(w, r, "sectionId", "invalid section ID")
+	if !ok {
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req SectionRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	section, err := h.service.UpdateSection(r.Context(), user.OrganizationID, reportID, sectionID, req, ifUnmodified)
+	if err != nil {
+		respondServiceError(w, err, "update report section", "failed to update section")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, section)
+}
+
+// DeleteSection handles removing a section from a report.
+//
+//	@Summary		Delete report section
+//	@Description	Remove a section from a report. Send the report's updatedAt as If-Match to fail with 412 if someone else changed it. Requires editor or admin role.
+//	@Tags			reports
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Report ID"
+//	@Param			sectionId	path		string	true	"Section ID"
+//	@Param			If-Match	header		string	false	"updatedAt of the report as last read"
+//	@Success		200			{object}	MessageResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/reports/{id}/sections/{sectionId} [delete]
+func (h *Handler) DeleteSection(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reportID, ok := parseID(w, r, "id", "invalid report ID")
+	if !ok {
+		return
+	}
+	sectionID, ok := parseID(w, r, "sectionId", "invalid section ID")
+	if !ok {
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.DeleteSection(r.Context(), user.OrganizationID, reportID, sectionID, ifUnmodified); err != nil {
+		respondServiceError(w, err, "delete report section", "failed to delete section")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "section deleted"})
+}
+
+// ReorderSections handles reordering the sections of a report.
+//
+//	@Summary		Reorder report sections
+//	@Description	Reorder the sections of a report. sectionIds must list every section of the report. Send the report's updatedAt as If-Match to fail with 412 if someone else changed it; reordering bumps it. Requires editor or admin role.
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string					true	"Report ID"
+//	@Param			If-Match	header		string					false	"updatedAt of the report as last read"
+//	@Param			request		body		ReorderSectionsRequest	true	"Section order"
+//	@Success		200			{object}	MessageResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		412			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/reports/{id}/sections/reorder [put]
+func (h *Handler) ReorderSections(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	reportID, ok := parseID(w, r, "id", "invalid report ID")
+	if !ok {
+		return
+	}
+
+	ifUnmodified, err := precondition.IfMatch(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req ReorderSectionsRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	if err := h.service.ReorderSections(r.Context(), user.OrganizationID, reportID, req.SectionIDs, ifUnmodified); err != nil {
+		respondServiceError(w, err, "reorder report sections", "failed to reorder sections")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "sections reordered"})
+}
+
+func parseID(w http.ResponseWriter, r *http.Request, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, message)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondServiceError writes the response for an error from the report
+// service, logging unexpected errors under op.
+func respondServiceError(w http.ResponseWriter, err error, op, message string) {
+	switch {
+	case errors.Is(err, ErrReportNotFound), errors.Is(err, ErrSectionNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "unauthorized")
+	case errors.Is(err, ErrPreconditionFailed):
+		respondError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		for _, target := range validationErrors {
+			if errors.Is(err, target) {
+				respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		log.Printf("%s error: %v", op, err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
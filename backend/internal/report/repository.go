@@ -0,0 +1,444 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for reports.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new report repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// CreateReport creates a report together with its initial sections, which
+// are positioned in slice order.
+func (r *Repository) CreateReport(ctx context.Context, report *Report, sections []Section) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	report.ID = uuid.New()
+	report.CreatedAt = now
+	report.UpdatedAt = now
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO reports (id, organization_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		report.ID, report.OrganizationID, report.Name, report.Description, report.CreatedAt, report.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range sections {
+		sections[i].Position = i
+		if err := insertSection(ctx, tx, report.ID, &sections[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetReportByID retrieves a report by its ID.
+func (r *Repository) GetReportByID(ctx context.Context, id uuid.UUID) (*Report, error) {
+	report := &Report{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, organization_id, name, description, created_at, updated_at
+		FROM reports WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	).Scan(&report.ID, &report.OrganizationID, &report.Name, &report.Description, &report.CreatedAt, &report.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetReportsByOrganizationID retrieves all reports for an organization.
+func (r *Repository) GetReportsByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]Report, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, organization_id, name, description, created_at, updated_at
+		FROM reports WHERE organization_id = $1 AND deleted_at IS NULL
+		ORDER BY name ASC, created_at ASC`,
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []Report
+	for rows.Next() {
+		var rep Report
+		if err := rows.Scan(&rep.ID, &rep.OrganizationID, &rep.Name, &rep.Description, &rep.CreatedAt, &rep.UpdatedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+
+	return reports, rows.Err()
+}
+
+// UpdateReport updates a report's details and returns its new update time.
+// If ifUnmodified is set, the update only applies while the report's update
+// time still equals it; otherwise nil is returned.
+func (r *Repository) UpdateReport(ctx context.Context, id uuid.UUID, name string, description *string, ifUnmodified *time.Time) (*time.Time, error) {
+	var updatedAt time.Time
+	err := r.pool.QueryRow(ctx,
+		`UPDATE reports SET name = $1, description = $2, updated_at = NOW()
+		WHERE id = $3 AND deleted_at IS NULL AND ($4::timestamptz IS NULL OR updated_at = $4)
+		RETURNING updated_at`,
+		name, description, id, ifUnmodified,
+	).Scan(&updatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedAt, nil
+}
+
+// DeleteReport moves a report to the trash. Its sections are kept so that
+// restoring it brings them back.
+func (r *Repository) DeleteReport(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE reports SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	)
+	return err
+}
+
+// GetDeletedReports retrieves the reports of an organization that are in the trash.
+func (r *Repository) GetDeletedReports(ctx context.Context, orgID uuid.UUID) ([]TrashedReport, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, name, deleted_at
+		FROM reports WHERE organization_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`,
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []TrashedReport
+	for rows.Next() {
+		var rep TrashedReport
+		if err := rows.Scan(&rep.ID, &rep.Name, &rep.DeletedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+
+	return reports, rows.Err()
+}
+
+// RestoreReport takes a report out of the trash. It reports whether a
+// trashed report of the organization was found.
+func (r *Repository) RestoreReport(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE reports SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NOT NULL`,
+		id, orgID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// PurgeDeletedReports permanently deletes reports trashed before the given
+// time, along with their sections, and returns the organization IDs of the
+// deleted reports by ID.
+func (r *Repository) PurgeDeletedReports(ctx context.Context, before time.Time) (map[uuid.UUID]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx,
+		`DELETE FROM reports WHERE deleted_at < $1 RETURNING id, organization_id`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purged := make(map[uuid.UUID]uuid.UUID)
+	for rows.Next() {
+		var id, orgID uuid.UUID
+		if err := rows.Scan(&id, &orgID); err != nil {
+			return nil, err
+		}
+		purged[id] = orgID
+	}
+	return purged, rows.Err()
+}
+
+// GetSections retrieves the sections of a report in order.
+func (r *Repository) GetSections(ctx context.Context, reportID uuid.UUID) ([]Section, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, type, position, title, body, metric_ids, goal_ids, columns, width, created_at, updated_at
+		FROM report_sections WHERE report_id = $1
+		ORDER BY position ASC, created_at ASC`,
+		reportID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sections []Section
+	for rows.Next() {
+		var s Section
+		var metricIDsJSON, goalIDsJSON []byte
+		if err := rows.Scan(&s.ID, &s.Type, &s.Position, &s.Title, &s.Body, &metricIDsJSON, &goalIDsJSON, &s.Columns, &s.Width, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(metricIDsJSON, &s.MetricIDs); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(goalIDsJSON, &s.GoalIDs); err != nil {
+			return nil, err
+		}
+		sections = append(sections, s)
+	}
+
+	return sections, rows.Err()
+}
+
+// CountSections returns the number of sections of a report.
+func (r *Repository) CountSections(ctx context.Context, reportID uuid.UUID) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM report_sections WHERE report_id = $1`,
+		reportID,
+	).Scan(&count)
+	return count, err
+}
+
+// CreateSection appends a section to a report. Like all section changes, it
+// bumps the report's update time and fails with ErrPreconditionFailed if
+// ifUnmodified is set and no longer matches it.
+func (r *Repository) CreateSection(ctx context.Context, reportID uuid.UUID, section *Section, ifUnmodified *time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := touchReport(ctx, tx, reportID, ifUnmodified); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx,
+		`SELECT COALESCE(MAX(position) + 1, 0) FROM report_sections WHERE report_id = $1`,
+		reportID,
+	).Scan(&section.Position)
+	if err != nil {
+		return err
+	}
+
+	if err := insertSection(ctx, tx, reportID, section); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateSection replaces a section's content. It reports whether the section
+// was found on the report.
+func (r *Repository) UpdateSection(ctx context.Context, reportID uuid.UUID, section *Section, ifUnmodified *time.Time) (bool, error) {
+	metricIDsJSON, goalIDsJSON, err := marshalIDs(section)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := touchReport(ctx, tx, reportID, ifUnmodified); err != nil {
+		return false, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`UPDATE report_sections
+		SET type = $1, title = $2, body = $3, metric_ids = $4, goal_ids = $5, columns = $6, width = $7, updated_at = NOW()
+		WHERE id = $8 AND report_id = $9
+		RETURNING position, created_at, updated_at`,
+		section.Type, section.Title, section.Body, metricIDsJSON, goalIDsJSON, section.Columns, section.Width, section.ID, reportID,
+	).Scan(&section.Position, &section.CreatedAt, &section.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// DeleteSection deletes a section. It reports whether the section was found
+// on the report.
+func (r *Repository) DeleteSection(ctx context.Context, reportID, sectionID uuid.UUID, ifUnmodified *time.Time) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := touchReport(ctx, tx, reportID, ifUnmodified); err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM report_sections WHERE id = $1 AND report_id = $2`,
+		sectionID, reportID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// UpdateSectionPositions updates the positions of all sections of a report.
+// sectionIDs must list every section exactly once, or ErrInvalidSectionOrder
+// is returned. The report's update time is bumped; if ifUnmodified is set
+// and no longer matches it, ErrPreconditionFailed is returned. Both checks
+// run under the report's row lock, like metric reordering on dashboards.
+func (r *Repository) UpdateSectionPositions(ctx context.Context, reportID uuid.UUID, sectionIDs []uuid.UUID, ifUnmodified *time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := touchReport(ctx, tx, reportID, ifUnmodified); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT id FROM report_sections WHERE report_id = $1`,
+		reportID,
+	)
+	if err != nil {
+		return err
+	}
+	current := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		current[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(sectionIDs) != len(current) {
+		return ErrInvalidSectionOrder
+	}
+	for _, id := range sectionIDs {
+		if !current[id] {
+			return ErrInvalidSectionOrder
+		}
+		delete(current, id) // Catches duplicates
+	}
+
+	for i, id := range sectionIDs {
+		_, err := tx.Exec(ctx,
+			`UPDATE report_sections SET position = $1, updated_at = NOW() WHERE id = $2 AND report_id = $3`,
+			i, id, reportID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// touchReport bumps a report's update time, locking its row for the rest of
+// the transaction. If ifUnmodified is set and no longer matches the update
+// time, ErrPreconditionFailed is returned.
+func touchReport(ctx context.Context, tx pgx.Tx, reportID uuid.UUID, ifUnmodified *time.Time) error {
+	tag, err := tx.Exec(ctx,
+		`UPDATE reports SET updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND ($2::timestamptz IS NULL OR updated_at = $2)`,
+		reportID, ifUnmodified,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// insertSection inserts a section at its Position, filling in its ID and times.
+func insertSection(ctx context.Context, tx pgx.Tx, reportID uuid.UUID, section *Section) error {
+	metricIDsJSON, goalIDsJSON, err := marshalIDs(section)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	section.ID = uuid.New()
+	section.CreatedAt = now
+	section.UpdatedAt = now
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO report_sections (id, report_id, position, type, title, body, metric_ids, goal_ids, columns, width, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		section.ID, reportID, section.Position, section.Type, section.Title, section.Body, metricIDsJSON, goalIDsJSON,
+		section.Columns, section.Width, section.CreatedAt, section.UpdatedAt,
+	)
+	return err
+}
+
+// marshalIDs encodes the metric and goal IDs of a section as JSON arrays.
+func marshalIDs(section *Section) (metricIDsJSON, goalIDsJSON []byte, err error) {
+	if metricIDsJSON, err = json.Marshal(idsOrEmpty(section.MetricIDs)); err != nil {
+		return nil, nil, err
+	}
+	if goalIDsJSON, err = json.Marshal(idsOrEmpty(section.GoalIDs)); err != nil {
+		return nil, nil, err
+	}
+	return metricIDsJSON, goalIDsJSON, nil
+}
+
+func idsOrEmpty(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
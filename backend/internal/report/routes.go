@@ -0,0 +1,28 @@
+package report
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers all report routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/reports", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// Read operations (all authenticated users)
+		r.Get("/", h.ListReports)
+		r.Get("/{id}", h.GetReport)
+
+		// Write operations (editor and admin only)
+		r.Post("/", h.CreateReport)
+		r.Put("/{id}", h.UpdateReport)
+		r.Delete("/{id}", h.DeleteReport)
+
+		r.Post("/{id}/sections", h.CreateSection)
+		r.Put("/{id}/sections/reorder", h.ReorderSections)
+		r.Put("/{id}/sections/{sectionId}", h.UpdateSection)
+		r.Delete("/{id}/sections/{sectionId}", h.DeleteSection)
+	})
+}
@@ -0,0 +1,431 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/goal"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// Service handles report business logic.
+type Service struct {
+	repo             *Repository
+	metricService    *metric.Service
+	dashboardService *dashboard.Service
+	goalService      *goal.Service
+	audit            *writeaudit.Service
+}
+
+// NewService creates a new report service.
+func NewService(repo *Repository, metricService *metric.Service, dashboardService *dashboard.Service, goalService *goal.Service, audit *writeaudit.Service) *Service {
+	return &Service{
+		repo:             repo,
+		metricService:    metricService,
+		dashboardService: dashboardService,
+		goalService:      goalService,
+		audit:            audit,
+	}
+}
+
+// CreateReport creates a report with its initial sections.
+func (s *Service) CreateReport(ctx context.Context, orgID uuid.UUID, req CreateReportRequest) (*ReportWithSections, error) {
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Sections) > MaxSections {
+		return nil, ErrTooManySections
+	}
+
+	sections := make([]Section, 0, len(req.Sections))
+	for _, sr := range req.Sections {
+		section, err := s.validateSection(ctx, orgID, sr)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, *section)
+	}
+
+	report := &Report{
+		OrganizationID: orgID,
+		Name:           name,
+		Description:    req.Description,
+	}
+	if err := s.repo.CreateReport(ctx, report, sections); err != nil {
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	created := &ReportWithSections{Report: *report, Sections: sections}
+	s.audit.Record(ctx, writeaudit.EntityReport, report.ID, writeaudit.ActionCreate, nil, created)
+	return created, nil
+}
+
+// ListReports returns all reports of an organization.
+func (s *Service) ListReports(ctx context.Context, orgID uuid.UUID) ([]Report, error) {
+	reports, err := s.repo.GetReportsByOrganizationID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	if reports == nil {
+		reports = []Report{}
+	}
+	return reports, nil
+}
+
+// GetReport returns a report with its sections after verifying organization ownership.
+func (s *Service) GetReport(ctx context.Context, orgID, reportID uuid.UUID) (*ReportWithSections, error) {
+	report, err := s.getOwnedReport(ctx, orgID, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := s.repo.GetSections(ctx, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report sections: %w", err)
+	}
+	if sections == nil {
+		sections = []Section{}
+	}
+
+	return &ReportWithSections{Report: *report, Sections: sections}, nil
+}
+
+// UpdateReport updates a report's name and description. If ifUnmodified is
+// set, the update fails with ErrPreconditionFailed unless the report was last
+// updated at that time.
+func (s *Service) UpdateReport(ctx context.Context, orgID, reportID uuid.UUID, req UpdateReportRequest, ifUnmodified *time.Time) (*Report, error) {
+	report, err := s.getOwnedReport(ctx, orgID, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt, err := s.repo.UpdateReport(ctx, reportID, name, req.Description, ifUnmodified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update report: %w", err)
+	}
+	if updatedAt == nil {
+		return nil, ErrPreconditionFailed
+	}
+
+	before := *report
+	report.Name = name
+	report.Description = req.Description
+	report.UpdatedAt = *updatedAt
+	s.audit.Record(ctx, writeaudit.EntityReport, reportID, writeaudit.ActionUpdate, before, report)
+	return report, nil
+}
+
+// DeleteReport moves a report to the trash.
+func (s *Service) DeleteReport(ctx context.Context, orgID, reportID uuid.UUID) error {
+	report, err := s.getOwnedReport(ctx, orgID, reportID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteReport(ctx, reportID); err != nil {
+		return fmt.Errorf("failed to delete report: %w", err)
+	}
+	s.audit.Record(ctx, writeaudit.EntityReport, reportID, writeaudit.ActionDelete, report, nil)
+	return nil
+}
+
+// ListDeletedReports returns the reports of an organization that are in the trash.
+func (s *Service) ListDeletedReports(ctx context.Context, orgID uuid.UUID) ([]TrashedReport, error) {
+	reports, err := s.repo.GetDeletedReports(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted reports: %w", err)
+	}
+	if reports == nil {
+		reports = []TrashedReport{}
+	}
+	return reports, nil
+}
+
+// RestoreReport takes a report out of the trash with its sections.
+func (s *Service) RestoreReport(ctx context.Context, orgID, reportID uuid.UUID) error {
+	restored, err := s.repo.RestoreReport(ctx, orgID, reportID)
+	if err != nil {
+		return fmt.Errorf("failed to restore report: %w", err)
+	}
+	if !restored {
+		return ErrReportNotFound
+	}
+	s.audit.Record(ctx, writeaudit.EntityReport, reportID, writeaudit.ActionRestore, nil, nil)
+	return nil
+}
+
+// PurgeDeletedReports permanently deletes reports trashed before the given time.
+func (s *Service) PurgeDeletedReports(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.repo.PurgeDeletedReports(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted reports: %w", err)
+	}
+	for id, orgID := range purged {
+		s.audit.RecordPurge(ctx, orgID, writeaudit.EntityReport, id)
+	}
+	return int64(len(purged)), nil
+}
+
+// CreateSection appends a section to a report. If ifUnmodified is set, the
+// change fails with ErrPreconditionFailed unless the report was last updated
+// at that time; this holds for all section changes.
+func (s *Service) CreateSection(ctx context.Context, orgID, reportID uuid.UUID, req SectionRequest, ifUnmodified *time.Time) (*Section, error) {
+	if _, err := s.getOwnedReport(ctx, orgID, reportID); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountSections(ctx, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count report sections: %w", err)
+	}
+	if count >= MaxSections {
+		return nil, ErrTooManySections
+	}
+
+	section, err := s.validateSection(ctx, orgID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateSection(ctx, reportID, section, ifUnmodified)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report section: %w", err)
+	}
+
+	s.audit.RecordPart(ctx, writeaudit.EntityReport, reportID, "section", nil, section)
+	return section, nil
+}
+
+// UpdateSection replaces the content of a section, keeping its position.
+func (s *Service) UpdateSection(ctx context.Context, orgID, reportID, sectionID uuid.UUID, req SectionRequest, ifUnmodified *time.Time) (*Section, error) {
+	if _, err := s.getOwnedReport(ctx, orgID, reportID); err != nil {
+		return nil, err
+	}
+
+	section, err := s.validateSection(ctx, orgID, req)
+	if err != nil {
+		return nil, err
+	}
+	section.ID = sectionID
+
+	found, err := s.repo.UpdateSection(ctx, reportID, section, ifUnmodified)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update report section: %w", err)
+	}
+	if !found {
+		return nil, ErrSectionNotFound
+	}
+
+	s.audit.RecordPart(ctx, writeaudit.EntityReport, reportID, "section", nil, section)
+	return section, nil
+}
+
+// DeleteSection removes a section from a report.
+func (s *Service) DeleteSection(ctx context.Context, orgID, reportID, sectionID uuid.UUID, ifUnmodified *time.Time) error {
+	if _, err := s.getOwnedReport(ctx, orgID, reportID); err != nil {
+		return err
+	}
+
+	found, err := s.repo.DeleteSection(ctx, reportID, sectionID, ifUnmodified)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete report section: %w", err)
+	}
+	if !found {
+		return ErrSectionNotFound
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityReport, reportID, "section", sectionID, nil)
+	return nil
+}
+
+// ReorderSections reorders the sections of a report. sectionIDs must list
+// every section of the report.
+func (s *Service) ReorderSections(ctx context.Context, orgID, reportID uuid.UUID, sectionIDs []uuid.UUID, ifUnmodified *time.Time) error {
+	if _, err := s.getOwnedReport(ctx, orgID, reportID); err != nil {
+		return err
+	}
+
+	err := s.repo.UpdateSectionPositions(ctx, reportID, sectionIDs, ifUnmodified)
+	if errors.Is(err, ErrPreconditionFailed) || errors.Is(err, ErrInvalidSectionOrder) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reorder report sections: %w", err)
+	}
+	s.audit.RecordPart(ctx, writeaudit.EntityReport, reportID, "sectionOrder", nil, sectionIDs)
+	return nil
+}
+
+// History returns the most recent writes to a report of an organization,
+// including changes to its sections.
+func (s *Service) History(ctx context.Context, orgID, reportID uuid.UUID) ([]writeaudit.Entry, error) {
+	return s.audit.History(ctx, orgID, writeaudit.EntityReport, reportID)
+}
+
+func (s *Service) getOwnedReport(ctx context.Context, orgID, reportID uuid.UUID) (*Report, error) {
+	report, err := s.repo.GetReportByID(ctx, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	if report.OrganizationID != orgID {
+		return nil, ErrUnauthorized
+	}
+	return report, nil
+}
+
+// validateSection checks a section request against the rules of its type and
+// returns the section to store. Referenced metrics must be on a dashboard of
+// the organization.
+func (s *Service) validateSection(ctx context.Context, orgID uuid.UUID, req SectionRequest) (*Section, error) {
+	if !req.Type.IsValid() {
+		return nil, ErrInvalidSectionType
+	}
+
+	width := req.Width
+	if width == "" {
+		width = SectionWidthFull
+	}
+	if !width.IsValid() {
+		return nil, ErrInvalidSectionWidth
+	}
+
+	title := trimmedOrNil(req.Title)
+	if title != nil && len(*title) > 255 {
+		return nil, ErrSectionTitleTooLong
+	}
+	body := trimmedOrNil(req.Body)
+
+	switch req.Type {
+	case SectionTypeHeading:
+		if title == nil {
+			return nil, ErrHeadingTitleRequired
+		}
+	case SectionTypeText:
+		if body == nil {
+			return nil, ErrTextBodyRequired
+		}
+	case SectionTypeKPIGroup:
+		if len(req.MetricIDs) == 0 || len(req.MetricIDs) > MaxKPIGroupMetrics {
+			return nil, ErrKPIGroupMetrics
+		}
+	case SectionTypeChart:
+		if len(req.MetricIDs) != 1 {
+			return nil, ErrChartMetric
+		}
+	case SectionTypeGoals:
+		if len(req.GoalIDs) == 0 || len(req.GoalIDs) > MaxSectionGoals {
+			return nil, ErrGoalsSectionGoals
+		}
+	}
+
+	if body != nil && req.Type != SectionTypeText {
+		return nil, ErrUnexpectedBody
+	}
+	if len(req.MetricIDs) > 0 && req.Type != SectionTypeKPIGroup && req.Type != SectionTypeChart {
+		return nil, ErrUnexpectedMetrics
+	}
+	if len(req.GoalIDs) > 0 && req.Type != SectionTypeGoals {
+		return nil, ErrUnexpectedGoals
+	}
+	if req.Columns != nil && (req.Type != SectionTypeKPIGroup || *req.Columns < 1 || *req.Columns > 4) {
+		return nil, ErrInvalidColumns
+	}
+
+	for _, id := range req.MetricIDs {
+		m, err := s.getOrgMetric(ctx, orgID, id)
+		if err != nil {
+			return nil, err
+		}
+		isScalar := m.DisplayMode == metric.DisplayModeScalar
+		if req.Type == SectionTypeKPIGroup && !isScalar {
+			return nil, ErrKPIGroupMetrics
+		}
+		if req.Type == SectionTypeChart && isScalar {
+			return nil, ErrChartMetric
+		}
+	}
+
+	for _, id := range req.GoalIDs {
+		if err := s.goalService.VerifyGoalOwnership(ctx, orgID, id); err != nil {
+			if errors.Is(err, goal.ErrGoalNotFound) {
+				return nil, ErrGoalNotFound
+			}
+			return nil, err
+		}
+	}
+
+	return &Section{
+		Type:      req.Type,
+		Title:     title,
+		Body:      body,
+		MetricIDs: req.MetricIDs,
+		GoalIDs:   req.GoalIDs,
+		Columns:   req.Columns,
+		Width:     width,
+	}, nil
+}
+
+// getOrgMetric returns a metric on one of the organization's dashboards.
+func (s *Service) getOrgMetric(ctx context.Context, orgID, metricID uuid.UUID) (*metric.Metric, error) {
+	m, err := s.metricService.GetByID(ctx, metricID)
+	if errors.Is(err, metric.ErrMetricNotFound) {
+		return nil, ErrMetricNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.dashboardService.VerifyDashboardOwnership(ctx, orgID, m.DashboardID)
+	if errors.Is(err, dashboard.ErrDashboardNotFound) || errors.Is(err, dashboard.ErrUnauthorized) {
+		return nil, ErrMetricNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrReportNameEmpty
+	}
+	if len(name) > 255 {
+		return "", ErrReportNameTooLong
+	}
+	return name, nil
+}
+
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
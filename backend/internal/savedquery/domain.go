@@ -0,0 +1,74 @@
+package savedquery
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/metric"
+)
+
+var (
+	ErrSavedQueryNotFound = errors.New("saved query not found")
+	ErrForbidden          = errors.New("only the owner or an admin can modify this saved query")
+	ErrNameEmpty          = errors.New("name is required")
+	ErrNameTooLong        = errors.New("name exceeds maximum length of 255 characters")
+	ErrDashboardNotFound  = errors.New("dashboard not found")
+)
+
+// SavedQuery is an explorer query saved for later, separate from dashboard metrics.
+type SavedQuery struct {
+	ID             uuid.UUID           `json:"id"`
+	OrganizationID uuid.UUID           `json:"organizationId"`
+	UserID         uuid.UUID           `json:"userId"` // Owner
+	Name           string              `json:"name"`
+	Description    *string             `json:"description,omitempty"`
+	Query          metric.ExploreQuery `json:"query"`
+	Shared         bool                `json:"shared"` // Visible to everyone in the organization
+	CreatedAt      time.Time           `json:"createdAt"`
+	UpdatedAt      time.Time           `json:"updatedAt"`
+}
+
+// CreateSavedQueryRequest is the request body for saving a query.
+type CreateSavedQueryRequest struct {
+	Name        string              `json:"name" validate:"required,max=255"`
+	Description *string             `json:"description,omitempty"`
+	Query       metric.ExploreQuery `json:"query"`
+	Shared      bool                `json:"shared"`
+}
+
+// UpdateSavedQueryRequest is the request body for updating a saved query.
+type UpdateSavedQueryRequest struct {
+	Name        string              `json:"name" validate:"required,max=255"`
+	Description *string             `json:"description,omitempty"`
+	Query       metric.ExploreQuery `json:"query"`
+	Shared      bool                `json:"shared"`
+}
+
+// PromoteSavedQueryRequest is the request body for adding a saved query to a
+// dashboard as a metric.
+type PromoteSavedQueryRequest struct {
+	DashboardID uuid.UUID `json:"dashboardId" validate:"required"`
+	Label       string    `json:"label,omitempty" validate:"max=255"` // Defaults to the saved query's name
+
+	// Display options not part of the query
+	ChartType             *metric.ChartType             `json:"chartType,omitempty"` // Defaults to line for time_series
+	Stacking              *metric.Stacking              `json:"stacking,omitempty"`
+	ComparisonDisplayType *metric.ComparisonDisplayType `json:"comparisonDisplayType,omitempty"`
+}
+
+// ListSavedQueriesResponse is the response for listing saved queries.
+type ListSavedQueriesResponse struct {
+	SavedQueries []SavedQuery `json:"savedQueries"`
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
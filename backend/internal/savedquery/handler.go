@@ -0,0 +1,338 @@
+package savedquery
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/platform/httperr"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// errorResponses are the responses to the errors of the saved query
+// handlers.
+var errorResponses = slices.Concat(httperr.Registry{
+	{Err: ErrSavedQueryNotFound, Status: http.StatusNotFound, Message: "saved query not found"},
+	{Err: ErrForbidden, Status: http.StatusForbidden},
+	{Err: ErrNameEmpty, Status: http.StatusBadRequest},
+	{Err: ErrNameTooLong, Status: http.StatusBadRequest},
+}, metric.QueryErrors)
+
+// promoteErrors are the responses to the errors of promoting a saved query,
+// which creates a metric.
+var promoteErrors = slices.Concat(httperr.Registry{
+	{Err: ErrDashboardNotFound, Status: http.StatusNotFound},
+}, errorResponses, metric.ConfigErrors)
+
+// Handler handles HTTP requests for saved queries.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new saved query handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListSavedQueries handles listing saved queries.
+//
+//	@Summary		List saved queries
+//	@Description	Get the user's own saved queries and the queries shared within the organization
+//	@Tags			saved-queries
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	ListSavedQueriesResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/saved-queries [get]
+func (h *Handler) ListSavedQueries(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	queries, err := h.service.List(r.Context(), user)
+	if err != nil {
+		log.Printf("list saved queries error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list saved queries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListSavedQueriesResponse{SavedQueries: queries})
+}
+
+// CreateSavedQuery handles saving an explorer query.
+//
+//	@Summary		Create saved query
+//	@Description	Save an explorer query for later. Set shared to make it visible to the whole organization.
+//	@Tags			saved-queries
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateSavedQueryRequest	true	"Saved query"
+//	@Success		201		{object}	SavedQuery
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/saved-queries [post]
+func (h *Handler) CreateSavedQuery(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateSavedQueryRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	sq, err := h.service.Create(r.Context(), user, req)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("create saved query error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to create saved query")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, sq)
+}
+
+// GetSavedQuery handles loading a saved query.
+//
+//	@Summary		Get saved query
+//	@Description	Get a saved query by ID to load it into the explorer
+//	@Tags			saved-queries
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Saved query ID"
+//	@Success		200	{object}	SavedQuery
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/saved-queries/{id} [get]
+func (h *Handler) GetSavedQuery(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid saved query ID")
+		return
+	}
+
+	sq, err := h.service.Get(r.Context(), user, id)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("get saved query error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get saved query")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sq)
+}
+
+// UpdateSavedQuery handles updating a saved query.
+//
+//	@Summary		Update saved query
+//	@Description	Update a saved query. Only the owner can update it; admins can also update shared queries.
+//	@Tags			saved-queries
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Saved query ID"
+//	@Param			request	body		UpdateSavedQueryRequest	true	"Saved query"
+//	@Success		200		{object}	SavedQuery
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/saved-queries/{id} [put]
+func (h *Handler) UpdateSavedQuery(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid saved query ID")
+		return
+	}
+
+	var req UpdateSavedQueryRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	sq, err := h.service.Update(r.Context(), user, id, req)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("update saved query error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to update saved query")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sq)
+}
+
+// DeleteSavedQuery handles deleting a saved query.
+//
+//	@Summary		Delete saved query
+//	@Description	Delete a saved query. Only the owner can delete it; admins can also delete shared queries.
+//	@Tags			saved-queries
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Saved query ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/saved-queries/{id} [delete]
+func (h *Handler) DeleteSavedQuery(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid saved query ID")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), user, id); err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("delete saved query error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to delete saved query")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "saved query deleted"})
+}
+
+// RunSavedQuery handles computing a saved query.
+//
+//	@Summary		Run saved query
+//	@Description	Compute a saved query in the explorer without modifying it
+//	@Tags			saved-queries
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Saved query ID"
+//	@Success		200	{object}	metric.ExploreResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/saved-queries/{id}/run [post]
+func (h *Handler) RunSavedQuery(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid saved query ID")
+		return
+	}
+
+	result, err := h.service.Run(r.Context(), user, id)
+	if err != nil {
+		if errorResponses.Respond(w, err) {
+			return
+		}
+		log.Printf("run saved query error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to run saved query")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, metric.ExploreResponse{Result: *result})
+}
+
+// PromoteSavedQuery handles adding a saved query to a dashboard as a metric.
+//
+//	@Summary		Promote saved query to metric
+//	@Description	Add a saved query to a dashboard as a new metric, keeping its filters and display settings. The label defaults to the saved query's name and the chart type of time series to line. Requires editor or admin role.
+//	@Tags			saved-queries
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string						true	"Saved query ID"
+//	@Param			request	body		PromoteSavedQueryRequest	true	"Target dashboard and display options"
+//	@Success		201		{object}	metric.Metric
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/saved-queries/{id}/promote [post]
+func (h *Handler) PromoteSavedQuery(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid saved query ID")
+		return
+	}
+
+	var req PromoteSavedQueryRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	m, err := h.service.Promote(r.Context(), user, id, req)
+	if err != nil {
+		if promoteErrors.Respond(w, err) {
+			return
+		}
+		log.Printf("promote saved query error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to promote saved query")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, m)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,123 @@
+package savedquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for saved queries.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new saved query repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Create creates a new saved query.
+func (r *Repository) Create(ctx context.Context, sq *SavedQuery) error {
+	queryJSON, err := json.Marshal(sq.Query)
+	if err != nil {
+		return err
+	}
+
+	sq.ID = uuid.New()
+	sq.CreatedAt = time.Now()
+	sq.UpdatedAt = sq.CreatedAt
+
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO saved_queries (id, organization_id, user_id, name, description, query, shared, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		sq.ID, sq.OrganizationID, sq.UserID, sq.Name, sq.Description, queryJSON, sq.Shared, sq.CreatedAt, sq.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a saved query by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*SavedQuery, error) {
+	sq := &SavedQuery{}
+	var queryJSON []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, organization_id, user_id, name, description, query, shared, created_at, updated_at
+		FROM saved_queries WHERE id = $1`,
+		id,
+	).Scan(&sq.ID, &sq.OrganizationID, &sq.UserID, &sq.Name, &sq.Description, &queryJSON, &sq.Shared, &sq.CreatedAt, &sq.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(queryJSON, &sq.Query); err != nil {
+		return nil, err
+	}
+
+	return sq, nil
+}
+
+// ListVisible retrieves the user's own saved queries and those shared in the organization.
+func (r *Repository) ListVisible(ctx context.Context, orgID, userID uuid.UUID) ([]SavedQuery, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, organization_id, user_id, name, description, query, shared, created_at, updated_at
+		FROM saved_queries
+		WHERE organization_id = $1 AND (user_id = $2 OR shared)
+		ORDER BY updated_at DESC`,
+		orgID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queries []SavedQuery
+	for rows.Next() {
+		var sq SavedQuery
+		var queryJSON []byte
+		if err := rows.Scan(&sq.ID, &sq.OrganizationID, &sq.UserID, &sq.Name, &sq.Description, &queryJSON, &sq.Shared, &sq.CreatedAt, &sq.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(queryJSON, &sq.Query); err != nil {
+			return nil, err
+		}
+		queries = append(queries, sq)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return queries, nil
+}
+
+// Update updates a saved query.
+func (r *Repository) Update(ctx context.Context, sq *SavedQuery) error {
+	queryJSON, err := json.Marshal(sq.Query)
+	if err != nil {
+		return err
+	}
+
+	return r.pool.QueryRow(ctx,
+		`UPDATE saved_queries SET name = $1, description = $2, query = $3, shared = $4
+		WHERE id = $5
+		RETURNING updated_at`,
+		sq.Name, sq.Description, queryJSON, sq.Shared, sq.ID,
+	).Scan(&sq.UpdatedAt)
+}
+
+// Delete deletes a saved query by its ID.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM saved_queries WHERE id = $1`,
+		id,
+	)
+	return err
+}
@@ -0,0 +1,21 @@
+package savedquery
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the saved query routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/saved-queries", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.ListSavedQueries)
+		r.Post("/", h.CreateSavedQuery)
+		r.Get("/{id}", h.GetSavedQuery)
+		r.Put("/{id}", h.UpdateSavedQuery)
+		r.Delete("/{id}", h.DeleteSavedQuery)
+		r.Post("/{id}/run", h.RunSavedQuery)
+		r.Post("/{id}/promote", h.PromoteSavedQuery)
+	})
+}
@@ -0,0 +1,192 @@
+package savedquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+// Service handles saved query business logic.
+type Service struct {
+	repo             *Repository
+	metricService    *metric.Service
+	dashboardService *dashboard.Service
+	audit            *writeaudit.Service
+}
+
+// NewService creates a new saved query service.
+func NewService(repo *Repository, metricService *metric.Service, dashboardService *dashboard.Service, audit *writeaudit.Service) *Service {
+	return &Service{
+		repo:             repo,
+		metricService:    metricService,
+		dashboardService: dashboardService,
+		audit:            audit,
+	}
+}
+
+// Create saves a query for the user.
+func (s *Service) Create(ctx context.Context, user *auth.User, req CreateSavedQueryRequest) (*SavedQuery, error) {
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.metricService.ValidateExploreQuery(ctx, user.OrganizationID, req.Query); err != nil {
+		return nil, err
+	}
+
+	sq := &SavedQuery{
+		OrganizationID: user.OrganizationID,
+		UserID:         user.ID,
+		Name:           name,
+		Description:    req.Description,
+		Query:          req.Query,
+		Shared:         req.Shared,
+	}
+	if err := s.repo.Create(ctx, sq); err != nil {
+		return nil, fmt.Errorf("failed to create saved query: %w", err)
+	}
+	s.audit.Record(ctx, writeaudit.EntitySavedQuery, sq.ID, writeaudit.ActionCreate, nil, sq)
+
+	return sq, nil
+}
+
+// List returns the user's saved queries and those shared in their organization.
+func (s *Service) List(ctx context.Context, user *auth.User) ([]SavedQuery, error) {
+	queries, err := s.repo.ListVisible(ctx, user.OrganizationID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved queries: %w", err)
+	}
+	if queries == nil {
+		queries = []SavedQuery{}
+	}
+	return queries, nil
+}
+
+// Get returns a saved query visible to the user.
+func (s *Service) Get(ctx context.Context, user *auth.User, id uuid.UUID) (*SavedQuery, error) {
+	sq, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved query: %w", err)
+	}
+	// Private queries of other users are reported as missing
+	if sq == nil || sq.OrganizationID != user.OrganizationID || (!sq.Shared && sq.UserID != user.ID) {
+		return nil, ErrSavedQueryNotFound
+	}
+	return sq, nil
+}
+
+// Update updates a saved query owned by the user. Admins may update shared queries.
+func (s *Service) Update(ctx context.Context, user *auth.User, id uuid.UUID, req UpdateSavedQueryRequest) (*SavedQuery, error) {
+	sq, err := s.getModifiable(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.metricService.ValidateExploreQuery(ctx, user.OrganizationID, req.Query); err != nil {
+		return nil, err
+	}
+
+	before := *sq
+	sq.Name = name
+	sq.Description = req.Description
+	sq.Query = req.Query
+	sq.Shared = req.Shared
+	if err := s.repo.Update(ctx, sq); err != nil {
+		return nil, fmt.Errorf("failed to update saved query: %w", err)
+	}
+	s.audit.Record(ctx, writeaudit.EntitySavedQuery, id, writeaudit.ActionUpdate, before, sq)
+
+	return sq, nil
+}
+
+// Delete deletes a saved query owned by the user. Admins may delete shared queries.
+func (s *Service) Delete(ctx context.Context, user *auth.User, id uuid.UUID) error {
+	sq, err := s.getModifiable(ctx, user, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete saved query: %w", err)
+	}
+	s.audit.Record(ctx, writeaudit.EntitySavedQuery, id, writeaudit.ActionDelete, sq, nil)
+	return nil
+}
+
+// Run computes a saved query in the explorer.
+func (s *Service) Run(ctx context.Context, user *auth.User, id uuid.UUID) (*metric.ComputedMetric, error) {
+	sq, err := s.Get(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.metricService.Explore(ctx, user.OrganizationID, sq.Query)
+}
+
+// Promote adds a saved query to a dashboard of the user's organization as a
+// new metric with the same query, filters and display settings. The saved
+// query itself is kept.
+func (s *Service) Promote(ctx context.Context, user *auth.User, id uuid.UUID, req PromoteSavedQueryRequest) (*metric.Metric, error) {
+	sq, err := s.Get(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.dashboardService.VerifyDashboardOwnership(ctx, user.OrganizationID, req.DashboardID); err != nil {
+		if errors.Is(err, dashboard.ErrDashboardNotFound) || errors.Is(err, dashboard.ErrUnauthorized) {
+			return nil, ErrDashboardNotFound
+		}
+		return nil, err
+	}
+
+	label := strings.TrimSpace(req.Label)
+	if label == "" {
+		label = sq.Name
+	}
+	chartType := req.ChartType
+	if chartType == nil && sq.Query.DisplayMode == metric.DisplayModeTimeSeries {
+		line := metric.ChartTypeLine
+		chartType = &line
+	}
+
+	return s.metricService.SaveExploration(ctx, user.OrganizationID, metric.SaveExplorationRequest{
+		DashboardID:           req.DashboardID,
+		Label:                 label,
+		Query:                 sq.Query,
+		ChartType:             chartType,
+		Stacking:              req.Stacking,
+		ComparisonDisplayType: req.ComparisonDisplayType,
+	})
+}
+
+func (s *Service) getModifiable(ctx context.Context, user *auth.User, id uuid.UUID) (*SavedQuery, error) {
+	sq, err := s.Get(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if sq.UserID != user.ID && user.Role != auth.RoleAdmin {
+		return nil, ErrForbidden
+	}
+	return sq, nil
+}
+
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrNameEmpty
+	}
+	if len(name) > 255 {
+		return "", ErrNameTooLong
+	}
+	return name, nil
+}
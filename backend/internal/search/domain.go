@@ -0,0 +1,57 @@
+package search
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrQueryEmpty   = errors.New("search query is required")
+	ErrQueryTooLong = errors.New("search query exceeds maximum length of 100 characters")
+	ErrInvalidType  = errors.New("invalid result type")
+)
+
+const (
+	maxQueryLength = 100
+	maxPerType     = 20 // Results returned per entity type
+)
+
+// ResultType identifies the kind of entity a search result refers to.
+type ResultType string
+
+const (
+	ResultTypeDashboard  ResultType = "dashboard"
+	ResultTypeMetric     ResultType = "metric"
+	ResultTypeDataSource ResultType = "data_source"
+)
+
+// IsValid checks if the result type is valid.
+func (t ResultType) IsValid() bool {
+	switch t {
+	case ResultTypeDashboard, ResultTypeMetric, ResultTypeDataSource:
+		return true
+	}
+	return false
+}
+
+// Result is a single search hit.
+type Result struct {
+	Type        ResultType `json:"type"`
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Subtitle    *string    `json:"subtitle,omitempty"`    // e.g. the measurement name of a metric
+	DashboardID *uuid.UUID `json:"dashboardId,omitempty"` // Set for metrics
+	MatchedOn   string     `json:"matchedOn"`             // Field that matched, e.g. "label" or "measurement_name"
+}
+
+// SearchResponse is the response for a search.
+type SearchResponse struct {
+	Query   string   `json:"query"`
+	Results []Result `json:"results"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
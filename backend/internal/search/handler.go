@@ -0,0 +1,73 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+)
+
+// Handler handles HTTP requests for search.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new search handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Search handles searching across dashboards, metrics, and data sources.
+//
+//	@Summary		Search
+//	@Description	Search dashboards (name), metrics (label and measurement name), and data sources (name) in the organization. Results are tagged with their type.
+//	@Tags			search
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			q		query		string	true	"Search text (case-insensitive substring)"
+//	@Param			types	query		string	false	"Comma-separated result types to include (dashboard, metric, data_source)"
+//	@Success		200		{object}	SearchResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/search [get]
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var types []ResultType
+	if v := r.URL.Query().Get("types"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			types = append(types, ResultType(strings.TrimSpace(t)))
+		}
+	}
+
+	resp, err := h.service.Search(r.Context(), user.OrganizationID, r.URL.Query().Get("q"), types)
+	if err != nil {
+		if errors.Is(err, ErrQueryEmpty) || errors.Is(err, ErrQueryTooLong) || errors.Is(err, ErrInvalidType) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("search error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to search")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
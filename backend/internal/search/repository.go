@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles search queries across entities.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new search repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// SearchDashboards finds dashboards whose name contains the query.
+func (r *Repository) SearchDashboards(ctx context.Context, orgID uuid.UUID, query string) ([]Result, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, name
+		FROM dashboards
+		WHERE organization_id = $1 AND deleted_at IS NULL AND name ILIKE $2 ESCAPE '\'
+		ORDER BY position(lower($3) in lower(name)), name
+		LIMIT $4`,
+		orgID, likePattern(query), query, maxPerType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		res := Result{Type: ResultTypeDashboard, MatchedOn: "name"}
+		if err := rows.Scan(&res.ID, &res.Title); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+
+	return results, rows.Err()
+}
+
+// SearchMetrics finds metrics whose label or measurement name contains the query.
+func (r *Repository) SearchMetrics(ctx context.Context, orgID uuid.UUID, query string) ([]Result, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.id, m.label, m.measurement_name, m.dashboard_id,
+		        CASE WHEN m.label ILIKE $2 ESCAPE '\' THEN 'label' ELSE 'measurement_name' END
+		FROM metrics m
+		JOIN dashboards d ON d.id = m.dashboard_id
+		WHERE d.organization_id = $1 AND d.deleted_at IS NULL AND m.deleted_at IS NULL
+		  AND (m.label ILIKE $2 ESCAPE '\' OR m.measurement_name ILIKE $2 ESCAPE '\')
+		ORDER BY position(lower($3) in lower(m.label)) = 0, m.label
+		LIMIT $4`,
+		orgID, likePattern(query), query, maxPerType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		res := Result{Type: ResultTypeMetric}
+		var measurementName string
+		var dashboardID uuid.UUID
+		if err := rows.Scan(&res.ID, &res.Title, &measurementName, &dashboardID, &res.MatchedOn); err != nil {
+			return nil, err
+		}
+		res.Subtitle = &measurementName
+		res.DashboardID = &dashboardID
+		results = append(results, res)
+	}
+
+	return results, rows.Err()
+}
+
+// SearchDataSources finds data sources whose name contains the query.
+func (r *Repository) SearchDataSources(ctx context.Context, orgID uuid.UUID, query string) ([]Result, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, name
+		FROM data_sources
+		WHERE organization_id = $1 AND name ILIKE $2 ESCAPE '\'
+		ORDER BY position(lower($3) in lower(name)), name
+		LIMIT $4`,
+		orgID, likePattern(query), query, maxPerType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		res := Result{Type: ResultTypeDataSource, MatchedOn: "name"}
+		if err := rows.Scan(&res.ID, &res.Title); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+
+	return results, rows.Err()
+}
+
+// likePattern builds a contains-pattern for ILIKE, escaping wildcards in the query.
+func likePattern(query string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+	return "%" + escaped + "%"
+}
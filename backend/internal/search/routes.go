@@ -0,0 +1,15 @@
+package search
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the search routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/search", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", h.Search)
+	})
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Service handles cross-entity search.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new search service.
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Search finds dashboards, metrics, and data sources in an organization matching
+// the query. If types is empty, all entity types are searched.
+func (s *Service) Search(ctx context.Context, orgID uuid.UUID, query string, types []ResultType) (*SearchResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrQueryEmpty
+	}
+	if utf8.RuneCountInString(query) > maxQueryLength {
+		return nil, ErrQueryTooLong
+	}
+
+	wanted := make(map[ResultType]bool)
+	for _, t := range types {
+		if !t.IsValid() {
+			return nil, ErrInvalidType
+		}
+		wanted[t] = true
+	}
+	include := func(t ResultType) bool {
+		return len(wanted) == 0 || wanted[t]
+	}
+
+	searches := []struct {
+		resultType ResultType
+		fn         func(context.Context, uuid.UUID, string) ([]Result, error)
+	}{
+		{ResultTypeDashboard, s.repo.SearchDashboards},
+		{ResultTypeMetric, s.repo.SearchMetrics},
+		{ResultTypeDataSource, s.repo.SearchDataSources},
+	}
+
+	results := []Result{}
+	for _, search := range searches {
+		if !include(search.resultType) {
+			continue
+		}
+		found, err := search.fn(ctx, orgID, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %s: %w", search.resultType, err)
+		}
+		results = append(results, found...)
+	}
+
+	return &SearchResponse{Query: query, Results: results}, nil
+}
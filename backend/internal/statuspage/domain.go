@@ -0,0 +1,133 @@
+package statuspage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Error definitions
+var (
+	ErrStatusPageNotFound = errors.New("status page not found")
+	ErrTitleTooLong       = errors.New("title exceeds maximum length of 255 characters")
+	ErrNoComponents       = errors.New("a status page needs at least one metric")
+	ErrTooManyComponents  = errors.New("a status page can show at most 20 metrics")
+	ErrDuplicateComponent = errors.New("each metric can appear only once on a status page")
+	ErrLabelTooLong       = errors.New("label exceeds maximum length of 255 characters")
+	ErrMetricNotFound     = errors.New("metric not found on this dashboard")
+	ErrMetricNotScalar    = errors.New("status pages can only show scalar metrics")
+	ErrInvalidBadWhen     = errors.New("badWhen must be above or below")
+	ErrInvalidThreshold   = errors.New("thresholds must be finite numbers")
+	ErrThresholdOrder     = errors.New("downAt must not be less severe than degradedAt")
+)
+
+const (
+	maxComponents = 20
+	historyDays   = 30          // Days of history behind the uptime summary
+	cacheTTL      = time.Minute // How long a rendered page is served before recomputing
+)
+
+// Status is the health of a component, a day or a whole page.
+type Status string
+
+const (
+	StatusOperational Status = "operational"
+	StatusDegraded    Status = "degraded"
+	StatusDown        Status = "down"
+	StatusNoData      Status = "no_data" // No value to compare against the thresholds
+)
+
+// severity orders statuses so the worst one can be picked.
+func (s Status) severity() int {
+	switch s {
+	case StatusOperational:
+		return 1
+	case StatusDegraded:
+		return 2
+	case StatusDown:
+		return 3
+	}
+	return 0
+}
+
+// BadWhen is which side of a threshold is unhealthy.
+type BadWhen string
+
+const (
+	BadWhenAbove BadWhen = "above" // Higher values are worse, e.g. error rates or latency
+	BadWhenBelow BadWhen = "below" // Lower values are worse, e.g. success rates or uptime
+)
+
+// IsValid checks if the direction is valid.
+func (b BadWhen) IsValid() bool {
+	return b == BadWhenAbove || b == BadWhenBelow
+}
+
+// Component is a metric shown on a status page with the thresholds its
+// value is judged by. A value at or beyond DownAt is down, at or beyond
+// DegradedAt degraded, and operational otherwise.
+type Component struct {
+	MetricID   uuid.UUID `json:"metricId"`
+	Label      string    `json:"label,omitempty"` // Shown instead of the metric label
+	BadWhen    BadWhen   `json:"badWhen"`
+	DegradedAt *float64  `json:"degradedAt,omitempty"`
+	DownAt     *float64  `json:"downAt,omitempty"`
+}
+
+// StatusPage is the public status page of a dashboard. Anyone with its
+// token can view it without signing in.
+type StatusPage struct {
+	ID             uuid.UUID   `json:"id"`
+	OrganizationID uuid.UUID   `json:"organizationId"`
+	DashboardID    uuid.UUID   `json:"dashboardId"`
+	Token          string      `json:"token"`
+	Title          string      `json:"title"`
+	Components     []Component `json:"components"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+// Request/Response types
+
+// PutStatusPageRequest is the request body for configuring a dashboard's
+// status page.
+type PutStatusPageRequest struct {
+	Title      string      `json:"title" validate:"max=255"` // Defaults to the dashboard name
+	Components []Component `json:"components"`
+}
+
+// PublicStatusPage is a rendered status page, as served to the public.
+type PublicStatusPage struct {
+	Title          string            `json:"title"`
+	OrganizationID uuid.UUID         `json:"organizationId"` // For fetching the public branding
+	Status         Status            `json:"status"`         // The worst status of the components
+	Components     []ComponentStatus `json:"components"`
+	GeneratedAt    time.Time         `json:"generatedAt"`
+}
+
+// ComponentStatus is the current and recent health of a component.
+type ComponentStatus struct {
+	Label         string      `json:"label"`
+	Status        Status      `json:"status"`
+	Value         *float64    `json:"value,omitempty"`         // The metric's value over its timeframe
+	UptimePercent *float64    `json:"uptimePercent,omitempty"` // Share of the days with data that were operational
+	Days          []DayStatus `json:"days,omitempty"`          // Oldest first; omitted for share-of metrics
+}
+
+// DayStatus is a component's health on one day.
+type DayStatus struct {
+	Date   string   `json:"date"` // YYYY-MM-DD
+	Status Status   `json:"status"`
+	Value  *float64 `json:"value,omitempty"`
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,257 @@
+package statuspage
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/platform/validate"
+)
+
+// validationErrors are the status page errors caused by invalid input.
+var validationErrors = []error{
+	ErrTitleTooLong,
+	ErrNoComponents,
+	ErrTooManyComponents,
+	ErrDuplicateComponent,
+	ErrLabelTooLong,
+	ErrMetricNotFound,
+	ErrMetricNotScalar,
+	ErrInvalidBadWhen,
+	ErrInvalidThreshold,
+	ErrThresholdOrder,
+}
+
+// Handler handles HTTP requests for status pages.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new status page handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetStatusPage handles getting a dashboard's status page configuration.
+//
+//	@Summary		Get status page
+//	@Description	Get the public status page configuration of a dashboard, including the token of its public link
+//	@Tags			status-pages
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	StatusPage
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/dashboards/{id}/status-page [get]
+func (h *Handler) GetStatusPage(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	p, err := h.service.Get(r.Context(), user.OrganizationID, dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "get status page", "failed to get status page")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+// PutStatusPage handles creating or replacing a dashboard's status page.
+//
+//	@Summary		Create or replace status page
+//	@Description	Publish selected scalar metrics of a dashboard as a public status page. Each metric is shown as operational, degraded or down by comparing its value with the degradedAt and downAt thresholds, from the side given by badWhen. A new page gets a token for its public link; an existing page keeps it.
+//	@Tags			status-pages
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Dashboard ID"
+//	@Param			request	body		PutStatusPageRequest	true	"Status page configuration"
+//	@Success		200		{object}	StatusPage				"Updated"
+//	@Success		201		{object}	StatusPage				"Created"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/dashboards/{id}/status-page [put]
+func (h *Handler) PutStatusPage(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	var req PutStatusPageRequest
+	if err := validate.DecodeJSON(r, &req); err != nil {
+		validate.RespondError(w, err)
+		return
+	}
+
+	p, created, err := h.service.Put(r.Context(), user.OrganizationID, dashboardID, req)
+	if err != nil {
+		respondServiceError(w, err, "put status page", "failed to save status page")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondJSON(w, status, p)
+}
+
+// RotateStatusPageToken handles replacing the token of a status page.
+//
+//	@Summary		Rotate status page token
+//	@Description	Give a dashboard's status page a new token. The previous public link stops working immediately.
+//	@Tags			status-pages
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	StatusPage
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/dashboards/{id}/status-page/rotate-token [post]
+func (h *Handler) RotateStatusPageToken(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	p, err := h.service.RotateToken(r.Context(), user.OrganizationID, dashboardID)
+	if err != nil {
+		respondServiceError(w, err, "rotate status page token", "failed to rotate token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+// DeleteStatusPage handles deleting a dashboard's status page.
+//
+//	@Summary		Delete status page
+//	@Description	Delete a dashboard's status page; its public link stops working
+//	@Tags			status-pages
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/dashboards/{id}/status-page [delete]
+func (h *Handler) DeleteStatusPage(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dashboardID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), user.OrganizationID, dashboardID); err != nil {
+		respondServiceError(w, err, "delete status page", "failed to delete status page")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "status page deleted"})
+}
+
+// GetPublicStatusPage handles serving a status page to the public.
+//
+//	@Summary		Get public status page
+//	@Description	Get the current status of each metric on a status page, with a daily history of the last 30 days and the share of those days that were operational. No authentication required. Pages are recomputed at most once a minute.
+//	@Tags			status-pages
+//	@Produce		json
+//	@Param			token	path		string	true	"Status page token"
+//	@Success		200		{object}	PublicStatusPage
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/public/status-pages/{token} [get]
+func (h *Handler) GetPublicStatusPage(w http.ResponseWriter, r *http.Request) {
+	page, err := h.service.Render(r.Context(), chi.URLParam(r, "token"))
+	if errors.Is(err, ErrStatusPageNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("render status page error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get status page")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	respondJSON(w, http.StatusOK, page)
+}
+
+func respondServiceError(w http.ResponseWriter, err error, op, message string) {
+	switch {
+	case errors.Is(err, ErrStatusPageNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, dashboard.ErrDashboardNotFound):
+		respondError(w, http.StatusNotFound, "dashboard not found")
+	case errors.Is(err, dashboard.ErrUnauthorized):
+		respondError(w, http.StatusForbidden, "unauthorized")
+	default:
+		for _, target := range validationErrors {
+			if errors.Is(err, target) {
+				respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		log.Printf("%s error: %v", op, err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,96 @@
+package statuspage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const statusPageColumns = `p.id, p.organization_id, p.dashboard_id, p.token, p.title, p.components, p.created_at, p.updated_at`
+
+// Repository handles database operations for status pages.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new status page repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Upsert creates or updates the status page of a dashboard. An existing
+// page keeps its ID and token.
+func (r *Repository) Upsert(ctx context.Context, p *StatusPage) error {
+	componentsJSON, err := json.Marshal(p.Components)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO status_pages (id, organization_id, dashboard_id, token, title, components, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (dashboard_id) DO UPDATE
+		SET title = EXCLUDED.title, components = EXCLUDED.components, updated_at = EXCLUDED.updated_at
+		RETURNING id, token, created_at, updated_at`,
+		uuid.New(), p.OrganizationID, p.DashboardID, p.Token, p.Title, componentsJSON, now,
+	).Scan(&p.ID, &p.Token, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// GetByDashboardID retrieves the status page of a dashboard.
+func (r *Repository) GetByDashboardID(ctx context.Context, dashboardID uuid.UUID) (*StatusPage, error) {
+	return r.get(ctx,
+		`SELECT `+statusPageColumns+` FROM status_pages p WHERE p.dashboard_id = $1`,
+		dashboardID,
+	)
+}
+
+// GetByToken retrieves a status page by its token. Pages of deleted
+// dashboards and of disabled organizations are not returned.
+func (r *Repository) GetByToken(ctx context.Context, token string) (*StatusPage, error) {
+	return r.get(ctx,
+		`SELECT `+statusPageColumns+` FROM status_pages p
+		JOIN dashboards d ON d.id = p.dashboard_id
+		JOIN organizations o ON o.id = d.organization_id
+		WHERE p.token = $1 AND d.deleted_at IS NULL AND o.disabled_at IS NULL`,
+		token,
+	)
+}
+
+// UpdateToken replaces the token of a dashboard's status page.
+func (r *Repository) UpdateToken(ctx context.Context, dashboardID uuid.UUID, token string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE status_pages SET token = $1, updated_at = NOW() WHERE dashboard_id = $2`,
+		token, dashboardID,
+	)
+	return err
+}
+
+// DeleteByDashboardID deletes the status page of a dashboard.
+func (r *Repository) DeleteByDashboardID(ctx context.Context, dashboardID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM status_pages WHERE dashboard_id = $1`, dashboardID)
+	return err
+}
+
+func (r *Repository) get(ctx context.Context, query string, args ...any) (*StatusPage, error) {
+	p := &StatusPage{}
+	var componentsJSON []byte
+	err := r.pool.QueryRow(ctx, query, args...).Scan(
+		&p.ID, &p.OrganizationID, &p.DashboardID, &p.Token, &p.Title, &componentsJSON, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(componentsJSON, &p.Components); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
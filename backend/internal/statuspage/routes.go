@@ -0,0 +1,26 @@
+package statuspage
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the status page routes. The public route is
+// rate limited with computeLimit, since rendering a page computes metrics.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware, computeLimit func(next http.Handler) http.Handler) {
+	r.Route("/dashboards/{id}/status-page", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// Read operations (all authenticated users)
+		r.Get("/", h.GetStatusPage)
+
+		// Write operations (editor and admin only)
+		r.Put("/", h.PutStatusPage)
+		r.Post("/rotate-token", h.RotateStatusPageToken)
+		r.Delete("/", h.DeleteStatusPage)
+	})
+
+	// Public route for customer-facing status pages
+	r.With(computeLimit).Get("/public/status-pages/{token}", h.GetPublicStatusPage)
+}
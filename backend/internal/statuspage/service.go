@@ -0,0 +1,412 @@
+package statuspage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/writeaudit"
+)
+
+const tokenBytes = 24
+
+// Service handles status page business logic.
+type Service struct {
+	repo             *Repository
+	metricService    *metric.Service
+	dashboardService *dashboard.Service
+	audit            *writeaudit.Service
+
+	mu    sync.Mutex // Guards cache
+	cache map[string]cachedPage
+}
+
+// cachedPage is a rendered page and when it was rendered.
+type cachedPage struct {
+	page *PublicStatusPage
+	at   time.Time
+}
+
+// NewService creates a new status page service.
+func NewService(repo *Repository, metricService *metric.Service, dashboardService *dashboard.Service, audit *writeaudit.Service) *Service {
+	return &Service{
+		repo:             repo,
+		metricService:    metricService,
+		dashboardService: dashboardService,
+		audit:            audit,
+		cache:            make(map[string]cachedPage),
+	}
+}
+
+// Get returns the status page of a dashboard of the organization.
+func (s *Service) Get(ctx context.Context, orgID, dashboardID uuid.UUID) (*StatusPage, error) {
+	if _, err := s.dashboardService.VerifyDashboardOwnership(ctx, orgID, dashboardID); err != nil {
+		return nil, err
+	}
+	return s.getByDashboardID(ctx, dashboardID)
+}
+
+// Put creates or replaces the status page of a dashboard. A new page gets a
+// fresh token; an existing page keeps its token.
+func (s *Service) Put(ctx context.Context, orgID, dashboardID uuid.UUID, req PutStatusPageRequest) (*StatusPage, bool, error) {
+	d, err := s.dashboardService.VerifyDashboardOwnership(ctx, orgID, dashboardID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = d.Name
+	}
+	if len(title) > 255 {
+		return nil, false, ErrTitleTooLong
+	}
+	components, err := s.validateComponents(ctx, dashboardID, req.Components)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := s.repo.GetByDashboardID(ctx, dashboardID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get status page: %w", err)
+	}
+	token, err := generateToken()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	p := &StatusPage{
+		OrganizationID: orgID,
+		DashboardID:    dashboardID,
+		Token:          token, // Ignored if the page exists
+		Title:          title,
+		Components:     components,
+	}
+	if err := s.repo.Upsert(ctx, p); err != nil {
+		return nil, false, fmt.Errorf("failed to save status page: %w", err)
+	}
+	s.invalidate(p.Token)
+	if existing == nil {
+		s.audit.Record(ctx, writeaudit.EntityStatusPage, p.ID, writeaudit.ActionCreate, nil, withoutToken(p))
+	} else {
+		s.audit.Record(ctx, writeaudit.EntityStatusPage, p.ID, writeaudit.ActionUpdate, withoutToken(existing), withoutToken(p))
+	}
+
+	return p, existing == nil, nil
+}
+
+// RotateToken gives a dashboard's status page a new token. The old public
+// link stops working immediately.
+func (s *Service) RotateToken(ctx context.Context, orgID, dashboardID uuid.UUID) (*StatusPage, error) {
+	p, err := s.Get(ctx, orgID, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	if err := s.repo.UpdateToken(ctx, dashboardID, token); err != nil {
+		return nil, fmt.Errorf("failed to update token: %w", err)
+	}
+	s.invalidate(p.Token)
+	s.audit.RecordPart(ctx, writeaudit.EntityStatusPage, p.ID, "tokenRotatedAt", nil, time.Now())
+
+	return s.getByDashboardID(ctx, dashboardID)
+}
+
+// Delete deletes the status page of a dashboard.
+func (s *Service) Delete(ctx context.Context, orgID, dashboardID uuid.UUID) error {
+	p, err := s.Get(ctx, orgID, dashboardID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteByDashboardID(ctx, dashboardID); err != nil {
+		return fmt.Errorf("failed to delete status page: %w", err)
+	}
+	s.invalidate(p.Token)
+	s.audit.Record(ctx, writeaudit.EntityStatusPage, p.ID, writeaudit.ActionDelete, withoutToken(p), nil)
+	return nil
+}
+
+// withoutToken returns a copy of a status page for the write audit. The token
+// grants access to the page, so it is left out.
+func withoutToken(p *StatusPage) StatusPage {
+	c := *p
+	c.Token = ""
+	return c
+}
+
+// Render returns the public view of the status page with the given token.
+// Rendered pages are reused for cacheTTL, so a popular page computes its
+// metrics at most once a minute.
+func (s *Service) Render(ctx context.Context, token string) (*PublicStatusPage, error) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	cached, ok := s.cache[token]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.at) < cacheTTL {
+		return cached.page, nil
+	}
+
+	p, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status page: %w", err)
+	}
+	if p == nil {
+		return nil, ErrStatusPageNotFound
+	}
+
+	page, err := s.render(ctx, p, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	// Drop expired pages so tokens that are no longer requested do not pile up
+	for t, c := range s.cache {
+		if now.Sub(c.at) >= cacheTTL {
+			delete(s.cache, t)
+		}
+	}
+	s.cache[token] = cachedPage{page: page, at: now}
+	s.mu.Unlock()
+
+	return page, nil
+}
+
+// render computes the components of a status page. Each component's metric
+// is computed as configured for its current value and as a daily series for
+// its history. Components whose metric was removed from the dashboard are
+// left out.
+func (s *Service) render(ctx context.Context, p *StatusPage, now time.Time) (*PublicStatusPage, error) {
+	metrics, err := s.metricService.GetByDashboardID(ctx, p.DashboardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+	byID := make(map[uuid.UUID]metric.Metric, len(metrics))
+	for _, m := range metrics {
+		byID[m.ID] = m
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	historyFrom := today.AddDate(0, 0, -(historyDays - 1))
+	daily := metric.GranularityDaily
+
+	var toCompute []metric.Metric
+	var shown []Component
+	var labels []string
+	var currentAt []int
+	var historyAt []int // Index of each component's history in toCompute, or -1
+	for _, c := range p.Components {
+		m, ok := byID[c.MetricID]
+		if !ok {
+			continue
+		}
+		label := c.Label
+		if label == "" {
+			label = m.Label
+		}
+		shown = append(shown, c)
+		labels = append(labels, label)
+
+		current := m
+		current.ComparisonEnabled = false
+		currentAt = append(currentAt, len(toCompute))
+		toCompute = append(toCompute, current)
+
+		// Shares cannot be bucketed by day, so they only get a current status
+		if m.ShareOf != nil {
+			historyAt = append(historyAt, -1)
+			continue
+		}
+		history := m
+		history.DisplayMode = metric.DisplayModeTimeSeries
+		history.Granularity = &daily
+		history.Timeframe = "custom"
+		history.DateFrom = &historyFrom
+		history.DateTo = &today
+		history.ComparisonEnabled = false
+		history.SplitBy = nil
+		history.CompositeSeries = nil
+		history.Stacking = nil
+		historyAt = append(historyAt, len(toCompute))
+		toCompute = append(toCompute, history)
+	}
+
+	var computed []metric.ComputedMetric
+	if len(toCompute) > 0 {
+		computed = s.metricService.Compute(ctx, p.OrganizationID, toCompute)
+	}
+
+	page := &PublicStatusPage{
+		Title:          p.Title,
+		OrganizationID: p.OrganizationID,
+		Status:         StatusNoData,
+		Components:     []ComponentStatus{},
+		GeneratedAt:    now,
+	}
+	for j, c := range shown {
+		cs := ComponentStatus{Label: labels[j], Status: StatusNoData}
+		if current := computed[currentAt[j]]; current.Error == nil && current.Value != nil {
+			cs.Value = current.Value
+			cs.Status = c.statusOf(*current.Value)
+		}
+		if historyAt[j] >= 0 {
+			cs.Days, cs.UptimePercent = c.history(computed[historyAt[j]], historyFrom)
+		}
+
+		if cs.Status.severity() > page.Status.severity() {
+			page.Status = cs.Status
+		}
+		page.Components = append(page.Components, cs)
+	}
+
+	return page, nil
+}
+
+// history judges each day of a component's daily values. Days without
+// measurements are no_data and do not count towards the uptime.
+func (c Component) history(computed metric.ComputedMetric, from time.Time) ([]DayStatus, *float64) {
+	values := make(map[string]float64, len(computed.DataPoints))
+	if computed.Error == nil {
+		for _, dp := range computed.DataPoints {
+			values[dp.Date] = dp.Value
+		}
+	}
+
+	days := make([]DayStatus, historyDays)
+	withData, operational := 0, 0
+	for d := range days {
+		date := from.AddDate(0, 0, d).Format("2006-01-02")
+		days[d] = DayStatus{Date: date, Status: StatusNoData}
+		v, ok := values[date]
+		if !ok {
+			continue
+		}
+		days[d].Value = &v
+		days[d].Status = c.statusOf(v)
+		withData++
+		if days[d].Status == StatusOperational {
+			operational++
+		}
+	}
+
+	if withData == 0 {
+		return days, nil
+	}
+	uptime := math.Round(float64(operational)/float64(withData)*10000) / 100
+	return days, &uptime
+}
+
+// statusOf judges a value by the component's thresholds.
+func (c Component) statusOf(v float64) Status {
+	if c.DownAt != nil && c.beyond(v, *c.DownAt) {
+		return StatusDown
+	}
+	if c.DegradedAt != nil && c.beyond(v, *c.DegradedAt) {
+		return StatusDegraded
+	}
+	return StatusOperational
+}
+
+// beyond reports whether v is at or on the unhealthy side of threshold.
+func (c Component) beyond(v, threshold float64) bool {
+	if c.BadWhen == BadWhenBelow {
+		return v <= threshold
+	}
+	return v >= threshold
+}
+
+// validateComponents checks that components show distinct scalar metrics of
+// the dashboard with consistent thresholds.
+func (s *Service) validateComponents(ctx context.Context, dashboardID uuid.UUID, components []Component) ([]Component, error) {
+	if len(components) == 0 {
+		return nil, ErrNoComponents
+	}
+	if len(components) > maxComponents {
+		return nil, ErrTooManyComponents
+	}
+
+	metrics, err := s.metricService.GetByDashboardID(ctx, dashboardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics: %w", err)
+	}
+	byID := make(map[uuid.UUID]metric.Metric, len(metrics))
+	for _, m := range metrics {
+		byID[m.ID] = m
+	}
+
+	seen := make(map[uuid.UUID]bool, len(components))
+	result := make([]Component, len(components))
+	for i, c := range components {
+		m, ok := byID[c.MetricID]
+		if !ok {
+			return nil, ErrMetricNotFound
+		}
+		if m.DisplayMode != metric.DisplayModeScalar {
+			return nil, ErrMetricNotScalar
+		}
+		if seen[c.MetricID] {
+			return nil, ErrDuplicateComponent
+		}
+		seen[c.MetricID] = true
+
+		c.Label = strings.TrimSpace(c.Label)
+		if len(c.Label) > 255 {
+			return nil, ErrLabelTooLong
+		}
+		if !c.BadWhen.IsValid() {
+			return nil, ErrInvalidBadWhen
+		}
+		for _, t := range []*float64{c.DegradedAt, c.DownAt} {
+			if t != nil && (math.IsNaN(*t) || math.IsInf(*t, 0)) {
+				return nil, ErrInvalidThreshold
+			}
+		}
+		if c.DegradedAt != nil && c.DownAt != nil && !c.beyond(*c.DownAt, *c.DegradedAt) {
+			return nil, ErrThresholdOrder
+		}
+		result[i] = c
+	}
+	return result, nil
+}
+
+// getByDashboardID returns the status page of a dashboard.
+func (s *Service) getByDashboardID(ctx context.Context, dashboardID uuid.UUID) (*StatusPage, error) {
+	p, err := s.repo.GetByDashboardID(ctx, dashboardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status page: %w", err)
+	}
+	if p == nil {
+		return nil, ErrStatusPageNotFound
+	}
+	return p, nil
+}
+
+// invalidate drops the rendered page of a token, so changes show at once.
+func (s *Service) invalidate(token string) {
+	s.mu.Lock()
+	delete(s.cache, token)
+	s.mu.Unlock()
+}
+
+// generateToken generates a random, URL-safe status page token.
+func generateToken() (string, error) {
+	bytes := make([]byte, tokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
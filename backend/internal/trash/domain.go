@@ -0,0 +1,53 @@
+package trash
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetentionPeriod is how long deleted items stay in the trash before they are purged.
+const RetentionPeriod = 30 * 24 * time.Hour
+
+// Error definitions
+var (
+	ErrItemNotFound = errors.New("item not found in trash")
+)
+
+// ItemType is the kind of a trashed item.
+type ItemType string
+
+const (
+	ItemTypeDashboard ItemType = "dashboard"
+	ItemTypeMetric    ItemType = "metric"
+	ItemTypeReport    ItemType = "report"
+)
+
+// Item is a deleted dashboard, metric or report that can still be restored.
+type Item struct {
+	Type          ItemType   `json:"type"`
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	DashboardID   *uuid.UUID `json:"dashboardId,omitempty"`   // Metrics only
+	DashboardName *string    `json:"dashboardName,omitempty"` // Metrics only
+	DeletedAt     time.Time  `json:"deletedAt"`
+	PurgeAt       time.Time  `json:"purgeAt"` // When the item is deleted for good
+}
+
+// Request/Response types
+
+// ListTrashResponse is the response for listing the trash.
+type ListTrashResponse struct {
+	Items []Item `json:"items"` // Most recently deleted first
+}
+
+// MessageResponse is a generic response with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
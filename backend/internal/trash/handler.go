@@ -0,0 +1,190 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+	"github.com/devbydaniel/litekpi/internal/usage"
+)
+
+// Handler handles HTTP requests for the trash.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new trash handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListTrash handles listing deleted dashboards, metrics and reports.
+//
+//	@Summary		List trash
+//	@Description	List the organization's deleted dashboards, metrics and reports. Items are purged 30 days after deletion. Metrics on a deleted dashboard are not listed; they are restored with their dashboard.
+//	@Tags			trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	ListTrashResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/trash [get]
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	items, err := h.service.List(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("list trash error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to list trash")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ListTrashResponse{Items: items})
+}
+
+// RestoreDashboard handles restoring a deleted dashboard.
+//
+//	@Summary		Restore dashboard
+//	@Description	Restore a deleted dashboard with its metrics. Requires editor or admin role.
+//	@Tags			trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Dashboard ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		402	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/trash/dashboards/{id}/restore [post]
+func (h *Handler) RestoreDashboard(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid dashboard ID")
+		return
+	}
+
+	if err := h.service.RestoreDashboard(r.Context(), user.OrganizationID, id); err != nil {
+		if errors.Is(err, ErrItemNotFound) {
+			respondError(w, http.StatusNotFound, "dashboard not found in trash")
+			return
+		}
+		if errors.Is(err, usage.ErrQuotaExceeded) {
+			respondError(w, http.StatusPaymentRequired, err.Error())
+			return
+		}
+		log.Printf("restore dashboard error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to restore dashboard")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "dashboard restored"})
+}
+
+// RestoreMetric handles restoring a deleted metric.
+//
+//	@Summary		Restore metric
+//	@Description	Restore a deleted metric to its dashboard. Requires editor or admin role.
+//	@Tags			trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Metric ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/trash/metrics/{id}/restore [post]
+func (h *Handler) RestoreMetric(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid metric ID")
+		return
+	}
+
+	if err := h.service.RestoreMetric(r.Context(), user.OrganizationID, id); err != nil {
+		if errors.Is(err, ErrItemNotFound) {
+			respondError(w, http.StatusNotFound, "metric not found in trash")
+			return
+		}
+		log.Printf("restore metric error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to restore metric")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "metric restored"})
+}
+
+// RestoreReport handles restoring a deleted report.
+//
+//	@Summary		Restore report
+//	@Description	Restore a deleted report with its sections. Requires editor or admin role.
+//	@Tags			trash
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Report ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/trash/reports/{id}/restore [post]
+func (h *Handler) RestoreReport(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid report ID")
+		return
+	}
+
+	if err := h.service.RestoreReport(r.Context(), user.OrganizationID, id); err != nil {
+		if errors.Is(err, ErrItemNotFound) {
+			respondError(w, http.StatusNotFound, "report not found in trash")
+			return
+		}
+		log.Printf("restore report error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to restore report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, MessageResponse{Message: "report restored"})
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,21 @@
+package trash
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the trash routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/trash", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		r.Get("/", h.ListTrash)
+
+		// Restoring is a write operation (editor and admin only)
+		r.Post("/dashboards/{id}/restore", h.RestoreDashboard)
+		r.Post("/metrics/{id}/restore", h.RestoreMetric)
+		r.Post("/reports/{id}/restore", h.RestoreReport)
+	})
+}
@@ -0,0 +1,39 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const purgeInterval = time.Hour
+
+// Runner purges items that have been in the trash longer than the retention period.
+type Runner struct {
+	service *Service
+}
+
+// NewRunner creates a new trash purge runner.
+func NewRunner(service *Service) *Runner {
+	return &Runner{service: service}
+}
+
+// Job returns the scheduled job that purges expired items.
+func (r *Runner) Job() jobs.Job {
+	return jobs.Job{Name: "trash_purge", Interval: purgeInterval, Run: r.RunOnce}
+}
+
+// RunOnce purges everything deleted more than RetentionPeriod ago.
+func (r *Runner) RunOnce(ctx context.Context) error {
+	dashboards, metrics, reports, err := r.service.Purge(ctx, time.Now().Add(-RetentionPeriod))
+	if err != nil {
+		return fmt.Errorf("failed to purge trash: %w", err)
+	}
+	if dashboards > 0 || metrics > 0 || reports > 0 {
+		log.Printf("purged %d dashboards, %d metrics and %d reports from the trash", dashboards, metrics, reports)
+	}
+	return nil
+}
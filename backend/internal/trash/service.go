@@ -0,0 +1,129 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/dashboard"
+	"github.com/devbydaniel/litekpi/internal/metric"
+	"github.com/devbydaniel/litekpi/internal/report"
+)
+
+// Service lists, restores and purges deleted dashboards, metrics and reports.
+type Service struct {
+	dashboardService *dashboard.Service
+	metricService    *metric.Service
+	reportService    *report.Service
+}
+
+// NewService creates a new trash service.
+func NewService(dashboardService *dashboard.Service, metricService *metric.Service, reportService *report.Service) *Service {
+	return &Service{
+		dashboardService: dashboardService,
+		metricService:    metricService,
+		reportService:    reportService,
+	}
+}
+
+// List returns the trashed dashboards, metrics and reports of an organization,
+// most recently deleted first.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]Item, error) {
+	dashboards, err := s.dashboardService.ListDeletedDashboards(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := s.metricService.ListDeleted(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := s.reportService.ListDeletedReports(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(dashboards)+len(metrics)+len(reports))
+	for _, d := range dashboards {
+		items = append(items, Item{
+			Type:      ItemTypeDashboard,
+			ID:        d.ID,
+			Name:      d.Name,
+			DeletedAt: d.DeletedAt,
+			PurgeAt:   d.DeletedAt.Add(RetentionPeriod),
+		})
+	}
+	for _, m := range metrics {
+		items = append(items, Item{
+			Type:          ItemTypeMetric,
+			ID:            m.ID,
+			Name:          m.Label,
+			DashboardID:   &m.DashboardID,
+			DashboardName: &m.DashboardName,
+			DeletedAt:     m.DeletedAt,
+			PurgeAt:       m.DeletedAt.Add(RetentionPeriod),
+		})
+	}
+
+	for _, rep := range reports {
+		items = append(items, Item{
+			Type:      ItemTypeReport,
+			ID:        rep.ID,
+			Name:      rep.Name,
+			DeletedAt: rep.DeletedAt,
+			PurgeAt:   rep.DeletedAt.Add(RetentionPeriod),
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// RestoreDashboard takes a dashboard out of the trash along with its metrics.
+func (s *Service) RestoreDashboard(ctx context.Context, orgID, dashboardID uuid.UUID) error {
+	err := s.dashboardService.RestoreDashboard(ctx, orgID, dashboardID)
+	if errors.Is(err, dashboard.ErrDashboardNotFound) {
+		return ErrItemNotFound
+	}
+	return err
+}
+
+// RestoreMetric takes a metric out of the trash. Metrics on a trashed
+// dashboard can only be restored with their dashboard.
+func (s *Service) RestoreMetric(ctx context.Context, orgID, metricID uuid.UUID) error {
+	err := s.metricService.Restore(ctx, orgID, metricID)
+	if errors.Is(err, metric.ErrMetricNotFound) {
+		return ErrItemNotFound
+	}
+	return err
+}
+
+// RestoreReport takes a report out of the trash along with its sections.
+func (s *Service) RestoreReport(ctx context.Context, orgID, reportID uuid.UUID) error {
+	err := s.reportService.RestoreReport(ctx, orgID, reportID)
+	if errors.Is(err, report.ErrReportNotFound) {
+		return ErrItemNotFound
+	}
+	return err
+}
+
+// Purge permanently deletes everything trashed before the given time and
+// returns the number of dashboards, metrics and reports deleted. Metrics on
+// purged dashboards are deleted with them and not counted.
+func (s *Service) Purge(ctx context.Context, before time.Time) (dashboards, metrics, reports int64, err error) {
+	if dashboards, err = s.dashboardService.PurgeDeletedDashboards(ctx, before); err != nil {
+		return 0, 0, 0, err
+	}
+	if metrics, err = s.metricService.PurgeDeleted(ctx, before); err != nil {
+		return dashboards, 0, 0, err
+	}
+	if reports, err = s.reportService.PurgeDeletedReports(ctx, before); err != nil {
+		return dashboards, metrics, 0, err
+	}
+	return dashboards, metrics, reports, nil
+}
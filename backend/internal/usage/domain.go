@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQuotaExceeded is matched by every QuotaExceededError via errors.Is.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Resource identifies a quota-limited resource.
+type Resource string
+
+const (
+	ResourceEvents      Resource = "events"
+	ResourceDataSources Resource = "data_sources"
+	ResourceDashboards  Resource = "dashboards"
+	ResourceUsers       Resource = "users"
+)
+
+// QuotaExceededError describes which quota an operation would exceed.
+type QuotaExceededError struct {
+	Resource Resource
+	Limit    int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	switch e.Resource {
+	case ResourceEvents:
+		return fmt.Sprintf("monthly event quota of %d exceeded", e.Limit)
+	case ResourceDataSources:
+		return fmt.Sprintf("data source quota of %d reached", e.Limit)
+	case ResourceDashboards:
+		return fmt.Sprintf("dashboard quota of %d reached", e.Limit)
+	case ResourceUsers:
+		return fmt.Sprintf("user quota of %d reached", e.Limit)
+	}
+	return fmt.Sprintf("%s quota of %d exceeded", e.Resource, e.Limit)
+}
+
+// Is makes errors.Is(err, ErrQuotaExceeded) match any QuotaExceededError.
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Quotas holds the effective limits for an organization. Zero means unlimited.
+type Quotas struct {
+	EventsPerMonth int64 `json:"eventsPerMonth"`
+	DataSources    int64 `json:"dataSources"`
+	Dashboards     int64 `json:"dashboards"`
+	Users          int64 `json:"users"`
+}
+
+// QuotaOverrides holds per-organization overrides. Nil fields use the instance default.
+type QuotaOverrides struct {
+	EventsPerMonth *int64
+	DataSources    *int64
+	Dashboards     *int64
+	Users          *int64
+}
+
+// Usage is an organization's consumption in the current metering period.
+type Usage struct {
+	PeriodStart        time.Time  `json:"periodStart"`
+	PeriodEnd          time.Time  `json:"periodEnd"`
+	EventsIngested     int64      `json:"eventsIngested"`
+	ComputeRequests    int64      `json:"computeRequests"`
+	StorageBytes       int64      `json:"storageBytes"`                 // Estimated size of stored measurements, refreshed daily
+	StorageEstimatedAt *time.Time `json:"storageEstimatedAt,omitempty"` // Omitted until the first estimate
+	Seats              int64      `json:"seats"`                        // Users in the organization
+	PendingInvites     int64      `json:"pendingInvites"`
+	DataSources        int64      `json:"dataSources"`
+	Dashboards         int64      `json:"dashboards"`
+	Quotas             Quotas     `json:"quotas"`
+}
+
+// ErrorResponse represents an API error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// currentPeriod returns the first day of the current calendar month in UTC.
+func currentPeriod() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,37 @@
+package usage
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/devbydaniel/litekpi/internal/platform/jobs"
+)
+
+const storageEstimateInterval = 24 * time.Hour
+
+// StorageEstimator estimates the size of each organization's measurements
+// in the background, so that reading usage does not scan them.
+type StorageEstimator struct {
+	service *Service
+}
+
+// NewStorageEstimator creates a new storage estimator.
+func NewStorageEstimator(service *Service) *StorageEstimator {
+	return &StorageEstimator{service: service}
+}
+
+// Job returns the scheduled job that estimates storage.
+func (e *StorageEstimator) Job() jobs.Job {
+	return jobs.Job{Name: "usage_storage_estimate", Interval: storageEstimateInterval, Run: e.RunOnce}
+}
+
+// RunOnce estimates the storage of every organization.
+func (e *StorageEstimator) RunOnce(ctx context.Context) error {
+	n, err := e.service.UpdateStorageEstimates(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("estimated storage of %d organizations", n)
+	return nil
+}
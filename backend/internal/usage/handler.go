@@ -0,0 +1,57 @@
+package usage
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+)
+
+// Handler handles HTTP requests for organization usage.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new usage handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetUsage handles returning the organization's usage for the current period.
+//
+//	@Summary		Get organization usage
+//	@Description	Get current-period ingested events, compute requests, storage, seats, and the organization's quotas
+//	@Tags			organization
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	Usage
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/organization/usage [get]
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	u, err := h.service.GetUsage(r.Context(), user.OrganizationID)
+	if err != nil {
+		log.Printf("get usage error: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to get usage")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, u)
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, ErrorResponse{Error: message})
+}
@@ -0,0 +1,191 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for usage metering and quotas.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new usage repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// GetQuotaOverrides retrieves the quota overrides for an organization.
+func (r *Repository) GetQuotaOverrides(ctx context.Context, orgID uuid.UUID) (*QuotaOverrides, error) {
+	o := &QuotaOverrides{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT max_events_per_month, max_data_sources, max_dashboards, max_users
+		FROM organization_quotas WHERE organization_id = $1`,
+		orgID,
+	).Scan(&o.EventsPerMonth, &o.DataSources, &o.Dashboards, &o.Users)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// CountDataSources returns the number of data sources in an organization.
+func (r *Repository) CountDataSources(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM data_sources WHERE organization_id = $1`,
+		orgID,
+	).Scan(&count)
+	return count, err
+}
+
+// CountDashboards returns the number of dashboards in an organization.
+func (r *Repository) CountDashboards(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM dashboards WHERE organization_id = $1 AND deleted_at IS NULL`,
+		orgID,
+	).Scan(&count)
+	return count, err
+}
+
+// CountSeats returns the number of users plus pending, unexpired invites in an organization.
+func (r *Repository) CountSeats(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx,
+		`SELECT
+			(SELECT COUNT(*) FROM users WHERE organization_id = $1) +
+			(SELECT COUNT(*) FROM invites WHERE organization_id = $1 AND accepted_at IS NULL AND expires_at > NOW())`,
+		orgID,
+	).Scan(&count)
+	return count, err
+}
+
+// GetEventsIngested returns the number of events ingested by an organization in a period.
+func (r *Repository) GetEventsIngested(ctx context.Context, orgID uuid.UUID, period time.Time) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx,
+		`SELECT events_ingested FROM usage_counters WHERE organization_id = $1 AND period = $2`,
+		orgID, period,
+	).Scan(&count)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return count, err
+}
+
+// GetPeriodCounters returns the event and compute request counters for an organization in a period.
+func (r *Repository) GetPeriodCounters(ctx context.Context, orgID uuid.UUID, period time.Time) (events, computeRequests int64, err error) {
+	err = r.pool.QueryRow(ctx,
+		`SELECT events_ingested, compute_requests FROM usage_counters WHERE organization_id = $1 AND period = $2`,
+		orgID, period,
+	).Scan(&events, &computeRequests)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, nil
+	}
+	return events, computeRequests, err
+}
+
+// CountUsers returns the number of users in an organization.
+func (r *Repository) CountUsers(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM users WHERE organization_id = $1`,
+		orgID,
+	).Scan(&count)
+	return count, err
+}
+
+// CountPendingInvites returns the number of pending, unexpired invites in an organization.
+func (r *Repository) CountPendingInvites(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM invites WHERE organization_id = $1 AND accepted_at IS NULL AND expires_at > NOW()`,
+		orgID,
+	).Scan(&count)
+	return count, err
+}
+
+// GetStorageEstimate returns the last estimated size of an organization's
+// measurements and when it was estimated, or nil if it has not been yet.
+func (r *Repository) GetStorageEstimate(ctx context.Context, orgID uuid.UUID) (int64, *time.Time, error) {
+	var bytes int64
+	var estimatedAt time.Time
+	err := r.pool.QueryRow(ctx,
+		`SELECT bytes, estimated_at FROM storage_estimates WHERE organization_id = $1`,
+		orgID,
+	).Scan(&bytes, &estimatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return bytes, &estimatedAt, nil
+}
+
+// UpdateStorageEstimates estimates the size of every organization's
+// measurements as its row count times the average row size of the
+// measurement partitions, and returns the number of organizations estimated.
+func (r *Repository) UpdateStorageEstimates(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`WITH row_size AS (
+			SELECT COALESCE(SUM(pg_relation_size(c.oid))::float8 / NULLIF(SUM(GREATEST(c.reltuples, 0)), 0), 0) AS bytes
+			FROM pg_partition_tree('measurements') t
+			JOIN pg_class c ON c.oid = t.relid
+			WHERE t.isleaf
+		), row_counts AS (
+			SELECT ds.organization_id, COUNT(*) AS n
+			FROM measurements m
+			JOIN data_sources ds ON ds.id = m.data_source_id
+			GROUP BY ds.organization_id
+		)
+		INSERT INTO storage_estimates (organization_id, bytes, estimated_at)
+		SELECT o.id, COALESCE(rc.n * rs.bytes, 0)::bigint, NOW()
+		FROM organizations o
+		CROSS JOIN row_size rs
+		LEFT JOIN row_counts rc ON rc.organization_id = o.id
+		ON CONFLICT (organization_id) DO UPDATE
+		SET bytes = EXCLUDED.bytes, estimated_at = EXCLUDED.estimated_at`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// IncrementComputeRequests adds one compute request to an organization's counter for a period.
+func (r *Repository) IncrementComputeRequests(ctx context.Context, orgID uuid.UUID, period time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO usage_counters (organization_id, period, compute_requests)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (organization_id, period)
+		DO UPDATE SET compute_requests = usage_counters.compute_requests + 1, updated_at = NOW()`,
+		orgID, period,
+	)
+	return err
+}
+
+// IncrementEventsIngested adds n to an organization's ingested event counter for a period.
+func (r *Repository) IncrementEventsIngested(ctx context.Context, orgID uuid.UUID, period time.Time, n int64) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO usage_counters (organization_id, period, events_ingested)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, period)
+		DO UPDATE SET events_ingested = usage_counters.events_ingested + EXCLUDED.events_ingested, updated_at = NOW()`,
+		orgID, period, n,
+	)
+	return err
+}
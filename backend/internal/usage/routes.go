@@ -0,0 +1,15 @@
+package usage
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the organization usage routes.
+func (h *Handler) RegisterRoutes(r chi.Router, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/organization", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/usage", h.GetUsage)
+	})
+}
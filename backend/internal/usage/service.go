@@ -0,0 +1,199 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/platform/config"
+)
+
+// Service meters organization usage and enforces quotas.
+type Service struct {
+	repo     *Repository
+	defaults Quotas
+}
+
+// NewService creates a new usage service.
+// Instance-wide default quotas come from configuration; zero means unlimited.
+func NewService(repo *Repository, cfg *config.Config) *Service {
+	return &Service{
+		repo: repo,
+		defaults: Quotas{
+			EventsPerMonth: cfg.Quotas.EventsPerMonth,
+			DataSources:    cfg.Quotas.DataSources,
+			Dashboards:     cfg.Quotas.Dashboards,
+			Users:          cfg.Quotas.Users,
+		},
+	}
+}
+
+// GetQuotas returns the effective quotas for an organization.
+func (s *Service) GetQuotas(ctx context.Context, orgID uuid.UUID) (*Quotas, error) {
+	quotas := s.defaults
+
+	overrides, err := s.repo.GetQuotaOverrides(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota overrides: %w", err)
+	}
+	if overrides != nil {
+		if overrides.EventsPerMonth != nil {
+			quotas.EventsPerMonth = *overrides.EventsPerMonth
+		}
+		if overrides.DataSources != nil {
+			quotas.DataSources = *overrides.DataSources
+		}
+		if overrides.Dashboards != nil {
+			quotas.Dashboards = *overrides.Dashboards
+		}
+		if overrides.Users != nil {
+			quotas.Users = *overrides.Users
+		}
+	}
+
+	return &quotas, nil
+}
+
+// CheckDataSourceQuota returns a QuotaExceededError if the organization cannot create another data source.
+func (s *Service) CheckDataSourceQuota(ctx context.Context, orgID uuid.UUID) error {
+	quotas, err := s.GetQuotas(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if quotas.DataSources == 0 {
+		return nil
+	}
+
+	count, err := s.repo.CountDataSources(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to count data sources: %w", err)
+	}
+	if count >= quotas.DataSources {
+		return &QuotaExceededError{Resource: ResourceDataSources, Limit: quotas.DataSources}
+	}
+	return nil
+}
+
+// CheckDashboardQuota returns a QuotaExceededError if the organization cannot create another dashboard.
+func (s *Service) CheckDashboardQuota(ctx context.Context, orgID uuid.UUID) error {
+	quotas, err := s.GetQuotas(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if quotas.Dashboards == 0 {
+		return nil
+	}
+
+	count, err := s.repo.CountDashboards(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to count dashboards: %w", err)
+	}
+	if count >= quotas.Dashboards {
+		return &QuotaExceededError{Resource: ResourceDashboards, Limit: quotas.Dashboards}
+	}
+	return nil
+}
+
+// HasSeatsAvailable reports whether the organization may add n more members.
+// Pending invites count as occupied seats.
+func (s *Service) HasSeatsAvailable(ctx context.Context, orgID uuid.UUID, n int64) (bool, error) {
+	quotas, err := s.GetQuotas(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	if quotas.Users == 0 {
+		return true, nil
+	}
+
+	count, err := s.repo.CountSeats(ctx, orgID)
+	if err != nil {
+		return false, fmt.Errorf("failed to count seats: %w", err)
+	}
+	return count+n <= quotas.Users, nil
+}
+
+// CheckEventQuota returns a QuotaExceededError if ingesting n more events
+// would exceed the organization's monthly event quota.
+func (s *Service) CheckEventQuota(ctx context.Context, orgID uuid.UUID, n int) error {
+	quotas, err := s.GetQuotas(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if quotas.EventsPerMonth == 0 {
+		return nil
+	}
+
+	ingested, err := s.repo.GetEventsIngested(ctx, orgID, currentPeriod())
+	if err != nil {
+		return fmt.Errorf("failed to get ingested events: %w", err)
+	}
+	if ingested+int64(n) > quotas.EventsPerMonth {
+		return &QuotaExceededError{Resource: ResourceEvents, Limit: quotas.EventsPerMonth}
+	}
+	return nil
+}
+
+// RecordEvents adds n ingested events to the organization's current period.
+func (s *Service) RecordEvents(ctx context.Context, orgID uuid.UUID, n int) error {
+	if err := s.repo.IncrementEventsIngested(ctx, orgID, currentPeriod(), int64(n)); err != nil {
+		return fmt.Errorf("failed to record ingested events: %w", err)
+	}
+	return nil
+}
+
+// RecordComputeRequest counts one metric compute request for the organization.
+func (s *Service) RecordComputeRequest(ctx context.Context, orgID uuid.UUID) error {
+	if err := s.repo.IncrementComputeRequests(ctx, orgID, currentPeriod()); err != nil {
+		return fmt.Errorf("failed to record compute request: %w", err)
+	}
+	return nil
+}
+
+// UpdateStorageEstimates re-estimates the size of every organization's
+// measurements and returns the number of organizations estimated.
+func (s *Service) UpdateStorageEstimates(ctx context.Context) (int64, error) {
+	n, err := s.repo.UpdateStorageEstimates(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update storage estimates: %w", err)
+	}
+	return n, nil
+}
+
+// GetUsage returns the organization's usage for the current period together with its quotas.
+func (s *Service) GetUsage(ctx context.Context, orgID uuid.UUID) (*Usage, error) {
+	period := currentPeriod()
+	u := &Usage{
+		PeriodStart: period,
+		PeriodEnd:   period.AddDate(0, 1, 0),
+	}
+
+	quotas, err := s.GetQuotas(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	u.Quotas = *quotas
+
+	u.EventsIngested, u.ComputeRequests, err = s.repo.GetPeriodCounters(ctx, orgID, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage counters: %w", err)
+	}
+
+	if u.StorageBytes, u.StorageEstimatedAt, err = s.repo.GetStorageEstimate(ctx, orgID); err != nil {
+		return nil, fmt.Errorf("failed to get storage estimate: %w", err)
+	}
+	if u.Seats, err = s.repo.CountUsers(ctx, orgID); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	if u.PendingInvites, err = s.repo.CountPendingInvites(ctx, orgID); err != nil {
+		return nil, fmt.Errorf("failed to count pending invites: %w", err)
+	}
+	if u.DataSources, err = s.repo.CountDataSources(ctx, orgID); err != nil {
+		return nil, fmt.Errorf("failed to count data sources: %w", err)
+	}
+	if u.Dashboards, err = s.repo.CountDashboards(ctx, orgID); err != nil {
+		return nil, fmt.Errorf("failed to count dashboards: %w", err)
+	}
+
+	return u, nil
+}
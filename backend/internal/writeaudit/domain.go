@@ -0,0 +1,74 @@
+package writeaudit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// historyLimit caps the number of entries returned with an entity.
+const historyLimit = 100
+
+// Action is the kind of write an entry records.
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionPurge   Action = "purge" // Deleted from the trash for good
+)
+
+// Entity types audited by the modules that own them.
+const (
+	EntityDashboard  = "dashboard"
+	EntityMetric     = "metric"
+	EntityGoal       = "goal"
+	EntityReport     = "report"
+	EntityDataSource = "data_source"
+	EntitySavedQuery = "saved_query"
+	EntityStatusPage = "status_page"
+	EntityBranding   = "branding"
+	EntitySlack      = "slack_connection"
+	EntityMCPKey     = "mcp_key"
+	EntityExport     = "export_config"
+	EntityNotify     = "notification_preferences"
+
+	// Recorded by auth through RecordWrite
+	EntityInvite = "invite"
+	EntityUser   = "user"
+	EntityDomain = "organization_domain"
+)
+
+// ignoredFields change on every write or with ingestion and are left out of
+// diffs.
+var ignoredFields = map[string]bool{
+	"updatedAt":      true,
+	"lastUsedAt":     true,
+	"lastReceivedAt": true,
+	"maxTimestamp":   true,
+	"backfilledAt":   true,
+}
+
+// Change is the value of a field before and after a write. Before is
+// omitted for created entities and After for deleted ones.
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Entry is a successful write to an entity.
+type Entry struct {
+	ID              uuid.UUID         `json:"id"`
+	OrganizationID  uuid.UUID         `json:"-"`
+	EntityType      string            `json:"entityType"`
+	EntityID        uuid.UUID         `json:"entityId"`
+	Action          Action            `json:"action"`
+	ActorID         *uuid.UUID        `json:"actorId,omitempty"`   // Omitted for deleted users and purges
+	ActorName       string            `json:"actorName,omitempty"` // Omitted for deleted users and purges
+	ImpersonationID *uuid.UUID        `json:"impersonationId,omitempty"`
+	Changes         map[string]Change `json:"changes"` // By JSON field name of the entity
+	CreatedAt       time.Time         `json:"createdAt"`
+}
@@ -0,0 +1,66 @@
+package writeaudit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository handles database operations for the write audit.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates a new write audit repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+// Create records a write.
+func (r *Repository) Create(ctx context.Context, e *Entry) error {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO write_audit (id, organization_id, entity_type, entity_id, action, actor_id, impersonation_id, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		e.ID, e.OrganizationID, e.EntityType, e.EntityID, e.Action, e.ActorID, e.ImpersonationID, changes, e.CreatedAt,
+	)
+	return err
+}
+
+// ListByEntity retrieves the most recent writes to an entity, newest first.
+func (r *Repository) ListByEntity(ctx context.Context, orgID uuid.UUID, entityType string, entityID uuid.UUID, limit int) ([]Entry, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT w.id, w.organization_id, w.entity_type, w.entity_id, w.action, w.actor_id, COALESCE(u.name, ''),
+		        w.impersonation_id, w.changes, w.created_at
+		FROM write_audit w
+		LEFT JOIN users u ON u.id = w.actor_id
+		WHERE w.organization_id = $1 AND w.entity_type = $2 AND w.entity_id = $3
+		ORDER BY w.created_at DESC
+		LIMIT $4`,
+		orgID, entityType, entityID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var changes []byte
+		if err := rows.Scan(&e.ID, &e.OrganizationID, &e.EntityType, &e.EntityID, &e.Action, &e.ActorID, &e.ActorName,
+			&e.ImpersonationID, &changes, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
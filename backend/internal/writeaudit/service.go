@@ -0,0 +1,168 @@
+package writeaudit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/devbydaniel/litekpi/internal/auth"
+)
+
+// Service records writes to entities and returns their history.
+type Service struct {
+	repo *Repository
+}
+
+// NewService creates a new write audit service.
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Record records a successful write by the user of ctx, with the fields that
+// differ between before and after. Before is nil for created entities and
+// after for deleted ones. Updates that change nothing and writes without a
+// user, such as those of background jobs, are not recorded; purges from the
+// trash are recorded with RecordPurge.
+//
+// The write has already happened when Record is called, so a failure to
+// record it is logged rather than returned.
+func (s *Service) Record(ctx context.Context, entityType string, entityID uuid.UUID, action Action, before, after any) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return
+	}
+
+	changes, err := diff(before, after)
+	if err != nil {
+		log.Printf("write audit diff error: %v", err)
+		return
+	}
+	if action == ActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	entry := &Entry{
+		ID:              uuid.New(),
+		OrganizationID:  user.OrganizationID,
+		EntityType:      entityType,
+		EntityID:        entityID,
+		Action:          action,
+		ActorID:         &user.ID,
+		ImpersonationID: auth.ImpersonationFromContext(ctx),
+		Changes:         changes,
+		CreatedAt:       time.Now(),
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		log.Printf("write audit error: %s %s %s: %v", action, entityType, entityID, err)
+	}
+}
+
+// RecordWrite is Record for packages that cannot import this one because it
+// depends on them, such as auth.
+func (s *Service) RecordWrite(ctx context.Context, entityType string, entityID uuid.UUID, action string, before, after any) {
+	s.Record(ctx, entityType, entityID, Action(action), before, after)
+}
+
+// RecordPart records a change to a part of an entity, such as a section of a
+// report, as an update of the entity. The part's field holds its state before
+// the change (its ID when it is deleted) and after; before is nil when the
+// part is created.
+func (s *Service) RecordPart(ctx context.Context, entityType string, entityID uuid.UUID, part string, before, after any) {
+	var b, a map[string]any
+	if before != nil {
+		b = map[string]any{part: before}
+	}
+	if after != nil {
+		a = map[string]any{part: after}
+	}
+	s.Record(ctx, entityType, entityID, ActionUpdate, b, a)
+}
+
+// RecordPurge records that an entity of an organization was deleted from the
+// trash for good. Purges are run by a background job, so the entry has no
+// actor.
+func (s *Service) RecordPurge(ctx context.Context, orgID uuid.UUID, entityType string, entityID uuid.UUID) {
+	entry := &Entry{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		EntityType:     entityType,
+		EntityID:       entityID,
+		Action:         ActionPurge,
+		Changes:        map[string]Change{},
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		log.Printf("write audit error: %s %s %s: %v", ActionPurge, entityType, entityID, err)
+	}
+}
+
+// History returns the most recent writes to an entity of an organization,
+// newest first.
+func (s *Service) History(ctx context.Context, orgID uuid.UUID, entityType string, entityID uuid.UUID) ([]Entry, error) {
+	entries, err := s.repo.ListByEntity(ctx, orgID, entityType, entityID, historyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list write history: %w", err)
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return entries, nil
+}
+
+// diff returns the top-level JSON fields whose values differ between before
+// and after, either of which may be nil.
+func diff(before, after any) (map[string]Change, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for name, value := range b {
+		if ignoredFields[name] || bytes.Equal(value, a[name]) {
+			continue
+		}
+		changes[name] = Change{Before: value, After: a[name]}
+	}
+	for name, value := range a {
+		if _, ok := b[name]; ok || ignoredFields[name] {
+			continue
+		}
+		changes[name] = Change{After: value}
+	}
+	return changes, nil
+}
+
+// fields returns the top-level fields of v marshaled as a JSON object.
+func fields(v any) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IncludeHistory reports whether a request for an entity asks for its write
+// history with ?includeHistory=true.
+func IncludeHistory(r *http.Request) bool {
+	include, _ := strconv.ParseBool(r.URL.Query().Get("includeHistory"))
+	return include
+}
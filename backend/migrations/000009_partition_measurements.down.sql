@@ -0,0 +1,26 @@
+-- Rollback measurements partitioning
+CREATE TABLE measurements_unpartitioned (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(data_source_id, name, timestamp)
+);
+
+INSERT INTO measurements_unpartitioned (id, data_source_id, name, value, timestamp, metadata, created_at)
+SELECT id, data_source_id, name, value, timestamp, metadata, created_at
+FROM measurements;
+
+DROP TABLE measurements;
+DROP FUNCTION IF EXISTS drop_measurements_partitions_before(DATE);
+DROP FUNCTION IF EXISTS create_measurements_partition(DATE);
+
+ALTER TABLE measurements_unpartitioned RENAME TO measurements;
+ALTER TABLE measurements RENAME CONSTRAINT measurements_unpartitioned_pkey TO measurements_pkey;
+ALTER TABLE measurements RENAME CONSTRAINT measurements_unpartitioned_data_source_id_name_timestamp_key TO measurements_data_source_id_name_timestamp_key;
+
+CREATE INDEX idx_measurements_data_source_id ON measurements(data_source_id);
+CREATE INDEX idx_measurements_data_source_timestamp ON measurements(data_source_id, timestamp);
@@ -0,0 +1,101 @@
+-- Partition measurements by month on timestamp
+ALTER TABLE measurements RENAME TO measurements_unpartitioned;
+ALTER TABLE measurements_unpartitioned RENAME CONSTRAINT measurements_pkey TO measurements_unpartitioned_pkey;
+ALTER TABLE measurements_unpartitioned RENAME CONSTRAINT measurements_data_source_id_name_timestamp_key TO measurements_unpartitioned_data_source_id_name_timestamp_key;
+ALTER INDEX idx_measurements_data_source_id RENAME TO idx_measurements_unpartitioned_data_source_id;
+ALTER INDEX idx_measurements_data_source_timestamp RENAME TO idx_measurements_unpartitioned_data_source_timestamp;
+
+CREATE TABLE measurements (
+    id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, timestamp),
+    UNIQUE(data_source_id, name, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+CREATE INDEX idx_measurements_data_source_id ON measurements(data_source_id);
+CREATE INDEX idx_measurements_data_source_timestamp ON measurements(data_source_id, timestamp);
+
+-- Catches rows outside any monthly partition until the maintenance job creates one
+CREATE TABLE measurements_default PARTITION OF measurements DEFAULT;
+
+-- Creates the monthly partition containing month_start (idempotent).
+-- Rows already in the default partition for that month are moved into it.
+CREATE OR REPLACE FUNCTION create_measurements_partition(month_start DATE)
+RETURNS TEXT AS $$
+DECLARE
+    range_start DATE := date_trunc('month', month_start)::date;
+    range_end DATE := (date_trunc('month', month_start) + INTERVAL '1 month')::date;
+    partition_name TEXT := 'measurements_' || to_char(date_trunc('month', month_start), 'YYYY_MM');
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN partition_name;
+    END IF;
+
+    CREATE TEMP TABLE measurements_partition_move (LIKE measurements) ON COMMIT DROP;
+
+    WITH moved AS (
+        DELETE FROM measurements_default
+        WHERE timestamp >= range_start AND timestamp < range_end
+        RETURNING *
+    )
+    INSERT INTO measurements_partition_move SELECT * FROM moved;
+
+    EXECUTE format(
+        'CREATE TABLE %I PARTITION OF measurements FOR VALUES FROM (%L) TO (%L)',
+        partition_name, range_start, range_end
+    );
+
+    INSERT INTO measurements SELECT * FROM measurements_partition_move;
+    DROP TABLE measurements_partition_move;
+
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drops monthly partitions that end on or before cutoff, returning their names.
+CREATE OR REPLACE FUNCTION drop_measurements_partitions_before(cutoff DATE)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    part RECORD;
+BEGIN
+    FOR part IN
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        JOIN pg_class p ON p.oid = i.inhparent
+        WHERE p.relname = 'measurements'
+          AND c.relname ~ '^measurements_[0-9]{4}_[0-9]{2}$'
+          AND (to_date(substring(c.relname from 14), 'YYYY_MM') + INTERVAL '1 month')::date <= cutoff
+    LOOP
+        EXECUTE format('DROP TABLE %I', part.relname);
+        RETURN NEXT part.relname;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create partitions for existing data and the upcoming months
+DO $$
+DECLARE
+    partition_month DATE;
+    last_month DATE := (date_trunc('month', NOW()) + INTERVAL '2 months')::date;
+BEGIN
+    SELECT COALESCE(date_trunc('month', MIN(timestamp))::date, date_trunc('month', NOW())::date)
+    INTO partition_month
+    FROM measurements_unpartitioned;
+
+    WHILE partition_month <= last_month LOOP
+        PERFORM create_measurements_partition(partition_month);
+        partition_month := (partition_month + INTERVAL '1 month')::date;
+    END LOOP;
+END $$;
+
+INSERT INTO measurements (id, data_source_id, name, value, timestamp, metadata, created_at)
+SELECT id, data_source_id, name, value, timestamp, metadata, created_at
+FROM measurements_unpartitioned;
+
+DROP TABLE measurements_unpartitioned;
@@ -0,0 +1,7 @@
+-- Rollback data source key prefix
+-- Note: keys hashed with the KDF will stop working and must be regenerated
+DROP INDEX IF EXISTS idx_data_sources_api_key_hash;
+DROP INDEX IF EXISTS idx_data_sources_api_key_prefix;
+
+ALTER TABLE data_sources DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE data_sources DROP COLUMN IF EXISTS api_key_prefix;
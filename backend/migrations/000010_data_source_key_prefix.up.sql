@@ -0,0 +1,6 @@
+-- Key prefix for indexed API key lookup and last-used tracking for data source keys
+ALTER TABLE data_sources ADD COLUMN api_key_prefix VARCHAR(16);
+ALTER TABLE data_sources ADD COLUMN last_used_at TIMESTAMPTZ;
+
+CREATE INDEX idx_data_sources_api_key_prefix ON data_sources(api_key_prefix);
+CREATE INDEX idx_data_sources_api_key_hash ON data_sources(api_key_hash) WHERE api_key_prefix IS NULL;
@@ -0,0 +1,2 @@
+-- Rollback data source CIDR allowlist
+ALTER TABLE data_sources DROP COLUMN IF EXISTS allowed_cidrs;
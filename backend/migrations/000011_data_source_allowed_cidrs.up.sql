@@ -0,0 +1,2 @@
+-- CIDR allowlist restricting where a data source API key may be used from
+ALTER TABLE data_sources ADD COLUMN allowed_cidrs TEXT[] NOT NULL DEFAULT '{}';
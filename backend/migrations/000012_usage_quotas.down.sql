@@ -0,0 +1,3 @@
+-- Rollback usage quotas
+DROP TABLE IF EXISTS usage_counters;
+DROP TABLE IF EXISTS organization_quotas;
@@ -0,0 +1,23 @@
+-- Per-organization quota overrides (NULL falls back to the instance default)
+CREATE TABLE organization_quotas (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    max_events_per_month BIGINT,
+    max_data_sources BIGINT,
+    max_dashboards BIGINT,
+    max_users BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_organization_quotas_updated_at
+    BEFORE UPDATE ON organization_quotas
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Monthly usage counters per organization
+CREATE TABLE usage_counters (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    period DATE NOT NULL,
+    events_ingested BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, period)
+);
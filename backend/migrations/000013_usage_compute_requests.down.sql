@@ -0,0 +1,2 @@
+-- Rollback compute request metering
+ALTER TABLE usage_counters DROP COLUMN IF EXISTS compute_requests;
@@ -0,0 +1,2 @@
+-- Track dashboard compute requests alongside ingested events
+ALTER TABLE usage_counters ADD COLUMN compute_requests BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Rollback instance administration
+DROP TABLE IF EXISTS instance_audit_log;
+ALTER TABLE organizations DROP COLUMN IF EXISTS disabled_at;
@@ -0,0 +1,15 @@
+-- Instance administration: disabled organizations and operator audit log
+ALTER TABLE organizations ADD COLUMN disabled_at TIMESTAMPTZ;
+
+CREATE TABLE instance_audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    action VARCHAR(50) NOT NULL,
+    organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_instance_audit_log_created_at ON instance_audit_log(created_at DESC);
+CREATE INDEX idx_instance_audit_log_organization_id ON instance_audit_log(organization_id);
@@ -0,0 +1,2 @@
+-- Rollback organization branding
+DROP TABLE IF EXISTS organization_branding;
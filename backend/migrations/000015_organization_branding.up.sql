@@ -0,0 +1,14 @@
+-- Per-organization email branding and template overrides
+CREATE TABLE organization_branding (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    logo_url TEXT,
+    accent_color VARCHAR(7),
+    from_name VARCHAR(100),
+    email_templates JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_organization_branding_updated_at
+    BEFORE UPDATE ON organization_branding
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
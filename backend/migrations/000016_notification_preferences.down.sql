@@ -0,0 +1,2 @@
+-- Rollback notification preferences
+DROP TABLE IF EXISTS notification_preferences;
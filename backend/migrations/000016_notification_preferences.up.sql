@@ -0,0 +1,14 @@
+-- Per-user notification preferences (absent rows fall back to the category default)
+CREATE TABLE notification_preferences (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL,
+    channel VARCHAR(50) NOT NULL,
+    resource_id UUID,
+    enabled BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_notification_preferences_unique ON notification_preferences(
+    user_id, category, channel, COALESCE(resource_id, '00000000-0000-0000-0000-000000000000'::uuid)
+);
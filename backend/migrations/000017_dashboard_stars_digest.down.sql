@@ -0,0 +1,3 @@
+-- Rollback starred dashboards and digest deliveries
+DROP TABLE IF EXISTS digest_deliveries;
+DROP TABLE IF EXISTS dashboard_stars;
@@ -0,0 +1,15 @@
+-- Starred dashboards per user and weekly digest delivery tracking
+CREATE TABLE dashboard_stars (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dashboard_id UUID NOT NULL REFERENCES dashboards(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, dashboard_id)
+);
+
+CREATE INDEX idx_dashboard_stars_dashboard_id ON dashboard_stars(dashboard_id);
+
+CREATE TABLE digest_deliveries (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_period DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
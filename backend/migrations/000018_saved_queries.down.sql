@@ -0,0 +1,2 @@
+-- Rollback saved queries
+DROP TABLE IF EXISTS saved_queries;
@@ -0,0 +1,19 @@
+-- Saved explorer queries, private to their owner unless shared with the organization
+CREATE TABLE saved_queries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    query JSONB NOT NULL,
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_saved_queries_organization_id ON saved_queries(organization_id);
+CREATE INDEX idx_saved_queries_user_id ON saved_queries(user_id);
+
+CREATE TRIGGER update_saved_queries_updated_at
+    BEFORE UPDATE ON saved_queries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Rollback ingest transforms
+DROP TABLE IF EXISTS ingest_transforms;
@@ -0,0 +1,11 @@
+-- Per-data-source transformation rules applied to measurements at ingest
+CREATE TABLE ingest_transforms (
+    data_source_id UUID PRIMARY KEY REFERENCES data_sources(id) ON DELETE CASCADE,
+    rules JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_ingest_transforms_updated_at
+    BEFORE UPDATE ON ingest_transforms
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
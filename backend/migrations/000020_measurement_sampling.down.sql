@@ -0,0 +1,3 @@
+-- Rollback measurement sampling
+DROP TABLE IF EXISTS measurement_sampling;
+ALTER TABLE measurements DROP COLUMN IF EXISTS weight;
@@ -0,0 +1,15 @@
+-- Per-measurement sampling: store 1 in rate measurements, each weighted by rate
+ALTER TABLE measurements ADD COLUMN weight INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE measurement_sampling (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    measurement_name VARCHAR(128) NOT NULL,
+    rate INTEGER NOT NULL CHECK (rate >= 1),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (data_source_id, measurement_name)
+);
+
+CREATE TRIGGER update_measurement_sampling_updated_at
+    BEFORE UPDATE ON measurement_sampling
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Rollback measurement rollups
+DROP VIEW IF EXISTS measurement_points;
+DROP TABLE IF EXISTS measurement_rollups;
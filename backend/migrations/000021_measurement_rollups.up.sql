@@ -0,0 +1,22 @@
+-- Per-day aggregates that replace raw measurements once they are old enough
+CREATE TABLE measurement_rollups (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    day DATE NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    value_sum DOUBLE PRECISION NOT NULL,
+    value_count BIGINT NOT NULL,
+    value_min DOUBLE PRECISION NOT NULL,
+    value_max DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (data_source_id, name, day, metadata)
+);
+
+-- Raw measurements and rollups as one set of weighted points. Each rollup is a
+-- single point at midnight UTC whose value is the mean and whose weight is the
+-- count, so SUM(value * weight) and SUM(weight) hold across both.
+CREATE VIEW measurement_points AS
+    SELECT data_source_id, name, timestamp, metadata, value, weight::BIGINT AS weight
+    FROM measurements
+    UNION ALL
+    SELECT data_source_id, name, day::timestamp AT TIME ZONE 'UTC', metadata, value_sum / value_count, value_count
+    FROM measurement_rollups;
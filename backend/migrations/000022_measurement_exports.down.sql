@@ -0,0 +1,2 @@
+-- Rollback measurement exports
+DROP TABLE IF EXISTS export_configs;
@@ -0,0 +1,21 @@
+-- Scheduled daily measurement exports to S3-compatible object storage
+CREATE TABLE export_configs (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    format VARCHAR(20) NOT NULL DEFAULT 'csv',
+    endpoint VARCHAR(255),
+    region VARCHAR(64) NOT NULL,
+    bucket VARCHAR(255) NOT NULL,
+    prefix VARCHAR(255) NOT NULL DEFAULT '',
+    access_key_id VARCHAR(255) NOT NULL,
+    secret_access_key TEXT NOT NULL,
+    last_exported_day DATE,
+    last_run_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_export_configs_updated_at
+    BEFORE UPDATE ON export_configs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
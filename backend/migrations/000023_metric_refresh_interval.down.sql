@@ -0,0 +1,2 @@
+-- Rollback metric refresh interval
+ALTER TABLE metrics DROP COLUMN IF EXISTS refresh_interval_seconds;
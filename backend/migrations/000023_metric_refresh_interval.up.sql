@@ -0,0 +1,2 @@
+-- Let metrics declare how fresh they need to be
+ALTER TABLE metrics ADD COLUMN refresh_interval_seconds INTEGER;
@@ -0,0 +1,2 @@
+-- Rollback compute jobs
+DROP TABLE IF EXISTS compute_jobs;
@@ -0,0 +1,24 @@
+-- Background compute jobs for queries too heavy for a synchronous request
+CREATE TABLE compute_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    dashboard_id UUID REFERENCES dashboards(id) ON DELETE CASCADE,
+    query JSONB,
+    progress_completed INTEGER NOT NULL DEFAULT 0,
+    progress_total INTEGER NOT NULL DEFAULT 0,
+    result JSONB,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_compute_jobs_organization_id ON compute_jobs(organization_id);
+CREATE INDEX idx_compute_jobs_status ON compute_jobs(status);
+
+CREATE TRIGGER update_compute_jobs_updated_at
+    BEFORE UPDATE ON compute_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
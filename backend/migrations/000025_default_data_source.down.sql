@@ -0,0 +1,2 @@
+-- Rollback default data source
+ALTER TABLE organizations DROP COLUMN IF EXISTS default_data_source_id;
@@ -0,0 +1,2 @@
+-- Default data source used when widget APIs omit dataSourceId
+ALTER TABLE organizations ADD COLUMN default_data_source_id UUID REFERENCES data_sources(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+-- Rollback measurement types
+DROP TABLE IF EXISTS measurement_types;
@@ -0,0 +1,14 @@
+-- Unit and semantic type per measurement name, used to format values by default
+CREATE TABLE measurement_types (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    measurement_name VARCHAR(128) NOT NULL,
+    semantic_type VARCHAR(20) NOT NULL,
+    unit VARCHAR(32),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (data_source_id, measurement_name)
+);
+
+CREATE TRIGGER update_measurement_types_updated_at
+    BEFORE UPDATE ON measurement_types
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
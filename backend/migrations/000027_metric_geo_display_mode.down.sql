@@ -0,0 +1,5 @@
+-- Rollback geo display mode
+UPDATE metrics SET display_mode = 'scalar' WHERE display_mode = 'geo';
+ALTER TABLE metrics DROP CONSTRAINT metrics_display_mode_check;
+ALTER TABLE metrics ADD CONSTRAINT metrics_display_mode_check
+    CHECK (display_mode IN ('scalar', 'time_series'));
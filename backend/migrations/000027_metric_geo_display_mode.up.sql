@@ -0,0 +1,4 @@
+-- Allow the geo display mode for map widgets
+ALTER TABLE metrics DROP CONSTRAINT metrics_display_mode_check;
+ALTER TABLE metrics ADD CONSTRAINT metrics_display_mode_check
+    CHECK (display_mode IN ('scalar', 'time_series', 'geo'));
@@ -0,0 +1,4 @@
+-- Allow the weekday-by-hour heatmap display mode
+ALTER TABLE metrics DROP CONSTRAINT metrics_display_mode_check;
+ALTER TABLE metrics ADD CONSTRAINT metrics_display_mode_check
+    CHECK (display_mode IN ('scalar', 'time_series', 'geo', 'heatmap'));
@@ -0,0 +1,2 @@
+-- Rollback metric stacking
+ALTER TABLE metrics DROP COLUMN IF EXISTS stacking;
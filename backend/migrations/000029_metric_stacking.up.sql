@@ -0,0 +1,3 @@
+-- Let split-by bar and area charts choose how their series are stacked
+ALTER TABLE metrics ADD COLUMN stacking VARCHAR(20)
+    CHECK (stacking IN ('stacked', 'grouped', 'percent_stacked'));
@@ -0,0 +1,2 @@
+-- Rollback metric share of
+ALTER TABLE metrics DROP COLUMN IF EXISTS share_of;
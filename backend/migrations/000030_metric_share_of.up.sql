@@ -0,0 +1,2 @@
+-- Let scalar metrics return their value as a share of a denominator
+ALTER TABLE metrics ADD COLUMN share_of JSONB;
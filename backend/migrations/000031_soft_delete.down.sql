@@ -0,0 +1,9 @@
+-- Rollback soft delete; anything still in the trash is deleted for good
+DELETE FROM metrics WHERE deleted_at IS NOT NULL;
+DELETE FROM dashboards WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_metrics_deleted_at;
+DROP INDEX IF EXISTS idx_dashboards_deleted_at;
+
+ALTER TABLE metrics DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE dashboards DROP COLUMN IF EXISTS deleted_at;
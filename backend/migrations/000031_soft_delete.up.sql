@@ -0,0 +1,6 @@
+-- Keep deleted dashboards and metrics in a trash until they are purged
+ALTER TABLE dashboards ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE metrics ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_dashboards_deleted_at ON dashboards(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_metrics_deleted_at ON metrics(deleted_at) WHERE deleted_at IS NOT NULL;
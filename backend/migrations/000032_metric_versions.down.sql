@@ -0,0 +1,2 @@
+-- Rollback metric versions
+DROP TABLE IF EXISTS metric_versions;
@@ -0,0 +1,10 @@
+-- Keep previous configurations of metrics so edits can be reverted
+CREATE TABLE metric_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    metric_id UUID NOT NULL REFERENCES metrics(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    config JSONB NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (metric_id, version)
+);
@@ -0,0 +1,10 @@
+-- Rollback weighted average aggregation
+UPDATE metrics SET aggregation = 'average', aggregation_key = NULL WHERE aggregation = 'weighted_average';
+
+ALTER TABLE metrics DROP CONSTRAINT check_aggregation_key_required;
+ALTER TABLE metrics ADD CONSTRAINT check_aggregation_key_required
+    CHECK (aggregation != 'count_unique' OR aggregation_key IS NOT NULL);
+
+ALTER TABLE metrics DROP CONSTRAINT metrics_aggregation_check;
+ALTER TABLE metrics ADD CONSTRAINT metrics_aggregation_check
+    CHECK (aggregation IN ('sum', 'average', 'count', 'count_unique'));
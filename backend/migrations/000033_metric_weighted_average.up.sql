@@ -0,0 +1,8 @@
+-- Allow weighted averages, whose aggregation_key names the metadata key holding each point's weight
+ALTER TABLE metrics DROP CONSTRAINT metrics_aggregation_check;
+ALTER TABLE metrics ADD CONSTRAINT metrics_aggregation_check
+    CHECK (aggregation IN ('sum', 'average', 'count', 'count_unique', 'weighted_average'));
+
+ALTER TABLE metrics DROP CONSTRAINT check_aggregation_key_required;
+ALTER TABLE metrics ADD CONSTRAINT check_aggregation_key_required
+    CHECK (aggregation NOT IN ('count_unique', 'weighted_average') OR aggregation_key IS NOT NULL);
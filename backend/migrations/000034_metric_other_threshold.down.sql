@@ -0,0 +1,2 @@
+-- Rollback metric other threshold
+ALTER TABLE metrics DROP COLUMN IF EXISTS other_threshold;
@@ -0,0 +1,3 @@
+-- Let split-by metrics merge series below a share of the total into "Other"
+ALTER TABLE metrics ADD COLUMN other_threshold DOUBLE PRECISION
+    CHECK (other_threshold > 0 AND other_threshold < 100);
@@ -0,0 +1,3 @@
+-- Rollback reports
+DROP TABLE IF EXISTS report_sections;
+DROP TABLE IF EXISTS reports;
@@ -0,0 +1,29 @@
+-- Reports: documents of ordered sections laid out from headings, KPI groups, charts and free text
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_reports_organization_id ON reports(organization_id);
+CREATE INDEX idx_reports_deleted_at ON reports(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE report_sections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    report_id UUID NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('heading', 'kpi_group', 'chart', 'text')),
+    title VARCHAR(255),
+    body TEXT,
+    metric_ids JSONB NOT NULL DEFAULT '[]',
+    columns INTEGER CHECK (columns BETWEEN 1 AND 4),
+    width VARCHAR(10) NOT NULL DEFAULT 'full' CHECK (width IN ('full', 'half')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_report_sections_report_id ON report_sections(report_id);
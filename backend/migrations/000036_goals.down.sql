@@ -0,0 +1,8 @@
+-- Rollback goals
+DELETE FROM report_sections WHERE type = 'goals';
+ALTER TABLE report_sections DROP CONSTRAINT report_sections_type_check;
+ALTER TABLE report_sections ADD CONSTRAINT report_sections_type_check
+    CHECK (type IN ('heading', 'kpi_group', 'chart', 'text'));
+ALTER TABLE report_sections DROP COLUMN IF EXISTS goal_ids;
+
+DROP TABLE IF EXISTS goals;
@@ -0,0 +1,26 @@
+-- Goals: targets for scalar metrics with a due date and an owner
+CREATE TABLE goals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    metric_id UUID NOT NULL REFERENCES metrics(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    target_value DOUBLE PRECISION NOT NULL,
+    start_value DOUBLE PRECISION,
+    due_date TIMESTAMPTZ NOT NULL,
+    owner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_goals_organization_id ON goals(organization_id);
+CREATE INDEX idx_goals_metric_id ON goals(metric_id);
+
+CREATE TRIGGER update_goals_updated_at
+    BEFORE UPDATE ON goals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Let reports list goals with their progress
+ALTER TABLE report_sections ADD COLUMN goal_ids JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE report_sections DROP CONSTRAINT report_sections_type_check;
+ALTER TABLE report_sections ADD CONSTRAINT report_sections_type_check
+    CHECK (type IN ('heading', 'kpi_group', 'chart', 'text', 'goals'));
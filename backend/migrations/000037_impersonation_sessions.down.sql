@@ -0,0 +1,2 @@
+-- Rollback impersonation sessions
+DROP TABLE IF EXISTS impersonation_sessions;
@@ -0,0 +1,2 @@
+-- Rollback organization domains
+DROP TABLE IF EXISTS organization_domains;
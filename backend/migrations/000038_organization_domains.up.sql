@@ -0,0 +1,14 @@
+-- Verified email domains whose new users join the organization automatically
+CREATE TABLE organization_domains (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    domain VARCHAR(253) NOT NULL,
+    default_role VARCHAR(20) NOT NULL DEFAULT 'viewer' CHECK (default_role IN ('editor', 'viewer')),
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, domain)
+);
+
+-- A domain can be verified by only one organization
+CREATE UNIQUE INDEX idx_organization_domains_verified_domain ON organization_domains(domain) WHERE verified_at IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Rollback product name and uploaded logo
+ALTER TABLE organization_branding DROP COLUMN IF EXISTS logo_content_type;
+ALTER TABLE organization_branding DROP COLUMN IF EXISTS logo_data;
+ALTER TABLE organization_branding DROP COLUMN IF EXISTS product_name;
@@ -0,0 +1,4 @@
+-- Product name and uploaded logo for organization branding
+ALTER TABLE organization_branding ADD COLUMN product_name VARCHAR(100);
+ALTER TABLE organization_branding ADD COLUMN logo_data BYTEA;
+ALTER TABLE organization_branding ADD COLUMN logo_content_type VARCHAR(50);
@@ -0,0 +1,10 @@
+-- Rollback measurement point spread
+DROP VIEW IF EXISTS measurement_points;
+CREATE VIEW measurement_points AS
+    SELECT data_source_id, name, timestamp, metadata, value, weight::BIGINT AS weight
+    FROM measurements
+    UNION ALL
+    SELECT data_source_id, name, day::timestamp AT TIME ZONE 'UTC', metadata, value_sum / value_count, value_count
+    FROM measurement_rollups;
+
+ALTER TABLE measurement_rollups DROP COLUMN IF EXISTS value_sum_sq;
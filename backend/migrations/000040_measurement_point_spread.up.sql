@@ -0,0 +1,14 @@
+-- Sum of squared values of each rollup, so the spread of downsampled days is kept
+ALTER TABLE measurement_rollups ADD COLUMN value_sum_sq DOUBLE PRECISION;
+
+-- Expose the minimum, maximum and weighted sum of squares of each point. A raw
+-- measurement is its own minimum and maximum; rollups from before value_sum_sq
+-- existed count as having no spread within their day.
+CREATE OR REPLACE VIEW measurement_points AS
+    SELECT data_source_id, name, timestamp, metadata, value, weight::BIGINT AS weight,
+           value AS value_min, value AS value_max, value * value * weight AS value_sum_sq
+    FROM measurements
+    UNION ALL
+    SELECT data_source_id, name, day::timestamp AT TIME ZONE 'UTC', metadata, value_sum / value_count, value_count,
+           value_min, value_max, COALESCE(value_sum_sq, value_sum * value_sum / value_count)
+    FROM measurement_rollups;
@@ -0,0 +1,5 @@
+-- Rollback metric orphaning
+DELETE FROM metrics WHERE data_source_id NOT IN (SELECT id FROM data_sources);
+ALTER TABLE metrics DROP COLUMN IF EXISTS orphaned_at;
+ALTER TABLE metrics ADD CONSTRAINT metrics_data_source_id_fkey
+    FOREIGN KEY (data_source_id) REFERENCES data_sources(id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- Metrics may outlive a deleted data source, flagged as orphaned, instead of
+-- being deleted along with it
+ALTER TABLE metrics DROP CONSTRAINT metrics_data_source_id_fkey;
+ALTER TABLE metrics ADD COLUMN orphaned_at TIMESTAMPTZ;
@@ -0,0 +1,14 @@
+-- Rollback relaxed uniqueness, keeping one measurement per name and timestamp
+DELETE FROM measurements m
+USING measurements other
+WHERE m.data_source_id = other.data_source_id
+  AND m.name = other.name
+  AND m.timestamp = other.timestamp
+  AND m.id > other.id;
+
+ALTER TABLE measurements DROP CONSTRAINT measurements_data_source_id_name_timestamp_dedupe_key_key;
+ALTER TABLE measurements ADD CONSTRAINT measurements_data_source_id_name_timestamp_key
+    UNIQUE (data_source_id, name, timestamp);
+ALTER TABLE measurements DROP COLUMN IF EXISTS dedupe_key;
+
+ALTER TABLE data_sources DROP COLUMN IF EXISTS relaxed_uniqueness;
@@ -0,0 +1,11 @@
+-- Data sources may opt out of (name, timestamp) uniqueness. Measurements are
+-- then only deduplicated by an optional client-supplied event ID.
+ALTER TABLE data_sources ADD COLUMN relaxed_uniqueness BOOLEAN NOT NULL DEFAULT false;
+
+-- '' for strict data sources, so (name, timestamp) stays unique. For relaxed
+-- ones the event ID, or NULL, which never conflicts.
+ALTER TABLE measurements ADD COLUMN dedupe_key VARCHAR(128) DEFAULT '';
+
+ALTER TABLE measurements DROP CONSTRAINT measurements_data_source_id_name_timestamp_key;
+ALTER TABLE measurements ADD CONSTRAINT measurements_data_source_id_name_timestamp_dedupe_key_key
+    UNIQUE (data_source_id, name, timestamp, dedupe_key);
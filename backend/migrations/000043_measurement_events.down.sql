@@ -0,0 +1,3 @@
+-- Rollback client-supplied event IDs
+DROP TABLE IF EXISTS measurement_events;
+ALTER TABLE measurements DROP COLUMN IF EXISTS event_id;
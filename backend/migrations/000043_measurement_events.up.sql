@@ -0,0 +1,14 @@
+-- Client-supplied event IDs, unique per data source. Measurements are
+-- partitioned by timestamp, so the uniqueness is kept in a table of its own.
+ALTER TABLE measurements ADD COLUMN event_id VARCHAR(128);
+
+CREATE TABLE measurement_events (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    event_id VARCHAR(128) NOT NULL,
+    measurement_id UUID NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (data_source_id, event_id)
+);
+
+CREATE INDEX idx_measurement_events_timestamp ON measurement_events(timestamp);
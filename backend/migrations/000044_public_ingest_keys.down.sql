@@ -0,0 +1,3 @@
+-- Rollback public ingest keys
+ALTER TABLE data_sources DROP COLUMN IF EXISTS allowed_origins;
+ALTER TABLE data_sources DROP COLUMN IF EXISTS public_key;
@@ -0,0 +1,4 @@
+-- Public keys for ingesting from browsers, restricted to an origin allowlist.
+-- Public keys are embedded in web pages, so they are stored in plain text.
+ALTER TABLE data_sources ADD COLUMN public_key VARCHAR(64) UNIQUE;
+ALTER TABLE data_sources ADD COLUMN allowed_origins TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+-- Rollback batch stream acknowledgment cursors
+DROP TABLE IF EXISTS ingest_streams;
@@ -0,0 +1,11 @@
+-- Acknowledgment cursors of client batch streams: the highest batch sequence
+-- stored per stream, so buffering clients can resume after a crash.
+CREATE TABLE ingest_streams (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    stream_id VARCHAR(128) NOT NULL,
+    accepted_through BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (data_source_id, stream_id)
+);
+
+CREATE INDEX idx_ingest_streams_updated_at ON ingest_streams(updated_at);
@@ -0,0 +1,8 @@
+-- Rollback external IDs
+DROP INDEX IF EXISTS idx_metrics_external_id;
+DROP INDEX IF EXISTS idx_dashboards_external_id;
+DROP INDEX IF EXISTS idx_data_sources_external_id;
+
+ALTER TABLE metrics DROP COLUMN IF EXISTS external_id;
+ALTER TABLE dashboards DROP COLUMN IF EXISTS external_id;
+ALTER TABLE data_sources DROP COLUMN IF EXISTS external_id;
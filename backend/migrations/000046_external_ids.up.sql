@@ -0,0 +1,9 @@
+-- Client-supplied external IDs, so API clients can create or update resources idempotently
+ALTER TABLE data_sources ADD COLUMN external_id VARCHAR(255);
+ALTER TABLE dashboards ADD COLUMN external_id VARCHAR(255);
+ALTER TABLE metrics ADD COLUMN external_id VARCHAR(255);
+
+-- Trashed dashboards and metrics release their external ID, so these need not exclude them
+CREATE UNIQUE INDEX idx_data_sources_external_id ON data_sources(organization_id, external_id) WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_dashboards_external_id ON dashboards(organization_id, external_id) WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_metrics_external_id ON metrics(dashboard_id, external_id) WHERE external_id IS NOT NULL;
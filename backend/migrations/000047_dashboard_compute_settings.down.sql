@@ -0,0 +1,3 @@
+-- Rollback dashboard compute settings
+ALTER TABLE dashboards DROP COLUMN IF EXISTS cache_ttl_seconds;
+ALTER TABLE dashboards DROP COLUMN IF EXISTS compute_concurrency;
@@ -0,0 +1,3 @@
+-- Per-dashboard compute parallelism and cache TTL; NULL computes one metric at a time without caching
+ALTER TABLE dashboards ADD COLUMN compute_concurrency INTEGER;
+ALTER TABLE dashboards ADD COLUMN cache_ttl_seconds INTEGER;
@@ -0,0 +1,2 @@
+-- Rollback metric trends
+DROP TABLE IF EXISTS metric_trends;
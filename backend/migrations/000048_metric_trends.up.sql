@@ -0,0 +1,12 @@
+-- Precomputed direction and magnitude of each scalar metric over its timeframe, for list responses.
+-- Direction and change are NULL when the metric has no value to compare.
+CREATE TABLE metric_trends (
+    metric_id UUID PRIMARY KEY REFERENCES metrics(id) ON DELETE CASCADE,
+    metric_updated_at TIMESTAMPTZ NOT NULL,
+    direction VARCHAR(8),
+    change DOUBLE PRECISION,
+    change_percent DOUBLE PRECISION,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_metric_trends_computed_at ON metric_trends(computed_at);
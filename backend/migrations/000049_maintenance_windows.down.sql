@@ -0,0 +1,2 @@
+-- Rollback maintenance windows
+DROP TABLE IF EXISTS maintenance_windows;
@@ -0,0 +1,2 @@
+-- Rollback Slack connections
+DROP TABLE IF EXISTS slack_connections;
@@ -0,0 +1,11 @@
+-- Slack workspaces connected to organizations, for the /kpi command and link unfurling
+CREATE TABLE slack_connections (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    team_id VARCHAR(32) NOT NULL,
+    team_name VARCHAR(255) NOT NULL DEFAULT '',
+    bot_token TEXT NOT NULL,
+    connected_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_slack_connections_team ON slack_connections(team_id);
@@ -0,0 +1,2 @@
+-- Rollback measurement names
+DROP TABLE IF EXISTS measurement_names;
@@ -0,0 +1,15 @@
+-- When each measurement name was first ingested per data source, so new names can be polled for
+CREATE TABLE measurement_names (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (data_source_id, name)
+);
+
+CREATE INDEX idx_measurement_names_first_seen ON measurement_names(data_source_id, first_seen_at DESC);
+
+-- Names ingested before the registry existed count as first seen at their oldest point
+INSERT INTO measurement_names (data_source_id, name, first_seen_at)
+SELECT data_source_id, name, MIN(timestamp)
+FROM measurement_points
+GROUP BY data_source_id, name;
@@ -0,0 +1,2 @@
+-- Rollback status pages
+DROP TABLE IF EXISTS status_pages;
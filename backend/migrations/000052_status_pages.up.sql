@@ -0,0 +1,14 @@
+-- Public status pages showing the health of selected dashboard metrics
+CREATE TABLE status_pages (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    dashboard_id UUID NOT NULL REFERENCES dashboards(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    components JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_status_pages_dashboard ON status_pages(dashboard_id);
+CREATE UNIQUE INDEX idx_status_pages_token ON status_pages(token);
@@ -0,0 +1,2 @@
+-- Rollback write audit
+DROP TABLE IF EXISTS write_audit;
@@ -0,0 +1,16 @@
+-- Write audit: who created, changed or deleted an organization's entities,
+-- with the fields that changed. Separate from the instance audit log of
+-- operator actions.
+CREATE TABLE write_audit (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    impersonation_id UUID,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_write_audit_entity ON write_audit(organization_id, entity_type, entity_id, created_at DESC);
@@ -0,0 +1,2 @@
+-- Rollback weekly data quality reports
+DROP TABLE IF EXISTS data_quality_reports;
@@ -0,0 +1,9 @@
+-- Weekly data quality reports per organization, one per analyzed week
+CREATE TABLE data_quality_reports (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    period DATE NOT NULL,
+    data_sources JSONB, -- NULL while the report is being generated
+    generated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, period)
+);
@@ -0,0 +1,4 @@
+-- Rollback ingest watermarks
+ALTER TABLE data_sources DROP COLUMN IF EXISTS backfilled_at;
+ALTER TABLE data_sources DROP COLUMN IF EXISTS last_received_at;
+ALTER TABLE data_sources DROP COLUMN IF EXISTS max_timestamp;
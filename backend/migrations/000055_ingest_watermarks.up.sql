@@ -0,0 +1,5 @@
+-- Track how far each data source's data is complete, and when late data last
+-- landed in buckets that were already reported
+ALTER TABLE data_sources ADD COLUMN max_timestamp TIMESTAMPTZ;
+ALTER TABLE data_sources ADD COLUMN last_received_at TIMESTAMPTZ;
+ALTER TABLE data_sources ADD COLUMN backfilled_at TIMESTAMPTZ;
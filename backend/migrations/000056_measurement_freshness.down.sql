@@ -0,0 +1,2 @@
+-- Rollback measurement freshness SLAs
+DROP TABLE IF EXISTS measurement_freshness;
@@ -0,0 +1,13 @@
+-- Per-measurement freshness SLAs: how often new data is expected to arrive
+CREATE TABLE measurement_freshness (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    measurement_name VARCHAR(128) NOT NULL,
+    expected_frequency VARCHAR(16) NOT NULL CHECK (expected_frequency IN ('hourly', 'daily', 'weekly')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (data_source_id, measurement_name)
+);
+
+CREATE TRIGGER update_measurement_freshness_updated_at
+    BEFORE UPDATE ON measurement_freshness
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Rollback metric composite series
+ALTER TABLE metrics DROP COLUMN IF EXISTS composite_series;
@@ -0,0 +1,2 @@
+-- Let time series metrics chart one series per measurement and filters
+ALTER TABLE metrics ADD COLUMN composite_series JSONB;
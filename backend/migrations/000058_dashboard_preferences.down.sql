@@ -0,0 +1,2 @@
+-- Rollback personal dashboard preferences
+DROP TABLE IF EXISTS dashboard_preferences;
@@ -0,0 +1,17 @@
+-- Personal dashboard layout overrides, merged into the dashboard when its owner reads it
+CREATE TABLE dashboard_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dashboard_id UUID NOT NULL REFERENCES dashboards(id) ON DELETE CASCADE,
+    collapsed_sections TEXT[] NOT NULL DEFAULT '{}',
+    hidden_metric_ids UUID[] NOT NULL DEFAULT '{}',
+    timeframe VARCHAR(20),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, dashboard_id)
+);
+
+CREATE INDEX idx_dashboard_preferences_dashboard_id ON dashboard_preferences(dashboard_id);
+
+CREATE TRIGGER update_dashboard_preferences_updated_at
+    BEFORE UPDATE ON dashboard_preferences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Rollback dashboard comments
+DROP TABLE IF EXISTS dashboard_comments;
@@ -0,0 +1,20 @@
+-- Discussion threads on dashboards and their metrics
+CREATE TABLE dashboard_comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    dashboard_id UUID NOT NULL REFERENCES dashboards(id) ON DELETE CASCADE,
+    metric_id UUID REFERENCES metrics(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES dashboard_comments(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    mentioned_user_ids UUID[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_dashboard_comments_dashboard_id ON dashboard_comments(dashboard_id, created_at);
+CREATE INDEX idx_dashboard_comments_parent_id ON dashboard_comments(parent_id);
+CREATE INDEX idx_dashboard_comments_metric_id ON dashboard_comments(metric_id);
+
+CREATE TRIGGER update_dashboard_comments_updated_at
+    BEFORE UPDATE ON dashboard_comments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Rollback archived measurement names
+DROP TABLE IF EXISTS archived_measurements;
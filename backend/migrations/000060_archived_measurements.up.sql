@@ -0,0 +1,7 @@
+-- Measurement names hidden from pickers; their data is kept
+CREATE TABLE archived_measurements (
+    data_source_id UUID NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    measurement_name VARCHAR(128) NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (data_source_id, measurement_name)
+);
@@ -0,0 +1,2 @@
+-- Rollback organization metric defaults
+DROP TABLE IF EXISTS metric_defaults;
@@ -0,0 +1,14 @@
+-- Organization defaults for new metrics, applied to fields a create request omits
+CREATE TABLE metric_defaults (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    timeframe VARCHAR(20),
+    granularity VARCHAR(20),
+    chart_type VARCHAR(20),
+    comparison_enabled BOOLEAN,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_metric_defaults_updated_at
+    BEFORE UPDATE ON metric_defaults
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Rollback fiscal year start month
+ALTER TABLE organizations DROP COLUMN IF EXISTS fiscal_year_start_month;
@@ -0,0 +1,3 @@
+-- Month the fiscal year of an organization starts in, for fiscal timeframes
+ALTER TABLE organizations ADD COLUMN fiscal_year_start_month SMALLINT NOT NULL DEFAULT 1
+    CHECK (fiscal_year_start_month BETWEEN 1 AND 12);
@@ -0,0 +1,2 @@
+-- Rollback week start
+ALTER TABLE organizations DROP COLUMN IF EXISTS week_start;
@@ -0,0 +1,3 @@
+-- Day weekly buckets of an organization start on: monday (ISO weeks) or sunday (US weeks)
+ALTER TABLE organizations ADD COLUMN week_start VARCHAR(10) NOT NULL DEFAULT 'monday'
+    CHECK (week_start IN ('monday', 'sunday'));
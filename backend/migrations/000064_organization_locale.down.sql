@@ -0,0 +1,2 @@
+-- Rollback organization locale
+ALTER TABLE organizations DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,2 @@
+-- Locale of an organization, for number and date formatting hints
+ALTER TABLE organizations ADD COLUMN locale VARCHAR(10) NOT NULL DEFAULT 'en-US';
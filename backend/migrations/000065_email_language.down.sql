@@ -0,0 +1,3 @@
+-- Rollback email languages
+ALTER TABLE organization_branding DROP COLUMN IF EXISTS email_language;
+ALTER TABLE users DROP COLUMN IF EXISTS language;
@@ -0,0 +1,3 @@
+-- Language of transactional emails per user and organization
+ALTER TABLE users ADD COLUMN language VARCHAR(10);
+ALTER TABLE organization_branding ADD COLUMN email_language VARCHAR(10);
@@ -0,0 +1,2 @@
+-- Rollback notification deliveries
+DROP TABLE IF EXISTS notification_deliveries;
@@ -0,0 +1,2 @@
+-- Rollback scheduled jobs
+DROP TABLE IF EXISTS scheduled_jobs;
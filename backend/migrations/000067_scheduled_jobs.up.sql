@@ -0,0 +1,14 @@
+-- Schedules of background jobs, shared by all replicas. The replica holding
+-- a job's lease runs it; the outcome of its last run is kept for the admin API
+CREATE TABLE scheduled_jobs (
+    name VARCHAR(100) PRIMARY KEY,
+    next_run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_by VARCHAR(255),
+    locked_until TIMESTAMPTZ,
+    last_started_at TIMESTAMPTZ,
+    last_finished_at TIMESTAMPTZ,
+    last_succeeded_at TIMESTAMPTZ,
+    last_duration_ms BIGINT,
+    last_error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0
+);
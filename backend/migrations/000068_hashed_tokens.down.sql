@@ -0,0 +1,5 @@
+-- Rollback hashed tokens. Hashes cannot be reversed, so outstanding tokens
+-- are dropped and pending invites expired; they have to be sent again
+DELETE FROM email_verification_tokens;
+DELETE FROM password_reset_tokens;
+UPDATE invites SET expires_at = LEAST(expires_at, NOW()) WHERE accepted_at IS NULL;
@@ -0,0 +1,6 @@
+-- Store verification, password reset and invite tokens as SHA-256 hashes, so
+-- that a leaked database or a slow lookup reveals nothing about valid tokens.
+-- Tokens already sent out keep working, as they are hashed the same way
+UPDATE email_verification_tokens SET token = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+UPDATE password_reset_tokens SET token = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+UPDATE invites SET token = encode(sha256(convert_to(token, 'UTF8')), 'hex');
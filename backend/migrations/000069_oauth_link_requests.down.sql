@@ -0,0 +1,2 @@
+-- Rollback OAuth link requests
+DROP TABLE IF EXISTS oauth_link_requests;
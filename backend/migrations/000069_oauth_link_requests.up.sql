@@ -0,0 +1,17 @@
+-- Requests to link an OAuth identity to the existing account with its email.
+-- The identity is linked only once the account owner confirms: with their
+-- password, through a link emailed to them, or while logged in
+CREATE TABLE oauth_link_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    oauth_account_id UUID NOT NULL REFERENCES oauth_accounts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL,  -- Hash of the token given to the browser that signed in
+    email_token VARCHAR(64),     -- Hash of the token emailed to the account owner
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_oauth_link_requests_account ON oauth_link_requests(oauth_account_id);
+CREATE UNIQUE INDEX idx_oauth_link_requests_token ON oauth_link_requests(token);
+CREATE UNIQUE INDEX idx_oauth_link_requests_email_token ON oauth_link_requests(email_token);
+CREATE INDEX idx_oauth_link_requests_user ON oauth_link_requests(user_id);
@@ -0,0 +1,2 @@
+-- Rollback storage estimates
+DROP TABLE IF EXISTS storage_estimates;
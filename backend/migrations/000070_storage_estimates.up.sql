@@ -0,0 +1,7 @@
+-- Estimated size of each organization's measurements, refreshed by a daily
+-- job so that reading usage does not scan the measurements
+CREATE TABLE storage_estimates (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    bytes BIGINT NOT NULL,
+    estimated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,24 @@
+-- Drops monthly partitions that end on or before cutoff, returning their names.
+-- Rows before cutoff in the default partition are deleted too, since no
+-- monthly partition covers them.
+CREATE OR REPLACE FUNCTION drop_measurements_partitions_before(cutoff DATE)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    part RECORD;
+BEGIN
+    FOR part IN
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        JOIN pg_class p ON p.oid = i.inhparent
+        WHERE p.relname = 'measurements'
+          AND c.relname ~ '^measurements_[0-9]{4}_[0-9]{2}$'
+          AND (to_date(substring(c.relname from 14), 'YYYY_MM') + INTERVAL '1 month')::date <= cutoff
+    LOOP
+        EXECUTE format('DROP TABLE %I', part.relname);
+        RETURN NEXT part.relname;
+    END LOOP;
+
+    DELETE FROM measurements_default WHERE timestamp < cutoff;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations so the server knows which
+// schema version it expects, independent of the files shipped next to it.
+package migrations
+
+import "embed"
+
+// FS holds the migration files, named <version>_<name>.<up|down>.sql.
+//
+//go:embed *.sql
+var FS embed.FS
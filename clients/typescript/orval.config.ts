@@ -0,0 +1,23 @@
+import { defineConfig } from 'orval'
+
+// The spec path is passed in by `make clients`, which builds from a copy of
+// this directory
+const spec = process.env.LITEKPI_SPEC ?? '../../backend/docs/swagger.json'
+
+export default defineConfig({
+  litekpi: {
+    input: spec,
+    output: {
+      mode: 'single',
+      target: './src/api.ts',
+      schemas: './src/models',
+      client: 'axios-functions',
+      override: {
+        mutator: {
+          path: './src/client.ts',
+          name: 'customInstance',
+        },
+      },
+    },
+  },
+})
@@ -0,0 +1,106 @@
+// Request function the generated API calls go through. Call configure once
+// before making requests.
+
+export interface ClientConfig {
+  // Base URL of the API, e.g. https://kpi.example.com/api/v1
+  baseUrl: string
+  // JWT sent as a bearer token, or a function returning the current one
+  token?: string | (() => string | undefined)
+  // API key of a data source, for ingest endpoints
+  apiKey?: string
+  fetch?: typeof fetch
+}
+
+let config: ClientConfig = { baseUrl: '/api/v1' }
+
+export function configure(next: ClientConfig): void {
+  config = next
+}
+
+export class ApiError extends Error {
+  constructor(
+    public status: number,
+    message: string,
+    public data?: unknown
+  ) {
+    super(message)
+    this.name = 'ApiError'
+  }
+}
+
+export type CustomInstanceConfig = {
+  url: string
+  method: 'GET' | 'POST' | 'PUT' | 'DELETE' | 'PATCH'
+  params?: Record<string, unknown>
+  data?: unknown
+  headers?: Record<string, string>
+  signal?: AbortSignal
+}
+
+export const customInstance = async <T>({
+  url,
+  method,
+  params,
+  data,
+  headers,
+  signal,
+}: CustomInstanceConfig): Promise<T> => {
+  // Build URL with query params, skipping unset ones
+  let fullUrl = `${config.baseUrl.replace(/\/$/, '')}${url}`
+  if (params) {
+    const searchParams = new URLSearchParams()
+    for (const [key, value] of Object.entries(params)) {
+      if (value !== undefined && value !== null) {
+        searchParams.set(key, String(value))
+      }
+    }
+    const query = searchParams.toString()
+    if (query) {
+      fullUrl += `?${query}`
+    }
+  }
+
+  // Set headers
+  const requestHeaders = new Headers(headers)
+  if (!requestHeaders.has('Content-Type') && data) {
+    requestHeaders.set('Content-Type', 'application/json')
+  }
+  const token = typeof config.token === 'function' ? config.token() : config.token
+  if (token) {
+    requestHeaders.set('Authorization', `Bearer ${token}`)
+  }
+  if (config.apiKey) {
+    requestHeaders.set('X-API-Key', config.apiKey)
+  }
+
+  const response = await (config.fetch ?? fetch)(fullUrl, {
+    method,
+    headers: requestHeaders,
+    body: data ? JSON.stringify(data) : undefined,
+    signal,
+  })
+
+  // Handle non-OK responses
+  if (!response.ok) {
+    let errorData: unknown
+    try {
+      errorData = await response.json()
+    } catch {
+      errorData = undefined
+    }
+    throw new ApiError(
+      response.status,
+      `HTTP ${response.status}: ${response.statusText}`,
+      errorData
+    )
+  }
+
+  // Handle empty responses
+  if (response.status === 204) {
+    return undefined as T
+  }
+
+  return response.json()
+}
+
+export default customInstance
@@ -0,0 +1,4 @@
+export * from './api'
+export * from './models'
+export { configure, ApiError } from './client'
+export type { ClientConfig } from './client'